@@ -3,6 +3,7 @@ package api
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
 	"github.com/kwalter26/udemy-simplebank/token"
@@ -12,6 +13,8 @@ import (
 
 type createAccountRequest struct {
 	Currency string `json:"currency" binding:"required,currency"`
+	// Product defaults to a checking account
+	Product string `json:"product"`
 }
 
 func (s *Server) createAccount(context *gin.Context) {
@@ -21,13 +24,30 @@ func (s *Server) createAccount(context *gin.Context) {
 		return
 	}
 
+	product := db.ProductChecking
+	if req.Product != "" {
+		accountProduct, err := s.store.GetAccountProduct(context, req.Product)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			context.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if err != nil || accountProduct.Internal {
+			context.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("unknown account product %q", req.Product)))
+			return
+		}
+		product = accountProduct.Code
+	}
+
 	authPayload := context.MustGet(authorizationPayloadKey).(*token.Payload)
-	arg := db.CreateAccountParams{
-		Owner:    authPayload.Username,
-		Currency: req.Currency,
+	arg := db.CreateAccountTxParams{
+		CreateAccountParams: db.CreateAccountParams{
+			Owner:    authPayload.Username,
+			Currency: req.Currency,
+			Product:  product,
+		},
 	}
 
-	account, err := s.store.CreateAccount(context, arg)
+	result, err := s.store.CreateAccountTx(context, arg)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
@@ -40,7 +60,7 @@ func (s *Server) createAccount(context *gin.Context) {
 		return
 	}
 
-	context.JSON(http.StatusOK, account)
+	context.JSON(http.StatusOK, result.Account)
 }
 
 type getAccountRequest struct {
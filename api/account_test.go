@@ -148,6 +148,7 @@ func randomAccount(owner string) db.Account {
 		Owner:    owner,
 		Balance:  util.RandomBalance(),
 		Currency: util.RandomCurrency(),
+		Product:  db.ProductChecking,
 	}
 }
 
@@ -173,22 +174,101 @@ func TestCreateAccount(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.CreateAccountParams{
-					Owner:    account.Owner,
-					Balance:  account.Balance,
-					Currency: account.Currency,
+				arg := db.CreateAccountTxParams{
+					CreateAccountParams: db.CreateAccountParams{
+						Owner:    account.Owner,
+						Balance:  account.Balance,
+						Currency: account.Currency,
+						Product:  db.ProductChecking,
+					},
 				}
 
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(arg)).
+					CreateAccountTx(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
-					Return(account, nil)
+					Return(db.CreateAccountTxResult{Account: account}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 				requireBodyMatchAccount(t, recorder.Body, account)
 			},
 		},
+		{
+			name: "Savings",
+			body: createAccountRequest{
+				Currency: account.Currency,
+				Product:  db.ProductSavings,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountProduct(gomock.Any(), gomock.Eq(db.ProductSavings)).
+					Times(1).
+					Return(db.AccountProduct{Code: db.ProductSavings, AnnualRateBps: 250, Compounding: "daily"}, nil)
+				arg := db.CreateAccountTxParams{
+					CreateAccountParams: db.CreateAccountParams{
+						Owner:    account.Owner,
+						Balance:  account.Balance,
+						Currency: account.Currency,
+						Product:  db.ProductSavings,
+					},
+				}
+
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.CreateAccountTxResult{Account: account}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "BadRequest (InternalProduct)",
+			body: createAccountRequest{
+				Currency: account.Currency,
+				Product:  db.ProductInterestExpense,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountProduct(gomock.Any(), gomock.Eq(db.ProductInterestExpense)).
+					Times(1).
+					Return(db.AccountProduct{Code: db.ProductInterestExpense, Internal: true}, nil)
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "BadRequest (UnknownProduct)",
+			body: createAccountRequest{
+				Currency: account.Currency,
+				Product:  "platinum",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountProduct(gomock.Any(), gomock.Eq("platinum")).
+					Times(1).
+					Return(db.AccountProduct{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name: "InternalError",
 			body: createAccountRequest{
@@ -198,16 +278,19 @@ func TestCreateAccount(t *testing.T) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.CreateAccountParams{
-					Owner:    account.Owner,
-					Balance:  account.Balance,
-					Currency: account.Currency,
+				arg := db.CreateAccountTxParams{
+					CreateAccountParams: db.CreateAccountParams{
+						Owner:    account.Owner,
+						Balance:  account.Balance,
+						Currency: account.Currency,
+						Product:  db.ProductChecking,
+					},
 				}
 
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(arg)).
+					CreateAccountTx(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
-					Return(db.Account{}, sql.ErrConnDone)
+					Return(db.CreateAccountTxResult{}, sql.ErrConnDone)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -223,7 +306,7 @@ func TestCreateAccount(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -241,9 +324,9 @@ func TestCreateAccount(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.Account{}, &pg.Error{Code: "23505"})
+					Return(db.CreateAccountTxResult{}, &pg.Error{Code: "23505"})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusForbidden, recorder.Code)
@@ -260,9 +343,9 @@ func TestCreateAccount(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.Account{}, &pg.Error{Code: "23503"})
+					Return(db.CreateAccountTxResult{}, &pg.Error{Code: "23503"})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusForbidden, recorder.Code)
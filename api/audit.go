@@ -0,0 +1,15 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+)
+
+// auditContext identifies the actor of an audited action and the client it came from.
+func auditContext(context *gin.Context, actor string) db.AuditContext {
+	return db.AuditContext{
+		Actor:     actor,
+		ClientIp:  context.ClientIP(),
+		UserAgent: context.Request.UserAgent(),
+	}
+}
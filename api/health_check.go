@@ -10,19 +10,16 @@ type healthCheckResponse struct {
 	Status string `json:"status"`
 }
 
+// getHealthz reports that the process is running without looking at its dependencies
 func (s *Server) getHealthz(context *gin.Context) {
-	err := s.store.Ping(context)
-	if err != nil {
-		context.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
-	}
 	context.JSON(http.StatusOK, healthCheckResponse{Status: "Ok"})
 }
 
+// getReadyz reports whether the database can be reached
 func (s *Server) getReadyz(context *gin.Context) {
 	err := s.store.Ping(context)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, errorResponse(err))
+		context.JSON(http.StatusServiceUnavailable, errorResponse(err))
 		return
 	}
 	context.JSON(http.StatusOK, healthCheckResponse{Status: "Ok"})
@@ -22,7 +22,7 @@ func TestGetHealthAndReady(t *testing.T) {
 			name: "OK",
 			url:  "/healthz",
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().Ping(gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -30,14 +30,14 @@ func TestGetHealthAndReady(t *testing.T) {
 			},
 		},
 		{
-			name: "DB Error",
+			name: "LivenessIgnoresDB",
 			url:  "/healthz",
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().Ping(gomock.Any()).Times(1).Return(errors.New("db error"))
+				store.EXPECT().Ping(gomock.Any()).Times(0).Return(errors.New("db error"))
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
-				require.Equal(t, `{"error":"db error"}`, recorder.Body.String())
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, `{"status":"Ok"}`, recorder.Body.String())
 			},
 		},
 		{
@@ -58,7 +58,7 @@ func TestGetHealthAndReady(t *testing.T) {
 				store.EXPECT().Ping(gomock.Any()).Times(1).Return(errors.New("db error"))
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
 				require.Equal(t, `{"error":"db error"}`, recorder.Body.String())
 			},
 		},
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/metrics"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	lockoutKindUsername = "username"
+	lockoutKindClientIp = "client_ip"
+)
+
+// errInvalidCredentials is returned for both unknown usernames and wrong passwords so that callers cannot tell
+// which one it was.
+var errInvalidCredentials = errors.New("invalid username or password")
+
+type loginLockoutKey struct {
+	kind   string
+	key    string
+	policy util.LockoutPolicy
+}
+
+// loginLockoutKeys returns the keys failed logins are tracked under: the username and, if known, the client IP.
+func (s *Server) loginLockoutKeys(context *gin.Context, username string) []loginLockoutKey {
+	keys := []loginLockoutKey{
+		{kind: lockoutKindUsername, key: username, policy: s.config.UsernameLockoutPolicy()},
+	}
+	if clientIp := context.ClientIP(); clientIp != "" {
+		keys = append(keys, loginLockoutKey{kind: lockoutKindClientIp, key: clientIp, policy: s.config.ClientIpLockoutPolicy()})
+	}
+	return keys
+}
+
+// checkLoginLockout writes a 429 response and returns false if any of the keys is currently locked.
+func (s *Server) checkLoginLockout(context *gin.Context, keys []loginLockoutKey) bool {
+	for _, k := range keys {
+		lockout, err := s.store.GetLoginLockout(context, db.GetLoginLockoutParams{Kind: k.kind, Key: k.key})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			context.JSON(http.StatusInternalServerError, errorResponse(err))
+			return false
+		}
+		if retryAfter := time.Until(lockout.LockedUntil); retryAfter > 0 {
+			metrics.LoginFailures.WithLabelValues(metrics.LoginFailureLockedOut).Inc()
+			retryAfter = retryAfter.Truncate(time.Second) + time.Second
+			context.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+			err = fmt.Errorf("too many failed login attempts, retry in %s", retryAfter)
+			context.JSON(http.StatusTooManyRequests, errorResponse(err))
+			return false
+		}
+	}
+	return true
+}
+
+// loginFailed records the failed attempt against every key and writes the same response for unknown users and
+// wrong passwords.
+func (s *Server) loginFailed(context *gin.Context, username string, keys []loginLockoutKey) {
+	metrics.LoginFailures.WithLabelValues(metrics.LoginFailureInvalidCredentials).Inc()
+	for _, k := range keys {
+		lockout, err := s.store.RecordLoginFailure(context, db.RecordLoginFailureParams{
+			Kind:        k.kind,
+			Key:         k.key,
+			ResetBefore: k.policy.ResetBefore(time.Now()),
+		})
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		backoff := k.policy.Backoff(lockout.FailedCount)
+		if backoff == 0 {
+			continue
+		}
+
+		_, err = s.store.LockLogin(context, db.LockLoginParams{
+			Kind:        k.kind,
+			Key:         k.key,
+			LockedUntil: time.Now().Add(backoff),
+		})
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	arg, err := db.NewAuditEventParams(auditContext(context, username), db.AuditActionLoginFailed, db.AuditTarget("user", username), nil, nil)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if _, err = s.store.CreateAuditEvent(context, arg); err != nil {
+		context.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	context.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+}
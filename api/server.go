@@ -8,16 +8,16 @@ import (
 	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
 	"github.com/kwalter26/udemy-simplebank/token"
 	"github.com/kwalter26/udemy-simplebank/util"
-	"github.com/newrelic/go-agent/v3/integrations/nrgin"
-	"github.com/newrelic/go-agent/v3/newrelic"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Server struct {
-	config     util.Config
-	store      db.Store
-	tokenMaker token.Maker
-	router     *gin.Engine
-	app        *newrelic.Application
+	config         util.Config
+	store          db.Store
+	tokenMaker     token.Maker
+	passwordHasher util.PasswordHasher
+	dummyPassword  *util.DummyPassword
+	router         *gin.Engine
 }
 
 func NewServer(config util.Config, store db.Store) (*Server, error) {
@@ -26,19 +26,17 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 		return nil, fmt.Errorf("cannot create token maketer: %w", err)
 	}
 
-	server := &Server{store: store, tokenMaker: maker, config: config}
+	hasher, err := util.NewPasswordHasher(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create password hasher: %w", err)
+	}
 
-	if config.NewRelicAppEnabled {
-		app, err := newrelic.NewApplication(
-			newrelic.ConfigAppName(config.NewRelicAppName),
-			newrelic.ConfigLicense(config.NewRelicLicenseKey),
-			newrelic.ConfigAppLogForwardingEnabled(config.NewRelicLogForwardingEnabled),
-			newrelic.ConfigDistributedTracerEnabled(config.NewRelicDistributedTracingEnabled),
-		)
-		if err != nil {
-			return nil, fmt.Errorf("cannot create new relic app: %w", err)
-		}
-		server.app = app
+	server := &Server{
+		store:          store,
+		tokenMaker:     maker,
+		config:         config,
+		passwordHasher: hasher,
+		dummyPassword:  util.NewDummyPassword(hasher),
 	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
@@ -48,14 +46,22 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 		}
 	}
 
-	server.setupRouter()
+	err = server.setupRouter()
+	if err != nil {
+		return nil, err
+	}
 	return server, nil
 }
 
-func (s *Server) setupRouter() {
+func (s *Server) setupRouter() error {
 	router := gin.Default()
 
-	router.Use(nrgin.Middleware(s.app))
+	// gin believes the X-Forwarded-For header of any client by default
+	if err := router.SetTrustedProxies(s.config.TrustedProxyList()); err != nil {
+		return fmt.Errorf("cannot set trusted proxies: %w", err)
+	}
+
+	router.Use(otelgin.Middleware("simplebank-gin"))
 	router.POST("/users", s.CreateUser)
 	router.POST("/users/login", s.loginUser)
 	router.POST("/token/renew_access", s.renewAccessToken)
@@ -73,6 +79,7 @@ func (s *Server) setupRouter() {
 	authRoutes.POST("/transfers", s.createTransfer)
 
 	s.router = router
+	return nil
 }
 
 func (s *Server) Start(address string) error {
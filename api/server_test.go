@@ -4,45 +4,36 @@ import (
 	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
 	"github.com/kwalter26/udemy-simplebank/util"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 )
 
-func TestNewServerWithNewRelic(t *testing.T) {
-	store := mockdb.NewMockStore(nil)
-	config := util.Config{
-		TokenSymmetricKey:   util.RandomString(32),
-		AccessTokenDuration: time.Minute,
-		NewRelicAppEnabled:  true,
-		NewRelicLicenseKey:  "1234567890123456789012345678901234567890",
-		NewRelicAppName:     "test",
-	}
-
-	_, err := NewServer(config, store)
-	require.NoError(t, err)
-}
+func TestNewServerTracesRequests(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
 
-func TestNewServerWithNewRelicEmptyLicense(t *testing.T) {
 	store := mockdb.NewMockStore(nil)
 	config := util.Config{
 		TokenSymmetricKey:   util.RandomString(32),
 		AccessTokenDuration: time.Minute,
-		NewRelicAppEnabled:  true,
 	}
 
-	_, err := NewServer(config, store)
-	require.Error(t, err)
-}
+	server, err := NewServer(config, store)
+	require.NoError(t, err)
 
-func TestNewServerWithNewRelicEmptyName(t *testing.T) {
-	store := mockdb.NewMockStore(nil)
-	config := util.Config{
-		TokenSymmetricKey:   util.RandomString(32),
-		AccessTokenDuration: time.Minute,
-		NewRelicAppEnabled:  true,
-		NewRelicLicenseKey:  "1234567890123456789012345678901234567890",
-	}
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/accounts", nil))
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
 
-	_, err := NewServer(config, store)
-	require.Error(t, err)
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "/accounts", spans[0].Name)
 }
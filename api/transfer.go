@@ -2,26 +2,50 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/metrics"
 	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
 	"net/http"
 )
 
+// createTransferRequest pays either an account, a saved payee, or a person by username or verified email into their
+// account in the currency.
 type createTransferRequest struct {
 	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"omitempty,min=1"`
+	PayeeID       int64  `json:"payee_id" binding:"omitempty,min=1"`
+	ToUsername    string `json:"to_username"`
+	ToEmail       string `json:"to_email" binding:"omitempty,email"`
 	Amount        int64  `json:"amount" binding:"required,gt=0"`
 	Currency      string `json:"currency" binding:"required,currency"`
 }
 
+// personTransferResponse is the response of a transfer to a payee or a person, which leaves out the account of the
+// recipient and only names them by the nickname of the payee or their masked full name.
+type personTransferResponse struct {
+	Transfer    db.Transfer `json:"transfer"`
+	FromAccount db.Account  `json:"from_account"`
+	FromEntry   db.Entry    `json:"from_entry"`
+	FeeEntry    *db.Entry   `json:"fee_entry,omitempty"`
+	Recipient   string      `json:"recipient"`
+}
+
+var errTransferRecipient = errors.New("exactly one of to_account_id, payee_id, to_username and to_email is required")
+
 func (s *Server) createTransfer(context *gin.Context) {
 	var req createTransferRequest
 	if err := context.ShouldBindJSON(&req); err != nil {
 		context.JSON(400, errorResponse(err))
 		return
 	}
+	if countRecipients(req) != 1 {
+		context.JSON(http.StatusBadRequest, errorResponse(errTransferRecipient))
+		return
+	}
 
 	fromAccount, valid := s.validAccountCurrency(context, req.FromAccountID, req.Currency)
 	if !valid {
@@ -35,26 +59,129 @@ func (s *Server) createTransfer(context *gin.Context) {
 		return
 	}
 
-	_, valid = s.validAccountCurrency(context, req.ToAccountID, req.Currency)
+	var recipientName string
+	switch {
+	case req.ToAccountID != 0:
+		_, valid = s.validAccountCurrency(context, req.ToAccountID, req.Currency)
+	case req.PayeeID != 0:
+		var payee db.Payee
+		payee, valid = s.validPayee(context, req, authPayload.Username)
+		req.ToAccountID = payee.AccountID
+		recipientName = payee.Nickname
+	default:
+		var recipient *db.Recipient
+		recipient, valid = s.resolveRecipient(context, req)
+		if valid {
+			req.ToAccountID = recipient.Account.ID
+			recipientName = util.MaskName(recipient.User.FullName)
+		}
+	}
 	if !valid {
 		return
 	}
+	if req.ToAccountID == req.FromAccountID {
+		context.JSON(http.StatusBadRequest, errorResponse(db.ErrSameAccount))
+		return
+	}
 
 	arg := db.TransferTxParams{
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
 		Amount:        req.Amount,
+		CoolingOff:    db.CoolingOff{Period: s.config.PayeeCoolingOff, MaxAmount: s.config.PayeeCoolingOffAmount},
+		Audit:         auditContext(context, authPayload.Username),
 	}
 
 	transfer, err := s.store.TransferTx(context, arg)
 	if err != nil {
+		if errors.Is(err, db.ErrAccountFrozen) {
+			context.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		var exceeded *db.ErrLimitExceeded
+		if errors.As(err, &exceeded) {
+			context.JSON(http.StatusTooManyRequests, limitExceededResponse(exceeded))
+			return
+		}
+		var coolingOff *db.ErrCoolingOff
+		if errors.As(err, &coolingOff) {
+			context.JSON(http.StatusForbidden, coolingOffResponse(coolingOff))
+			return
+		}
 		context.JSON(500, errorResponse(err))
 		return
 	}
+	metrics.RecordTransfer(req.Currency, req.Amount)
 
+	if recipientName != "" {
+		context.JSON(200, personTransferResponse{
+			Transfer:    transfer.Transfer,
+			FromAccount: transfer.FromAccount,
+			FromEntry:   transfer.FromEntry,
+			FeeEntry:    transfer.FeeEntry,
+			Recipient:   recipientName,
+		})
+		return
+	}
 	context.JSON(200, transfer)
 }
 
+func countRecipients(req createTransferRequest) int {
+	count := 0
+	for _, set := range []bool{req.ToAccountID != 0, req.PayeeID != 0, req.ToUsername != "", req.ToEmail != ""} {
+		if set {
+			count++
+		}
+	}
+	return count
+}
+
+// validPayee checks that the payee belongs to the user and is in the currency. Its cooling-off period is enforced by
+// TransferTx.
+func (s *Server) validPayee(context *gin.Context, req createTransferRequest, username string) (db.Payee, bool) {
+	payee, err := s.store.GetPayee(context, req.PayeeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			context.JSON(http.StatusNotFound, errorResponse(err))
+			return payee, false
+		}
+		context.JSON(500, errorResponse(err))
+		return payee, false
+	}
+	if payee.Owner != username {
+		err := fmt.Errorf("payee doesn't belong to the authenticated user")
+		context.JSON(http.StatusUnauthorized, errorResponse(err))
+		return payee, false
+	}
+	if payee.Currency != req.Currency {
+		err = fmt.Errorf("payee [%d] currency mismatch: %s vs %s", payee.ID, payee.Currency, req.Currency)
+		context.JSON(http.StatusBadRequest, errorResponse(err))
+		return payee, false
+	}
+	return payee, true
+}
+
+// resolveRecipient finds the account in the currency of the person paid by username or email.
+func (s *Server) resolveRecipient(context *gin.Context, req createTransferRequest) (*db.Recipient, bool) {
+	recipient, err := s.store.ResolveRecipient(context, db.ResolveRecipientParams{
+		Username: req.ToUsername,
+		Email:    req.ToEmail,
+		Currency: req.Currency,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecipientNotFound):
+			context.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, db.ErrRecipientNoAccount):
+			context.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			context.JSON(500, errorResponse(err))
+		}
+		return nil, false
+	}
+	return &recipient, true
+}
+
 // check valid account currency
 func (s *Server) validAccountCurrency(context *gin.Context, accountID int64, currency string) (db.Account, bool) {
 	account, err := s.store.GetAccount(context, accountID)
@@ -73,3 +200,24 @@ func (s *Server) validAccountCurrency(context *gin.Context, accountID int64, cur
 	}
 	return account, true
 }
+
+// limitExceededResponse tells which limit a transfer exceeded and how much can still be transferred.
+func limitExceededResponse(err *db.ErrLimitExceeded) gin.H {
+	return gin.H{
+		"error":     err.Error(),
+		"scope":     err.Scope,
+		"window":    err.Window,
+		"limit":     err.Limit,
+		"remaining": err.Remaining,
+	}
+}
+
+// coolingOffResponse tells until when transfers to a new recipient are capped and how much can still be transferred.
+func coolingOffResponse(err *db.ErrCoolingOff) gin.H {
+	return gin.H{
+		"error":      err.Error(),
+		"until":      err.Until,
+		"max_amount": err.MaxAmount,
+		"remaining":  err.Remaining,
+	}
+}
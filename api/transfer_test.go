@@ -2,11 +2,14 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"github.com/golang/mock/gomock"
 	mockDb "github.com/kwalter26/udemy-simplebank/db/mock"
 	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/limits"
 	"github.com/kwalter26/udemy-simplebank/token"
 	"github.com/kwalter26/udemy-simplebank/util"
 	"github.com/stretchr/testify/require"
@@ -27,6 +30,20 @@ func TestCreateTransfer(t *testing.T) {
 	account2.Currency = currency
 	account3 := randomAccount(user2.Username)
 	account3.Currency = currency
+	payee := db.Payee{
+		ID:                  util.RandomInt(1, 1000),
+		Owner:               user.Username,
+		Nickname:            "rent",
+		AccountID:           account3.ID,
+		Currency:            currency,
+		CoolingOffUntil:     sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+		CoolingOffMaxAmount: 100,
+	}
+	settledPayee := payee
+	settledPayee.ID = payee.ID + 1
+	settledPayee.CoolingOffUntil.Time = time.Now().Add(-time.Hour)
+	otherPayee := payee
+	otherPayee.Owner = user2.Username
 	transfer := db.Transfer{
 		ID:            util.RandomInt(1, 1000),
 		FromAccountID: account1.ID,
@@ -238,6 +255,343 @@ func TestCreateTransfer(t *testing.T) {
 			},
 		},
 		// Get account internal server error
+		{
+			name: "AccountFrozen",
+			arg: createTransferRequest{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+				Currency:      currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockDb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), account2.ID).
+					Times(1).
+					Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, db.ErrAccountFrozen)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "LimitExceeded",
+			arg: createTransferRequest{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+				Currency:      currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockDb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), account2.ID).
+					Times(1).
+					Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.ErrLimitExceeded{Scope: limits.ScopeUser, Window: limits.WindowDaily, Limit: 100, Remaining: 5})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.JSONEq(t, `{"error":"user daily limit of 100 exceeded, 5 remaining","scope":"user","window":"daily","limit":100,"remaining":5}`, recorder.Body.String())
+			},
+		},
+		{
+			name: "PayByUsername",
+			arg: createTransferRequest{
+				FromAccountID: account1.ID,
+				ToUsername:    user2.Username,
+				Amount:        amount,
+				Currency:      currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockDb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					ResolveRecipient(gomock.Any(), gomock.Eq(db.ResolveRecipientParams{Username: user2.Username, Currency: currency})).
+					Times(1).
+					Return(db.Recipient{User: user2, Account: account3}, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+						require.Equal(t, account1.ID, arg.FromAccountID)
+						require.Equal(t, account3.ID, arg.ToAccountID)
+						return db.TransferTxResult{FromAccount: account1, ToAccount: account3, Transfer: transfer}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var rsp map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, util.MaskName(user2.FullName), rsp["recipient"])
+				require.NotContains(t, rsp, "to_account")
+				require.NotContains(t, rsp, "to_entry")
+			},
+		},
+		{
+			name: "PayByEmailNoAccountInCurrency",
+			arg: createTransferRequest{
+				FromAccountID: account1.ID,
+				ToEmail:       user2.Email,
+				Amount:        amount,
+				Currency:      currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockDb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					ResolveRecipient(gomock.Any(), gomock.Eq(db.ResolveRecipientParams{Email: user2.Email, Currency: currency})).
+					Times(1).
+					Return(db.Recipient{}, fmt.Errorf("%w %s", db.ErrRecipientNoAccount, currency))
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "recipient has no account in currency USD")
+			},
+		},
+		{
+			name: "RecipientNotFound",
+			arg: createTransferRequest{
+				FromAccountID: account1.ID,
+				ToUsername:    user2.Username,
+				Amount:        amount,
+				Currency:      currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockDb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					ResolveRecipient(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Recipient{}, db.ErrRecipientNotFound)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "PayYourself",
+			arg: createTransferRequest{
+				FromAccountID: account1.ID,
+				ToUsername:    user.Username,
+				Amount:        amount,
+				Currency:      currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockDb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					ResolveRecipient(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Recipient{User: user, Account: account1}, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "SeveralRecipients",
+			arg: createTransferRequest{
+				FromAccountID: account1.ID,
+				ToAccountID:   account3.ID,
+				ToUsername:    user2.Username,
+				Amount:        amount,
+				Currency:      currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockDb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "PayPayee",
+			arg: createTransferRequest{
+				FromAccountID: account1.ID,
+				PayeeID:       settledPayee.ID,
+				Amount:        amount,
+				Currency:      currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockDb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					GetPayee(gomock.Any(), gomock.Eq(settledPayee.ID)).
+					Times(1).
+					Return(settledPayee, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+						require.Equal(t, account3.ID, arg.ToAccountID)
+						return db.TransferTxResult{FromAccount: account1, ToAccount: account3, Transfer: transfer}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var rsp map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, settledPayee.Nickname, rsp["recipient"])
+				require.NotContains(t, rsp, "to_account")
+			},
+		},
+		{
+			name: "PayeeCoolingOff",
+			arg: createTransferRequest{
+				FromAccountID: account1.ID,
+				PayeeID:       payee.ID,
+				Amount:        payee.CoolingOffMaxAmount + 1,
+				Currency:      currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockDb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					GetPayee(gomock.Any(), gomock.Eq(payee.ID)).
+					Times(1).
+					Return(payee, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.ErrCoolingOff{
+						Until:     payee.CoolingOffUntil.Time,
+						MaxAmount: payee.CoolingOffMaxAmount,
+						Remaining: payee.CoolingOffMaxAmount,
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				var rsp map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, float64(payee.CoolingOffMaxAmount), rsp["max_amount"])
+				require.Equal(t, float64(payee.CoolingOffMaxAmount), rsp["remaining"])
+				require.NotContains(t, rsp, "payee_id")
+			},
+		},
+		{
+			name: "PayeeCoolingOffSmallAmount",
+			arg: createTransferRequest{
+				FromAccountID: account1.ID,
+				PayeeID:       payee.ID,
+				Amount:        payee.CoolingOffMaxAmount,
+				Currency:      currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockDb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					GetPayee(gomock.Any(), gomock.Eq(payee.ID)).
+					Times(1).
+					Return(payee, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{FromAccount: account1, ToAccount: account3, Transfer: transfer}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "PayeeOfAnotherUser",
+			arg: createTransferRequest{
+				FromAccountID: account1.ID,
+				PayeeID:       otherPayee.ID,
+				Amount:        amount,
+				Currency:      currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockDb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), account1.ID).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					GetPayee(gomock.Any(), gomock.Eq(otherPayee.ID)).
+					Times(1).
+					Return(otherPayee, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
 		{
 			name: "InternalErrorOnGetAccount",
 			arg: createTransferRequest{
@@ -1,15 +1,12 @@
 package api
 
 import (
-	ctx "context"
 	"database/sql"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
 	"github.com/kwalter26/udemy-simplebank/util"
 	"github.com/lib/pq"
-	"github.com/newrelic/go-agent/v3/newrelic"
-	"log"
 	"net/http"
 	"time"
 )
@@ -49,7 +46,12 @@ func (s *Server) CreateUser(context *gin.Context) {
 		return
 	}
 
-	hashedPassword, err := util.HashPassword(req.Password)
+	hashedPassword, err := s.passwordHasher.Hash(req.Password)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
 	arg := db.CreateUserParams{
 		Username:       req.Username,
 		FullName:       req.FullName,
@@ -96,15 +98,16 @@ func (s *Server) loginUser(context *gin.Context) {
 		return
 	}
 
-	txn := s.app.StartTransaction("postgresQuery")
-	log.Println("txn start", txn)
-	c := newrelic.NewContext(ctx.Background(), txn)
-	user, err := s.store.GetUser(c, req.Username)
-	txn.End()
-	log.Println("txn end", txn)
+	lockoutKeys := s.loginLockoutKeys(context, req.Username)
+	if !s.checkLoginLockout(context, lockoutKeys) {
+		return
+	}
+
+	user, err := s.store.GetUser(context.Request.Context(), req.Username)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			context.JSON(http.StatusNotFound, errorResponse(err))
+			s.dummyPassword.Check(req.Password)
+			s.loginFailed(context, req.Username, lockoutKeys)
 			return
 		}
 		context.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -113,7 +116,13 @@ func (s *Server) loginUser(context *gin.Context) {
 
 	err = util.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
-		context.JSON(http.StatusUnauthorized, errorResponse(err))
+		s.loginFailed(context, req.Username, lockoutKeys)
+		return
+	}
+
+	err = s.store.ResetLoginLockout(context, db.ResetLoginLockoutParams{Kind: lockoutKindUsername, Key: user.Username})
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
@@ -129,14 +138,17 @@ func (s *Server) loginUser(context *gin.Context) {
 		return
 	}
 
-	session, err := s.store.CreateSession(context, db.CreateSessionParams{
-		ID:           refreshPayload.ID,
-		Username:     user.Username,
-		RefreshToken: refreshToken,
-		UserAgent:    context.Request.UserAgent(),
-		ClientIp:     context.ClientIP(),
-		IsBlocked:    false,
-		ExpiresAt:    refreshPayload.ExpireAt,
+	result, err := s.store.CreateSessionTx(context, db.CreateSessionTxParams{
+		CreateSessionParams: db.CreateSessionParams{
+			ID:           refreshPayload.ID,
+			Username:     user.Username,
+			RefreshToken: refreshToken,
+			UserAgent:    context.Request.UserAgent(),
+			ClientIp:     context.ClientIP(),
+			IsBlocked:    false,
+			ExpiresAt:    refreshPayload.ExpireAt,
+		},
+		Audit: auditContext(context, user.Username),
 	})
 
 	if err != nil {
@@ -145,7 +157,7 @@ func (s *Server) loginUser(context *gin.Context) {
 	}
 
 	rsp := LoginUserResponse{
-		SessionID:             session.ID,
+		SessionID:             result.Session.ID,
 		AccessToken:           accessToken,
 		AccessTokenExpiresAt:  accessPayload.ExpireAt,
 		RefreshToken:          refreshToken,
@@ -17,6 +17,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 type eqCreateUserParamsMatcher struct {
@@ -47,6 +48,33 @@ func EqCreateUserParamsMatcher(arg db.CreateUserParams, password string) gomock.
 	return eqCreateUserParamsMatcher{arg: arg, password: password}
 }
 
+type eqRecordLoginFailureParamsMatcher struct {
+	kind   string
+	key    string
+	window time.Duration
+}
+
+func (expected eqRecordLoginFailureParamsMatcher) Matches(x interface{}) bool {
+	actualArg, ok := x.(db.RecordLoginFailureParams)
+	if !ok {
+		return false
+	}
+
+	resetBefore := time.Now().Add(-expected.window)
+	return actualArg.Kind == expected.kind &&
+		actualArg.Key == expected.key &&
+		actualArg.ResetBefore.After(resetBefore.Add(-time.Minute)) &&
+		!actualArg.ResetBefore.After(resetBefore)
+}
+
+func (expected eqRecordLoginFailureParamsMatcher) String() string {
+	return fmt.Sprintf("matches kind %v, key %v and a reset %v ago", expected.kind, expected.key, expected.window)
+}
+
+func EqRecordLoginFailureParams(kind, key string, window time.Duration) gomock.Matcher {
+	return eqRecordLoginFailureParamsMatcher{kind: kind, key: key, window: window}
+}
+
 // TestCreateUserAPI tests the CreateUser API
 func TestCreateUserAPI(t *testing.T) {
 	user, password := createRandomUser(t)
@@ -230,12 +258,22 @@ func TestLoginUserAPI(t *testing.T) {
 				"password": password,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginLockout(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginLockout{}, sql.ErrNoRows)
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
 				store.EXPECT().
-					CreateSession(gomock.Any(), gomock.Any()).Times(1)
+					ResetLoginLockout(gomock.Any(), gomock.Eq(db.ResetLoginLockoutParams{Kind: lockoutKindUsername, Key: user.Username})).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					CreateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateSessionTxResult{Session: db.Session{Username: user.Username}}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -271,13 +309,46 @@ func TestLoginUserAPI(t *testing.T) {
 				"password": password,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginLockout(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginLockout{}, sql.ErrNoRows)
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), EqRecordLoginFailureParams(lockoutKindUsername, user.Username, 24*time.Hour)).
+					Times(1).
+					Return(db.LoginLockout{FailedCount: 1}, nil)
+				store.EXPECT().
+					CreateAuditEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AuditEvent{}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), errInvalidCredentials.Error())
+			},
+		},
+		{
+			name: "LockedOut",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginLockout(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginLockout{FailedCount: 5, LockedUntil: time.Now().Add(time.Minute)}, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.NotEmpty(t, recorder.Header().Get("Retry-After"))
 			},
 		},
 		{
@@ -287,6 +358,10 @@ func TestLoginUserAPI(t *testing.T) {
 				"password": password,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginLockout(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginLockout{}, sql.ErrNoRows)
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
@@ -303,13 +378,30 @@ func TestLoginUserAPI(t *testing.T) {
 				"password": "badpassword",
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginLockout(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginLockout{}, sql.ErrNoRows)
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginLockout{FailedCount: 5}, nil)
+				store.EXPECT().
+					LockLogin(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginLockout{}, nil)
+				store.EXPECT().
+					CreateAuditEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AuditEvent{}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), errInvalidCredentials.Error())
 			},
 		},
 	}
@@ -0,0 +1,100 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrNotFound is returned when no blob is stored under a key.
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps files, such as generated statements, under slash separated keys like statements/12/2023-01.pdf.
+type Store interface {
+	// Put stores content under key, replacing any previous blob, and returns its size.
+	Put(ctx context.Context, key string, content io.Reader) (int64, error)
+	// Get opens the blob stored under key, or fails with ErrNotFound.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// LocalStore keeps blobs as files below a root directory.
+type LocalStore struct {
+	root string
+}
+
+// NewLocalStore creates a store below root, creating the directory if needed.
+func NewLocalStore(root string) (*LocalStore, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("cannot create blob directory: %w", err)
+	}
+	return &LocalStore{root: root}, nil
+}
+
+// Put writes content to a temporary file next to the blob and renames it, so that readers never see a partial
+// blob.
+func (store *LocalStore) Put(ctx context.Context, key string, content io.Reader) (int64, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, fmt.Errorf("cannot create blob directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".blob-*")
+	if err != nil {
+		return 0, fmt.Errorf("cannot create blob: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	size, err := io.Copy(file, content)
+	if err != nil {
+		file.Close()
+		return 0, fmt.Errorf("cannot write blob: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return 0, fmt.Errorf("cannot write blob: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return 0, fmt.Errorf("cannot store blob: %w", err)
+	}
+	return size, nil
+}
+
+func (store *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (store *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// path maps a key to a file below the root. Keys escaping the root are rejected.
+func (store *LocalStore) path(key string) (string, error) {
+	name := filepath.FromSlash(key)
+	if key == "" || !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(store.root, name), nil
+}
@@ -0,0 +1,53 @@
+package blob
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "blobs")
+	store, err := NewLocalStore(root)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	size, err := store.Put(ctx, "statements/12/2023-01.pdf", strings.NewReader("first"))
+	require.NoError(t, err)
+	require.Equal(t, int64(5), size)
+	size, err = store.Put(ctx, "statements/12/2023-01.pdf", strings.NewReader("second"))
+	require.NoError(t, err)
+	require.Equal(t, int64(6), size)
+
+	content, err := store.Get(ctx, "statements/12/2023-01.pdf")
+	require.NoError(t, err)
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	require.NoError(t, content.Close())
+	require.Equal(t, "second", string(data))
+
+	entries, err := os.ReadDir(filepath.Join(root, "statements", "12"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "no temporary file is left behind")
+
+	require.NoError(t, store.Delete(ctx, "statements/12/2023-01.pdf"))
+	require.NoError(t, store.Delete(ctx, "statements/12/2023-01.pdf"))
+	_, err = store.Get(ctx, "statements/12/2023-01.pdf")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLocalStoreInvalidKey(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../outside", "/etc/passwd", "statements/../../outside"} {
+		_, err = store.Put(context.Background(), key, strings.NewReader("data"))
+		require.ErrorContains(t, err, "invalid blob key", key)
+		_, err = store.Get(context.Background(), key)
+		require.ErrorContains(t, err, "invalid blob key", key)
+	}
+}
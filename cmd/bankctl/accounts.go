@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"strconv"
+	"time"
+)
+
+var accountHeader = []string{"ID", "OWNER", "BALANCE", "CURRENCY", "PRODUCT", "FROZEN", "CREATED AT"}
+
+func accountRow(account db.Account) []string {
+	return []string{
+		strconv.FormatInt(account.ID, 10),
+		account.Owner,
+		strconv.FormatInt(account.Balance, 10),
+		account.Currency,
+		account.Product,
+		strconv.FormatBool(account.Frozen),
+		formatTime(account.CreatedAt),
+	}
+}
+
+func printAccount(app *app, account db.Account) error {
+	return app.printer.print(account, accountHeader, [][]string{accountRow(account)})
+}
+
+func createAccount(ctx context.Context, app *app, args []string) error {
+	flags := flag.NewFlagSet("accounts create", flag.ContinueOnError)
+	owner := flags.String("owner", "", "username of the owner")
+	currency := flags.String("currency", "", "currency of the account")
+	product := flags.String("product", db.ProductChecking, "product of the account, e.g. checking or savings")
+	if err := parseFlags(flags, args, "owner", "currency"); err != nil {
+		return err
+	}
+	if !util.IsSupportedCurrency(*currency) {
+		return fmt.Errorf("unsupported currency %q", *currency)
+	}
+
+	store, err := app.getStore()
+	if err != nil {
+		return err
+	}
+	accountProduct, err := store.GetAccountProduct(ctx, *product)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("unknown product %q", *product)
+		}
+		return fmt.Errorf("cannot get product: %w", err)
+	}
+	if accountProduct.Internal {
+		return fmt.Errorf("product %q is internal to the bank", *product)
+	}
+	result, err := store.CreateAccountTx(ctx, db.CreateAccountTxParams{
+		CreateAccountParams: db.CreateAccountParams{
+			Owner:    *owner,
+			Currency: *currency,
+			Product:  accountProduct.Code,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create account: %w", err)
+	}
+	return printAccount(app, result.Account)
+}
+
+func freezeAccount(ctx context.Context, app *app, args []string) error {
+	return setAccountFrozen(ctx, app, "accounts freeze", args, true)
+}
+
+func unfreezeAccount(ctx context.Context, app *app, args []string) error {
+	return setAccountFrozen(ctx, app, "accounts unfreeze", args, false)
+}
+
+func setAccountFrozen(ctx context.Context, app *app, name string, args []string, frozen bool) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	id := flags.Int64("id", 0, "id of the account")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *id <= 0 {
+		return fmt.Errorf("%s: missing -id", name)
+	}
+
+	store, err := app.getStore()
+	if err != nil {
+		return err
+	}
+	result, err := store.FreezeAccountTx(ctx, db.FreezeAccountTxParams{
+		AccountID: *id,
+		Frozen:    frozen,
+		Audit:     app.audit,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("account %d not found", *id)
+		}
+		return fmt.Errorf("cannot update account: %w", err)
+	}
+	return printAccount(app, result.Account)
+}
+
+// statement is an account with a page of its entries, oldest first.
+type statement struct {
+	Account db.Account `json:"account"`
+	Entries []db.Entry `json:"entries"`
+}
+
+func printStatement(ctx context.Context, app *app, args []string) error {
+	flags := flag.NewFlagSet("accounts statement", flag.ContinueOnError)
+	id := flags.Int64("id", 0, "id of the account")
+	pageSize := flags.Int("page-size", 50, "number of entries")
+	page := flags.Int("page", 1, "page of entries, starting at 1")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *id <= 0 {
+		return errors.New("accounts statement: missing -id")
+	}
+	if *pageSize < 1 || *page < 1 {
+		return errors.New("accounts statement: -page-size and -page must be positive")
+	}
+
+	store, err := app.getStore()
+	if err != nil {
+		return err
+	}
+	account, err := store.GetAccount(ctx, *id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("account %d not found", *id)
+		}
+		return fmt.Errorf("cannot get account: %w", err)
+	}
+	entries, err := store.ListAccountEntries(ctx, db.ListAccountEntriesParams{
+		AccountID: account.ID,
+		Limit:     int32(*pageSize),
+		Offset:    int32((*page - 1) * *pageSize),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot list entries: %w", err)
+	}
+
+	if app.printer.format == outputJson {
+		return app.printer.json(statement{Account: account, Entries: entries})
+	}
+	if err = printAccount(app, account); err != nil {
+		return err
+	}
+	fmt.Fprintln(app.printer.out)
+	rows := make([][]string, 0, len(entries))
+	for _, entry := range entries {
+		rows = append(rows, []string{strconv.FormatInt(entry.ID, 10), strconv.FormatInt(entry.Amount, 10), formatTime(entry.CreatedAt)})
+	}
+	return app.printer.table([]string{"ENTRY", "AMOUNT", "CREATED AT"}, rows)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/fees"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"strconv"
+)
+
+var feeRuleHeader = []string{"ID", "CURRENCY", "KIND", "FLAT", "RATE BPS", "MIN", "MAX", "FREE/MONTH", "ACTIVE", "CREATED AT"}
+
+func feeRuleRow(rule db.FeeRule) []string {
+	return []string{
+		strconv.FormatInt(rule.ID, 10),
+		rule.Currency,
+		rule.Kind,
+		strconv.FormatInt(rule.FlatAmount, 10),
+		strconv.FormatInt(int64(rule.RateBps), 10),
+		strconv.FormatInt(rule.MinAmount, 10),
+		strconv.FormatInt(rule.MaxAmount, 10),
+		strconv.FormatInt(int64(rule.FreeTransfersPerMonth), 10),
+		strconv.FormatBool(rule.Active),
+		formatTime(rule.CreatedAt),
+	}
+}
+
+func listFeeRules(ctx context.Context, app *app, args []string) error {
+	flags := flag.NewFlagSet("fees list", flag.ContinueOnError)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	store, err := app.getStore()
+	if err != nil {
+		return err
+	}
+	rules, err := store.ListFeeRules(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list fee rules: %w", err)
+	}
+	rows := make([][]string, 0, len(rules))
+	for _, rule := range rules {
+		rows = append(rows, feeRuleRow(rule))
+	}
+	return app.printer.print(rules, feeRuleHeader, rows)
+}
+
+func setFeeRule(ctx context.Context, app *app, args []string) error {
+	flags := flag.NewFlagSet("fees set", flag.ContinueOnError)
+	currency := flags.String("currency", "", "currency of the transfers the rule charges")
+	kind := flags.String("kind", "", "flat or percentage")
+	flat := flags.Int64("flat", 0, "fee of a flat rule")
+	rateBps := flags.Int("rate-bps", 0, "fee of a percentage rule in basis points of the amount")
+	minFee := flags.Int64("min", 0, "minimum fee of a percentage rule")
+	maxFee := flags.Int64("max", 0, "maximum fee of a percentage rule, 0 for none")
+	freePerMonth := flags.Int("free-per-month", 0, "transfers of a calendar month that are not charged")
+	if err := parseFlags(flags, args, "currency", "kind"); err != nil {
+		return err
+	}
+	if !util.IsSupportedCurrency(*currency) {
+		return fmt.Errorf("unsupported currency %q", *currency)
+	}
+	rule := fees.Rule{
+		Kind:                  *kind,
+		FlatAmount:            *flat,
+		RateBps:               int32(*rateBps),
+		MinAmount:             *minFee,
+		MaxAmount:             *maxFee,
+		FreeTransfersPerMonth: int32(*freePerMonth),
+	}
+	if err := rule.Validate(); err != nil {
+		return fmt.Errorf("fees set: %w", err)
+	}
+
+	store, err := app.getStore()
+	if err != nil {
+		return err
+	}
+	result, err := store.SetFeeRuleTx(ctx, db.SetFeeRuleTxParams{
+		Currency: *currency,
+		Rule:     &rule,
+		Audit:    app.audit,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot set fee rule: %w", err)
+	}
+	return app.printer.print(result.Rule, feeRuleHeader, [][]string{feeRuleRow(*result.Rule)})
+}
+
+func disableFeeRule(ctx context.Context, app *app, args []string) error {
+	flags := flag.NewFlagSet("fees disable", flag.ContinueOnError)
+	currency := flags.String("currency", "", "currency whose transfers become free")
+	if err := parseFlags(flags, args, "currency"); err != nil {
+		return err
+	}
+
+	store, err := app.getStore()
+	if err != nil {
+		return err
+	}
+	result, err := store.SetFeeRuleTx(ctx, db.SetFeeRuleTxParams{
+		Currency: *currency,
+		Audit:    app.audit,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot disable fee rule: %w", err)
+	}
+	if len(result.Deactivated) == 0 {
+		return fmt.Errorf("fees disable: no active fee rule for %s", *currency)
+	}
+	rows := make([][]string, 0, len(result.Deactivated))
+	for _, rule := range result.Deactivated {
+		rows = append(rows, feeRuleRow(rule))
+	}
+	return app.printer.print(result.Deactivated, feeRuleHeader, rows)
+}
@@ -0,0 +1,209 @@
+// Command bankctl runs the operator tasks of the bank against its database and task queue: it creates users and
+// accounts, freezes accounts, sets transfer fees, blocks sessions, migrates the database, replays dead tasks and
+// prints statements.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/hibiken/asynq"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/kwalter26/udemy-simplebank/worker"
+	_ "github.com/lib/pq"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+const usage = `usage: bankctl [-config dir] [-output table|json] <command> <subcommand> [flags]
+
+commands:
+  users create        -username -full-name -email [-password] [-role]
+  users set-role      -username -role
+  accounts create     -owner -currency [-product]
+  accounts freeze     -id
+  accounts unfreeze   -id
+  accounts statement  -id [-page-size] [-page]
+  fees list
+  fees set            -currency -kind flat|percentage [-flat] [-rate-bps] [-min] [-max] [-free-per-month]
+  fees disable        -currency
+  sessions block      -username [-id]
+  migrate up          [-dry-run]
+  migrate down        [steps]
+  migrate goto        version
+  migrate force       version
+  migrate status
+  tasks list          -queue [-state archived|retry] [-page-size] [-page]
+  tasks replay        -queue (-id | -all)
+`
+
+// command runs a subcommand with the arguments after its name.
+type command func(ctx context.Context, app *app, args []string) error
+
+var commands = map[string]map[string]command{
+	"users": {
+		"create":   createUser,
+		"set-role": setUserRole,
+	},
+	"accounts": {
+		"create":    createAccount,
+		"freeze":    freezeAccount,
+		"unfreeze":  unfreezeAccount,
+		"statement": printStatement,
+	},
+	"fees": {
+		"list":    listFeeRules,
+		"set":     setFeeRule,
+		"disable": disableFeeRule,
+	},
+	"sessions": {
+		"block": blockSessions,
+	},
+	"migrate": {
+		"up":     migrateUp,
+		"down":   migrateDown,
+		"goto":   migrateGoto,
+		"force":  migrateForce,
+		"status": migrateStatus,
+	},
+	"tasks": {
+		"list":   listTasks,
+		"replay": replayTasks,
+	},
+}
+
+// app holds the dependencies of the commands. The store and the inspector are opened on first use, so that
+// commands like migrate do not need them and tests can set them up front.
+type app struct {
+	config    util.Config
+	printer   printer
+	stdin     io.Reader
+	audit     db.AuditContext
+	store     db.Store
+	inspector worker.TaskInspector
+	closers   []io.Closer
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "bankctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("bankctl", flag.ContinueOnError)
+	configDir := flags.String("config", ".", "directory of the app.env file")
+	output := flags.String("output", outputTable, "output format, table or json")
+	flags.Usage = func() { fmt.Fprint(flags.Output(), usage) }
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cmd, err := lookupCommand(flags.Args())
+	if err != nil {
+		flags.Usage()
+		return err
+	}
+
+	printer, err := newPrinter(*output, stdout)
+	if err != nil {
+		return err
+	}
+	config, err := util.LoadConfig(*configDir, false)
+	if err != nil {
+		return fmt.Errorf("cannot load config: %w", err)
+	}
+
+	app := &app{
+		config:  config,
+		printer: printer,
+		stdin:   stdin,
+		audit:   operatorAudit(),
+	}
+	defer app.close()
+	return cmd(ctx, app, flags.Args()[2:])
+}
+
+func lookupCommand(args []string) (command, error) {
+	if len(args) < 2 {
+		return nil, errors.New("missing command")
+	}
+	group, ok := commands[args[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown command %q", args[0])
+	}
+	cmd, ok := group[args[1]]
+	if !ok {
+		names := make([]string, 0, len(group))
+		for name := range group {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown subcommand %q of %s, must be one of %s", args[1], args[0], strings.Join(names, ", "))
+	}
+	return cmd, nil
+}
+
+// operatorAudit attributes the changes of bankctl to the operator running it.
+func operatorAudit() db.AuditContext {
+	operator := os.Getenv("USER")
+	if operator == "" {
+		operator = "unknown"
+	}
+	return db.AuditContext{Actor: "bankctl:" + operator, UserAgent: "bankctl"}
+}
+
+func (app *app) getStore() (db.Store, error) {
+	if app.store == nil {
+		conn, err := sql.Open(app.config.DBDriver, app.config.DBSource)
+		if err != nil {
+			return nil, fmt.Errorf("cannot connect to db: %w", err)
+		}
+		app.closers = append(app.closers, conn)
+		app.store = db.NewStore(conn)
+	}
+	return app.store, nil
+}
+
+func (app *app) getInspector() (worker.TaskInspector, error) {
+	if app.inspector == nil {
+		if app.config.TaskQueueBackend == "memory" {
+			return nil, errors.New("tasks of the memory backend live in the server process and cannot be inspected")
+		}
+		inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: app.config.RedisAddress})
+		app.closers = append(app.closers, inspector)
+		app.inspector = worker.NewRedisTaskInspector(inspector)
+	}
+	return app.inspector, nil
+}
+
+func (app *app) close() {
+	for _, closer := range app.closers {
+		closer.Close()
+	}
+}
+
+// parseFlags parses the flags of a subcommand and reports the ones that are required but missing.
+func parseFlags(flags *flag.FlagSet, args []string, required ...string) error {
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	for _, name := range required {
+		if flags.Lookup(name).Value.String() == "" {
+			return fmt.Errorf("%s: missing -%s", flags.Name(), name)
+		}
+	}
+	return nil
+}
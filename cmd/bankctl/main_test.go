@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	_ "github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/fees"
+	"github.com/kwalter26/udemy-simplebank/migration"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/kwalter26/udemy-simplebank/worker"
+	mockwk "github.com/kwalter26/udemy-simplebank/worker/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func newTestApp(t *testing.T, store db.Store, inspector worker.TaskInspector, format string, stdin string) (*app, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	printer, err := newPrinter(format, out)
+	require.NoError(t, err)
+
+	return &app{
+		config:    util.Config{BcryptCost: 4},
+		printer:   printer,
+		stdin:     strings.NewReader(stdin),
+		audit:     db.AuditContext{Actor: "bankctl:tester", UserAgent: "bankctl"},
+		store:     store,
+		inspector: inspector,
+	}, out
+}
+
+func TestLookupCommand(t *testing.T) {
+	cmd, err := lookupCommand([]string{"accounts", "freeze", "-id", "1"})
+	require.NoError(t, err)
+	require.NotNil(t, cmd)
+
+	_, err = lookupCommand([]string{"accounts"})
+	require.EqualError(t, err, "missing command")
+
+	_, err = lookupCommand([]string{"accounts", "close"})
+	require.EqualError(t, err, `unknown subcommand "close" of accounts, must be one of create, freeze, statement, unfreeze`)
+}
+
+func TestCreateUser(t *testing.T) {
+	user := db.User{
+		Username: util.RandomOwner(),
+		FullName: util.RandomOwner(),
+		Email:    util.RandomEmail(),
+		Role:     util.DepositorRole,
+	}
+
+	testCases := []struct {
+		name          string
+		args          []string
+		stdin         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, out string, err error)
+	}{
+		{
+			name:  "PasswordFromStdin",
+			args:  []string{"-username", user.Username, "-full-name", user.FullName, "-email", user.Email},
+			stdin: "secret123\n",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						require.NoError(t, util.CheckPassword("secret123", arg.CreateUserParams.HashedPassword))
+						tasks, err := arg.AfterCreate(user)
+						require.NoError(t, err)
+						require.Len(t, tasks, 1)
+						require.Equal(t, worker.TaskSendVerifyEmail, tasks[0].TaskType)
+						return db.CreateUserTxResult{User: user}, nil
+					})
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				require.Contains(t, out, user.Username)
+				require.Contains(t, out, util.DepositorRole)
+			},
+		},
+		{
+			name:  "Banker",
+			args:  []string{"-username", user.Username, "-full-name", user.FullName, "-email", user.Email, "-password", "secret123", "-role", util.BankerRole},
+			stdin: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				banker := user
+				banker.Role = util.BankerRole
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						require.Equal(t, util.BankerRole, arg.Role)
+						require.Equal(t, db.AuditContext{Actor: "bankctl:tester", UserAgent: "bankctl"}, arg.Audit)
+						return db.CreateUserTxResult{User: banker}, nil
+					})
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				require.Contains(t, out, util.BankerRole)
+			},
+		},
+		{
+			name:  "CreateFails",
+			args:  []string{"-username", user.Username, "-full-name", user.FullName, "-email", user.Email, "-password", "secret123", "-role", util.BankerRole},
+			stdin: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateUserTxResult{}, sql.ErrConnDone)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, out string, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				require.Empty(t, out)
+			},
+		},
+		{
+			name:  "InvalidEmail",
+			args:  []string{"-username", user.Username, "-full-name", user.FullName, "-email", "invalid", "-password", "secret123"},
+			stdin: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, out string, err error) {
+				require.ErrorContains(t, err, "invalid email")
+			},
+		},
+		{
+			name:  "InvalidRole",
+			args:  []string{"-username", user.Username, "-full-name", user.FullName, "-email", user.Email, "-password", "secret123", "-role", "admin"},
+			stdin: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, out string, err error) {
+				require.ErrorContains(t, err, `invalid role "admin"`)
+			},
+		},
+		{
+			name:  "MissingPassword",
+			args:  []string{"-username", user.Username, "-full-name", user.FullName, "-email", user.Email},
+			stdin: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, out string, err error) {
+				require.EqualError(t, err, "missing -password and no password on stdin")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			app, out := newTestApp(t, store, nil, outputTable, tc.stdin)
+			err := createUser(context.Background(), app, tc.args)
+			tc.checkResponse(t, out.String(), err)
+		})
+	}
+}
+
+func TestCreateAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	account := db.Account{ID: 7, Owner: util.RandomOwner(), Currency: util.USD, Product: db.ProductSavings}
+	store.EXPECT().
+		GetAccountProduct(gomock.Any(), gomock.Eq(db.ProductSavings)).
+		Times(1).
+		Return(db.AccountProduct{Code: db.ProductSavings, AnnualRateBps: 250}, nil)
+	store.EXPECT().
+		CreateAccountTx(gomock.Any(), gomock.Eq(db.CreateAccountTxParams{
+			CreateAccountParams: db.CreateAccountParams{Owner: account.Owner, Currency: util.USD, Product: db.ProductSavings},
+		})).
+		Times(1).
+		Return(db.CreateAccountTxResult{Account: account}, nil)
+	store.EXPECT().
+		GetAccountProduct(gomock.Any(), gomock.Eq(db.ProductInterestExpense)).
+		Times(1).
+		Return(db.AccountProduct{Code: db.ProductInterestExpense, Internal: true}, nil)
+
+	app, out := newTestApp(t, store, nil, outputTable, "")
+	require.NoError(t, createAccount(context.Background(), app, []string{"-owner", account.Owner, "-currency", util.USD, "-product", db.ProductSavings}))
+	require.Contains(t, out.String(), "PRODUCT")
+	require.Contains(t, out.String(), db.ProductSavings)
+
+	err := createAccount(context.Background(), app, []string{"-owner", account.Owner, "-currency", util.USD, "-product", db.ProductInterestExpense})
+	require.EqualError(t, err, `product "interest_expense" is internal to the bank`)
+}
+
+func TestSetFeeRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	rule := db.FeeRule{ID: 4, Currency: util.USD, Kind: fees.KindPercentage, RateBps: 150, MinAmount: 1, MaxAmount: 50, FreeTransfersPerMonth: 3, Active: true}
+	store.EXPECT().
+		SetFeeRuleTx(gomock.Any(), gomock.Eq(db.SetFeeRuleTxParams{
+			Currency: util.USD,
+			Rule:     &fees.Rule{Kind: fees.KindPercentage, RateBps: 150, MinAmount: 1, MaxAmount: 50, FreeTransfersPerMonth: 3},
+			Audit:    db.AuditContext{Actor: "bankctl:tester", UserAgent: "bankctl"},
+		})).
+		Times(1).
+		Return(db.SetFeeRuleTxResult{Rule: &rule}, nil)
+
+	app, out := newTestApp(t, store, nil, outputJson, "")
+	err := setFeeRule(context.Background(), app, []string{"-currency", util.USD, "-kind", fees.KindPercentage, "-rate-bps", "150", "-min", "1", "-max", "50", "-free-per-month", "3"})
+	require.NoError(t, err)
+	var printed db.FeeRule
+	require.NoError(t, json.Unmarshal(out.Bytes(), &printed))
+	require.Equal(t, rule.ID, printed.ID)
+
+	err = setFeeRule(context.Background(), app, []string{"-currency", util.USD, "-kind", fees.KindPercentage, "-min", "10", "-max", "5"})
+	require.EqualError(t, err, "fees set: maximum cannot be below the minimum")
+
+	store.EXPECT().
+		SetFeeRuleTx(gomock.Any(), gomock.Eq(db.SetFeeRuleTxParams{Currency: util.EUR, Audit: app.audit})).
+		Times(1).
+		Return(db.SetFeeRuleTxResult{}, nil)
+	err = disableFeeRule(context.Background(), app, []string{"-currency", util.EUR})
+	require.EqualError(t, err, "fees disable: no active fee rule for EUR")
+}
+
+func TestFreezeAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	account := db.Account{ID: 7, Owner: util.RandomOwner(), Currency: util.USD, Frozen: true}
+	store.EXPECT().
+		FreezeAccountTx(gomock.Any(), gomock.Eq(db.FreezeAccountTxParams{
+			AccountID: account.ID,
+			Frozen:    true,
+			Audit:     db.AuditContext{Actor: "bankctl:tester", UserAgent: "bankctl"},
+		})).
+		Times(1).
+		Return(db.FreezeAccountTxResult{Account: account}, nil)
+
+	app, out := newTestApp(t, store, nil, outputJson, "")
+	require.NoError(t, freezeAccount(context.Background(), app, []string{"-id", "7"}))
+
+	var got db.Account
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	require.Equal(t, account, got)
+
+	require.EqualError(t, unfreezeAccount(context.Background(), app, nil), "accounts unfreeze: missing -id")
+}
+
+func TestPrintStatement(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	account := db.Account{ID: 7, Owner: util.RandomOwner(), Balance: 40, Currency: util.USD}
+	entries := []db.Entry{{ID: 1, AccountID: account.ID, Amount: 50}, {ID: 2, AccountID: account.ID, Amount: -10}}
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	store.EXPECT().
+		ListAccountEntries(gomock.Any(), gomock.Eq(db.ListAccountEntriesParams{AccountID: account.ID, Limit: 10, Offset: 10})).
+		Times(1).
+		Return(entries, nil)
+
+	app, out := newTestApp(t, store, nil, outputJson, "")
+	require.NoError(t, printStatement(context.Background(), app, []string{"-id", "7", "-page-size", "10", "-page", "2"}))
+
+	var got statement
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	require.Equal(t, account, got.Account)
+	require.Equal(t, entries, got.Entries)
+}
+
+func TestReplayTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	inspector := mockwk.NewMockTaskInspector(ctrl)
+
+	gomock.InOrder(
+		inspector.EXPECT().
+			ListFailedTasks(worker.EmailQueue, asynq.TaskStateArchived, replayPageSize, 1).
+			Return([]*asynq.TaskInfo{{ID: "task-1"}, {ID: "task-2"}}, nil),
+		inspector.EXPECT().
+			ListFailedTasks(worker.EmailQueue, asynq.TaskStateArchived, replayPageSize, 1).
+			Return(nil, nil),
+	)
+	for _, taskID := range []string{"task-1", "task-2"} {
+		inspector.EXPECT().RunTask(worker.EmailQueue, taskID).Times(1).Return(nil)
+		store.EXPECT().
+			ResolveDeadLetterTask(gomock.Any(), gomock.Eq(db.ResolveDeadLetterTaskParams{
+				Queue:      worker.EmailQueue,
+				TaskID:     taskID,
+				Resolution: db.DeadLetterTaskRetried,
+			})).
+			Times(1).
+			Return(nil)
+	}
+
+	app, out := newTestApp(t, store, inspector, outputTable, "")
+	require.NoError(t, replayTasks(context.Background(), app, []string{"-queue", worker.EmailQueue, "-all"}))
+	require.Equal(t, "REPLAYED\ntask-1\ntask-2\n", out.String())
+
+	require.EqualError(t, replayTasks(context.Background(), app, []string{"-queue", worker.EmailQueue}), "tasks replay: need either -id or -all")
+	require.ErrorContains(t, replayTasks(context.Background(), app, []string{"-queue", "unknown", "-all"}), `unknown queue "unknown"`)
+}
+
+func TestReplayDeadOutboxTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	inspector := mockwk.NewMockTaskInspector(ctrl)
+
+	inspector.EXPECT().RunTask(worker.EmailQueue, "task-1").Times(1).Return(asynq.ErrTaskNotFound)
+	store.EXPECT().
+		ReviveOutboxTask(gomock.Any(), gomock.Eq(db.ReviveOutboxTaskParams{Queue: worker.EmailQueue, TaskID: "task-1"})).
+		Times(1).
+		Return(int64(1), nil)
+	store.EXPECT().
+		ResolveDeadLetterTask(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil)
+
+	app, out := newTestApp(t, store, inspector, outputTable, "")
+	require.NoError(t, replayTasks(context.Background(), app, []string{"-queue", worker.EmailQueue, "-id", "task-1"}))
+	require.Equal(t, "REPLAYED\ntask-1\n", out.String())
+}
+
+func TestMigrateUp(t *testing.T) {
+	app, out := newTestApp(t, nil, nil, outputJson, "")
+	// the stub database only records versions and starts empty every time it is opened
+	app.config.MigrationUrl = "file://../../db/migration"
+	app.config.DBSource = "stub://"
+
+	require.NoError(t, migrateUp(context.Background(), app, []string{"-dry-run"}))
+	var status migration.Status
+	require.NoError(t, json.Unmarshal(out.Bytes(), &status))
+	require.Zero(t, status.Version)
+	require.NotEmpty(t, status.Pending)
+
+	out.Reset()
+	require.NoError(t, migrateUp(context.Background(), app, nil))
+	require.NoError(t, json.Unmarshal(out.Bytes(), &status))
+	require.Equal(t, status.Latest, status.Version)
+	require.Empty(t, status.Pending)
+
+	require.EqualError(t, migrateForce(context.Background(), app, nil), "migrate force: missing version")
+}
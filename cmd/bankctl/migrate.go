@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/kwalter26/udemy-simplebank/migration"
+	"strconv"
+	"strings"
+)
+
+func printMigrationStatus(app *app, status migration.Status) error {
+	pending := make([]string, 0, len(status.Pending))
+	for _, version := range status.Pending {
+		pending = append(pending, strconv.FormatUint(uint64(version), 10))
+	}
+	return app.printer.print(status, []string{"VERSION", "LATEST", "DIRTY", "PENDING"}, [][]string{{
+		strconv.FormatUint(uint64(status.Version), 10),
+		strconv.FormatUint(uint64(status.Latest), 10),
+		strconv.FormatBool(status.Dirty),
+		strings.Join(pending, ","),
+	}})
+}
+
+// runMigration opens the migrations of the config, applies change and prints the status the schema ends up at.
+// The status is printed even when change fails, so that a dirty version shows up.
+func runMigration(app *app, change func(runner *migration.Runner) (migration.Status, error)) error {
+	runner, err := migration.NewRunner(app.config.MigrationUrl, app.config.DBSource)
+	if err != nil {
+		return err
+	}
+	defer runner.Close()
+
+	status, err := change(runner)
+	if printErr := printMigrationStatus(app, status); err == nil {
+		err = printErr
+	}
+	return err
+}
+
+// migrateUp applies the pending migrations. With -dry-run it only lists them.
+func migrateUp(ctx context.Context, app *app, args []string) error {
+	flags := flag.NewFlagSet("migrate up", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "list the pending migrations without applying them")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return errors.New("migrate up: takes no arguments")
+	}
+	return runMigration(app, func(runner *migration.Runner) (migration.Status, error) {
+		if *dryRun {
+			return runner.Status()
+		}
+		return runner.Up()
+	})
+}
+
+// migrateDown rolls back the given number of migrations, one by default.
+func migrateDown(ctx context.Context, app *app, args []string) error {
+	steps := 1
+	if len(args) > 1 {
+		return errors.New("migrate down: takes at most one argument")
+	}
+	if len(args) == 1 {
+		var err error
+		steps, err = strconv.Atoi(args[0])
+		if err != nil || steps < 1 {
+			return fmt.Errorf("migrate down: invalid number of steps %q", args[0])
+		}
+	}
+	return runMigration(app, func(runner *migration.Runner) (migration.Status, error) {
+		return runner.Steps(-steps)
+	})
+}
+
+// migrateGoto migrates up or down to the given version.
+func migrateGoto(ctx context.Context, app *app, args []string) error {
+	version, err := parseVersion("migrate goto", args)
+	if err != nil {
+		return err
+	}
+	return runMigration(app, func(runner *migration.Runner) (migration.Status, error) {
+		return runner.Goto(version)
+	})
+}
+
+// migrateForce sets the version of a dirty schema after it was repaired by hand.
+func migrateForce(ctx context.Context, app *app, args []string) error {
+	version, err := parseVersion("migrate force", args)
+	if err != nil {
+		return err
+	}
+	return runMigration(app, func(runner *migration.Runner) (migration.Status, error) {
+		return runner.Force(version)
+	})
+}
+
+func migrateStatus(ctx context.Context, app *app, args []string) error {
+	if len(args) > 0 {
+		return errors.New("migrate status: takes no arguments")
+	}
+	return runMigration(app, func(runner *migration.Runner) (migration.Status, error) {
+		status, err := runner.Status()
+		if err == nil && status.Dirty {
+			err = migration.DirtyError{Version: status.Version}
+		}
+		return status, err
+	})
+}
+
+func parseVersion(name string, args []string) (uint, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("%s: missing version", name)
+	}
+	version, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid version %q", name, args[0])
+	}
+	return uint(version), nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	outputTable = "table"
+	outputJson  = "json"
+)
+
+// printer writes command results as aligned tables for people or as JSON for scripts.
+type printer struct {
+	format string
+	out    io.Writer
+}
+
+func newPrinter(format string, out io.Writer) (printer, error) {
+	if format != outputTable && format != outputJson {
+		return printer{}, fmt.Errorf("unsupported output %q, must be %s or %s", format, outputTable, outputJson)
+	}
+	return printer{format: format, out: out}, nil
+}
+
+// print writes value as JSON, or the rows under header as a table.
+func (p printer) print(value interface{}, header []string, rows [][]string) error {
+	if p.format == outputJson {
+		return p.json(value)
+	}
+	return p.table(header, rows)
+}
+
+func (p printer) json(value interface{}) error {
+	encoder := json.NewEncoder(p.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func (p printer) table(header []string, rows [][]string) error {
+	writer := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
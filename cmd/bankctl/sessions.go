@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"strconv"
+	"time"
+)
+
+// sessionOutput is a session without its refresh token.
+type sessionOutput struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	UserAgent string    `json:"user_agent"`
+	ClientIp  string    `json:"client_ip"`
+	IsBlocked bool      `json:"is_blocked"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// blockSessions blocks all sessions of a user, or only the one given with -id.
+func blockSessions(ctx context.Context, app *app, args []string) error {
+	flags := flag.NewFlagSet("sessions block", flag.ContinueOnError)
+	username := flags.String("username", "", "owner of the sessions")
+	id := flags.String("id", "", "only block the session with this id")
+	if err := parseFlags(flags, args, "username"); err != nil {
+		return err
+	}
+
+	arg := db.BlockSessionsParams{Username: *username}
+	if *id != "" {
+		sessionID, err := uuid.Parse(*id)
+		if err != nil {
+			return fmt.Errorf("invalid session id: %w", err)
+		}
+		arg.ID = uuid.NullUUID{UUID: sessionID, Valid: true}
+	}
+
+	store, err := app.getStore()
+	if err != nil {
+		return err
+	}
+	result, err := store.BlockSessionsTx(ctx, db.BlockSessionsTxParams{
+		BlockSessionsParams: arg,
+		Audit:               app.audit,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot block sessions: %w", err)
+	}
+
+	output := make([]sessionOutput, 0, len(result.Sessions))
+	rows := make([][]string, 0, len(result.Sessions))
+	for _, session := range result.Sessions {
+		output = append(output, sessionOutput{
+			ID:        session.ID,
+			Username:  session.Username,
+			UserAgent: session.UserAgent,
+			ClientIp:  session.ClientIp,
+			IsBlocked: session.IsBlocked,
+			ExpiresAt: session.ExpiresAt,
+		})
+		rows = append(rows, []string{session.ID.String(), session.Username, session.ClientIp, session.UserAgent, strconv.FormatBool(session.IsBlocked), formatTime(session.ExpiresAt)})
+	}
+	return app.printer.print(output, []string{"ID", "USERNAME", "CLIENT IP", "USER AGENT", "BLOCKED", "EXPIRES AT"}, rows)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/hibiken/asynq"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"github.com/kwalter26/udemy-simplebank/worker"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// replayPageSize is how many dead tasks replay -all reads from redis at a time.
+const replayPageSize = 100
+
+// taskOutput is a failed task with its payload redacted.
+type taskOutput struct {
+	ID           string    `json:"id"`
+	Queue        string    `json:"queue"`
+	Type         string    `json:"type"`
+	Payload      string    `json:"payload"`
+	State        string    `json:"state"`
+	Retried      int       `json:"retried"`
+	MaxRetry     int       `json:"max_retry"`
+	LastError    string    `json:"last_error"`
+	LastFailedAt time.Time `json:"last_failed_at"`
+}
+
+func listTasks(ctx context.Context, app *app, args []string) error {
+	flags := flag.NewFlagSet("tasks list", flag.ContinueOnError)
+	queue := flags.String("queue", "", "queue of the tasks")
+	state := flags.String("state", "archived", "archived tasks ran out of retries, retry tasks wait for their next attempt")
+	pageSize := flags.Int("page-size", 50, "number of tasks")
+	page := flags.Int("page", 1, "page of tasks, starting at 1")
+	if err := parseFlags(flags, args, "queue"); err != nil {
+		return err
+	}
+	if err := validateQueue(*queue); err != nil {
+		return err
+	}
+	taskState, err := parseTaskState(*state)
+	if err != nil {
+		return err
+	}
+	if *pageSize < 1 || *page < 1 {
+		return errors.New("tasks list: -page-size and -page must be positive")
+	}
+
+	inspector, err := app.getInspector()
+	if err != nil {
+		return err
+	}
+	tasks, err := inspector.ListFailedTasks(*queue, taskState, *pageSize, *page)
+	if err != nil {
+		return fmt.Errorf("cannot list tasks: %w", err)
+	}
+
+	output := make([]taskOutput, 0, len(tasks))
+	rows := make([][]string, 0, len(tasks))
+	for _, task := range tasks {
+		output = append(output, taskOutput{
+			ID:           task.ID,
+			Queue:        task.Queue,
+			Type:         task.Type,
+			Payload:      string(logging.RedactJSON(task.Payload)),
+			State:        task.State.String(),
+			Retried:      task.Retried,
+			MaxRetry:     task.MaxRetry,
+			LastError:    task.LastErr,
+			LastFailedAt: task.LastFailedAt,
+		})
+		rows = append(rows, []string{task.ID, task.Type, strconv.Itoa(task.Retried) + "/" + strconv.Itoa(task.MaxRetry), formatTime(task.LastFailedAt), task.LastErr})
+	}
+	return app.printer.print(output, []string{"ID", "TYPE", "RETRIED", "LAST FAILED AT", "LAST ERROR"}, rows)
+}
+
+// replayTasks moves dead tasks back to pending and marks their dead letters as retried.
+func replayTasks(ctx context.Context, app *app, args []string) error {
+	flags := flag.NewFlagSet("tasks replay", flag.ContinueOnError)
+	queue := flags.String("queue", "", "queue of the tasks")
+	id := flags.String("id", "", "id of the task to replay")
+	all := flags.Bool("all", false, "replay every dead task of the queue")
+	if err := parseFlags(flags, args, "queue"); err != nil {
+		return err
+	}
+	if err := validateQueue(*queue); err != nil {
+		return err
+	}
+	if (*id == "") == !*all {
+		return errors.New("tasks replay: need either -id or -all")
+	}
+
+	inspector, err := app.getInspector()
+	if err != nil {
+		return err
+	}
+	store, err := app.getStore()
+	if err != nil {
+		return err
+	}
+
+	taskIDs := []string{*id}
+	if *all {
+		taskIDs = nil
+	}
+	replayed := []string{}
+	for {
+		if *all {
+			// replayed tasks leave the archive, so the first page always holds the next ones
+			tasks, err := inspector.ListFailedTasks(*queue, asynq.TaskStateArchived, replayPageSize, 1)
+			if err != nil {
+				return fmt.Errorf("cannot list tasks: %w", err)
+			}
+			taskIDs = taskIDs[:0]
+			for _, task := range tasks {
+				taskIDs = append(taskIDs, task.ID)
+			}
+		}
+		if len(taskIDs) == 0 {
+			break
+		}
+
+		for _, taskID := range taskIDs {
+			err = inspector.RunTask(*queue, taskID)
+			if errors.Is(err, asynq.ErrQueueNotFound) || errors.Is(err, asynq.ErrTaskNotFound) {
+				// tasks the outbox relay gave up on never reached the queue, they are published again instead
+				var revived int64
+				revived, err = store.ReviveOutboxTask(ctx, db.ReviveOutboxTaskParams{Queue: *queue, TaskID: taskID})
+				if err == nil && revived == 0 {
+					err = asynq.ErrTaskNotFound
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("cannot replay task %s: %w", taskID, err)
+			}
+			replayed = append(replayed, taskID)
+
+			err = store.ResolveDeadLetterTask(ctx, db.ResolveDeadLetterTaskParams{
+				Queue:      *queue,
+				TaskID:     taskID,
+				Resolution: db.DeadLetterTaskRetried,
+			})
+			if err != nil {
+				return fmt.Errorf("task %s was replayed but its dead letter was not resolved: %w", taskID, err)
+			}
+		}
+		if !*all {
+			break
+		}
+	}
+
+	rows := make([][]string, 0, len(replayed))
+	for _, taskID := range replayed {
+		rows = append(rows, []string{taskID})
+	}
+	return app.printer.print(struct {
+		Queue    string   `json:"queue"`
+		Replayed []string `json:"replayed"`
+	}{*queue, replayed}, []string{"REPLAYED"}, rows)
+}
+
+func validateQueue(queue string) error {
+	for _, known := range worker.Queues() {
+		if queue == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown queue %q, must be one of %s", queue, strings.Join(worker.Queues(), ", "))
+}
+
+func parseTaskState(state string) (asynq.TaskState, error) {
+	switch state {
+	case "archived":
+		return asynq.TaskStateArchived, nil
+	case "retry":
+		return asynq.TaskStateRetry, nil
+	default:
+		return 0, fmt.Errorf("invalid state %q, must be archived or retry", state)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/kwalter26/udemy-simplebank/val"
+	"github.com/kwalter26/udemy-simplebank/worker"
+	"strings"
+	"time"
+)
+
+// userOutput is a user without its password hash.
+type userOutput struct {
+	Username        string    `json:"username"`
+	FullName        string    `json:"full_name"`
+	Email           string    `json:"email"`
+	Role            string    `json:"role"`
+	IsEmailVerified bool      `json:"is_email_verified"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func printUser(app *app, user db.User) error {
+	output := userOutput{
+		Username:        user.Username,
+		FullName:        user.FullName,
+		Email:           user.Email,
+		Role:            user.Role,
+		IsEmailVerified: user.IsEmailVerified,
+		CreatedAt:       user.CreatedAt,
+	}
+	return app.printer.print(output,
+		[]string{"USERNAME", "FULL NAME", "EMAIL", "ROLE", "VERIFIED", "CREATED AT"},
+		[][]string{{user.Username, user.FullName, user.Email, user.Role, fmt.Sprint(user.IsEmailVerified), formatTime(user.CreatedAt)}},
+	)
+}
+
+// createUser creates a user like the CreateUser RPC does, including the verification email. The password is read
+// from stdin unless given with -password, so that it does not end up in the shell history.
+func createUser(ctx context.Context, app *app, args []string) error {
+	flags := flag.NewFlagSet("users create", flag.ContinueOnError)
+	username := flags.String("username", "", "username")
+	fullName := flags.String("full-name", "", "full name")
+	email := flags.String("email", "", "email address")
+	password := flags.String("password", "", "password, read from stdin when empty")
+	role := flags.String("role", util.DepositorRole, "role, depositor or banker")
+	if err := parseFlags(flags, args, "username", "full-name", "email"); err != nil {
+		return err
+	}
+
+	if *password == "" {
+		var err error
+		*password, err = readPassword(app)
+		if err != nil {
+			return err
+		}
+	}
+	if err := validateUser(app.config, *username, *fullName, *email, *password, *role); err != nil {
+		return err
+	}
+
+	hasher, err := util.NewPasswordHasher(app.config)
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := hasher.Hash(*password)
+	if err != nil {
+		return fmt.Errorf("cannot hash password: %w", err)
+	}
+
+	store, err := app.getStore()
+	if err != nil {
+		return err
+	}
+	result, err := store.CreateUserTx(ctx, db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       *username,
+			FullName:       *fullName,
+			HashedPassword: hashedPassword,
+			Email:          *email,
+		},
+		Role:  *role,
+		Audit: app.audit,
+		AfterCreate: func(user db.User) ([]db.CreateOutboxTaskParams, error) {
+			task, err := worker.NewSendVerifyEmailOutboxTask(ctx, &worker.PayloadSendVerifyEmail{Username: user.Username}, worker.OutboxOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return []db.CreateOutboxTaskParams{task}, nil
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create user: %w", err)
+	}
+
+	return printUser(app, result.User)
+}
+
+func setUserRole(ctx context.Context, app *app, args []string) error {
+	flags := flag.NewFlagSet("users set-role", flag.ContinueOnError)
+	username := flags.String("username", "", "username")
+	role := flags.String("role", "", "role, depositor or banker")
+	if err := parseFlags(flags, args, "username", "role"); err != nil {
+		return err
+	}
+	if err := validateRole(*role); err != nil {
+		return err
+	}
+
+	store, err := app.getStore()
+	if err != nil {
+		return err
+	}
+	user, err := updateRole(ctx, app, store, *username, *role)
+	if err != nil {
+		return err
+	}
+	return printUser(app, user)
+}
+
+func updateRole(ctx context.Context, app *app, store db.Store, username string, role string) (db.User, error) {
+	result, err := store.UpdateUserTx(ctx, db.UpdateUserTxParams{
+		UpdateUserParams: db.UpdateUserParams{
+			Username: username,
+			Role:     sql.NullString{String: role, Valid: true},
+		},
+		Audit: app.audit,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.User{}, fmt.Errorf("user %s not found", username)
+		}
+		return db.User{}, fmt.Errorf("cannot set role: %w", err)
+	}
+	return result.User, nil
+}
+
+func validateUser(config util.Config, username, fullName, email, password, role string) error {
+	if err := val.ValidateUsername(username); err != nil {
+		return fmt.Errorf("invalid username: %w", err)
+	}
+	if err := val.ValidateFullName(fullName); err != nil {
+		return fmt.Errorf("invalid full name: %w", err)
+	}
+	if err := val.ValidateEmail(email); err != nil {
+		return fmt.Errorf("invalid email: %w", err)
+	}
+	if err := val.NewPasswordPolicy(config).Validate(password); err != nil {
+		return fmt.Errorf("invalid password: %w", err)
+	}
+	return validateRole(role)
+}
+
+func validateRole(role string) error {
+	if role != util.DepositorRole && role != util.BankerRole {
+		return fmt.Errorf("invalid role %q, must be %s or %s", role, util.DepositorRole, util.BankerRole)
+	}
+	return nil
+}
+
+func readPassword(app *app) (string, error) {
+	line, err := bufio.NewReader(app.stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("missing -password and no password on stdin")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
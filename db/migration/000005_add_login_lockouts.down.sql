@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "login_lockouts";
+
+ALTER TABLE "users"
+    DROP COLUMN "role";
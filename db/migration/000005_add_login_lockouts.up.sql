@@ -0,0 +1,14 @@
+CREATE TABLE "login_lockouts"
+(
+    "kind"           varchar     NOT NULL,
+    "key"            varchar     NOT NULL,
+    "failed_count"   integer     NOT NULL DEFAULT 0,
+    "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+    "locked_until"   timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+    PRIMARY KEY ("kind", "key")
+);
+
+COMMENT ON COLUMN "login_lockouts"."kind" IS 'username or client_ip';
+
+ALTER TABLE "users"
+    ADD COLUMN "role" varchar NOT NULL DEFAULT 'depositor';
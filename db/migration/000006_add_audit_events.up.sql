@@ -0,0 +1,31 @@
+CREATE TABLE "audit_events"
+(
+    "id"         bigserial PRIMARY KEY,
+    "actor"      varchar     NOT NULL,
+    "action"     varchar     NOT NULL,
+    "target"     varchar     NOT NULL,
+    "client_ip"  varchar     NOT NULL DEFAULT '',
+    "user_agent" varchar     NOT NULL DEFAULT '',
+    "before"     jsonb       NOT NULL DEFAULT '{}',
+    "after"      jsonb       NOT NULL DEFAULT '{}',
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "audit_events" ("actor", "created_at");
+
+CREATE INDEX ON "audit_events" ("target", "created_at");
+
+COMMENT ON COLUMN "audit_events"."target" IS 'kind and id of the affected row, e.g. user:alice';
+
+CREATE FUNCTION "audit_events_append_only"() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_events_append_only"
+    BEFORE UPDATE OR DELETE
+    ON "audit_events"
+    FOR EACH ROW
+EXECUTE FUNCTION "audit_events_append_only"();
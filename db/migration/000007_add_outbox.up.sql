@@ -0,0 +1,20 @@
+CREATE TABLE "outbox"
+(
+    "id"           bigserial PRIMARY KEY,
+    "task_id"      varchar     NOT NULL,
+    "task_type"    varchar     NOT NULL,
+    "payload"      bytea       NOT NULL,
+    "queue"        varchar     NOT NULL,
+    "max_retry"    integer     NOT NULL,
+    "process_at"   timestamptz NOT NULL DEFAULT (now()),
+    "attempts"     integer     NOT NULL DEFAULT 0,
+    "last_error"   varchar     NOT NULL DEFAULT '',
+    "created_at"   timestamptz NOT NULL DEFAULT (now()),
+    "published_at" timestamptz
+);
+
+CREATE UNIQUE INDEX ON "outbox" ("task_id");
+
+CREATE INDEX ON "outbox" ("id") WHERE "published_at" IS NULL;
+
+COMMENT ON COLUMN "outbox"."task_id" IS 'asynq task id, used to drop duplicates when a row is published twice';
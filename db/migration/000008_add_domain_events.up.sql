@@ -0,0 +1,21 @@
+CREATE TABLE "domain_events"
+(
+    "id"         bigserial PRIMARY KEY,
+    "owner"      varchar     NOT NULL,
+    "account_id" bigint,
+    "event_type" varchar     NOT NULL,
+    "payload"    jsonb       NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "domain_events" ("owner", "id");
+
+COMMENT ON COLUMN "domain_events"."id" IS 'offset subscribers resume from';
+
+COMMENT ON COLUMN "domain_events"."owner" IS 'user allowed to see the event';
+
+ALTER TABLE "domain_events"
+    ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "domain_events"
+    ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
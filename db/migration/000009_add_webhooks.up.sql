@@ -0,0 +1,41 @@
+CREATE TABLE "webhooks"
+(
+    "id"          bigserial PRIMARY KEY,
+    "owner"       varchar     NOT NULL,
+    "url"         varchar     NOT NULL,
+    "event_types" varchar[]   NOT NULL,
+    "secret"      varchar     NOT NULL,
+    "created_at"  timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_deliveries"
+(
+    "id"            bigserial PRIMARY KEY,
+    "webhook_id"    bigint      NOT NULL,
+    "event_id"      bigint      NOT NULL,
+    "event_type"    varchar     NOT NULL,
+    "payload"       jsonb       NOT NULL,
+    "status"        varchar     NOT NULL DEFAULT 'pending',
+    "attempts"      integer     NOT NULL DEFAULT 0,
+    "response_code" integer     NOT NULL DEFAULT 0,
+    "last_error"    varchar     NOT NULL DEFAULT '',
+    "created_at"    timestamptz NOT NULL DEFAULT (now()),
+    "updated_at"    timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "webhooks" ("owner");
+
+CREATE INDEX ON "webhook_deliveries" ("webhook_id", "id");
+
+COMMENT ON COLUMN "webhooks"."secret" IS 'HMAC key the payloads are signed with';
+
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending, succeeded, failed or dead';
+
+ALTER TABLE "webhooks"
+    ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "webhook_deliveries"
+    ADD FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("id");
+
+ALTER TABLE "webhook_deliveries"
+    ADD FOREIGN KEY ("event_id") REFERENCES "domain_events" ("id");
@@ -0,0 +1,18 @@
+CREATE TABLE "dead_letter_tasks"
+(
+    "id"          bigserial PRIMARY KEY,
+    "task_id"     varchar     NOT NULL,
+    "task_type"   varchar     NOT NULL,
+    "queue"       varchar     NOT NULL,
+    "payload"     bytea       NOT NULL,
+    "last_error"  varchar     NOT NULL DEFAULT '',
+    "retried"     integer     NOT NULL DEFAULT 0,
+    "max_retry"   integer     NOT NULL DEFAULT 0,
+    "failed_at"   timestamptz NOT NULL DEFAULT (now()),
+    "resolution"  varchar     NOT NULL DEFAULT '',
+    "resolved_at" timestamptz
+);
+
+CREATE UNIQUE INDEX ON "dead_letter_tasks" ("queue", "task_id");
+
+COMMENT ON COLUMN "dead_letter_tasks"."resolution" IS 'empty while unresolved, then retried or deleted';
@@ -0,0 +1,4 @@
+ALTER TABLE "accounts"
+    ADD COLUMN "frozen" boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "accounts"."frozen" IS 'frozen accounts can neither send nor receive transfers';
@@ -0,0 +1,22 @@
+CREATE TABLE "account_statements"
+(
+    "id"              bigserial PRIMARY KEY,
+    "account_id"      bigint      NOT NULL,
+    "period_start"    timestamptz NOT NULL,
+    "period_end"      timestamptz NOT NULL,
+    "blob_key"        varchar     NOT NULL,
+    "size_bytes"      bigint      NOT NULL,
+    "opening_balance" bigint      NOT NULL,
+    "closing_balance" bigint      NOT NULL,
+    "created_at"      timestamptz NOT NULL DEFAULT (now()),
+    "notified_at"     timestamptz
+);
+
+CREATE UNIQUE INDEX ON "account_statements" ("account_id", "period_start");
+
+ALTER TABLE "account_statements"
+    ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+COMMENT ON COLUMN "account_statements"."blob_key" IS 'key of the PDF in the blob store';
+
+COMMENT ON COLUMN "account_statements"."notified_at" IS 'when the owner was emailed about the statement';
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS "interest_accruals";
+
+-- the ledger user and its accounts are kept: entries and transfers refer to them
+ALTER TABLE "accounts"
+    DROP CONSTRAINT IF EXISTS "owner_currency_product_key";
+
+ALTER TABLE "accounts"
+    DROP COLUMN IF EXISTS "product";
+
+ALTER TABLE "accounts"
+    ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
+
+DROP TABLE IF EXISTS "account_products";
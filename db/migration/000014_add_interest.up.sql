@@ -0,0 +1,63 @@
+CREATE TABLE "account_products"
+(
+    "code"            varchar PRIMARY KEY,
+    "name"            varchar     NOT NULL,
+    "annual_rate_bps" integer     NOT NULL DEFAULT 0,
+    "compounding"     varchar     NOT NULL DEFAULT 'monthly',
+    "internal"        boolean     NOT NULL DEFAULT false,
+    "created_at"      timestamptz NOT NULL DEFAULT (now())
+);
+
+INSERT INTO "account_products" ("code", "name", "annual_rate_bps", "compounding", "internal")
+VALUES ('checking', 'Checking', 0, 'monthly', false),
+       ('savings', 'Savings', 250, 'daily', false),
+       ('interest_expense', 'Interest expense', 0, 'monthly', true);
+
+ALTER TABLE "accounts"
+    ADD COLUMN "product" varchar NOT NULL DEFAULT 'checking';
+
+ALTER TABLE "accounts"
+    DROP CONSTRAINT "owner_currency_key";
+
+ALTER TABLE "accounts"
+    ADD CONSTRAINT "owner_currency_product_key" UNIQUE ("owner", "currency", "product");
+
+-- the bank owns its ledger accounts through a user that cannot log in, and whose name cannot be registered
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email", "is_email_verified")
+VALUES ('simplebank.ledger', '', 'Simple Bank', 'ledger@simplebank.invalid', true);
+
+CREATE TABLE "interest_accruals"
+(
+    "account_id"      bigint      NOT NULL,
+    "accrual_date"    date        NOT NULL,
+    "balance"         bigint      NOT NULL,
+    "annual_rate_bps" integer     NOT NULL,
+    "compounding"     varchar     NOT NULL,
+    "amount_micros"   bigint      NOT NULL,
+    "transfer_id"     bigint,
+    "created_at"      timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("account_id", "accrual_date")
+);
+
+CREATE INDEX ON "interest_accruals" ("account_id") WHERE "transfer_id" IS NULL;
+
+ALTER TABLE "accounts"
+    ADD FOREIGN KEY ("product") REFERENCES "account_products" ("code");
+
+ALTER TABLE "interest_accruals"
+    ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_accruals"
+    ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+COMMENT ON COLUMN "account_products"."annual_rate_bps" IS 'yearly interest rate in basis points';
+
+COMMENT ON COLUMN "account_products"."compounding" IS 'daily or monthly';
+
+COMMENT ON COLUMN "account_products"."internal" IS 'internal products are the ledger accounts of the bank';
+
+COMMENT ON COLUMN "interest_accruals"."balance" IS 'balance at the end of the day';
+
+COMMENT ON COLUMN "interest_accruals"."amount_micros" IS 'interest of the day in millionths of the currency unit';
+
+COMMENT ON COLUMN "interest_accruals"."transfer_id" IS 'transfer that posted the interest, null until posted';
@@ -0,0 +1,14 @@
+ALTER TABLE "entries"
+    DROP COLUMN IF EXISTS "is_fee";
+
+ALTER TABLE "transfers"
+    DROP COLUMN IF EXISTS "fee_rule_id";
+
+ALTER TABLE "transfers"
+    DROP COLUMN IF EXISTS "fee";
+
+DROP INDEX IF EXISTS "transfers_from_account_id_created_at_idx";
+
+DROP TABLE IF EXISTS "fee_rules";
+
+-- the fee revenue product is kept: the ledger accounts of the bank refer to it
@@ -0,0 +1,44 @@
+CREATE TABLE "fee_rules"
+(
+    "id"                       bigserial PRIMARY KEY,
+    "currency"                 varchar     NOT NULL,
+    "kind"                     varchar     NOT NULL,
+    "flat_amount"              bigint      NOT NULL DEFAULT 0,
+    "rate_bps"                 integer     NOT NULL DEFAULT 0,
+    "min_amount"               bigint      NOT NULL DEFAULT 0,
+    "max_amount"               bigint      NOT NULL DEFAULT 0,
+    "free_transfers_per_month" integer     NOT NULL DEFAULT 0,
+    "active"                   boolean     NOT NULL DEFAULT true,
+    "created_at"               timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "fee_rules" ("currency") WHERE "active";
+
+INSERT INTO "account_products" ("code", "name", "annual_rate_bps", "compounding", "internal")
+VALUES ('fee_revenue', 'Fee revenue', 0, 'monthly', true);
+
+ALTER TABLE "transfers"
+    ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "transfers"
+    ADD COLUMN "fee_rule_id" bigint;
+
+ALTER TABLE "entries"
+    ADD COLUMN "is_fee" boolean NOT NULL DEFAULT false;
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+ALTER TABLE "transfers"
+    ADD FOREIGN KEY ("fee_rule_id") REFERENCES "fee_rules" ("id");
+
+COMMENT ON COLUMN "fee_rules"."kind" IS 'flat or percentage';
+
+COMMENT ON COLUMN "fee_rules"."rate_bps" IS 'fee of a percentage rule in basis points of the amount';
+
+COMMENT ON COLUMN "fee_rules"."max_amount" IS '0 means no maximum';
+
+COMMENT ON COLUMN "fee_rules"."free_transfers_per_month" IS 'transfers of a calendar month that are not charged';
+
+COMMENT ON COLUMN "transfers"."fee" IS 'charged to the source account on top of the amount';
+
+COMMENT ON COLUMN "entries"."is_fee" IS 'entry of the fee of its transfer';
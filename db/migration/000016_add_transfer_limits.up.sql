@@ -0,0 +1,35 @@
+CREATE TABLE "transfer_limits"
+(
+    "id"               bigserial PRIMARY KEY,
+    "currency"         varchar     NOT NULL,
+    "username"         varchar,
+    "account_id"       bigint,
+    "per_transfer_max" bigint      NOT NULL DEFAULT 0,
+    "daily_max"        bigint      NOT NULL DEFAULT 0,
+    "monthly_max"      bigint      NOT NULL DEFAULT 0,
+    "updated_at"       timestamptz NOT NULL DEFAULT (now()),
+    CONSTRAINT "transfer_limits_scope_check" CHECK ("username" IS NULL OR "account_id" IS NULL)
+);
+
+CREATE UNIQUE INDEX ON "transfer_limits" ("currency") WHERE "username" IS NULL AND "account_id" IS NULL;
+
+CREATE UNIQUE INDEX ON "transfer_limits" ("username", "currency") WHERE "username" IS NOT NULL;
+
+CREATE UNIQUE INDEX ON "transfer_limits" ("account_id") WHERE "account_id" IS NOT NULL;
+
+INSERT INTO "transfer_limits" ("currency", "per_transfer_max", "daily_max", "monthly_max")
+VALUES ('USD', 1000000, 5000000, 20000000),
+       ('EUR', 1000000, 5000000, 20000000),
+       ('CAD', 1000000, 5000000, 20000000);
+
+ALTER TABLE "transfer_limits"
+    ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_limits"
+    ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+COMMENT ON COLUMN "transfer_limits"."username" IS 'user the limits replace the defaults of the currency for, null for the defaults';
+
+COMMENT ON COLUMN "transfer_limits"."account_id" IS 'account the limits apply to on top of the limits of its owner';
+
+COMMENT ON COLUMN "transfer_limits"."per_transfer_max" IS '0 means no limit, as for daily_max and monthly_max';
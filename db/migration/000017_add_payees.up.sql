@@ -0,0 +1,23 @@
+CREATE TABLE "payees"
+(
+    "id"                     bigserial PRIMARY KEY,
+    "owner"                  varchar     NOT NULL,
+    "nickname"               varchar     NOT NULL,
+    "account_id"             bigint      NOT NULL,
+    "currency"               varchar     NOT NULL,
+    "cooling_off_until"      timestamptz,
+    "cooling_off_max_amount" bigint      NOT NULL DEFAULT 0,
+    "created_at"             timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "payees" ("owner", "nickname");
+
+CREATE UNIQUE INDEX ON "payees" ("owner", "account_id");
+
+ALTER TABLE "payees"
+    ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "payees"
+    ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+COMMENT ON COLUMN "payees"."cooling_off_until" IS 'until then transfers to the payee cannot exceed cooling_off_max_amount, null for no cooling-off';
@@ -0,0 +1,6 @@
+-- row triggers do not fire on TRUNCATE, which would otherwise empty the table at once
+CREATE TRIGGER "audit_events_no_truncate"
+    BEFORE TRUNCATE
+    ON "audit_events"
+    FOR EACH STATEMENT
+EXECUTE FUNCTION "audit_events_append_only"();
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "outbox_id_idx";
+
+ALTER TABLE "outbox" DROP COLUMN IF EXISTS "dead_at";
+
+CREATE INDEX ON "outbox" ("id") WHERE "published_at" IS NULL;
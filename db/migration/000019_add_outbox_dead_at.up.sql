@@ -0,0 +1,7 @@
+ALTER TABLE "outbox" ADD COLUMN "dead_at" timestamptz;
+
+DROP INDEX IF EXISTS "outbox_id_idx";
+
+CREATE INDEX ON "outbox" ("id") WHERE "published_at" IS NULL AND "dead_at" IS NULL;
+
+COMMENT ON COLUMN "outbox"."dead_at" IS 'set when the relay gave up publishing the task, which then lives on in dead_letter_tasks';
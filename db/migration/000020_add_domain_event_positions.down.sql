@@ -0,0 +1,3 @@
+ALTER TABLE "domain_events" DROP COLUMN IF EXISTS "position";
+
+COMMENT ON COLUMN "domain_events"."id" IS 'offset subscribers resume from';
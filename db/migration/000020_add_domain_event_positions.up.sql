@@ -0,0 +1,14 @@
+ALTER TABLE "domain_events" ADD COLUMN "position" bigint;
+
+-- every event committed so far is final, so its id is a valid position
+UPDATE "domain_events" SET "position" = "id";
+
+CREATE UNIQUE INDEX ON "domain_events" ("position");
+
+CREATE INDEX ON "domain_events" ("owner", "position");
+
+CREATE INDEX ON "domain_events" ("id") WHERE "position" IS NULL;
+
+COMMENT ON COLUMN "domain_events"."id" IS NULL;
+
+COMMENT ON COLUMN "domain_events"."position" IS 'offset subscribers resume from, given in commit order once the event is committed';
@@ -0,0 +1,7 @@
+ALTER TABLE "interest_accruals" ADD COLUMN "kind" varchar NOT NULL DEFAULT 'daily';
+
+ALTER TABLE "interest_accruals" DROP CONSTRAINT "interest_accruals_pkey";
+
+ALTER TABLE "interest_accruals" ADD PRIMARY KEY ("account_id", "accrual_date", "kind");
+
+COMMENT ON COLUMN "interest_accruals"."kind" IS 'daily, or carry for the rounding remainder of the previous posting';
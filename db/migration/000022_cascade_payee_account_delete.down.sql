@@ -0,0 +1,8 @@
+ALTER TABLE "payees" DROP CONSTRAINT IF EXISTS "payees_account_id_fkey";
+
+ALTER TABLE "payees"
+    ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+COMMENT ON COLUMN "payees"."account_id" IS NULL;
+
+COMMENT ON COLUMN "payees"."cooling_off_until" IS 'until then transfers to the payee cannot exceed cooling_off_max_amount, null for no cooling-off';
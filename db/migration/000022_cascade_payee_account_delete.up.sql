@@ -0,0 +1,9 @@
+-- a payee of a deleted account can no longer be paid, so it goes with the account instead of blocking its deletion
+ALTER TABLE "payees" DROP CONSTRAINT "payees_account_id_fkey";
+
+ALTER TABLE "payees"
+    ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+COMMENT ON COLUMN "payees"."account_id" IS 'the payee is deleted with the account';
+
+COMMENT ON COLUMN "payees"."cooling_off_until" IS 'until then the transfers to the payee cannot exceed cooling_off_max_amount in total, null for no cooling-off';
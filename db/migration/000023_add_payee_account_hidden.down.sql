@@ -0,0 +1 @@
+ALTER TABLE "payees" DROP COLUMN IF EXISTS "account_hidden";
@@ -0,0 +1,10 @@
+ALTER TABLE "payees" ADD COLUMN "account_hidden" boolean NOT NULL DEFAULT false;
+
+-- payees saved before it was recorded may have been found by username or email, so only accounts of the owner stay shown
+UPDATE "payees" p
+SET "account_hidden" = true
+FROM "accounts" a
+WHERE a.id = p.account_id
+  AND a.owner <> p.owner;
+
+COMMENT ON COLUMN "payees"."account_hidden" IS 'the payee was found by username or email, so its account is not shown to the owner';
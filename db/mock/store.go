@@ -7,6 +7,7 @@ package mockdb
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
@@ -51,300 +52,1522 @@ func (mr *MockStoreMockRecorder) AddAccountBalance(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountBalance", reflect.TypeOf((*MockStore)(nil).AddAccountBalance), arg0, arg1)
 }
 
+// AssignDomainEventPositions mocks base method.
+func (m *MockStore) AssignDomainEventPositions(arg0 context.Context, arg1 int32) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignDomainEventPositions", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignDomainEventPositions indicates an expected call of AssignDomainEventPositions.
+func (mr *MockStoreMockRecorder) AssignDomainEventPositions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignDomainEventPositions", reflect.TypeOf((*MockStore)(nil).AssignDomainEventPositions), arg0, arg1)
+}
+
+// AssignDomainEventPositionsTx mocks base method.
+func (m *MockStore) AssignDomainEventPositionsTx(arg0 context.Context, arg1 db.AssignDomainEventPositionsTxParams) (db.AssignDomainEventPositionsTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignDomainEventPositionsTx", arg0, arg1)
+	ret0, _ := ret[0].(db.AssignDomainEventPositionsTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignDomainEventPositionsTx indicates an expected call of AssignDomainEventPositionsTx.
+func (mr *MockStoreMockRecorder) AssignDomainEventPositionsTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignDomainEventPositionsTx", reflect.TypeOf((*MockStore)(nil).AssignDomainEventPositionsTx), arg0, arg1)
+}
+
+// BatchTransferTx mocks base method.
+func (m *MockStore) BatchTransferTx(arg0 context.Context, arg1 db.BatchTransferTxParams) (db.BatchTransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(db.BatchTransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchTransferTx indicates an expected call of BatchTransferTx.
+func (mr *MockStoreMockRecorder) BatchTransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchTransferTx", reflect.TypeOf((*MockStore)(nil).BatchTransferTx), arg0, arg1)
+}
+
+// BlockSessions mocks base method.
+func (m *MockStore) BlockSessions(arg0 context.Context, arg1 db.BlockSessionsParams) ([]db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSessions", arg0, arg1)
+	ret0, _ := ret[0].([]db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockSessions indicates an expected call of BlockSessions.
+func (mr *MockStoreMockRecorder) BlockSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSessions", reflect.TypeOf((*MockStore)(nil).BlockSessions), arg0, arg1)
+}
+
+// BlockSessionsTx mocks base method.
+func (m *MockStore) BlockSessionsTx(arg0 context.Context, arg1 db.BlockSessionsTxParams) (db.BlockSessionsTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSessionsTx", arg0, arg1)
+	ret0, _ := ret[0].(db.BlockSessionsTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockSessionsTx indicates an expected call of BlockSessionsTx.
+func (mr *MockStoreMockRecorder) BlockSessionsTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSessionsTx", reflect.TypeOf((*MockStore)(nil).BlockSessionsTx), arg0, arg1)
+}
+
+// CountTransfersSince mocks base method.
+func (m *MockStore) CountTransfersSince(arg0 context.Context, arg1 db.CountTransfersSinceParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountTransfersSince", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountTransfersSince indicates an expected call of CountTransfersSince.
+func (mr *MockStoreMockRecorder) CountTransfersSince(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTransfersSince", reflect.TypeOf((*MockStore)(nil).CountTransfersSince), arg0, arg1)
+}
+
 // CreateAccount mocks base method.
 func (m *MockStore) CreateAccount(arg0 context.Context, arg1 db.CreateAccountParams) (db.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateAccount", arg0, arg1)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "CreateAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccount indicates an expected call of CreateAccount.
+func (mr *MockStoreMockRecorder) CreateAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockStore)(nil).CreateAccount), arg0, arg1)
+}
+
+// CreateAccountIfNotExists mocks base method.
+func (m *MockStore) CreateAccountIfNotExists(arg0 context.Context, arg1 db.CreateAccountIfNotExistsParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccountIfNotExists", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAccountIfNotExists indicates an expected call of CreateAccountIfNotExists.
+func (mr *MockStoreMockRecorder) CreateAccountIfNotExists(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccountIfNotExists", reflect.TypeOf((*MockStore)(nil).CreateAccountIfNotExists), arg0, arg1)
+}
+
+// CreateAccountStatement mocks base method.
+func (m *MockStore) CreateAccountStatement(arg0 context.Context, arg1 db.CreateAccountStatementParams) (db.AccountStatement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccountStatement", arg0, arg1)
+	ret0, _ := ret[0].(db.AccountStatement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccountStatement indicates an expected call of CreateAccountStatement.
+func (mr *MockStoreMockRecorder) CreateAccountStatement(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccountStatement", reflect.TypeOf((*MockStore)(nil).CreateAccountStatement), arg0, arg1)
+}
+
+// CreateAccountTx mocks base method.
+func (m *MockStore) CreateAccountTx(arg0 context.Context, arg1 db.CreateAccountTxParams) (db.CreateAccountTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccountTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateAccountTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccountTx indicates an expected call of CreateAccountTx.
+func (mr *MockStoreMockRecorder) CreateAccountTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccountTx", reflect.TypeOf((*MockStore)(nil).CreateAccountTx), arg0, arg1)
+}
+
+// CreateAuditEvent mocks base method.
+func (m *MockStore) CreateAuditEvent(arg0 context.Context, arg1 db.CreateAuditEventParams) (db.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", arg0, arg1)
+	ret0, _ := ret[0].(db.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockStoreMockRecorder) CreateAuditEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockStore)(nil).CreateAuditEvent), arg0, arg1)
+}
+
+// CreateDeadLetterTask mocks base method.
+func (m *MockStore) CreateDeadLetterTask(arg0 context.Context, arg1 db.CreateDeadLetterTaskParams) (db.DeadLetterTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDeadLetterTask", arg0, arg1)
+	ret0, _ := ret[0].(db.DeadLetterTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDeadLetterTask indicates an expected call of CreateDeadLetterTask.
+func (mr *MockStoreMockRecorder) CreateDeadLetterTask(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDeadLetterTask", reflect.TypeOf((*MockStore)(nil).CreateDeadLetterTask), arg0, arg1)
+}
+
+// CreateDomainEvent mocks base method.
+func (m *MockStore) CreateDomainEvent(arg0 context.Context, arg1 db.CreateDomainEventParams) (db.DomainEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDomainEvent", arg0, arg1)
+	ret0, _ := ret[0].(db.DomainEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDomainEvent indicates an expected call of CreateDomainEvent.
+func (mr *MockStoreMockRecorder) CreateDomainEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDomainEvent", reflect.TypeOf((*MockStore)(nil).CreateDomainEvent), arg0, arg1)
+}
+
+// CreateEntry mocks base method.
+func (m *MockStore) CreateEntry(arg0 context.Context, arg1 db.CreateEntryParams) (db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEntry", arg0, arg1)
+	ret0, _ := ret[0].(db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEntry indicates an expected call of CreateEntry.
+func (mr *MockStoreMockRecorder) CreateEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), arg0, arg1)
+}
+
+// CreateFeeRule mocks base method.
+func (m *MockStore) CreateFeeRule(arg0 context.Context, arg1 db.CreateFeeRuleParams) (db.FeeRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFeeRule", arg0, arg1)
+	ret0, _ := ret[0].(db.FeeRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFeeRule indicates an expected call of CreateFeeRule.
+func (mr *MockStoreMockRecorder) CreateFeeRule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFeeRule", reflect.TypeOf((*MockStore)(nil).CreateFeeRule), arg0, arg1)
+}
+
+// CreateInterestAccrual mocks base method.
+func (m *MockStore) CreateInterestAccrual(arg0 context.Context, arg1 db.CreateInterestAccrualParams) (db.InterestAccrual, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInterestAccrual", arg0, arg1)
+	ret0, _ := ret[0].(db.InterestAccrual)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInterestAccrual indicates an expected call of CreateInterestAccrual.
+func (mr *MockStoreMockRecorder) CreateInterestAccrual(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInterestAccrual", reflect.TypeOf((*MockStore)(nil).CreateInterestAccrual), arg0, arg1)
+}
+
+// CreateInterestCarry mocks base method.
+func (m *MockStore) CreateInterestCarry(arg0 context.Context, arg1 db.CreateInterestCarryParams) (db.InterestAccrual, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInterestCarry", arg0, arg1)
+	ret0, _ := ret[0].(db.InterestAccrual)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInterestCarry indicates an expected call of CreateInterestCarry.
+func (mr *MockStoreMockRecorder) CreateInterestCarry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInterestCarry", reflect.TypeOf((*MockStore)(nil).CreateInterestCarry), arg0, arg1)
+}
+
+// CreateOutboxTask mocks base method.
+func (m *MockStore) CreateOutboxTask(arg0 context.Context, arg1 db.CreateOutboxTaskParams) (db.Outbox, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOutboxTask", arg0, arg1)
+	ret0, _ := ret[0].(db.Outbox)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOutboxTask indicates an expected call of CreateOutboxTask.
+func (mr *MockStoreMockRecorder) CreateOutboxTask(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOutboxTask", reflect.TypeOf((*MockStore)(nil).CreateOutboxTask), arg0, arg1)
+}
+
+// CreatePayee mocks base method.
+func (m *MockStore) CreatePayee(arg0 context.Context, arg1 db.CreatePayeeParams) (db.Payee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayee", arg0, arg1)
+	ret0, _ := ret[0].(db.Payee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePayee indicates an expected call of CreatePayee.
+func (mr *MockStoreMockRecorder) CreatePayee(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayee", reflect.TypeOf((*MockStore)(nil).CreatePayee), arg0, arg1)
+}
+
+// CreateSession mocks base method.
+func (m *MockStore) CreateSession(arg0 context.Context, arg1 db.CreateSessionParams) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+}
+
+// CreateSessionTx mocks base method.
+func (m *MockStore) CreateSessionTx(arg0 context.Context, arg1 db.CreateSessionTxParams) (db.CreateSessionTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSessionTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateSessionTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSessionTx indicates an expected call of CreateSessionTx.
+func (mr *MockStoreMockRecorder) CreateSessionTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSessionTx", reflect.TypeOf((*MockStore)(nil).CreateSessionTx), arg0, arg1)
+}
+
+// CreateTransfer mocks base method.
+func (m *MockStore) CreateTransfer(arg0 context.Context, arg1 db.CreateTransferParams) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransfer indicates an expected call of CreateTransfer.
+func (mr *MockStoreMockRecorder) CreateTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), arg0, arg1)
+}
+
+// CreateTransferLimit mocks base method.
+func (m *MockStore) CreateTransferLimit(arg0 context.Context, arg1 db.CreateTransferLimitParams) (db.TransferLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransferLimit", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransferLimit indicates an expected call of CreateTransferLimit.
+func (mr *MockStoreMockRecorder) CreateTransferLimit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransferLimit", reflect.TypeOf((*MockStore)(nil).CreateTransferLimit), arg0, arg1)
+}
+
+// CreateUser mocks base method.
+func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
+}
+
+// CreateUserTx mocks base method.
+func (m *MockStore) CreateUserTx(arg0 context.Context, arg1 db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateUserTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserTx indicates an expected call of CreateUserTx.
+func (mr *MockStoreMockRecorder) CreateUserTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserTx", reflect.TypeOf((*MockStore)(nil).CreateUserTx), arg0, arg1)
+}
+
+// CreateVerifyEmail mocks base method.
+func (m *MockStore) CreateVerifyEmail(arg0 context.Context, arg1 db.CreateVerifyEmailParams) (db.VerifyEmail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVerifyEmail", arg0, arg1)
+	ret0, _ := ret[0].(db.VerifyEmail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateVerifyEmail indicates an expected call of CreateVerifyEmail.
+func (mr *MockStoreMockRecorder) CreateVerifyEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVerifyEmail", reflect.TypeOf((*MockStore)(nil).CreateVerifyEmail), arg0, arg1)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockStore) CreateWebhook(arg0 context.Context, arg1 db.CreateWebhookParams) (db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", arg0, arg1)
+	ret0, _ := ret[0].(db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockStoreMockRecorder) CreateWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockStore)(nil).CreateWebhook), arg0, arg1)
+}
+
+// CreateWebhookDeliveries mocks base method.
+func (m *MockStore) CreateWebhookDeliveries(arg0 context.Context, arg1 db.CreateWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDeliveries", arg0, arg1)
+	ret0, _ := ret[0].([]db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookDeliveries indicates an expected call of CreateWebhookDeliveries.
+func (mr *MockStoreMockRecorder) CreateWebhookDeliveries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).CreateWebhookDeliveries), arg0, arg1)
+}
+
+// DeactivateFeeRules mocks base method.
+func (m *MockStore) DeactivateFeeRules(arg0 context.Context, arg1 string) ([]db.FeeRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeactivateFeeRules", arg0, arg1)
+	ret0, _ := ret[0].([]db.FeeRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeactivateFeeRules indicates an expected call of DeactivateFeeRules.
+func (mr *MockStoreMockRecorder) DeactivateFeeRules(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeactivateFeeRules", reflect.TypeOf((*MockStore)(nil).DeactivateFeeRules), arg0, arg1)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockStore) DeleteAccount(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockStoreMockRecorder) DeleteAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockStore)(nil).DeleteAccount), arg0, arg1)
+}
+
+// DeleteDeadOutboxTask mocks base method.
+func (m *MockStore) DeleteDeadOutboxTask(arg0 context.Context, arg1 db.DeleteDeadOutboxTaskParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeadOutboxTask", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteDeadOutboxTask indicates an expected call of DeleteDeadOutboxTask.
+func (mr *MockStoreMockRecorder) DeleteDeadOutboxTask(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeadOutboxTask", reflect.TypeOf((*MockStore)(nil).DeleteDeadOutboxTask), arg0, arg1)
+}
+
+// DeleteEntry mocks base method.
+func (m *MockStore) DeleteEntry(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEntry", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEntry indicates an expected call of DeleteEntry.
+func (mr *MockStoreMockRecorder) DeleteEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEntry", reflect.TypeOf((*MockStore)(nil).DeleteEntry), arg0, arg1)
+}
+
+// DeletePayee mocks base method.
+func (m *MockStore) DeletePayee(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePayee", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePayee indicates an expected call of DeletePayee.
+func (mr *MockStoreMockRecorder) DeletePayee(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePayee", reflect.TypeOf((*MockStore)(nil).DeletePayee), arg0, arg1)
+}
+
+// DeletePublishedOutboxTasks mocks base method.
+func (m *MockStore) DeletePublishedOutboxTasks(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePublishedOutboxTasks", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePublishedOutboxTasks indicates an expected call of DeletePublishedOutboxTasks.
+func (mr *MockStoreMockRecorder) DeletePublishedOutboxTasks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePublishedOutboxTasks", reflect.TypeOf((*MockStore)(nil).DeletePublishedOutboxTasks), arg0, arg1)
+}
+
+// DeleteTransfer mocks base method.
+func (m *MockStore) DeleteTransfer(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTransfer", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTransfer indicates an expected call of DeleteTransfer.
+func (mr *MockStoreMockRecorder) DeleteTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTransfer", reflect.TypeOf((*MockStore)(nil).DeleteTransfer), arg0, arg1)
+}
+
+// DeleteTransferLimit mocks base method.
+func (m *MockStore) DeleteTransferLimit(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTransferLimit", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTransferLimit indicates an expected call of DeleteTransferLimit.
+func (mr *MockStoreMockRecorder) DeleteTransferLimit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTransferLimit", reflect.TypeOf((*MockStore)(nil).DeleteTransferLimit), arg0, arg1)
+}
+
+// FreezeAccountTx mocks base method.
+func (m *MockStore) FreezeAccountTx(arg0 context.Context, arg1 db.FreezeAccountTxParams) (db.FreezeAccountTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FreezeAccountTx", arg0, arg1)
+	ret0, _ := ret[0].(db.FreezeAccountTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FreezeAccountTx indicates an expected call of FreezeAccountTx.
+func (mr *MockStoreMockRecorder) FreezeAccountTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreezeAccountTx", reflect.TypeOf((*MockStore)(nil).FreezeAccountTx), arg0, arg1)
+}
+
+// GetAccount mocks base method.
+func (m *MockStore) GetAccount(arg0 context.Context, arg1 int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccount indicates an expected call of GetAccount.
+func (mr *MockStoreMockRecorder) GetAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockStore)(nil).GetAccount), arg0, arg1)
+}
+
+// GetAccountByProduct mocks base method.
+func (m *MockStore) GetAccountByProduct(arg0 context.Context, arg1 db.GetAccountByProductParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountByProduct", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountByProduct indicates an expected call of GetAccountByProduct.
+func (mr *MockStoreMockRecorder) GetAccountByProduct(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountByProduct", reflect.TypeOf((*MockStore)(nil).GetAccountByProduct), arg0, arg1)
+}
+
+// GetAccountForUpdate mocks base method.
+func (m *MockStore) GetAccountForUpdate(arg0 context.Context, arg1 int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountForUpdate indicates an expected call of GetAccountForUpdate.
+func (mr *MockStoreMockRecorder) GetAccountForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountForUpdate", reflect.TypeOf((*MockStore)(nil).GetAccountForUpdate), arg0, arg1)
+}
+
+// GetAccountProduct mocks base method.
+func (m *MockStore) GetAccountProduct(arg0 context.Context, arg1 string) (db.AccountProduct, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountProduct", arg0, arg1)
+	ret0, _ := ret[0].(db.AccountProduct)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountProduct indicates an expected call of GetAccountProduct.
+func (mr *MockStoreMockRecorder) GetAccountProduct(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountProduct", reflect.TypeOf((*MockStore)(nil).GetAccountProduct), arg0, arg1)
+}
+
+// GetAccountStatement mocks base method.
+func (m *MockStore) GetAccountStatement(arg0 context.Context, arg1 int64) (db.AccountStatement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountStatement", arg0, arg1)
+	ret0, _ := ret[0].(db.AccountStatement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountStatement indicates an expected call of GetAccountStatement.
+func (mr *MockStoreMockRecorder) GetAccountStatement(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountStatement", reflect.TypeOf((*MockStore)(nil).GetAccountStatement), arg0, arg1)
+}
+
+// GetAccountStatementBalances mocks base method.
+func (m *MockStore) GetAccountStatementBalances(arg0 context.Context, arg1 db.GetAccountStatementBalancesParams) (db.GetAccountStatementBalancesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountStatementBalances", arg0, arg1)
+	ret0, _ := ret[0].(db.GetAccountStatementBalancesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountStatementBalances indicates an expected call of GetAccountStatementBalances.
+func (mr *MockStoreMockRecorder) GetAccountStatementBalances(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountStatementBalances", reflect.TypeOf((*MockStore)(nil).GetAccountStatementBalances), arg0, arg1)
+}
+
+// GetAccountStatementByPeriod mocks base method.
+func (m *MockStore) GetAccountStatementByPeriod(arg0 context.Context, arg1 db.GetAccountStatementByPeriodParams) (db.AccountStatement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountStatementByPeriod", arg0, arg1)
+	ret0, _ := ret[0].(db.AccountStatement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountStatementByPeriod indicates an expected call of GetAccountStatementByPeriod.
+func (mr *MockStoreMockRecorder) GetAccountStatementByPeriod(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountStatementByPeriod", reflect.TypeOf((*MockStore)(nil).GetAccountStatementByPeriod), arg0, arg1)
+}
+
+// GetAccountTransferUsage mocks base method.
+func (m *MockStore) GetAccountTransferUsage(arg0 context.Context, arg1 db.GetAccountTransferUsageParams) (db.GetAccountTransferUsageRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountTransferUsage", arg0, arg1)
+	ret0, _ := ret[0].(db.GetAccountTransferUsageRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountTransferUsage indicates an expected call of GetAccountTransferUsage.
+func (mr *MockStoreMockRecorder) GetAccountTransferUsage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountTransferUsage", reflect.TypeOf((*MockStore)(nil).GetAccountTransferUsage), arg0, arg1)
+}
+
+// GetActiveFeeRule mocks base method.
+func (m *MockStore) GetActiveFeeRule(arg0 context.Context, arg1 string) (db.FeeRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveFeeRule", arg0, arg1)
+	ret0, _ := ret[0].(db.FeeRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveFeeRule indicates an expected call of GetActiveFeeRule.
+func (mr *MockStoreMockRecorder) GetActiveFeeRule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveFeeRule", reflect.TypeOf((*MockStore)(nil).GetActiveFeeRule), arg0, arg1)
+}
+
+// GetDeadLetterTask mocks base method.
+func (m *MockStore) GetDeadLetterTask(arg0 context.Context, arg1 db.GetDeadLetterTaskParams) (db.DeadLetterTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeadLetterTask", arg0, arg1)
+	ret0, _ := ret[0].(db.DeadLetterTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadLetterTask indicates an expected call of GetDeadLetterTask.
+func (mr *MockStoreMockRecorder) GetDeadLetterTask(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeadLetterTask", reflect.TypeOf((*MockStore)(nil).GetDeadLetterTask), arg0, arg1)
+}
+
+// GetEntry mocks base method.
+func (m *MockStore) GetEntry(arg0 context.Context, arg1 int64) (db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEntry", arg0, arg1)
+	ret0, _ := ret[0].(db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEntry indicates an expected call of GetEntry.
+func (mr *MockStoreMockRecorder) GetEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), arg0, arg1)
+}
+
+// GetLatestDomainEventOffset mocks base method.
+func (m *MockStore) GetLatestDomainEventOffset(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestDomainEventOffset", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestDomainEventOffset indicates an expected call of GetLatestDomainEventOffset.
+func (mr *MockStoreMockRecorder) GetLatestDomainEventOffset(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestDomainEventOffset", reflect.TypeOf((*MockStore)(nil).GetLatestDomainEventOffset), arg0)
+}
+
+// GetLoginLockout mocks base method.
+func (m *MockStore) GetLoginLockout(arg0 context.Context, arg1 db.GetLoginLockoutParams) (db.LoginLockout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginLockout", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginLockout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginLockout indicates an expected call of GetLoginLockout.
+func (mr *MockStoreMockRecorder) GetLoginLockout(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginLockout", reflect.TypeOf((*MockStore)(nil).GetLoginLockout), arg0, arg1)
+}
+
+// GetPayee mocks base method.
+func (m *MockStore) GetPayee(arg0 context.Context, arg1 int64) (db.Payee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayee", arg0, arg1)
+	ret0, _ := ret[0].(db.Payee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayee indicates an expected call of GetPayee.
+func (mr *MockStoreMockRecorder) GetPayee(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayee", reflect.TypeOf((*MockStore)(nil).GetPayee), arg0, arg1)
+}
+
+// GetPayeeByAccount mocks base method.
+func (m *MockStore) GetPayeeByAccount(arg0 context.Context, arg1 db.GetPayeeByAccountParams) (db.Payee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayeeByAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Payee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayeeByAccount indicates an expected call of GetPayeeByAccount.
+func (mr *MockStoreMockRecorder) GetPayeeByAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayeeByAccount", reflect.TypeOf((*MockStore)(nil).GetPayeeByAccount), arg0, arg1)
+}
+
+// GetPayeeTransferTotal mocks base method.
+func (m *MockStore) GetPayeeTransferTotal(arg0 context.Context, arg1 db.GetPayeeTransferTotalParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPayeeTransferTotal", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPayeeTransferTotal indicates an expected call of GetPayeeTransferTotal.
+func (mr *MockStoreMockRecorder) GetPayeeTransferTotal(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPayeeTransferTotal", reflect.TypeOf((*MockStore)(nil).GetPayeeTransferTotal), arg0, arg1)
+}
+
+// GetPendingInterestMicros mocks base method.
+func (m *MockStore) GetPendingInterestMicros(arg0 context.Context, arg1 db.GetPendingInterestMicrosParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingInterestMicros", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingInterestMicros indicates an expected call of GetPendingInterestMicros.
+func (mr *MockStoreMockRecorder) GetPendingInterestMicros(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingInterestMicros", reflect.TypeOf((*MockStore)(nil).GetPendingInterestMicros), arg0, arg1)
+}
+
+// GetRecipientTransferSummary mocks base method.
+func (m *MockStore) GetRecipientTransferSummary(arg0 context.Context, arg1 db.GetRecipientTransferSummaryParams) (db.GetRecipientTransferSummaryRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecipientTransferSummary", arg0, arg1)
+	ret0, _ := ret[0].(db.GetRecipientTransferSummaryRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecipientTransferSummary indicates an expected call of GetRecipientTransferSummary.
+func (mr *MockStoreMockRecorder) GetRecipientTransferSummary(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecipientTransferSummary", reflect.TypeOf((*MockStore)(nil).GetRecipientTransferSummary), arg0, arg1)
+}
+
+// GetSession mocks base method.
+func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
+}
+
+// GetTransfer mocks base method.
+func (m *MockStore) GetTransfer(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransfer indicates an expected call of GetTransfer.
+func (mr *MockStoreMockRecorder) GetTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), arg0, arg1)
+}
+
+// GetTransferAllowance mocks base method.
+func (m *MockStore) GetTransferAllowance(arg0 context.Context, arg1 db.GetTransferAllowanceParams) (db.TransferAllowance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferAllowance", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferAllowance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferAllowance indicates an expected call of GetTransferAllowance.
+func (mr *MockStoreMockRecorder) GetTransferAllowance(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferAllowance", reflect.TypeOf((*MockStore)(nil).GetTransferAllowance), arg0, arg1)
+}
+
+// GetTransferLimitForUpdate mocks base method.
+func (m *MockStore) GetTransferLimitForUpdate(arg0 context.Context, arg1 db.GetTransferLimitForUpdateParams) (db.TransferLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferLimitForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferLimitForUpdate indicates an expected call of GetTransferLimitForUpdate.
+func (mr *MockStoreMockRecorder) GetTransferLimitForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferLimitForUpdate", reflect.TypeOf((*MockStore)(nil).GetTransferLimitForUpdate), arg0, arg1)
+}
+
+// GetUser mocks base method.
+func (m *MockStore) GetUser(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockStore) GetUserByEmail(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockStoreMockRecorder) GetUserByEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockStore)(nil).GetUserByEmail), arg0, arg1)
+}
+
+// GetUserForUpdate mocks base method.
+func (m *MockStore) GetUserForUpdate(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserForUpdate indicates an expected call of GetUserForUpdate.
+func (mr *MockStoreMockRecorder) GetUserForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserForUpdate", reflect.TypeOf((*MockStore)(nil).GetUserForUpdate), arg0, arg1)
+}
+
+// GetUserTransferUsage mocks base method.
+func (m *MockStore) GetUserTransferUsage(arg0 context.Context, arg1 db.GetUserTransferUsageParams) (db.GetUserTransferUsageRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserTransferUsage", arg0, arg1)
+	ret0, _ := ret[0].(db.GetUserTransferUsageRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserTransferUsage indicates an expected call of GetUserTransferUsage.
+func (mr *MockStoreMockRecorder) GetUserTransferUsage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTransferUsage", reflect.TypeOf((*MockStore)(nil).GetUserTransferUsage), arg0, arg1)
+}
+
+// GetWebhook mocks base method.
+func (m *MockStore) GetWebhook(arg0 context.Context, arg1 int64) (db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", arg0, arg1)
+	ret0, _ := ret[0].(db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockStoreMockRecorder) GetWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockStore)(nil).GetWebhook), arg0, arg1)
+}
+
+// GetWebhookDelivery mocks base method.
+func (m *MockStore) GetWebhookDelivery(arg0 context.Context, arg1 int64) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDelivery indicates an expected call of GetWebhookDelivery.
+func (mr *MockStoreMockRecorder) GetWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDelivery", reflect.TypeOf((*MockStore)(nil).GetWebhookDelivery), arg0, arg1)
+}
+
+// ListAccountEntries mocks base method.
+func (m *MockStore) ListAccountEntries(arg0 context.Context, arg1 db.ListAccountEntriesParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountEntries", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountEntries indicates an expected call of ListAccountEntries.
+func (mr *MockStoreMockRecorder) ListAccountEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountEntries", reflect.TypeOf((*MockStore)(nil).ListAccountEntries), arg0, arg1)
+}
+
+// ListAccountIDsCreatedBefore mocks base method.
+func (m *MockStore) ListAccountIDsCreatedBefore(arg0 context.Context, arg1 db.ListAccountIDsCreatedBeforeParams) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountIDsCreatedBefore", arg0, arg1)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountIDsCreatedBefore indicates an expected call of ListAccountIDsCreatedBefore.
+func (mr *MockStoreMockRecorder) ListAccountIDsCreatedBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountIDsCreatedBefore", reflect.TypeOf((*MockStore)(nil).ListAccountIDsCreatedBefore), arg0, arg1)
+}
+
+// ListAccountProducts mocks base method.
+func (m *MockStore) ListAccountProducts(arg0 context.Context) ([]db.AccountProduct, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountProducts", arg0)
+	ret0, _ := ret[0].([]db.AccountProduct)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountProducts indicates an expected call of ListAccountProducts.
+func (mr *MockStoreMockRecorder) ListAccountProducts(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountProducts", reflect.TypeOf((*MockStore)(nil).ListAccountProducts), arg0)
+}
+
+// ListAccountStatements mocks base method.
+func (m *MockStore) ListAccountStatements(arg0 context.Context, arg1 db.ListAccountStatementsParams) ([]db.AccountStatement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountStatements", arg0, arg1)
+	ret0, _ := ret[0].([]db.AccountStatement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountStatements indicates an expected call of ListAccountStatements.
+func (mr *MockStoreMockRecorder) ListAccountStatements(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountStatements", reflect.TypeOf((*MockStore)(nil).ListAccountStatements), arg0, arg1)
+}
+
+// ListAccounts mocks base method.
+func (m *MockStore) ListAccounts(arg0 context.Context, arg1 db.ListAccountsParams) ([]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccounts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccounts indicates an expected call of ListAccounts.
+func (mr *MockStoreMockRecorder) ListAccounts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), arg0, arg1)
+}
+
+// ListAccountsForUpdate mocks base method.
+func (m *MockStore) ListAccountsForUpdate(arg0 context.Context, arg1 []int64) ([]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountsForUpdate", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountsForUpdate indicates an expected call of ListAccountsForUpdate.
+func (mr *MockStoreMockRecorder) ListAccountsForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountsForUpdate", reflect.TypeOf((*MockStore)(nil).ListAccountsForUpdate), arg0, arg1)
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockStore) ListAuditEvents(arg0 context.Context, arg1 db.ListAuditEventsParams) ([]db.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", arg0, arg1)
+	ret0, _ := ret[0].([]db.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockStoreMockRecorder) ListAuditEvents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockStore)(nil).ListAuditEvents), arg0, arg1)
+}
+
+// ListDomainEvents mocks base method.
+func (m *MockStore) ListDomainEvents(arg0 context.Context, arg1 db.ListDomainEventsParams) ([]db.DomainEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDomainEvents", arg0, arg1)
+	ret0, _ := ret[0].([]db.DomainEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDomainEvents indicates an expected call of ListDomainEvents.
+func (mr *MockStoreMockRecorder) ListDomainEvents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDomainEvents", reflect.TypeOf((*MockStore)(nil).ListDomainEvents), arg0, arg1)
+}
+
+// ListEntries mocks base method.
+func (m *MockStore) ListEntries(arg0 context.Context, arg1 db.ListEntriesParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEntries", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEntries indicates an expected call of ListEntries.
+func (mr *MockStoreMockRecorder) ListEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockStore)(nil).ListEntries), arg0, arg1)
+}
+
+// ListFeeRules mocks base method.
+func (m *MockStore) ListFeeRules(arg0 context.Context) ([]db.FeeRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFeeRules", arg0)
+	ret0, _ := ret[0].([]db.FeeRule)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateAccount indicates an expected call of CreateAccount.
-func (mr *MockStoreMockRecorder) CreateAccount(arg0, arg1 interface{}) *gomock.Call {
+// ListFeeRules indicates an expected call of ListFeeRules.
+func (mr *MockStoreMockRecorder) ListFeeRules(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockStore)(nil).CreateAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeeRules", reflect.TypeOf((*MockStore)(nil).ListFeeRules), arg0)
 }
 
-// CreateEntry mocks base method.
-func (m *MockStore) CreateEntry(arg0 context.Context, arg1 db.CreateEntryParams) (db.Entry, error) {
+// ListInterestAccruals mocks base method.
+func (m *MockStore) ListInterestAccruals(arg0 context.Context, arg1 db.ListInterestAccrualsParams) ([]db.InterestAccrual, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateEntry", arg0, arg1)
-	ret0, _ := ret[0].(db.Entry)
+	ret := m.ctrl.Call(m, "ListInterestAccruals", arg0, arg1)
+	ret0, _ := ret[0].([]db.InterestAccrual)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateEntry indicates an expected call of CreateEntry.
-func (mr *MockStoreMockRecorder) CreateEntry(arg0, arg1 interface{}) *gomock.Call {
+// ListInterestAccruals indicates an expected call of ListInterestAccruals.
+func (mr *MockStoreMockRecorder) ListInterestAccruals(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInterestAccruals", reflect.TypeOf((*MockStore)(nil).ListInterestAccruals), arg0, arg1)
 }
 
-// CreateSession mocks base method.
-func (m *MockStore) CreateSession(arg0 context.Context, arg1 db.CreateSessionParams) (db.Session, error) {
+// ListInterestBearingAccountIDs mocks base method.
+func (m *MockStore) ListInterestBearingAccountIDs(arg0 context.Context, arg1 db.ListInterestBearingAccountIDsParams) ([]int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
-	ret0, _ := ret[0].(db.Session)
+	ret := m.ctrl.Call(m, "ListInterestBearingAccountIDs", arg0, arg1)
+	ret0, _ := ret[0].([]int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateSession indicates an expected call of CreateSession.
-func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+// ListInterestBearingAccountIDs indicates an expected call of ListInterestBearingAccountIDs.
+func (mr *MockStoreMockRecorder) ListInterestBearingAccountIDs(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInterestBearingAccountIDs", reflect.TypeOf((*MockStore)(nil).ListInterestBearingAccountIDs), arg0, arg1)
 }
 
-// CreateTransfer mocks base method.
-func (m *MockStore) CreateTransfer(arg0 context.Context, arg1 db.CreateTransferParams) (db.Transfer, error) {
+// ListLoginFailureClientIps mocks base method.
+func (m *MockStore) ListLoginFailureClientIps(arg0 context.Context, arg1 db.ListLoginFailureClientIpsParams) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTransfer", arg0, arg1)
-	ret0, _ := ret[0].(db.Transfer)
+	ret := m.ctrl.Call(m, "ListLoginFailureClientIps", arg0, arg1)
+	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateTransfer indicates an expected call of CreateTransfer.
-func (mr *MockStoreMockRecorder) CreateTransfer(arg0, arg1 interface{}) *gomock.Call {
+// ListLoginFailureClientIps indicates an expected call of ListLoginFailureClientIps.
+func (mr *MockStoreMockRecorder) ListLoginFailureClientIps(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoginFailureClientIps", reflect.TypeOf((*MockStore)(nil).ListLoginFailureClientIps), arg0, arg1)
 }
 
-// CreateUser mocks base method.
-func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
+// ListPayees mocks base method.
+func (m *MockStore) ListPayees(arg0 context.Context, arg1 db.ListPayeesParams) ([]db.Payee, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "ListPayees", arg0, arg1)
+	ret0, _ := ret[0].([]db.Payee)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateUser indicates an expected call of CreateUser.
-func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+// ListPayees indicates an expected call of ListPayees.
+func (mr *MockStoreMockRecorder) ListPayees(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPayees", reflect.TypeOf((*MockStore)(nil).ListPayees), arg0, arg1)
 }
 
-// CreateUserTx mocks base method.
-func (m *MockStore) CreateUserTx(arg0 context.Context, arg1 db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+// ListPendingInterestAccrualsForUpdate mocks base method.
+func (m *MockStore) ListPendingInterestAccrualsForUpdate(arg0 context.Context, arg1 db.ListPendingInterestAccrualsForUpdateParams) ([]db.InterestAccrual, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUserTx", arg0, arg1)
-	ret0, _ := ret[0].(db.CreateUserTxResult)
+	ret := m.ctrl.Call(m, "ListPendingInterestAccrualsForUpdate", arg0, arg1)
+	ret0, _ := ret[0].([]db.InterestAccrual)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateUserTx indicates an expected call of CreateUserTx.
-func (mr *MockStoreMockRecorder) CreateUserTx(arg0, arg1 interface{}) *gomock.Call {
+// ListPendingInterestAccrualsForUpdate indicates an expected call of ListPendingInterestAccrualsForUpdate.
+func (mr *MockStoreMockRecorder) ListPendingInterestAccrualsForUpdate(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserTx", reflect.TypeOf((*MockStore)(nil).CreateUserTx), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingInterestAccrualsForUpdate", reflect.TypeOf((*MockStore)(nil).ListPendingInterestAccrualsForUpdate), arg0, arg1)
 }
 
-// CreateVerifyEmail mocks base method.
-func (m *MockStore) CreateVerifyEmail(arg0 context.Context, arg1 db.CreateVerifyEmailParams) (db.VerifyEmail, error) {
+// ListPendingOutboxTasks mocks base method.
+func (m *MockStore) ListPendingOutboxTasks(arg0 context.Context, arg1 int32) ([]db.Outbox, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateVerifyEmail", arg0, arg1)
-	ret0, _ := ret[0].(db.VerifyEmail)
+	ret := m.ctrl.Call(m, "ListPendingOutboxTasks", arg0, arg1)
+	ret0, _ := ret[0].([]db.Outbox)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateVerifyEmail indicates an expected call of CreateVerifyEmail.
-func (mr *MockStoreMockRecorder) CreateVerifyEmail(arg0, arg1 interface{}) *gomock.Call {
+// ListPendingOutboxTasks indicates an expected call of ListPendingOutboxTasks.
+func (mr *MockStoreMockRecorder) ListPendingOutboxTasks(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVerifyEmail", reflect.TypeOf((*MockStore)(nil).CreateVerifyEmail), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingOutboxTasks", reflect.TypeOf((*MockStore)(nil).ListPendingOutboxTasks), arg0, arg1)
 }
 
-// DeleteAccount mocks base method.
-func (m *MockStore) DeleteAccount(arg0 context.Context, arg1 int64) error {
+// ListStatementEntries mocks base method.
+func (m *MockStore) ListStatementEntries(arg0 context.Context, arg1 db.ListStatementEntriesParams) ([]db.ListStatementEntriesRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteAccount", arg0, arg1)
+	ret := m.ctrl.Call(m, "ListStatementEntries", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListStatementEntriesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStatementEntries indicates an expected call of ListStatementEntries.
+func (mr *MockStoreMockRecorder) ListStatementEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStatementEntries", reflect.TypeOf((*MockStore)(nil).ListStatementEntries), arg0, arg1)
+}
+
+// ListTransferLimitsForAccount mocks base method.
+func (m *MockStore) ListTransferLimitsForAccount(arg0 context.Context, arg1 db.ListTransferLimitsForAccountParams) ([]db.TransferLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransferLimitsForAccount", arg0, arg1)
+	ret0, _ := ret[0].([]db.TransferLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransferLimitsForAccount indicates an expected call of ListTransferLimitsForAccount.
+func (mr *MockStoreMockRecorder) ListTransferLimitsForAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransferLimitsForAccount", reflect.TypeOf((*MockStore)(nil).ListTransferLimitsForAccount), arg0, arg1)
+}
+
+// ListTransfers mocks base method.
+func (m *MockStore) ListTransfers(arg0 context.Context, arg1 db.ListTransfersParams) ([]db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransfers", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransfers indicates an expected call of ListTransfers.
+func (mr *MockStoreMockRecorder) ListTransfers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), arg0, arg1)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockStore) ListWebhookDeliveries(arg0 context.Context, arg1 db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", arg0, arg1)
+	ret0, _ := ret[0].([]db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockStoreMockRecorder) ListWebhookDeliveries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).ListWebhookDeliveries), arg0, arg1)
+}
+
+// LockLogin mocks base method.
+func (m *MockStore) LockLogin(arg0 context.Context, arg1 db.LockLoginParams) (db.LoginLockout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginLockout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockStoreMockRecorder) LockLogin(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockStore)(nil).LockLogin), arg0, arg1)
+}
+
+// MarkAccountStatementNotified mocks base method.
+func (m *MockStore) MarkAccountStatementNotified(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAccountStatementNotified", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteAccount indicates an expected call of DeleteAccount.
-func (mr *MockStoreMockRecorder) DeleteAccount(arg0, arg1 interface{}) *gomock.Call {
+// MarkAccountStatementNotified indicates an expected call of MarkAccountStatementNotified.
+func (mr *MockStoreMockRecorder) MarkAccountStatementNotified(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockStore)(nil).DeleteAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAccountStatementNotified", reflect.TypeOf((*MockStore)(nil).MarkAccountStatementNotified), arg0, arg1)
 }
 
-// DeleteEntry mocks base method.
-func (m *MockStore) DeleteEntry(arg0 context.Context, arg1 int64) error {
+// MarkInterestAccrualsPosted mocks base method.
+func (m *MockStore) MarkInterestAccrualsPosted(arg0 context.Context, arg1 db.MarkInterestAccrualsPostedParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteEntry", arg0, arg1)
+	ret := m.ctrl.Call(m, "MarkInterestAccrualsPosted", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteEntry indicates an expected call of DeleteEntry.
-func (mr *MockStoreMockRecorder) DeleteEntry(arg0, arg1 interface{}) *gomock.Call {
+// MarkInterestAccrualsPosted indicates an expected call of MarkInterestAccrualsPosted.
+func (mr *MockStoreMockRecorder) MarkInterestAccrualsPosted(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEntry", reflect.TypeOf((*MockStore)(nil).DeleteEntry), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkInterestAccrualsPosted", reflect.TypeOf((*MockStore)(nil).MarkInterestAccrualsPosted), arg0, arg1)
 }
 
-// DeleteTransfer mocks base method.
-func (m *MockStore) DeleteTransfer(arg0 context.Context, arg1 int64) error {
+// MarkOutboxTaskPublished mocks base method.
+func (m *MockStore) MarkOutboxTaskPublished(arg0 context.Context, arg1 int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteTransfer", arg0, arg1)
+	ret := m.ctrl.Call(m, "MarkOutboxTaskPublished", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteTransfer indicates an expected call of DeleteTransfer.
-func (mr *MockStoreMockRecorder) DeleteTransfer(arg0, arg1 interface{}) *gomock.Call {
+// MarkOutboxTaskPublished indicates an expected call of MarkOutboxTaskPublished.
+func (mr *MockStoreMockRecorder) MarkOutboxTaskPublished(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTransfer", reflect.TypeOf((*MockStore)(nil).DeleteTransfer), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxTaskPublished", reflect.TypeOf((*MockStore)(nil).MarkOutboxTaskPublished), arg0, arg1)
 }
 
-// GetAccount mocks base method.
-func (m *MockStore) GetAccount(arg0 context.Context, arg1 int64) (db.Account, error) {
+// Ping mocks base method.
+func (m *MockStore) Ping(arg0 context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccount", arg0, arg1)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "Ping", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockStoreMockRecorder) Ping(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStore)(nil).Ping), arg0)
+}
+
+// PostInterestTx mocks base method.
+func (m *MockStore) PostInterestTx(arg0 context.Context, arg1 db.PostInterestTxParams) (db.PostInterestTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostInterestTx", arg0, arg1)
+	ret0, _ := ret[0].(db.PostInterestTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccount indicates an expected call of GetAccount.
-func (mr *MockStoreMockRecorder) GetAccount(arg0, arg1 interface{}) *gomock.Call {
+// PostInterestTx indicates an expected call of PostInterestTx.
+func (mr *MockStoreMockRecorder) PostInterestTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockStore)(nil).GetAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostInterestTx", reflect.TypeOf((*MockStore)(nil).PostInterestTx), arg0, arg1)
 }
 
-// GetAccountForUpdate mocks base method.
-func (m *MockStore) GetAccountForUpdate(arg0 context.Context, arg1 int64) (db.Account, error) {
+// PublishOutboxTx mocks base method.
+func (m *MockStore) PublishOutboxTx(arg0 context.Context, arg1 db.PublishOutboxTxParams) (db.PublishOutboxTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountForUpdate", arg0, arg1)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "PublishOutboxTx", arg0, arg1)
+	ret0, _ := ret[0].(db.PublishOutboxTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccountForUpdate indicates an expected call of GetAccountForUpdate.
-func (mr *MockStoreMockRecorder) GetAccountForUpdate(arg0, arg1 interface{}) *gomock.Call {
+// PublishOutboxTx indicates an expected call of PublishOutboxTx.
+func (mr *MockStoreMockRecorder) PublishOutboxTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountForUpdate", reflect.TypeOf((*MockStore)(nil).GetAccountForUpdate), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishOutboxTx", reflect.TypeOf((*MockStore)(nil).PublishOutboxTx), arg0, arg1)
 }
 
-// GetEntry mocks base method.
-func (m *MockStore) GetEntry(arg0 context.Context, arg1 int64) (db.Entry, error) {
+// QuoteTransferFee mocks base method.
+func (m *MockStore) QuoteTransferFee(arg0 context.Context, arg1 db.QuoteTransferFeeParams) (db.TransferFeeQuote, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetEntry", arg0, arg1)
-	ret0, _ := ret[0].(db.Entry)
+	ret := m.ctrl.Call(m, "QuoteTransferFee", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferFeeQuote)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetEntry indicates an expected call of GetEntry.
-func (mr *MockStoreMockRecorder) GetEntry(arg0, arg1 interface{}) *gomock.Call {
+// QuoteTransferFee indicates an expected call of QuoteTransferFee.
+func (mr *MockStoreMockRecorder) QuoteTransferFee(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuoteTransferFee", reflect.TypeOf((*MockStore)(nil).QuoteTransferFee), arg0, arg1)
 }
 
-// GetSession mocks base method.
-func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+// RecordLoginFailure mocks base method.
+func (m *MockStore) RecordLoginFailure(arg0 context.Context, arg1 db.RecordLoginFailureParams) (db.LoginLockout, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
-	ret0, _ := ret[0].(db.Session)
+	ret := m.ctrl.Call(m, "RecordLoginFailure", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginLockout)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetSession indicates an expected call of GetSession.
-func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockStoreMockRecorder) RecordLoginFailure(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockStore)(nil).RecordLoginFailure), arg0, arg1)
 }
 
-// GetTransfer mocks base method.
-func (m *MockStore) GetTransfer(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+// RecordOutboxTaskFailure mocks base method.
+func (m *MockStore) RecordOutboxTaskFailure(arg0 context.Context, arg1 db.RecordOutboxTaskFailureParams) (db.Outbox, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTransfer", arg0, arg1)
-	ret0, _ := ret[0].(db.Transfer)
+	ret := m.ctrl.Call(m, "RecordOutboxTaskFailure", arg0, arg1)
+	ret0, _ := ret[0].(db.Outbox)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTransfer indicates an expected call of GetTransfer.
-func (mr *MockStoreMockRecorder) GetTransfer(arg0, arg1 interface{}) *gomock.Call {
+// RecordOutboxTaskFailure indicates an expected call of RecordOutboxTaskFailure.
+func (mr *MockStoreMockRecorder) RecordOutboxTaskFailure(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordOutboxTaskFailure", reflect.TypeOf((*MockStore)(nil).RecordOutboxTaskFailure), arg0, arg1)
 }
 
-// GetUser mocks base method.
-func (m *MockStore) GetUser(arg0 context.Context, arg1 string) (db.User, error) {
+// ResetLoginLockout mocks base method.
+func (m *MockStore) ResetLoginLockout(arg0 context.Context, arg1 db.ResetLoginLockoutParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "ResetLoginLockout", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginLockout indicates an expected call of ResetLoginLockout.
+func (mr *MockStoreMockRecorder) ResetLoginLockout(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginLockout", reflect.TypeOf((*MockStore)(nil).ResetLoginLockout), arg0, arg1)
+}
+
+// ResolveDeadLetterTask mocks base method.
+func (m *MockStore) ResolveDeadLetterTask(arg0 context.Context, arg1 db.ResolveDeadLetterTaskParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveDeadLetterTask", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResolveDeadLetterTask indicates an expected call of ResolveDeadLetterTask.
+func (mr *MockStoreMockRecorder) ResolveDeadLetterTask(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveDeadLetterTask", reflect.TypeOf((*MockStore)(nil).ResolveDeadLetterTask), arg0, arg1)
+}
+
+// ResolveRecipient mocks base method.
+func (m *MockStore) ResolveRecipient(arg0 context.Context, arg1 db.ResolveRecipientParams) (db.Recipient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveRecipient", arg0, arg1)
+	ret0, _ := ret[0].(db.Recipient)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUser indicates an expected call of GetUser.
-func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+// ResolveRecipient indicates an expected call of ResolveRecipient.
+func (mr *MockStoreMockRecorder) ResolveRecipient(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveRecipient", reflect.TypeOf((*MockStore)(nil).ResolveRecipient), arg0, arg1)
 }
 
-// ListAccounts mocks base method.
-func (m *MockStore) ListAccounts(arg0 context.Context, arg1 db.ListAccountsParams) ([]db.Account, error) {
+// ReviveOutboxTask mocks base method.
+func (m *MockStore) ReviveOutboxTask(arg0 context.Context, arg1 db.ReviveOutboxTaskParams) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAccounts", arg0, arg1)
-	ret0, _ := ret[0].([]db.Account)
+	ret := m.ctrl.Call(m, "ReviveOutboxTask", arg0, arg1)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListAccounts indicates an expected call of ListAccounts.
-func (mr *MockStoreMockRecorder) ListAccounts(arg0, arg1 interface{}) *gomock.Call {
+// ReviveOutboxTask indicates an expected call of ReviveOutboxTask.
+func (mr *MockStoreMockRecorder) ReviveOutboxTask(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviveOutboxTask", reflect.TypeOf((*MockStore)(nil).ReviveOutboxTask), arg0, arg1)
 }
 
-// ListEntries mocks base method.
-func (m *MockStore) ListEntries(arg0 context.Context, arg1 db.ListEntriesParams) ([]db.Entry, error) {
+// SetAccountFrozen mocks base method.
+func (m *MockStore) SetAccountFrozen(arg0 context.Context, arg1 db.SetAccountFrozenParams) (db.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListEntries", arg0, arg1)
-	ret0, _ := ret[0].([]db.Entry)
+	ret := m.ctrl.Call(m, "SetAccountFrozen", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListEntries indicates an expected call of ListEntries.
-func (mr *MockStoreMockRecorder) ListEntries(arg0, arg1 interface{}) *gomock.Call {
+// SetAccountFrozen indicates an expected call of SetAccountFrozen.
+func (mr *MockStoreMockRecorder) SetAccountFrozen(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockStore)(nil).ListEntries), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccountFrozen", reflect.TypeOf((*MockStore)(nil).SetAccountFrozen), arg0, arg1)
 }
 
-// ListTransfers mocks base method.
-func (m *MockStore) ListTransfers(arg0 context.Context, arg1 db.ListTransfersParams) ([]db.Transfer, error) {
+// SetFeeRuleTx mocks base method.
+func (m *MockStore) SetFeeRuleTx(arg0 context.Context, arg1 db.SetFeeRuleTxParams) (db.SetFeeRuleTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListTransfers", arg0, arg1)
-	ret0, _ := ret[0].([]db.Transfer)
+	ret := m.ctrl.Call(m, "SetFeeRuleTx", arg0, arg1)
+	ret0, _ := ret[0].(db.SetFeeRuleTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListTransfers indicates an expected call of ListTransfers.
-func (mr *MockStoreMockRecorder) ListTransfers(arg0, arg1 interface{}) *gomock.Call {
+// SetFeeRuleTx indicates an expected call of SetFeeRuleTx.
+func (mr *MockStoreMockRecorder) SetFeeRuleTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFeeRuleTx", reflect.TypeOf((*MockStore)(nil).SetFeeRuleTx), arg0, arg1)
 }
 
-// Ping mocks base method.
-func (m *MockStore) Ping(arg0 context.Context) error {
+// SetTransferLimitTx mocks base method.
+func (m *MockStore) SetTransferLimitTx(arg0 context.Context, arg1 db.SetTransferLimitTxParams) (db.SetTransferLimitTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Ping", arg0)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "SetTransferLimitTx", arg0, arg1)
+	ret0, _ := ret[0].(db.SetTransferLimitTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Ping indicates an expected call of Ping.
-func (mr *MockStoreMockRecorder) Ping(arg0 interface{}) *gomock.Call {
+// SetTransferLimitTx indicates an expected call of SetTransferLimitTx.
+func (mr *MockStoreMockRecorder) SetTransferLimitTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStore)(nil).Ping), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTransferLimitTx", reflect.TypeOf((*MockStore)(nil).SetTransferLimitTx), arg0, arg1)
 }
 
 // TransferTx mocks base method.
@@ -362,6 +1585,21 @@ func (mr *MockStoreMockRecorder) TransferTx(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferTx", reflect.TypeOf((*MockStore)(nil).TransferTx), arg0, arg1)
 }
 
+// TryLockDomainEventPositions mocks base method.
+func (m *MockStore) TryLockDomainEventPositions(arg0 context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TryLockDomainEventPositions", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TryLockDomainEventPositions indicates an expected call of TryLockDomainEventPositions.
+func (mr *MockStoreMockRecorder) TryLockDomainEventPositions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryLockDomainEventPositions", reflect.TypeOf((*MockStore)(nil).TryLockDomainEventPositions), arg0)
+}
+
 // UpdateAccount mocks base method.
 func (m *MockStore) UpdateAccount(arg0 context.Context, arg1 db.UpdateAccountParams) (db.Account, error) {
 	m.ctrl.T.Helper()
@@ -391,6 +1629,21 @@ func (mr *MockStoreMockRecorder) UpdateEntry(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEntry", reflect.TypeOf((*MockStore)(nil).UpdateEntry), arg0, arg1)
 }
 
+// UpdatePayee mocks base method.
+func (m *MockStore) UpdatePayee(arg0 context.Context, arg1 db.UpdatePayeeParams) (db.Payee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePayee", arg0, arg1)
+	ret0, _ := ret[0].(db.Payee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePayee indicates an expected call of UpdatePayee.
+func (mr *MockStoreMockRecorder) UpdatePayee(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePayee", reflect.TypeOf((*MockStore)(nil).UpdatePayee), arg0, arg1)
+}
+
 // UpdateTransfer mocks base method.
 func (m *MockStore) UpdateTransfer(arg0 context.Context, arg1 db.UpdateTransferParams) error {
 	m.ctrl.T.Helper()
@@ -405,6 +1658,21 @@ func (mr *MockStoreMockRecorder) UpdateTransfer(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTransfer", reflect.TypeOf((*MockStore)(nil).UpdateTransfer), arg0, arg1)
 }
 
+// UpdateTransferLimit mocks base method.
+func (m *MockStore) UpdateTransferLimit(arg0 context.Context, arg1 db.UpdateTransferLimitParams) (db.TransferLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTransferLimit", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTransferLimit indicates an expected call of UpdateTransferLimit.
+func (mr *MockStoreMockRecorder) UpdateTransferLimit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTransferLimit", reflect.TypeOf((*MockStore)(nil).UpdateTransferLimit), arg0, arg1)
+}
+
 // UpdateUser mocks base method.
 func (m *MockStore) UpdateUser(arg0 context.Context, arg1 db.UpdateUserParams) (db.User, error) {
 	m.ctrl.T.Helper()
@@ -420,6 +1688,21 @@ func (mr *MockStoreMockRecorder) UpdateUser(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockStore)(nil).UpdateUser), arg0, arg1)
 }
 
+// UpdateUserTx mocks base method.
+func (m *MockStore) UpdateUserTx(arg0 context.Context, arg1 db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserTx", arg0, arg1)
+	ret0, _ := ret[0].(db.UpdateUserTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserTx indicates an expected call of UpdateUserTx.
+func (mr *MockStoreMockRecorder) UpdateUserTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserTx", reflect.TypeOf((*MockStore)(nil).UpdateUserTx), arg0, arg1)
+}
+
 // UpdateVerifyEmail mocks base method.
 func (m *MockStore) UpdateVerifyEmail(arg0 context.Context, arg1 db.UpdateVerifyEmailParams) (db.VerifyEmail, error) {
 	m.ctrl.T.Helper()
@@ -435,6 +1718,21 @@ func (mr *MockStoreMockRecorder) UpdateVerifyEmail(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVerifyEmail", reflect.TypeOf((*MockStore)(nil).UpdateVerifyEmail), arg0, arg1)
 }
 
+// UpdateWebhookDeliveryAttempt mocks base method.
+func (m *MockStore) UpdateWebhookDeliveryAttempt(arg0 context.Context, arg1 db.UpdateWebhookDeliveryAttemptParams) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDeliveryAttempt", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhookDeliveryAttempt indicates an expected call of UpdateWebhookDeliveryAttempt.
+func (mr *MockStoreMockRecorder) UpdateWebhookDeliveryAttempt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDeliveryAttempt", reflect.TypeOf((*MockStore)(nil).UpdateWebhookDeliveryAttempt), arg0, arg1)
+}
+
 // VerifyEmailTx mocks base method.
 func (m *MockStore) VerifyEmailTx(arg0 context.Context, arg1 db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error) {
 	m.ctrl.T.Helper()
@@ -1,19 +1,48 @@
 -- name: CreateAccount :one
 INSERT INTO accounts (owner,
-                      balance, currency)
-VALUES ($1, $2, $3)
+                      balance, currency, product)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
+
+-- name: CreateAccountIfNotExists :exec
+INSERT INTO accounts (owner,
+                      balance, currency, product)
+VALUES ($1, 0, $2, $3)
+ON CONFLICT (owner, currency, product) DO NOTHING;
+
 -- name: GetAccount :one
 SELECT *
 FROM accounts
 WHERE id = $1
 LIMIT 1;
+-- name: GetAccountByProduct :one
+SELECT *
+FROM accounts
+WHERE owner = $1
+  AND currency = $2
+  AND product = $3
+LIMIT 1;
 -- name: GetAccountForUpdate :one
 SELECT *
 FROM accounts
 WHERE id = $1
 LIMIT 1 FOR NO KEY UPDATE;
 
+-- name: ListAccountsForUpdate :many
+SELECT *
+FROM accounts
+WHERE id = ANY (sqlc.arg(ids)::bigint[])
+ORDER BY id
+FOR NO KEY UPDATE;
+
+-- name: ListAccountIDsCreatedBefore :many
+SELECT id
+FROM accounts
+WHERE created_at < sqlc.arg(created_before)
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(page_size);
+
 -- name: ListAccounts :many
 SELECT *
 FROM accounts
@@ -33,6 +62,12 @@ SET balance = balance + sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
+-- name: SetAccountFrozen :one
+UPDATE accounts
+SET frozen = $2
+WHERE id = $1
+RETURNING *;
+
 -- name: DeleteAccount :exec
 DELETE FROM accounts
 WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,41 @@
+-- name: CreateAccountStatement :one
+INSERT INTO account_statements (account_id,
+                                period_start,
+                                period_end,
+                                blob_key,
+                                size_bytes,
+                                opening_balance,
+                                closing_balance)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (account_id, period_start) DO UPDATE
+    SET period_end      = EXCLUDED.period_end,
+        blob_key        = EXCLUDED.blob_key,
+        size_bytes      = EXCLUDED.size_bytes,
+        opening_balance = EXCLUDED.opening_balance,
+        closing_balance = EXCLUDED.closing_balance
+RETURNING *;
+
+-- name: GetAccountStatement :one
+SELECT *
+FROM account_statements
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetAccountStatementByPeriod :one
+SELECT *
+FROM account_statements
+WHERE account_id = $1
+  AND period_start = $2
+LIMIT 1;
+
+-- name: ListAccountStatements :many
+SELECT *
+FROM account_statements
+WHERE account_id = $1
+ORDER BY period_start DESC
+LIMIT $2 OFFSET $3;
+
+-- name: MarkAccountStatementNotified :exec
+UPDATE account_statements
+SET notified_at = now()
+WHERE id = $1;
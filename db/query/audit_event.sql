@@ -0,0 +1,20 @@
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (actor,
+                          action,
+                          target,
+                          client_ip,
+                          user_agent,
+                          before,
+                          after)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: ListAuditEvents :many
+SELECT *
+FROM audit_events
+WHERE (sqlc.narg(actor)::varchar IS NULL OR actor = sqlc.narg(actor))
+  AND (sqlc.narg(target)::varchar IS NULL OR target = sqlc.narg(target))
+  AND created_at >= sqlc.arg(start_time)
+  AND created_at < sqlc.arg(end_time)
+ORDER BY id DESC
+LIMIT sqlc.arg(limit_count) OFFSET sqlc.arg(offset_count);
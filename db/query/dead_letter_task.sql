@@ -0,0 +1,32 @@
+-- name: CreateDeadLetterTask :one
+INSERT INTO dead_letter_tasks (task_id,
+                               task_type,
+                               queue,
+                               payload,
+                               last_error,
+                               retried,
+                               max_retry)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (queue, task_id) DO UPDATE
+    SET last_error  = EXCLUDED.last_error,
+        retried     = EXCLUDED.retried,
+        max_retry   = EXCLUDED.max_retry,
+        failed_at   = now(),
+        resolution  = '',
+        resolved_at = NULL
+RETURNING *;
+
+-- name: GetDeadLetterTask :one
+SELECT *
+FROM dead_letter_tasks
+WHERE queue = $1
+  AND task_id = $2
+LIMIT 1;
+
+-- name: ResolveDeadLetterTask :exec
+UPDATE dead_letter_tasks
+SET resolution  = $3,
+    resolved_at = now()
+WHERE queue = $1
+  AND task_id = $2
+  AND resolved_at IS NULL;
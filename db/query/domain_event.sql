@@ -0,0 +1,36 @@
+-- name: CreateDomainEvent :one
+INSERT INTO domain_events (owner,
+                           account_id,
+                           event_type,
+                           payload)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListDomainEvents :many
+SELECT *
+FROM domain_events
+WHERE owner = sqlc.arg(owner)
+  AND position > sqlc.arg(after_offset)::bigint
+  AND (sqlc.narg(account_id)::bigint IS NULL OR account_id = sqlc.narg(account_id))
+ORDER BY position
+LIMIT sqlc.arg(limit_count);
+
+-- name: GetLatestDomainEventOffset :one
+SELECT COALESCE(MAX(position), 0)::bigint AS offset
+FROM domain_events;
+
+-- name: TryLockDomainEventPositions :one
+-- only one transaction at a time gives out positions, so that they commit in order
+SELECT pg_try_advisory_xact_lock(hashtext('domain_events.position')) AS locked;
+
+-- name: AssignDomainEventPositions :execrows
+-- numbers the committed events without a position after the last position, in id order
+UPDATE domain_events
+SET position = numbered.position
+FROM (SELECT id,
+             (SELECT COALESCE(MAX(position), 0) FROM domain_events) + row_number() OVER (ORDER BY id) AS position
+      FROM domain_events
+      WHERE position IS NULL
+      ORDER BY id
+      LIMIT sqlc.arg(limit_count)) AS numbered
+WHERE domain_events.id = numbered.id;
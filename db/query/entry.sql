@@ -1,6 +1,6 @@
 -- name: CreateEntry :one
-INSERT INTO entries (account_id,amount)
-VALUES ($1,$2)
+INSERT INTO entries (account_id,amount,transfer_id,is_fee)
+VALUES ($1,$2,sqlc.narg(transfer_id),sqlc.arg(is_fee))
 RETURNING *;
 -- name: GetEntry :one
 SELECT * FROM entries WHERE id = $1 LIMIT 1;
@@ -9,7 +9,35 @@ SELECT * FROM entries
 ORDER BY id
 LIMIT $1
 OFFSET $2;
+-- name: ListAccountEntries :many
+SELECT * FROM entries
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
 -- name: UpdateEntry :exec
 UPDATE entries SET amount = $1 WHERE id = $2;
 -- name: DeleteEntry :exec
-DELETE FROM entries WHERE id = $1;
\ No newline at end of file
+DELETE FROM entries WHERE id = $1;
+-- name: GetAccountStatementBalances :one
+SELECT a.balance,
+       COALESCE((SELECT SUM(e.amount) FROM entries e
+                 WHERE e.account_id = a.id AND e.created_at >= sqlc.arg(to_time)::timestamptz), 0)::bigint AS after_period,
+       COALESCE((SELECT SUM(e.amount) FROM entries e
+                 WHERE e.account_id = a.id
+                   AND e.created_at >= sqlc.arg(from_time)::timestamptz
+                   AND e.created_at < sqlc.arg(to_time)::timestamptz), 0)::bigint AS in_period,
+       COALESCE((SELECT MAX(e.id) FROM entries e WHERE e.account_id = a.id), 0)::bigint AS last_entry_id
+FROM accounts a
+WHERE a.id = sqlc.arg(account_id);
+-- name: ListStatementEntries :many
+SELECT e.id, e.amount, e.created_at, e.transfer_id, e.is_fee, t.from_account_id, t.to_account_id
+FROM entries e
+LEFT JOIN transfers t ON t.id = e.transfer_id
+WHERE e.account_id = sqlc.arg(account_id)
+  AND e.created_at >= sqlc.arg(from_time)
+  AND e.created_at < sqlc.arg(to_time)
+  AND e.id > sqlc.arg(after_id)
+  AND e.id <= sqlc.arg(last_entry_id)
+ORDER BY e.id
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,35 @@
+-- name: GetActiveFeeRule :one
+SELECT *
+FROM fee_rules
+WHERE currency = $1
+  AND active
+LIMIT 1;
+
+-- name: ListFeeRules :many
+SELECT *
+FROM fee_rules
+ORDER BY currency, id DESC;
+
+-- name: CreateFeeRule :one
+INSERT INTO fee_rules (currency,
+                       kind,
+                       flat_amount,
+                       rate_bps,
+                       min_amount,
+                       max_amount,
+                       free_transfers_per_month)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: DeactivateFeeRules :many
+UPDATE fee_rules
+SET active = false
+WHERE currency = $1
+  AND active
+RETURNING *;
+
+-- name: CountTransfersSince :one
+SELECT COUNT(*)
+FROM transfers
+WHERE from_account_id = sqlc.arg(from_account_id)
+  AND created_at >= sqlc.arg(since);
@@ -0,0 +1,73 @@
+-- name: GetAccountProduct :one
+SELECT *
+FROM account_products
+WHERE code = $1
+LIMIT 1;
+
+-- name: ListAccountProducts :many
+SELECT *
+FROM account_products
+ORDER BY code;
+
+-- name: ListInterestBearingAccountIDs :many
+SELECT a.id
+FROM accounts a
+         JOIN account_products p ON p.code = a.product
+WHERE p.annual_rate_bps > 0
+  AND NOT p.internal
+  AND a.created_at < sqlc.arg(created_before)
+  AND a.id > sqlc.arg(after_id)
+ORDER BY a.id
+LIMIT sqlc.arg(page_size);
+
+-- name: CreateInterestAccrual :one
+INSERT INTO interest_accruals (account_id,
+                               accrual_date,
+                               balance,
+                               annual_rate_bps,
+                               compounding,
+                               amount_micros)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (account_id, accrual_date, kind) DO NOTHING
+RETURNING *;
+
+-- name: CreateInterestCarry :one
+INSERT INTO interest_accruals (account_id,
+                               accrual_date,
+                               kind,
+                               balance,
+                               annual_rate_bps,
+                               compounding,
+                               amount_micros)
+VALUES ($1, $2, 'carry', $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetPendingInterestMicros :one
+SELECT COALESCE(SUM(amount_micros), 0)::bigint
+FROM interest_accruals
+WHERE account_id = sqlc.arg(account_id)
+  AND transfer_id IS NULL
+  AND accrual_date < sqlc.arg(before_date);
+
+-- name: ListPendingInterestAccrualsForUpdate :many
+SELECT *
+FROM interest_accruals
+WHERE account_id = sqlc.arg(account_id)
+  AND transfer_id IS NULL
+  AND accrual_date < sqlc.arg(before_date)
+ORDER BY accrual_date, kind
+FOR UPDATE;
+
+-- name: MarkInterestAccrualsPosted :exec
+UPDATE interest_accruals
+SET transfer_id = sqlc.arg(transfer_id)
+WHERE account_id = sqlc.arg(account_id)
+  AND transfer_id IS NULL
+  AND accrual_date < sqlc.arg(before_date);
+
+-- name: ListInterestAccruals :many
+SELECT *
+FROM interest_accruals
+WHERE account_id = $1
+ORDER BY accrual_date DESC, kind
+LIMIT $2 OFFSET $3;
@@ -0,0 +1,39 @@
+-- name: GetLoginLockout :one
+SELECT *
+FROM login_lockouts
+WHERE kind = $1
+  AND key = $2
+LIMIT 1;
+
+-- name: RecordLoginFailure :one
+-- failures older than reset_before are forgotten, so that the count only grows with failures close to each other
+INSERT INTO login_lockouts (kind, key, failed_count, last_failed_at)
+VALUES (sqlc.arg(kind), sqlc.arg(key), 1, now())
+ON CONFLICT (kind, key) DO UPDATE
+    SET failed_count   = CASE
+                             WHEN login_lockouts.last_failed_at < sqlc.arg(reset_before) THEN 1
+                             ELSE login_lockouts.failed_count + 1
+        END,
+        last_failed_at = now()
+RETURNING *;
+
+-- name: LockLogin :one
+UPDATE login_lockouts
+SET locked_until = sqlc.arg(locked_until)
+WHERE kind = sqlc.arg(kind)
+  AND key = sqlc.arg(key)
+RETURNING *;
+
+-- name: ResetLoginLockout :exec
+DELETE
+FROM login_lockouts
+WHERE kind = $1
+  AND key = $2;
+
+-- name: ListLoginFailureClientIps :many
+SELECT DISTINCT client_ip
+FROM audit_events
+WHERE target = sqlc.arg(target)
+  AND action = sqlc.arg(action)
+  AND created_at >= sqlc.arg(since)
+  AND client_ip <> '';
@@ -0,0 +1,54 @@
+-- name: CreateOutboxTask :one
+INSERT INTO outbox (task_id,
+                    task_type,
+                    payload,
+                    queue,
+                    max_retry,
+                    process_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListPendingOutboxTasks :many
+SELECT *
+FROM outbox
+WHERE published_at IS NULL
+  AND dead_at IS NULL
+ORDER BY id
+LIMIT $1 FOR UPDATE SKIP LOCKED;
+
+-- name: MarkOutboxTaskPublished :exec
+UPDATE outbox
+SET published_at = now()
+WHERE id = $1;
+
+-- name: RecordOutboxTaskFailure :one
+-- the task is given up once it failed max_attempts times, or never if max_attempts is 0
+UPDATE outbox
+SET attempts   = attempts + 1,
+    last_error = sqlc.arg(last_error),
+    dead_at    = CASE
+                     WHEN sqlc.arg(max_attempts)::integer > 0 AND attempts + 1 >= sqlc.arg(max_attempts)::integer
+                         THEN now()
+        END
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ReviveOutboxTask :execrows
+UPDATE outbox
+SET attempts = 0,
+    dead_at  = NULL
+WHERE queue = $1
+  AND task_id = $2
+  AND dead_at IS NOT NULL;
+
+-- name: DeleteDeadOutboxTask :execrows
+DELETE
+FROM outbox
+WHERE queue = $1
+  AND task_id = $2
+  AND dead_at IS NOT NULL;
+
+-- name: DeletePublishedOutboxTasks :execrows
+DELETE
+FROM outbox
+WHERE published_at < sqlc.arg(published_before)::timestamptz;
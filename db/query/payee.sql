@@ -0,0 +1,51 @@
+-- name: CreatePayee :one
+INSERT INTO payees (owner, nickname, account_id, currency, cooling_off_until, cooling_off_max_amount, account_hidden)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetPayee :one
+SELECT *
+FROM payees
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListPayees :many
+SELECT *
+FROM payees
+WHERE owner = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+
+-- name: UpdatePayee :one
+UPDATE payees
+SET nickname = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: DeletePayee :exec
+DELETE
+FROM payees
+WHERE id = $1;
+
+-- name: GetPayeeByAccount :one
+SELECT *
+FROM payees
+WHERE owner = $1
+  AND account_id = $2
+LIMIT 1;
+
+-- name: GetPayeeTransferTotal :one
+SELECT COALESCE(SUM(t.amount), 0)::bigint
+FROM transfers t
+         JOIN accounts a ON a.id = t.from_account_id
+WHERE a.owner = sqlc.arg(owner)
+  AND t.to_account_id = sqlc.arg(account_id)
+  AND t.created_at >= sqlc.arg(since);
+
+-- name: GetRecipientTransferSummary :one
+SELECT COALESCE(MIN(t.created_at), now())::timestamptz AS first_paid_at,
+       COALESCE(SUM(t.amount), 0)::bigint           AS total
+FROM transfers t
+         JOIN accounts a ON a.id = t.from_account_id
+WHERE a.owner = sqlc.arg(owner)
+  AND t.to_account_id = sqlc.arg(account_id);
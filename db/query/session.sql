@@ -13,4 +13,12 @@ RETURNING *;
 SELECT *
 FROM sessions
 WHERE id = $1
-LIMIT 1;
\ No newline at end of file
+LIMIT 1;
+
+-- name: BlockSessions :many
+UPDATE sessions
+SET is_blocked = true
+WHERE username = sqlc.arg(username)
+  AND (sqlc.narg(id)::uuid IS NULL OR id = sqlc.narg(id))
+  AND is_blocked = false
+RETURNING *;
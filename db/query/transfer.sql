@@ -1,6 +1,6 @@
 -- name: CreateTransfer :one
-INSERT INTO transfers (amount, from_account_id, to_account_id)
-values ($1, $2, $3)
+INSERT INTO transfers (amount, from_account_id, to_account_id, fee, fee_rule_id)
+values ($1, $2, $3, sqlc.arg(fee), sqlc.narg(fee_rule_id))
 RETURNING *;
 -- name: GetTransfer :one
 SELECT * FROM transfers
@@ -0,0 +1,55 @@
+-- name: ListTransferLimitsForAccount :many
+SELECT *
+FROM transfer_limits
+WHERE account_id = sqlc.arg(account_id)::bigint
+   OR (username = sqlc.arg(owner)::varchar AND currency = sqlc.arg(currency))
+   OR (username IS NULL AND account_id IS NULL AND currency = sqlc.arg(currency));
+
+-- name: GetTransferLimitForUpdate :one
+SELECT *
+FROM transfer_limits
+WHERE currency = sqlc.arg(currency)
+  AND username IS NOT DISTINCT FROM sqlc.narg(username)
+  AND account_id IS NOT DISTINCT FROM sqlc.narg(account_id)
+LIMIT 1
+FOR UPDATE;
+
+-- name: CreateTransferLimit :one
+INSERT INTO transfer_limits (currency,
+                             username,
+                             account_id,
+                             per_transfer_max,
+                             daily_max,
+                             monthly_max)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: UpdateTransferLimit :one
+UPDATE transfer_limits
+SET per_transfer_max = $2,
+    daily_max        = $3,
+    monthly_max      = $4,
+    updated_at       = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteTransferLimit :exec
+DELETE
+FROM transfer_limits
+WHERE id = $1;
+
+-- name: GetAccountTransferUsage :one
+SELECT COALESCE(SUM(amount) FILTER (WHERE created_at >= sqlc.arg(day_start)), 0)::bigint AS daily,
+       COALESCE(SUM(amount), 0)::bigint                                                  AS monthly
+FROM transfers
+WHERE from_account_id = sqlc.arg(account_id)
+  AND created_at >= sqlc.arg(month_start);
+
+-- name: GetUserTransferUsage :one
+SELECT COALESCE(SUM(t.amount) FILTER (WHERE t.created_at >= sqlc.arg(day_start)), 0)::bigint AS daily,
+       COALESCE(SUM(t.amount), 0)::bigint                                                    AS monthly
+FROM transfers t
+         JOIN accounts a ON a.id = t.from_account_id
+WHERE a.owner = sqlc.arg(owner)
+  AND a.currency = sqlc.arg(currency)
+  AND t.created_at >= sqlc.arg(month_start);
@@ -13,6 +13,20 @@ SET hashed_password     = COALESCE(sqlc.narg(hashed_password), hashed_password),
     password_changed_at = COALESCE(sqlc.narg(password_changed_at), password_changed_at),
     full_name           = COALESCE(sqlc.narg(full_name), full_name),
     email               = COALESCE(sqlc.narg(email), email),
-    is_email_verified   = COALESCE(sqlc.narg(is_email_verified), is_email_verified)
+    is_email_verified   = COALESCE(sqlc.narg(is_email_verified), is_email_verified),
+    role                = COALESCE(sqlc.narg(role), role)
 WHERE username = sqlc.arg(username)
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetUserForUpdate :one
+SELECT *
+FROM users
+WHERE username = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetUserByEmail :one
+SELECT *
+FROM users
+WHERE email = $1
+LIMIT 1;
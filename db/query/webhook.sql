@@ -0,0 +1,50 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (owner,
+                      url,
+                      event_types,
+                      secret)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetWebhook :one
+SELECT *
+FROM webhooks
+WHERE id = $1
+LIMIT 1;
+
+-- name: CreateWebhookDeliveries :many
+INSERT INTO webhook_deliveries (webhook_id,
+                                event_id,
+                                event_type,
+                                payload)
+SELECT id,
+       sqlc.arg(event_id)::bigint,
+       sqlc.arg(event_type)::varchar,
+       sqlc.arg(payload)::jsonb
+FROM webhooks
+WHERE owner = sqlc.arg(owner)
+  AND sqlc.arg(event_type)::varchar = ANY (event_types)
+RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT *
+FROM webhook_deliveries
+WHERE id = $1
+LIMIT 1;
+
+-- name: UpdateWebhookDeliveryAttempt :one
+UPDATE webhook_deliveries
+SET status        = $2,
+    attempts      = attempts + 1,
+    response_code = $3,
+    last_error    = $4,
+    updated_at    = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: ListWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY id DESC
+LIMIT $2 OFFSET $3;
@@ -7,13 +7,16 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + $1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, frozen, product
 `
 
 type AddAccountBalanceParams struct {
@@ -30,25 +33,33 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Frozen,
+		&i.Product,
 	)
 	return i, err
 }
 
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (owner,
-                      balance, currency)
-VALUES ($1, $2, $3)
-RETURNING id, owner, balance, currency, created_at
+                      balance, currency, product)
+VALUES ($1, $2, $3, $4)
+RETURNING id, owner, balance, currency, created_at, frozen, product
 `
 
 type CreateAccountParams struct {
 	Owner    string `json:"owner"`
 	Balance  int64  `json:"balance"`
 	Currency string `json:"currency"`
+	Product  string `json:"product"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := q.db.QueryRowContext(ctx, createAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.Product,
+	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -56,10 +67,30 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Frozen,
+		&i.Product,
 	)
 	return i, err
 }
 
+const createAccountIfNotExists = `-- name: CreateAccountIfNotExists :exec
+INSERT INTO accounts (owner,
+                      balance, currency, product)
+VALUES ($1, 0, $2, $3)
+ON CONFLICT (owner, currency, product) DO NOTHING
+`
+
+type CreateAccountIfNotExistsParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+	Product  string `json:"product"`
+}
+
+func (q *Queries) CreateAccountIfNotExists(ctx context.Context, arg CreateAccountIfNotExistsParams) error {
+	_, err := q.db.ExecContext(ctx, createAccountIfNotExists, arg.Owner, arg.Currency, arg.Product)
+	return err
+}
+
 const deleteAccount = `-- name: DeleteAccount :exec
 DELETE FROM accounts
 WHERE id = $1
@@ -71,7 +102,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at
+SELECT id, owner, balance, currency, created_at, frozen, product
 FROM accounts
 WHERE id = $1
 LIMIT 1
@@ -86,12 +117,44 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Frozen,
+		&i.Product,
+	)
+	return i, err
+}
+
+const getAccountByProduct = `-- name: GetAccountByProduct :one
+SELECT id, owner, balance, currency, created_at, frozen, product
+FROM accounts
+WHERE owner = $1
+  AND currency = $2
+  AND product = $3
+LIMIT 1
+`
+
+type GetAccountByProductParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+	Product  string `json:"product"`
+}
+
+func (q *Queries) GetAccountByProduct(ctx context.Context, arg GetAccountByProductParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByProduct, arg.Owner, arg.Currency, arg.Product)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Frozen,
+		&i.Product,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at
+SELECT id, owner, balance, currency, created_at, frozen, product
 FROM accounts
 WHERE id = $1
 LIMIT 1 FOR NO KEY UPDATE
@@ -106,12 +169,52 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Frozen,
+		&i.Product,
 	)
 	return i, err
 }
 
+const listAccountIDsCreatedBefore = `-- name: ListAccountIDsCreatedBefore :many
+SELECT id
+FROM accounts
+WHERE created_at < $1
+  AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListAccountIDsCreatedBeforeParams struct {
+	CreatedBefore time.Time `json:"created_before"`
+	AfterID       int64     `json:"after_id"`
+	PageSize      int32     `json:"page_size"`
+}
+
+func (q *Queries) ListAccountIDsCreatedBefore(ctx context.Context, arg ListAccountIDsCreatedBeforeParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountIDsCreatedBefore, arg.CreatedBefore, arg.AfterID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at
+SELECT id, owner, balance, currency, created_at, frozen, product
 FROM accounts
 WHERE owner = $1
 ORDER BY id
@@ -139,6 +242,47 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.Frozen,
+			&i.Product,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsForUpdate = `-- name: ListAccountsForUpdate :many
+SELECT id, owner, balance, currency, created_at, frozen, product
+FROM accounts
+WHERE id = ANY ($1::bigint[])
+ORDER BY id
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) ListAccountsForUpdate(ctx context.Context, ids []int64) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsForUpdate, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Frozen,
+			&i.Product,
 		); err != nil {
 			return nil, err
 		}
@@ -153,11 +297,38 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	return items, nil
 }
 
+const setAccountFrozen = `-- name: SetAccountFrozen :one
+UPDATE accounts
+SET frozen = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, frozen, product
+`
+
+type SetAccountFrozenParams struct {
+	ID     int64 `json:"id"`
+	Frozen bool  `json:"frozen"`
+}
+
+func (q *Queries) SetAccountFrozen(ctx context.Context, arg SetAccountFrozenParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, setAccountFrozen, arg.ID, arg.Frozen)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Frozen,
+		&i.Product,
+	)
+	return i, err
+}
+
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, frozen, product
 `
 
 type UpdateAccountParams struct {
@@ -174,6 +345,8 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Frozen,
+		&i.Product,
 	)
 	return i, err
 }
@@ -15,6 +15,7 @@ func createRandomAccount(t *testing.T) Account {
 		Owner:    user.Username,
 		Balance:  util.RandomBalance(),
 		Currency: util.RandomCurrency(),
+		Product:  ProductChecking,
 	}
 
 	account, err := testQueries.CreateAccount(context.Background(), arg)
@@ -24,6 +25,7 @@ func createRandomAccount(t *testing.T) Account {
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Balance, account.Balance)
 	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, arg.Product, account.Product)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
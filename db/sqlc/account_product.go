@@ -0,0 +1,21 @@
+package db
+
+// Account products. Customers open checking and savings accounts; internal products are the ledger accounts of
+// the bank.
+const (
+	ProductChecking        = "checking"
+	ProductSavings         = "savings"
+	ProductInterestExpense = "interest_expense"
+	ProductFeeRevenue      = "fee_revenue"
+)
+
+// BankLedgerOwner is the user owning the ledger accounts of the bank. It cannot log in, and since usernames are
+// alphanumeric no customer can register it.
+const BankLedgerOwner = "simplebank.ledger"
+
+// Kinds of interest accruals. A carry accrual holds the rounding remainder of a posting, and is posted with the
+// next month.
+const (
+	InterestAccrualDaily = "daily"
+	InterestAccrualCarry = "carry"
+)
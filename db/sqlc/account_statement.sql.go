@@ -0,0 +1,180 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: account_statement.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createAccountStatement = `-- name: CreateAccountStatement :one
+INSERT INTO account_statements (account_id,
+                                period_start,
+                                period_end,
+                                blob_key,
+                                size_bytes,
+                                opening_balance,
+                                closing_balance)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (account_id, period_start) DO UPDATE
+    SET period_end      = EXCLUDED.period_end,
+        blob_key        = EXCLUDED.blob_key,
+        size_bytes      = EXCLUDED.size_bytes,
+        opening_balance = EXCLUDED.opening_balance,
+        closing_balance = EXCLUDED.closing_balance
+RETURNING id, account_id, period_start, period_end, blob_key, size_bytes, opening_balance, closing_balance, created_at, notified_at
+`
+
+type CreateAccountStatementParams struct {
+	AccountID      int64     `json:"account_id"`
+	PeriodStart    time.Time `json:"period_start"`
+	PeriodEnd      time.Time `json:"period_end"`
+	BlobKey        string    `json:"blob_key"`
+	SizeBytes      int64     `json:"size_bytes"`
+	OpeningBalance int64     `json:"opening_balance"`
+	ClosingBalance int64     `json:"closing_balance"`
+}
+
+func (q *Queries) CreateAccountStatement(ctx context.Context, arg CreateAccountStatementParams) (AccountStatement, error) {
+	row := q.db.QueryRowContext(ctx, createAccountStatement,
+		arg.AccountID,
+		arg.PeriodStart,
+		arg.PeriodEnd,
+		arg.BlobKey,
+		arg.SizeBytes,
+		arg.OpeningBalance,
+		arg.ClosingBalance,
+	)
+	var i AccountStatement
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.BlobKey,
+		&i.SizeBytes,
+		&i.OpeningBalance,
+		&i.ClosingBalance,
+		&i.CreatedAt,
+		&i.NotifiedAt,
+	)
+	return i, err
+}
+
+const getAccountStatement = `-- name: GetAccountStatement :one
+SELECT id, account_id, period_start, period_end, blob_key, size_bytes, opening_balance, closing_balance, created_at, notified_at
+FROM account_statements
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetAccountStatement(ctx context.Context, id int64) (AccountStatement, error) {
+	row := q.db.QueryRowContext(ctx, getAccountStatement, id)
+	var i AccountStatement
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.BlobKey,
+		&i.SizeBytes,
+		&i.OpeningBalance,
+		&i.ClosingBalance,
+		&i.CreatedAt,
+		&i.NotifiedAt,
+	)
+	return i, err
+}
+
+const getAccountStatementByPeriod = `-- name: GetAccountStatementByPeriod :one
+SELECT id, account_id, period_start, period_end, blob_key, size_bytes, opening_balance, closing_balance, created_at, notified_at
+FROM account_statements
+WHERE account_id = $1
+  AND period_start = $2
+LIMIT 1
+`
+
+type GetAccountStatementByPeriodParams struct {
+	AccountID   int64     `json:"account_id"`
+	PeriodStart time.Time `json:"period_start"`
+}
+
+func (q *Queries) GetAccountStatementByPeriod(ctx context.Context, arg GetAccountStatementByPeriodParams) (AccountStatement, error) {
+	row := q.db.QueryRowContext(ctx, getAccountStatementByPeriod, arg.AccountID, arg.PeriodStart)
+	var i AccountStatement
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.BlobKey,
+		&i.SizeBytes,
+		&i.OpeningBalance,
+		&i.ClosingBalance,
+		&i.CreatedAt,
+		&i.NotifiedAt,
+	)
+	return i, err
+}
+
+const listAccountStatements = `-- name: ListAccountStatements :many
+SELECT id, account_id, period_start, period_end, blob_key, size_bytes, opening_balance, closing_balance, created_at, notified_at
+FROM account_statements
+WHERE account_id = $1
+ORDER BY period_start DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListAccountStatementsParams struct {
+	AccountID int64 `json:"account_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+func (q *Queries) ListAccountStatements(ctx context.Context, arg ListAccountStatementsParams) ([]AccountStatement, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountStatements, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountStatement{}
+	for rows.Next() {
+		var i AccountStatement
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.PeriodStart,
+			&i.PeriodEnd,
+			&i.BlobKey,
+			&i.SizeBytes,
+			&i.OpeningBalance,
+			&i.ClosingBalance,
+			&i.CreatedAt,
+			&i.NotifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markAccountStatementNotified = `-- name: MarkAccountStatementNotified :exec
+UPDATE account_statements
+SET notified_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) MarkAccountStatementNotified(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markAccountStatementNotified, id)
+	return err
+}
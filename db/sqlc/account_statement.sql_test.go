@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomAccountStatement(t *testing.T, account Account, periodStart time.Time) AccountStatement {
+	arg := CreateAccountStatementParams{
+		AccountID:      account.ID,
+		PeriodStart:    periodStart,
+		PeriodEnd:      periodStart.AddDate(0, 1, 0),
+		BlobKey:        fmt.Sprintf("statements/%d/%s.pdf", account.ID, periodStart.Format("2006-01")),
+		SizeBytes:      1024,
+		OpeningBalance: 100,
+		ClosingBalance: 150,
+	}
+
+	statement, err := testQueries.CreateAccountStatement(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, statement.ID)
+	require.Equal(t, arg.AccountID, statement.AccountID)
+	require.WithinDuration(t, arg.PeriodStart, statement.PeriodStart, time.Second)
+	require.Equal(t, arg.BlobKey, statement.BlobKey)
+	require.Equal(t, arg.ClosingBalance, statement.ClosingBalance)
+	require.False(t, statement.NotifiedAt.Valid)
+	return statement
+}
+
+func TestCreateAccountStatementReplacesPeriod(t *testing.T) {
+	account := createRandomAccount(t)
+	periodStart := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	statement1 := createRandomAccountStatement(t, account, periodStart)
+
+	statement2, err := testQueries.CreateAccountStatement(context.Background(), CreateAccountStatementParams{
+		AccountID:      account.ID,
+		PeriodStart:    periodStart,
+		PeriodEnd:      statement1.PeriodEnd,
+		BlobKey:        statement1.BlobKey,
+		SizeBytes:      2048,
+		OpeningBalance: 100,
+		ClosingBalance: 150,
+	})
+	require.NoError(t, err)
+	require.Equal(t, statement1.ID, statement2.ID)
+	require.Equal(t, int64(2048), statement2.SizeBytes)
+
+	statement3, err := testQueries.GetAccountStatementByPeriod(context.Background(), GetAccountStatementByPeriodParams{
+		AccountID:   account.ID,
+		PeriodStart: periodStart,
+	})
+	require.NoError(t, err)
+	require.Equal(t, statement1.ID, statement3.ID)
+}
+
+func TestListAccountStatements(t *testing.T) {
+	account := createRandomAccount(t)
+	january := createRandomAccountStatement(t, account, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	february := createRandomAccountStatement(t, account, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC))
+
+	statements, err := testQueries.ListAccountStatements(context.Background(), ListAccountStatementsParams{
+		AccountID: account.ID,
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, statements, 2)
+	require.Equal(t, february.ID, statements[0].ID)
+	require.Equal(t, january.ID, statements[1].ID)
+}
+
+func TestMarkAccountStatementNotified(t *testing.T) {
+	account := createRandomAccount(t)
+	statement := createRandomAccountStatement(t, account, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	require.NoError(t, testQueries.MarkAccountStatementNotified(context.Background(), statement.ID))
+	statement, err := testQueries.GetAccountStatement(context.Background(), statement.ID)
+	require.NoError(t, err)
+	require.True(t, statement.NotifiedAt.Valid)
+
+	_, err = testQueries.GetAccountStatement(context.Background(), statement.ID+1000000)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListAccountIDsCreatedBefore(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	ids, err := testQueries.ListAccountIDsCreatedBefore(context.Background(), ListAccountIDsCreatedBeforeParams{
+		CreatedBefore: time.Now().Add(time.Minute),
+		AfterID:       account1.ID - 1,
+		PageSize:      2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int64{account1.ID, account2.ID}, ids)
+
+	ids, err = testQueries.ListAccountIDsCreatedBefore(context.Background(), ListAccountIDsCreatedBeforeParams{
+		CreatedBefore: account1.CreatedAt,
+		AfterID:       account1.ID - 1,
+		PageSize:      2,
+	})
+	require.NoError(t, err)
+	require.NotContains(t, ids, account1.ID)
+}
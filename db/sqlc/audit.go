@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditActionUserUpdate       = "user.update"
+	AuditActionPasswordChange   = "user.password_change"
+	AuditActionLogin            = "user.login"
+	AuditActionLoginFailed      = "user.login_failed"
+	AuditActionSessionCreate    = "session.create"
+	AuditActionSessionBlock     = "session.block"
+	AuditActionTransferCreate   = "transfer.create"
+	AuditActionAccountFreeze    = "account.freeze"
+	AuditActionAccountUnfreeze  = "account.unfreeze"
+	AuditActionFeeRuleSet       = "fee_rule.set"
+	AuditActionTransferLimitSet = "transfer_limit.set"
+)
+
+// AuditContext identifies who performed an audited action and from where.
+type AuditContext struct {
+	Actor     string
+	ClientIp  string
+	UserAgent string
+}
+
+// AuditTarget formats the target of an audit event from the kind and id of the affected row.
+func AuditTarget(kind string, id interface{}) string {
+	return fmt.Sprintf("%s:%v", kind, id)
+}
+
+// NewAuditEventParams builds the params of an audit event. Before and after are stored as JSON, an empty object
+// when nil.
+func NewAuditEventParams(audit AuditContext, action string, target string, before interface{}, after interface{}) (CreateAuditEventParams, error) {
+	beforeJson, err := auditSnapshot(before)
+	if err != nil {
+		return CreateAuditEventParams{}, err
+	}
+	afterJson, err := auditSnapshot(after)
+	if err != nil {
+		return CreateAuditEventParams{}, err
+	}
+
+	return CreateAuditEventParams{
+		Actor:     audit.Actor,
+		Action:    action,
+		Target:    target,
+		ClientIp:  audit.ClientIp,
+		UserAgent: audit.UserAgent,
+		Before:    beforeJson,
+		After:     afterJson,
+	}, nil
+}
+
+// recordAuditEvent writes an audit event, normally in the transaction of the audited action.
+func recordAuditEvent(ctx context.Context, q *Queries, audit AuditContext, action string, target string, before interface{}, after interface{}) error {
+	arg, err := NewAuditEventParams(audit, action, target, before, after)
+	if err != nil {
+		return fmt.Errorf("cannot build audit event: %w", err)
+	}
+	_, err = q.CreateAuditEvent(ctx, arg)
+	return err
+}
+
+func auditSnapshot(value interface{}) (json.RawMessage, error) {
+	if value == nil {
+		return json.RawMessage("{}"), nil
+	}
+	return json.Marshal(value)
+}
+
+// auditUser is the audited view of a user. It leaves out the password hash.
+type auditUser struct {
+	Username          string    `json:"username"`
+	FullName          string    `json:"full_name"`
+	Email             string    `json:"email"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	Role              string    `json:"role"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+}
+
+func newAuditUser(user User) auditUser {
+	return auditUser{
+		Username:          user.Username,
+		FullName:          user.FullName,
+		Email:             user.Email,
+		IsEmailVerified:   user.IsEmailVerified,
+		Role:              user.Role,
+		PasswordChangedAt: user.PasswordChangedAt,
+	}
+}
+
+// auditSession is the audited view of a session. It leaves out the refresh token.
+type auditSession struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	UserAgent string    `json:"user_agent"`
+	ClientIp  string    `json:"client_ip"`
+	IsBlocked bool      `json:"is_blocked"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func newAuditSession(session Session) auditSession {
+	return auditSession{
+		ID:        session.ID.String(),
+		Username:  session.Username,
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		IsBlocked: session.IsBlocked,
+		ExpiresAt: session.ExpiresAt,
+	}
+}
@@ -0,0 +1,120 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: audit_event.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const createAuditEvent = `-- name: CreateAuditEvent :one
+INSERT INTO audit_events (actor,
+                          action,
+                          target,
+                          client_ip,
+                          user_agent,
+                          before,
+                          after)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, actor, action, target, client_ip, user_agent, before, after, created_at
+`
+
+type CreateAuditEventParams struct {
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Target    string          `json:"target"`
+	ClientIp  string          `json:"client_ip"`
+	UserAgent string          `json:"user_agent"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+}
+
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error) {
+	row := q.db.QueryRowContext(ctx, createAuditEvent,
+		arg.Actor,
+		arg.Action,
+		arg.Target,
+		arg.ClientIp,
+		arg.UserAgent,
+		arg.Before,
+		arg.After,
+	)
+	var i AuditEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Actor,
+		&i.Action,
+		&i.Target,
+		&i.ClientIp,
+		&i.UserAgent,
+		&i.Before,
+		&i.After,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, actor, action, target, client_ip, user_agent, before, after, created_at
+FROM audit_events
+WHERE ($1::varchar IS NULL OR actor = $1)
+  AND ($2::varchar IS NULL OR target = $2)
+  AND created_at >= $3
+  AND created_at < $4
+ORDER BY id DESC
+LIMIT $6 OFFSET $5
+`
+
+type ListAuditEventsParams struct {
+	Actor       sql.NullString `json:"actor"`
+	Target      sql.NullString `json:"target"`
+	StartTime   time.Time      `json:"start_time"`
+	EndTime     time.Time      `json:"end_time"`
+	OffsetCount int32          `json:"offset_count"`
+	LimitCount  int32          `json:"limit_count"`
+}
+
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEvents,
+		arg.Actor,
+		arg.Target,
+		arg.StartTime,
+		arg.EndTime,
+		arg.OffsetCount,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditEvent{}
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Actor,
+			&i.Action,
+			&i.Target,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.Before,
+			&i.After,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
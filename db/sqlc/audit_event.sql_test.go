@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomAuditEvent(t *testing.T, actor string) AuditEvent {
+	audit := AuditContext{Actor: actor, ClientIp: "10.0.0.1", UserAgent: "test"}
+	arg, err := NewAuditEventParams(audit, AuditActionUserUpdate, AuditTarget("user", actor), nil, map[string]string{"full_name": util.RandomOwner()})
+	require.NoError(t, err)
+
+	event, err := testQueries.CreateAuditEvent(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, event.ID)
+	require.Equal(t, arg.Actor, event.Actor)
+	require.Equal(t, arg.Action, event.Action)
+	require.Equal(t, arg.Target, event.Target)
+	require.JSONEq(t, "{}", string(event.Before))
+	require.JSONEq(t, string(arg.After), string(event.After))
+	require.NotZero(t, event.CreatedAt)
+	return event
+}
+
+func TestCreateAuditEvent(t *testing.T) {
+	createRandomAuditEvent(t, util.RandomOwner())
+}
+
+func TestListAuditEvents(t *testing.T) {
+	actor := util.RandomOwner()
+	for i := 0; i < 3; i++ {
+		createRandomAuditEvent(t, actor)
+	}
+	createRandomAuditEvent(t, util.RandomOwner())
+
+	events, err := testQueries.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		Actor:       sql.NullString{String: actor, Valid: true},
+		StartTime:   time.Now().Add(-time.Minute),
+		EndTime:     time.Now().Add(time.Minute),
+		LimitCount:  2,
+		OffsetCount: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	for _, event := range events {
+		require.Equal(t, actor, event.Actor)
+	}
+	require.Greater(t, events[0].ID, events[1].ID)
+}
+
+func TestAuditEventsAreAppendOnly(t *testing.T) {
+	event := createRandomAuditEvent(t, util.RandomOwner())
+
+	_, err := testDB.Exec("UPDATE audit_events SET actor = 'someone' WHERE id = $1", event.ID)
+	require.ErrorContains(t, err, "append-only")
+
+	_, err = testDB.Exec("DELETE FROM audit_events WHERE id = $1", event.ID)
+	require.ErrorContains(t, err, "append-only")
+
+	_, err = testDB.Exec("TRUNCATE audit_events")
+	require.ErrorContains(t, err, "append-only")
+}
+
+func TestUpdateUserTxRecordsAuditEvent(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	newName := util.RandomOwner()
+
+	result, err := store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{
+			Username: user.Username,
+			FullName: sql.NullString{String: newName, Valid: true},
+		},
+		Audit: AuditContext{Actor: user.Username},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newName, result.User.FullName)
+
+	events, err := testQueries.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		Target:     sql.NullString{String: AuditTarget("user", user.Username), Valid: true},
+		StartTime:  time.Now().Add(-time.Minute),
+		EndTime:    time.Now().Add(time.Minute),
+		LimitCount: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, AuditActionUserUpdate, events[0].Action)
+
+	var before, after map[string]interface{}
+	require.NoError(t, json.Unmarshal(events[0].Before, &before))
+	require.NoError(t, json.Unmarshal(events[0].After, &after))
+	require.Equal(t, user.FullName, before["full_name"])
+	require.Equal(t, newName, after["full_name"])
+	require.NotContains(t, after, "hashed_password")
+}
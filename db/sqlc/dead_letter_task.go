@@ -0,0 +1,7 @@
+package db
+
+// Resolutions of a dead letter task, set when a banker retries or deletes it.
+const (
+	DeadLetterTaskRetried = "retried"
+	DeadLetterTaskDeleted = "deleted"
+)
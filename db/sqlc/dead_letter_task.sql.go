@@ -0,0 +1,118 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: dead_letter_task.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createDeadLetterTask = `-- name: CreateDeadLetterTask :one
+INSERT INTO dead_letter_tasks (task_id,
+                               task_type,
+                               queue,
+                               payload,
+                               last_error,
+                               retried,
+                               max_retry)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (queue, task_id) DO UPDATE
+    SET last_error  = EXCLUDED.last_error,
+        retried     = EXCLUDED.retried,
+        max_retry   = EXCLUDED.max_retry,
+        failed_at   = now(),
+        resolution  = '',
+        resolved_at = NULL
+RETURNING id, task_id, task_type, queue, payload, last_error, retried, max_retry, failed_at, resolution, resolved_at
+`
+
+type CreateDeadLetterTaskParams struct {
+	TaskID    string `json:"task_id"`
+	TaskType  string `json:"task_type"`
+	Queue     string `json:"queue"`
+	Payload   []byte `json:"payload"`
+	LastError string `json:"last_error"`
+	Retried   int32  `json:"retried"`
+	MaxRetry  int32  `json:"max_retry"`
+}
+
+func (q *Queries) CreateDeadLetterTask(ctx context.Context, arg CreateDeadLetterTaskParams) (DeadLetterTask, error) {
+	row := q.db.QueryRowContext(ctx, createDeadLetterTask,
+		arg.TaskID,
+		arg.TaskType,
+		arg.Queue,
+		arg.Payload,
+		arg.LastError,
+		arg.Retried,
+		arg.MaxRetry,
+	)
+	var i DeadLetterTask
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.TaskType,
+		&i.Queue,
+		&i.Payload,
+		&i.LastError,
+		&i.Retried,
+		&i.MaxRetry,
+		&i.FailedAt,
+		&i.Resolution,
+		&i.ResolvedAt,
+	)
+	return i, err
+}
+
+const getDeadLetterTask = `-- name: GetDeadLetterTask :one
+SELECT id, task_id, task_type, queue, payload, last_error, retried, max_retry, failed_at, resolution, resolved_at
+FROM dead_letter_tasks
+WHERE queue = $1
+  AND task_id = $2
+LIMIT 1
+`
+
+type GetDeadLetterTaskParams struct {
+	Queue  string `json:"queue"`
+	TaskID string `json:"task_id"`
+}
+
+func (q *Queries) GetDeadLetterTask(ctx context.Context, arg GetDeadLetterTaskParams) (DeadLetterTask, error) {
+	row := q.db.QueryRowContext(ctx, getDeadLetterTask, arg.Queue, arg.TaskID)
+	var i DeadLetterTask
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.TaskType,
+		&i.Queue,
+		&i.Payload,
+		&i.LastError,
+		&i.Retried,
+		&i.MaxRetry,
+		&i.FailedAt,
+		&i.Resolution,
+		&i.ResolvedAt,
+	)
+	return i, err
+}
+
+const resolveDeadLetterTask = `-- name: ResolveDeadLetterTask :exec
+UPDATE dead_letter_tasks
+SET resolution  = $3,
+    resolved_at = now()
+WHERE queue = $1
+  AND task_id = $2
+  AND resolved_at IS NULL
+`
+
+type ResolveDeadLetterTaskParams struct {
+	Queue      string `json:"queue"`
+	TaskID     string `json:"task_id"`
+	Resolution string `json:"resolution"`
+}
+
+func (q *Queries) ResolveDeadLetterTask(ctx context.Context, arg ResolveDeadLetterTaskParams) error {
+	_, err := q.db.ExecContext(ctx, resolveDeadLetterTask, arg.Queue, arg.TaskID, arg.Resolution)
+	return err
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func createRandomDeadLetterTask(t *testing.T) DeadLetterTask {
+	arg := CreateDeadLetterTaskParams{
+		TaskID:    uuid.NewString(),
+		TaskType:  "task:" + util.RandomString(8),
+		Queue:     "default",
+		Payload:   []byte(`{"username":"` + util.RandomOwner() + `"}`),
+		LastError: "boom",
+		Retried:   3,
+		MaxRetry:  3,
+	}
+
+	task, err := testQueries.CreateDeadLetterTask(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, task.ID)
+	require.Equal(t, arg.TaskID, task.TaskID)
+	require.Equal(t, arg.TaskType, task.TaskType)
+	require.Equal(t, arg.Queue, task.Queue)
+	require.Equal(t, arg.Payload, task.Payload)
+	require.Equal(t, arg.LastError, task.LastError)
+	require.Empty(t, task.Resolution)
+	require.False(t, task.ResolvedAt.Valid)
+	return task
+}
+
+func TestCreateDeadLetterTask(t *testing.T) {
+	createRandomDeadLetterTask(t)
+}
+
+func TestCreateDeadLetterTaskAfterRetry(t *testing.T) {
+	task := createRandomDeadLetterTask(t)
+
+	err := testQueries.ResolveDeadLetterTask(context.Background(), ResolveDeadLetterTaskParams{
+		Queue:      task.Queue,
+		TaskID:     task.TaskID,
+		Resolution: DeadLetterTaskRetried,
+	})
+	require.NoError(t, err)
+
+	resolved, err := testQueries.GetDeadLetterTask(context.Background(), GetDeadLetterTaskParams{Queue: task.Queue, TaskID: task.TaskID})
+	require.NoError(t, err)
+	require.Equal(t, DeadLetterTaskRetried, resolved.Resolution)
+	require.True(t, resolved.ResolvedAt.Valid)
+
+	// the retried task failed for good again
+	failed, err := testQueries.CreateDeadLetterTask(context.Background(), CreateDeadLetterTaskParams{
+		TaskID:    task.TaskID,
+		TaskType:  task.TaskType,
+		Queue:     task.Queue,
+		Payload:   task.Payload,
+		LastError: "boom again",
+		Retried:   3,
+		MaxRetry:  3,
+	})
+	require.NoError(t, err)
+	require.Equal(t, task.ID, failed.ID)
+	require.Equal(t, "boom again", failed.LastError)
+	require.Empty(t, failed.Resolution)
+	require.False(t, failed.ResolvedAt.Valid)
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// Types of the domain events emitted by the store transactions.
+const (
+	DomainEventAccountOpened     = "account.opened"
+	DomainEventTransferCompleted = "transfer.completed"
+	DomainEventBalanceChanged    = "balance.changed"
+	DomainEventUserVerified      = "user.verified"
+)
+
+// AccountOpenedEvent is the payload of an account.opened event.
+type AccountOpenedEvent struct {
+	AccountID int64  `json:"account_id"`
+	Owner     string `json:"owner"`
+	Currency  string `json:"currency"`
+	Balance   int64  `json:"balance"`
+}
+
+// TransferCompletedEvent is the payload of a transfer.completed event.
+type TransferCompletedEvent struct {
+	TransferID    int64 `json:"transfer_id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	Amount        int64 `json:"amount"`
+	Fee           int64 `json:"fee"`
+}
+
+// BalanceChangedEvent is the payload of a balance.changed event. Amount is the signed change, Balance the balance
+// after it.
+type BalanceChangedEvent struct {
+	AccountID int64  `json:"account_id"`
+	EntryID   int64  `json:"entry_id"`
+	Currency  string `json:"currency"`
+	Amount    int64  `json:"amount"`
+	Balance   int64  `json:"balance"`
+}
+
+// UserVerifiedEvent is the payload of a user.verified event.
+type UserVerifiedEvent struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// recordDomainEvent writes a domain event visible to owner in the transaction that caused it, along with the
+// deliveries to the owner's webhooks. An accountID of 0 means the event is not about an account.
+func recordDomainEvent(ctx context.Context, q *Queries, owner string, accountID int64, eventType string, payload interface{}) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("cannot marshal domain event: %w", err)
+	}
+
+	event, err := q.CreateDomainEvent(ctx, CreateDomainEventParams{
+		Owner: owner,
+		AccountID: sql.NullInt64{
+			Int64: accountID,
+			Valid: accountID != 0,
+		},
+		EventType: eventType,
+		Payload:   jsonPayload,
+	})
+	if err != nil {
+		return err
+	}
+
+	return enqueueWebhookDeliveries(ctx, q, event)
+}
+
+// recordTransferEvents emits the events of a transfer to the owner of each account: the transfer itself and the
+// balance changes of the account, the fee being a change of its own. An owner of both accounts gets the events of
+// both. The fee revenue account, when the transfer has a fee, gets the balance change of the fee.
+func recordTransferEvents(ctx context.Context, q *Queries, result TransferTxResult) error {
+	transfer := TransferCompletedEvent{
+		TransferID:    result.Transfer.ID,
+		FromAccountID: result.Transfer.FromAccountID,
+		ToAccountID:   result.Transfer.ToAccountID,
+		Amount:        result.Transfer.Amount,
+		Fee:           result.Transfer.Fee,
+	}
+
+	fromBalance := result.FromAccount.Balance
+	if result.FeeEntry != nil {
+		fromBalance -= result.FeeEntry.Amount
+	}
+	sides := []struct {
+		account Account
+		entry   Entry
+		balance int64
+	}{
+		{result.FromAccount, result.FromEntry, fromBalance},
+		{result.ToAccount, result.ToEntry, result.ToAccount.Balance},
+	}
+	for _, side := range sides {
+		err := recordDomainEvent(ctx, q, side.account.Owner, side.account.ID, DomainEventTransferCompleted, transfer)
+		if err != nil {
+			return err
+		}
+
+		err = recordBalanceChangedEvent(ctx, q, side.account, side.entry, side.balance)
+		if err != nil {
+			return err
+		}
+	}
+
+	if result.FeeEntry != nil {
+		err := recordBalanceChangedEvent(ctx, q, result.FromAccount, *result.FeeEntry, result.FromAccount.Balance)
+		if err != nil {
+			return err
+		}
+	}
+	if result.RevenueEntry != nil {
+		return recordBalanceChangedEvent(ctx, q, *result.RevenueAccount, *result.RevenueEntry, result.RevenueAccount.Balance)
+	}
+	return nil
+}
+
+// recordBalanceChangedEvent emits the change of entry to the owner of account, whose balance is balance after it.
+func recordBalanceChangedEvent(ctx context.Context, q *Queries, account Account, entry Entry, balance int64) error {
+	return recordDomainEvent(ctx, q, account.Owner, account.ID, DomainEventBalanceChanged, BalanceChangedEvent{
+		AccountID: account.ID,
+		EntryID:   entry.ID,
+		Currency:  account.Currency,
+		Amount:    entry.Amount,
+		Balance:   balance,
+	})
+}
@@ -0,0 +1,146 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: domain_event.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+const assignDomainEventPositions = `-- name: AssignDomainEventPositions :execrows
+UPDATE domain_events
+SET position = numbered.position
+FROM (SELECT id,
+             (SELECT COALESCE(MAX(position), 0) FROM domain_events) + row_number() OVER (ORDER BY id) AS position
+      FROM domain_events
+      WHERE position IS NULL
+      ORDER BY id
+      LIMIT $1) AS numbered
+WHERE domain_events.id = numbered.id
+`
+
+// numbers the committed events without a position after the last position, in id order
+func (q *Queries) AssignDomainEventPositions(ctx context.Context, limitCount int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, assignDomainEventPositions, limitCount)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createDomainEvent = `-- name: CreateDomainEvent :one
+INSERT INTO domain_events (owner,
+                           account_id,
+                           event_type,
+                           payload)
+VALUES ($1, $2, $3, $4)
+RETURNING id, owner, account_id, event_type, payload, created_at, position
+`
+
+type CreateDomainEventParams struct {
+	Owner     string          `json:"owner"`
+	AccountID sql.NullInt64   `json:"account_id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+func (q *Queries) CreateDomainEvent(ctx context.Context, arg CreateDomainEventParams) (DomainEvent, error) {
+	row := q.db.QueryRowContext(ctx, createDomainEvent,
+		arg.Owner,
+		arg.AccountID,
+		arg.EventType,
+		arg.Payload,
+	)
+	var i DomainEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.AccountID,
+		&i.EventType,
+		&i.Payload,
+		&i.CreatedAt,
+		&i.Position,
+	)
+	return i, err
+}
+
+const getLatestDomainEventOffset = `-- name: GetLatestDomainEventOffset :one
+SELECT COALESCE(MAX(position), 0)::bigint AS offset
+FROM domain_events
+`
+
+func (q *Queries) GetLatestDomainEventOffset(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getLatestDomainEventOffset)
+	var offset int64
+	err := row.Scan(&offset)
+	return offset, err
+}
+
+const listDomainEvents = `-- name: ListDomainEvents :many
+SELECT id, owner, account_id, event_type, payload, created_at, position
+FROM domain_events
+WHERE owner = $1
+  AND position > $2::bigint
+  AND ($3::bigint IS NULL OR account_id = $3)
+ORDER BY position
+LIMIT $4
+`
+
+type ListDomainEventsParams struct {
+	Owner       string        `json:"owner"`
+	AfterOffset int64         `json:"after_offset"`
+	AccountID   sql.NullInt64 `json:"account_id"`
+	LimitCount  int32         `json:"limit_count"`
+}
+
+func (q *Queries) ListDomainEvents(ctx context.Context, arg ListDomainEventsParams) ([]DomainEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listDomainEvents,
+		arg.Owner,
+		arg.AfterOffset,
+		arg.AccountID,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DomainEvent{}
+	for rows.Next() {
+		var i DomainEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.AccountID,
+			&i.EventType,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.Position,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const tryLockDomainEventPositions = `-- name: TryLockDomainEventPositions :one
+SELECT pg_try_advisory_xact_lock(hashtext('domain_events.position')) AS locked
+`
+
+// only one transaction at a time gives out positions, so that they commit in order
+func (q *Queries) TryLockDomainEventPositions(ctx context.Context) (bool, error) {
+	row := q.db.QueryRowContext(ctx, tryLockDomainEventPositions)
+	var locked bool
+	err := row.Scan(&locked)
+	return locked, err
+}
@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// assignAllDomainEventPositions gives every committed event its position.
+func assignAllDomainEventPositions(t *testing.T) {
+	store := NewStore(testDB)
+	for {
+		result, err := store.AssignDomainEventPositionsTx(context.Background(), AssignDomainEventPositionsTxParams{Limit: 1000})
+		require.NoError(t, err)
+		if result.Assigned == 0 {
+			return
+		}
+	}
+}
+
+func listAllDomainEvents(t *testing.T, owner string) []DomainEvent {
+	assignAllDomainEventPositions(t)
+	events, err := testQueries.ListDomainEvents(context.Background(), ListDomainEventsParams{
+		Owner:      owner,
+		LimitCount: 100,
+	})
+	require.NoError(t, err)
+	return events
+}
+
+func TestCreateAccountTxEmitsAccountOpened(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	result, err := store.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		CreateAccountParams: CreateAccountParams{
+			Owner:    user.Username,
+			Currency: util.RandomCurrency(),
+			Product:  ProductChecking,
+		},
+	})
+	require.NoError(t, err)
+
+	events := listAllDomainEvents(t, user.Username)
+	require.Len(t, events, 1)
+	require.Equal(t, DomainEventAccountOpened, events[0].EventType)
+	require.Equal(t, sql.NullInt64{Int64: result.Account.ID, Valid: true}, events[0].AccountID)
+
+	var payload AccountOpenedEvent
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+	require.Equal(t, result.Account.Currency, payload.Currency)
+}
+
+func TestTransferTxEmitsEvents(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	amount := int64(10)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+
+	for _, account := range []Account{result.FromAccount, result.ToAccount} {
+		events := listAllDomainEvents(t, account.Owner)
+		require.Len(t, events, 2)
+		require.Equal(t, DomainEventTransferCompleted, events[0].EventType)
+		require.Equal(t, DomainEventBalanceChanged, events[1].EventType)
+		require.Less(t, events[0].Position.Int64, events[1].Position.Int64)
+
+		var changed BalanceChangedEvent
+		require.NoError(t, json.Unmarshal(events[1].Payload, &changed))
+		require.Equal(t, account.ID, changed.AccountID)
+		require.Equal(t, account.Balance, changed.Balance)
+	}
+
+	// resuming after the first event skips it
+	events, err := testQueries.ListDomainEvents(context.Background(), ListDomainEventsParams{
+		Owner:       account1.Owner,
+		AfterOffset: listAllDomainEvents(t, account1.Owner)[0].Position.Int64,
+		AccountID:   sql.NullInt64{Int64: account1.ID, Valid: true},
+		LimitCount:  100,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, DomainEventBalanceChanged, events[0].EventType)
+}
+
+func TestDomainEventPositionsFollowCommitOrder(t *testing.T) {
+	user := createRandomUser(t)
+	assignAllDomainEventPositions(t)
+	latest, err := testQueries.GetLatestDomainEventOffset(context.Background())
+	require.NoError(t, err)
+
+	createEvent := func(tx *sql.Tx) DomainEvent {
+		event, err := New(tx).CreateDomainEvent(context.Background(), CreateDomainEventParams{
+			Owner:     user.Username,
+			EventType: DomainEventUserVerified,
+			Payload:   []byte(`{}`),
+		})
+		require.NoError(t, err)
+		return event
+	}
+
+	// the first transaction takes the lower id but commits last
+	tx1, err := testDB.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+	defer tx1.Rollback()
+	tx2, err := testDB.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+	defer tx2.Rollback()
+
+	event1 := createEvent(tx1)
+	event2 := createEvent(tx2)
+	require.Less(t, event1.ID, event2.ID)
+	require.NoError(t, tx2.Commit())
+
+	// a subscriber reads the event of the second transaction while the first one is still running
+	events := listAllDomainEvents(t, user.Username)
+	require.Len(t, events, 1)
+	require.Equal(t, event2.ID, events[0].ID)
+	require.Greater(t, events[0].Position.Int64, latest)
+	offset := events[0].Position.Int64
+
+	require.NoError(t, tx1.Commit())
+
+	// and resumes after it: the event committed last comes after it, where resuming after the id would skip it
+	assignAllDomainEventPositions(t)
+	events, err = testQueries.ListDomainEvents(context.Background(), ListDomainEventsParams{
+		Owner:       user.Username,
+		AfterOffset: offset,
+		LimitCount:  100,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, event1.ID, events[0].ID)
+	require.Greater(t, events[0].Position.Int64, offset)
+}
@@ -7,27 +7,38 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
-INSERT INTO entries (account_id,amount)
-VALUES ($1,$2)
-RETURNING id, account_id, amount, created_at
+INSERT INTO entries (account_id,amount,transfer_id,is_fee)
+VALUES ($1,$2,$3,$4)
+RETURNING id, account_id, amount, created_at, transfer_id, is_fee
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID  int64         `json:"account_id"`
+	Amount     int64         `json:"amount"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	IsFee      bool          `json:"is_fee"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.TransferID,
+		arg.IsFee,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.IsFee,
 	)
 	return i, err
 }
@@ -41,8 +52,46 @@ func (q *Queries) DeleteEntry(ctx context.Context, id int64) error {
 	return err
 }
 
+const getAccountStatementBalances = `-- name: GetAccountStatementBalances :one
+SELECT a.balance,
+       COALESCE((SELECT SUM(e.amount) FROM entries e
+                 WHERE e.account_id = a.id AND e.created_at >= $1::timestamptz), 0)::bigint AS after_period,
+       COALESCE((SELECT SUM(e.amount) FROM entries e
+                 WHERE e.account_id = a.id
+                   AND e.created_at >= $2::timestamptz
+                   AND e.created_at < $1::timestamptz), 0)::bigint AS in_period,
+       COALESCE((SELECT MAX(e.id) FROM entries e WHERE e.account_id = a.id), 0)::bigint AS last_entry_id
+FROM accounts a
+WHERE a.id = $3
+`
+
+type GetAccountStatementBalancesParams struct {
+	ToTime    time.Time `json:"to_time"`
+	FromTime  time.Time `json:"from_time"`
+	AccountID int64     `json:"account_id"`
+}
+
+type GetAccountStatementBalancesRow struct {
+	Balance     int64 `json:"balance"`
+	AfterPeriod int64 `json:"after_period"`
+	InPeriod    int64 `json:"in_period"`
+	LastEntryID int64 `json:"last_entry_id"`
+}
+
+func (q *Queries) GetAccountStatementBalances(ctx context.Context, arg GetAccountStatementBalancesParams) (GetAccountStatementBalancesRow, error) {
+	row := q.db.QueryRowContext(ctx, getAccountStatementBalances, arg.ToTime, arg.FromTime, arg.AccountID)
+	var i GetAccountStatementBalancesRow
+	err := row.Scan(
+		&i.Balance,
+		&i.AfterPeriod,
+		&i.InPeriod,
+		&i.LastEntryID,
+	)
+	return i, err
+}
+
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries WHERE id = $1 LIMIT 1
+SELECT id, account_id, amount, created_at, transfer_id, is_fee FROM entries WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
@@ -53,12 +102,58 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.IsFee,
 	)
 	return i, err
 }
 
+const listAccountEntries = `-- name: ListAccountEntries :many
+SELECT id, account_id, amount, created_at, transfer_id, is_fee FROM entries
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListAccountEntriesParams struct {
+	AccountID int64 `json:"account_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntries, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.IsFee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id, is_fee FROM entries
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -83,6 +178,78 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+			&i.IsFee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatementEntries = `-- name: ListStatementEntries :many
+SELECT e.id, e.amount, e.created_at, e.transfer_id, e.is_fee, t.from_account_id, t.to_account_id
+FROM entries e
+LEFT JOIN transfers t ON t.id = e.transfer_id
+WHERE e.account_id = $1
+  AND e.created_at >= $2
+  AND e.created_at < $3
+  AND e.id > $4
+  AND e.id <= $5
+ORDER BY e.id
+LIMIT $6
+`
+
+type ListStatementEntriesParams struct {
+	AccountID   int64     `json:"account_id"`
+	FromTime    time.Time `json:"from_time"`
+	ToTime      time.Time `json:"to_time"`
+	AfterID     int64     `json:"after_id"`
+	LastEntryID int64     `json:"last_entry_id"`
+	PageSize    int32     `json:"page_size"`
+}
+
+type ListStatementEntriesRow struct {
+	ID            int64         `json:"id"`
+	Amount        int64         `json:"amount"`
+	CreatedAt     time.Time     `json:"created_at"`
+	TransferID    sql.NullInt64 `json:"transfer_id"`
+	IsFee         bool          `json:"is_fee"`
+	FromAccountID sql.NullInt64 `json:"from_account_id"`
+	ToAccountID   sql.NullInt64 `json:"to_account_id"`
+}
+
+func (q *Queries) ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStatementEntries,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.AfterID,
+		arg.LastEntryID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListStatementEntriesRow{}
+	for rows.Next() {
+		var i ListStatementEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.IsFee,
+			&i.FromAccountID,
+			&i.ToAccountID,
 		); err != nil {
 			return nil, err
 		}
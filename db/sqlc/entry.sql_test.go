@@ -6,6 +6,7 @@ import (
 	"github.com/kwalter26/udemy-simplebank/util"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 // Create a random entry
@@ -97,3 +98,66 @@ func TestDeleteEntry(t *testing.T) {
 	require.Empty(t, entry2)
 	require.EqualError(t, err, sql.ErrNoRows.Error())
 }
+
+// TestListAccountEntries: tests the ListAccountEntries function
+func TestListAccountEntries(t *testing.T) {
+	entry := createRandomEntry(t)
+	createRandomEntry(t)
+
+	entries, err := testQueries.ListAccountEntries(context.Background(), ListAccountEntriesParams{
+		AccountID: entry.AccountID,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, entry, entries[0])
+}
+
+// TestAccountStatementEntries: tests the balances and the entries of an account statement
+func TestAccountStatementEntries(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	store := NewStore(testDB)
+
+	from := time.Now().Add(-time.Minute)
+	results := make([]TransferTxResult, 3)
+	for i := range results {
+		result, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        int64(i + 1),
+		})
+		require.NoError(t, err)
+		results[i] = result
+	}
+	to := time.Now().Add(time.Minute)
+
+	balances, err := testQueries.GetAccountStatementBalances(context.Background(), GetAccountStatementBalancesParams{
+		AccountID: account1.ID,
+		FromTime:  from,
+		ToTime:    to,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-6, balances.Balance)
+	require.Zero(t, balances.AfterPeriod)
+	require.Equal(t, int64(-6), balances.InPeriod)
+	require.Equal(t, results[2].FromEntry.ID, balances.LastEntryID)
+
+	entries, err := testQueries.ListStatementEntries(context.Background(), ListStatementEntriesParams{
+		AccountID:   account1.ID,
+		FromTime:    from,
+		ToTime:      to,
+		AfterID:     results[0].FromEntry.ID,
+		LastEntryID: balances.LastEntryID,
+		PageSize:    5,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	for i, entry := range entries {
+		result := results[i+1]
+		require.Equal(t, result.FromEntry.ID, entry.ID)
+		require.Equal(t, result.Transfer.ID, entry.TransferID.Int64)
+		require.Equal(t, account1.ID, entry.FromAccountID.Int64)
+		require.Equal(t, account2.ID, entry.ToAccountID.Int64)
+	}
+}
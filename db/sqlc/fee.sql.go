@@ -0,0 +1,186 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: fee.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const countTransfersSince = `-- name: CountTransfersSince :one
+SELECT COUNT(*)
+FROM transfers
+WHERE from_account_id = $1
+  AND created_at >= $2
+`
+
+type CountTransfersSinceParams struct {
+	FromAccountID int64     `json:"from_account_id"`
+	Since         time.Time `json:"since"`
+}
+
+func (q *Queries) CountTransfersSince(ctx context.Context, arg CountTransfersSinceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countTransfersSince, arg.FromAccountID, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createFeeRule = `-- name: CreateFeeRule :one
+INSERT INTO fee_rules (currency,
+                       kind,
+                       flat_amount,
+                       rate_bps,
+                       min_amount,
+                       max_amount,
+                       free_transfers_per_month)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, currency, kind, flat_amount, rate_bps, min_amount, max_amount, free_transfers_per_month, active, created_at
+`
+
+type CreateFeeRuleParams struct {
+	Currency              string `json:"currency"`
+	Kind                  string `json:"kind"`
+	FlatAmount            int64  `json:"flat_amount"`
+	RateBps               int32  `json:"rate_bps"`
+	MinAmount             int64  `json:"min_amount"`
+	MaxAmount             int64  `json:"max_amount"`
+	FreeTransfersPerMonth int32  `json:"free_transfers_per_month"`
+}
+
+func (q *Queries) CreateFeeRule(ctx context.Context, arg CreateFeeRuleParams) (FeeRule, error) {
+	row := q.db.QueryRowContext(ctx, createFeeRule,
+		arg.Currency,
+		arg.Kind,
+		arg.FlatAmount,
+		arg.RateBps,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.FreeTransfersPerMonth,
+	)
+	var i FeeRule
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.Kind,
+		&i.FlatAmount,
+		&i.RateBps,
+		&i.MinAmount,
+		&i.MaxAmount,
+		&i.FreeTransfersPerMonth,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deactivateFeeRules = `-- name: DeactivateFeeRules :many
+UPDATE fee_rules
+SET active = false
+WHERE currency = $1
+  AND active
+RETURNING id, currency, kind, flat_amount, rate_bps, min_amount, max_amount, free_transfers_per_month, active, created_at
+`
+
+func (q *Queries) DeactivateFeeRules(ctx context.Context, currency string) ([]FeeRule, error) {
+	rows, err := q.db.QueryContext(ctx, deactivateFeeRules, currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FeeRule{}
+	for rows.Next() {
+		var i FeeRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Currency,
+			&i.Kind,
+			&i.FlatAmount,
+			&i.RateBps,
+			&i.MinAmount,
+			&i.MaxAmount,
+			&i.FreeTransfersPerMonth,
+			&i.Active,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActiveFeeRule = `-- name: GetActiveFeeRule :one
+SELECT id, currency, kind, flat_amount, rate_bps, min_amount, max_amount, free_transfers_per_month, active, created_at
+FROM fee_rules
+WHERE currency = $1
+  AND active
+LIMIT 1
+`
+
+func (q *Queries) GetActiveFeeRule(ctx context.Context, currency string) (FeeRule, error) {
+	row := q.db.QueryRowContext(ctx, getActiveFeeRule, currency)
+	var i FeeRule
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.Kind,
+		&i.FlatAmount,
+		&i.RateBps,
+		&i.MinAmount,
+		&i.MaxAmount,
+		&i.FreeTransfersPerMonth,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listFeeRules = `-- name: ListFeeRules :many
+SELECT id, currency, kind, flat_amount, rate_bps, min_amount, max_amount, free_transfers_per_month, active, created_at
+FROM fee_rules
+ORDER BY currency, id DESC
+`
+
+func (q *Queries) ListFeeRules(ctx context.Context) ([]FeeRule, error) {
+	rows, err := q.db.QueryContext(ctx, listFeeRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FeeRule{}
+	for rows.Next() {
+		var i FeeRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Currency,
+			&i.Kind,
+			&i.FlatAmount,
+			&i.RateBps,
+			&i.MinAmount,
+			&i.MaxAmount,
+			&i.FreeTransfersPerMonth,
+			&i.Active,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
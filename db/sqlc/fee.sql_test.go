@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"github.com/kwalter26/udemy-simplebank/fees"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// setFeeRule makes rule the active fee rule of currency until the test ends, when transfers become free again so
+// that other tests are not charged.
+func setFeeRule(t *testing.T, currency string, rule fees.Rule) FeeRule {
+	store := NewStore(testDB)
+	result, err := store.SetFeeRuleTx(context.Background(), SetFeeRuleTxParams{Currency: currency, Rule: &rule})
+	require.NoError(t, err)
+	require.NotNil(t, result.Rule)
+	t.Cleanup(func() {
+		_, err := store.SetFeeRuleTx(context.Background(), SetFeeRuleTxParams{Currency: currency})
+		require.NoError(t, err)
+	})
+	return *result.Rule
+}
+
+func TestSetFeeRuleTx(t *testing.T) {
+	first := setFeeRule(t, util.CAD, fees.Rule{Kind: fees.KindFlat, FlatAmount: 2})
+	require.True(t, first.Active)
+
+	store := NewStore(testDB)
+	result, err := store.SetFeeRuleTx(context.Background(), SetFeeRuleTxParams{
+		Currency: util.CAD,
+		Rule:     &fees.Rule{Kind: fees.KindPercentage, RateBps: 100, MinAmount: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Deactivated, 1)
+	require.Equal(t, first.ID, result.Deactivated[0].ID)
+	require.False(t, result.Deactivated[0].Active)
+
+	active, err := testQueries.GetActiveFeeRule(context.Background(), util.CAD)
+	require.NoError(t, err)
+	require.Equal(t, result.Rule.ID, active.ID)
+	require.Equal(t, fees.KindPercentage, active.Kind)
+}
+
+func TestTransferTxFee(t *testing.T) {
+	store := NewStore(testDB)
+	rule := setFeeRule(t, util.EUR, fees.Rule{Kind: fees.KindFlat, FlatAmount: 2, FreeTransfersPerMonth: 1})
+	account1 := createRandomAccountInCurrency(t, util.EUR)
+	account2 := createRandomAccountInCurrency(t, util.EUR)
+
+	quote, err := store.QuoteTransferFee(context.Background(), QuoteTransferFeeParams{FromAccount: account1, Amount: 10})
+	require.NoError(t, err)
+	require.Zero(t, quote.Fee)
+	require.Equal(t, int32(1), quote.FreeTransfersRemaining)
+	require.Equal(t, rule.ID, quote.Rule.ID)
+
+	// the first transfer of the month is free
+	result, err := store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	require.NoError(t, err)
+	require.Zero(t, result.Transfer.Fee)
+	require.Equal(t, rule.ID, result.Transfer.FeeRuleID.Int64)
+	require.Nil(t, result.FeeEntry)
+	require.Equal(t, account1.Balance-10, result.FromAccount.Balance)
+
+	quote, err = store.QuoteTransferFee(context.Background(), QuoteTransferFeeParams{FromAccount: account1, Amount: 10})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), quote.Fee)
+	require.Zero(t, quote.FreeTransfersRemaining)
+
+	revenue, err := ledgerAccount(context.Background(), testQueries, util.EUR, ProductFeeRevenue)
+	require.NoError(t, err)
+
+	result, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Transfer.Fee)
+	require.Equal(t, account1.Balance-22, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+20, result.ToAccount.Balance)
+
+	require.NotNil(t, result.FeeEntry)
+	require.Equal(t, account1.ID, result.FeeEntry.AccountID)
+	require.Equal(t, int64(-2), result.FeeEntry.Amount)
+	require.True(t, result.FeeEntry.IsFee)
+	require.Equal(t, result.Transfer.ID, result.FeeEntry.TransferID.Int64)
+
+	require.NotNil(t, result.RevenueEntry)
+	require.Equal(t, revenue.ID, result.RevenueEntry.AccountID)
+	require.Equal(t, int64(2), result.RevenueEntry.Amount)
+	require.Equal(t, BankLedgerOwner, result.RevenueAccount.Owner)
+	require.Equal(t, ProductFeeRevenue, result.RevenueAccount.Product)
+	require.Equal(t, revenue.Balance+2, result.RevenueAccount.Balance)
+}
+
+func TestQuoteTransferFeeLedgerAccount(t *testing.T) {
+	setFeeRule(t, util.USD, fees.Rule{Kind: fees.KindFlat, FlatAmount: 2})
+	expense, err := ledgerAccount(context.Background(), testQueries, util.USD, ProductInterestExpense)
+	require.NoError(t, err)
+
+	quote, err := NewStore(testDB).QuoteTransferFee(context.Background(), QuoteTransferFeeParams{FromAccount: expense, Amount: 10})
+	require.NoError(t, err)
+	require.Zero(t, quote.Fee)
+	require.Nil(t, quote.Rule)
+}
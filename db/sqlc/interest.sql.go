@@ -0,0 +1,334 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: interest.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createInterestAccrual = `-- name: CreateInterestAccrual :one
+INSERT INTO interest_accruals (account_id,
+                               accrual_date,
+                               balance,
+                               annual_rate_bps,
+                               compounding,
+                               amount_micros)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (account_id, accrual_date, kind) DO NOTHING
+RETURNING account_id, accrual_date, balance, annual_rate_bps, compounding, amount_micros, transfer_id, created_at, kind
+`
+
+type CreateInterestAccrualParams struct {
+	AccountID     int64     `json:"account_id"`
+	AccrualDate   time.Time `json:"accrual_date"`
+	Balance       int64     `json:"balance"`
+	AnnualRateBps int32     `json:"annual_rate_bps"`
+	Compounding   string    `json:"compounding"`
+	AmountMicros  int64     `json:"amount_micros"`
+}
+
+func (q *Queries) CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (InterestAccrual, error) {
+	row := q.db.QueryRowContext(ctx, createInterestAccrual,
+		arg.AccountID,
+		arg.AccrualDate,
+		arg.Balance,
+		arg.AnnualRateBps,
+		arg.Compounding,
+		arg.AmountMicros,
+	)
+	var i InterestAccrual
+	err := row.Scan(
+		&i.AccountID,
+		&i.AccrualDate,
+		&i.Balance,
+		&i.AnnualRateBps,
+		&i.Compounding,
+		&i.AmountMicros,
+		&i.TransferID,
+		&i.CreatedAt,
+		&i.Kind,
+	)
+	return i, err
+}
+
+const createInterestCarry = `-- name: CreateInterestCarry :one
+INSERT INTO interest_accruals (account_id,
+                               accrual_date,
+                               kind,
+                               balance,
+                               annual_rate_bps,
+                               compounding,
+                               amount_micros)
+VALUES ($1, $2, 'carry', $3, $4, $5, $6)
+RETURNING account_id, accrual_date, balance, annual_rate_bps, compounding, amount_micros, transfer_id, created_at, kind
+`
+
+type CreateInterestCarryParams struct {
+	AccountID     int64     `json:"account_id"`
+	AccrualDate   time.Time `json:"accrual_date"`
+	Balance       int64     `json:"balance"`
+	AnnualRateBps int32     `json:"annual_rate_bps"`
+	Compounding   string    `json:"compounding"`
+	AmountMicros  int64     `json:"amount_micros"`
+}
+
+func (q *Queries) CreateInterestCarry(ctx context.Context, arg CreateInterestCarryParams) (InterestAccrual, error) {
+	row := q.db.QueryRowContext(ctx, createInterestCarry,
+		arg.AccountID,
+		arg.AccrualDate,
+		arg.Balance,
+		arg.AnnualRateBps,
+		arg.Compounding,
+		arg.AmountMicros,
+	)
+	var i InterestAccrual
+	err := row.Scan(
+		&i.AccountID,
+		&i.AccrualDate,
+		&i.Balance,
+		&i.AnnualRateBps,
+		&i.Compounding,
+		&i.AmountMicros,
+		&i.TransferID,
+		&i.CreatedAt,
+		&i.Kind,
+	)
+	return i, err
+}
+
+const getAccountProduct = `-- name: GetAccountProduct :one
+SELECT code, name, annual_rate_bps, compounding, internal, created_at
+FROM account_products
+WHERE code = $1
+LIMIT 1
+`
+
+func (q *Queries) GetAccountProduct(ctx context.Context, code string) (AccountProduct, error) {
+	row := q.db.QueryRowContext(ctx, getAccountProduct, code)
+	var i AccountProduct
+	err := row.Scan(
+		&i.Code,
+		&i.Name,
+		&i.AnnualRateBps,
+		&i.Compounding,
+		&i.Internal,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPendingInterestMicros = `-- name: GetPendingInterestMicros :one
+SELECT COALESCE(SUM(amount_micros), 0)::bigint
+FROM interest_accruals
+WHERE account_id = $1
+  AND transfer_id IS NULL
+  AND accrual_date < $2
+`
+
+type GetPendingInterestMicrosParams struct {
+	AccountID  int64     `json:"account_id"`
+	BeforeDate time.Time `json:"before_date"`
+}
+
+func (q *Queries) GetPendingInterestMicros(ctx context.Context, arg GetPendingInterestMicrosParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getPendingInterestMicros, arg.AccountID, arg.BeforeDate)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const listAccountProducts = `-- name: ListAccountProducts :many
+SELECT code, name, annual_rate_bps, compounding, internal, created_at
+FROM account_products
+ORDER BY code
+`
+
+func (q *Queries) ListAccountProducts(ctx context.Context) ([]AccountProduct, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountProducts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountProduct{}
+	for rows.Next() {
+		var i AccountProduct
+		if err := rows.Scan(
+			&i.Code,
+			&i.Name,
+			&i.AnnualRateBps,
+			&i.Compounding,
+			&i.Internal,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInterestAccruals = `-- name: ListInterestAccruals :many
+SELECT account_id, accrual_date, balance, annual_rate_bps, compounding, amount_micros, transfer_id, created_at, kind
+FROM interest_accruals
+WHERE account_id = $1
+ORDER BY accrual_date DESC, kind
+LIMIT $2 OFFSET $3
+`
+
+type ListInterestAccrualsParams struct {
+	AccountID int64 `json:"account_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+func (q *Queries) ListInterestAccruals(ctx context.Context, arg ListInterestAccrualsParams) ([]InterestAccrual, error) {
+	rows, err := q.db.QueryContext(ctx, listInterestAccruals, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []InterestAccrual{}
+	for rows.Next() {
+		var i InterestAccrual
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.AccrualDate,
+			&i.Balance,
+			&i.AnnualRateBps,
+			&i.Compounding,
+			&i.AmountMicros,
+			&i.TransferID,
+			&i.CreatedAt,
+			&i.Kind,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInterestBearingAccountIDs = `-- name: ListInterestBearingAccountIDs :many
+SELECT a.id
+FROM accounts a
+         JOIN account_products p ON p.code = a.product
+WHERE p.annual_rate_bps > 0
+  AND NOT p.internal
+  AND a.created_at < $1
+  AND a.id > $2
+ORDER BY a.id
+LIMIT $3
+`
+
+type ListInterestBearingAccountIDsParams struct {
+	CreatedBefore time.Time `json:"created_before"`
+	AfterID       int64     `json:"after_id"`
+	PageSize      int32     `json:"page_size"`
+}
+
+func (q *Queries) ListInterestBearingAccountIDs(ctx context.Context, arg ListInterestBearingAccountIDsParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listInterestBearingAccountIDs, arg.CreatedBefore, arg.AfterID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingInterestAccrualsForUpdate = `-- name: ListPendingInterestAccrualsForUpdate :many
+SELECT account_id, accrual_date, balance, annual_rate_bps, compounding, amount_micros, transfer_id, created_at, kind
+FROM interest_accruals
+WHERE account_id = $1
+  AND transfer_id IS NULL
+  AND accrual_date < $2
+ORDER BY accrual_date, kind
+FOR UPDATE
+`
+
+type ListPendingInterestAccrualsForUpdateParams struct {
+	AccountID  int64     `json:"account_id"`
+	BeforeDate time.Time `json:"before_date"`
+}
+
+func (q *Queries) ListPendingInterestAccrualsForUpdate(ctx context.Context, arg ListPendingInterestAccrualsForUpdateParams) ([]InterestAccrual, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingInterestAccrualsForUpdate, arg.AccountID, arg.BeforeDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []InterestAccrual{}
+	for rows.Next() {
+		var i InterestAccrual
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.AccrualDate,
+			&i.Balance,
+			&i.AnnualRateBps,
+			&i.Compounding,
+			&i.AmountMicros,
+			&i.TransferID,
+			&i.CreatedAt,
+			&i.Kind,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markInterestAccrualsPosted = `-- name: MarkInterestAccrualsPosted :exec
+UPDATE interest_accruals
+SET transfer_id = $1
+WHERE account_id = $2
+  AND transfer_id IS NULL
+  AND accrual_date < $3
+`
+
+type MarkInterestAccrualsPostedParams struct {
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	AccountID  int64         `json:"account_id"`
+	BeforeDate time.Time     `json:"before_date"`
+}
+
+func (q *Queries) MarkInterestAccrualsPosted(ctx context.Context, arg MarkInterestAccrualsPostedParams) error {
+	_, err := q.db.ExecContext(ctx, markInterestAccrualsPosted, arg.TransferID, arg.AccountID, arg.BeforeDate)
+	return err
+}
@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/kwalter26/udemy-simplebank/interest"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomSavingsAccount(t *testing.T) Account {
+	user := createRandomUser(t)
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  1000,
+		Currency: util.USD,
+		Product:  ProductSavings,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ProductSavings, account.Product)
+	return account
+}
+
+func addInterestAccrual(t *testing.T, account Account, day time.Time, micros int64) {
+	_, err := testQueries.CreateInterestAccrual(context.Background(), CreateInterestAccrualParams{
+		AccountID:     account.ID,
+		AccrualDate:   day,
+		Balance:       account.Balance,
+		AnnualRateBps: 250,
+		Compounding:   interest.CompoundingDaily,
+		AmountMicros:  micros,
+	})
+	require.NoError(t, err)
+}
+
+func TestAccountProducts(t *testing.T) {
+	products, err := testQueries.ListAccountProducts(context.Background())
+	require.NoError(t, err)
+	codes := make([]string, 0, len(products))
+	for _, product := range products {
+		codes = append(codes, product.Code)
+	}
+	require.Subset(t, codes, []string{ProductChecking, ProductInterestExpense, ProductSavings})
+
+	savings, err := testQueries.GetAccountProduct(context.Background(), ProductSavings)
+	require.NoError(t, err)
+	require.Positive(t, savings.AnnualRateBps)
+	require.True(t, interest.IsSupportedCompounding(savings.Compounding))
+	require.False(t, savings.Internal)
+}
+
+func TestListInterestBearingAccountIDs(t *testing.T) {
+	checking := createRandomAccount(t)
+	savings := createRandomSavingsAccount(t)
+
+	ids, err := testQueries.ListInterestBearingAccountIDs(context.Background(), ListInterestBearingAccountIDsParams{
+		CreatedBefore: time.Now().Add(time.Minute),
+		AfterID:       checking.ID - 1,
+		PageSize:      1000,
+	})
+	require.NoError(t, err)
+	require.Contains(t, ids, savings.ID)
+	require.NotContains(t, ids, checking.ID)
+
+	// accounts opened after the day are left out
+	ids, err = testQueries.ListInterestBearingAccountIDs(context.Background(), ListInterestBearingAccountIDsParams{
+		CreatedBefore: savings.CreatedAt,
+		AfterID:       savings.ID - 1,
+		PageSize:      1000,
+	})
+	require.NoError(t, err)
+	require.NotContains(t, ids, savings.ID)
+}
+
+func TestCreateInterestAccrualOncePerDay(t *testing.T) {
+	account := createRandomSavingsAccount(t)
+	day := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+	addInterestAccrual(t, account, day, 68_493)
+
+	_, err := testQueries.CreateInterestAccrual(context.Background(), CreateInterestAccrualParams{
+		AccountID:     account.ID,
+		AccrualDate:   day,
+		Balance:       account.Balance,
+		AnnualRateBps: 250,
+		Compounding:   interest.CompoundingDaily,
+		AmountMicros:  1,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	pending, err := testQueries.GetPendingInterestMicros(context.Background(), GetPendingInterestMicrosParams{
+		AccountID:  account.ID,
+		BeforeDate: day.AddDate(0, 0, 1),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(68_493), pending)
+}
+
+// TestPostInterestTx posts a partial month: the account earned interest for its last three days only.
+func TestPostInterestTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomSavingsAccount(t)
+	for day := 29; day <= 31; day++ {
+		addInterestAccrual(t, account, time.Date(2023, 1, day, 0, 0, 0, 0, time.UTC), 400_000)
+	}
+	nextMonth := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	addInterestAccrual(t, account, nextMonth, 400_000)
+
+	result, err := store.PostInterestTx(context.Background(), PostInterestTxParams{AccountID: account.ID, Before: nextMonth})
+	require.NoError(t, err)
+	require.Len(t, result.Accruals, 3)
+	require.NotNil(t, result.Transfer)
+	// 1.2 rounds to 1
+	require.Equal(t, int64(1), result.Transfer.Transfer.Amount)
+	require.Equal(t, account.ID, result.Transfer.ToAccount.ID)
+	require.Equal(t, account.Balance+1, result.Transfer.ToAccount.Balance)
+	require.Equal(t, BankLedgerOwner, result.Transfer.FromAccount.Owner)
+	require.Equal(t, ProductInterestExpense, result.Transfer.FromAccount.Product)
+	require.Equal(t, account.Currency, result.Transfer.FromAccount.Currency)
+
+	// the remaining 0.2 is carried into the next month
+	require.NotNil(t, result.Carry)
+	require.Equal(t, InterestAccrualCarry, result.Carry.Kind)
+	require.Equal(t, int64(200_000), result.Carry.AmountMicros)
+	require.True(t, result.Carry.AccrualDate.Equal(nextMonth))
+	require.False(t, result.Carry.TransferID.Valid)
+
+	accruals, err := testQueries.ListInterestAccruals(context.Background(), ListInterestAccrualsParams{AccountID: account.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, accruals, 5)
+	require.Equal(t, InterestAccrualCarry, accruals[0].Kind)
+	require.Equal(t, InterestAccrualDaily, accruals[1].Kind)
+	for _, accrual := range accruals[:2] {
+		require.False(t, accrual.TransferID.Valid)
+	}
+	for _, accrual := range accruals[2:] {
+		require.Equal(t, result.Transfer.Transfer.ID, accrual.TransferID.Int64)
+	}
+
+	// posting the same days again does nothing
+	result, err = store.PostInterestTx(context.Background(), PostInterestTxParams{AccountID: account.ID, Before: nextMonth})
+	require.NoError(t, err)
+	require.Empty(t, result.Accruals)
+	require.Nil(t, result.Transfer)
+}
+
+func TestPostInterestTxKeepsFractions(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomSavingsAccount(t)
+	addInterestAccrual(t, account, time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC), 300_000)
+
+	result, err := store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID: account.ID,
+		Before:    time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.Nil(t, result.Transfer)
+
+	pending, err := testQueries.GetPendingInterestMicros(context.Background(), GetPendingInterestMicrosParams{
+		AccountID:  account.ID,
+		BeforeDate: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(300_000), pending)
+}
+
+// TestPostInterestTxCarriesRemainder posts months whose daily micros do not sum to whole units: the remainder of
+// each posting is paid with a later month, so over the months the account receives all of its interest.
+func TestPostInterestTxCarriesRemainder(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomSavingsAccount(t)
+
+	var accrued, posted int64
+	for month := time.January; month <= time.March; month++ {
+		start := time.Date(2023, month, 1, 0, 0, 0, 0, time.UTC)
+		end := start.AddDate(0, 1, 0)
+		for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+			addInterestAccrual(t, account, day, 40_000)
+			accrued += 40_000
+		}
+
+		result, err := store.PostInterestTx(context.Background(), PostInterestTxParams{AccountID: account.ID, Before: end})
+		require.NoError(t, err)
+		require.NotNil(t, result.Transfer)
+		posted += result.Transfer.Transfer.Amount
+
+		require.NotNil(t, result.Carry)
+		require.True(t, result.Carry.AccrualDate.Equal(end))
+		require.Equal(t, accrued-posted*interest.MicrosPerUnit, result.Carry.AmountMicros)
+
+		pending, err := testQueries.GetPendingInterestMicros(context.Background(), GetPendingInterestMicrosParams{
+			AccountID:  account.ID,
+			BeforeDate: end.AddDate(0, 0, 1),
+		})
+		require.NoError(t, err)
+		require.Equal(t, result.Carry.AmountMicros, pending)
+	}
+	// 1.24, 1.12 and 1.24 would each round to 1; with the remainders carried the months post 1, 1 and 2
+	require.Equal(t, int64(4), posted)
+	require.Equal(t, int64(-400_000), accrued-posted*interest.MicrosPerUnit)
+}
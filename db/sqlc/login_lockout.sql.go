@@ -0,0 +1,151 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: login_lockout.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const getLoginLockout = `-- name: GetLoginLockout :one
+SELECT kind, key, failed_count, last_failed_at, locked_until
+FROM login_lockouts
+WHERE kind = $1
+  AND key = $2
+LIMIT 1
+`
+
+type GetLoginLockoutParams struct {
+	Kind string `json:"kind"`
+	Key  string `json:"key"`
+}
+
+func (q *Queries) GetLoginLockout(ctx context.Context, arg GetLoginLockoutParams) (LoginLockout, error) {
+	row := q.db.QueryRowContext(ctx, getLoginLockout, arg.Kind, arg.Key)
+	var i LoginLockout
+	err := row.Scan(
+		&i.Kind,
+		&i.Key,
+		&i.FailedCount,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const listLoginFailureClientIps = `-- name: ListLoginFailureClientIps :many
+SELECT DISTINCT client_ip
+FROM audit_events
+WHERE target = $1
+  AND action = $2
+  AND created_at >= $3
+  AND client_ip <> ''
+`
+
+type ListLoginFailureClientIpsParams struct {
+	Target string    `json:"target"`
+	Action string    `json:"action"`
+	Since  time.Time `json:"since"`
+}
+
+func (q *Queries) ListLoginFailureClientIps(ctx context.Context, arg ListLoginFailureClientIpsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listLoginFailureClientIps, arg.Target, arg.Action, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var client_ip string
+		if err := rows.Scan(&client_ip); err != nil {
+			return nil, err
+		}
+		items = append(items, client_ip)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockLogin = `-- name: LockLogin :one
+UPDATE login_lockouts
+SET locked_until = $1
+WHERE kind = $2
+  AND key = $3
+RETURNING kind, key, failed_count, last_failed_at, locked_until
+`
+
+type LockLoginParams struct {
+	LockedUntil time.Time `json:"locked_until"`
+	Kind        string    `json:"kind"`
+	Key         string    `json:"key"`
+}
+
+func (q *Queries) LockLogin(ctx context.Context, arg LockLoginParams) (LoginLockout, error) {
+	row := q.db.QueryRowContext(ctx, lockLogin, arg.LockedUntil, arg.Kind, arg.Key)
+	var i LoginLockout
+	err := row.Scan(
+		&i.Kind,
+		&i.Key,
+		&i.FailedCount,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_lockouts (kind, key, failed_count, last_failed_at)
+VALUES ($1, $2, 1, now())
+ON CONFLICT (kind, key) DO UPDATE
+    SET failed_count   = CASE
+                             WHEN login_lockouts.last_failed_at < $3 THEN 1
+                             ELSE login_lockouts.failed_count + 1
+        END,
+        last_failed_at = now()
+RETURNING kind, key, failed_count, last_failed_at, locked_until
+`
+
+type RecordLoginFailureParams struct {
+	Kind        string    `json:"kind"`
+	Key         string    `json:"key"`
+	ResetBefore time.Time `json:"reset_before"`
+}
+
+// failures older than reset_before are forgotten, so that the count only grows with failures close to each other
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginLockout, error) {
+	row := q.db.QueryRowContext(ctx, recordLoginFailure, arg.Kind, arg.Key, arg.ResetBefore)
+	var i LoginLockout
+	err := row.Scan(
+		&i.Kind,
+		&i.Key,
+		&i.FailedCount,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const resetLoginLockout = `-- name: ResetLoginLockout :exec
+DELETE
+FROM login_lockouts
+WHERE kind = $1
+  AND key = $2
+`
+
+type ResetLoginLockoutParams struct {
+	Kind string `json:"kind"`
+	Key  string `json:"key"`
+}
+
+func (q *Queries) ResetLoginLockout(ctx context.Context, arg ResetLoginLockoutParams) error {
+	_, err := q.db.ExecContext(ctx, resetLoginLockout, arg.Kind, arg.Key)
+	return err
+}
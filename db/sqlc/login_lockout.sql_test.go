@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRecordLoginFailure(t *testing.T) {
+	key := util.RandomOwner()
+	arg := RecordLoginFailureParams{Kind: "username", Key: key}
+
+	lockout1, err := testQueries.RecordLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Kind, lockout1.Kind)
+	require.Equal(t, arg.Key, lockout1.Key)
+	require.Equal(t, int32(1), lockout1.FailedCount)
+	require.True(t, lockout1.LockedUntil.Before(time.Now()))
+
+	lockout2, err := testQueries.RecordLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), lockout2.FailedCount)
+}
+
+func TestRecordLoginFailureAfterWindow(t *testing.T) {
+	key := util.RandomOwner()
+	arg := RecordLoginFailureParams{Kind: "username", Key: key}
+
+	for i := 0; i < 3; i++ {
+		_, err := testQueries.RecordLoginFailure(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	// the last failure is older than the reset time, so the count starts again
+	arg.ResetBefore = time.Now().Add(time.Minute)
+	lockout, err := testQueries.RecordLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), lockout.FailedCount)
+}
+
+func TestLockLogin(t *testing.T) {
+	key := util.RandomOwner()
+	_, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{Kind: "client_ip", Key: key})
+	require.NoError(t, err)
+
+	lockedUntil := time.Now().Add(time.Minute)
+	lockout, err := testQueries.LockLogin(context.Background(), LockLoginParams{
+		Kind:        "client_ip",
+		Key:         key,
+		LockedUntil: lockedUntil,
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, lockedUntil, lockout.LockedUntil, time.Second)
+
+	lockout2, err := testQueries.GetLoginLockout(context.Background(), GetLoginLockoutParams{Kind: "client_ip", Key: key})
+	require.NoError(t, err)
+	require.Equal(t, lockout.FailedCount, lockout2.FailedCount)
+	require.WithinDuration(t, lockout.LockedUntil, lockout2.LockedUntil, time.Second)
+}
+
+func TestResetLoginLockout(t *testing.T) {
+	key := util.RandomOwner()
+	_, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{Kind: "username", Key: key})
+	require.NoError(t, err)
+
+	err = testQueries.ResetLoginLockout(context.Background(), ResetLoginLockoutParams{Kind: "username", Key: key})
+	require.NoError(t, err)
+
+	lockout, err := testQueries.GetLoginLockout(context.Background(), GetLoginLockoutParams{Kind: "username", Key: key})
+	require.Error(t, err)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+	require.Empty(t, lockout)
+}
@@ -5,6 +5,8 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +18,76 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// frozen accounts can neither send nor receive transfers
+	Frozen  bool   `json:"frozen"`
+	Product string `json:"product"`
+}
+
+type AccountProduct struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// yearly interest rate in basis points
+	AnnualRateBps int32 `json:"annual_rate_bps"`
+	// daily or monthly
+	Compounding string `json:"compounding"`
+	// internal products are the ledger accounts of the bank
+	Internal  bool      `json:"internal"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AccountStatement struct {
+	ID          int64     `json:"id"`
+	AccountID   int64     `json:"account_id"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	// key of the PDF in the blob store
+	BlobKey        string    `json:"blob_key"`
+	SizeBytes      int64     `json:"size_bytes"`
+	OpeningBalance int64     `json:"opening_balance"`
+	ClosingBalance int64     `json:"closing_balance"`
+	CreatedAt      time.Time `json:"created_at"`
+	// when the owner was emailed about the statement
+	NotifiedAt sql.NullTime `json:"notified_at"`
+}
+
+type AuditEvent struct {
+	ID     int64  `json:"id"`
+	Actor  string `json:"actor"`
+	Action string `json:"action"`
+	// kind and id of the affected row, e.g. user:alice
+	Target    string          `json:"target"`
+	ClientIp  string          `json:"client_ip"`
+	UserAgent string          `json:"user_agent"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type DeadLetterTask struct {
+	ID        int64     `json:"id"`
+	TaskID    string    `json:"task_id"`
+	TaskType  string    `json:"task_type"`
+	Queue     string    `json:"queue"`
+	Payload   []byte    `json:"payload"`
+	LastError string    `json:"last_error"`
+	Retried   int32     `json:"retried"`
+	MaxRetry  int32     `json:"max_retry"`
+	FailedAt  time.Time `json:"failed_at"`
+	// empty while unresolved, then retried or deleted
+	Resolution string       `json:"resolution"`
+	ResolvedAt sql.NullTime `json:"resolved_at"`
+}
+
+type DomainEvent struct {
+	ID int64 `json:"id"`
+	// user allowed to see the event
+	Owner     string          `json:"owner"`
+	AccountID sql.NullInt64   `json:"account_id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+	// offset subscribers resume from, given in commit order once the event is committed
+	Position sql.NullInt64 `json:"position"`
 }
 
 type Entry struct {
@@ -24,6 +96,84 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// transfer that made the entry, if any
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	// entry of the fee of its transfer
+	IsFee bool `json:"is_fee"`
+}
+
+type FeeRule struct {
+	ID       int64  `json:"id"`
+	Currency string `json:"currency"`
+	// flat or percentage
+	Kind       string `json:"kind"`
+	FlatAmount int64  `json:"flat_amount"`
+	// fee of a percentage rule in basis points of the amount
+	RateBps   int32 `json:"rate_bps"`
+	MinAmount int64 `json:"min_amount"`
+	// 0 means no maximum
+	MaxAmount int64 `json:"max_amount"`
+	// transfers of a calendar month that are not charged
+	FreeTransfersPerMonth int32     `json:"free_transfers_per_month"`
+	Active                bool      `json:"active"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+type InterestAccrual struct {
+	AccountID   int64     `json:"account_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+	// balance at the end of the day
+	Balance       int64  `json:"balance"`
+	AnnualRateBps int32  `json:"annual_rate_bps"`
+	Compounding   string `json:"compounding"`
+	// interest of the day in millionths of the currency unit
+	AmountMicros int64 `json:"amount_micros"`
+	// transfer that posted the interest, null until posted
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	CreatedAt  time.Time     `json:"created_at"`
+	// daily, or carry for the rounding remainder of the previous posting
+	Kind string `json:"kind"`
+}
+
+type LoginLockout struct {
+	// username or client_ip
+	Kind         string    `json:"kind"`
+	Key          string    `json:"key"`
+	FailedCount  int32     `json:"failed_count"`
+	LastFailedAt time.Time `json:"last_failed_at"`
+	LockedUntil  time.Time `json:"locked_until"`
+}
+
+type Outbox struct {
+	ID int64 `json:"id"`
+	// asynq task id, used to drop duplicates when a row is published twice
+	TaskID      string       `json:"task_id"`
+	TaskType    string       `json:"task_type"`
+	Payload     []byte       `json:"payload"`
+	Queue       string       `json:"queue"`
+	MaxRetry    int32        `json:"max_retry"`
+	ProcessAt   time.Time    `json:"process_at"`
+	Attempts    int32        `json:"attempts"`
+	LastError   string       `json:"last_error"`
+	CreatedAt   time.Time    `json:"created_at"`
+	PublishedAt sql.NullTime `json:"published_at"`
+	// set when the relay gave up publishing the task, which then lives on in dead_letter_tasks
+	DeadAt sql.NullTime `json:"dead_at"`
+}
+
+type Payee struct {
+	ID       int64  `json:"id"`
+	Owner    string `json:"owner"`
+	Nickname string `json:"nickname"`
+	// the payee is deleted with the account
+	AccountID int64  `json:"account_id"`
+	Currency  string `json:"currency"`
+	// until then the transfers to the payee cannot exceed cooling_off_max_amount in total, null for no cooling-off
+	CoolingOffUntil     sql.NullTime `json:"cooling_off_until"`
+	CoolingOffMaxAmount int64        `json:"cooling_off_max_amount"`
+	CreatedAt           time.Time    `json:"created_at"`
+	// the payee was found by username or email, so its account is not shown to the owner
+	AccountHidden bool `json:"account_hidden"`
 }
 
 type Session struct {
@@ -44,6 +194,23 @@ type Transfer struct {
 	// must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// charged to the source account on top of the amount
+	Fee       int64         `json:"fee"`
+	FeeRuleID sql.NullInt64 `json:"fee_rule_id"`
+}
+
+type TransferLimit struct {
+	ID       int64  `json:"id"`
+	Currency string `json:"currency"`
+	// user the limits replace the defaults of the currency for, null for the defaults
+	Username sql.NullString `json:"username"`
+	// account the limits apply to on top of the limits of its owner
+	AccountID sql.NullInt64 `json:"account_id"`
+	// 0 means no limit, as for daily_max and monthly_max
+	PerTransferMax int64     `json:"per_transfer_max"`
+	DailyMax       int64     `json:"daily_max"`
+	MonthlyMax     int64     `json:"monthly_max"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 type User struct {
@@ -54,6 +221,7 @@ type User struct {
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 	IsEmailVerified   bool      `json:"is_email_verified"`
+	Role              string    `json:"role"`
 }
 
 type VerifyEmail struct {
@@ -65,3 +233,28 @@ type VerifyEmail struct {
 	CreatedAt  time.Time `json:"created_at"`
 	ExpiredAt  time.Time `json:"expired_at"`
 }
+
+type Webhook struct {
+	ID         int64    `json:"id"`
+	Owner      string   `json:"owner"`
+	Url        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	// HMAC key the payloads are signed with
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID        int64           `json:"id"`
+	WebhookID int64           `json:"webhook_id"`
+	EventID   int64           `json:"event_id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	// pending, succeeded, failed or dead
+	Status       string    `json:"status"`
+	Attempts     int32     `json:"attempts"`
+	ResponseCode int32     `json:"response_code"`
+	LastError    string    `json:"last_error"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
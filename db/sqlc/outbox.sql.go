@@ -0,0 +1,210 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: outbox.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createOutboxTask = `-- name: CreateOutboxTask :one
+INSERT INTO outbox (task_id,
+                    task_type,
+                    payload,
+                    queue,
+                    max_retry,
+                    process_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, task_id, task_type, payload, queue, max_retry, process_at, attempts, last_error, created_at, published_at, dead_at
+`
+
+type CreateOutboxTaskParams struct {
+	TaskID    string    `json:"task_id"`
+	TaskType  string    `json:"task_type"`
+	Payload   []byte    `json:"payload"`
+	Queue     string    `json:"queue"`
+	MaxRetry  int32     `json:"max_retry"`
+	ProcessAt time.Time `json:"process_at"`
+}
+
+func (q *Queries) CreateOutboxTask(ctx context.Context, arg CreateOutboxTaskParams) (Outbox, error) {
+	row := q.db.QueryRowContext(ctx, createOutboxTask,
+		arg.TaskID,
+		arg.TaskType,
+		arg.Payload,
+		arg.Queue,
+		arg.MaxRetry,
+		arg.ProcessAt,
+	)
+	var i Outbox
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.TaskType,
+		&i.Payload,
+		&i.Queue,
+		&i.MaxRetry,
+		&i.ProcessAt,
+		&i.Attempts,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.PublishedAt,
+		&i.DeadAt,
+	)
+	return i, err
+}
+
+const deleteDeadOutboxTask = `-- name: DeleteDeadOutboxTask :execrows
+DELETE
+FROM outbox
+WHERE queue = $1
+  AND task_id = $2
+  AND dead_at IS NOT NULL
+`
+
+type DeleteDeadOutboxTaskParams struct {
+	Queue  string `json:"queue"`
+	TaskID string `json:"task_id"`
+}
+
+func (q *Queries) DeleteDeadOutboxTask(ctx context.Context, arg DeleteDeadOutboxTaskParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDeadOutboxTask, arg.Queue, arg.TaskID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deletePublishedOutboxTasks = `-- name: DeletePublishedOutboxTasks :execrows
+DELETE
+FROM outbox
+WHERE published_at < $1::timestamptz
+`
+
+func (q *Queries) DeletePublishedOutboxTasks(ctx context.Context, publishedBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePublishedOutboxTasks, publishedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listPendingOutboxTasks = `-- name: ListPendingOutboxTasks :many
+SELECT id, task_id, task_type, payload, queue, max_retry, process_at, attempts, last_error, created_at, published_at, dead_at
+FROM outbox
+WHERE published_at IS NULL
+  AND dead_at IS NULL
+ORDER BY id
+LIMIT $1 FOR UPDATE SKIP LOCKED
+`
+
+func (q *Queries) ListPendingOutboxTasks(ctx context.Context, limit int32) ([]Outbox, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingOutboxTasks, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Outbox{}
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskID,
+			&i.TaskType,
+			&i.Payload,
+			&i.Queue,
+			&i.MaxRetry,
+			&i.ProcessAt,
+			&i.Attempts,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.PublishedAt,
+			&i.DeadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOutboxTaskPublished = `-- name: MarkOutboxTaskPublished :exec
+UPDATE outbox
+SET published_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) MarkOutboxTaskPublished(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markOutboxTaskPublished, id)
+	return err
+}
+
+const recordOutboxTaskFailure = `-- name: RecordOutboxTaskFailure :one
+UPDATE outbox
+SET attempts   = attempts + 1,
+    last_error = $1,
+    dead_at    = CASE
+                     WHEN $2::integer > 0 AND attempts + 1 >= $2::integer
+                         THEN now()
+        END
+WHERE id = $3
+RETURNING id, task_id, task_type, payload, queue, max_retry, process_at, attempts, last_error, created_at, published_at, dead_at
+`
+
+type RecordOutboxTaskFailureParams struct {
+	LastError   string `json:"last_error"`
+	MaxAttempts int32  `json:"max_attempts"`
+	ID          int64  `json:"id"`
+}
+
+// the task is given up once it failed max_attempts times, or never if max_attempts is 0
+func (q *Queries) RecordOutboxTaskFailure(ctx context.Context, arg RecordOutboxTaskFailureParams) (Outbox, error) {
+	row := q.db.QueryRowContext(ctx, recordOutboxTaskFailure, arg.LastError, arg.MaxAttempts, arg.ID)
+	var i Outbox
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.TaskType,
+		&i.Payload,
+		&i.Queue,
+		&i.MaxRetry,
+		&i.ProcessAt,
+		&i.Attempts,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.PublishedAt,
+		&i.DeadAt,
+	)
+	return i, err
+}
+
+const reviveOutboxTask = `-- name: ReviveOutboxTask :execrows
+UPDATE outbox
+SET attempts = 0,
+    dead_at  = NULL
+WHERE queue = $1
+  AND task_id = $2
+  AND dead_at IS NOT NULL
+`
+
+type ReviveOutboxTaskParams struct {
+	Queue  string `json:"queue"`
+	TaskID string `json:"task_id"`
+}
+
+func (q *Queries) ReviveOutboxTask(ctx context.Context, arg ReviveOutboxTaskParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, reviveOutboxTask, arg.Queue, arg.TaskID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func randomOutboxTask() CreateOutboxTaskParams {
+	return CreateOutboxTaskParams{
+		TaskID:    uuid.NewString(),
+		TaskType:  "task:test",
+		Payload:   []byte(`{"username":"` + util.RandomOwner() + `"}`),
+		Queue:     "default",
+		MaxRetry:  3,
+		ProcessAt: time.Now(),
+	}
+}
+
+func TestCreateUserTxWritesOutbox(t *testing.T) {
+	store := NewStore(testDB)
+	task := randomOutboxTask()
+
+	result, err := store.CreateUserTx(context.Background(), CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       util.RandomOwner(),
+			HashedPassword: util.RandomString(32),
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		},
+		AfterCreate: func(user User) ([]CreateOutboxTaskParams, error) {
+			return []CreateOutboxTaskParams{task}, nil
+		},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, result.User)
+
+	var count int
+	err = testDB.QueryRow("SELECT count(*) FROM outbox WHERE task_id = $1 AND published_at IS NULL", task.TaskID).Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
+
+func TestCreateUserTxRollsBackOutbox(t *testing.T) {
+	store := NewStore(testDB)
+	task := randomOutboxTask()
+	username := util.RandomOwner()
+
+	_, err := store.CreateUserTx(context.Background(), CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       username,
+			HashedPassword: util.RandomString(32),
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		},
+		AfterCreate: func(user User) ([]CreateOutboxTaskParams, error) {
+			return []CreateOutboxTaskParams{task, task}, nil
+		},
+	})
+	require.Error(t, err)
+
+	var count int
+	err = testDB.QueryRow("SELECT count(*) FROM outbox WHERE task_id = $1", task.TaskID).Scan(&count)
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	_, err = testQueries.GetUser(context.Background(), username)
+	require.Error(t, err)
+}
+
+func TestPublishOutboxTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	published, err := testQueries.CreateOutboxTask(context.Background(), randomOutboxTask())
+	require.NoError(t, err)
+	failed, err := testQueries.CreateOutboxTask(context.Background(), randomOutboxTask())
+	require.NoError(t, err)
+
+	// other tests leave pending tasks behind, only ours are published or failed
+	_, err = store.PublishOutboxTx(context.Background(), PublishOutboxTxParams{
+		Limit: 1000,
+		Publish: func(task Outbox) error {
+			if task.TaskID == failed.TaskID {
+				return errors.New("redis down")
+			}
+			if task.TaskID != published.TaskID {
+				return errors.New("not ours")
+			}
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	var publishedAt *time.Time
+	err = testDB.QueryRow("SELECT published_at FROM outbox WHERE id = $1", published.ID).Scan(&publishedAt)
+	require.NoError(t, err)
+	require.NotNil(t, publishedAt)
+
+	var attempts int32
+	var lastError string
+	err = testDB.QueryRow("SELECT attempts, last_error, published_at FROM outbox WHERE id = $1", failed.ID).Scan(&attempts, &lastError, &publishedAt)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), attempts)
+	require.Equal(t, "redis down", lastError)
+	require.Nil(t, publishedAt)
+
+	deleted, err := testQueries.DeletePublishedOutboxTasks(context.Background(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+}
+
+func TestPublishOutboxTxDeadLetter(t *testing.T) {
+	store := NewStore(testDB)
+
+	task, err := testQueries.CreateOutboxTask(context.Background(), randomOutboxTask())
+	require.NoError(t, err)
+
+	publish := func() PublishOutboxTxResult {
+		// other tests leave pending tasks behind, they are published
+		result, err := store.PublishOutboxTx(context.Background(), PublishOutboxTxParams{
+			Limit:       1000,
+			MaxAttempts: 2,
+			Publish: func(outboxTask Outbox) error {
+				if outboxTask.TaskID == task.TaskID {
+					return errors.New("redis down")
+				}
+				return nil
+			},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	result := publish()
+	require.Equal(t, 1, result.Failed)
+	require.Zero(t, result.Dead)
+
+	result = publish()
+	require.Equal(t, 1, result.Failed)
+	require.Equal(t, 1, result.Dead)
+
+	deadLetter, err := testQueries.GetDeadLetterTask(context.Background(), GetDeadLetterTaskParams{Queue: task.Queue, TaskID: task.TaskID})
+	require.NoError(t, err)
+	require.Equal(t, task.TaskType, deadLetter.TaskType)
+	require.Equal(t, task.Payload, deadLetter.Payload)
+	require.Equal(t, "redis down", deadLetter.LastError)
+	require.Equal(t, int32(2), deadLetter.Retried)
+
+	// a dead task is not published anymore
+	result = publish()
+	require.Zero(t, result.Failed)
+
+	revived, err := testQueries.ReviveOutboxTask(context.Background(), ReviveOutboxTaskParams{Queue: task.Queue, TaskID: task.TaskID})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), revived)
+
+	result = publish()
+	require.Equal(t, 1, result.Failed)
+	require.Zero(t, result.Dead)
+}
+
+func TestDeleteDeadOutboxTask(t *testing.T) {
+	task, err := testQueries.CreateOutboxTask(context.Background(), randomOutboxTask())
+	require.NoError(t, err)
+
+	// only dead tasks are deleted
+	deleted, err := testQueries.DeleteDeadOutboxTask(context.Background(), DeleteDeadOutboxTaskParams{Queue: task.Queue, TaskID: task.TaskID})
+	require.NoError(t, err)
+	require.Zero(t, deleted)
+
+	task, err = testQueries.RecordOutboxTaskFailure(context.Background(), RecordOutboxTaskFailureParams{
+		ID:          task.ID,
+		LastError:   "redis down",
+		MaxAttempts: 1,
+	})
+	require.NoError(t, err)
+	require.True(t, task.DeadAt.Valid)
+
+	deleted, err = testQueries.DeleteDeadOutboxTask(context.Background(), DeleteDeadOutboxTaskParams{Queue: task.Queue, TaskID: task.TaskID})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+}
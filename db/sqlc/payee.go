@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CoolingOff caps the transfers of an owner to an account of someone else it started paying, or saved as a payee,
+// less than Period ago: until then they cannot exceed MaxAmount in total. The zero value caps nothing.
+type CoolingOff struct {
+	Period    time.Duration
+	MaxAmount int64
+}
+
+// ErrCoolingOff is returned by TransferTx when a transfer to a new recipient, or to the account of a payee in its
+// cooling-off period, would take the total transferred to it above the cooling-off maximum.
+type ErrCoolingOff struct {
+	Until     time.Time `json:"until"`
+	MaxAmount int64     `json:"max_amount"`
+	// Remaining is how much can still be transferred to the recipient before Until.
+	Remaining int64 `json:"remaining"`
+}
+
+func (err *ErrCoolingOff) Error() string {
+	return fmt.Sprintf("transfers to a new recipient cannot exceed %d in total until %s, %d remaining",
+		err.MaxAmount, err.Until.UTC().Format(time.RFC3339), err.Remaining)
+}
+
+// InCoolingOff reports whether the cooling-off period of the payee is still running at now.
+func (payee Payee) InCoolingOff(now time.Time) bool {
+	return payee.CoolingOffUntil.Valid && now.Before(payee.CoolingOffUntil.Time)
+}
+
+// checkCoolingOff returns an *ErrCoolingOff when transferring amount from account to toAccount exceeds a cooling-off
+// maximum, counting every transfer from the accounts of the owner to toAccount. The cap of a payee of the owner in its
+// cooling-off period applies however the transfer names the recipient, and the cap of policy applies to a recipient
+// the owner first paid or saved less than policy.Period ago, so neither paying the account directly nor deleting the
+// payee gets around it. The owner is locked by checkTransferLimits, so concurrent transfers to toAccount are counted
+// one after the other.
+func checkCoolingOff(ctx context.Context, q *Queries, account Account, toAccount Account, amount int64, policy CoolingOff, now time.Time) error {
+	if toAccount.Owner == account.Owner {
+		return nil
+	}
+
+	payee, err := q.GetPayeeByAccount(ctx, GetPayeeByAccountParams{
+		Owner:     account.Owner,
+		AccountID: toAccount.ID,
+	})
+	saved := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if saved && payee.InCoolingOff(now) {
+		total, err := q.GetPayeeTransferTotal(ctx, GetPayeeTransferTotalParams{
+			Owner:     account.Owner,
+			AccountID: toAccount.ID,
+			Since:     payee.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if err := coolingOffExceeded(payee.CoolingOffUntil.Time, payee.CoolingOffMaxAmount, total, amount); err != nil {
+			return err
+		}
+	}
+
+	if policy.Period <= 0 {
+		return nil
+	}
+	summary, err := q.GetRecipientTransferSummary(ctx, GetRecipientTransferSummaryParams{
+		Owner:     account.Owner,
+		AccountID: toAccount.ID,
+	})
+	if err != nil {
+		return err
+	}
+	start := summary.FirstPaidAt
+	if saved && payee.CreatedAt.Before(start) {
+		start = payee.CreatedAt
+	}
+	until := start.Add(policy.Period)
+	if !now.Before(until) {
+		return nil
+	}
+	return coolingOffExceeded(until, policy.MaxAmount, summary.Total, amount)
+}
+
+// coolingOffExceeded returns an *ErrCoolingOff when amount on top of total exceeds maxAmount.
+func coolingOffExceeded(until time.Time, maxAmount int64, total int64, amount int64) error {
+	remaining := maxAmount - total
+	if remaining < 0 {
+		remaining = 0
+	}
+	if amount > remaining {
+		return &ErrCoolingOff{
+			Until:     until,
+			MaxAmount: maxAmount,
+			Remaining: remaining,
+		}
+	}
+	return nil
+}
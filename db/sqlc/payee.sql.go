@@ -0,0 +1,242 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: payee.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createPayee = `-- name: CreatePayee :one
+INSERT INTO payees (owner, nickname, account_id, currency, cooling_off_until, cooling_off_max_amount, account_hidden)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, owner, nickname, account_id, currency, cooling_off_until, cooling_off_max_amount, created_at, account_hidden
+`
+
+type CreatePayeeParams struct {
+	Owner               string       `json:"owner"`
+	Nickname            string       `json:"nickname"`
+	AccountID           int64        `json:"account_id"`
+	Currency            string       `json:"currency"`
+	CoolingOffUntil     sql.NullTime `json:"cooling_off_until"`
+	CoolingOffMaxAmount int64        `json:"cooling_off_max_amount"`
+	AccountHidden       bool         `json:"account_hidden"`
+}
+
+func (q *Queries) CreatePayee(ctx context.Context, arg CreatePayeeParams) (Payee, error) {
+	row := q.db.QueryRowContext(ctx, createPayee,
+		arg.Owner,
+		arg.Nickname,
+		arg.AccountID,
+		arg.Currency,
+		arg.CoolingOffUntil,
+		arg.CoolingOffMaxAmount,
+		arg.AccountHidden,
+	)
+	var i Payee
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.Currency,
+		&i.CoolingOffUntil,
+		&i.CoolingOffMaxAmount,
+		&i.CreatedAt,
+		&i.AccountHidden,
+	)
+	return i, err
+}
+
+const deletePayee = `-- name: DeletePayee :exec
+DELETE
+FROM payees
+WHERE id = $1
+`
+
+func (q *Queries) DeletePayee(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deletePayee, id)
+	return err
+}
+
+const getPayee = `-- name: GetPayee :one
+SELECT id, owner, nickname, account_id, currency, cooling_off_until, cooling_off_max_amount, created_at, account_hidden
+FROM payees
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetPayee(ctx context.Context, id int64) (Payee, error) {
+	row := q.db.QueryRowContext(ctx, getPayee, id)
+	var i Payee
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.Currency,
+		&i.CoolingOffUntil,
+		&i.CoolingOffMaxAmount,
+		&i.CreatedAt,
+		&i.AccountHidden,
+	)
+	return i, err
+}
+
+const getPayeeByAccount = `-- name: GetPayeeByAccount :one
+SELECT id, owner, nickname, account_id, currency, cooling_off_until, cooling_off_max_amount, created_at, account_hidden
+FROM payees
+WHERE owner = $1
+  AND account_id = $2
+LIMIT 1
+`
+
+type GetPayeeByAccountParams struct {
+	Owner     string `json:"owner"`
+	AccountID int64  `json:"account_id"`
+}
+
+func (q *Queries) GetPayeeByAccount(ctx context.Context, arg GetPayeeByAccountParams) (Payee, error) {
+	row := q.db.QueryRowContext(ctx, getPayeeByAccount, arg.Owner, arg.AccountID)
+	var i Payee
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.Currency,
+		&i.CoolingOffUntil,
+		&i.CoolingOffMaxAmount,
+		&i.CreatedAt,
+		&i.AccountHidden,
+	)
+	return i, err
+}
+
+const getPayeeTransferTotal = `-- name: GetPayeeTransferTotal :one
+SELECT COALESCE(SUM(t.amount), 0)::bigint
+FROM transfers t
+         JOIN accounts a ON a.id = t.from_account_id
+WHERE a.owner = $1
+  AND t.to_account_id = $2
+  AND t.created_at >= $3
+`
+
+type GetPayeeTransferTotalParams struct {
+	Owner     string    `json:"owner"`
+	AccountID int64     `json:"account_id"`
+	Since     time.Time `json:"since"`
+}
+
+func (q *Queries) GetPayeeTransferTotal(ctx context.Context, arg GetPayeeTransferTotalParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getPayeeTransferTotal, arg.Owner, arg.AccountID, arg.Since)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const getRecipientTransferSummary = `-- name: GetRecipientTransferSummary :one
+SELECT COALESCE(MIN(t.created_at), now())::timestamptz AS first_paid_at,
+       COALESCE(SUM(t.amount), 0)::bigint           AS total
+FROM transfers t
+         JOIN accounts a ON a.id = t.from_account_id
+WHERE a.owner = $1
+  AND t.to_account_id = $2
+`
+
+type GetRecipientTransferSummaryParams struct {
+	Owner     string `json:"owner"`
+	AccountID int64  `json:"account_id"`
+}
+
+type GetRecipientTransferSummaryRow struct {
+	FirstPaidAt time.Time `json:"first_paid_at"`
+	Total       int64     `json:"total"`
+}
+
+func (q *Queries) GetRecipientTransferSummary(ctx context.Context, arg GetRecipientTransferSummaryParams) (GetRecipientTransferSummaryRow, error) {
+	row := q.db.QueryRowContext(ctx, getRecipientTransferSummary, arg.Owner, arg.AccountID)
+	var i GetRecipientTransferSummaryRow
+	err := row.Scan(&i.FirstPaidAt, &i.Total)
+	return i, err
+}
+
+const listPayees = `-- name: ListPayees :many
+SELECT id, owner, nickname, account_id, currency, cooling_off_until, cooling_off_max_amount, created_at, account_hidden
+FROM payees
+WHERE owner = $1
+ORDER BY id
+LIMIT $2 OFFSET $3
+`
+
+type ListPayeesParams struct {
+	Owner  string `json:"owner"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+func (q *Queries) ListPayees(ctx context.Context, arg ListPayeesParams) ([]Payee, error) {
+	rows, err := q.db.QueryContext(ctx, listPayees, arg.Owner, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Payee{}
+	for rows.Next() {
+		var i Payee
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Nickname,
+			&i.AccountID,
+			&i.Currency,
+			&i.CoolingOffUntil,
+			&i.CoolingOffMaxAmount,
+			&i.CreatedAt,
+			&i.AccountHidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updatePayee = `-- name: UpdatePayee :one
+UPDATE payees
+SET nickname = $2
+WHERE id = $1
+RETURNING id, owner, nickname, account_id, currency, cooling_off_until, cooling_off_max_amount, created_at, account_hidden
+`
+
+type UpdatePayeeParams struct {
+	ID       int64  `json:"id"`
+	Nickname string `json:"nickname"`
+}
+
+func (q *Queries) UpdatePayee(ctx context.Context, arg UpdatePayeeParams) (Payee, error) {
+	row := q.db.QueryRowContext(ctx, updatePayee, arg.ID, arg.Nickname)
+	var i Payee
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.Currency,
+		&i.CoolingOffUntil,
+		&i.CoolingOffMaxAmount,
+		&i.CreatedAt,
+		&i.AccountHidden,
+	)
+	return i, err
+}
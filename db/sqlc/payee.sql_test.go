@@ -0,0 +1,200 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomPayee(t *testing.T, owner User, account Account) Payee {
+	arg := CreatePayeeParams{
+		Owner:               owner.Username,
+		Nickname:            account.Owner,
+		AccountID:           account.ID,
+		Currency:            account.Currency,
+		CoolingOffUntil:     sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+		CoolingOffMaxAmount: 100,
+		AccountHidden:       util.RandomInt(0, 1) == 1,
+	}
+
+	payee, err := testQueries.CreatePayee(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, payee.ID)
+	require.Equal(t, arg.Owner, payee.Owner)
+	require.Equal(t, arg.Nickname, payee.Nickname)
+	require.Equal(t, arg.AccountID, payee.AccountID)
+	require.Equal(t, arg.Currency, payee.Currency)
+	require.WithinDuration(t, arg.CoolingOffUntil.Time, payee.CoolingOffUntil.Time, time.Second)
+	require.Equal(t, arg.CoolingOffMaxAmount, payee.CoolingOffMaxAmount)
+	require.Equal(t, arg.AccountHidden, payee.AccountHidden)
+	require.NotZero(t, payee.CreatedAt)
+	return payee
+}
+
+func TestCreatePayee(t *testing.T) {
+	owner := createRandomUser(t)
+	account := createRandomAccount(t)
+	createRandomPayee(t, owner, account)
+
+	// an account is saved once per owner
+	_, err := testQueries.CreatePayee(context.Background(), CreatePayeeParams{
+		Owner:     owner.Username,
+		Nickname:  "again",
+		AccountID: account.ID,
+		Currency:  account.Currency,
+	})
+	require.Error(t, err)
+}
+
+func TestListPayees(t *testing.T) {
+	owner := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomPayee(t, owner, createRandomAccount(t))
+	}
+	createRandomPayee(t, createRandomUser(t), createRandomAccount(t))
+
+	payees, err := testQueries.ListPayees(context.Background(), ListPayeesParams{
+		Owner:  owner.Username,
+		Limit:  2,
+		Offset: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, payees, 2)
+	for _, payee := range payees {
+		require.Equal(t, owner.Username, payee.Owner)
+	}
+}
+
+func TestUpdateAndDeletePayee(t *testing.T) {
+	payee := createRandomPayee(t, createRandomUser(t), createRandomAccount(t))
+
+	renamed, err := testQueries.UpdatePayee(context.Background(), UpdatePayeeParams{ID: payee.ID, Nickname: "renamed"})
+	require.NoError(t, err)
+	require.Equal(t, "renamed", renamed.Nickname)
+	require.Equal(t, payee.AccountID, renamed.AccountID)
+
+	require.NoError(t, testQueries.DeletePayee(context.Background(), payee.ID))
+	_, err = testQueries.GetPayee(context.Background(), payee.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestPayeeInCoolingOff(t *testing.T) {
+	now := time.Now()
+	payee := Payee{CoolingOffUntil: sql.NullTime{Time: now.Add(time.Hour), Valid: true}}
+	require.True(t, payee.InCoolingOff(now))
+	require.False(t, payee.InCoolingOff(now.Add(time.Hour)))
+	require.False(t, Payee{}.InCoolingOff(now))
+}
+
+// TestDeleteAccountDeletesPayees deletes the account of a payee: the payee goes with it.
+func TestDeleteAccountDeletesPayees(t *testing.T) {
+	account := createRandomAccount(t)
+	payee := createRandomPayee(t, createRandomUser(t), account)
+
+	require.NoError(t, testQueries.DeleteAccount(context.Background(), account.ID))
+	_, err := testQueries.GetPayee(context.Background(), payee.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+// TestTransferTxPayeeCoolingOff transfers to a new payee from two accounts of its owner, one by paying the account
+// directly: the cooling-off maximum caps their total.
+func TestTransferTxPayeeCoolingOff(t *testing.T) {
+	store := NewStore(testDB)
+	checking := createRandomAccountInCurrency(t, util.USD)
+	savings, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    checking.Owner,
+		Balance:  1000,
+		Currency: util.USD,
+		Product:  ProductSavings,
+	})
+	require.NoError(t, err)
+	owner, err := testQueries.GetUser(context.Background(), checking.Owner)
+	require.NoError(t, err)
+	payee := createRandomPayee(t, owner, createRandomAccountInCurrency(t, util.USD))
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountID: checking.ID, ToAccountID: payee.AccountID, Amount: 60})
+	require.NoError(t, err)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountID: savings.ID, ToAccountID: payee.AccountID, Amount: 40})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountID: checking.ID, ToAccountID: payee.AccountID, Amount: 1})
+	var coolingOff *ErrCoolingOff
+	require.True(t, errors.As(err, &coolingOff))
+	require.WithinDuration(t, payee.CoolingOffUntil.Time, coolingOff.Until, time.Second)
+	require.Equal(t, int64(100), coolingOff.MaxAmount)
+	require.Zero(t, coolingOff.Remaining)
+
+	// transfers from other users to the same account are not capped
+	other := createRandomAccountInCurrency(t, util.USD)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountID: other.ID, ToAccountID: payee.AccountID, Amount: 101})
+	require.NoError(t, err)
+}
+
+// TestTransferTxCoolingOffAfterPayeeDeleted deletes a new payee once its cooling-off maximum is reached and pays its
+// account directly: the cap of the cooling-off policy still applies from the first transfer to it.
+func TestTransferTxCoolingOffAfterPayeeDeleted(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccountInCurrency(t, util.USD)
+	owner, err := testQueries.GetUser(context.Background(), account.Owner)
+	require.NoError(t, err)
+	payee := createRandomPayee(t, owner, createRandomAccountInCurrency(t, util.USD))
+	policy := CoolingOff{Period: time.Hour, MaxAmount: 100}
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID, ToAccountID: payee.AccountID, Amount: 100, CoolingOff: policy,
+	})
+	require.NoError(t, err)
+	require.NoError(t, testQueries.DeletePayee(context.Background(), payee.ID))
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID, ToAccountID: payee.AccountID, Amount: 1, CoolingOff: policy,
+	})
+	var coolingOff *ErrCoolingOff
+	require.True(t, errors.As(err, &coolingOff))
+	require.Equal(t, int64(100), coolingOff.MaxAmount)
+	require.Zero(t, coolingOff.Remaining)
+	require.WithinDuration(t, time.Now().Add(time.Hour), coolingOff.Until, time.Second)
+}
+
+// TestTransferTxCoolingOffNewRecipient pays an account never saved as a payee: the cooling-off policy caps the
+// transfers to it, but not the transfers to an account of the same owner or to a recipient paid before the period.
+func TestTransferTxCoolingOffNewRecipient(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccountInCurrency(t, util.USD)
+	recipient := createRandomAccountInCurrency(t, util.USD)
+	policy := CoolingOff{Period: time.Hour, MaxAmount: 100}
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID, ToAccountID: recipient.ID, Amount: 60, CoolingOff: policy,
+	})
+	require.NoError(t, err)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID, ToAccountID: recipient.ID, Amount: 41, CoolingOff: policy,
+	})
+	var coolingOff *ErrCoolingOff
+	require.True(t, errors.As(err, &coolingOff))
+	require.Equal(t, int64(40), coolingOff.Remaining)
+
+	savings, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    account.Owner,
+		Currency: util.USD,
+		Product:  ProductSavings,
+	})
+	require.NoError(t, err)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID, ToAccountID: savings.ID, Amount: 101, CoolingOff: policy,
+	})
+	require.NoError(t, err)
+
+	// the recipient was first paid longer ago than a shorter period
+	time.Sleep(10 * time.Millisecond)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID, ToAccountID: recipient.ID, Amount: 101,
+		CoolingOff: CoolingOff{Period: time.Millisecond, MaxAmount: 100},
+	})
+	require.NoError(t, err)
+}
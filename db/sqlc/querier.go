@@ -6,35 +6,113 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	// numbers the committed events without a position after the last position, in id order
+	AssignDomainEventPositions(ctx context.Context, limitCount int32) (int64, error)
+	BlockSessions(ctx context.Context, arg BlockSessionsParams) ([]Session, error)
+	CountTransfersSince(ctx context.Context, arg CountTransfersSinceParams) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountIfNotExists(ctx context.Context, arg CreateAccountIfNotExistsParams) error
+	CreateAccountStatement(ctx context.Context, arg CreateAccountStatementParams) (AccountStatement, error)
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error)
+	CreateDeadLetterTask(ctx context.Context, arg CreateDeadLetterTaskParams) (DeadLetterTask, error)
+	CreateDomainEvent(ctx context.Context, arg CreateDomainEventParams) (DomainEvent, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateFeeRule(ctx context.Context, arg CreateFeeRuleParams) (FeeRule, error)
+	CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (InterestAccrual, error)
+	CreateInterestCarry(ctx context.Context, arg CreateInterestCarryParams) (InterestAccrual, error)
+	CreateOutboxTask(ctx context.Context, arg CreateOutboxTaskParams) (Outbox, error)
+	CreatePayee(ctx context.Context, arg CreatePayeeParams) (Payee, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferLimit(ctx context.Context, arg CreateTransferLimitParams) (TransferLimit, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error)
+	CreateWebhookDeliveries(ctx context.Context, arg CreateWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	DeactivateFeeRules(ctx context.Context, currency string) ([]FeeRule, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteDeadOutboxTask(ctx context.Context, arg DeleteDeadOutboxTaskParams) (int64, error)
 	DeleteEntry(ctx context.Context, id int64) error
+	DeletePayee(ctx context.Context, id int64) error
+	DeletePublishedOutboxTasks(ctx context.Context, publishedBefore time.Time) (int64, error)
 	DeleteTransfer(ctx context.Context, id int64) error
+	DeleteTransferLimit(ctx context.Context, id int64) error
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountByProduct(ctx context.Context, arg GetAccountByProductParams) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountProduct(ctx context.Context, code string) (AccountProduct, error)
+	GetAccountStatement(ctx context.Context, id int64) (AccountStatement, error)
+	GetAccountStatementBalances(ctx context.Context, arg GetAccountStatementBalancesParams) (GetAccountStatementBalancesRow, error)
+	GetAccountStatementByPeriod(ctx context.Context, arg GetAccountStatementByPeriodParams) (AccountStatement, error)
+	GetAccountTransferUsage(ctx context.Context, arg GetAccountTransferUsageParams) (GetAccountTransferUsageRow, error)
+	GetActiveFeeRule(ctx context.Context, currency string) (FeeRule, error)
+	GetDeadLetterTask(ctx context.Context, arg GetDeadLetterTaskParams) (DeadLetterTask, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetLatestDomainEventOffset(ctx context.Context) (int64, error)
+	GetLoginLockout(ctx context.Context, arg GetLoginLockoutParams) (LoginLockout, error)
+	GetPayee(ctx context.Context, id int64) (Payee, error)
+	GetPayeeByAccount(ctx context.Context, arg GetPayeeByAccountParams) (Payee, error)
+	GetPayeeTransferTotal(ctx context.Context, arg GetPayeeTransferTotalParams) (int64, error)
+	GetPendingInterestMicros(ctx context.Context, arg GetPendingInterestMicrosParams) (int64, error)
+	GetRecipientTransferSummary(ctx context.Context, arg GetRecipientTransferSummaryParams) (GetRecipientTransferSummaryRow, error)
 	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferLimitForUpdate(ctx context.Context, arg GetTransferLimitForUpdateParams) (TransferLimit, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserForUpdate(ctx context.Context, username string) (User, error)
+	GetUserTransferUsage(ctx context.Context, arg GetUserTransferUsageParams) (GetUserTransferUsageRow, error)
+	GetWebhook(ctx context.Context, id int64) (Webhook, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error)
+	ListAccountIDsCreatedBefore(ctx context.Context, arg ListAccountIDsCreatedBeforeParams) ([]int64, error)
+	ListAccountProducts(ctx context.Context) ([]AccountProduct, error)
+	ListAccountStatements(ctx context.Context, arg ListAccountStatementsParams) ([]AccountStatement, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsForUpdate(ctx context.Context, ids []int64) ([]Account, error)
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	ListDomainEvents(ctx context.Context, arg ListDomainEventsParams) ([]DomainEvent, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListFeeRules(ctx context.Context) ([]FeeRule, error)
+	ListInterestAccruals(ctx context.Context, arg ListInterestAccrualsParams) ([]InterestAccrual, error)
+	ListInterestBearingAccountIDs(ctx context.Context, arg ListInterestBearingAccountIDsParams) ([]int64, error)
+	ListLoginFailureClientIps(ctx context.Context, arg ListLoginFailureClientIpsParams) ([]string, error)
+	ListPayees(ctx context.Context, arg ListPayeesParams) ([]Payee, error)
+	ListPendingInterestAccrualsForUpdate(ctx context.Context, arg ListPendingInterestAccrualsForUpdateParams) ([]InterestAccrual, error)
+	ListPendingOutboxTasks(ctx context.Context, limit int32) ([]Outbox, error)
+	ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error)
+	ListTransferLimitsForAccount(ctx context.Context, arg ListTransferLimitsForAccountParams) ([]TransferLimit, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	LockLogin(ctx context.Context, arg LockLoginParams) (LoginLockout, error)
+	MarkAccountStatementNotified(ctx context.Context, id int64) error
+	MarkInterestAccrualsPosted(ctx context.Context, arg MarkInterestAccrualsPostedParams) error
+	MarkOutboxTaskPublished(ctx context.Context, id int64) error
+	// failures older than reset_before are forgotten, so that the count only grows with failures close to each other
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginLockout, error)
+	// the task is given up once it failed max_attempts times, or never if max_attempts is 0
+	RecordOutboxTaskFailure(ctx context.Context, arg RecordOutboxTaskFailureParams) (Outbox, error)
+	ResetLoginLockout(ctx context.Context, arg ResetLoginLockoutParams) error
+	ResolveDeadLetterTask(ctx context.Context, arg ResolveDeadLetterTaskParams) error
+	ReviveOutboxTask(ctx context.Context, arg ReviveOutboxTaskParams) (int64, error)
+	SetAccountFrozen(ctx context.Context, arg SetAccountFrozenParams) (Account, error)
+	// only one transaction at a time gives out positions, so that they commit in order
+	TryLockDomainEventPositions(ctx context.Context) (bool, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
 	UpdateEntry(ctx context.Context, arg UpdateEntryParams) error
+	UpdatePayee(ctx context.Context, arg UpdatePayeeParams) (Payee, error)
 	UpdateTransfer(ctx context.Context, arg UpdateTransferParams) error
+	UpdateTransferLimit(ctx context.Context, arg UpdateTransferLimitParams) (TransferLimit, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
 	UpdateVerifyEmail(ctx context.Context, arg UpdateVerifyEmailParams) (VerifyEmail, error)
+	UpdateWebhookDeliveryAttempt(ctx context.Context, arg UpdateWebhookDeliveryAttemptParams) (WebhookDelivery, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrRecipientNotFound  = errors.New("recipient not found")
+	ErrRecipientNoAccount = errors.New("recipient has no account in currency")
+)
+
+// ResolveRecipientParams contains the input parameters of ResolveRecipient. Exactly one of Username and Email is
+// set.
+type ResolveRecipientParams struct {
+	Username string
+	Email    string
+	Currency string
+}
+
+// Recipient is a user paid by name or email and the account the money goes to.
+type Recipient struct {
+	User    User
+	Account Account
+}
+
+// ResolveRecipient finds the user with the username, or the verified email, and their checking account in the
+// currency, which is unique by owner, currency and product. A person can hold a checking and a savings account in
+// the same currency, and the payer cannot see them to choose: money sent by name always goes to the checking
+// account, the one people pay and get paid from, and never into savings. It returns ErrRecipientNotFound when there
+// is no such user and an error wrapping ErrRecipientNoAccount when they have no checking account in the currency,
+// even if they have a savings account in it. The bank's ledger cannot be paid.
+func (store *SQLStore) ResolveRecipient(ctx context.Context, arg ResolveRecipientParams) (Recipient, error) {
+	var recipient Recipient
+
+	var err error
+	if arg.Email != "" {
+		recipient.User, err = store.GetUserByEmail(ctx, arg.Email)
+		if err == nil && !recipient.User.IsEmailVerified {
+			err = sql.ErrNoRows
+		}
+	} else {
+		recipient.User, err = store.GetUser(ctx, arg.Username)
+	}
+	if err == nil && recipient.User.Username == BankLedgerOwner {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Recipient{}, ErrRecipientNotFound
+		}
+		return Recipient{}, err
+	}
+
+	recipient.Account, err = store.GetAccountByProduct(ctx, GetAccountByProductParams{
+		Owner:    recipient.User.Username,
+		Currency: arg.Currency,
+		Product:  ProductChecking,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Recipient{}, fmt.Errorf("%w %s", ErrRecipientNoAccount, arg.Currency)
+		}
+		return Recipient{}, err
+	}
+	return recipient, nil
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestResolveRecipient(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccountInCurrency(t, util.USD)
+
+	recipient, err := store.ResolveRecipient(context.Background(), ResolveRecipientParams{Username: account.Owner, Currency: util.USD})
+	require.NoError(t, err)
+	require.Equal(t, account.Owner, recipient.User.Username)
+	require.Equal(t, account.ID, recipient.Account.ID)
+
+	_, err = store.ResolveRecipient(context.Background(), ResolveRecipientParams{Username: account.Owner, Currency: util.EUR})
+	require.ErrorIs(t, err, ErrRecipientNoAccount)
+	require.EqualError(t, err, "recipient has no account in currency EUR")
+
+	// unverified emails cannot be paid
+	_, err = store.ResolveRecipient(context.Background(), ResolveRecipientParams{Email: recipient.User.Email, Currency: util.USD})
+	require.ErrorIs(t, err, ErrRecipientNotFound)
+
+	_, err = testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		Username:        account.Owner,
+		IsEmailVerified: sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+	byEmail, err := store.ResolveRecipient(context.Background(), ResolveRecipientParams{Email: recipient.User.Email, Currency: util.USD})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, byEmail.Account.ID)
+
+	_, err = store.ResolveRecipient(context.Background(), ResolveRecipientParams{Username: BankLedgerOwner, Currency: util.USD})
+	require.ErrorIs(t, err, ErrRecipientNotFound)
+	_, err = store.ResolveRecipient(context.Background(), ResolveRecipientParams{Username: util.RandomOwner(), Currency: util.USD})
+	require.ErrorIs(t, err, ErrRecipientNotFound)
+}
+
+// TestResolveRecipientSeveralAccounts pays a person holding a savings and a checking account in the currency: the
+// money goes to the checking account, whichever was opened first.
+func TestResolveRecipientSeveralAccounts(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	savings, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Currency: util.USD,
+		Product:  ProductSavings,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ResolveRecipient(context.Background(), ResolveRecipientParams{Username: user.Username, Currency: util.USD})
+	require.ErrorIs(t, err, ErrRecipientNoAccount)
+
+	checking, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Currency: util.USD,
+		Product:  ProductChecking,
+	})
+	require.NoError(t, err)
+	require.Greater(t, checking.ID, savings.ID)
+
+	recipient, err := store.ResolveRecipient(context.Background(), ResolveRecipientParams{Username: user.Username, Currency: util.USD})
+	require.NoError(t, err)
+	require.Equal(t, checking.ID, recipient.Account.ID)
+}
@@ -12,6 +12,52 @@ import (
 	"github.com/google/uuid"
 )
 
+const blockSessions = `-- name: BlockSessions :many
+UPDATE sessions
+SET is_blocked = true
+WHERE username = $1
+  AND ($2::uuid IS NULL OR id = $2)
+  AND is_blocked = false
+RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at
+`
+
+type BlockSessionsParams struct {
+	Username string        `json:"username"`
+	ID       uuid.NullUUID `json:"id"`
+}
+
+func (q *Queries) BlockSessions(ctx context.Context, arg BlockSessionsParams) ([]Session, error) {
+	rows, err := q.db.QueryContext(ctx, blockSessions, arg.Username, arg.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.RefreshToken,
+			&i.UserAgent,
+			&i.ClientIp,
+			&i.IsBlocked,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createSession = `-- name: CreateSession :one
 INSERT INTO sessions (id,
                       username,
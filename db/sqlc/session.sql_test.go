@@ -76,3 +76,26 @@ func createRandomSession(t *testing.T) Session {
 
 	return session
 }
+
+// Test block sessions
+func TestBlockSessionsTx(t *testing.T) {
+	session := createRandomSession(t)
+	store := NewStore(testDB)
+
+	result, err := store.BlockSessionsTx(context.Background(), BlockSessionsTxParams{
+		BlockSessionsParams: BlockSessionsParams{Username: session.Username},
+		Audit:               AuditContext{Actor: "bankctl:test"},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Sessions, 1)
+	require.Equal(t, session.ID, result.Sessions[0].ID)
+	require.True(t, result.Sessions[0].IsBlocked)
+
+	// blocking again changes nothing
+	result, err = store.BlockSessionsTx(context.Background(), BlockSessionsTxParams{
+		BlockSessionsParams: BlockSessionsParams{Username: session.Username},
+		Audit:               AuditContext{Actor: "bankctl:test"},
+	})
+	require.NoError(t, err)
+	require.Empty(t, result.Sessions)
+}
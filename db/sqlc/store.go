@@ -10,8 +10,22 @@ type Store interface {
 	Querier
 	Ping(ctx context.Context) error
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (CreateAccountTxResult, error)
 	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
 	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error)
+	UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error)
+	CreateSessionTx(ctx context.Context, arg CreateSessionTxParams) (CreateSessionTxResult, error)
+	PublishOutboxTx(ctx context.Context, arg PublishOutboxTxParams) (PublishOutboxTxResult, error)
+	AssignDomainEventPositionsTx(ctx context.Context, arg AssignDomainEventPositionsTxParams) (AssignDomainEventPositionsTxResult, error)
+	FreezeAccountTx(ctx context.Context, arg FreezeAccountTxParams) (FreezeAccountTxResult, error)
+	BlockSessionsTx(ctx context.Context, arg BlockSessionsTxParams) (BlockSessionsTxResult, error)
+	PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error)
+	QuoteTransferFee(ctx context.Context, arg QuoteTransferFeeParams) (TransferFeeQuote, error)
+	SetFeeRuleTx(ctx context.Context, arg SetFeeRuleTxParams) (SetFeeRuleTxResult, error)
+	GetTransferAllowance(ctx context.Context, arg GetTransferAllowanceParams) (TransferAllowance, error)
+	SetTransferLimitTx(ctx context.Context, arg SetTransferLimitTxParams) (SetTransferLimitTxResult, error)
+	ResolveRecipient(ctx context.Context, arg ResolveRecipientParams) (Recipient, error)
 }
 
 // SQLStore provides all functions to execute SQL queries and transactions
@@ -27,7 +41,7 @@ func (store *SQLStore) Ping(ctx context.Context) error {
 // NewStore creates a new Store
 func NewStore(db *sql.DB) Store {
 	return &SQLStore{
-		Queries: New(db),
+		Queries: New(newTracedDBTX(db)),
 		db:      db,
 	}
 }
@@ -41,7 +55,7 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) erro
 	}
 
 	// create a new Queries instance with the transaction
-	q := New(tx)
+	q := New(newTracedDBTX(tx))
 
 	// execute the function
 	err = fn(q)
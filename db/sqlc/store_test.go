@@ -61,6 +61,7 @@ func TestTransferTx(t *testing.T) {
 		require.NotEmpty(t, fromEntry)
 		require.Equal(t, account1.ID, fromEntry.AccountID)
 		require.Equal(t, -amount, fromEntry.Amount)
+		require.Equal(t, transfer.ID, fromEntry.TransferID.Int64)
 		require.NotZero(t, fromEntry.ID)
 		require.NotZero(t, fromEntry.CreatedAt)
 
@@ -71,6 +72,7 @@ func TestTransferTx(t *testing.T) {
 		require.NotEmpty(t, toEntry)
 		require.Equal(t, account2.ID, toEntry.AccountID)
 		require.Equal(t, amount, toEntry.Amount)
+		require.Equal(t, transfer.ID, toEntry.TransferID.Int64)
 		require.NotZero(t, toEntry.ID)
 		require.NotZero(t, toEntry.CreatedAt)
 
@@ -157,3 +159,168 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+func TestTransferTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	result, err := store.FreezeAccountTx(context.Background(), FreezeAccountTxParams{
+		AccountID: account2.ID,
+		Frozen:    true,
+		Audit:     AuditContext{Actor: "bankctl:test"},
+	})
+	require.NoError(t, err)
+	require.True(t, result.Account.Frozen)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	// the transfer was rolled back
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	result, err = store.FreezeAccountTx(context.Background(), FreezeAccountTxParams{
+		AccountID: account2.ID,
+		Frozen:    false,
+		Audit:     AuditContext{Actor: "bankctl:test"},
+	})
+	require.NoError(t, err)
+	require.False(t, result.Account.Frozen)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+}
+
+func createRandomAccountInCurrency(t *testing.T, currency string) Account {
+	user := createRandomUser(t)
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  util.RandomBalance(),
+		Currency: currency,
+		Product:  ProductChecking,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestBatchTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	source := createRandomAccountInCurrency(t, util.USD)
+	payee1 := createRandomAccountInCurrency(t, util.USD)
+	payee2 := createRandomAccountInCurrency(t, util.USD)
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		FromAccountID: source.ID,
+		Lines: []BatchTransferLine{
+			{ToAccountID: payee1.ID, Amount: 10},
+			{ToAccountID: payee2.ID, Amount: 20},
+			{ToAccountID: payee1.ID, Amount: 5},
+		},
+		Mode:  BatchTransferAllOrNothing,
+		Audit: AuditContext{Actor: source.Owner},
+	})
+	require.NoError(t, err)
+	require.Equal(t, source.Balance-35, result.FromAccount.Balance)
+	require.Len(t, result.Lines, 3)
+	for _, line := range result.Lines {
+		require.Equal(t, BatchTransferLineCompleted, line.Status)
+		require.NoError(t, line.Err)
+		require.NotNil(t, line.Transfer)
+		require.Equal(t, line.Amount, line.Transfer.Amount)
+	}
+
+	updatedPayee1, err := testQueries.GetAccount(context.Background(), payee1.ID)
+	require.NoError(t, err)
+	require.Equal(t, payee1.Balance+15, updatedPayee1.Balance)
+}
+
+func TestBatchTransferTxInvalidLines(t *testing.T) {
+	store := NewStore(testDB)
+	source := createRandomAccountInCurrency(t, util.USD)
+	payee := createRandomAccountInCurrency(t, util.USD)
+	euroPayee := createRandomAccountInCurrency(t, util.EUR)
+
+	lines := []BatchTransferLine{
+		{ToAccountID: payee.ID, Amount: 10},
+		{ToAccountID: euroPayee.ID, Amount: 10},
+		{ToAccountID: source.ID, Amount: 10},
+		{ToAccountID: -1, Amount: 10},
+		{ToAccountID: payee.ID, Amount: 0},
+	}
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		FromAccountID: source.ID,
+		Lines:         lines,
+		Mode:          BatchTransferAllOrNothing,
+	})
+	require.ErrorIs(t, err, ErrBatchTransferRejected)
+	require.Equal(t, BatchTransferLineSkipped, result.Lines[0].Status)
+	require.ErrorIs(t, result.Lines[1].Err, ErrCurrencyMismatch)
+	require.ErrorIs(t, result.Lines[2].Err, ErrSameAccount)
+	require.ErrorIs(t, result.Lines[3].Err, ErrAccountNotFound)
+	require.ErrorIs(t, result.Lines[4].Err, ErrInvalidAmount)
+
+	// nothing was transferred
+	updatedSource, err := testQueries.GetAccount(context.Background(), source.ID)
+	require.NoError(t, err)
+	require.Equal(t, source.Balance, updatedSource.Balance)
+
+	result, err = store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		FromAccountID: source.ID,
+		Lines:         lines,
+		Mode:          BatchTransferBestEffort,
+	})
+	require.NoError(t, err)
+	require.Equal(t, BatchTransferLineCompleted, result.Lines[0].Status)
+	for _, line := range result.Lines[1:] {
+		require.Equal(t, BatchTransferLineFailed, line.Status)
+		require.Nil(t, line.Transfer)
+	}
+	require.Equal(t, source.Balance-10, result.FromAccount.Balance)
+}
+
+func TestBatchTransferTxDeadlock(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccountInCurrency(t, util.USD)
+	account2 := createRandomAccountInCurrency(t, util.USD)
+	account3 := createRandomAccountInCurrency(t, util.USD)
+
+	// batches from every account to the others lock overlapping accounts
+	sources := []Account{account1, account2, account3, account3, account2, account1}
+	errs := make(chan error)
+	for _, source := range sources {
+		var lines []BatchTransferLine
+		for _, account := range []Account{account3, account2, account1} {
+			if account.ID != source.ID {
+				lines = append(lines, BatchTransferLine{ToAccountID: account.ID, Amount: 10})
+			}
+		}
+		go func(source Account, lines []BatchTransferLine) {
+			_, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+				FromAccountID: source.ID,
+				Lines:         lines,
+			})
+			errs <- err
+		}(source, lines)
+	}
+	for range sources {
+		require.NoError(t, <-errs)
+	}
+
+	// every account sent and received 40
+	for _, account := range []Account{account1, account2, account3} {
+		updated, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, updated.Balance)
+	}
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/kwalter26/udemy-simplebank/tracing"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+const sqlcNamePrefix = "-- name: "
+
+// tracedDBTX starts a span for every query run through the wrapped DBTX, named after the sqlc query.
+type tracedDBTX struct {
+	db DBTX
+}
+
+func newTracedDBTX(db DBTX) DBTX {
+	return &tracedDBTX{db: db}
+}
+
+func (t *tracedDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	result, err := t.db.ExecContext(ctx, query, args...)
+	recordQueryError(span, err)
+	return result, err
+}
+
+func (t *tracedDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	stmt, err := t.db.PrepareContext(ctx, query)
+	recordQueryError(span, err)
+	return stmt, err
+}
+
+func (t *tracedDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	recordQueryError(span, err)
+	return rows, err
+}
+
+func (t *tracedDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	row := t.db.QueryRowContext(ctx, query, args...)
+	recordQueryError(span, row.Err())
+	return row
+}
+
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := queryName(query)
+	return tracing.Tracer().Start(ctx, "db "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperation(name),
+			semconv.DBStatement(query),
+		),
+	)
+}
+
+func recordQueryError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// queryName returns the name sqlc puts in the comment on the first line of every generated query.
+func queryName(query string) string {
+	if !strings.HasPrefix(query, sqlcNamePrefix) {
+		return "query"
+	}
+	fields := strings.Fields(strings.TrimPrefix(query, sqlcNamePrefix))
+	if len(fields) == 0 {
+		return "query"
+	}
+	return fields[0]
+}
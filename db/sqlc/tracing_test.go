@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+type fakeDBTX struct {
+	DBTX
+	err error
+}
+
+func (f fakeDBTX) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return nil, f.err
+}
+
+func TestQueryName(t *testing.T) {
+	require.Equal(t, "GetUser", queryName(getUser))
+	require.Equal(t, "UpdateUser", queryName(updateUser))
+	require.Equal(t, "query", queryName("SELECT 1"))
+}
+
+func TestTracedDBTX(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	_, err := newTracedDBTX(fakeDBTX{}).ExecContext(ctx, deleteAccount, 1)
+	require.NoError(t, err)
+
+	queryErr := errors.New("connection refused")
+	_, err = newTracedDBTX(fakeDBTX{err: queryErr}).ExecContext(ctx, deleteAccount, 1)
+	require.ErrorIs(t, err, queryErr)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	require.Equal(t, "db DeleteAccount", spans[0].Name)
+	require.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent.SpanID())
+	require.Equal(t, codes.Unset, spans[0].Status.Code)
+	require.Equal(t, codes.Error, spans[1].Status.Code)
+}
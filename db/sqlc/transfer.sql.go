@@ -7,22 +7,31 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
-INSERT INTO transfers (amount, from_account_id, to_account_id)
-values ($1, $2, $3)
-RETURNING id, from_account_id, to_account_id, amount, created_at
+INSERT INTO transfers (amount, from_account_id, to_account_id, fee, fee_rule_id)
+values ($1, $2, $3, $4, $5)
+RETURNING id, from_account_id, to_account_id, amount, created_at, fee, fee_rule_id
 `
 
 type CreateTransferParams struct {
-	Amount        int64 `json:"amount"`
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
+	Amount        int64         `json:"amount"`
+	FromAccountID int64         `json:"from_account_id"`
+	ToAccountID   int64         `json:"to_account_id"`
+	Fee           int64         `json:"fee"`
+	FeeRuleID     sql.NullInt64 `json:"fee_rule_id"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.Amount, arg.FromAccountID, arg.ToAccountID)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.Amount,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Fee,
+		arg.FeeRuleID,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -30,6 +39,8 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Fee,
+		&i.FeeRuleID,
 	)
 	return i, err
 }
@@ -45,7 +56,7 @@ func (q *Queries) DeleteTransfer(ctx context.Context, id int64) error {
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, fee, fee_rule_id FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -58,12 +69,14 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.Fee,
+		&i.FeeRuleID,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, fee, fee_rule_id FROM transfers
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -89,6 +102,8 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.Fee,
+			&i.FeeRuleID,
 		); err != nil {
 			return nil, err
 		}
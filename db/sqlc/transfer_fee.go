@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/kwalter26/udemy-simplebank/fees"
+	"time"
+)
+
+// QuoteTransferFeeParams contains the input parameters of QuoteTransferFee
+type QuoteTransferFeeParams struct {
+	FromAccount Account
+	Amount      int64
+}
+
+// TransferFeeQuote is the fee of a transfer.
+type TransferFeeQuote struct {
+	Amount int64 `json:"amount"`
+	Fee    int64 `json:"fee"`
+	// Rule is the fee rule of the currency. It is nil when there is none, or when the bank pays from a ledger account.
+	Rule *FeeRule `json:"rule,omitempty"`
+	// FreeTransfersRemaining is how many free transfers were left this month before the transfer.
+	FreeTransfersRemaining int32 `json:"free_transfers_remaining"`
+}
+
+// Rule returns the fee rule as the fees package computes it.
+func (rule FeeRule) Rule() fees.Rule {
+	return fees.Rule{
+		Kind:                  rule.Kind,
+		FlatAmount:            rule.FlatAmount,
+		RateBps:               rule.RateBps,
+		MinAmount:             rule.MinAmount,
+		MaxAmount:             rule.MaxAmount,
+		FreeTransfersPerMonth: rule.FreeTransfersPerMonth,
+	}
+}
+
+// QuoteTransferFee returns the fee a transfer from an account would be charged now. TransferTx computes the fee
+// again when it runs, so the charged fee differs when the rule changes or other transfers are made in between.
+func (store *SQLStore) QuoteTransferFee(ctx context.Context, arg QuoteTransferFeeParams) (TransferFeeQuote, error) {
+	rule, err := activeFeeRule(ctx, store.Queries, arg.FromAccount)
+	if err != nil {
+		return TransferFeeQuote{Amount: arg.Amount}, err
+	}
+	return quoteTransferFee(ctx, store.Queries, arg.FromAccount, rule, arg.Amount, time.Now())
+}
+
+// activeFeeRule returns the fee rule that applies to transfers from account, or nil when there is none. The ledger
+// accounts of the bank are never charged.
+func activeFeeRule(ctx context.Context, q *Queries, account Account) (*FeeRule, error) {
+	if account.Owner == BankLedgerOwner {
+		return nil, nil
+	}
+	rule, err := q.GetActiveFeeRule(ctx, account.Currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// quoteTransferFee computes the fee of a transfer under rule, counting the transfers account made since the start
+// of the month of now. Within a transfer the account is locked first, so that concurrent transfers cannot both use
+// the last free transfer.
+func quoteTransferFee(ctx context.Context, q *Queries, account Account, rule *FeeRule, amount int64, now time.Time) (TransferFeeQuote, error) {
+	quote := TransferFeeQuote{Amount: amount, Rule: rule}
+	if rule == nil {
+		return quote, nil
+	}
+
+	count, err := q.CountTransfersSince(ctx, CountTransfersSinceParams{
+		FromAccountID: account.ID,
+		Since:         fees.MonthStart(now),
+	})
+	if err != nil {
+		return quote, err
+	}
+	computed := fees.Compute(rule.Rule(), amount, count)
+	quote.Fee = computed.Fee
+	quote.FreeTransfersRemaining = computed.FreeTransfersRemaining
+	return quote, nil
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"github.com/kwalter26/udemy-simplebank/limits"
+	"time"
+)
+
+// ErrLimitExceeded is returned by TransferTx when the transfer would exceed a limit of the source account or of its
+// owner. It tells which limit and how much can still be transferred.
+type ErrLimitExceeded = limits.ErrLimitExceeded
+
+// GetTransferAllowanceParams contains the input parameters of GetTransferAllowance
+type GetTransferAllowanceParams struct {
+	Account Account
+}
+
+// TransferAllowance is the limits of the transfers from an account and how much of them is used.
+type TransferAllowance struct {
+	// UserScope is limits.ScopeUser when the owner has limits of its own, and limits.ScopeDefault when the defaults
+	// of the currency apply.
+	UserScope string        `json:"user_scope"`
+	User      limits.Limits `json:"user"`
+	UserUsage limits.Usage  `json:"user_usage"`
+	// Account is zero when the account has no limits of its own.
+	Account      limits.Limits `json:"account"`
+	AccountUsage limits.Usage  `json:"account_usage"`
+}
+
+// Limits returns the maximums of the row.
+func (limit TransferLimit) Limits() limits.Limits {
+	return limits.Limits{
+		PerTransfer: limit.PerTransferMax,
+		Daily:       limit.DailyMax,
+		Monthly:     limit.MonthlyMax,
+	}
+}
+
+// Scope returns whether the row holds the defaults of its currency, or the limits of a user or of an account.
+func (limit TransferLimit) Scope() string {
+	switch {
+	case limit.AccountID.Valid:
+		return limits.ScopeAccount
+	case limit.Username.Valid:
+		return limits.ScopeUser
+	}
+	return limits.ScopeDefault
+}
+
+// GetTransferAllowance returns the limits of the transfers from an account and their usage now.
+func (store *SQLStore) GetTransferAllowance(ctx context.Context, arg GetTransferAllowanceParams) (TransferAllowance, error) {
+	return transferAllowance(ctx, store.Queries, arg.Account, time.Now())
+}
+
+func transferAllowance(ctx context.Context, q *Queries, account Account, now time.Time) (TransferAllowance, error) {
+	allowance := TransferAllowance{UserScope: limits.ScopeDefault}
+
+	rows, err := q.ListTransferLimitsForAccount(ctx, ListTransferLimitsForAccountParams{
+		AccountID: account.ID,
+		Owner:     account.Owner,
+		Currency:  account.Currency,
+	})
+	if err != nil {
+		return allowance, err
+	}
+	for _, row := range rows {
+		switch row.Scope() {
+		case limits.ScopeAccount:
+			allowance.Account = row.Limits()
+		case limits.ScopeUser:
+			allowance.UserScope = limits.ScopeUser
+			allowance.User = row.Limits()
+		case limits.ScopeDefault:
+			if allowance.UserScope == limits.ScopeDefault {
+				allowance.User = row.Limits()
+			}
+		}
+	}
+
+	dayStart, monthStart := limits.DayStart(now), limits.MonthStart(now)
+	userUsage, err := q.GetUserTransferUsage(ctx, GetUserTransferUsageParams{
+		DayStart:   dayStart,
+		Owner:      account.Owner,
+		Currency:   account.Currency,
+		MonthStart: monthStart,
+	})
+	if err != nil {
+		return allowance, err
+	}
+	allowance.UserUsage = limits.Usage{Daily: userUsage.Daily, Monthly: userUsage.Monthly}
+
+	accountUsage, err := q.GetAccountTransferUsage(ctx, GetAccountTransferUsageParams{
+		DayStart:   dayStart,
+		AccountID:  account.ID,
+		MonthStart: monthStart,
+	})
+	if err != nil {
+		return allowance, err
+	}
+	allowance.AccountUsage = limits.Usage{Daily: accountUsage.Daily, Monthly: accountUsage.Monthly}
+	return allowance, nil
+}
+
+// checkTransferLimits returns an *ErrLimitExceeded when transferring amount from account exceeds the limits of its
+// owner or its own. The account is locked by the caller and the owner is locked here, so that concurrent transfers
+// from any account of the owner are counted one after the other.
+func checkTransferLimits(ctx context.Context, q *Queries, account Account, amount int64, now time.Time) error {
+	if _, err := q.GetUserForUpdate(ctx, account.Owner); err != nil {
+		return err
+	}
+	allowance, err := transferAllowance(ctx, q, account, now)
+	if err != nil {
+		return err
+	}
+	if err := limits.Check(limits.ScopeUser, allowance.User, allowance.UserUsage, amount); err != nil {
+		return err
+	}
+	return limits.Check(limits.ScopeAccount, allowance.Account, allowance.AccountUsage, amount)
+}
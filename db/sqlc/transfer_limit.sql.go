@@ -0,0 +1,242 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: transfer_limit.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createTransferLimit = `-- name: CreateTransferLimit :one
+INSERT INTO transfer_limits (currency,
+                             username,
+                             account_id,
+                             per_transfer_max,
+                             daily_max,
+                             monthly_max)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, currency, username, account_id, per_transfer_max, daily_max, monthly_max, updated_at
+`
+
+type CreateTransferLimitParams struct {
+	Currency       string         `json:"currency"`
+	Username       sql.NullString `json:"username"`
+	AccountID      sql.NullInt64  `json:"account_id"`
+	PerTransferMax int64          `json:"per_transfer_max"`
+	DailyMax       int64          `json:"daily_max"`
+	MonthlyMax     int64          `json:"monthly_max"`
+}
+
+func (q *Queries) CreateTransferLimit(ctx context.Context, arg CreateTransferLimitParams) (TransferLimit, error) {
+	row := q.db.QueryRowContext(ctx, createTransferLimit,
+		arg.Currency,
+		arg.Username,
+		arg.AccountID,
+		arg.PerTransferMax,
+		arg.DailyMax,
+		arg.MonthlyMax,
+	)
+	var i TransferLimit
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.Username,
+		&i.AccountID,
+		&i.PerTransferMax,
+		&i.DailyMax,
+		&i.MonthlyMax,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteTransferLimit = `-- name: DeleteTransferLimit :exec
+DELETE
+FROM transfer_limits
+WHERE id = $1
+`
+
+func (q *Queries) DeleteTransferLimit(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteTransferLimit, id)
+	return err
+}
+
+const getAccountTransferUsage = `-- name: GetAccountTransferUsage :one
+SELECT COALESCE(SUM(amount) FILTER (WHERE created_at >= $1), 0)::bigint AS daily,
+       COALESCE(SUM(amount), 0)::bigint                                                  AS monthly
+FROM transfers
+WHERE from_account_id = $2
+  AND created_at >= $3
+`
+
+type GetAccountTransferUsageParams struct {
+	DayStart   time.Time `json:"day_start"`
+	AccountID  int64     `json:"account_id"`
+	MonthStart time.Time `json:"month_start"`
+}
+
+type GetAccountTransferUsageRow struct {
+	Daily   int64 `json:"daily"`
+	Monthly int64 `json:"monthly"`
+}
+
+func (q *Queries) GetAccountTransferUsage(ctx context.Context, arg GetAccountTransferUsageParams) (GetAccountTransferUsageRow, error) {
+	row := q.db.QueryRowContext(ctx, getAccountTransferUsage, arg.DayStart, arg.AccountID, arg.MonthStart)
+	var i GetAccountTransferUsageRow
+	err := row.Scan(&i.Daily, &i.Monthly)
+	return i, err
+}
+
+const getTransferLimitForUpdate = `-- name: GetTransferLimitForUpdate :one
+SELECT id, currency, username, account_id, per_transfer_max, daily_max, monthly_max, updated_at
+FROM transfer_limits
+WHERE currency = $1
+  AND username IS NOT DISTINCT FROM $2
+  AND account_id IS NOT DISTINCT FROM $3
+LIMIT 1
+FOR UPDATE
+`
+
+type GetTransferLimitForUpdateParams struct {
+	Currency  string         `json:"currency"`
+	Username  sql.NullString `json:"username"`
+	AccountID sql.NullInt64  `json:"account_id"`
+}
+
+func (q *Queries) GetTransferLimitForUpdate(ctx context.Context, arg GetTransferLimitForUpdateParams) (TransferLimit, error) {
+	row := q.db.QueryRowContext(ctx, getTransferLimitForUpdate, arg.Currency, arg.Username, arg.AccountID)
+	var i TransferLimit
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.Username,
+		&i.AccountID,
+		&i.PerTransferMax,
+		&i.DailyMax,
+		&i.MonthlyMax,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getUserTransferUsage = `-- name: GetUserTransferUsage :one
+SELECT COALESCE(SUM(t.amount) FILTER (WHERE t.created_at >= $1), 0)::bigint AS daily,
+       COALESCE(SUM(t.amount), 0)::bigint                                                    AS monthly
+FROM transfers t
+         JOIN accounts a ON a.id = t.from_account_id
+WHERE a.owner = $2
+  AND a.currency = $3
+  AND t.created_at >= $4
+`
+
+type GetUserTransferUsageParams struct {
+	DayStart   time.Time `json:"day_start"`
+	Owner      string    `json:"owner"`
+	Currency   string    `json:"currency"`
+	MonthStart time.Time `json:"month_start"`
+}
+
+type GetUserTransferUsageRow struct {
+	Daily   int64 `json:"daily"`
+	Monthly int64 `json:"monthly"`
+}
+
+func (q *Queries) GetUserTransferUsage(ctx context.Context, arg GetUserTransferUsageParams) (GetUserTransferUsageRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserTransferUsage,
+		arg.DayStart,
+		arg.Owner,
+		arg.Currency,
+		arg.MonthStart,
+	)
+	var i GetUserTransferUsageRow
+	err := row.Scan(&i.Daily, &i.Monthly)
+	return i, err
+}
+
+const listTransferLimitsForAccount = `-- name: ListTransferLimitsForAccount :many
+SELECT id, currency, username, account_id, per_transfer_max, daily_max, monthly_max, updated_at
+FROM transfer_limits
+WHERE account_id = $1::bigint
+   OR (username = $2::varchar AND currency = $3)
+   OR (username IS NULL AND account_id IS NULL AND currency = $3)
+`
+
+type ListTransferLimitsForAccountParams struct {
+	AccountID int64  `json:"account_id"`
+	Owner     string `json:"owner"`
+	Currency  string `json:"currency"`
+}
+
+func (q *Queries) ListTransferLimitsForAccount(ctx context.Context, arg ListTransferLimitsForAccountParams) ([]TransferLimit, error) {
+	rows, err := q.db.QueryContext(ctx, listTransferLimitsForAccount, arg.AccountID, arg.Owner, arg.Currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TransferLimit{}
+	for rows.Next() {
+		var i TransferLimit
+		if err := rows.Scan(
+			&i.ID,
+			&i.Currency,
+			&i.Username,
+			&i.AccountID,
+			&i.PerTransferMax,
+			&i.DailyMax,
+			&i.MonthlyMax,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateTransferLimit = `-- name: UpdateTransferLimit :one
+UPDATE transfer_limits
+SET per_transfer_max = $2,
+    daily_max        = $3,
+    monthly_max      = $4,
+    updated_at       = now()
+WHERE id = $1
+RETURNING id, currency, username, account_id, per_transfer_max, daily_max, monthly_max, updated_at
+`
+
+type UpdateTransferLimitParams struct {
+	ID             int64 `json:"id"`
+	PerTransferMax int64 `json:"per_transfer_max"`
+	DailyMax       int64 `json:"daily_max"`
+	MonthlyMax     int64 `json:"monthly_max"`
+}
+
+func (q *Queries) UpdateTransferLimit(ctx context.Context, arg UpdateTransferLimitParams) (TransferLimit, error) {
+	row := q.db.QueryRowContext(ctx, updateTransferLimit,
+		arg.ID,
+		arg.PerTransferMax,
+		arg.DailyMax,
+		arg.MonthlyMax,
+	)
+	var i TransferLimit
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.Username,
+		&i.AccountID,
+		&i.PerTransferMax,
+		&i.DailyMax,
+		&i.MonthlyMax,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
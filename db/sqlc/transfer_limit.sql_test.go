@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/kwalter26/udemy-simplebank/limits"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// setTransferLimit sets the limits of a user or an account until the test ends.
+func setTransferLimit(t *testing.T, arg SetTransferLimitTxParams) TransferLimit {
+	store := NewStore(testDB)
+	result, err := store.SetTransferLimitTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotNil(t, result.Limit)
+	t.Cleanup(func() {
+		arg.Limits = nil
+		_, err := store.SetTransferLimitTx(context.Background(), arg)
+		require.NoError(t, err)
+	})
+	return *result.Limit
+}
+
+func TestSetTransferLimitTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	arg := SetTransferLimitTxParams{
+		Currency: util.USD,
+		Username: user.Username,
+		Limits:   &limits.Limits{Daily: 100},
+	}
+
+	result, err := store.SetTransferLimitTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Nil(t, result.Previous)
+	require.Equal(t, limits.ScopeUser, result.Limit.Scope())
+	require.Equal(t, limits.Limits{Daily: 100}, result.Limit.Limits())
+
+	arg.Limits = &limits.Limits{PerTransfer: 10, Monthly: 500}
+	updated, err := store.SetTransferLimitTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, result.Limit.ID, updated.Limit.ID)
+	require.Equal(t, result.Limit.ID, updated.Previous.ID)
+	require.Equal(t, limits.Limits{PerTransfer: 10, Monthly: 500}, updated.Limit.Limits())
+
+	arg.Limits = nil
+	removed, err := store.SetTransferLimitTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Nil(t, removed.Limit)
+	require.Equal(t, result.Limit.ID, removed.Previous.ID)
+
+	removed, err = store.SetTransferLimitTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Nil(t, removed.Limit)
+	require.Nil(t, removed.Previous)
+}
+
+func TestTransferTxUserLimit(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccountInCurrency(t, util.USD)
+	account2 := createRandomAccountInCurrency(t, util.USD)
+	setTransferLimit(t, SetTransferLimitTxParams{
+		Currency: util.USD,
+		Username: account1.Owner,
+		Limits:   &limits.Limits{Daily: 15},
+	})
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	var exceeded *ErrLimitExceeded
+	require.True(t, errors.As(err, &exceeded))
+	require.Equal(t, limits.ScopeUser, exceeded.Scope)
+	require.Equal(t, limits.WindowDaily, exceeded.Window)
+	require.Equal(t, int64(15), exceeded.Limit)
+	require.Equal(t, int64(5), exceeded.Remaining)
+
+	allowance, err := store.GetTransferAllowance(context.Background(), GetTransferAllowanceParams{Account: account1})
+	require.NoError(t, err)
+	require.Equal(t, limits.ScopeUser, allowance.UserScope)
+	require.Equal(t, int64(10), allowance.UserUsage.Daily)
+	require.Equal(t, int64(10), allowance.AccountUsage.Monthly)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 5})
+	require.NoError(t, err)
+}
+
+func TestTransferTxAccountLimit(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccountInCurrency(t, util.USD)
+	account2 := createRandomAccountInCurrency(t, util.USD)
+	setTransferLimit(t, SetTransferLimitTxParams{
+		Currency:  util.USD,
+		AccountID: account1.ID,
+		Limits:    &limits.Limits{PerTransfer: 5},
+	})
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 6})
+	var exceeded *ErrLimitExceeded
+	require.True(t, errors.As(err, &exceeded))
+	require.Equal(t, limits.ScopeAccount, exceeded.Scope)
+	require.Equal(t, limits.WindowTransfer, exceeded.Window)
+
+	account, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, account.Balance)
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+)
+
+// AssignDomainEventPositionsTxParams contains the input parameters of the AssignDomainEventPositions transaction
+type AssignDomainEventPositionsTxParams struct {
+	Limit int32
+}
+
+// AssignDomainEventPositionsTxResult is the result of the AssignDomainEventPositions transaction
+type AssignDomainEventPositionsTxResult struct {
+	Assigned int64
+}
+
+// AssignDomainEventPositionsTx gives up to Limit committed events the positions subscribers resume from. Event ids
+// are taken when a transaction inserts the event but become visible when it commits, so a subscriber resuming after
+// an id misses the events of transactions that committed late. Positions are only given to committed events and by
+// one transaction at a time, so they become visible in order. A call made while another one holds the lock returns
+// without assigning anything, the other one does the work.
+func (store *SQLStore) AssignDomainEventPositionsTx(ctx context.Context, arg AssignDomainEventPositionsTxParams) (AssignDomainEventPositionsTxResult, error) {
+	var result AssignDomainEventPositionsTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		locked, err := q.TryLockDomainEventPositions(ctx)
+		if err != nil || !locked {
+			return err
+		}
+
+		result.Assigned, err = q.AssignDomainEventPositions(ctx, arg.Limit)
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,184 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Modes of a batch transfer
+const (
+	// BatchTransferAllOrNothing makes no transfer at all when any line is invalid.
+	BatchTransferAllOrNothing = "all_or_nothing"
+	// BatchTransferBestEffort makes the transfers of the valid lines and skips the others.
+	BatchTransferBestEffort = "best_effort"
+)
+
+// Statuses of a batch transfer line
+const (
+	BatchTransferLineCompleted = "completed"
+	BatchTransferLineFailed    = "failed"
+	// BatchTransferLineSkipped is a valid line that was not transferred because another line failed.
+	BatchTransferLineSkipped = "skipped"
+)
+
+// Errors of a batch transfer line
+var (
+	ErrAccountNotFound  = errors.New("account not found")
+	ErrCurrencyMismatch = errors.New("account currency does not match")
+	ErrSameAccount      = errors.New("cannot transfer to the source account")
+	ErrInvalidAmount    = errors.New("amount must be positive")
+)
+
+// ErrBatchTransferRejected is returned by BatchTransferTx in all or nothing mode when a line failed. The result
+// tells which lines failed and why.
+var ErrBatchTransferRejected = errors.New("batch transfer rejected")
+
+// BatchTransferLine is one destination of a batch transfer.
+type BatchTransferLine struct {
+	ToAccountID int64 `json:"to_account_id"`
+	Amount      int64 `json:"amount"`
+}
+
+// BatchTransferTxParams contains the input parameters of the batch transfer transaction
+type BatchTransferTxParams struct {
+	FromAccountID int64               `json:"from_account_id"`
+	Lines         []BatchTransferLine `json:"lines"`
+	Mode          string              `json:"mode"`
+	CoolingOff    CoolingOff          `json:"-"`
+	Audit         AuditContext        `json:"-"`
+}
+
+// BatchTransferLineResult is the outcome of one line, in the order of the lines.
+type BatchTransferLineResult struct {
+	BatchTransferLine
+	Status   string    `json:"status"`
+	Err      error     `json:"-"`
+	Transfer *Transfer `json:"transfer,omitempty"`
+}
+
+// BatchTransferTxResult is the result of the batch transfer transaction
+type BatchTransferTxResult struct {
+	FromAccount Account                   `json:"from_account"`
+	Lines       []BatchTransferLineResult `json:"lines"`
+}
+
+// BatchTransferTx pays many accounts from one source account within a single database transaction. All accounts, with
+// the fee revenue account when transfers are charged, are locked in id order before any balance changes, so that
+// concurrent transfers cannot deadlock. Every line is then checked: in all or nothing mode one invalid line rejects the
+// whole batch with ErrBatchTransferRejected, in best effort mode invalid lines are skipped. A line exceeding a transfer
+// limit or the cooling-off maximum of a new recipient is invalid the same way, once the lines before it are transferred.
+// Each completed line is a regular transfer, audited and with its domain events. A missing or frozen source account
+// fails the whole batch in both modes.
+func (store *SQLStore) BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error) {
+	var result BatchTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		fromAccount, err := q.GetAccount(ctx, arg.FromAccountID)
+		if err != nil {
+			return err
+		}
+		rule, revenueAccount, err := transferFeeRule(ctx, q, fromAccount)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int64, 0, len(arg.Lines)+2)
+		ids = append(ids, arg.FromAccountID)
+		for _, line := range arg.Lines {
+			ids = append(ids, line.ToAccountID)
+		}
+		if rule != nil {
+			ids = append(ids, revenueAccount.ID)
+		}
+		locked, err := q.ListAccountsForUpdate(ctx, ids)
+		if err != nil {
+			return err
+		}
+		accounts := make(map[int64]Account, len(locked))
+		for _, account := range locked {
+			accounts[account.ID] = account
+		}
+
+		fromAccount, ok := accounts[arg.FromAccountID]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		if fromAccount.Frozen {
+			return ErrAccountFrozen
+		}
+		result.FromAccount = fromAccount
+
+		result.Lines = make([]BatchTransferLineResult, len(arg.Lines))
+		failed := false
+		for i, line := range arg.Lines {
+			result.Lines[i] = BatchTransferLineResult{BatchTransferLine: line, Status: BatchTransferLineSkipped}
+			if err := validateBatchTransferLine(fromAccount, accounts, line); err != nil {
+				result.Lines[i].Status = BatchTransferLineFailed
+				result.Lines[i].Err = err
+				failed = true
+			}
+		}
+		if failed && arg.Mode != BatchTransferBestEffort {
+			return ErrBatchTransferRejected
+		}
+
+		for i := range result.Lines {
+			line := &result.Lines[i]
+			if line.Status == BatchTransferLineFailed {
+				continue
+			}
+			transferResult, err := transfer(ctx, q, TransferTxParams{
+				FromAccountID: arg.FromAccountID,
+				ToAccountID:   line.ToAccountID,
+				Amount:        line.Amount,
+				CoolingOff:    arg.CoolingOff,
+				Audit:         arg.Audit,
+			})
+			var exceeded *ErrLimitExceeded
+			var coolingOff *ErrCoolingOff
+			if errors.As(err, &exceeded) || errors.As(err, &coolingOff) {
+				// the limits are checked before the line writes anything, so the transaction can go on
+				line.Status = BatchTransferLineFailed
+				line.Err = err
+				if arg.Mode != BatchTransferBestEffort {
+					for j := 0; j < i; j++ {
+						result.Lines[j].Status = BatchTransferLineSkipped
+						result.Lines[j].Transfer = nil
+					}
+					return ErrBatchTransferRejected
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			line.Status = BatchTransferLineCompleted
+			line.Transfer = &transferResult.Transfer
+			result.FromAccount = transferResult.FromAccount
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+func validateBatchTransferLine(fromAccount Account, accounts map[int64]Account, line BatchTransferLine) error {
+	if line.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if line.ToAccountID == fromAccount.ID {
+		return ErrSameAccount
+	}
+	toAccount, ok := accounts[line.ToAccountID]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	if toAccount.Currency != fromAccount.Currency {
+		return ErrCurrencyMismatch
+	}
+	if toAccount.Frozen {
+		return ErrAccountFrozen
+	}
+	return nil
+}
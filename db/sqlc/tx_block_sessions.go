@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+)
+
+// BlockSessionsTxParams contains the input parameters of the BlockSessions transaction
+type BlockSessionsTxParams struct {
+	BlockSessionsParams
+	Audit AuditContext
+}
+
+// BlockSessionsTxResult is the result of the BlockSessions transaction
+type BlockSessionsTxResult struct {
+	Sessions []Session
+}
+
+// BlockSessionsTx blocks the sessions of a user, or only the one with the given id, so that their refresh tokens
+// are rejected. Every blocked session is recorded in the audit log within the same database transaction.
+func (store *SQLStore) BlockSessionsTx(ctx context.Context, arg BlockSessionsTxParams) (BlockSessionsTxResult, error) {
+	var result BlockSessionsTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Sessions, err = q.BlockSessions(ctx, arg.BlockSessionsParams)
+		if err != nil {
+			return err
+		}
+
+		for _, session := range result.Sessions {
+			before := session
+			before.IsBlocked = false
+			err = recordAuditEvent(ctx, q, arg.Audit, AuditActionSessionBlock, AuditTarget("session", session.ID), newAuditSession(before), newAuditSession(session))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return result, err
+}
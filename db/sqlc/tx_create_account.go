@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+)
+
+// CreateAccountTxParams contains the input parameters of the CreateAccount transaction
+type CreateAccountTxParams struct {
+	CreateAccountParams
+}
+
+// CreateAccountTxResult is the result of the CreateAccount transaction
+type CreateAccountTxResult struct {
+	Account Account
+}
+
+// CreateAccountTx opens an account and emits an account.opened event within a single database transaction.
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (CreateAccountTxResult, error) {
+	var result CreateAccountTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Account, err = q.CreateAccount(ctx, arg.CreateAccountParams)
+		if err != nil {
+			return err
+		}
+
+		return recordDomainEvent(ctx, q, result.Account.Owner, result.Account.ID, DomainEventAccountOpened, AccountOpenedEvent{
+			AccountID: result.Account.ID,
+			Owner:     result.Account.Owner,
+			Currency:  result.Account.Currency,
+			Balance:   result.Account.Balance,
+		})
+	})
+
+	return result, err
+}
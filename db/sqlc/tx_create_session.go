@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+)
+
+// CreateSessionTxParams contains the input parameters of the CreateSession transaction
+type CreateSessionTxParams struct {
+	CreateSessionParams
+	Audit AuditContext
+}
+
+// CreateSessionTxResult is the result of the CreateSession transaction
+type CreateSessionTxResult struct {
+	Session Session
+}
+
+// CreateSessionTx creates the session of a successful login and records the login and the session in the audit log
+// within a single database transaction.
+func (store *SQLStore) CreateSessionTx(ctx context.Context, arg CreateSessionTxParams) (CreateSessionTxResult, error) {
+	var result CreateSessionTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Session, err = q.CreateSession(ctx, arg.CreateSessionParams)
+		if err != nil {
+			return err
+		}
+
+		err = recordAuditEvent(ctx, q, arg.Audit, AuditActionLogin, AuditTarget("user", result.Session.Username), nil, nil)
+		if err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, q, arg.Audit, AuditActionSessionCreate, AuditTarget("session", result.Session.ID), nil, newAuditSession(result.Session))
+	})
+
+	return result, err
+}
@@ -2,12 +2,18 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 // CreateUserTxParams contains the input parameters of the CreateUser transaction
 type CreateUserTxParams struct {
 	CreateUserParams CreateUserParams
-	AfterCreate      func(user User) error
+	// Role is given to the user instead of the default one, and the change is audited with Audit. Empty keeps the
+	// default role.
+	Role  string
+	Audit AuditContext
+	// AfterCreate returns the tasks to write to the outbox. They are only published once the user is committed.
+	AfterCreate func(user User) ([]CreateOutboxTaskParams, error)
 }
 
 // CreateUserTxResult is the result of the CreateUser transaction
@@ -15,9 +21,9 @@ type CreateUserTxResult struct {
 	User User
 }
 
-// CreateUserTx performs a money CreateUser from one account to the other.
-// It creates a CreateUser record, add account entries, and update accounts' balance within a single database transaction.
-// If any of the operations fail, it will rollback the transaction and return an error.
+// CreateUserTx creates a user with its role and writes the tasks of AfterCreate to the outbox within a single
+// database transaction. If any of the operations fail, it will rollback the transaction and return an error, so that
+// no user is left without its role or its tasks.
 func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
 	var result CreateUserTxResult
 
@@ -29,10 +35,34 @@ func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams)
 			return err
 		}
 
-		if arg.AfterCreate != nil {
-			return arg.AfterCreate(result.User)
+		if arg.Role != "" && arg.Role != result.User.Role {
+			before := result.User
+			result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+				Username: before.Username,
+				Role:     sql.NullString{String: arg.Role, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+			err = recordAuditEvent(ctx, q, arg.Audit, AuditActionUserUpdate, AuditTarget("user", result.User.Username), newAuditUser(before), newAuditUser(result.User))
+			if err != nil {
+				return err
+			}
 		}
 
+		if arg.AfterCreate == nil {
+			return nil
+		}
+
+		tasks, err := arg.AfterCreate(result.User)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			if _, err = q.CreateOutboxTask(ctx, task); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 
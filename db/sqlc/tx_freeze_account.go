@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+)
+
+// FreezeAccountTxParams contains the input parameters of the FreezeAccount transaction
+type FreezeAccountTxParams struct {
+	AccountID int64
+	Frozen    bool
+	Audit     AuditContext
+}
+
+// FreezeAccountTxResult is the result of the FreezeAccount transaction
+type FreezeAccountTxResult struct {
+	Account Account
+}
+
+// auditAccountFreeze is the audited view of a frozen or unfrozen account.
+type auditAccountFreeze struct {
+	Frozen bool `json:"frozen"`
+}
+
+// FreezeAccountTx freezes or unfreezes an account and records the change in the audit log within a single
+// database transaction.
+func (store *SQLStore) FreezeAccountTx(ctx context.Context, arg FreezeAccountTxParams) (FreezeAccountTxResult, error) {
+	var result FreezeAccountTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.SetAccountFrozen(ctx, SetAccountFrozenParams{
+			ID:     arg.AccountID,
+			Frozen: arg.Frozen,
+		})
+		if err != nil {
+			return err
+		}
+
+		action := AuditActionAccountFreeze
+		if !arg.Frozen {
+			action = AuditActionAccountUnfreeze
+		}
+		return recordAuditEvent(ctx, q, arg.Audit, action, AuditTarget("account", result.Account.ID),
+			auditAccountFreeze{Frozen: before.Frozen}, auditAccountFreeze{Frozen: result.Account.Frozen})
+	})
+
+	return result, err
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/kwalter26/udemy-simplebank/interest"
+	"time"
+)
+
+// PostInterestTxParams contains the input parameters of the PostInterest transaction
+type PostInterestTxParams struct {
+	AccountID int64
+	// Before is the first day that is not posted: the pending accruals of all earlier days are.
+	Before time.Time
+	Audit  AuditContext
+}
+
+// PostInterestTxResult is the result of the PostInterest transaction
+type PostInterestTxResult struct {
+	Accruals []InterestAccrual
+	// Transfer pays the interest from the interest expense account of the bank. It is nil when nothing was posted.
+	Transfer *TransferTxResult
+	// Carry holds the rounding remainder of the posted interest. It is nil when the interest was a whole amount.
+	Carry *InterestAccrual
+}
+
+// PostInterestTx pays the interest accrued on an account and not posted yet, as a transfer from the interest
+// expense account of the bank in the currency of the account. The accruals are locked and marked posted in the
+// same transaction, so posting the same days again does nothing. Interest that rounds to less than a unit stays
+// pending and is posted with the next month. The rounding remainder of posted interest is carried forward as a
+// pending accrual on the first day that is not posted, so no fraction of a unit is ever lost.
+func (store *SQLStore) PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error) {
+	var result PostInterestTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Accruals, err = q.ListPendingInterestAccrualsForUpdate(ctx, ListPendingInterestAccrualsForUpdateParams{
+			AccountID:  arg.AccountID,
+			BeforeDate: arg.Before,
+		})
+		if err != nil {
+			return err
+		}
+		var micros int64
+		for _, accrual := range result.Accruals {
+			micros += accrual.AmountMicros
+		}
+		amount := interest.Round(micros)
+		if amount <= 0 {
+			return nil
+		}
+
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		expense, err := ledgerAccount(ctx, q, account.Currency, ProductInterestExpense)
+		if err != nil {
+			return err
+		}
+
+		transferResult, err := transfer(ctx, q, TransferTxParams{
+			FromAccountID: expense.ID,
+			ToAccountID:   account.ID,
+			Amount:        amount,
+			Audit:         arg.Audit,
+		})
+		if err != nil {
+			return err
+		}
+		result.Transfer = &transferResult
+
+		err = q.MarkInterestAccrualsPosted(ctx, MarkInterestAccrualsPostedParams{
+			TransferID: sql.NullInt64{Int64: transferResult.Transfer.ID, Valid: true},
+			AccountID:  arg.AccountID,
+			BeforeDate: arg.Before,
+		})
+		if err != nil {
+			return err
+		}
+
+		remainder := micros - amount*interest.MicrosPerUnit
+		if remainder == 0 {
+			return nil
+		}
+		last := result.Accruals[len(result.Accruals)-1]
+		carry, err := q.CreateInterestCarry(ctx, CreateInterestCarryParams{
+			AccountID:     arg.AccountID,
+			AccrualDate:   arg.Before,
+			Balance:       last.Balance,
+			AnnualRateBps: last.AnnualRateBps,
+			Compounding:   last.Compounding,
+			AmountMicros:  remainder,
+		})
+		if err != nil {
+			return err
+		}
+		result.Carry = &carry
+		return nil
+	})
+
+	return result, err
+}
+
+// ledgerAccount returns the ledger account of the bank for a currency and an internal product, and opens it the
+// first time it is used.
+func ledgerAccount(ctx context.Context, q *Queries, currency string, product string) (Account, error) {
+	err := q.CreateAccountIfNotExists(ctx, CreateAccountIfNotExistsParams{
+		Owner:    BankLedgerOwner,
+		Currency: currency,
+		Product:  product,
+	})
+	if err != nil {
+		return Account{}, err
+	}
+	return q.GetAccountByProduct(ctx, GetAccountByProductParams{
+		Owner:    BankLedgerOwner,
+		Currency: currency,
+		Product:  product,
+	})
+}
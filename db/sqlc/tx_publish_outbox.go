@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+)
+
+// PublishOutboxTxParams contains the input parameters of the PublishOutbox transaction
+type PublishOutboxTxParams struct {
+	Limit int32
+	// MaxAttempts is how many times a task may fail to publish, 0 retries it forever.
+	MaxAttempts int32
+	Publish     func(task Outbox) error
+}
+
+// PublishOutboxTxResult is the result of the PublishOutbox transaction
+type PublishOutboxTxResult struct {
+	Published int
+	Failed    int
+	// Dead counts the failed tasks that ran out of attempts.
+	Dead int
+}
+
+// PublishOutboxTx locks up to Limit pending outbox tasks, publishes them in order and marks the ones that were
+// published. Failed tasks keep their error and are picked up again by the next call, until they failed MaxAttempts
+// times: they are then left out of the outbox and recorded as dead letters, where a banker can retry or delete
+// them. Rows locked by another relay are skipped, so several instances can publish at the same time.
+func (store *SQLStore) PublishOutboxTx(ctx context.Context, arg PublishOutboxTxParams) (PublishOutboxTxResult, error) {
+	var result PublishOutboxTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		tasks, err := q.ListPendingOutboxTasks(ctx, arg.Limit)
+		if err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			if publishErr := arg.Publish(task); publishErr != nil {
+				result.Failed++
+				task, err = q.RecordOutboxTaskFailure(ctx, RecordOutboxTaskFailureParams{
+					ID:          task.ID,
+					LastError:   publishErr.Error(),
+					MaxAttempts: arg.MaxAttempts,
+				})
+				if err != nil {
+					return err
+				}
+				if !task.DeadAt.Valid {
+					continue
+				}
+
+				result.Dead++
+				_, err = q.CreateDeadLetterTask(ctx, CreateDeadLetterTaskParams{
+					TaskID:    task.TaskID,
+					TaskType:  task.TaskType,
+					Queue:     task.Queue,
+					Payload:   task.Payload,
+					LastError: task.LastError,
+					Retried:   task.Attempts,
+					MaxRetry:  task.MaxRetry,
+				})
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err = q.MarkOutboxTaskPublished(ctx, task.ID); err != nil {
+				return err
+			}
+			result.Published++
+		}
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"github.com/kwalter26/udemy-simplebank/fees"
+)
+
+// SetFeeRuleTxParams contains the input parameters of the SetFeeRule transaction
+type SetFeeRuleTxParams struct {
+	Currency string
+	// Rule replaces the active rule of the currency. Nil makes transfers in the currency free.
+	Rule  *fees.Rule
+	Audit AuditContext
+}
+
+// SetFeeRuleTxResult is the result of the SetFeeRule transaction
+type SetFeeRuleTxResult struct {
+	Rule        *FeeRule
+	Deactivated []FeeRule
+}
+
+// SetFeeRuleTx replaces the active fee rule of a currency and records the change in the audit log within a single
+// database transaction. Old rules are kept inactive, since the transfers they charged refer to them.
+func (store *SQLStore) SetFeeRuleTx(ctx context.Context, arg SetFeeRuleTxParams) (SetFeeRuleTxResult, error) {
+	var result SetFeeRuleTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Deactivated, err = q.DeactivateFeeRules(ctx, arg.Currency)
+		if err != nil {
+			return err
+		}
+
+		if arg.Rule != nil {
+			rule, err := q.CreateFeeRule(ctx, CreateFeeRuleParams{
+				Currency:              arg.Currency,
+				Kind:                  arg.Rule.Kind,
+				FlatAmount:            arg.Rule.FlatAmount,
+				RateBps:               arg.Rule.RateBps,
+				MinAmount:             arg.Rule.MinAmount,
+				MaxAmount:             arg.Rule.MaxAmount,
+				FreeTransfersPerMonth: arg.Rule.FreeTransfersPerMonth,
+			})
+			if err != nil {
+				return err
+			}
+			result.Rule = &rule
+		}
+
+		// at most one rule is active, so at most one was deactivated
+		var before, after interface{}
+		if len(result.Deactivated) > 0 {
+			before = result.Deactivated[0]
+		}
+		if result.Rule != nil {
+			after = *result.Rule
+		}
+		return recordAuditEvent(ctx, q, arg.Audit, AuditActionFeeRuleSet, AuditTarget("fee_rule", arg.Currency), before, after)
+	})
+
+	return result, err
+}
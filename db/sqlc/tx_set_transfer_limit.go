@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/kwalter26/udemy-simplebank/limits"
+)
+
+// SetTransferLimitTxParams contains the input parameters of the SetTransferLimit transaction. Without Username and
+// AccountID the defaults of the currency are set; AccountID must be an account in the currency.
+type SetTransferLimitTxParams struct {
+	Currency  string
+	Username  string
+	AccountID int64
+	// Limits replace the limits of the scope. Nil removes them, so that a user falls back to the defaults.
+	Limits *limits.Limits
+	Audit  AuditContext
+}
+
+// SetTransferLimitTxResult is the result of the SetTransferLimit transaction
+type SetTransferLimitTxResult struct {
+	// Limit is nil when the limits were removed.
+	Limit    *TransferLimit
+	Previous *TransferLimit
+}
+
+// SetTransferLimitTx creates, replaces or removes the transfer limits of a currency, a user or an account and
+// records the change in the audit log within a single database transaction.
+func (store *SQLStore) SetTransferLimitTx(ctx context.Context, arg SetTransferLimitTxParams) (SetTransferLimitTxResult, error) {
+	var result SetTransferLimitTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		username := sql.NullString{String: arg.Username, Valid: arg.Username != ""}
+		accountID := sql.NullInt64{Int64: arg.AccountID, Valid: arg.AccountID != 0}
+
+		previous, err := q.GetTransferLimitForUpdate(ctx, GetTransferLimitForUpdateParams{
+			Currency:  arg.Currency,
+			Username:  username,
+			AccountID: accountID,
+		})
+		switch {
+		case err == nil:
+			result.Previous = &previous
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+
+		var limit TransferLimit
+		switch {
+		case arg.Limits == nil && result.Previous == nil:
+			return nil
+		case arg.Limits == nil:
+			err = q.DeleteTransferLimit(ctx, previous.ID)
+		case result.Previous == nil:
+			limit, err = q.CreateTransferLimit(ctx, CreateTransferLimitParams{
+				Currency:       arg.Currency,
+				Username:       username,
+				AccountID:      accountID,
+				PerTransferMax: arg.Limits.PerTransfer,
+				DailyMax:       arg.Limits.Daily,
+				MonthlyMax:     arg.Limits.Monthly,
+			})
+			result.Limit = &limit
+		default:
+			limit, err = q.UpdateTransferLimit(ctx, UpdateTransferLimitParams{
+				ID:             previous.ID,
+				PerTransferMax: arg.Limits.PerTransfer,
+				DailyMax:       arg.Limits.Daily,
+				MonthlyMax:     arg.Limits.Monthly,
+			})
+			result.Limit = &limit
+		}
+		if err != nil {
+			return err
+		}
+
+		var before, after interface{}
+		target := limit.ID
+		if result.Previous != nil {
+			before = *result.Previous
+			target = result.Previous.ID
+		}
+		if result.Limit != nil {
+			after = *result.Limit
+		}
+		return recordAuditEvent(ctx, q, arg.Audit, AuditActionTransferLimitSet, AuditTarget("transfer_limit", target), before, after)
+	})
+
+	return result, err
+}
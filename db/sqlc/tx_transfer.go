@@ -2,13 +2,22 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
 )
 
+// ErrAccountFrozen is returned by TransferTx when one of the accounts is frozen.
+var ErrAccountFrozen = errors.New("account is frozen")
+
 // TransferTxParams contains the input parameters of the transfer transaction
 type TransferTxParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64        `json:"from_account_id"`
+	ToAccountID   int64        `json:"to_account_id"`
+	Amount        int64        `json:"amount"`
+	CoolingOff    CoolingOff   `json:"-"`
+	Audit         AuditContext `json:"-"`
 }
 
 // TransferTxResult is the result of the transfer transaction
@@ -18,10 +27,27 @@ type TransferTxResult struct {
 	ToAccount   Account  `json:"to_account"`
 	FromEntry   Entry    `json:"from_entry"`
 	ToEntry     Entry    `json:"to_entry"`
+	// FeeEntry charges the fee to the source account, and RevenueEntry credits it to RevenueAccount, the fee
+	// revenue account of the bank. They are nil when the transfer is free.
+	FeeEntry       *Entry   `json:"fee_entry,omitempty"`
+	RevenueAccount *Account `json:"-"`
+	RevenueEntry   *Entry   `json:"-"`
+}
+
+// auditTransferBalances is the audited view of a transfer and the balances it changed.
+type auditTransferBalances struct {
+	Transfer           *Transfer `json:"transfer,omitempty"`
+	FromAccountBalance int64     `json:"from_account_balance"`
+	ToAccountBalance   int64     `json:"to_account_balance"`
 }
 
 // TransferTx performs a money transfer from one account to the other.
 // It creates a transfer record, add account entries, and update accounts' balance within a single database transaction.
+// The fee of the active fee rule of the currency is charged to the source account and credited to the fee revenue
+// account of the bank in the same transaction.
+// It fails with an *ErrLimitExceeded when the amount exceeds a transfer limit of the source account or of its owner,
+// and with an *ErrCoolingOff when it exceeds what is left of the cooling-off maximum of a new payee or recipient of
+// the owner.
 // If any of the operations fail, it will rollback the transaction and return an error.
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
@@ -29,66 +55,184 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 	// execute a database transaction
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
+		result, err = transfer(ctx, q, arg)
+		return err
+	})
 
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			Amount:        arg.Amount,
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-		})
-		if err != nil {
-			return err
+	return result, err
+}
+
+// transfer moves the money of one transfer and its fee, audits it and records its domain events within the
+// transaction of q. The accounts, including the fee revenue account, are locked in id order before the fee is
+// computed.
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	fromAccount, err := q.GetAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		return result, err
+	}
+	rule, revenueAccount, err := transferFeeRule(ctx, q, fromAccount)
+	if err != nil {
+		return result, err
+	}
+
+	ids := []int64{arg.FromAccountID, arg.ToAccountID}
+	if rule != nil {
+		ids = append(ids, revenueAccount.ID)
+	}
+	locked, err := q.ListAccountsForUpdate(ctx, ids)
+	if err != nil {
+		return result, err
+	}
+	var toAccount Account
+	for _, account := range locked {
+		if account.Frozen && (account.ID == arg.FromAccountID || account.ID == arg.ToAccountID) {
+			return result, ErrAccountFrozen
+		}
+		if account.ID == arg.ToAccountID {
+			toAccount = account
+		}
+	}
+
+	now := time.Now()
+	if fromAccount.Owner != BankLedgerOwner {
+		if err := checkTransferLimits(ctx, q, fromAccount, arg.Amount, now); err != nil {
+			return result, err
+		}
+		if err := checkCoolingOff(ctx, q, fromAccount, toAccount, arg.Amount, arg.CoolingOff, now); err != nil {
+			return result, err
 		}
+	}
+
+	quote, err := quoteTransferFee(ctx, q, fromAccount, rule, arg.Amount, now)
+	if err != nil {
+		return result, err
+	}
+	transferParams := CreateTransferParams{
+		Amount:        arg.Amount,
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Fee:           quote.Fee,
+	}
+	if rule != nil {
+		transferParams.FeeRuleID = sql.NullInt64{Int64: rule.ID, Valid: true}
+	}
+	result.Transfer, err = q.CreateTransfer(ctx, transferParams)
+	if err != nil {
+		return result, err
+	}
+	transferID := sql.NullInt64{Int64: result.Transfer.ID, Valid: true}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  arg.FromAccountID,
+		Amount:     -arg.Amount,
+		TransferID: transferID,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  arg.ToAccountID,
+		Amount:     arg.Amount,
+		TransferID: transferID,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	changes := map[int64]int64{arg.FromAccountID: -arg.Amount}
+	changes[arg.ToAccountID] += arg.Amount
+	if quote.Fee > 0 {
+		feeEntry, err := q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:  arg.FromAccountID,
+			Amount:     -quote.Fee,
+			TransferID: transferID,
+			IsFee:      true,
 		})
 		if err != nil {
-			return err
+			return result, err
 		}
+		result.FeeEntry = &feeEntry
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
+		revenueEntry, err := q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:  revenueAccount.ID,
+			Amount:     quote.Fee,
+			TransferID: transferID,
+			IsFee:      true,
 		})
 		if err != nil {
-			return err
+			return result, err
 		}
+		result.RevenueEntry = &revenueEntry
 
-		// update accounts' balance
-
-		if arg.FromAccountID < arg.ToAccountID {
-
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-
-		} else {
+		changes[arg.FromAccountID] -= quote.Fee
+		changes[revenueAccount.ID] += quote.Fee
+	}
 
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	// update accounts' balance
+	accounts, err := addMoney(ctx, q, changes)
+	if err != nil {
+		return result, err
+	}
+	result.FromAccount = accounts[arg.FromAccountID]
+	result.ToAccount = accounts[arg.ToAccountID]
 
-		}
+	before := auditTransferBalances{
+		FromAccountBalance: result.FromAccount.Balance + arg.Amount + quote.Fee,
+		ToAccountBalance:   result.ToAccount.Balance - arg.Amount,
+	}
+	after := auditTransferBalances{
+		Transfer:           &result.Transfer,
+		FromAccountBalance: result.FromAccount.Balance,
+		ToAccountBalance:   result.ToAccount.Balance,
+	}
+	err = recordAuditEvent(ctx, q, arg.Audit, AuditActionTransferCreate, AuditTarget("transfer", result.Transfer.ID), before, after)
+	if err != nil {
+		return result, err
+	}
 
-		return nil
-	})
+	if result.RevenueEntry != nil {
+		account := accounts[revenueAccount.ID]
+		result.RevenueAccount = &account
+	}
 
-	return result, err
+	return result, recordTransferEvents(ctx, q, result)
 }
 
-func addMoney(ctx context.Context, q *Queries, accountID1 int64, amount1 int64, accountID2 int64, amount2 int64) (account1 Account, account2 Account, err error) {
-	account1, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID1,
-		Amount: amount1,
-	})
+// transferFeeRule returns the fee rule of transfers from account and the fee revenue account of the bank in its
+// currency, opening it the first time it is used. The rule is nil when transfers from account are free.
+func transferFeeRule(ctx context.Context, q *Queries, account Account) (*FeeRule, Account, error) {
+	rule, err := activeFeeRule(ctx, q, account)
+	if err != nil || rule == nil {
+		return nil, Account{}, err
+	}
+	revenueAccount, err := ledgerAccount(ctx, q, account.Currency, ProductFeeRevenue)
 	if err != nil {
-		return
+		return nil, Account{}, err
 	}
+	return rule, revenueAccount, nil
+}
 
-	account2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID2,
-		Amount: amount2,
-	})
-	if err != nil {
-		return
+// addMoney applies the balance changes in account id order and returns the updated accounts by id.
+func addMoney(ctx context.Context, q *Queries, changes map[int64]int64) (map[int64]Account, error) {
+	ids := make([]int64, 0, len(changes))
+	for id := range changes {
+		ids = append(ids, id)
 	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
 
-	return
+	accounts := make(map[int64]Account, len(ids))
+	for _, id := range ids {
+		account, err := q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     id,
+			Amount: changes[id],
+		})
+		if err != nil {
+			return nil, err
+		}
+		accounts[id] = account
+	}
+	return accounts, nil
 }
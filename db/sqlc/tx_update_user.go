@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+)
+
+// UpdateUserTxParams contains the input parameters of the UpdateUser transaction
+type UpdateUserTxParams struct {
+	UpdateUserParams
+	Audit AuditContext
+}
+
+// UpdateUserTxResult is the result of the UpdateUser transaction
+type UpdateUserTxResult struct {
+	User User
+}
+
+// UpdateUserTx updates a user and records the change in the audit log within a single database transaction.
+func (store *SQLStore) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error) {
+	var result UpdateUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUser(ctx, arg.UpdateUserParams)
+		if err != nil {
+			return err
+		}
+
+		action := AuditActionUserUpdate
+		if arg.HashedPassword.Valid {
+			action = AuditActionPasswordChange
+		}
+		return recordAuditEvent(ctx, q, arg.Audit, action, AuditTarget("user", result.User.Username), newAuditUser(before), newAuditUser(result.User))
+	})
+
+	return result, err
+}
@@ -41,7 +41,14 @@ func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParam
 				Valid: true,
 			},
 		})
-		return err
+		if err != nil {
+			return err
+		}
+
+		return recordDomainEvent(ctx, q, result.User.Username, 0, DomainEventUserVerified, UserVerifiedEvent{
+			Username: result.User.Username,
+			Email:    result.User.Email,
+		})
 	})
 
 	return result, err
@@ -13,7 +13,7 @@ import (
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (username, hashed_password, full_name, email)
 VALUES ($1, $2, $3, $4)
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role
 `
 
 type CreateUserParams struct {
@@ -39,12 +39,13 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsEmailVerified,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role
 FROM users
 WHERE username = $1
 LIMIT 1
@@ -61,6 +62,54 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsEmailVerified,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role
+FROM users
+WHERE email = $1
+LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getUserForUpdate = `-- name: GetUserForUpdate :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role
+FROM users
+WHERE username = $1
+LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetUserForUpdate(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserForUpdate, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
 	)
 	return i, err
 }
@@ -71,9 +120,10 @@ SET hashed_password     = COALESCE($1, hashed_password),
     password_changed_at = COALESCE($2, password_changed_at),
     full_name           = COALESCE($3, full_name),
     email               = COALESCE($4, email),
-    is_email_verified   = COALESCE($5, is_email_verified)
-WHERE username = $6
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified
+    is_email_verified   = COALESCE($5, is_email_verified),
+    role                = COALESCE($6, role)
+WHERE username = $7
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role
 `
 
 type UpdateUserParams struct {
@@ -82,6 +132,7 @@ type UpdateUserParams struct {
 	FullName          sql.NullString `json:"full_name"`
 	Email             sql.NullString `json:"email"`
 	IsEmailVerified   sql.NullBool   `json:"is_email_verified"`
+	Role              sql.NullString `json:"role"`
 	Username          string         `json:"username"`
 }
 
@@ -92,6 +143,7 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		arg.FullName,
 		arg.Email,
 		arg.IsEmailVerified,
+		arg.Role,
 		arg.Username,
 	)
 	var i User
@@ -103,6 +155,7 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsEmailVerified,
+		&i.Role,
 	)
 	return i, err
 }
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"github.com/kwalter26/udemy-simplebank/util"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -27,6 +28,7 @@ func createRandomUser(t *testing.T) User {
 	require.Equal(t, arg.HashedPassword, user.HashedPassword)
 	require.Equal(t, arg.FullName, user.FullName)
 	require.Equal(t, arg.Email, user.Email)
+	require.Equal(t, util.DepositorRole, user.Role)
 
 	require.True(t, user.PasswordChangedAt.IsZero())
 	require.NotZero(t, user.CreatedAt)
@@ -147,3 +149,56 @@ func TestUpdateUsersFullNameEmailPassword(t *testing.T) {
 	require.WithinDuration(t, oldUser.PasswordChangedAt, updatedUser.PasswordChangedAt, time.Second)
 	require.WithinDuration(t, oldUser.CreatedAt, updatedUser.CreatedAt, time.Second)
 }
+
+func TestCreateUserTxWithRole(t *testing.T) {
+	store := NewStore(testDB)
+	arg := CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       util.RandomOwner(),
+			HashedPassword: util.RandomString(32),
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		},
+		Role:  util.BankerRole,
+		Audit: AuditContext{Actor: "bankctl:tester"},
+	}
+
+	result, err := store.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, util.BankerRole, result.User.Role)
+
+	events, err := testQueries.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		Target:     sql.NullString{String: AuditTarget("user", result.User.Username), Valid: true},
+		StartTime:  time.Now().Add(-time.Minute),
+		EndTime:    time.Now().Add(time.Minute),
+		LimitCount: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, AuditActionUserUpdate, events[0].Action)
+	require.Equal(t, "bankctl:tester", events[0].Actor)
+}
+
+// TestCreateUserTxWithRoleRollsBack fails after the role is set: neither the user nor its role is left behind.
+func TestCreateUserTxWithRoleRollsBack(t *testing.T) {
+	store := NewStore(testDB)
+	username := util.RandomOwner()
+
+	_, err := store.CreateUserTx(context.Background(), CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       username,
+			HashedPassword: util.RandomString(32),
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		},
+		Role: util.BankerRole,
+		AfterCreate: func(user User) ([]CreateOutboxTaskParams, error) {
+			require.Equal(t, util.BankerRole, user.Role)
+			return nil, errors.New("cannot build task")
+		},
+	})
+	require.EqualError(t, err, "cannot build task")
+
+	_, err = testQueries.GetUser(context.Background(), username)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
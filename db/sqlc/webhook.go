@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Statuses of a webhook delivery. A failed delivery is retried until it succeeds or runs out of retries and is dead.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+	WebhookDeliveryDead      = "dead"
+)
+
+// Webhook deliveries are enqueued through the outbox and processed by the worker under this task type and queue.
+const (
+	WebhookDeliveryTaskType = "task:deliver_webhook"
+	WebhookDeliveryQueue    = "webhook"
+	WebhookDeliveryMaxRetry = 8
+)
+
+// WebhookPayload is the body posted to a webhook endpoint.
+type WebhookPayload struct {
+	EventID   int64           `json:"event_id"`
+	Type      string          `json:"type"`
+	AccountID *int64          `json:"account_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WebhookDeliveryTaskPayload is the outbox payload of a webhook delivery task.
+type WebhookDeliveryTaskPayload struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// enqueueWebhookDeliveries creates a delivery for every webhook of the event owner subscribed to its type, and the
+// outbox task delivering it once the transaction is committed.
+func enqueueWebhookDeliveries(ctx context.Context, q *Queries, event DomainEvent) error {
+	payload := WebhookPayload{
+		EventID:   event.ID,
+		Type:      event.EventType,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Payload,
+	}
+	if event.AccountID.Valid {
+		payload.AccountID = &event.AccountID.Int64
+	}
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("cannot marshal webhook payload: %w", err)
+	}
+
+	deliveries, err := q.CreateWebhookDeliveries(ctx, CreateWebhookDeliveriesParams{
+		EventID:   event.ID,
+		EventType: event.EventType,
+		Payload:   jsonPayload,
+		Owner:     event.Owner,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		taskPayload, err := json.Marshal(WebhookDeliveryTaskPayload{DeliveryID: delivery.ID})
+		if err != nil {
+			return fmt.Errorf("cannot marshal webhook task: %w", err)
+		}
+
+		_, err = q.CreateOutboxTask(ctx, CreateOutboxTaskParams{
+			TaskID:    uuid.NewString(),
+			TaskType:  WebhookDeliveryTaskType,
+			Payload:   taskPayload,
+			Queue:     WebhookDeliveryQueue,
+			MaxRetry:  WebhookDeliveryMaxRetry,
+			ProcessAt: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
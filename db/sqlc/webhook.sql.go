@@ -0,0 +1,248 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: webhook.sql
+
+package db
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/lib/pq"
+)
+
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO webhooks (owner,
+                      url,
+                      event_types,
+                      secret)
+VALUES ($1, $2, $3, $4)
+RETURNING id, owner, url, event_types, secret, created_at
+`
+
+type CreateWebhookParams struct {
+	Owner      string   `json:"owner"`
+	Url        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret"`
+}
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, createWebhook,
+		arg.Owner,
+		arg.Url,
+		pq.Array(arg.EventTypes),
+		arg.Secret,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		pq.Array(&i.EventTypes),
+		&i.Secret,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createWebhookDeliveries = `-- name: CreateWebhookDeliveries :many
+INSERT INTO webhook_deliveries (webhook_id,
+                                event_id,
+                                event_type,
+                                payload)
+SELECT id,
+       $1::bigint,
+       $2::varchar,
+       $3::jsonb
+FROM webhooks
+WHERE owner = $4
+  AND $2::varchar = ANY (event_types)
+RETURNING id, webhook_id, event_id, event_type, payload, status, attempts, response_code, last_error, created_at, updated_at
+`
+
+type CreateWebhookDeliveriesParams struct {
+	EventID   int64           `json:"event_id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	Owner     string          `json:"owner"`
+}
+
+func (q *Queries) CreateWebhookDeliveries(ctx context.Context, arg CreateWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, createWebhookDeliveries,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+		arg.Owner,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.ResponseCode,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhook = `-- name: GetWebhook :one
+SELECT id, owner, url, event_types, secret, created_at
+FROM webhooks
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetWebhook(ctx context.Context, id int64) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, getWebhook, id)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		pq.Array(&i.EventTypes),
+		&i.Secret,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT id, webhook_id, event_id, event_type, payload, status, attempts, response_code, last_error, created_at, updated_at
+FROM webhook_deliveries
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookDelivery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.ResponseCode,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, webhook_id, event_id, event_type, payload, status, attempts, response_code, last_error, created_at, updated_at
+FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY id DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListWebhookDeliveriesParams struct {
+	WebhookID int64 `json:"webhook_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookDeliveries, arg.WebhookID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.ResponseCode,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateWebhookDeliveryAttempt = `-- name: UpdateWebhookDeliveryAttempt :one
+UPDATE webhook_deliveries
+SET status        = $2,
+    attempts      = attempts + 1,
+    response_code = $3,
+    last_error    = $4,
+    updated_at    = now()
+WHERE id = $1
+RETURNING id, webhook_id, event_id, event_type, payload, status, attempts, response_code, last_error, created_at, updated_at
+`
+
+type UpdateWebhookDeliveryAttemptParams struct {
+	ID           int64  `json:"id"`
+	Status       string `json:"status"`
+	ResponseCode int32  `json:"response_code"`
+	LastError    string `json:"last_error"`
+}
+
+func (q *Queries) UpdateWebhookDeliveryAttempt(ctx context.Context, arg UpdateWebhookDeliveryAttemptParams) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, updateWebhookDeliveryAttempt,
+		arg.ID,
+		arg.Status,
+		arg.ResponseCode,
+		arg.LastError,
+	)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.ResponseCode,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTransferTxEnqueuesWebhookDeliveries(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	hook, err := testQueries.CreateWebhook(context.Background(), CreateWebhookParams{
+		Owner:      account2.Owner,
+		Url:        "https://example.com/hooks",
+		EventTypes: []string{DomainEventBalanceChanged},
+		Secret:     "secret",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{DomainEventBalanceChanged}, hook.EventTypes)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	deliveries, err := testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		WebhookID: hook.ID,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, DomainEventBalanceChanged, deliveries[0].EventType)
+	require.Equal(t, WebhookDeliveryPending, deliveries[0].Status)
+
+	var payload WebhookPayload
+	require.NoError(t, json.Unmarshal(deliveries[0].Payload, &payload))
+	require.Equal(t, deliveries[0].EventID, payload.EventID)
+	require.Equal(t, account2.ID, *payload.AccountID)
+
+	var count int
+	err = testDB.QueryRow(
+		"SELECT count(*) FROM outbox WHERE task_type = $1 AND payload = $2",
+		WebhookDeliveryTaskType, []byte(`{"delivery_id":`+jsonInt(deliveries[0].ID)+`}`),
+	).Scan(&count)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	delivery, err := testQueries.UpdateWebhookDeliveryAttempt(context.Background(), UpdateWebhookDeliveryAttemptParams{
+		ID:           deliveries[0].ID,
+		Status:       WebhookDeliveryFailed,
+		ResponseCode: 500,
+		LastError:    "webhook endpoint responded with 500",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), delivery.Attempts)
+	require.Equal(t, WebhookDeliveryFailed, delivery.Status)
+}
+
+func jsonInt(value int64) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
@@ -9,6 +9,7 @@ CREATE TABLE "users"
     "full_name"           varchar        NOT NULL,
     "email"               varchar UNIQUE NOT NULL,
     "is_email_verified"   boolean        NOT NULL DEFAULT false,
+    "role"                varchar        NOT NULL DEFAULT 'depositor',
     "password_changed_at" timestamptz    NOT NULL DEFAULT '0001-01-01 00:00:00Z',
     "created_at"          timestamptz    NOT NULL DEFAULT (now())
 );
@@ -30,7 +31,19 @@ CREATE TABLE "accounts"
     "owner"      varchar     NOT NULL,
     "balance"    bigint      NOT NULL,
     "currency"   varchar     NOT NULL,
-    "created_at" timestamptz NOT NULL DEFAULT (now())
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "frozen"     boolean     NOT NULL DEFAULT false,
+    "product"    varchar     NOT NULL DEFAULT 'checking'
+);
+
+CREATE TABLE "account_products"
+(
+    "code"            varchar PRIMARY KEY,
+    "name"            varchar     NOT NULL,
+    "annual_rate_bps" integer     NOT NULL DEFAULT 0,
+    "compounding"     varchar     NOT NULL DEFAULT 'monthly',
+    "internal"        boolean     NOT NULL DEFAULT false,
+    "created_at"      timestamptz NOT NULL DEFAULT (now())
 );
 
 CREATE TABLE "entries"
@@ -38,7 +51,9 @@ CREATE TABLE "entries"
   "id" bigserial PRIMARY KEY,
   "account_id" bigserial,
   "amount" bigint NOT NULL,
-  "created_at" timestamptz NOT NULL DEFAULT (now())
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "transfer_id" bigint,
+  "is_fee" boolean NOT NULL DEFAULT false
 );
 
 CREATE TABLE "transfers" (
@@ -46,6 +61,8 @@ CREATE TABLE "transfers" (
   "from_account_id" bigserial,
   "to_account_id" bigserial,
   "ammount" bigint NOT NULL,
+  "fee" bigint NOT NULL DEFAULT 0,
+  "fee_rule_id" bigint,
   "created_at" timestamptz NOT NULL DEFAULT (now())
 );
 
@@ -60,33 +77,303 @@ CREATE TABLE "sessions" (
                             "created_at"    timestamptz NOT NULL DEFAULT (now())
 );
 
+CREATE TABLE "login_lockouts"
+(
+    "kind"           varchar     NOT NULL,
+    "key"            varchar     NOT NULL,
+    "failed_count"   integer     NOT NULL DEFAULT 0,
+    "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+    "locked_until"   timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+    PRIMARY KEY ("kind", "key")
+);
+
+CREATE TABLE "audit_events"
+(
+    "id"         bigserial PRIMARY KEY,
+    "actor"      varchar     NOT NULL,
+    "action"     varchar     NOT NULL,
+    "target"     varchar     NOT NULL,
+    "client_ip"  varchar     NOT NULL DEFAULT '',
+    "user_agent" varchar     NOT NULL DEFAULT '',
+    "before"     jsonb       NOT NULL DEFAULT '{}',
+    "after"      jsonb       NOT NULL DEFAULT '{}',
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "outbox"
+(
+    "id"           bigserial PRIMARY KEY,
+    "task_id"      varchar     NOT NULL,
+    "task_type"    varchar     NOT NULL,
+    "payload"      bytea       NOT NULL,
+    "queue"        varchar     NOT NULL,
+    "max_retry"    integer     NOT NULL,
+    "process_at"   timestamptz NOT NULL DEFAULT (now()),
+    "attempts"     integer     NOT NULL DEFAULT 0,
+    "last_error"   varchar     NOT NULL DEFAULT '',
+    "created_at"   timestamptz NOT NULL DEFAULT (now()),
+    "published_at" timestamptz,
+    "dead_at"      timestamptz
+);
+
+CREATE TABLE "domain_events"
+(
+    "id"         bigserial PRIMARY KEY,
+    "owner"      varchar     NOT NULL,
+    "account_id" bigint,
+    "event_type" varchar     NOT NULL,
+    "payload"    jsonb       NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "position"   bigint
+);
+
+CREATE TABLE "webhooks"
+(
+    "id"          bigserial PRIMARY KEY,
+    "owner"       varchar     NOT NULL,
+    "url"         varchar     NOT NULL,
+    "event_types" varchar[]   NOT NULL,
+    "secret"      varchar     NOT NULL,
+    "created_at"  timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_deliveries"
+(
+    "id"            bigserial PRIMARY KEY,
+    "webhook_id"    bigint      NOT NULL,
+    "event_id"      bigint      NOT NULL,
+    "event_type"    varchar     NOT NULL,
+    "payload"       jsonb       NOT NULL,
+    "status"        varchar     NOT NULL DEFAULT 'pending',
+    "attempts"      integer     NOT NULL DEFAULT 0,
+    "response_code" integer     NOT NULL DEFAULT 0,
+    "last_error"    varchar     NOT NULL DEFAULT '',
+    "created_at"    timestamptz NOT NULL DEFAULT (now()),
+    "updated_at"    timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "dead_letter_tasks"
+(
+    "id"          bigserial PRIMARY KEY,
+    "task_id"     varchar     NOT NULL,
+    "task_type"   varchar     NOT NULL,
+    "queue"       varchar     NOT NULL,
+    "payload"     bytea       NOT NULL,
+    "last_error"  varchar     NOT NULL DEFAULT '',
+    "retried"     integer     NOT NULL DEFAULT 0,
+    "max_retry"   integer     NOT NULL DEFAULT 0,
+    "failed_at"   timestamptz NOT NULL DEFAULT (now()),
+    "resolution"  varchar     NOT NULL DEFAULT '',
+    "resolved_at" timestamptz
+);
+
+CREATE TABLE "account_statements"
+(
+    "id"              bigserial PRIMARY KEY,
+    "account_id"      bigint      NOT NULL,
+    "period_start"    timestamptz NOT NULL,
+    "period_end"      timestamptz NOT NULL,
+    "blob_key"        varchar     NOT NULL,
+    "size_bytes"      bigint      NOT NULL,
+    "opening_balance" bigint      NOT NULL,
+    "closing_balance" bigint      NOT NULL,
+    "created_at"      timestamptz NOT NULL DEFAULT (now()),
+    "notified_at"     timestamptz
+);
+
+CREATE TABLE "interest_accruals"
+(
+    "account_id"      bigint      NOT NULL,
+    "accrual_date"    date        NOT NULL,
+    "balance"         bigint      NOT NULL,
+    "annual_rate_bps" integer     NOT NULL,
+    "compounding"     varchar     NOT NULL,
+    "amount_micros"   bigint      NOT NULL,
+    "transfer_id"     bigint,
+    "created_at"      timestamptz NOT NULL DEFAULT (now()),
+    "kind"            varchar     NOT NULL DEFAULT 'daily',
+    PRIMARY KEY ("account_id", "accrual_date", "kind")
+);
+
+CREATE TABLE "fee_rules"
+(
+    "id"                       bigserial PRIMARY KEY,
+    "currency"                 varchar     NOT NULL,
+    "kind"                     varchar     NOT NULL,
+    "flat_amount"              bigint      NOT NULL DEFAULT 0,
+    "rate_bps"                 integer     NOT NULL DEFAULT 0,
+    "min_amount"               bigint      NOT NULL DEFAULT 0,
+    "max_amount"               bigint      NOT NULL DEFAULT 0,
+    "free_transfers_per_month" integer     NOT NULL DEFAULT 0,
+    "active"                   boolean     NOT NULL DEFAULT true,
+    "created_at"               timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "transfer_limits"
+(
+    "id"               bigserial PRIMARY KEY,
+    "currency"         varchar     NOT NULL,
+    "username"         varchar,
+    "account_id"       bigint,
+    "per_transfer_max" bigint      NOT NULL DEFAULT 0,
+    "daily_max"        bigint      NOT NULL DEFAULT 0,
+    "monthly_max"      bigint      NOT NULL DEFAULT 0,
+    "updated_at"       timestamptz NOT NULL DEFAULT (now()),
+    CONSTRAINT "transfer_limits_scope_check" CHECK ("username" IS NULL OR "account_id" IS NULL)
+);
+
+CREATE TABLE "payees"
+(
+    "id"                     bigserial PRIMARY KEY,
+    "owner"                  varchar     NOT NULL,
+    "nickname"               varchar     NOT NULL,
+    "account_id"             bigint      NOT NULL,
+    "currency"               varchar     NOT NULL,
+    "cooling_off_until"      timestamptz,
+    "cooling_off_max_amount" bigint      NOT NULL DEFAULT 0,
+    "created_at"             timestamptz NOT NULL DEFAULT (now()),
+    "account_hidden"         boolean     NOT NULL DEFAULT false
+);
+
 CREATE UNIQUE INDEX ON "verify_emails" ("secret_code");
 
 CREATE INDEX ON "accounts" ("owner");
 
-CREATE UNIQUE INDEX ON "accounts" ("owner", "currency");
+CREATE UNIQUE INDEX ON "accounts" ("owner", "currency", "product");
 
 CREATE INDEX ON "entries" ("account_id");
 
+CREATE INDEX ON "entries" ("account_id", "created_at");
+
 CREATE INDEX ON "transfers" ("from_account_id");
 
 CREATE INDEX ON "transfers" ("to_account_id");
 
 CREATE INDEX ON "transfers" ("from_account_id", "to_account_id");
 
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+CREATE INDEX ON "audit_events" ("actor", "created_at");
+
+CREATE INDEX ON "audit_events" ("target", "created_at");
+
+CREATE UNIQUE INDEX ON "outbox" ("task_id");
+
+CREATE INDEX ON "outbox" ("id") WHERE "published_at" IS NULL AND "dead_at" IS NULL;
+
+CREATE INDEX ON "domain_events" ("owner", "id");
+
+CREATE UNIQUE INDEX ON "domain_events" ("position");
+
+CREATE INDEX ON "domain_events" ("owner", "position");
+
+CREATE INDEX ON "domain_events" ("id") WHERE "position" IS NULL;
+
+CREATE INDEX ON "webhooks" ("owner");
+
+CREATE INDEX ON "webhook_deliveries" ("webhook_id", "id");
+
+CREATE UNIQUE INDEX ON "dead_letter_tasks" ("queue", "task_id");
+
+CREATE UNIQUE INDEX ON "account_statements" ("account_id", "period_start");
+
+CREATE INDEX ON "interest_accruals" ("account_id") WHERE "transfer_id" IS NULL;
+
+CREATE UNIQUE INDEX ON "fee_rules" ("currency") WHERE "active";
+
+CREATE UNIQUE INDEX ON "transfer_limits" ("currency") WHERE "username" IS NULL AND "account_id" IS NULL;
+
+CREATE UNIQUE INDEX ON "transfer_limits" ("username", "currency") WHERE "username" IS NOT NULL;
+
+CREATE UNIQUE INDEX ON "transfer_limits" ("account_id") WHERE "account_id" IS NOT NULL;
+
+CREATE UNIQUE INDEX ON "payees" ("owner", "nickname");
+
+CREATE UNIQUE INDEX ON "payees" ("owner", "account_id");
+
+COMMENT ON COLUMN "accounts"."frozen" IS 'frozen accounts can neither send nor receive transfers';
+
+COMMENT ON COLUMN "account_products"."annual_rate_bps" IS 'yearly interest rate in basis points';
+
+COMMENT ON COLUMN "account_products"."compounding" IS 'daily or monthly';
+
+COMMENT ON COLUMN "account_products"."internal" IS 'internal products are the ledger accounts of the bank';
+
 COMMENT ON COLUMN "entries"."amount" IS 'can be negative or positive';
 
+COMMENT ON COLUMN "entries"."transfer_id" IS 'transfer that made the entry, if any';
+
+COMMENT ON COLUMN "entries"."is_fee" IS 'entry of the fee of its transfer';
+
 COMMENT ON COLUMN "transfers"."ammount" IS 'must be positive';
 
+COMMENT ON COLUMN "transfers"."fee" IS 'charged to the source account on top of the amount';
+
+COMMENT ON COLUMN "login_lockouts"."kind" IS 'username or client_ip';
+
+COMMENT ON COLUMN "audit_events"."target" IS 'kind and id of the affected row, e.g. user:alice';
+
+COMMENT ON COLUMN "outbox"."task_id" IS 'asynq task id, used to drop duplicates when a row is published twice';
+
+COMMENT ON COLUMN "outbox"."dead_at" IS 'set when the relay gave up publishing the task, which then lives on in dead_letter_tasks';
+
+COMMENT ON COLUMN "domain_events"."position" IS 'offset subscribers resume from, given in commit order once the event is committed';
+
+COMMENT ON COLUMN "domain_events"."owner" IS 'user allowed to see the event';
+
+COMMENT ON COLUMN "webhooks"."secret" IS 'HMAC key the payloads are signed with';
+
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending, succeeded, failed or dead';
+
+COMMENT ON COLUMN "dead_letter_tasks"."resolution" IS 'empty while unresolved, then retried or deleted';
+
+COMMENT ON COLUMN "account_statements"."blob_key" IS 'key of the PDF in the blob store';
+
+COMMENT ON COLUMN "account_statements"."notified_at" IS 'when the owner was emailed about the statement';
+
+COMMENT ON COLUMN "interest_accruals"."balance" IS 'balance at the end of the day';
+
+COMMENT ON COLUMN "interest_accruals"."amount_micros" IS 'interest of the day in millionths of the currency unit';
+
+COMMENT ON COLUMN "interest_accruals"."transfer_id" IS 'transfer that posted the interest, null until posted';
+
+COMMENT ON COLUMN "interest_accruals"."kind" IS 'daily, or carry for the rounding remainder of the previous posting';
+
+COMMENT ON COLUMN "fee_rules"."kind" IS 'flat or percentage';
+
+COMMENT ON COLUMN "fee_rules"."rate_bps" IS 'fee of a percentage rule in basis points of the amount';
+
+COMMENT ON COLUMN "fee_rules"."max_amount" IS '0 means no maximum';
+
+COMMENT ON COLUMN "fee_rules"."free_transfers_per_month" IS 'transfers of a calendar month that are not charged';
+
+COMMENT ON COLUMN "transfer_limits"."username" IS 'user the limits replace the defaults of the currency for, null for the defaults';
+
+COMMENT ON COLUMN "transfer_limits"."account_id" IS 'account the limits apply to on top of the limits of its owner';
+
+COMMENT ON COLUMN "transfer_limits"."per_transfer_max" IS '0 means no limit, as for daily_max and monthly_max';
+
+COMMENT ON COLUMN "payees"."account_id" IS 'the payee is deleted with the account';
+
+COMMENT ON COLUMN "payees"."cooling_off_until" IS 'until then the transfers to the payee cannot exceed cooling_off_max_amount in total, null for no cooling-off';
+
+COMMENT ON COLUMN "payees"."account_hidden" IS 'the payee was found by username or email, so its account is not shown to the owner';
+
 ALTER TABLE "verify_emails"
     ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
 
 ALTER TABLE "accounts"
     ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
 
+ALTER TABLE "accounts"
+    ADD FOREIGN KEY ("product") REFERENCES "account_products" ("code");
+
 ALTER TABLE "entries"
     ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
 
+ALTER TABLE "entries"
+    ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
 ALTER TABLE "transfers"
     ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
 
@@ -94,3 +381,29 @@ ALTER TABLE "transfers"
     ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
 
 ALTER TABLE "sessions" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "domain_events" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "domain_events" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "webhooks" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("id");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("event_id") REFERENCES "domain_events" ("id");
+
+ALTER TABLE "account_statements" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("fee_rule_id") REFERENCES "fee_rules" ("id");
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "payees" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "payees" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
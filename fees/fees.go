@@ -0,0 +1,100 @@
+// Package fees computes the fee charged on a transfer. Each currency has at most one active rule, which charges
+// either a flat amount or a percentage of the amount clamped to a minimum and a maximum, after a number of free
+// transfers every calendar month.
+package fees
+
+import (
+	"errors"
+	"math/big"
+	"time"
+)
+
+// Kinds of fee rule
+const (
+	// KindFlat charges the same amount on every transfer.
+	KindFlat = "flat"
+	// KindPercentage charges a rate of the amount, clamped to the minimum and the maximum of the rule.
+	KindPercentage = "percentage"
+)
+
+// basisPoints is the number of basis points in 100%.
+const basisPoints = 10_000
+
+// Rule is how transfers in a currency are charged. A MaxAmount of 0 means no maximum.
+type Rule struct {
+	Kind                  string
+	FlatAmount            int64
+	RateBps               int32
+	MinAmount             int64
+	MaxAmount             int64
+	FreeTransfersPerMonth int32
+}
+
+// Quote is the fee of a transfer.
+type Quote struct {
+	Fee int64
+	// FreeTransfersRemaining is how many free transfers were left this month before the transfer.
+	FreeTransfersRemaining int32
+}
+
+// IsSupportedKind reports whether kind is one of the kinds of fee rule.
+func IsSupportedKind(kind string) bool {
+	return kind == KindFlat || kind == KindPercentage
+}
+
+// Validate checks that the rule can be applied.
+func (rule Rule) Validate() error {
+	switch {
+	case !IsSupportedKind(rule.Kind):
+		return errors.New("kind must be flat or percentage")
+	case rule.FlatAmount < 0:
+		return errors.New("flat amount cannot be negative")
+	case rule.RateBps < 0 || rule.RateBps > basisPoints:
+		return errors.New("rate must be between 0 and 10000 basis points")
+	case rule.MinAmount < 0 || rule.MaxAmount < 0:
+		return errors.New("minimum and maximum cannot be negative")
+	case rule.MaxAmount > 0 && rule.MaxAmount < rule.MinAmount:
+		return errors.New("maximum cannot be below the minimum")
+	case rule.FreeTransfersPerMonth < 0:
+		return errors.New("free transfers per month cannot be negative")
+	}
+	return nil
+}
+
+// Compute returns the fee of a transfer of amount, given how many transfers the source account already made this
+// month. The first FreeTransfersPerMonth transfers of a month are free. A percentage fee is rounded half up.
+func Compute(rule Rule, amount int64, transfersThisMonth int64) Quote {
+	var quote Quote
+	if remaining := int64(rule.FreeTransfersPerMonth) - transfersThisMonth; remaining > 0 {
+		quote.FreeTransfersRemaining = int32(remaining)
+		return quote
+	}
+
+	switch rule.Kind {
+	case KindFlat:
+		quote.Fee = rule.FlatAmount
+	case KindPercentage:
+		quote.Fee = percentage(amount, rule.RateBps)
+		if quote.Fee < rule.MinAmount {
+			quote.Fee = rule.MinAmount
+		}
+		if rule.MaxAmount > 0 && quote.Fee > rule.MaxAmount {
+			quote.Fee = rule.MaxAmount
+		}
+	}
+	return quote
+}
+
+// MonthStart returns the start of the calendar month in UTC that t falls in, from when the free transfers are
+// counted.
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// percentage returns rateBps basis points of the positive amount, rounded half up.
+func percentage(amount int64, rateBps int32) int64 {
+	numerator := new(big.Int).Mul(big.NewInt(amount), big.NewInt(int64(rateBps)))
+	numerator.Add(numerator, big.NewInt(basisPoints/2))
+	return numerator.Quo(numerator, big.NewInt(basisPoints)).Int64()
+}
@@ -0,0 +1,51 @@
+package fees
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCompute(t *testing.T) {
+	flat := Rule{Kind: KindFlat, FlatAmount: 2}
+	percent := Rule{Kind: KindPercentage, RateBps: 150, MinAmount: 1, MaxAmount: 50}
+
+	for _, tc := range []struct {
+		name      string
+		rule      Rule
+		amount    int64
+		transfers int64
+		want      Quote
+	}{
+		{"Flat", flat, 1000, 0, Quote{Fee: 2}},
+		{"Percentage", percent, 1000, 0, Quote{Fee: 15}},
+		{"PercentageRoundsHalfUp", percent, 100, 0, Quote{Fee: 2}},
+		{"PercentageMinimum", percent, 10, 0, Quote{Fee: 1}},
+		{"PercentageMaximum", percent, 1_000_000, 0, Quote{Fee: 50}},
+		{"PercentageNoMaximum", Rule{Kind: KindPercentage, RateBps: 150}, 1_000_000, 0, Quote{Fee: 15_000}},
+		{"LargeAmount", Rule{Kind: KindPercentage, RateBps: 10_000}, 9_000_000_000_000_000_000, 0, Quote{Fee: 9_000_000_000_000_000_000}},
+		{"FirstFree", Rule{Kind: KindFlat, FlatAmount: 2, FreeTransfersPerMonth: 3}, 1000, 0, Quote{FreeTransfersRemaining: 3}},
+		{"LastFree", Rule{Kind: KindFlat, FlatAmount: 2, FreeTransfersPerMonth: 3}, 1000, 2, Quote{FreeTransfersRemaining: 1}},
+		{"FreeUsedUp", Rule{Kind: KindFlat, FlatAmount: 2, FreeTransfersPerMonth: 3}, 1000, 3, Quote{Fee: 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, Compute(tc.rule, tc.amount, tc.transfers))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Rule{Kind: KindFlat, FlatAmount: 2}.Validate())
+	require.NoError(t, Rule{Kind: KindPercentage, RateBps: 150, MinAmount: 1}.Validate())
+
+	require.EqualError(t, Rule{Kind: "tiered"}.Validate(), "kind must be flat or percentage")
+	require.EqualError(t, Rule{Kind: KindFlat, FlatAmount: -1}.Validate(), "flat amount cannot be negative")
+	require.EqualError(t, Rule{Kind: KindPercentage, RateBps: 10_001}.Validate(), "rate must be between 0 and 10000 basis points")
+	require.EqualError(t, Rule{Kind: KindPercentage, MinAmount: 10, MaxAmount: 5}.Validate(), "maximum cannot be below the minimum")
+	require.EqualError(t, Rule{Kind: KindFlat, FreeTransfersPerMonth: -1}.Validate(), "free transfers per month cannot be negative")
+}
+
+func TestMonthStart(t *testing.T) {
+	require.Equal(t, time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), MonthStart(time.Date(2023, 3, 31, 23, 59, 0, 0, time.UTC)))
+	require.Equal(t, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), MonthStart(time.Date(2023, 3, 1, 0, 30, 0, 0, time.FixedZone("CET", 3600))))
+}
@@ -0,0 +1,66 @@
+package gapi
+
+import (
+	"fmt"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"github.com/kwalter26/udemy-simplebank/statement"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// AccountStatementPattern is where the statement of an account is downloaded, with from, to and format as query
+// parameters. It is not a gRPC method: a gateway route cannot stream a file, so it is served on the gateway mux
+// by hand and the pattern itself names it for authentication and rate limits.
+const AccountStatementPattern = "/v1/accounts/{account_id}/statement"
+
+// AccountStatementHandler streams a statement as it is generated, so that a long period is never held in memory.
+// Errors found before the first byte is written are answered like gateway errors; a failure after that can only
+// cut the download short.
+func (s *Server) AccountStatementHandler(mux *runtime.ServeMux) runtime.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request, pathParams map[string]string) {
+		ctx := req.Context()
+		_, outboundMarshaler := runtime.MarshalerForRequest(mux, req)
+
+		payload, err := authenticatedUser(ctx)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outboundMarshaler, res, req, err)
+			return
+		}
+
+		accountID, err := strconv.ParseInt(pathParams["account_id"], 10, 64)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outboundMarshaler, res, req, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+				fieldViolation("account_id", fmt.Errorf("must be a number")),
+			}))
+			return
+		}
+		query := req.URL.Query()
+		period, format, violations := validateAccountStatementRequest(accountID, query.Get("from"), query.Get("to"), query.Get("format"))
+		if violations != nil {
+			runtime.HTTPError(ctx, mux, outboundMarshaler, res, req, invalidArgumentError(violations))
+			return
+		}
+
+		account, err := s.statementAccount(ctx, payload.Username, accountID)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outboundMarshaler, res, req, err)
+			return
+		}
+
+		writer, err := statement.NewWriter(format, res)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outboundMarshaler, res, req, err)
+			return
+		}
+		res.Header().Set("Content-Type", statement.ContentType(format))
+		res.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+			"filename": statement.FileName(account.ID, period, format),
+		}))
+		if _, err := statement.Generate(ctx, s.store, account, period, writer); err != nil {
+			logging.Ctx(ctx).Error().Err(err).Int64("account_id", account.ID).Msg("failed to stream account statement")
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package gapi
+
+import (
+	"context"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+)
+
+// auditContext identifies the actor of an audited action and the client it came from.
+func (s *Server) auditContext(ctx context.Context, actor string) db.AuditContext {
+	mdtd := s.extractMetadata(ctx)
+	return db.AuditContext{
+		Actor:     actor,
+		ClientIp:  mdtd.ClientIp,
+		UserAgent: mdtd.UserAgent,
+	}
+}
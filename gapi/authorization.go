@@ -2,9 +2,16 @@ package gapi
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"github.com/kwalter26/udemy-simplebank/pb"
 	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
 	"strings"
 )
 
@@ -13,6 +20,87 @@ const (
 	authorizationBearer = "bearer"
 )
 
+// publicMethods are the only RPCs that can be called without an access token. Every other method, including the
+// ones added later, requires authentication.
+var publicMethods = map[string]bool{
+	pb.SimpleBank_CreateUser_FullMethodName:                          true,
+	pb.SimpleBank_Login_FullMethodName:                               true,
+	pb.SimpleBank_VerifyEmail_FullMethodName:                         true,
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": true,
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo":      true,
+	"/grpc.health.v1.Health/Check":                                   true,
+	"/grpc.health.v1.Health/Watch":                                   true,
+}
+
+type authPayloadKey struct{}
+
+// AuthPayload returns the token payload of the authenticated caller stored in the context by the auth interceptor.
+func AuthPayload(ctx context.Context) (*token.Payload, bool) {
+	payload, ok := ctx.Value(authPayloadKey{}).(*token.Payload)
+	return payload, ok && payload != nil
+}
+
+// AuthInterceptor authenticates every unary call to a method that is not public and stores the token payload in
+// the context.
+func (s *Server) AuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamAuthInterceptor is the streaming counterpart of AuthInterceptor.
+func (s *Server) StreamAuthInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// HttpAuthenticator rejects gateway requests to methods that are not public when they carry no valid access token.
+// The gateway calls the server directly, so the gRPC interceptors never see those requests.
+func (s *Server) HttpAuthenticator(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		method, ok := gatewayRouteMethod(req)
+		if !ok || publicMethods[method] {
+			handler.ServeHTTP(res, req)
+			return
+		}
+
+		md := metadata.Pairs(authorizationHeader, req.Header.Get(authorizationHeader))
+		ctx, err := s.authenticate(metadata.NewIncomingContext(req.Context(), md), method)
+		if err != nil {
+			writeHttpError(res, err)
+			return
+		}
+		handler.ServeHTTP(res, req.WithContext(context.WithValue(req.Context(), authPayloadKey{}, ctx.Value(authPayloadKey{}))))
+	})
+}
+
+// authenticate verifies the access token of calls to non-public methods and returns a context carrying its payload.
+func (s *Server) authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	if publicMethods[fullMethod] {
+		return ctx, nil
+	}
+
+	payload, err := s.authorizeUser(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+	return context.WithValue(ctx, authPayloadKey{}, payload), nil
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
 func (s *Server) authorizeUser(ctx context.Context) (*token.Payload, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
@@ -20,7 +108,7 @@ func (s *Server) authorizeUser(ctx context.Context) (*token.Payload, error) {
 	}
 
 	values := md.Get(authorizationHeader)
-	if len(values) == 0 {
+	if len(values) == 0 || values[0] == "" {
 		return nil, fmt.Errorf("missing authorization header")
 	}
 
@@ -43,3 +131,35 @@ func (s *Server) authorizeUser(ctx context.Context) (*token.Payload, error) {
 
 	return payload, nil
 }
+
+// authenticatedUser returns the payload stored by the auth interceptor. The returned error is already a gRPC status.
+func authenticatedUser(ctx context.Context) (*token.Payload, error) {
+	payload, ok := AuthPayload(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing authentication")
+	}
+	return payload, nil
+}
+
+// authorizeBanker makes sure the authenticated caller has the banker role. The role is read from the database so
+// that revoking it takes effect immediately. The returned error is already a gRPC status.
+func (s *Server) authorizeBanker(ctx context.Context) (*token.Payload, error) {
+	payload, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.Unauthenticated, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %s", err)
+	}
+
+	if user.Role != util.BankerRole {
+		return nil, status.Error(codes.PermissionDenied, "banker role required")
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,156 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	server := newTestServer(t, nil, nil)
+	user, _ := createRandomUser(t)
+
+	testCases := []struct {
+		name       string
+		fullMethod string
+		ctx        context.Context
+		code       codes.Code
+	}{
+		{
+			name:       "PublicMethod",
+			fullMethod: pb.SimpleBank_Login_FullMethodName,
+			ctx:        context.Background(),
+			code:       codes.OK,
+		},
+		{
+			name:       "Authenticated",
+			fullMethod: pb.SimpleBank_UpdateUser_FullMethodName,
+			ctx:        getAuthCtx(t, server.tokenMaker, user, time.Minute),
+			code:       codes.OK,
+		},
+		{
+			name:       "MissingToken",
+			fullMethod: pb.SimpleBank_UpdateUser_FullMethodName,
+			ctx:        context.Background(),
+			code:       codes.Unauthenticated,
+		},
+		{
+			name:       "ExpiredToken",
+			fullMethod: pb.SimpleBank_UpdateUser_FullMethodName,
+			ctx:        getAuthCtx(t, server.tokenMaker, user, -time.Minute),
+			code:       codes.Unauthenticated,
+		},
+		{
+			name:       "UnknownMethodFailsClosed",
+			fullMethod: "/pb.SimpleBank/NotYetListed",
+			ctx:        context.Background(),
+			code:       codes.Unauthenticated,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var payloadUsername string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				if payload, ok := AuthPayload(ctx); ok {
+					payloadUsername = payload.Username
+				}
+				return "ok", nil
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: tc.fullMethod}
+			_, err := server.AuthInterceptor(tc.ctx, nil, info, handler)
+			require.Equal(t, tc.code, status.Code(err))
+
+			if tc.code == codes.OK && !publicMethods[tc.fullMethod] {
+				require.Equal(t, user.Username, payloadUsername)
+			}
+		})
+	}
+}
+
+func TestStreamAuthInterceptor(t *testing.T) {
+	server := newTestServer(t, nil, nil)
+	user, _ := createRandomUser(t)
+
+	var payloadUsername string
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		payload, ok := AuthPayload(stream.Context())
+		require.True(t, ok)
+		payloadUsername = payload.Username
+		return nil
+	}
+	info := &grpc.StreamServerInfo{FullMethod: "/pb.SimpleBank/SomeStream"}
+
+	stream := &testServerStream{ctx: getAuthCtx(t, server.tokenMaker, user, time.Minute)}
+	require.NoError(t, server.StreamAuthInterceptor(nil, stream, info, handler))
+	require.Equal(t, user.Username, payloadUsername)
+
+	stream = &testServerStream{ctx: context.Background()}
+	err := server.StreamAuthInterceptor(nil, stream, info, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestHttpAuthenticator(t *testing.T) {
+	server := newTestServer(t, nil, nil)
+	user, _ := createRandomUser(t)
+
+	accessToken, _, err := server.tokenMaker.CreateToken(user.Username, time.Minute)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		status        int
+	}{
+		{name: "PublicRoute", method: http.MethodPost, path: "/v1/login_user", status: http.StatusOK},
+		{name: "NotAGatewayRoute", method: http.MethodGet, path: "/swagger/index.html", status: http.StatusOK},
+		{name: "MissingToken", method: http.MethodPatch, path: "/v1/update_user", status: http.StatusUnauthorized},
+		{name: "InvalidToken", method: http.MethodPatch, path: "/v1/update_user", authorization: "Bearer invalid", status: http.StatusUnauthorized},
+		{name: "Authenticated", method: http.MethodPatch, path: "/v1/update_user", authorization: fmt.Sprintf("Bearer %s", accessToken), status: http.StatusOK},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			handler := server.HttpAuthenticator(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				if tc.authorization != "" {
+					payload, ok := AuthPayload(req.Context())
+					require.True(t, ok)
+					require.Equal(t, user.Username, payload.Username)
+				}
+				res.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.authorization != "" {
+				req.Header.Set(authorizationHeader, tc.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			require.Equal(t, tc.status, recorder.Code)
+		})
+	}
+}
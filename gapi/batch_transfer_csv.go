@@ -0,0 +1,118 @@
+package gapi
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// BatchTransferCsvPattern is where the lines of a batch transfer are uploaded as CSV, with the currency and mode
+// as query parameters. It is routed to the BatchTransfer method, so authentication and rate limits treat both
+// alike.
+const BatchTransferCsvPattern = "/v1/accounts/{from_account_id}/batch_transfers/csv"
+
+// batchTransferCsvLineSize bounds the size of the upload, which is far above what a line of two numbers needs.
+const batchTransferCsvLineSize = 256
+
+// BatchTransferCsvHandler serves the CSV upload on the gateway mux. The first row is a header naming the
+// to_account_id and amount columns, other columns are ignored.
+func (s *Server) BatchTransferCsvHandler(mux *runtime.ServeMux) runtime.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request, pathParams map[string]string) {
+		_, outboundMarshaler := runtime.MarshalerForRequest(mux, req)
+		ctx, err := runtime.AnnotateIncomingContext(req.Context(), mux, req, pb.SimpleBank_BatchTransfer_FullMethodName, runtime.WithHTTPPathPattern(BatchTransferCsvPattern))
+		if err != nil {
+			runtime.HTTPError(req.Context(), mux, outboundMarshaler, res, req, err)
+			return
+		}
+
+		req.Body = http.MaxBytesReader(res, req.Body, int64((s.batchTransferMaxLines()+1)*batchTransferCsvLineSize))
+		rsp, err := s.batchTransferCsv(ctx, req, pathParams)
+		if err != nil {
+			runtime.HTTPError(ctx, mux, outboundMarshaler, res, req, err)
+			return
+		}
+		runtime.ForwardResponseMessage(ctx, mux, outboundMarshaler, res, req, rsp, mux.GetForwardResponseOptions()...)
+	}
+}
+
+func (s *Server) batchTransferCsv(ctx context.Context, req *http.Request, pathParams map[string]string) (*pb.BatchTransferResponse, error) {
+	fromAccountID, err := strconv.ParseInt(pathParams["from_account_id"], 10, 64)
+	if err != nil {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			fieldViolation("from_account_id", fmt.Errorf("must be a number")),
+		})
+	}
+
+	lines, err := parseBatchTransferCsv(req.Body, s.batchTransferMaxLines())
+	if err != nil {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{fieldViolation("csv", err)})
+	}
+
+	query := req.URL.Query()
+	return s.BatchTransfer(ctx, &pb.BatchTransferRequest{
+		FromAccountId: fromAccountID,
+		Currency:      query.Get("currency"),
+		Mode:          query.Get("mode"),
+		Lines:         lines,
+	})
+}
+
+// parseBatchTransferCsv reads the lines of a batch transfer. It stops reading once there are more than maxLines,
+// so that the request is rejected without holding a huge upload in memory.
+func parseBatchTransferCsv(body io.Reader, maxLines int) ([]*pb.BatchTransferLine, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("missing header")
+		}
+		return nil, err
+	}
+	toAccountColumn, amountColumn := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "to_account_id":
+			toAccountColumn = i
+		case "amount":
+			amountColumn = i
+		}
+	}
+	if toAccountColumn < 0 || amountColumn < 0 {
+		return nil, errors.New("header must name the to_account_id and amount columns")
+	}
+
+	var lines []*pb.BatchTransferLine
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(lines) == maxLines {
+			return nil, fmt.Errorf("must have at most %d lines", maxLines)
+		}
+
+		row, _ := reader.FieldPos(0)
+		toAccountID, err := strconv.ParseInt(strings.TrimSpace(record[toAccountColumn]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid to_account_id %q", row, record[toAccountColumn])
+		}
+		amount, err := strconv.ParseInt(strings.TrimSpace(record[amountColumn]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid amount %q", row, record[amountColumn])
+		}
+		lines = append(lines, &pb.BatchTransferLine{ToAccountId: toAccountID, Amount: amount})
+	}
+}
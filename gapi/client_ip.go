@@ -0,0 +1,74 @@
+package gapi
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// parseTrustedProxies parses the IPs and CIDRs of the trusted proxies. A plain IP trusts that single address.
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// forwardedHops splits X-Forwarded-For values into the addresses of the hops, the client first.
+func forwardedHops(forwardedFor []string) []string {
+	var hops []string
+	for _, value := range forwardedFor {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+// clientIp returns the client of a request from the addresses it went through, the client first and the address
+// of the connection last. Every hop but the last one can be made up by the client, so the hops are only believed
+// while they were added by a trusted proxy: the client is the right-most address that is not a trusted proxy.
+func (s *Server) clientIp(hops []string) string {
+	for i := len(hops) - 1; i > 0; i-- {
+		if !s.isTrustedProxy(hops[i]) {
+			return hops[i]
+		}
+	}
+	if len(hops) == 0 {
+		return ""
+	}
+	return hops[0]
+}
+
+func (s *Server) isTrustedProxy(addr string) bool {
+	ip := net.ParseIP(clientIpKey(addr))
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range s.trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
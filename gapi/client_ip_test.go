@@ -0,0 +1,66 @@
+package gapi
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"testing"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := parseTrustedProxies([]string{"10.0.0.1", "192.168.0.0/16", "::1"})
+	require.NoError(t, err)
+	require.Len(t, proxies, 3)
+	require.Equal(t, "10.0.0.1/32", proxies[0].String())
+	require.Equal(t, "192.168.0.0/16", proxies[1].String())
+	require.Equal(t, "::1/128", proxies[2].String())
+
+	_, err = parseTrustedProxies([]string{"proxy.local"})
+	require.Error(t, err)
+
+	_, err = parseTrustedProxies([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+}
+
+func TestClientIp(t *testing.T) {
+	server := newTestServer(t, nil, nil)
+	trustedProxies, err := parseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		trustedProxies []*net.IPNet
+		hops           []string
+		want           string
+	}{
+		{name: "NoHops", hops: nil, want: ""},
+		{name: "Connection", hops: []string{"1.2.3.4:5555"}, want: "1.2.3.4:5555"},
+		{name: "UntrustedForwardedFor", hops: []string{"6.6.6.6", "1.2.3.4:5555"}, want: "1.2.3.4:5555"},
+		{name: "TrustedProxy", trustedProxies: trustedProxies, hops: []string{"1.2.3.4", "10.0.0.1:5555"}, want: "1.2.3.4"},
+		{name: "SpoofedFirstHop", trustedProxies: trustedProxies, hops: []string{"6.6.6.6", "1.2.3.4", "10.0.0.2", "10.0.0.1:5555"}, want: "1.2.3.4"},
+		{name: "OnlyTrustedProxies", trustedProxies: trustedProxies, hops: []string{"10.0.0.3", "10.0.0.1:5555"}, want: "10.0.0.3"},
+		{name: "InvalidHop", trustedProxies: trustedProxies, hops: []string{"1.2.3.4", "unknown", "10.0.0.1:5555"}, want: "unknown"},
+	}
+
+	for _, tc := range testCases {
+		server.trustedProxies = tc.trustedProxies
+		require.Equal(t, tc.want, server.clientIp(tc.hops), tc.name)
+	}
+}
+
+func TestExtractMetadataClientIp(t *testing.T) {
+	server := newTestServer(t, nil, nil)
+
+	// a gateway request: the gateway adds the address of its connection to X-Forwarded-For
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(xForwardedForHeader, "6.6.6.6, 1.2.3.4"))
+	require.Equal(t, "1.2.3.4", server.extractMetadata(ctx).ClientIp)
+
+	// a gRPC request: the peer is the last hop
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5555}})
+	require.Equal(t, "10.0.0.1:5555", server.extractMetadata(ctx).ClientIp)
+
+	server.trustedProxies, _ = parseTrustedProxies([]string{"10.0.0.1"})
+	require.Equal(t, "1.2.3.4", server.extractMetadata(ctx).ClientIp)
+}
@@ -1,8 +1,14 @@
 package gapi
 
 import (
+	"encoding/json"
+	"fmt"
+	"github.com/hibiken/asynq"
 	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/limits"
+	"github.com/kwalter26/udemy-simplebank/logging"
 	"github.com/kwalter26/udemy-simplebank/pb"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -15,3 +21,236 @@ func userToPb(user db.User) *pb.User {
 		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt),
 	}
 }
+
+// Convert db.AuditEvent to pb.AuditEvent
+func auditEventToPb(event db.AuditEvent) (*pb.AuditEvent, error) {
+	before, err := jsonToStruct(event.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := jsonToStruct(event.After)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.AuditEvent{
+		Id:        event.ID,
+		Actor:     event.Actor,
+		Action:    event.Action,
+		Target:    event.Target,
+		ClientIp:  event.ClientIp,
+		UserAgent: event.UserAgent,
+		Before:    before,
+		After:     after,
+		CreatedAt: timestamppb.New(event.CreatedAt),
+	}, nil
+}
+
+// Convert db.Webhook to pb.Webhook. The secret is left out.
+func webhookToPb(hook db.Webhook) *pb.Webhook {
+	return &pb.Webhook{
+		Id:         hook.ID,
+		Url:        hook.Url,
+		EventTypes: hook.EventTypes,
+		CreatedAt:  timestamppb.New(hook.CreatedAt),
+	}
+}
+
+// Convert db.WebhookDelivery to pb.WebhookDelivery
+func webhookDeliveryToPb(delivery db.WebhookDelivery) *pb.WebhookDelivery {
+	return &pb.WebhookDelivery{
+		Id:           delivery.ID,
+		WebhookId:    delivery.WebhookID,
+		EventId:      delivery.EventID,
+		EventType:    delivery.EventType,
+		Status:       delivery.Status,
+		Attempts:     delivery.Attempts,
+		ResponseCode: delivery.ResponseCode,
+		LastError:    delivery.LastError,
+		CreatedAt:    timestamppb.New(delivery.CreatedAt),
+		UpdatedAt:    timestamppb.New(delivery.UpdatedAt),
+	}
+}
+
+// Convert db.DomainEvent to pb.DomainEvent
+func domainEventToPb(event db.DomainEvent) (*pb.DomainEvent, error) {
+	rsp := &pb.DomainEvent{
+		Offset:    event.Position.Int64,
+		CreatedAt: timestamppb.New(event.CreatedAt),
+	}
+
+	var err error
+	switch event.EventType {
+	case db.DomainEventAccountOpened:
+		var payload db.AccountOpenedEvent
+		err = json.Unmarshal(event.Payload, &payload)
+		rsp.Event = &pb.DomainEvent_AccountOpened{AccountOpened: &pb.AccountOpened{
+			AccountId: payload.AccountID,
+			Owner:     payload.Owner,
+			Currency:  payload.Currency,
+			Balance:   payload.Balance,
+		}}
+	case db.DomainEventTransferCompleted:
+		var payload db.TransferCompletedEvent
+		err = json.Unmarshal(event.Payload, &payload)
+		rsp.Event = &pb.DomainEvent_TransferCompleted{TransferCompleted: &pb.TransferCompleted{
+			TransferId:    payload.TransferID,
+			FromAccountId: payload.FromAccountID,
+			ToAccountId:   payload.ToAccountID,
+			Amount:        payload.Amount,
+			Fee:           payload.Fee,
+		}}
+	case db.DomainEventBalanceChanged:
+		var payload db.BalanceChangedEvent
+		err = json.Unmarshal(event.Payload, &payload)
+		rsp.Event = &pb.DomainEvent_BalanceChanged{BalanceChanged: &pb.BalanceChanged{
+			AccountId: payload.AccountID,
+			EntryId:   payload.EntryID,
+			Currency:  payload.Currency,
+			Amount:    payload.Amount,
+			Balance:   payload.Balance,
+		}}
+	case db.DomainEventUserVerified:
+		var payload db.UserVerifiedEvent
+		err = json.Unmarshal(event.Payload, &payload)
+		rsp.Event = &pb.DomainEvent_UserVerified{UserVerified: &pb.UserVerified{
+			Username: payload.Username,
+			Email:    payload.Email,
+		}}
+	default:
+		return nil, fmt.Errorf("unknown domain event type %q", event.EventType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rsp, nil
+}
+
+func jsonToStruct(data json.RawMessage) (*structpb.Struct, error) {
+	fields := map[string]interface{}{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, err
+		}
+	}
+	return structpb.NewStruct(fields)
+}
+
+func failedTaskToPb(task *asynq.TaskInfo) *pb.FailedTask {
+	rsp := &pb.FailedTask{
+		Id:        task.ID,
+		Queue:     task.Queue,
+		Type:      task.Type,
+		Payload:   string(logging.RedactJSON(task.Payload)),
+		State:     task.State.String(),
+		Retried:   int32(task.Retried),
+		MaxRetry:  int32(task.MaxRetry),
+		LastError: task.LastErr,
+	}
+	if !task.LastFailedAt.IsZero() {
+		rsp.LastFailedAt = timestamppb.New(task.LastFailedAt)
+	}
+	if !task.NextProcessAt.IsZero() {
+		rsp.NextProcessAt = timestamppb.New(task.NextProcessAt)
+	}
+	return rsp
+}
+
+// batchTransferLineToPb converts the result of the line at index i.
+func batchTransferLineToPb(i int, line db.BatchTransferLineResult) *pb.BatchTransferLineResult {
+	rsp := &pb.BatchTransferLineResult{
+		Line:        int32(i + 1),
+		ToAccountId: line.ToAccountID,
+		Amount:      line.Amount,
+		Status:      line.Status,
+	}
+	if line.Err != nil {
+		rsp.Error = line.Err.Error()
+	}
+	if line.Transfer != nil {
+		rsp.TransferId = line.Transfer.ID
+		rsp.Fee = line.Transfer.Fee
+	}
+	return rsp
+}
+
+// Convert db.AccountStatement to pb.AccountStatement
+func accountStatementToPb(record db.AccountStatement) *pb.AccountStatement {
+	return &pb.AccountStatement{
+		Id:             record.ID,
+		AccountId:      record.AccountID,
+		PeriodStart:    timestamppb.New(record.PeriodStart),
+		PeriodEnd:      timestamppb.New(record.PeriodEnd),
+		OpeningBalance: record.OpeningBalance,
+		ClosingBalance: record.ClosingBalance,
+		SizeBytes:      record.SizeBytes,
+		CreatedAt:      timestamppb.New(record.CreatedAt),
+	}
+}
+
+// Convert limits.Limits to pb.TransferLimits
+func transferLimitsToPb(l limits.Limits) *pb.TransferLimits {
+	return &pb.TransferLimits{
+		PerTransferMax: l.PerTransfer,
+		DailyMax:       l.Daily,
+		MonthlyMax:     l.Monthly,
+	}
+}
+
+// Convert pb.TransferLimits to limits.Limits
+func transferLimitsFromPb(l *pb.TransferLimits) limits.Limits {
+	return limits.Limits{
+		PerTransfer: l.GetPerTransferMax(),
+		Daily:       l.GetDailyMax(),
+		Monthly:     l.GetMonthlyMax(),
+	}
+}
+
+// Convert db.TransferLimit to pb.TransferLimit
+func transferLimitToPb(limit db.TransferLimit) *pb.TransferLimit {
+	return &pb.TransferLimit{
+		Id:        limit.ID,
+		Scope:     limit.Scope(),
+		Currency:  limit.Currency,
+		Username:  limit.Username.String,
+		AccountId: limit.AccountID.Int64,
+		Limits:    transferLimitsToPb(limit.Limits()),
+		UpdatedAt: timestamppb.New(limit.UpdatedAt),
+	}
+}
+
+// Convert limits of a scope and their usage to pb.TransferLimitUsage
+func transferLimitUsageToPb(scope string, l limits.Limits, usage limits.Usage) *pb.TransferLimitUsage {
+	rsp := &pb.TransferLimitUsage{
+		Scope:       scope,
+		Limits:      transferLimitsToPb(l),
+		DailyUsed:   usage.Daily,
+		MonthlyUsed: usage.Monthly,
+	}
+	if remaining, limited := limits.Remaining(l.Daily, usage.Daily); limited {
+		rsp.DailyRemaining = &remaining
+	}
+	if remaining, limited := limits.Remaining(l.Monthly, usage.Monthly); limited {
+		rsp.MonthlyRemaining = &remaining
+	}
+	return rsp
+}
+
+// Convert db.Payee to pb.Payee
+func payeeToPb(payee db.Payee) *pb.Payee {
+	rsp := &pb.Payee{
+		Id:                  payee.ID,
+		Nickname:            payee.Nickname,
+		Currency:            payee.Currency,
+		CoolingOffMaxAmount: payee.CoolingOffMaxAmount,
+		CreatedAt:           timestamppb.New(payee.CreatedAt),
+	}
+	// a payee found by username or email must not turn them into an account number
+	if !payee.AccountHidden {
+		rsp.AccountId = payee.AccountID
+	}
+	if payee.CoolingOffUntil.Valid {
+		rsp.CoolingOffUntil = timestamppb.New(payee.CoolingOffUntil.Time)
+	}
+	return rsp
+}
@@ -1,9 +1,12 @@
 package gapi
 
 import (
+	"encoding/json"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"net/http"
 )
 
 func fieldViolation(field string, err error) *errdetails.BadRequest_FieldViolation {
@@ -28,3 +31,14 @@ func invalidArgumentError(violations []*errdetails.BadRequest_FieldViolation) er
 func unauthenticatedError(err error) error {
 	return status.Error(codes.Unauthenticated, err.Error())
 }
+
+// writeHttpError writes a gRPC status error the way the gateway does, for middlewares running in front of it.
+func writeHttpError(res http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(runtime.HTTPStatusFromCode(st.Code()))
+	_ = json.NewEncoder(res).Encode(map[string]interface{}{
+		"code":    st.Code(),
+		"message": st.Message(),
+	})
+}
@@ -2,14 +2,35 @@ package gapi
 
 import (
 	"context"
-	"github.com/rs/zerolog/log"
+	"github.com/kwalter26/udemy-simplebank/logging"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"net/http"
 	"time"
 )
 
+// maxLoggedBodySize caps how much of a response body is kept for the error log.
+const maxLoggedBodySize = 4096
+
+// GrpcRequestId accepts the request id sent in the metadata or generates one, returns it in the response header and
+// stores it with a correlated logger in the context.
+func GrpcRequestId(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(logging.RequestIdHeader); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	if !logging.ValidRequestId(id) {
+		id = logging.NewRequestId()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(logging.RequestIdHeader, id))
+	return handler(logging.WithRequestId(ctx, id), req)
+}
+
 func GrpcLogger(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 	startTime := time.Now()
 	result, err := handler(ctx, req)
@@ -19,9 +40,9 @@ func GrpcLogger(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo
 		statusCode = st.Code()
 	}
 
-	logger := log.Info()
+	logger := logging.Ctx(ctx).Info()
 	if err != nil {
-		logger = log.Error().Err(err)
+		logger = logging.Ctx(ctx).Error().Err(err)
 	}
 
 	logger.
@@ -34,6 +55,30 @@ func GrpcLogger(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo
 	return result, err
 }
 
+// HttpRequestId is the gateway counterpart of GrpcRequestId. The id is also set on the request header, from where
+// GatewayMetadata copies it into the gRPC metadata.
+func HttpRequestId(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(logging.RequestIdHeader)
+		if !logging.ValidRequestId(id) {
+			id = logging.NewRequestId()
+		}
+
+		req.Header.Set(logging.RequestIdHeader, id)
+		res.Header().Set(logging.RequestIdHeader, id)
+		handler.ServeHTTP(res, req.WithContext(logging.WithRequestId(req.Context(), id)))
+	})
+}
+
+// GatewayMetadata passes the request id of a gateway request on as gRPC metadata.
+func GatewayMetadata(ctx context.Context, req *http.Request) metadata.MD {
+	id := logging.RequestId(req.Context())
+	if id == "" {
+		return nil
+	}
+	return metadata.Pairs(logging.RequestIdHeader, id)
+}
+
 type ResponseRecorder struct {
 	http.ResponseWriter
 	StatusCode int
@@ -46,13 +91,29 @@ func (r *ResponseRecorder) WriteHeader(statusCode int) {
 }
 
 func (r *ResponseRecorder) Write(body []byte) (int, error) {
-	r.Body = body
+	if remaining := maxLoggedBodySize - len(r.Body); remaining > 0 {
+		if len(body) < remaining {
+			remaining = len(body)
+		}
+		r.Body = append(r.Body, body[:remaining]...)
+	}
 	return r.ResponseWriter.Write(body)
 }
 
+// Flush passes flushes on, so that streamed responses are not held back by the recorder.
+func (r *ResponseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the wrapped writer.
+func (r *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 func HttpLogger(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
-		logger := log.Info()
 		startTime := time.Now()
 		rec := &ResponseRecorder{
 			ResponseWriter: res,
@@ -61,14 +122,15 @@ func HttpLogger(handler http.Handler) http.Handler {
 		handler.ServeHTTP(rec, req)
 		duration := time.Since(startTime)
 
+		logger := logging.Ctx(req.Context()).Info()
 		if rec.StatusCode != http.StatusOK {
-			logger = log.Error().Bytes("response_body", rec.Body)
+			logger = logging.Ctx(req.Context()).Error().Bytes("response_body", logging.RedactJSON(rec.Body))
 		}
 
 		logger.
 			Str("protocol", "http").
 			Str("method", req.Method).
-			Str("path", req.RequestURI).
+			Str("path", logging.RedactURL(req.RequestURI)).
 			Int("status_code", rec.StatusCode).
 			Str("status_text", http.StatusText(rec.StatusCode)).
 			Dur("duration", duration).
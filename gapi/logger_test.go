@@ -0,0 +1,76 @@
+package gapi
+
+import (
+	"context"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHttpRequestId(t *testing.T) {
+	var contextId, headerId string
+	handler := HttpRequestId(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		contextId = logging.RequestId(req.Context())
+		headerId = GatewayMetadata(req.Context(), req).Get(logging.RequestIdHeader)[0]
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/login_user", nil)
+	req.Header.Set(logging.RequestIdHeader, "client-id")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, "client-id", contextId)
+	require.Equal(t, "client-id", headerId)
+	require.Equal(t, "client-id", recorder.Header().Get(logging.RequestIdHeader))
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/login_user", nil)
+	req.Header.Set(logging.RequestIdHeader, "not valid")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.NotEqual(t, "not valid", contextId)
+	require.True(t, logging.ValidRequestId(contextId))
+	require.Equal(t, contextId, recorder.Header().Get(logging.RequestIdHeader))
+}
+
+func TestGrpcRequestId(t *testing.T) {
+	var contextId string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		contextId = logging.RequestId(ctx)
+		return nil, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.SimpleBank/LoginUser"}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(logging.RequestIdHeader, "client-id"))
+	_, err := GrpcRequestId(ctx, nil, info, handler)
+	require.NoError(t, err)
+	require.Equal(t, "client-id", contextId)
+
+	_, err = GrpcRequestId(context.Background(), nil, info, handler)
+	require.NoError(t, err)
+	require.True(t, logging.ValidRequestId(contextId))
+}
+
+func TestResponseRecorderKeepsWholeBody(t *testing.T) {
+	rec := &ResponseRecorder{ResponseWriter: httptest.NewRecorder()}
+	_, _ = rec.Write([]byte(`{"code":16,`))
+	_, _ = rec.Write([]byte(`"message":"invalid token"}`))
+	require.Equal(t, `{"code":16,"message":"invalid token"}`, string(rec.Body))
+
+	_, _ = rec.Write(make([]byte, maxLoggedBodySize))
+	require.Len(t, rec.Body, maxLoggedBodySize)
+}
+
+func TestResponseRecorderFlush(t *testing.T) {
+	res := httptest.NewRecorder()
+	var writer http.ResponseWriter = &ResponseRecorder{ResponseWriter: res}
+
+	flusher, ok := writer.(http.Flusher)
+	require.True(t, ok)
+	flusher.Flush()
+	require.True(t, res.Flushed)
+
+	require.NoError(t, http.NewResponseController(writer).Flush())
+}
@@ -0,0 +1,144 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/metrics"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"net"
+	"time"
+)
+
+const (
+	lockoutKindUsername = "username"
+	lockoutKindClientIp = "client_ip"
+)
+
+// errInvalidCredentials is returned for both unknown usernames and wrong passwords so that callers cannot tell
+// which one it was.
+var errInvalidCredentials = status.Error(codes.Unauthenticated, "invalid username or password")
+
+type loginLockoutKey struct {
+	kind   string
+	key    string
+	policy util.LockoutPolicy
+}
+
+// loginLockoutKeys returns the keys failed logins are tracked under: the username and, if known, the client IP.
+func (s *Server) loginLockoutKeys(username string, mdtd *Metadata) []loginLockoutKey {
+	keys := []loginLockoutKey{
+		{kind: lockoutKindUsername, key: username, policy: s.config.UsernameLockoutPolicy()},
+	}
+	if clientIp := clientIpKey(mdtd.ClientIp); clientIp != "" {
+		keys = append(keys, loginLockoutKey{kind: lockoutKindClientIp, key: clientIp, policy: s.config.ClientIpLockoutPolicy()})
+	}
+	return keys
+}
+
+// checkLoginLockout returns a ResourceExhausted error if any of the keys is currently locked.
+func (s *Server) checkLoginLockout(ctx context.Context, keys []loginLockoutKey) error {
+	for _, k := range keys {
+		lockout, err := s.store.GetLoginLockout(ctx, db.GetLoginLockoutParams{Kind: k.kind, Key: k.key})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return status.Errorf(codes.Internal, "failed to check login lockout: %s", err)
+		}
+		if retryAfter := time.Until(lockout.LockedUntil); retryAfter > 0 {
+			metrics.LoginFailures.WithLabelValues(metrics.LoginFailureLockedOut).Inc()
+			return lockedOutError(retryAfter)
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login against every key and locks the keys that went over their policy.
+func (s *Server) recordLoginFailure(ctx context.Context, keys []loginLockoutKey) error {
+	for _, k := range keys {
+		lockout, err := s.store.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+			Kind:        k.kind,
+			Key:         k.key,
+			ResetBefore: k.policy.ResetBefore(time.Now()),
+		})
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to record login failure: %s", err)
+		}
+
+		backoff := k.policy.Backoff(lockout.FailedCount)
+		if backoff == 0 {
+			continue
+		}
+
+		_, err = s.store.LockLogin(ctx, db.LockLoginParams{
+			Kind:        k.kind,
+			Key:         k.key,
+			LockedUntil: time.Now().Add(backoff),
+		})
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to lock login: %s", err)
+		}
+	}
+	return nil
+}
+
+// resetLoginLockout clears the failed login counter of a username. The counters of client IPs are not cleared by a
+// successful login, which anyone with an account could use to keep guessing the passwords of others; they are
+// forgotten after the failure window instead.
+func (s *Server) resetLoginLockout(ctx context.Context, username string) error {
+	return s.store.ResetLoginLockout(ctx, db.ResetLoginLockoutParams{Kind: lockoutKindUsername, Key: username})
+}
+
+// unlockLogin clears the failed login counter of a username and of the client IPs its failed logins came from
+// within the failure window.
+func (s *Server) unlockLogin(ctx context.Context, username string) error {
+	if err := s.resetLoginLockout(ctx, username); err != nil {
+		return err
+	}
+
+	clientIps, err := s.store.ListLoginFailureClientIps(ctx, db.ListLoginFailureClientIpsParams{
+		Target: db.AuditTarget("user", username),
+		Action: db.AuditActionLoginFailed,
+		Since:  s.config.ClientIpLockoutPolicy().ResetBefore(time.Now()),
+	})
+	if err != nil {
+		return err
+	}
+	cleared := map[string]bool{}
+	for _, clientIp := range clientIps {
+		key := clientIpKey(clientIp)
+		if cleared[key] {
+			continue
+		}
+		cleared[key] = true
+		if err := s.store.ResetLoginLockout(ctx, db.ResetLoginLockoutParams{Kind: lockoutKindClientIp, Key: key}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func lockedOutError(retryAfter time.Duration) error {
+	retryAfter = retryAfter.Truncate(time.Second) + time.Second
+	st := status.New(codes.ResourceExhausted, fmt.Sprintf("too many failed login attempts, retry in %s", retryAfter))
+
+	detail, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if err != nil {
+		return st.Err()
+	}
+	return detail.Err()
+}
+
+// clientIpKey strips the port from the peer address so that every connection from a host shares one counter.
+func clientIpKey(clientIp string) string {
+	if host, _, err := net.SplitHostPort(clientIp); err == nil {
+		return host
+	}
+	return clientIp
+}
@@ -15,7 +15,7 @@ func newTestServer(t *testing.T, store db.Store, taskDistributor worker.TaskDist
 		AccessTokenDuration: time.Minute,
 	}
 
-	server, err := NewServer(config, store, taskDistributor)
+	server, err := NewServer(config, store, taskDistributor, nil, nil)
 	require.NoError(t, err)
 	return server
 }
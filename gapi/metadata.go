@@ -17,9 +17,13 @@ type Metadata struct {
 	ClientIp  string
 }
 
+// extractMetadata reads the user agent and client IP of a request. The client IP is taken from the address of the
+// connection, or from the X-Forwarded-For header of trusted proxies. The gateway adds the address of its own
+// connection to that header, since gateway requests have no peer.
 func (s *Server) extractMetadata(ctx context.Context) *Metadata {
 	mdtd := &Metadata{}
 
+	var hops []string
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
 		if userAgents := md.Get(grpcGatewayUserAgentHeader); len(userAgents) > 0 {
 			mdtd.UserAgent = userAgents[0]
@@ -27,16 +31,15 @@ func (s *Server) extractMetadata(ctx context.Context) *Metadata {
 		if userAgents := md.Get(userAgentHeader); len(userAgents) > 0 {
 			mdtd.UserAgent = userAgents[0]
 		}
-		if clientIps := md.Get(xForwardedForHeader); len(clientIps) > 0 {
-			mdtd.ClientIp = clientIps[0]
-		}
+		hops = forwardedHops(md.Get(xForwardedForHeader))
 	}
 
 	if p, ok := peer.FromContext(ctx); ok {
 		if p.Addr != nil {
-			mdtd.ClientIp = p.Addr.String()
+			hops = append(hops, p.Addr.String())
 		}
 	}
+	mdtd.ClientIp = s.clientIp(hops)
 
 	return mdtd
 }
@@ -0,0 +1,47 @@
+package gapi
+
+import (
+	"context"
+	"github.com/kwalter26/udemy-simplebank/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// otherHttpMethod labels requests that are not routed to a gRPC method, so unknown paths can't blow up the number
+// of series.
+const otherHttpMethod = "other"
+
+// GrpcMetrics records the count and latency of every unary call.
+func GrpcMetrics(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	startTime := time.Now()
+	result, err := handler(ctx, req)
+	code := status.Code(err).String()
+
+	metrics.GrpcRequests.WithLabelValues(info.FullMethod, code).Inc()
+	metrics.GrpcRequestDuration.WithLabelValues(info.FullMethod, code).Observe(time.Since(startTime).Seconds())
+	return result, err
+}
+
+// HttpMetrics records the count and latency of every gateway request, labelled with the gRPC method it is routed to.
+func HttpMetrics(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		startTime := time.Now()
+		rec := &ResponseRecorder{
+			ResponseWriter: res,
+			StatusCode:     http.StatusOK,
+		}
+		handler.ServeHTTP(rec, req)
+
+		method, ok := gatewayRouteMethod(req)
+		if !ok {
+			method = otherHttpMethod
+		}
+		code := strconv.Itoa(rec.StatusCode)
+
+		metrics.HttpRequests.WithLabelValues(method, code).Inc()
+		metrics.HttpRequestDuration.WithLabelValues(method, code).Observe(time.Since(startTime).Seconds())
+	})
+}
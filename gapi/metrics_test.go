@@ -0,0 +1,28 @@
+package gapi
+
+import (
+	"github.com/kwalter26/udemy-simplebank/metrics"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHttpMetrics(t *testing.T) {
+	handler := HttpMetrics(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusUnauthorized)
+	}))
+
+	loginCounter := metrics.HttpRequests.WithLabelValues(pb.SimpleBank_Login_FullMethodName, "401")
+	otherCounter := metrics.HttpRequests.WithLabelValues(otherHttpMethod, "401")
+	loginBefore := testutil.ToFloat64(loginCounter)
+	otherBefore := testutil.ToFloat64(otherCounter)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/login_user", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/does/not/exist", nil))
+
+	require.Equal(t, loginBefore+1, testutil.ToFloat64(loginCounter))
+	require.Equal(t, otherBefore+1, testutil.ToFloat64(otherCounter))
+}
@@ -0,0 +1,144 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/ratelimit"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"net/http"
+	"strings"
+)
+
+// gatewayRoute is an HTTP rule of the gateway, used to find which RPC an HTTP request is going to.
+type gatewayRoute struct {
+	verb       string
+	segments   []string
+	fullMethod string
+}
+
+// gatewayRoutes is built from the google.api.http options of the service so that it never drifts from the proto.
+var gatewayRoutes = loadGatewayRoutes()
+
+// RateLimiter returns a unary interceptor applying the configured per-method rate limits.
+func (s *Server) RateLimiter(limiter ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return ratelimit.UnaryServerInterceptor(limiter, s.rateLimits, s.rateLimitKey)
+}
+
+// HttpRateLimiter wraps the gateway handler with the configured per-method rate limits.
+func (s *Server) HttpRateLimiter(limiter ratelimit.Limiter, handler http.Handler) http.Handler {
+	return ratelimit.HttpMiddleware(limiter, s.rateLimits, gatewayMethod, s.httpRateLimitKey, handler)
+}
+
+// rateLimitKey limits authenticated callers by username and everybody else by client IP.
+func (s *Server) rateLimitKey(ctx context.Context) string {
+	if payload, err := s.authorizeUser(ctx); err == nil {
+		return "user:" + payload.Username
+	}
+	return "ip:" + clientIpKey(s.extractMetadata(ctx).ClientIp)
+}
+
+func (s *Server) httpRateLimitKey(req *http.Request) string {
+	fields := strings.Fields(req.Header.Get(authorizationHeader))
+	if len(fields) == 2 && strings.ToLower(fields[0]) == authorizationBearer {
+		if payload, err := s.tokenMaker.VerifyToken(fields[1]); err == nil {
+			return "user:" + payload.Username
+		}
+	}
+
+	hops := append(forwardedHops(req.Header.Values(xForwardedForHeader)), req.RemoteAddr)
+	return "ip:" + clientIpKey(s.clientIp(hops))
+}
+
+// gatewayMethod returns the full gRPC method name an HTTP request is routed to, or the path itself for anything
+// that is not a gateway route (swagger, ...).
+func gatewayMethod(req *http.Request) string {
+	if method, ok := gatewayRouteMethod(req); ok {
+		return method
+	}
+	return req.URL.Path
+}
+
+// gatewayRouteMethod returns the full gRPC method name an HTTP request is routed to, and false if it is not a
+// gateway route.
+func gatewayRouteMethod(req *http.Request) (string, bool) {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for _, route := range gatewayRoutes {
+		if route.verb == req.Method && route.matches(segments) {
+			return route.fullMethod, true
+		}
+	}
+	return "", false
+}
+
+func (r gatewayRoute) matches(segments []string) bool {
+	if len(r.segments) != len(segments) {
+		return false
+	}
+	for i, segment := range r.segments {
+		if strings.HasPrefix(segment, "{") {
+			continue
+		}
+		if segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func loadGatewayRoutes() []gatewayRoute {
+	var routes []gatewayRoute
+
+	services := pb.File_service_simplebank_proto.Services()
+	for i := 0; i < services.Len(); i++ {
+		service := services.Get(i)
+		methods := service.Methods()
+		for j := 0; j < methods.Len(); j++ {
+			method := methods.Get(j)
+			rule, ok := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if !ok || rule == nil {
+				continue
+			}
+
+			verb, path := httpRulePattern(rule)
+			if path == "" {
+				continue
+			}
+			routes = append(routes, gatewayRoute{
+				verb:       verb,
+				segments:   strings.Split(strings.Trim(path, "/"), "/"),
+				fullMethod: fmt.Sprintf("/%s/%s", service.FullName(), method.Name()),
+			})
+		}
+	}
+
+	// routes registered on the gateway by hand
+	routes = append(routes, gatewayRoute{
+		verb:       http.MethodPost,
+		segments:   strings.Split(strings.Trim(BatchTransferCsvPattern, "/"), "/"),
+		fullMethod: pb.SimpleBank_BatchTransfer_FullMethodName,
+	}, gatewayRoute{
+		verb:       http.MethodGet,
+		segments:   strings.Split(strings.Trim(AccountStatementPattern, "/"), "/"),
+		fullMethod: AccountStatementPattern,
+	})
+	return routes
+}
+
+func httpRulePattern(rule *annotations.HttpRule) (string, string) {
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, pattern.Delete
+	}
+	return "", ""
+}
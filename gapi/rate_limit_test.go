@@ -0,0 +1,63 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/peer"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGatewayMethod(t *testing.T) {
+	testCases := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{method: http.MethodPost, path: "/v1/create_user", want: pb.SimpleBank_CreateUser_FullMethodName},
+		{method: http.MethodPost, path: "/v1/login_user", want: pb.SimpleBank_Login_FullMethodName},
+		{method: http.MethodPatch, path: "/v1/update_user", want: pb.SimpleBank_UpdateUser_FullMethodName},
+		{method: http.MethodGet, path: "/v1/verify_email", want: pb.SimpleBank_VerifyEmail_FullMethodName},
+		{method: http.MethodGet, path: "/v1/login_user", want: "/v1/login_user"},
+		{method: http.MethodGet, path: "/swagger/index.html", want: "/swagger/index.html"},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		require.Equal(t, tc.want, gatewayMethod(req), "%s %s", tc.method, tc.path)
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	server := newTestServer(t, nil, nil)
+	user, _ := createRandomUser(t)
+
+	ctx := getAuthCtx(t, server.tokenMaker, user, time.Minute)
+	require.Equal(t, "user:"+user.Username, server.rateLimitKey(ctx))
+
+	ctx = peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+	require.Equal(t, "ip:10.0.0.1", server.rateLimitKey(ctx))
+
+	accessToken, _, err := server.tokenMaker.CreateToken(user.Username, time.Minute)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPatch, "/v1/update_user", nil)
+	req.Header.Set(authorizationHeader, fmt.Sprintf("Bearer %s", accessToken))
+	require.Equal(t, "user:"+user.Username, server.httpRateLimitKey(req))
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/login_user", nil)
+	req.RemoteAddr = "10.0.0.2:5555"
+	require.Equal(t, "ip:10.0.0.2", server.httpRateLimitKey(req))
+
+	// the header of a client that is not a trusted proxy is ignored
+	req.Header.Set(xForwardedForHeader, "10.0.0.3, 10.0.0.4")
+	require.Equal(t, "ip:10.0.0.2", server.httpRateLimitKey(req))
+
+	server.trustedProxies, err = parseTrustedProxies([]string{"10.0.0.2", "10.0.0.4"})
+	require.NoError(t, err)
+	require.Equal(t, "ip:10.0.0.3", server.httpRateLimitKey(req))
+}
@@ -0,0 +1,133 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/metrics"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultBatchTransferMaxLines is used when BATCH_TRANSFER_MAX_LINES is not set.
+const defaultBatchTransferMaxLines = 1000
+
+func (s *Server) BatchTransfer(context context.Context, req *pb.BatchTransferRequest) (*pb.BatchTransferResponse, error) {
+	payload, err := authenticatedUser(context)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateBatchTransferRequest(req, s.batchTransferMaxLines()); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	fromAccount, err := s.store.GetAccount(context, req.GetFromAccountId())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+	if fromAccount.Owner != payload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "from account doesn't belong to the authenticated user")
+	}
+	if fromAccount.Currency != req.GetCurrency() {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			fieldViolation("currency", fmt.Errorf("account [%d] currency mismatch: %s vs %s", fromAccount.ID, fromAccount.Currency, req.GetCurrency())),
+		})
+	}
+
+	arg := db.BatchTransferTxParams{
+		FromAccountID: fromAccount.ID,
+		Lines:         make([]db.BatchTransferLine, 0, len(req.GetLines())),
+		Mode:          req.GetMode(),
+		CoolingOff:    db.CoolingOff{Period: s.config.PayeeCoolingOff, MaxAmount: s.config.PayeeCoolingOffAmount},
+		Audit:         s.auditContext(context, payload.Username),
+	}
+	if arg.Mode == "" {
+		arg.Mode = db.BatchTransferAllOrNothing
+	}
+	for _, line := range req.GetLines() {
+		arg.Lines = append(arg.Lines, db.BatchTransferLine{ToAccountID: line.GetToAccountId(), Amount: line.GetAmount()})
+	}
+
+	result, err := s.store.BatchTransferTx(context, arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrBatchTransferRejected):
+			return nil, batchTransferRejectedError(result)
+		case errors.Is(err, db.ErrAccountFrozen):
+			return nil, status.Errorf(codes.PermissionDenied, "from account is frozen")
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, status.Errorf(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to transfer: %s", err)
+	}
+
+	rsp := &pb.BatchTransferResponse{
+		FromAccountId: result.FromAccount.ID,
+		FromBalance:   result.FromAccount.Balance,
+		Lines:         make([]*pb.BatchTransferLineResult, 0, len(result.Lines)),
+	}
+	for i, line := range result.Lines {
+		switch line.Status {
+		case db.BatchTransferLineCompleted:
+			rsp.Completed++
+			metrics.RecordTransfer(fromAccount.Currency, line.Amount)
+		case db.BatchTransferLineFailed:
+			rsp.Failed++
+		}
+		rsp.Lines = append(rsp.Lines, batchTransferLineToPb(i, line))
+	}
+	return rsp, nil
+}
+
+func (s *Server) batchTransferMaxLines() int {
+	if s.config.BatchTransferMaxLines > 0 {
+		return s.config.BatchTransferMaxLines
+	}
+	return defaultBatchTransferMaxLines
+}
+
+// batchTransferRejectedError lists the lines that rejected an all or nothing batch as field violations.
+func batchTransferRejectedError(result db.BatchTransferTxResult) error {
+	var violations []*errdetails.BadRequest_FieldViolation
+	for i, line := range result.Lines {
+		if line.Err != nil {
+			violations = append(violations, fieldViolation(fmt.Sprintf("lines[%d]", i), line.Err))
+		}
+	}
+	return invalidArgumentError(violations)
+}
+
+func validateBatchTransferRequest(req *pb.BatchTransferRequest, maxLines int) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetFromAccountId() < 1 {
+		violations = append(violations, fieldViolation("from_account_id", fmt.Errorf("must be positive")))
+	}
+	if !util.IsSupportedCurrency(req.GetCurrency()) {
+		violations = append(violations, fieldViolation("currency", fmt.Errorf("unsupported currency %q", req.GetCurrency())))
+	}
+	switch req.GetMode() {
+	case "", db.BatchTransferAllOrNothing, db.BatchTransferBestEffort:
+	default:
+		violations = append(violations, fieldViolation("mode", fmt.Errorf("must be %s or %s", db.BatchTransferAllOrNothing, db.BatchTransferBestEffort)))
+	}
+	if len(req.GetLines()) == 0 || len(req.GetLines()) > maxLines {
+		violations = append(violations, fieldViolation("lines", fmt.Errorf("must have between 1 and %d lines", maxLines)))
+	}
+	for i, line := range req.GetLines() {
+		if line.GetToAccountId() < 1 {
+			violations = append(violations, fieldViolation(fmt.Sprintf("lines[%d].to_account_id", i), fmt.Errorf("must be positive")))
+		}
+		if line.GetAmount() < 1 {
+			violations = append(violations, fieldViolation(fmt.Sprintf("lines[%d].amount", i), fmt.Errorf("must be positive")))
+		}
+	}
+	return violations
+}
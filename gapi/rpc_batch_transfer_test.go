@@ -0,0 +1,403 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatchTransferAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := db.Account{ID: util.RandomInt(1, 1000), Owner: user.Username, Balance: 1000, Currency: util.USD}
+	otherAccount := db.Account{ID: account.ID + 1, Owner: util.RandomOwner(), Currency: util.USD}
+	lines := []*pb.BatchTransferLine{
+		{ToAccountId: account.ID + 10, Amount: 100},
+		{ToAccountId: account.ID + 11, Amount: 200},
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.BatchTransferRequest
+		maxLines      int
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.BatchTransferResponse, err error)
+	}{
+		{
+			name: "BestEffort",
+			req: &pb.BatchTransferRequest{
+				FromAccountId: account.ID,
+				Currency:      util.USD,
+				Mode:          db.BatchTransferBestEffort,
+				Lines:         lines,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.BatchTransferTxParams) (db.BatchTransferTxResult, error) {
+						require.Equal(t, account.ID, arg.FromAccountID)
+						require.Equal(t, db.BatchTransferBestEffort, arg.Mode)
+						require.Equal(t, []db.BatchTransferLine{
+							{ToAccountID: account.ID + 10, Amount: 100},
+							{ToAccountID: account.ID + 11, Amount: 200},
+						}, arg.Lines)
+						require.Equal(t, user.Username, arg.Audit.Actor)
+
+						from := account
+						from.Balance -= 100
+						return db.BatchTransferTxResult{
+							FromAccount: from,
+							Lines: []db.BatchTransferLineResult{
+								{BatchTransferLine: arg.Lines[0], Status: db.BatchTransferLineCompleted, Transfer: &db.Transfer{ID: 7}},
+								{BatchTransferLine: arg.Lines[1], Status: db.BatchTransferLineFailed, Err: db.ErrAccountNotFound},
+							},
+						}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.BatchTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.Balance-100, res.FromBalance)
+				require.Equal(t, int32(1), res.Completed)
+				require.Equal(t, int32(1), res.Failed)
+				require.Len(t, res.Lines, 2)
+				require.Equal(t, int32(1), res.Lines[0].Line)
+				require.Equal(t, int64(7), res.Lines[0].TransferId)
+				require.Equal(t, db.BatchTransferLineFailed, res.Lines[1].Status)
+				require.Equal(t, db.ErrAccountNotFound.Error(), res.Lines[1].Error)
+			},
+		},
+		{
+			name: "Rejected",
+			req: &pb.BatchTransferRequest{
+				FromAccountId: account.ID,
+				Currency:      util.USD,
+				Lines:         lines,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.BatchTransferTxParams) (db.BatchTransferTxResult, error) {
+						require.Equal(t, db.BatchTransferAllOrNothing, arg.Mode)
+						return db.BatchTransferTxResult{
+							FromAccount: account,
+							Lines: []db.BatchTransferLineResult{
+								{BatchTransferLine: arg.Lines[0], Status: db.BatchTransferLineSkipped},
+								{BatchTransferLine: arg.Lines[1], Status: db.BatchTransferLineFailed, Err: db.ErrCurrencyMismatch},
+							},
+						}, db.ErrBatchTransferRejected
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.BatchTransferResponse, err error) {
+				require.Nil(t, res)
+				st := status.Convert(err)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+				require.Len(t, st.Details(), 1)
+				violations := st.Details()[0].(*errdetails.BadRequest).GetFieldViolations()
+				require.Len(t, violations, 1)
+				require.Equal(t, "lines[1]", violations[0].Field)
+				require.Equal(t, db.ErrCurrencyMismatch.Error(), violations[0].Description)
+			},
+		},
+		{
+			name: "FrozenAccount",
+			req: &pb.BatchTransferRequest{
+				FromAccountId: account.ID,
+				Currency:      util.USD,
+				Lines:         lines,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BatchTransferTxResult{}, db.ErrAccountFrozen)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.BatchTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "AccountOfAnotherUser",
+			req: &pb.BatchTransferRequest{
+				FromAccountId: otherAccount.ID,
+				Currency:      util.USD,
+				Lines:         lines,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(otherAccount.ID)).Times(1).Return(otherAccount, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.BatchTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "AccountNotFound",
+			req: &pb.BatchTransferRequest{
+				FromAccountId: account.ID,
+				Currency:      util.USD,
+				Lines:         lines,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.BatchTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			req: &pb.BatchTransferRequest{
+				FromAccountId: account.ID,
+				Currency:      util.EUR,
+				Lines:         lines,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.BatchTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "TooManyLines",
+			req: &pb.BatchTransferRequest{
+				FromAccountId: account.ID,
+				Currency:      util.USD,
+				Lines:         lines,
+			},
+			maxLines: 1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.BatchTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidLine",
+			req: &pb.BatchTransferRequest{
+				FromAccountId: account.ID,
+				Currency:      util.USD,
+				Mode:          "sometimes",
+				Lines:         []*pb.BatchTransferLine{{ToAccountId: 0, Amount: -1}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.BatchTransferResponse, err error) {
+				require.Nil(t, res)
+				st := status.Convert(err)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+				violations := st.Details()[0].(*errdetails.BadRequest).GetFieldViolations()
+				require.Len(t, violations, 3)
+			},
+		},
+		{
+			name: "Unauthenticated",
+			req: &pb.BatchTransferRequest{
+				FromAccountId: account.ID,
+				Currency:      util.USD,
+				Lines:         lines,
+			},
+			buildStubs: func(store *mockdb.MockStore) {},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.BatchTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			server.config.BatchTransferMaxLines = tc.maxLines
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_BatchTransfer_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.BatchTransfer(ctx, req.(*pb.BatchTransferRequest))
+			})
+			res, _ := out.(*pb.BatchTransferResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestBatchTransferCsv(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := db.Account{ID: util.RandomInt(1, 1000), Owner: user.Username, Balance: 1000, Currency: util.USD}
+
+	testCases := []struct {
+		name          string
+		body          string
+		authorize     bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			body:      "name,amount,to_account_id\nalice,100,11\nbob, 200, 12\n",
+			authorize: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.BatchTransferTxParams) (db.BatchTransferTxResult, error) {
+						require.Equal(t, db.BatchTransferBestEffort, arg.Mode)
+						require.Equal(t, []db.BatchTransferLine{{ToAccountID: 11, Amount: 100}, {ToAccountID: 12, Amount: 200}}, arg.Lines)
+
+						result := db.BatchTransferTxResult{FromAccount: account}
+						for _, line := range arg.Lines {
+							result.Lines = append(result.Lines, db.BatchTransferLineResult{BatchTransferLine: line, Status: db.BatchTransferLineCompleted})
+						}
+						return result, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"completed":2`)
+			},
+		},
+		{
+			name:      "InvalidAmount",
+			body:      "to_account_id,amount\n11,ten\n",
+			authorize: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "MissingColumn",
+			body:      "to_account_id\n11\n",
+			authorize: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "TooManyLines",
+			body:      "to_account_id,amount\n11,1\n12,1\n13,1\n",
+			authorize: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "at most 2 lines")
+			},
+		},
+		{
+			name:      "Unauthenticated",
+			body:      "to_account_id,amount\n11,100\n",
+			authorize: false,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			server.config.BatchTransferMaxLines = 2
+			mux := runtime.NewServeMux()
+			require.NoError(t, mux.HandlePath(http.MethodPost, BatchTransferCsvPattern, server.BatchTransferCsvHandler(mux)))
+			handler := server.HttpAuthenticator(mux)
+
+			url := fmt.Sprintf("/v1/accounts/%d/batch_transfers/csv?currency=USD&mode=best_effort", account.ID)
+			request := httptest.NewRequest(http.MethodPost, url, strings.NewReader(tc.body))
+			request.Header.Set("Content-Type", "text/csv")
+			if tc.authorize {
+				accessToken, _, err := server.tokenMaker.CreateToken(user.Username, time.Minute)
+				require.NoError(t, err)
+				request.Header.Set("Authorization", "Bearer "+accessToken)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
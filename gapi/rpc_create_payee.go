@@ -0,0 +1,134 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/kwalter26/udemy-simplebank/val"
+	"github.com/lib/pq"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+func (s *Server) CreatePayee(context context.Context, req *pb.CreatePayeeRequest) (*pb.CreatePayeeResponse, error) {
+	payload, err := authenticatedUser(context)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateCreatePayeeRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := s.payeeAccount(context, req)
+	if err != nil {
+		return nil, err
+	}
+
+	arg := db.CreatePayeeParams{
+		Owner:         payload.Username,
+		Nickname:      req.GetNickname(),
+		AccountID:     account.ID,
+		Currency:      account.Currency,
+		AccountHidden: req.GetAccountId() == 0,
+	}
+	// moving money between one's own accounts carries no fraud risk
+	if s.config.PayeeCoolingOff > 0 && account.Owner != payload.Username {
+		arg.CoolingOffUntil = sql.NullTime{Time: time.Now().Add(s.config.PayeeCoolingOff), Valid: true}
+		arg.CoolingOffMaxAmount = s.config.PayeeCoolingOffAmount
+	}
+
+	payee, err := s.store.CreatePayee(context, arg)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return nil, status.Errorf(codes.AlreadyExists, "a payee with this nickname or account already exists")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create payee: %s", err)
+	}
+
+	rsp := &pb.CreatePayeeResponse{
+		Payee: payeeToPb(payee),
+	}
+	return rsp, nil
+}
+
+// payeeAccount finds the account a new payee pays into: the account given by id, or the account in the currency
+// of the person given by username or email.
+func (s *Server) payeeAccount(ctx context.Context, req *pb.CreatePayeeRequest) (db.Account, error) {
+	if req.GetAccountId() == 0 {
+		recipient, err := s.store.ResolveRecipient(ctx, db.ResolveRecipientParams{
+			Username: req.GetUsername(),
+			Email:    req.GetEmail(),
+			Currency: req.GetCurrency(),
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, db.ErrRecipientNotFound):
+				return db.Account{}, status.Errorf(codes.NotFound, "%s", err)
+			case errors.Is(err, db.ErrRecipientNoAccount):
+				return db.Account{}, status.Errorf(codes.FailedPrecondition, "%s", err)
+			}
+			return db.Account{}, status.Errorf(codes.Internal, "failed to find recipient: %s", err)
+		}
+		return recipient.Account, nil
+	}
+
+	account, err := s.store.GetAccount(ctx, req.GetAccountId())
+	if err == nil && account.Owner == db.BankLedgerOwner {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return account, status.Errorf(codes.NotFound, "account not found")
+		}
+		return account, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+	if account.Currency != req.GetCurrency() {
+		violation := fieldViolation("currency", fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, req.GetCurrency()))
+		return account, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{violation})
+	}
+	return account, nil
+}
+
+func validateCreatePayeeRequest(req *pb.CreatePayeeRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validatePayeeNickname(req.GetNickname()); err != nil {
+		violations = append(violations, fieldViolation("nickname", err))
+	}
+	if !util.IsSupportedCurrency(req.GetCurrency()) {
+		violations = append(violations, fieldViolation("currency", fmt.Errorf("unsupported currency %q", req.GetCurrency())))
+	}
+
+	recipients := 0
+	if req.GetAccountId() != 0 {
+		recipients++
+		if req.GetAccountId() < 0 {
+			violations = append(violations, fieldViolation("account_id", fmt.Errorf("must be positive")))
+		}
+	}
+	if req.GetUsername() != "" {
+		recipients++
+		if err := val.ValidateUsername(req.GetUsername()); err != nil {
+			violations = append(violations, fieldViolation("username", err))
+		}
+	}
+	if req.GetEmail() != "" {
+		recipients++
+		if err := val.ValidateEmail(req.GetEmail()); err != nil {
+			violations = append(violations, fieldViolation("email", err))
+		}
+	}
+	if recipients != 1 {
+		violations = append(violations, fieldViolation("account_id", fmt.Errorf("exactly one of account_id, username and email is required")))
+	}
+	return violations
+}
+
+func validatePayeeNickname(nickname string) error {
+	return val.ValidateString(nickname, 1, 50)
+}
@@ -0,0 +1,257 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestCreatePayeeAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	recipient, _ := createRandomUser(t)
+	account := db.Account{ID: util.RandomInt(1, 1000), Owner: recipient.Username, Currency: util.USD}
+	ownAccount := db.Account{ID: account.ID + 1, Owner: user.Username, Currency: util.USD}
+
+	testCases := []struct {
+		name          string
+		req           *pb.CreatePayeeRequest
+		coolingOff    time.Duration
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.CreatePayeeResponse, err error)
+	}{
+		{
+			name:       "ByAccountWithCoolingOff",
+			req:        &pb.CreatePayeeRequest{Nickname: "landlord", Currency: util.USD, AccountId: account.ID},
+			coolingOff: 24 * time.Hour,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					CreatePayee(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePayeeParams) (db.Payee, error) {
+						require.Equal(t, user.Username, arg.Owner)
+						require.Equal(t, "landlord", arg.Nickname)
+						require.Equal(t, account.ID, arg.AccountID)
+						require.Equal(t, util.USD, arg.Currency)
+						require.False(t, arg.AccountHidden)
+						require.True(t, arg.CoolingOffUntil.Valid)
+						require.WithinDuration(t, time.Now().Add(24*time.Hour), arg.CoolingOffUntil.Time, time.Minute)
+						require.Equal(t, int64(500), arg.CoolingOffMaxAmount)
+						return db.Payee{ID: 1, Owner: arg.Owner, Nickname: arg.Nickname, AccountID: arg.AccountID, Currency: arg.Currency,
+							CoolingOffUntil: arg.CoolingOffUntil, CoolingOffMaxAmount: arg.CoolingOffMaxAmount}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreatePayeeResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "landlord", res.Payee.Nickname)
+				require.Equal(t, account.ID, res.Payee.AccountId)
+				require.NotNil(t, res.Payee.CoolingOffUntil)
+				require.Equal(t, int64(500), res.Payee.CoolingOffMaxAmount)
+			},
+		},
+		{
+			name:       "OwnAccountWithoutCoolingOff",
+			req:        &pb.CreatePayeeRequest{Nickname: "savings", Currency: util.USD, AccountId: ownAccount.ID},
+			coolingOff: 24 * time.Hour,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(ownAccount.ID)).Times(1).Return(ownAccount, nil)
+				store.EXPECT().
+					CreatePayee(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePayeeParams) (db.Payee, error) {
+						require.False(t, arg.CoolingOffUntil.Valid)
+						return db.Payee{ID: 2, Owner: arg.Owner, Nickname: arg.Nickname, AccountID: arg.AccountID, Currency: arg.Currency}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreatePayeeResponse, err error) {
+				require.NoError(t, err)
+				require.Nil(t, res.Payee.CoolingOffUntil)
+			},
+		},
+		{
+			name: "ByUsername",
+			req:  &pb.CreatePayeeRequest{Nickname: "friend", Currency: util.USD, Username: recipient.Username},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResolveRecipient(gomock.Any(), gomock.Eq(db.ResolveRecipientParams{Username: recipient.Username, Currency: util.USD})).
+					Times(1).
+					Return(db.Recipient{User: recipient, Account: account}, nil)
+				store.EXPECT().
+					CreatePayee(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePayeeParams) (db.Payee, error) {
+						require.Equal(t, account.ID, arg.AccountID)
+						require.True(t, arg.AccountHidden)
+						require.False(t, arg.CoolingOffUntil.Valid)
+						return db.Payee{ID: 3, Owner: arg.Owner, Nickname: arg.Nickname, AccountID: arg.AccountID, Currency: arg.Currency,
+							AccountHidden: arg.AccountHidden}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreatePayeeResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(3), res.Payee.Id)
+				// the account of the person is not given away
+				require.Zero(t, res.Payee.AccountId)
+			},
+		},
+		{
+			name: "RecipientHasNoAccount",
+			req:  &pb.CreatePayeeRequest{Nickname: "friend", Currency: util.CAD, Email: recipient.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResolveRecipient(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Recipient{}, fmt.Errorf("%w %s", db.ErrRecipientNoAccount, util.CAD))
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreatePayeeResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name: "LedgerAccount",
+			req:  &pb.CreatePayeeRequest{Nickname: "bank", Currency: util.USD, AccountId: account.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				ledger := account
+				ledger.Owner = db.BankLedgerOwner
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(ledger, nil)
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreatePayeeResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			req:  &pb.CreatePayeeRequest{Nickname: "landlord", Currency: util.EUR, AccountId: account.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreatePayeeResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "AlreadyExists",
+			req:  &pb.CreatePayeeRequest{Nickname: "landlord", Currency: util.USD, AccountId: account.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(1).Return(db.Payee{}, &pq.Error{Code: "23505"})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreatePayeeResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.AlreadyExists, status.Code(err))
+			},
+		},
+		{
+			name: "AccountNotFound",
+			req:  &pb.CreatePayeeRequest{Nickname: "landlord", Currency: util.USD, AccountId: account.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreatePayeeResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidArguments",
+			req:  &pb.CreatePayeeRequest{Nickname: "", Currency: "XYZ", AccountId: account.ID, Username: recipient.Username},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreatePayeeResponse, err error) {
+				require.Nil(t, res)
+				st := status.Convert(err)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+				violations := st.Details()[0].(*errdetails.BadRequest).GetFieldViolations()
+				require.Len(t, violations, 3)
+			},
+		},
+		{
+			name: "Unauthenticated",
+			req:  &pb.CreatePayeeRequest{Nickname: "landlord", Currency: util.USD, AccountId: account.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.CreatePayeeResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			server.config.PayeeCoolingOff = tc.coolingOff
+			server.config.PayeeCoolingOffAmount = 500
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_CreatePayee_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.CreatePayee(ctx, req.(*pb.CreatePayeeRequest))
+			})
+			res, _ := out.(*pb.CreatePayeeResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
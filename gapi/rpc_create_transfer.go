@@ -0,0 +1,210 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/metrics"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/kwalter26/udemy-simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *Server) CreateTransfer(context context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	payload, err := authenticatedUser(context)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateCreateTransferRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	fromAccount, err := s.store.GetAccount(context, req.GetFromAccountId())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+	if fromAccount.Owner != payload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "from account doesn't belong to the authenticated user")
+	}
+	if fromAccount.Currency != req.GetCurrency() {
+		return nil, currencyMismatchError(fromAccount.ID, fromAccount.Currency, req.GetCurrency())
+	}
+
+	toAccount, recipient, err := s.transferRecipient(context, payload.Username, req)
+	if err != nil {
+		return nil, err
+	}
+	if toAccount.ID == fromAccount.ID {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			fieldViolation("to_account_id", db.ErrSameAccount),
+		})
+	}
+
+	result, err := s.store.TransferTx(context, db.TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        req.GetAmount(),
+		CoolingOff:    db.CoolingOff{Period: s.config.PayeeCoolingOff, MaxAmount: s.config.PayeeCoolingOffAmount},
+		Audit:         s.auditContext(context, payload.Username),
+	})
+	if err != nil {
+		var exceeded *db.ErrLimitExceeded
+		var coolingOff *db.ErrCoolingOff
+		switch {
+		case errors.Is(err, db.ErrAccountFrozen):
+			return nil, status.Errorf(codes.PermissionDenied, "account is frozen")
+		case errors.As(err, &exceeded):
+			return nil, status.Errorf(codes.ResourceExhausted, "%s", err)
+		case errors.As(err, &coolingOff):
+			return nil, status.Errorf(codes.PermissionDenied, "%s", err)
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, status.Errorf(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to transfer: %s", err)
+	}
+	metrics.RecordTransfer(req.GetCurrency(), req.GetAmount())
+
+	rsp := &pb.CreateTransferResponse{
+		TransferId:    result.Transfer.ID,
+		FromAccountId: result.FromAccount.ID,
+		FromBalance:   result.FromAccount.Balance,
+		Amount:        result.Transfer.Amount,
+		Fee:           result.Transfer.Fee,
+		Recipient:     recipient,
+		CreatedAt:     timestamppb.New(result.Transfer.CreatedAt),
+	}
+	// the account of a payee or a person is not shown to the payer
+	if recipient == "" {
+		rsp.ToAccountId = result.ToAccount.ID
+	}
+	return rsp, nil
+}
+
+// transferRecipientRequest is a request naming the recipient of a transfer by exactly one of an account id, a payee
+// of the caller, a username or an email.
+type transferRecipientRequest interface {
+	GetToAccountId() int64
+	GetPayeeId() int64
+	GetToUsername() string
+	GetToEmail() string
+	GetCurrency() string
+}
+
+// transferRecipient finds the account a transfer pays into, with the name the payer knows the recipient by: the
+// nickname of a payee, the masked full name of a person, or nothing for an account given by id.
+func (s *Server) transferRecipient(ctx context.Context, username string, req transferRecipientRequest) (db.Account, string, error) {
+	switch {
+	case req.GetToAccountId() != 0:
+		account, err := s.store.GetAccount(ctx, req.GetToAccountId())
+		// the ledger accounts of the bank only take money through interest and fees
+		if err == nil && account.Owner == db.BankLedgerOwner {
+			err = sql.ErrNoRows
+		}
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return account, "", status.Errorf(codes.NotFound, "to account not found")
+			}
+			return account, "", status.Errorf(codes.Internal, "failed to get account: %s", err)
+		}
+		if account.Currency != req.GetCurrency() {
+			return account, "", currencyMismatchError(account.ID, account.Currency, req.GetCurrency())
+		}
+		return account, "", nil
+	case req.GetPayeeId() != 0:
+		payee, err := s.ownedPayee(ctx, username, req.GetPayeeId())
+		if err != nil {
+			return db.Account{}, "", err
+		}
+		if payee.Currency != req.GetCurrency() {
+			violation := fieldViolation("currency", fmt.Errorf("payee [%d] currency mismatch: %s vs %s", payee.ID, payee.Currency, req.GetCurrency()))
+			return db.Account{}, "", invalidArgumentError([]*errdetails.BadRequest_FieldViolation{violation})
+		}
+		account, err := s.store.GetAccount(ctx, payee.AccountID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return account, "", status.Errorf(codes.NotFound, "to account not found")
+			}
+			return account, "", status.Errorf(codes.Internal, "failed to get account: %s", err)
+		}
+		return account, payee.Nickname, nil
+	}
+
+	recipient, err := s.store.ResolveRecipient(ctx, db.ResolveRecipientParams{
+		Username: req.GetToUsername(),
+		Email:    req.GetToEmail(),
+		Currency: req.GetCurrency(),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecipientNotFound):
+			return db.Account{}, "", status.Errorf(codes.NotFound, "%s", err)
+		case errors.Is(err, db.ErrRecipientNoAccount):
+			return db.Account{}, "", status.Errorf(codes.FailedPrecondition, "%s", err)
+		}
+		return db.Account{}, "", status.Errorf(codes.Internal, "failed to find recipient: %s", err)
+	}
+	return recipient.Account, util.MaskName(recipient.User.FullName), nil
+}
+
+func currencyMismatchError(accountID int64, accountCurrency string, currency string) error {
+	violation := fieldViolation("currency", fmt.Errorf("account [%d] currency mismatch: %s vs %s", accountID, accountCurrency, currency))
+	return invalidArgumentError([]*errdetails.BadRequest_FieldViolation{violation})
+}
+
+func validateCreateTransferRequest(req *pb.CreateTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetFromAccountId() < 1 {
+		violations = append(violations, fieldViolation("from_account_id", fmt.Errorf("must be positive")))
+	}
+	if req.GetAmount() < 1 {
+		violations = append(violations, fieldViolation("amount", fmt.Errorf("must be positive")))
+	}
+	if !util.IsSupportedCurrency(req.GetCurrency()) {
+		violations = append(violations, fieldViolation("currency", fmt.Errorf("unsupported currency %q", req.GetCurrency())))
+	}
+
+	violations = append(violations, validateTransferRecipient(req)...)
+	return violations
+}
+
+// validateTransferRecipient checks that the request names exactly one valid recipient.
+func validateTransferRecipient(req transferRecipientRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	recipients := 0
+	if req.GetToAccountId() != 0 {
+		recipients++
+		if req.GetToAccountId() < 0 {
+			violations = append(violations, fieldViolation("to_account_id", fmt.Errorf("must be positive")))
+		}
+	}
+	if req.GetPayeeId() != 0 {
+		recipients++
+		if req.GetPayeeId() < 0 {
+			violations = append(violations, fieldViolation("payee_id", fmt.Errorf("must be positive")))
+		}
+	}
+	if req.GetToUsername() != "" {
+		recipients++
+		if err := val.ValidateUsername(req.GetToUsername()); err != nil {
+			violations = append(violations, fieldViolation("to_username", err))
+		}
+	}
+	if req.GetToEmail() != "" {
+		recipients++
+		if err := val.ValidateEmail(req.GetToEmail()); err != nil {
+			violations = append(violations, fieldViolation("to_email", err))
+		}
+	}
+	if recipients != 1 {
+		violations = append(violations, fieldViolation("to_account_id", fmt.Errorf("exactly one of to_account_id, payee_id, to_username and to_email is required")))
+	}
+	return violations
+}
@@ -0,0 +1,351 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/limits"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestCreateTransferAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	recipientUser, _ := createRandomUser(t)
+	recipientUser.FullName = "Jane Doe"
+	account := db.Account{ID: util.RandomInt(1, 1000), Owner: user.Username, Balance: 1000, Currency: util.USD}
+	toAccount := db.Account{ID: account.ID + 1, Owner: recipientUser.Username, Balance: 100, Currency: util.USD}
+	payee := db.Payee{
+		ID:        util.RandomInt(1, 1000),
+		Owner:     user.Username,
+		Nickname:  "rent",
+		AccountID: toAccount.ID,
+		Currency:  util.USD,
+	}
+	otherPayee := payee
+	otherPayee.Owner = recipientUser.Username
+	transfer := db.Transfer{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: account.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        100,
+		Fee:           2,
+		CreatedAt:     time.Now(),
+	}
+	result := db.TransferTxResult{
+		Transfer:    transfer,
+		FromAccount: db.Account{ID: account.ID, Owner: user.Username, Balance: 898, Currency: util.USD},
+		ToAccount:   db.Account{ID: toAccount.ID, Owner: recipientUser.Username, Balance: 200, Currency: util.USD},
+	}
+	expectTransfer := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			TransferTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+				require.Equal(t, account.ID, arg.FromAccountID)
+				require.Equal(t, toAccount.ID, arg.ToAccountID)
+				require.Equal(t, int64(100), arg.Amount)
+				require.Equal(t, db.CoolingOff{Period: 24 * time.Hour, MaxAmount: 500}, arg.CoolingOff)
+				require.Equal(t, user.Username, arg.Audit.Actor)
+				return result, nil
+			})
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.CreateTransferRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.CreateTransferResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.CreateTransferRequest{FromAccountId: account.ID, ToAccountId: toAccount.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				expectTransfer(store)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, transfer.ID, res.TransferId)
+				require.Equal(t, int64(898), res.FromBalance)
+				require.Equal(t, int64(100), res.Amount)
+				require.Equal(t, int64(2), res.Fee)
+				require.Equal(t, toAccount.ID, res.ToAccountId)
+				require.Empty(t, res.Recipient)
+			},
+		},
+		{
+			name: "PayPayee",
+			req:  &pb.CreateTransferRequest{FromAccountId: account.ID, PayeeId: payee.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(payee, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				expectTransfer(store)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, payee.Nickname, res.Recipient)
+				require.Zero(t, res.ToAccountId)
+			},
+		},
+		{
+			name: "PayByUsername",
+			req:  &pb.CreateTransferRequest{FromAccountId: account.ID, ToUsername: recipientUser.Username, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ResolveRecipient(gomock.Any(), gomock.Eq(db.ResolveRecipientParams{Username: recipientUser.Username, Currency: util.USD})).
+					Times(1).
+					Return(db.Recipient{User: recipientUser, Account: toAccount}, nil)
+				expectTransfer(store)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "J*** D**", res.Recipient)
+				require.Zero(t, res.ToAccountId)
+			},
+		},
+		{
+			name: "PayByEmailWithoutAccount",
+			req:  &pb.CreateTransferRequest{FromAccountId: account.ID, ToEmail: recipientUser.Email, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ResolveRecipient(gomock.Any(), gomock.Eq(db.ResolveRecipientParams{Email: recipientUser.Email, Currency: util.USD})).
+					Times(1).
+					Return(db.Recipient{}, fmt.Errorf("%w %s", db.ErrRecipientNoAccount, util.USD))
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name: "PayeeOfAnotherUser",
+			req:  &pb.CreateTransferRequest{FromAccountId: account.ID, PayeeId: otherPayee.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(otherPayee.ID)).Times(1).Return(otherPayee, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "PayeeCoolingOff",
+			req:  &pb.CreateTransferRequest{FromAccountId: account.ID, PayeeId: payee.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(payee, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.ErrCoolingOff{Until: time.Now().Add(time.Hour), MaxAmount: 50, Remaining: 20})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+				require.Contains(t, status.Convert(err).Message(), "20 remaining")
+			},
+		},
+		{
+			name: "LimitExceeded",
+			req:  &pb.CreateTransferRequest{FromAccountId: account.ID, ToAccountId: toAccount.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.ErrLimitExceeded{Scope: limits.ScopeUser, Window: limits.WindowDaily, Limit: 50, Remaining: 20})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.ResourceExhausted, status.Code(err))
+			},
+		},
+		{
+			name: "FrozenAccount",
+			req:  &pb.CreateTransferRequest{FromAccountId: account.ID, ToAccountId: toAccount.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrAccountFrozen)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "ToAccountNotFound",
+			req:  &pb.CreateTransferRequest{FromAccountId: account.ID, ToAccountId: toAccount.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "ToLedgerAccount",
+			req:  &pb.CreateTransferRequest{FromAccountId: account.ID, ToAccountId: toAccount.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				ledgerAccount := toAccount
+				ledgerAccount.Owner = db.BankLedgerOwner
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(ledgerAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "SameAccount",
+			req:  &pb.CreateTransferRequest{FromAccountId: account.ID, ToAccountId: account.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "FromAccountOfAnotherUser",
+			req:  &pb.CreateTransferRequest{FromAccountId: toAccount.ID, ToAccountId: account.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "TwoRecipients",
+			req:  &pb.CreateTransferRequest{FromAccountId: account.ID, ToAccountId: toAccount.ID, PayeeId: payee.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "NoRecipient",
+			req:  &pb.CreateTransferRequest{FromAccountId: account.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "Unauthenticated",
+			req:  &pb.CreateTransferRequest{FromAccountId: account.ID, ToAccountId: toAccount.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			server.config.PayeeCoolingOff = 24 * time.Hour
+			server.config.PayeeCoolingOffAmount = 500
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_CreateTransfer_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.CreateTransfer(ctx, req.(*pb.CreateTransferRequest))
+			})
+			res, _ := out.(*pb.CreateTransferResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
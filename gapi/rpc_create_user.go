@@ -2,10 +2,8 @@ package gapi
 
 import (
 	"context"
-	"github.com/hibiken/asynq"
 	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
 	"github.com/kwalter26/udemy-simplebank/pb"
-	"github.com/kwalter26/udemy-simplebank/util"
 	"github.com/kwalter26/udemy-simplebank/val"
 	"github.com/kwalter26/udemy-simplebank/worker"
 	"github.com/lib/pq"
@@ -16,11 +14,11 @@ import (
 )
 
 func (s *Server) CreateUser(context context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
-	if violations := validateCreateUserRequest(req); violations != nil {
+	if violations := validateCreateUserRequest(req, s.passwordPolicy); violations != nil {
 		return nil, invalidArgumentError(violations)
 	}
 
-	hashedPassword, err := util.HashPassword(req.GetPassword())
+	hashedPassword, err := s.passwordHasher.Hash(req.GetPassword())
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to hash password: %s", err)
 	}
@@ -32,18 +30,22 @@ func (s *Server) CreateUser(context context.Context, req *pb.CreateUserRequest)
 			HashedPassword: hashedPassword,
 			Email:          req.GetEmail(),
 		},
-		AfterCreate: func(user db.User) error {
-			// Send task to worker
+		AfterCreate: func(user db.User) ([]db.CreateOutboxTaskParams, error) {
+			// Send task to worker once the user is committed
 			taskPayload := worker.PayloadSendVerifyEmail{
 				Username: user.Username,
 			}
-			opts := []asynq.Option{
-				asynq.MaxRetry(10),
-				asynq.ProcessIn(10 * time.Second),
-				asynq.Queue(worker.EmailQueue),
+			opts := worker.OutboxOptions{
+				Queue:     worker.EmailQueue,
+				MaxRetry:  10,
+				ProcessIn: 10 * time.Second,
 			}
 
-			return s.taskDistributor.DistributeTaskSendVerifyEmail(context, &taskPayload, opts...)
+			task, err := worker.NewSendVerifyEmailOutboxTask(context, &taskPayload, opts)
+			if err != nil {
+				return nil, err
+			}
+			return []db.CreateOutboxTaskParams{task}, nil
 		},
 	}
 
@@ -64,11 +66,11 @@ func (s *Server) CreateUser(context context.Context, req *pb.CreateUserRequest)
 	return response, nil
 }
 
-func validateCreateUserRequest(req *pb.CreateUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+func validateCreateUserRequest(req *pb.CreateUserRequest, passwordPolicy val.PasswordPolicy) (violations []*errdetails.BadRequest_FieldViolation) {
 	if err := val.ValidateUsername(req.GetUsername()); err != nil {
 		violations = append(violations, fieldViolation("username", err))
 	}
-	if err := val.ValidatePassword(req.GetPassword()); err != nil {
+	if err := passwordPolicy.Validate(req.GetPassword()); err != nil {
 		violations = append(violations, fieldViolation("password", err))
 	}
 	if err := val.ValidateFullName(req.GetFullName()); err != nil {
@@ -3,6 +3,7 @@ package gapi
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"github.com/golang/mock/gomock"
 	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
@@ -40,9 +41,19 @@ func (expected eqCreateUserTxParamsMatcher) Matches(x interface{}) bool {
 		return false
 	}
 
-	err = actualArg.AfterCreate(expected.user)
+	tasks, err := actualArg.AfterCreate(expected.user)
+	if err != nil || len(tasks) != 1 {
+		return false
+	}
 
-	return err == nil
+	var payload worker.PayloadSendVerifyEmail
+	if err = json.Unmarshal(tasks[0].Payload, &payload); err != nil {
+		return false
+	}
+	return tasks[0].TaskType == worker.TaskSendVerifyEmail &&
+		tasks[0].Queue == worker.EmailQueue &&
+		tasks[0].TaskID != "" &&
+		payload.Username == expected.user.Username
 }
 
 func (expected eqCreateUserTxParamsMatcher) String() string {
@@ -84,14 +95,10 @@ func TestCreateUserAPI(t *testing.T) {
 					Times(1).
 					Return(db.CreateUserTxResult{User: user}, nil)
 
-				taskPayload := worker.PayloadSendVerifyEmail{
-					Username: user.Username,
-				}
-
+				// the task goes through the outbox, not straight to redis
 				taskDistributor.EXPECT().
-					DistributeTaskSendVerifyEmail(gomock.Any(), &taskPayload, gomock.Any()).
-					Times(1).
-					Return(nil)
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(t *testing.T, res *pb.CreateUserResponse, err error) {
 				require.NoError(t, err)
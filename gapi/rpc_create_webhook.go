@@ -0,0 +1,72 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/val"
+	"github.com/kwalter26/udemy-simplebank/webhook"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// webhookEventTypes are the domain events a webhook can subscribe to.
+var webhookEventTypes = map[string]bool{
+	db.DomainEventAccountOpened:     true,
+	db.DomainEventTransferCompleted: true,
+	db.DomainEventBalanceChanged:    true,
+	db.DomainEventUserVerified:      true,
+}
+
+func (s *Server) CreateWebhook(context context.Context, req *pb.CreateWebhookRequest) (*pb.CreateWebhookResponse, error) {
+	payload, err := authenticatedUser(context)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateCreateWebhookRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create webhook: %s", err)
+	}
+
+	hook, err := s.store.CreateWebhook(context, db.CreateWebhookParams{
+		Owner:      payload.Username,
+		Url:        req.GetUrl(),
+		EventTypes: req.GetEventTypes(),
+		Secret:     secret,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create webhook: %s", err)
+	}
+
+	rsp := &pb.CreateWebhookResponse{
+		Webhook: webhookToPb(hook),
+		Secret:  hook.Secret,
+	}
+	return rsp, nil
+}
+
+func validateCreateWebhookRequest(req *pb.CreateWebhookRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateWebhookUrl(req.GetUrl()); err != nil {
+		violations = append(violations, fieldViolation("url", err))
+	}
+	if len(req.GetEventTypes()) == 0 {
+		violations = append(violations, fieldViolation("event_types", fmt.Errorf("must not be empty")))
+	}
+	seen := map[string]bool{}
+	for _, eventType := range req.GetEventTypes() {
+		if !webhookEventTypes[eventType] {
+			violations = append(violations, fieldViolation("event_types", fmt.Errorf("unsupported event type %q", eventType)))
+		} else if seen[eventType] {
+			violations = append(violations, fieldViolation("event_types", fmt.Errorf("duplicate event type %q", eventType)))
+		}
+		seen[eventType] = true
+	}
+	return violations
+}
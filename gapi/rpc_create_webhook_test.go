@@ -0,0 +1,201 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateWebhookAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	url := "https://example.com/hooks/simplebank"
+	eventTypes := []string{db.DomainEventBalanceChanged, db.DomainEventTransferCompleted}
+
+	testCases := []struct {
+		name          string
+		req           *pb.CreateWebhookRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.CreateWebhookResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.CreateWebhookRequest{
+				Url:        url,
+				EventTypes: eventTypes,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateWebhookParams) (db.Webhook, error) {
+						require.Equal(t, user.Username, arg.Owner)
+						require.Equal(t, url, arg.Url)
+						require.Equal(t, eventTypes, arg.EventTypes)
+						require.True(t, strings.HasPrefix(arg.Secret, "whsec_"))
+						return db.Webhook{ID: 1, Owner: arg.Owner, Url: arg.Url, EventTypes: arg.EventTypes, Secret: arg.Secret}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateWebhookResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(1), res.Webhook.Id)
+				require.Equal(t, url, res.Webhook.Url)
+				require.Equal(t, eventTypes, res.Webhook.EventTypes)
+				require.NotEmpty(t, res.Secret)
+			},
+		},
+		{
+			name: "InvalidUrl",
+			req: &pb.CreateWebhookRequest{
+				Url:        "ftp://example.com/hooks",
+				EventTypes: eventTypes,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateWebhookResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "PrivateUrl",
+			req: &pb.CreateWebhookRequest{
+				Url:        "http://169.254.169.254/latest/meta-data",
+				EventTypes: eventTypes,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateWebhookResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "LocalhostUrl",
+			req: &pb.CreateWebhookRequest{
+				Url:        "http://localhost:8080/hooks",
+				EventTypes: eventTypes,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateWebhookResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "UnsupportedEventType",
+			req: &pb.CreateWebhookRequest{
+				Url:        url,
+				EventTypes: []string{"account.closed"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateWebhookResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "NoEventTypes",
+			req: &pb.CreateWebhookRequest{
+				Url: url,
+			},
+			buildStubs: func(store *mockdb.MockStore) {},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateWebhookResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "Unauthenticated",
+			req: &pb.CreateWebhookRequest{
+				Url:        url,
+				EventTypes: eventTypes,
+			},
+			buildStubs: func(store *mockdb.MockStore) {},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateWebhookResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req: &pb.CreateWebhookRequest{
+				Url:        url,
+				EventTypes: eventTypes,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Webhook{}, sql.ErrConnDone)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateWebhookResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_CreateWebhook_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.CreateWebhook(ctx, req.(*pb.CreateWebhookRequest))
+			})
+			res, _ := out.(*pb.CreateWebhookResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hibiken/asynq"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+func (s *Server) DeleteFailedTask(context context.Context, req *pb.DeleteFailedTaskRequest) (*pb.DeleteFailedTaskResponse, error) {
+	if _, err := s.authorizeBanker(context); err != nil {
+		return nil, err
+	}
+
+	if violations := validateDeleteFailedTaskRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	err := s.taskInspector.DeleteTask(req.GetQueue(), req.GetTaskId())
+	if errors.Is(err, asynq.ErrQueueNotFound) || errors.Is(err, asynq.ErrTaskNotFound) {
+		// tasks the outbox relay gave up on never reached the queue, they are deleted from the outbox instead
+		var deleted int64
+		deleted, err = s.store.DeleteDeadOutboxTask(context, db.DeleteDeadOutboxTaskParams{Queue: req.GetQueue(), TaskID: req.GetTaskId()})
+		if err == nil && deleted == 0 {
+			err = asynq.ErrTaskNotFound
+		}
+	}
+	if err != nil {
+		return nil, failedTaskError("delete", err)
+	}
+	s.resolveDeadLetterTask(context, req.GetQueue(), req.GetTaskId(), db.DeadLetterTaskDeleted)
+
+	rsp := &pb.DeleteFailedTaskResponse{
+		TaskId: req.GetTaskId(),
+	}
+	return rsp, nil
+}
+
+func validateDeleteFailedTaskRequest(req *pb.DeleteFailedTaskRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validateTaskQueue(req.GetQueue()); err != nil {
+		violations = append(violations, fieldViolation("queue", err))
+	}
+	if req.GetTaskId() == "" {
+		violations = append(violations, fieldViolation("task_id", fmt.Errorf("must not be empty")))
+	}
+	return violations
+}
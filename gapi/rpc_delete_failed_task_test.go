@@ -0,0 +1,198 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/kwalter26/udemy-simplebank/worker"
+	mockwk "github.com/kwalter26/udemy-simplebank/worker/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestDeleteFailedTaskAPI(t *testing.T) {
+	banker, _ := createRandomUser(t)
+	banker.Role = util.BankerRole
+
+	taskID := util.RandomString(16)
+
+	testCases := []struct {
+		name          string
+		req           *pb.DeleteFailedTaskRequest
+		buildStubs    func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.DeleteFailedTaskResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.DeleteFailedTaskRequest{Queue: worker.WebhookQueue, TaskId: taskID},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				inspector.EXPECT().
+					DeleteTask(gomock.Eq(worker.WebhookQueue), gomock.Eq(taskID)).
+					Times(1).
+					Return(nil)
+				arg := db.ResolveDeadLetterTaskParams{
+					Queue:      worker.WebhookQueue,
+					TaskID:     taskID,
+					Resolution: db.DeadLetterTaskDeleted,
+				}
+				store.EXPECT().
+					ResolveDeadLetterTask(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.DeleteFailedTaskResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, taskID, res.TaskId)
+			},
+		},
+		{
+			name: "DeadOutboxTask",
+			req:  &pb.DeleteFailedTaskRequest{Queue: worker.WebhookQueue, TaskId: taskID},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				inspector.EXPECT().
+					DeleteTask(gomock.Eq(worker.WebhookQueue), gomock.Eq(taskID)).
+					Times(1).
+					Return(asynq.ErrTaskNotFound)
+				store.EXPECT().
+					DeleteDeadOutboxTask(gomock.Any(), gomock.Eq(db.DeleteDeadOutboxTaskParams{Queue: worker.WebhookQueue, TaskID: taskID})).
+					Times(1).
+					Return(int64(1), nil)
+				store.EXPECT().
+					ResolveDeadLetterTask(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.DeleteFailedTaskResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, taskID, res.TaskId)
+			},
+		},
+		{
+			name: "QueueNotFound",
+			req:  &pb.DeleteFailedTaskRequest{Queue: worker.WebhookQueue, TaskId: taskID},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				inspector.EXPECT().
+					DeleteTask(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(asynq.ErrQueueNotFound)
+				store.EXPECT().
+					DeleteDeadOutboxTask(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.DeleteFailedTaskResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidQueue",
+			req:  &pb.DeleteFailedTaskRequest{Queue: "unknown", TaskId: taskID},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				inspector.EXPECT().
+					DeleteTask(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.DeleteFailedTaskResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name:       "Unauthenticated",
+			req:        &pb.DeleteFailedTaskRequest{Queue: worker.WebhookQueue, TaskId: taskID},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.DeleteFailedTaskResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.DeleteFailedTaskRequest{Queue: worker.WebhookQueue, TaskId: taskID},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				inspector.EXPECT().
+					DeleteTask(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(errors.New("redis down"))
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.DeleteFailedTaskResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			inspector := mockwk.NewMockTaskInspector(ctrl)
+
+			tc.buildStubs(store, inspector)
+
+			server := newTestServer(t, store, nil)
+			server.taskInspector = inspector
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_DeleteFailedTask_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.DeleteFailedTask(ctx, req.(*pb.DeleteFailedTaskRequest))
+			})
+			res, _ := out.(*pb.DeleteFailedTaskResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
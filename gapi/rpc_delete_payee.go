@@ -0,0 +1,41 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) DeletePayee(context context.Context, req *pb.DeletePayeeRequest) (*pb.DeletePayeeResponse, error) {
+	payload, err := authenticatedUser(context)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateDeletePayeeRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	if _, err := s.ownedPayee(context, payload.Username, req.GetId()); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.DeletePayee(context, req.GetId()); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete payee: %s", err)
+	}
+
+	rsp := &pb.DeletePayeeResponse{
+		Id: req.GetId(),
+	}
+	return rsp, nil
+}
+
+func validateDeletePayeeRequest(req *pb.DeletePayeeRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetId() < 1 {
+		violations = append(violations, fieldViolation("id", fmt.Errorf("must be positive")))
+	}
+	return violations
+}
@@ -0,0 +1,112 @@
+package gapi
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestDeletePayeeAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	payee := db.Payee{ID: util.RandomInt(1, 1000), Owner: user.Username, Nickname: "landlord", AccountID: 10, Currency: util.USD}
+
+	testCases := []struct {
+		name          string
+		req           *pb.DeletePayeeRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.DeletePayeeResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.DeletePayeeRequest{Id: payee.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(payee, nil)
+				store.EXPECT().DeletePayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.DeletePayeeResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, payee.ID, res.Id)
+			},
+		},
+		{
+			name: "NotOwner",
+			req:  &pb.DeletePayeeRequest{Id: payee.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				other := payee
+				other.Owner = util.RandomOwner()
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(other, nil)
+				store.EXPECT().DeletePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.DeletePayeeResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidID",
+			req:  &pb.DeletePayeeRequest{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.DeletePayeeResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "Unauthenticated",
+			req:  &pb.DeletePayeeRequest{Id: payee.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.DeletePayeeResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_DeletePayee_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.DeletePayee(ctx, req.(*pb.DeletePayeeRequest))
+			})
+			res, _ := out.(*pb.DeletePayeeResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
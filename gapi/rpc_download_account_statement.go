@@ -0,0 +1,72 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/kwalter26/udemy-simplebank/blob"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/statement"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+)
+
+// DownloadAccountStatement returns the PDF of a monthly statement. Through the gateway the body is the file itself.
+func (s *Server) DownloadAccountStatement(context context.Context, req *pb.DownloadAccountStatementRequest) (*httpbody.HttpBody, error) {
+	payload, err := authenticatedUser(context)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateDownloadAccountStatementRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := s.statementAccount(context, payload.Username, req.GetAccountId())
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := s.store.GetAccountStatement(context, req.GetStatementId())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "statement not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get statement: %s", err)
+	}
+	if record.AccountID != account.ID {
+		return nil, status.Errorf(codes.NotFound, "statement not found")
+	}
+
+	file, err := s.blobs.Get(context, record.BlobKey)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "statement file not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to open statement: %s", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to read statement: %s", err)
+	}
+
+	return &httpbody.HttpBody{
+		ContentType: statement.ContentType(statement.FormatPDF),
+		Data:        data,
+	}, nil
+}
+
+func validateDownloadAccountStatementRequest(req *pb.DownloadAccountStatementRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetAccountId() < 1 {
+		violations = append(violations, fieldViolation("account_id", fmt.Errorf("must be positive")))
+	}
+	if req.GetStatementId() < 1 {
+		violations = append(violations, fieldViolation("statement_id", fmt.Errorf("must be positive")))
+	}
+	return violations
+}
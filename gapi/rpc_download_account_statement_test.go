@@ -0,0 +1,172 @@
+package gapi
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/kwalter26/udemy-simplebank/blob"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestDownloadAccountStatementAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := db.Account{ID: util.RandomInt(1, 1000), Owner: user.Username, Balance: 150, Currency: util.USD}
+	otherAccount := db.Account{ID: account.ID + 1, Owner: util.RandomOwner(), Currency: util.USD}
+	record := db.AccountStatement{ID: 9, AccountID: account.ID, BlobKey: "statements/1/2023-01.pdf"}
+	missing := db.AccountStatement{ID: 10, AccountID: account.ID, BlobKey: "statements/1/2023-02.pdf"}
+	pdf := []byte("%PDF-1.3 statement")
+
+	testCases := []struct {
+		name          string
+		req           *pb.DownloadAccountStatementRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *httpbody.HttpBody, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.DownloadAccountStatementRequest{AccountId: account.ID, StatementId: record.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountStatement(gomock.Any(), gomock.Eq(record.ID)).Times(1).Return(record, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *httpbody.HttpBody, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "application/pdf", res.ContentType)
+				require.Equal(t, pdf, res.Data)
+			},
+		},
+		{
+			name: "StatementOfAnotherAccount",
+			req:  &pb.DownloadAccountStatementRequest{AccountId: account.ID, StatementId: record.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					GetAccountStatement(gomock.Any(), gomock.Eq(record.ID)).
+					Times(1).
+					Return(db.AccountStatement{ID: record.ID, AccountID: otherAccount.ID, BlobKey: record.BlobKey}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *httpbody.HttpBody, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "AccountOfAnotherUser",
+			req:  &pb.DownloadAccountStatementRequest{AccountId: otherAccount.ID, StatementId: record.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(otherAccount.ID)).Times(1).Return(otherAccount, nil)
+				store.EXPECT().GetAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *httpbody.HttpBody, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "StatementNotFound",
+			req:  &pb.DownloadAccountStatementRequest{AccountId: account.ID, StatementId: record.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountStatement(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountStatement{}, sql.ErrNoRows)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *httpbody.HttpBody, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "FileNotFound",
+			req:  &pb.DownloadAccountStatementRequest{AccountId: account.ID, StatementId: missing.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountStatement(gomock.Any(), gomock.Eq(missing.ID)).Times(1).Return(missing, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *httpbody.HttpBody, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidStatementId",
+			req:  &pb.DownloadAccountStatementRequest{AccountId: account.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *httpbody.HttpBody, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name:       "Unauthenticated",
+			req:        &pb.DownloadAccountStatementRequest{AccountId: account.ID, StatementId: record.ID},
+			buildStubs: func(store *mockdb.MockStore) {},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *httpbody.HttpBody, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			blobs, err := blob.NewLocalStore(t.TempDir())
+			require.NoError(t, err)
+			_, err = blobs.Put(context.Background(), record.BlobKey, bytes.NewReader(pdf))
+			require.NoError(t, err)
+
+			server := newTestServer(t, store, nil)
+			server.blobs = blobs
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_DownloadAccountStatement_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.DownloadAccountStatement(ctx, req.(*pb.DownloadAccountStatementRequest))
+			})
+			res, _ := out.(*httpbody.HttpBody)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
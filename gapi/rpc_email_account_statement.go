@@ -0,0 +1,82 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/statement"
+	"github.com/kwalter26/udemy-simplebank/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"time"
+)
+
+func (s *Server) EmailAccountStatement(context context.Context, req *pb.EmailAccountStatementRequest) (*pb.EmailAccountStatementResponse, error) {
+	payload, err := authenticatedUser(context)
+	if err != nil {
+		return nil, err
+	}
+
+	period, format, violations := validateAccountStatementRequest(req.GetAccountId(), req.GetFrom(), req.GetTo(), req.GetFormat())
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := s.statementAccount(context, payload.Username, req.GetAccountId())
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := worker.NewSendAccountStatementOutboxTask(context, &worker.PayloadSendAccountStatement{
+		AccountID: account.ID,
+		Format:    format,
+		From:      period.From,
+		To:        period.To,
+	}, worker.OutboxOptions{})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create statement task: %s", err)
+	}
+	if _, err := s.store.CreateOutboxTask(context, task); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to enqueue statement task: %s", err)
+	}
+
+	return &pb.EmailAccountStatementResponse{TaskId: task.TaskID}, nil
+}
+
+// statementAccount returns the account a statement is asked for, which must belong to the caller.
+func (s *Server) statementAccount(ctx context.Context, username string, accountID int64) (db.Account, error) {
+	account, err := s.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return account, status.Errorf(codes.NotFound, "account not found")
+		}
+		return account, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+	if account.Owner != username {
+		return account, status.Errorf(codes.PermissionDenied, "account doesn't belong to the authenticated user")
+	}
+	return account, nil
+}
+
+// validateAccountStatementRequest parses the period of a statement. The format defaults to CSV.
+func validateAccountStatementRequest(accountID int64, from string, to string, format string) (period statement.Period, _ string, violations []*errdetails.BadRequest_FieldViolation) {
+	if accountID < 1 {
+		violations = append(violations, fieldViolation("account_id", fmt.Errorf("must be positive")))
+	}
+	period, err := statement.ParsePeriod(from, to, time.Now())
+	if err != nil {
+		violations = append(violations, fieldViolation("period", err))
+	}
+	if format == "" {
+		format = statement.FormatCSV
+	}
+	if !statement.IsSupportedFormat(format) {
+		violations = append(violations, fieldViolation("format", fmt.Errorf("must be one of %s", strings.Join(statement.Formats, ", "))))
+	}
+	return period, format, violations
+}
@@ -0,0 +1,246 @@
+package gapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/statement"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/kwalter26/udemy-simplebank/worker"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEmailAccountStatementAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := db.Account{ID: util.RandomInt(1, 1000), Owner: user.Username, Balance: 1000, Currency: util.USD}
+
+	testCases := []struct {
+		name          string
+		req           *pb.EmailAccountStatementRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.EmailAccountStatementResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.EmailAccountStatementRequest{
+				AccountId: account.ID,
+				From:      "2023-01-01",
+				To:        "2023-01-31",
+				Format:    statement.FormatOFX,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					CreateOutboxTask(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateOutboxTaskParams) (db.Outbox, error) {
+						require.Equal(t, worker.TaskSendAccountStatement, arg.TaskType)
+						require.Equal(t, worker.EmailQueue, arg.Queue)
+
+						var payload worker.PayloadSendAccountStatement
+						require.NoError(t, json.Unmarshal(arg.Payload, &payload))
+						require.Equal(t, account.ID, payload.AccountID)
+						require.Equal(t, statement.FormatOFX, payload.Format)
+						require.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), payload.From)
+						require.Equal(t, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), payload.To)
+						return db.Outbox{TaskID: arg.TaskID}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.EmailAccountStatementResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, res.GetTaskId())
+			},
+		},
+		{
+			name: "NotOwner",
+			req:  &pb.EmailAccountStatementRequest{AccountId: account.ID, From: "2023-01-01"},
+			buildStubs: func(store *mockdb.MockStore) {
+				other := account
+				other.Owner = util.RandomOwner()
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(other, nil)
+				store.EXPECT().CreateOutboxTask(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.EmailAccountStatementResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidPeriod",
+			req:  &pb.EmailAccountStatementRequest{AccountId: account.ID, From: "2023-02-01", To: "2023-01-01", Format: "xlsx"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.EmailAccountStatementResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name:       "Unauthenticated",
+			req:        &pb.EmailAccountStatementRequest{AccountId: account.ID, From: "2023-01-01"},
+			buildStubs: func(store *mockdb.MockStore) {},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.EmailAccountStatementResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_EmailAccountStatement_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.EmailAccountStatement(ctx, req.(*pb.EmailAccountStatementRequest))
+			})
+			res, _ := out.(*pb.EmailAccountStatementResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestAccountStatementDownload(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := db.Account{ID: util.RandomInt(1, 1000), Owner: user.Username, Balance: 1000, Currency: util.USD}
+
+	testCases := []struct {
+		name          string
+		query         string
+		authorize     bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			query:     "from=2023-01-01&to=2023-01-31&format=json",
+			authorize: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					GetAccountStatementBalances(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.GetAccountStatementBalancesRow{Balance: 1000, InPeriod: 100, LastEntryID: 3}, nil)
+				store.EXPECT().
+					ListStatementEntries(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.ListStatementEntriesRow{{ID: 3, Amount: 100}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+				require.Equal(t, fmt.Sprintf(`attachment; filename=statement-%d-20230101-20230201.json`, account.ID), recorder.Header().Get("Content-Disposition"))
+
+				var body struct {
+					OpeningBalance int64            `json:"opening_balance"`
+					ClosingBalance int64            `json:"closing_balance"`
+					Lines          []statement.Line `json:"lines"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+				require.Equal(t, int64(900), body.OpeningBalance)
+				require.Equal(t, int64(1000), body.ClosingBalance)
+				require.Len(t, body.Lines, 1)
+			},
+		},
+		{
+			name:      "NotOwner",
+			query:     "from=2023-01-01",
+			authorize: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				other := account
+				other.Owner = util.RandomOwner()
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).Return(other, nil)
+				store.EXPECT().GetAccountStatementBalances(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "MissingFrom",
+			query:     "format=csv",
+			authorize: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "Unauthenticated",
+			query:     "from=2023-01-01",
+			authorize: false,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			mux := runtime.NewServeMux()
+			require.NoError(t, mux.HandlePath(http.MethodGet, AccountStatementPattern, server.AccountStatementHandler(mux)))
+			handler := server.HttpAuthenticator(mux)
+
+			url := fmt.Sprintf("/v1/accounts/%d/statement?%s", account.ID, tc.query)
+			request := httptest.NewRequest(http.MethodGet, url, nil)
+			if tc.authorize {
+				accessToken, _, err := server.tokenMaker.CreateToken(user.Username, time.Minute)
+				require.NoError(t, err)
+				request.Header.Set("Authorization", "Bearer "+accessToken)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/limits"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) GetTransferLimits(context context.Context, req *pb.GetTransferLimitsRequest) (*pb.GetTransferLimitsResponse, error) {
+	payload, err := authenticatedUser(context)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateGetTransferLimitsRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := s.store.GetAccount(context, req.GetAccountId())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+	if account.Owner != payload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "account doesn't belong to the authenticated user")
+	}
+
+	allowance, err := s.store.GetTransferAllowance(context, db.GetTransferAllowanceParams{
+		Account: account,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get transfer limits: %s", err)
+	}
+
+	rsp := &pb.GetTransferLimitsResponse{
+		AccountId: account.ID,
+		Currency:  account.Currency,
+		User:      transferLimitUsageToPb(allowance.UserScope, allowance.User, allowance.UserUsage),
+	}
+	if allowance.Account != (limits.Limits{}) {
+		rsp.Account = transferLimitUsageToPb(limits.ScopeAccount, allowance.Account, allowance.AccountUsage)
+	}
+	return rsp, nil
+}
+
+func validateGetTransferLimitsRequest(req *pb.GetTransferLimitsRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetAccountId() < 1 {
+		violations = append(violations, fieldViolation("account_id", fmt.Errorf("must be positive")))
+	}
+	return violations
+}
@@ -0,0 +1,167 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/limits"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestGetTransferLimitsAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := db.Account{ID: util.RandomInt(1, 1000), Owner: user.Username, Balance: 1000, Currency: util.USD}
+	allowance := db.TransferAllowance{
+		UserScope:    limits.ScopeDefault,
+		User:         limits.Limits{PerTransfer: 100, Daily: 500},
+		UserUsage:    limits.Usage{Daily: 120, Monthly: 700},
+		AccountUsage: limits.Usage{Daily: 20, Monthly: 70},
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.GetTransferLimitsRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.GetTransferLimitsResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.GetTransferLimitsRequest{AccountId: account.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					GetTransferAllowance(gomock.Any(), gomock.Eq(db.GetTransferAllowanceParams{Account: account})).
+					Times(1).
+					Return(allowance, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetTransferLimitsResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.ID, res.AccountId)
+				require.Equal(t, util.USD, res.Currency)
+				require.Equal(t, limits.ScopeDefault, res.User.Scope)
+				require.Equal(t, int64(100), res.User.Limits.PerTransferMax)
+				require.Equal(t, int64(120), res.User.DailyUsed)
+				require.Equal(t, int64(380), res.User.GetDailyRemaining())
+				require.Nil(t, res.User.MonthlyRemaining)
+				require.Nil(t, res.Account)
+			},
+		},
+		{
+			name: "AccountLimits",
+			req:  &pb.GetTransferLimitsRequest{AccountId: account.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				withAccount := allowance
+				withAccount.Account = limits.Limits{Monthly: 50}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetTransferAllowance(gomock.Any(), gomock.Any()).Times(1).Return(withAccount, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetTransferLimitsResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res.Account)
+				require.Equal(t, limits.ScopeAccount, res.Account.Scope)
+				require.Equal(t, int64(70), res.Account.MonthlyUsed)
+				require.NotNil(t, res.Account.MonthlyRemaining)
+				require.Zero(t, res.Account.GetMonthlyRemaining())
+				require.Nil(t, res.Account.DailyRemaining)
+			},
+		},
+		{
+			name: "NotOwner",
+			req:  &pb.GetTransferLimitsRequest{AccountId: account.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				other := account
+				other.Owner = util.RandomOwner()
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(other, nil)
+				store.EXPECT().GetTransferAllowance(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetTransferLimitsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "AccountNotFound",
+			req:  &pb.GetTransferLimitsRequest{AccountId: account.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetTransferAllowance(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetTransferLimitsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidAccountID",
+			req:  &pb.GetTransferLimitsRequest{AccountId: 0},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetTransferLimitsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "Unauthenticated",
+			req:  &pb.GetTransferLimitsRequest{AccountId: account.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.GetTransferLimitsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_GetTransferLimits_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.GetTransferLimits(ctx, req.(*pb.GetTransferLimitsRequest))
+			})
+			res, _ := out.(*pb.GetTransferLimitsResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultAccountStatementsPageSize = 12
+	maxAccountStatementsPageSize     = 100
+)
+
+func (s *Server) ListAccountStatements(context context.Context, req *pb.ListAccountStatementsRequest) (*pb.ListAccountStatementsResponse, error) {
+	payload, err := authenticatedUser(context)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateListAccountStatementsRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := s.statementAccount(context, payload.Username, req.GetAccountId())
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := req.GetPageSize()
+	if pageSize == 0 {
+		pageSize = defaultAccountStatementsPageSize
+	}
+	pageId := req.GetPageId()
+	if pageId == 0 {
+		pageId = 1
+	}
+
+	records, err := s.store.ListAccountStatements(context, db.ListAccountStatementsParams{
+		AccountID: account.ID,
+		Limit:     pageSize,
+		Offset:    (pageId - 1) * pageSize,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list account statements: %s", err)
+	}
+
+	rsp := &pb.ListAccountStatementsResponse{
+		Statements: make([]*pb.AccountStatement, 0, len(records)),
+	}
+	for _, record := range records {
+		rsp.Statements = append(rsp.Statements, accountStatementToPb(record))
+	}
+	return rsp, nil
+}
+
+func validateListAccountStatementsRequest(req *pb.ListAccountStatementsRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetAccountId() < 1 {
+		violations = append(violations, fieldViolation("account_id", fmt.Errorf("must be positive")))
+	}
+	if req.GetPageId() < 0 {
+		violations = append(violations, fieldViolation("page_id", fmt.Errorf("must be positive")))
+	}
+	if req.GetPageSize() < 0 || req.GetPageSize() > maxAccountStatementsPageSize {
+		violations = append(violations, fieldViolation("page_size", fmt.Errorf("must be between 1 and %d", maxAccountStatementsPageSize)))
+	}
+	return violations
+}
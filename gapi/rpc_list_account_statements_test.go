@@ -0,0 +1,199 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestListAccountStatementsAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := db.Account{ID: util.RandomInt(1, 1000), Owner: user.Username, Balance: 150, Currency: util.USD}
+	otherAccount := db.Account{ID: account.ID + 1, Owner: util.RandomOwner(), Currency: util.USD}
+	record := db.AccountStatement{
+		ID:             9,
+		AccountID:      account.ID,
+		PeriodStart:    time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:      time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+		BlobKey:        "statements/1/2023-01.pdf",
+		SizeBytes:      2048,
+		OpeningBalance: 100,
+		ClosingBalance: 150,
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListAccountStatementsRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.ListAccountStatementsResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.ListAccountStatementsRequest{
+				AccountId: account.ID,
+				PageId:    2,
+				PageSize:  5,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					ListAccountStatements(gomock.Any(), gomock.Eq(db.ListAccountStatementsParams{
+						AccountID: account.ID,
+						Limit:     5,
+						Offset:    5,
+					})).
+					Times(1).
+					Return([]db.AccountStatement{record}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountStatementsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Statements, 1)
+				require.Equal(t, record.ID, res.Statements[0].Id)
+				require.Equal(t, record.PeriodStart, res.Statements[0].PeriodStart.AsTime())
+				require.Equal(t, record.ClosingBalance, res.Statements[0].ClosingBalance)
+				require.Equal(t, record.SizeBytes, res.Statements[0].SizeBytes)
+			},
+		},
+		{
+			name: "DefaultPage",
+			req: &pb.ListAccountStatementsRequest{
+				AccountId: account.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					ListAccountStatements(gomock.Any(), gomock.Eq(db.ListAccountStatementsParams{
+						AccountID: account.ID,
+						Limit:     defaultAccountStatementsPageSize,
+						Offset:    0,
+					})).
+					Times(1).
+					Return([]db.AccountStatement{}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountStatementsResponse, err error) {
+				require.NoError(t, err)
+				require.Empty(t, res.Statements)
+			},
+		},
+		{
+			name: "AccountOfAnotherUser",
+			req: &pb.ListAccountStatementsRequest{
+				AccountId: otherAccount.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(otherAccount.ID)).
+					Times(1).
+					Return(otherAccount, nil)
+				store.EXPECT().ListAccountStatements(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountStatementsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidPageSize",
+			req: &pb.ListAccountStatementsRequest{
+				AccountId: account.ID,
+				PageSize:  maxAccountStatementsPageSize + 1,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountStatementsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "Unauthenticated",
+			req: &pb.ListAccountStatementsRequest{
+				AccountId: account.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountStatementsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req: &pb.ListAccountStatementsRequest{
+				AccountId: account.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					ListAccountStatements(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAccountStatementsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_ListAccountStatements_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.ListAccountStatements(ctx, req.(*pb.ListAccountStatementsRequest))
+			})
+			res, _ := out.(*pb.ListAccountStatementsResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
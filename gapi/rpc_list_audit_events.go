@@ -0,0 +1,91 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+const (
+	defaultAuditEventsPageSize = 50
+	maxAuditEventsPageSize     = 500
+)
+
+// auditEventsEndOfTime is used as end time when the request has none.
+var auditEventsEndOfTime = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func (s *Server) ListAuditEvents(context context.Context, req *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error) {
+	if _, err := s.authorizeBanker(context); err != nil {
+		return nil, err
+	}
+
+	if violations := validateListAuditEventsRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	pageSize := req.GetPageSize()
+	if pageSize == 0 {
+		pageSize = defaultAuditEventsPageSize
+	}
+	pageId := req.GetPageId()
+	if pageId == 0 {
+		pageId = 1
+	}
+
+	arg := db.ListAuditEventsParams{
+		Actor: sql.NullString{
+			String: req.GetActor(),
+			Valid:  req.Actor != nil,
+		},
+		Target: sql.NullString{
+			String: req.GetTarget(),
+			Valid:  req.Target != nil,
+		},
+		StartTime:   time.Time{},
+		EndTime:     auditEventsEndOfTime,
+		LimitCount:  pageSize,
+		OffsetCount: (pageId - 1) * pageSize,
+	}
+	if req.StartTime != nil {
+		arg.StartTime = req.GetStartTime().AsTime()
+	}
+	if req.EndTime != nil {
+		arg.EndTime = req.GetEndTime().AsTime()
+	}
+
+	events, err := s.store.ListAuditEvents(context, arg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list audit events: %s", err)
+	}
+
+	rsp := &pb.ListAuditEventsResponse{
+		Events: make([]*pb.AuditEvent, 0, len(events)),
+	}
+	for _, event := range events {
+		pbEvent, err := auditEventToPb(event)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to convert audit event: %s", err)
+		}
+		rsp.Events = append(rsp.Events, pbEvent)
+	}
+	return rsp, nil
+}
+
+func validateListAuditEventsRequest(req *pb.ListAuditEventsRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetPageId() < 0 {
+		violations = append(violations, fieldViolation("page_id", fmt.Errorf("must be positive")))
+	}
+	if req.GetPageSize() < 0 || req.GetPageSize() > maxAuditEventsPageSize {
+		violations = append(violations, fieldViolation("page_size", fmt.Errorf("must be between 1 and %d", maxAuditEventsPageSize)))
+	}
+	if req.StartTime != nil && req.EndTime != nil && !req.GetStartTime().AsTime().Before(req.GetEndTime().AsTime()) {
+		violations = append(violations, fieldViolation("end_time", fmt.Errorf("must be after start_time")))
+	}
+	return violations
+}
@@ -0,0 +1,231 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"testing"
+	"time"
+)
+
+func TestListAuditEventsAPI(t *testing.T) {
+	banker, _ := createRandomUser(t)
+	banker.Role = util.BankerRole
+	depositor, _ := createRandomUser(t)
+	depositor.Role = util.DepositorRole
+
+	event := db.AuditEvent{
+		ID:        util.RandomInt(1, 1000),
+		Actor:     depositor.Username,
+		Action:    db.AuditActionUserUpdate,
+		Target:    db.AuditTarget("user", depositor.Username),
+		ClientIp:  "10.0.0.1",
+		UserAgent: "test",
+		Before:    json.RawMessage(`{"full_name":"old"}`),
+		After:     json.RawMessage(`{"full_name":"new"}`),
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	startTime := time.Now().Add(-time.Hour).UTC()
+	endTime := time.Now().UTC()
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListAuditEventsRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.ListAuditEventsResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.ListAuditEventsRequest{
+				Actor:     &depositor.Username,
+				StartTime: timestamppb.New(startTime),
+				EndTime:   timestamppb.New(endTime),
+				PageId:    2,
+				PageSize:  10,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				arg := db.ListAuditEventsParams{
+					Actor:       sql.NullString{String: depositor.Username, Valid: true},
+					StartTime:   startTime,
+					EndTime:     endTime,
+					LimitCount:  10,
+					OffsetCount: 10,
+				}
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.AuditEvent{event}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAuditEventsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Events, 1)
+				require.Equal(t, event.ID, res.Events[0].Id)
+				require.Equal(t, event.Action, res.Events[0].Action)
+				require.Equal(t, event.Target, res.Events[0].Target)
+				require.Equal(t, "old", res.Events[0].Before.AsMap()["full_name"])
+				require.Equal(t, "new", res.Events[0].After.AsMap()["full_name"])
+			},
+		},
+		{
+			name: "DefaultPage",
+			req:  &pb.ListAuditEventsRequest{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				arg := db.ListAuditEventsParams{
+					EndTime:    auditEventsEndOfTime,
+					LimitCount: defaultAuditEventsPageSize,
+				}
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.AuditEvent{}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAuditEventsResponse, err error) {
+				require.NoError(t, err)
+				require.Empty(t, res.Events)
+			},
+		},
+		{
+			name: "NotBanker",
+			req:  &pb.ListAuditEventsRequest{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(depositor.Username)).
+					Times(1).
+					Return(depositor, nil)
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, depositor, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAuditEventsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name:       "Unauthenticated",
+			req:        &pb.ListAuditEventsRequest{},
+			buildStubs: func(store *mockdb.MockStore) {},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAuditEventsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidTimeRange",
+			req: &pb.ListAuditEventsRequest{
+				StartTime: timestamppb.New(endTime),
+				EndTime:   timestamppb.New(startTime),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAuditEventsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidPageSize",
+			req: &pb.ListAuditEventsRequest{
+				PageSize: maxAuditEventsPageSize + 1,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAuditEventsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.ListAuditEventsRequest{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListAuditEventsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_ListAuditEvents_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.ListAuditEvents(ctx, req.(*pb.ListAuditEventsRequest))
+			})
+			res, _ := out.(*pb.ListAuditEventsResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultFailedTasksPageSize = 20
+	maxFailedTasksPageSize     = 100
+)
+
+// failedTaskStates are the task states that can be listed. Archived tasks ran out of retries.
+var failedTaskStates = map[string]asynq.TaskState{
+	"":         asynq.TaskStateArchived,
+	"archived": asynq.TaskStateArchived,
+	"retry":    asynq.TaskStateRetry,
+}
+
+func (s *Server) ListFailedTasks(context context.Context, req *pb.ListFailedTasksRequest) (*pb.ListFailedTasksResponse, error) {
+	if _, err := s.authorizeBanker(context); err != nil {
+		return nil, err
+	}
+
+	if violations := validateListFailedTasksRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	pageSize := req.GetPageSize()
+	if pageSize == 0 {
+		pageSize = defaultFailedTasksPageSize
+	}
+	pageId := req.GetPageId()
+	if pageId == 0 {
+		pageId = 1
+	}
+
+	tasks, err := s.taskInspector.ListFailedTasks(req.GetQueue(), failedTaskStates[req.GetState()], int(pageSize), int(pageId))
+	if err != nil {
+		if errors.Is(err, asynq.ErrQueueNotFound) {
+			// nothing was ever enqueued to the queue
+			return &pb.ListFailedTasksResponse{Tasks: []*pb.FailedTask{}}, nil
+		}
+		return nil, status.Errorf(codes.Internal, "failed to list failed tasks: %s", err)
+	}
+
+	rsp := &pb.ListFailedTasksResponse{
+		Tasks: make([]*pb.FailedTask, 0, len(tasks)),
+	}
+	for _, task := range tasks {
+		rsp.Tasks = append(rsp.Tasks, failedTaskToPb(task))
+	}
+	return rsp, nil
+}
+
+func validateListFailedTasksRequest(req *pb.ListFailedTasksRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validateTaskQueue(req.GetQueue()); err != nil {
+		violations = append(violations, fieldViolation("queue", err))
+	}
+	if _, ok := failedTaskStates[req.GetState()]; !ok {
+		violations = append(violations, fieldViolation("state", fmt.Errorf("must be archived or retry")))
+	}
+	if req.GetPageId() < 0 {
+		violations = append(violations, fieldViolation("page_id", fmt.Errorf("must be positive")))
+	}
+	if req.GetPageSize() < 0 || req.GetPageSize() > maxFailedTasksPageSize {
+		violations = append(violations, fieldViolation("page_size", fmt.Errorf("must be between 1 and %d", maxFailedTasksPageSize)))
+	}
+	return violations
+}
+
+func validateTaskQueue(queue string) error {
+	for _, known := range worker.Queues() {
+		if queue == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %v", worker.Queues())
+}
+
+// failedTaskError converts an error of the task inspector to a gRPC status.
+func failedTaskError(action string, err error) error {
+	if errors.Is(err, asynq.ErrQueueNotFound) || errors.Is(err, asynq.ErrTaskNotFound) {
+		return status.Errorf(codes.NotFound, "task not found")
+	}
+	return status.Errorf(codes.Internal, "failed to %s task: %s", action, err)
+}
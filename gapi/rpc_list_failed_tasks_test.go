@@ -0,0 +1,219 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/kwalter26/udemy-simplebank/worker"
+	mockwk "github.com/kwalter26/udemy-simplebank/worker/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestListFailedTasksAPI(t *testing.T) {
+	banker, _ := createRandomUser(t)
+	banker.Role = util.BankerRole
+	depositor, _ := createRandomUser(t)
+	depositor.Role = util.DepositorRole
+
+	task := &asynq.TaskInfo{
+		ID:           util.RandomString(16),
+		Queue:        worker.EmailQueue,
+		Type:         worker.TaskSendVerifyEmail,
+		Payload:      []byte(`{"username":"alice","password":"secret"}`),
+		State:        asynq.TaskStateArchived,
+		MaxRetry:     10,
+		Retried:      10,
+		LastErr:      "smtp down",
+		LastFailedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListFailedTasksRequest
+		buildStubs    func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.ListFailedTasksResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.ListFailedTasksRequest{Queue: worker.EmailQueue},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				inspector.EXPECT().
+					ListFailedTasks(gomock.Eq(worker.EmailQueue), gomock.Eq(asynq.TaskStateArchived), gomock.Eq(defaultFailedTasksPageSize), gomock.Eq(1)).
+					Times(1).
+					Return([]*asynq.TaskInfo{task}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListFailedTasksResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Tasks, 1)
+				require.Equal(t, task.ID, res.Tasks[0].Id)
+				require.Equal(t, "archived", res.Tasks[0].State)
+				require.Equal(t, task.LastErr, res.Tasks[0].LastError)
+				require.Equal(t, task.LastFailedAt, res.Tasks[0].LastFailedAt.AsTime())
+				require.Nil(t, res.Tasks[0].NextProcessAt)
+				require.NotContains(t, res.Tasks[0].Payload, "secret")
+			},
+		},
+		{
+			name: "RetryState",
+			req:  &pb.ListFailedTasksRequest{Queue: worker.WebhookQueue, State: "retry", PageId: 2, PageSize: 5},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				inspector.EXPECT().
+					ListFailedTasks(gomock.Eq(worker.WebhookQueue), gomock.Eq(asynq.TaskStateRetry), gomock.Eq(5), gomock.Eq(2)).
+					Times(1).
+					Return([]*asynq.TaskInfo{}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListFailedTasksResponse, err error) {
+				require.NoError(t, err)
+				require.Empty(t, res.Tasks)
+			},
+		},
+		{
+			name: "QueueNeverUsed",
+			req:  &pb.ListFailedTasksRequest{Queue: worker.DefaultQueue},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				inspector.EXPECT().
+					ListFailedTasks(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, asynq.ErrQueueNotFound)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListFailedTasksResponse, err error) {
+				require.NoError(t, err)
+				require.Empty(t, res.Tasks)
+			},
+		},
+		{
+			name: "NotBanker",
+			req:  &pb.ListFailedTasksRequest{Queue: worker.EmailQueue},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(depositor.Username)).
+					Times(1).
+					Return(depositor, nil)
+				inspector.EXPECT().
+					ListFailedTasks(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, depositor, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListFailedTasksResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidQueue",
+			req:  &pb.ListFailedTasksRequest{Queue: "unknown"},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				inspector.EXPECT().
+					ListFailedTasks(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListFailedTasksResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidState",
+			req:  &pb.ListFailedTasksRequest{Queue: worker.EmailQueue, State: "pending"},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListFailedTasksResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.ListFailedTasksRequest{Queue: worker.EmailQueue},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				inspector.EXPECT().
+					ListFailedTasks(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, errors.New("redis down"))
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListFailedTasksResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			inspector := mockwk.NewMockTaskInspector(ctrl)
+
+			tc.buildStubs(store, inspector)
+
+			server := newTestServer(t, store, nil)
+			server.taskInspector = inspector
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_ListFailedTasks_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.ListFailedTasks(ctx, req.(*pb.ListFailedTasksRequest))
+			})
+			res, _ := out.(*pb.ListFailedTasksResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
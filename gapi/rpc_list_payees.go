@@ -0,0 +1,63 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPayeesPageSize = 50
+	maxPayeesPageSize     = 100
+)
+
+func (s *Server) ListPayees(context context.Context, req *pb.ListPayeesRequest) (*pb.ListPayeesResponse, error) {
+	payload, err := authenticatedUser(context)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateListPayeesRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	pageSize := req.GetPageSize()
+	if pageSize == 0 {
+		pageSize = defaultPayeesPageSize
+	}
+	pageId := req.GetPageId()
+	if pageId == 0 {
+		pageId = 1
+	}
+
+	payees, err := s.store.ListPayees(context, db.ListPayeesParams{
+		Owner:  payload.Username,
+		Limit:  pageSize,
+		Offset: (pageId - 1) * pageSize,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list payees: %s", err)
+	}
+
+	rsp := &pb.ListPayeesResponse{
+		Payees: make([]*pb.Payee, 0, len(payees)),
+	}
+	for _, payee := range payees {
+		rsp.Payees = append(rsp.Payees, payeeToPb(payee))
+	}
+	return rsp, nil
+}
+
+func validateListPayeesRequest(req *pb.ListPayeesRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetPageId() < 0 {
+		violations = append(violations, fieldViolation("page_id", fmt.Errorf("must be positive")))
+	}
+	if req.GetPageSize() < 0 || req.GetPageSize() > maxPayeesPageSize {
+		violations = append(violations, fieldViolation("page_size", fmt.Errorf("must be between 1 and %d", maxPayeesPageSize)))
+	}
+	return violations
+}
@@ -0,0 +1,122 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestListPayeesAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	payees := []db.Payee{
+		{ID: 1, Owner: user.Username, Nickname: "landlord", AccountID: 10, Currency: util.USD,
+			CoolingOffUntil: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}, CoolingOffMaxAmount: 500},
+		{ID: 2, Owner: user.Username, Nickname: "friend", AccountID: 11, Currency: util.EUR},
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListPayeesRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.ListPayeesResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.ListPayeesRequest{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPayees(gomock.Any(), gomock.Eq(db.ListPayeesParams{Owner: user.Username, Limit: defaultPayeesPageSize, Offset: 0})).
+					Times(1).
+					Return(payees, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListPayeesResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Payees, 2)
+				require.Equal(t, "landlord", res.Payees[0].Nickname)
+				require.NotNil(t, res.Payees[0].CoolingOffUntil)
+				require.Nil(t, res.Payees[1].CoolingOffUntil)
+			},
+		},
+		{
+			name: "SecondPage",
+			req:  &pb.ListPayeesRequest{PageId: 2, PageSize: 5},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPayees(gomock.Any(), gomock.Eq(db.ListPayeesParams{Owner: user.Username, Limit: 5, Offset: 5})).
+					Times(1).
+					Return([]db.Payee{}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListPayeesResponse, err error) {
+				require.NoError(t, err)
+				require.Empty(t, res.Payees)
+			},
+		},
+		{
+			name: "InvalidPageSize",
+			req:  &pb.ListPayeesRequest{PageSize: maxPayeesPageSize + 1},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListPayees(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListPayeesResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "Unauthenticated",
+			req:  &pb.ListPayeesRequest{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListPayees(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.ListPayeesResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_ListPayees_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.ListPayees(ctx, req.(*pb.ListPayeesRequest))
+			})
+			res, _ := out.(*pb.ListPayeesResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultWebhookDeliveriesPageSize = 20
+	maxWebhookDeliveriesPageSize     = 100
+)
+
+func (s *Server) ListWebhookDeliveries(context context.Context, req *pb.ListWebhookDeliveriesRequest) (*pb.ListWebhookDeliveriesResponse, error) {
+	payload, err := authenticatedUser(context)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateListWebhookDeliveriesRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	hook, err := s.store.GetWebhook(context, req.GetWebhookId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "webhook not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get webhook: %s", err)
+	}
+	if hook.Owner != payload.Username {
+		// do not tell other users which webhooks exist
+		return nil, status.Errorf(codes.NotFound, "webhook not found")
+	}
+
+	pageSize := req.GetPageSize()
+	if pageSize == 0 {
+		pageSize = defaultWebhookDeliveriesPageSize
+	}
+	pageId := req.GetPageId()
+	if pageId == 0 {
+		pageId = 1
+	}
+
+	deliveries, err := s.store.ListWebhookDeliveries(context, db.ListWebhookDeliveriesParams{
+		WebhookID: hook.ID,
+		Limit:     pageSize,
+		Offset:    (pageId - 1) * pageSize,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list webhook deliveries: %s", err)
+	}
+
+	rsp := &pb.ListWebhookDeliveriesResponse{
+		Deliveries: make([]*pb.WebhookDelivery, 0, len(deliveries)),
+	}
+	for _, delivery := range deliveries {
+		rsp.Deliveries = append(rsp.Deliveries, webhookDeliveryToPb(delivery))
+	}
+	return rsp, nil
+}
+
+func validateListWebhookDeliveriesRequest(req *pb.ListWebhookDeliveriesRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetWebhookId() < 1 {
+		violations = append(violations, fieldViolation("webhook_id", fmt.Errorf("must be positive")))
+	}
+	if req.GetPageId() < 0 {
+		violations = append(violations, fieldViolation("page_id", fmt.Errorf("must be positive")))
+	}
+	if req.GetPageSize() < 0 || req.GetPageSize() > maxWebhookDeliveriesPageSize {
+		violations = append(violations, fieldViolation("page_size", fmt.Errorf("must be between 1 and %d", maxWebhookDeliveriesPageSize)))
+	}
+	return violations
+}
@@ -0,0 +1,192 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestListWebhookDeliveriesAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	hook := db.Webhook{ID: util.RandomInt(1, 1000), Owner: user.Username, Url: "https://example.com/hooks"}
+	otherHook := db.Webhook{ID: hook.ID + 1, Owner: util.RandomOwner(), Url: "https://example.com/hooks"}
+	delivery := db.WebhookDelivery{
+		ID:           util.RandomInt(1, 1000),
+		WebhookID:    hook.ID,
+		EventID:      3,
+		EventType:    db.DomainEventBalanceChanged,
+		Status:       db.WebhookDeliveryDead,
+		Attempts:     9,
+		ResponseCode: http.StatusBadGateway,
+		LastError:    "webhook endpoint responded with 502",
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListWebhookDeliveriesRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.ListWebhookDeliveriesResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.ListWebhookDeliveriesRequest{
+				WebhookId: hook.ID,
+				PageId:    2,
+				PageSize:  5,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhook(gomock.Any(), gomock.Eq(hook.ID)).
+					Times(1).
+					Return(hook, nil)
+				store.EXPECT().
+					ListWebhookDeliveries(gomock.Any(), gomock.Eq(db.ListWebhookDeliveriesParams{
+						WebhookID: hook.ID,
+						Limit:     5,
+						Offset:    5,
+					})).
+					Times(1).
+					Return([]db.WebhookDelivery{delivery}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListWebhookDeliveriesResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Deliveries, 1)
+				require.Equal(t, delivery.ID, res.Deliveries[0].Id)
+				require.Equal(t, db.WebhookDeliveryDead, res.Deliveries[0].Status)
+				require.Equal(t, delivery.Attempts, res.Deliveries[0].Attempts)
+				require.Equal(t, delivery.LastError, res.Deliveries[0].LastError)
+			},
+		},
+		{
+			name: "WebhookOfAnotherUser",
+			req: &pb.ListWebhookDeliveriesRequest{
+				WebhookId: otherHook.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhook(gomock.Any(), gomock.Eq(otherHook.ID)).
+					Times(1).
+					Return(otherHook, nil)
+				store.EXPECT().ListWebhookDeliveries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListWebhookDeliveriesResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "WebhookNotFound",
+			req: &pb.ListWebhookDeliveriesRequest{
+				WebhookId: hook.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhook(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Webhook{}, sql.ErrNoRows)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListWebhookDeliveriesResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidPageSize",
+			req: &pb.ListWebhookDeliveriesRequest{
+				WebhookId: hook.ID,
+				PageSize:  maxWebhookDeliveriesPageSize + 1,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListWebhookDeliveriesResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "Unauthenticated",
+			req: &pb.ListWebhookDeliveriesRequest{
+				WebhookId: hook.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.ListWebhookDeliveriesResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req: &pb.ListWebhookDeliveriesRequest{
+				WebhookId: hook.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhook(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(hook, nil)
+				store.EXPECT().
+					ListWebhookDeliveries(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListWebhookDeliveriesResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_ListWebhookDeliveries_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.ListWebhookDeliveries(ctx, req.(*pb.ListWebhookDeliveriesRequest))
+			})
+			res, _ := out.(*pb.ListWebhookDeliveriesResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
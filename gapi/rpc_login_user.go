@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"github.com/kwalter26/udemy-simplebank/metrics"
 	"github.com/kwalter26/udemy-simplebank/pb"
 	"github.com/kwalter26/udemy-simplebank/util"
 	"github.com/kwalter26/udemy-simplebank/val"
@@ -18,22 +20,33 @@ func (s *Server) Login(context context.Context, req *pb.LoginUserRequest) (*pb.L
 		return nil, invalidArgumentError(violations)
 	}
 
-	user, err := s.store.GetUser(context, req.GetUsername())
+	mdtd := s.extractMetadata(context)
+	lockoutKeys := s.loginLockoutKeys(req.GetUsername(), mdtd)
+	if err := s.checkLoginLockout(context, lockoutKeys); err != nil {
+		return nil, err
+	}
 
+	user, err := s.store.GetUser(context, req.GetUsername())
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, status.Errorf(codes.NotFound, "username not found: %s", err)
-
+			s.dummyPassword.Check(req.GetPassword())
+			return nil, s.loginFailed(context, req.GetUsername(), lockoutKeys)
 		}
 		return nil, status.Errorf(codes.Internal, "failed to login user: %s", err)
+	}
 
+	err = util.CheckPassword(req.GetPassword(), user.HashedPassword)
+	if err != nil {
+		return nil, s.loginFailed(context, req.GetUsername(), lockoutKeys)
 	}
 
-	err = util.CheckPassword(req.Password, user.HashedPassword)
+	err = s.resetLoginLockout(context, user.Username)
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "failed to login user: %s", err)
+		return nil, status.Errorf(codes.Internal, "failed to login user: %s", err)
 	}
 
+	user = s.upgradePasswordHash(context, user, req.GetPassword())
+
 	accessToken, accessPayload, err := s.tokenMaker.CreateToken(user.Username, s.config.AccessTokenDuration)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to login user: %s", err)
@@ -44,15 +57,17 @@ func (s *Server) Login(context context.Context, req *pb.LoginUserRequest) (*pb.L
 		return nil, status.Errorf(codes.Internal, "failed to login user: %s", err)
 	}
 
-	mdtd := s.extractMetadata(context)
-	session, err := s.store.CreateSession(context, db.CreateSessionParams{
-		ID:           refreshPayload.ID,
-		Username:     user.Username,
-		RefreshToken: refreshToken,
-		UserAgent:    mdtd.UserAgent,
-		ClientIp:     mdtd.ClientIp,
-		IsBlocked:    false,
-		ExpiresAt:    refreshPayload.ExpireAt,
+	result, err := s.store.CreateSessionTx(context, db.CreateSessionTxParams{
+		CreateSessionParams: db.CreateSessionParams{
+			ID:           refreshPayload.ID,
+			Username:     user.Username,
+			RefreshToken: refreshToken,
+			UserAgent:    mdtd.UserAgent,
+			ClientIp:     mdtd.ClientIp,
+			IsBlocked:    false,
+			ExpiresAt:    refreshPayload.ExpireAt,
+		},
+		Audit: s.auditContext(context, user.Username),
 	})
 
 	if err != nil {
@@ -61,7 +76,7 @@ func (s *Server) Login(context context.Context, req *pb.LoginUserRequest) (*pb.L
 
 	rsp := &pb.LoginUserResponse{
 		User:                  userToPb(user),
-		SessionId:             session.ID.String(),
+		SessionId:             result.Session.ID.String(),
 		AccessToken:           accessToken,
 		RefreshToken:          refreshToken,
 		AccessTokenExpiresAt:  timestamppb.New(accessPayload.ExpireAt),
@@ -70,6 +85,50 @@ func (s *Server) Login(context context.Context, req *pb.LoginUserRequest) (*pb.L
 	return rsp, nil
 }
 
+// upgradePasswordHash rehashes the password if it was stored with another algorithm or weaker parameters than the
+// configured hasher. Failing to upgrade does not fail the login.
+func (s *Server) upgradePasswordHash(ctx context.Context, user db.User, password string) db.User {
+	if !s.passwordHasher.NeedsRehash(user.HashedPassword) {
+		return user
+	}
+
+	hashedPassword, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		logging.Ctx(ctx).Warn().Err(err).Str("username", user.Username).Msg("failed to rehash password")
+		return user
+	}
+
+	updatedUser, err := s.store.UpdateUser(ctx, db.UpdateUserParams{
+		Username: user.Username,
+		HashedPassword: sql.NullString{
+			String: hashedPassword,
+			Valid:  true,
+		},
+	})
+	if err != nil {
+		logging.Ctx(ctx).Warn().Err(err).Str("username", user.Username).Msg("failed to store rehashed password")
+		return user
+	}
+	return updatedUser
+}
+
+// loginFailed records the failed attempt and returns the same error for unknown users and wrong passwords.
+func (s *Server) loginFailed(ctx context.Context, username string, lockoutKeys []loginLockoutKey) error {
+	metrics.LoginFailures.WithLabelValues(metrics.LoginFailureInvalidCredentials).Inc()
+	if err := s.recordLoginFailure(ctx, lockoutKeys); err != nil {
+		return err
+	}
+
+	arg, err := db.NewAuditEventParams(s.auditContext(ctx, username), db.AuditActionLoginFailed, db.AuditTarget("user", username), nil, nil)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to build audit event: %s", err)
+	}
+	if _, err = s.store.CreateAuditEvent(ctx, arg); err != nil {
+		return status.Errorf(codes.Internal, "failed to record audit event: %s", err)
+	}
+	return errInvalidCredentials
+}
+
 func validateLoginUserRequest(req *pb.LoginUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
 	if err := val.ValidateUsername(req.GetUsername()); err != nil {
 		violations = append(violations, fieldViolation("username", err))
@@ -0,0 +1,323 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"net"
+	"testing"
+	"time"
+)
+
+type eqRecordLoginFailureParamsMatcher struct {
+	kind   string
+	key    string
+	window time.Duration
+}
+
+func (expected eqRecordLoginFailureParamsMatcher) Matches(x interface{}) bool {
+	actualArg, ok := x.(db.RecordLoginFailureParams)
+	if !ok {
+		return false
+	}
+
+	resetBefore := time.Now().Add(-expected.window)
+	return actualArg.Kind == expected.kind &&
+		actualArg.Key == expected.key &&
+		actualArg.ResetBefore.After(resetBefore.Add(-time.Minute)) &&
+		!actualArg.ResetBefore.After(resetBefore)
+}
+
+func (expected eqRecordLoginFailureParamsMatcher) String() string {
+	return fmt.Sprintf("matches kind %v, key %v and a reset %v ago", expected.kind, expected.key, expected.window)
+}
+
+func EqRecordLoginFailureParams(kind, key string, window time.Duration) gomock.Matcher {
+	return eqRecordLoginFailureParamsMatcher{kind: kind, key: key, window: window}
+}
+
+func TestLoginUserAPI(t *testing.T) {
+	user, password := createRandomUser(t)
+	clientAddr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 54321}
+
+	weakHasher := &util.BcryptHasher{Cost: bcrypt.MinCost}
+	weakHashedPassword, err := weakHasher.Hash(password)
+	require.NoError(t, err)
+	weakUser := user
+	weakUser.HashedPassword = weakHashedPassword
+
+	usernameKey := db.GetLoginLockoutParams{Kind: lockoutKindUsername, Key: user.Username}
+	clientIpKey := db.GetLoginLockoutParams{Kind: lockoutKindClientIp, Key: "10.0.0.1"}
+
+	testCases := []struct {
+		name          string
+		req           *pb.LoginUserRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.LoginUserResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginLockout(gomock.Any(), gomock.Eq(usernameKey)).
+					Times(1).
+					Return(db.LoginLockout{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetLoginLockout(gomock.Any(), gomock.Eq(clientIpKey)).
+					Times(1).
+					Return(db.LoginLockout{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					ResetLoginLockout(gomock.Any(), gomock.Eq(db.ResetLoginLockoutParams{Kind: lockoutKindUsername, Key: user.Username})).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					CreateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateSessionTxParams) (db.CreateSessionTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, db.AuditContext{Actor: user.Username, ClientIp: "10.0.0.1:54321"}, arg.Audit)
+						return db.CreateSessionTxResult{Session: db.Session{ID: arg.ID, Username: user.Username}}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				require.Equal(t, user.Username, res.User.Username)
+				require.NotEmpty(t, res.AccessToken)
+				require.NotEmpty(t, res.RefreshToken)
+			},
+		},
+		{
+			name: "RehashWeakPassword",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginLockout(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginLockout{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(weakUser, nil)
+				store.EXPECT().
+					ResetLoginLockout(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.True(t, arg.HashedPassword.Valid)
+						require.False(t, arg.PasswordChangedAt.Valid)
+						require.NoError(t, util.CheckPassword(password, arg.HashedPassword.String))
+						require.False(t, weakHasher.NeedsRehash(arg.HashedPassword.String))
+						return user, nil
+					})
+				store.EXPECT().
+					CreateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateSessionTxResult{Session: db.Session{Username: user.Username}}, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+			},
+		},
+		{
+			name: "UserNotFound",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginLockout(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginLockout{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), EqRecordLoginFailureParams(lockoutKindUsername, user.Username, 24*time.Hour)).
+					Times(1).
+					Return(db.LoginLockout{FailedCount: 1}, nil)
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), EqRecordLoginFailureParams(lockoutKindClientIp, "10.0.0.1", 24*time.Hour)).
+					Times(1).
+					Return(db.LoginLockout{FailedCount: 1}, nil)
+				store.EXPECT().
+					CreateAuditEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateAuditEventParams) (db.AuditEvent, error) {
+						require.Equal(t, db.AuditActionLoginFailed, arg.Action)
+						require.Equal(t, user.Username, arg.Actor)
+						require.Equal(t, "user:"+user.Username, arg.Target)
+						return db.AuditEvent{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, errInvalidCredentials, err)
+			},
+		},
+		{
+			name: "WrongPasswordLocksUsername",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: "wrong_password",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginLockout(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginLockout{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), EqRecordLoginFailureParams(lockoutKindUsername, user.Username, 24*time.Hour)).
+					Times(1).
+					Return(db.LoginLockout{FailedCount: 5}, nil)
+				store.EXPECT().
+					LockLogin(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.LockLoginParams) (db.LoginLockout, error) {
+						require.Equal(t, lockoutKindUsername, arg.Kind)
+						require.WithinDuration(t, time.Now().Add(30*time.Second), arg.LockedUntil, time.Second)
+						return db.LoginLockout{}, nil
+					})
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), EqRecordLoginFailureParams(lockoutKindClientIp, "10.0.0.1", 24*time.Hour)).
+					Times(1).
+					Return(db.LoginLockout{FailedCount: 5}, nil)
+				store.EXPECT().
+					CreateAuditEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AuditEvent{}, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, errInvalidCredentials, err)
+			},
+		},
+		{
+			name: "LockedOut",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginLockout(gomock.Any(), gomock.Eq(usernameKey)).
+					Times(1).
+					Return(db.LoginLockout{FailedCount: 6, LockedUntil: time.Now().Add(time.Minute)}, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.Nil(t, res)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.ResourceExhausted, st.Code())
+				require.Len(t, st.Details(), 1)
+			},
+		},
+		{
+			name: "ClientIpLockedOut",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginLockout(gomock.Any(), gomock.Eq(usernameKey)).
+					Times(1).
+					Return(db.LoginLockout{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetLoginLockout(gomock.Any(), gomock.Eq(clientIpKey)).
+					Times(1).
+					Return(db.LoginLockout{FailedCount: 20, LockedUntil: time.Now().Add(time.Minute)}, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.ResourceExhausted, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginLockout(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.LoginLockout{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidUsername",
+			req: &pb.LoginUserRequest{
+				Username: "invalid-user#",
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: clientAddr})
+			res, err := server.Login(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
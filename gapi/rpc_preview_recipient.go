@@ -0,0 +1,66 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/kwalter26/udemy-simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) PreviewRecipient(context context.Context, req *pb.PreviewRecipientRequest) (*pb.PreviewRecipientResponse, error) {
+	if _, err := authenticatedUser(context); err != nil {
+		return nil, err
+	}
+
+	if violations := validatePreviewRecipientRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	recipient, err := s.store.ResolveRecipient(context, db.ResolveRecipientParams{
+		Username: req.GetUsername(),
+		Email:    req.GetEmail(),
+		Currency: req.GetCurrency(),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecipientNotFound):
+			return nil, status.Errorf(codes.NotFound, "%s", err)
+		case errors.Is(err, db.ErrRecipientNoAccount):
+			return nil, status.Errorf(codes.FailedPrecondition, "%s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to find recipient: %s", err)
+	}
+
+	rsp := &pb.PreviewRecipientResponse{
+		MaskedFullName: util.MaskName(recipient.User.FullName),
+		Currency:       recipient.Account.Currency,
+	}
+	return rsp, nil
+}
+
+func validatePreviewRecipientRequest(req *pb.PreviewRecipientRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	switch {
+	case req.GetUsername() != "" && req.GetEmail() != "":
+		violations = append(violations, fieldViolation("email", fmt.Errorf("cannot be set with username")))
+	case req.GetUsername() != "":
+		if err := val.ValidateUsername(req.GetUsername()); err != nil {
+			violations = append(violations, fieldViolation("username", err))
+		}
+	case req.GetEmail() != "":
+		if err := val.ValidateEmail(req.GetEmail()); err != nil {
+			violations = append(violations, fieldViolation("email", err))
+		}
+	default:
+		violations = append(violations, fieldViolation("username", fmt.Errorf("username or email is required")))
+	}
+	if !util.IsSupportedCurrency(req.GetCurrency()) {
+		violations = append(violations, fieldViolation("currency", fmt.Errorf("unsupported currency %q", req.GetCurrency())))
+	}
+	return violations
+}
@@ -0,0 +1,168 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestPreviewRecipientAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	recipient, _ := createRandomUser(t)
+	account := db.Account{ID: util.RandomInt(1, 1000), Owner: recipient.Username, Currency: util.EUR}
+
+	testCases := []struct {
+		name          string
+		req           *pb.PreviewRecipientRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.PreviewRecipientResponse, err error)
+	}{
+		{
+			name: "ByUsername",
+			req:  &pb.PreviewRecipientRequest{Username: recipient.Username, Currency: util.EUR},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResolveRecipient(gomock.Any(), gomock.Eq(db.ResolveRecipientParams{Username: recipient.Username, Currency: util.EUR})).
+					Times(1).
+					Return(db.Recipient{User: recipient, Account: account}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.PreviewRecipientResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, util.MaskName(recipient.FullName), res.MaskedFullName)
+				require.NotEqual(t, recipient.FullName, res.MaskedFullName)
+				require.Equal(t, util.EUR, res.Currency)
+			},
+		},
+		{
+			name: "ByEmail",
+			req:  &pb.PreviewRecipientRequest{Email: recipient.Email, Currency: util.EUR},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResolveRecipient(gomock.Any(), gomock.Eq(db.ResolveRecipientParams{Email: recipient.Email, Currency: util.EUR})).
+					Times(1).
+					Return(db.Recipient{User: recipient, Account: account}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.PreviewRecipientResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, util.MaskName(recipient.FullName), res.MaskedFullName)
+			},
+		},
+		{
+			name: "NotFound",
+			req:  &pb.PreviewRecipientRequest{Username: recipient.Username, Currency: util.EUR},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Any()).Times(1).Return(db.Recipient{}, db.ErrRecipientNotFound)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.PreviewRecipientResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "NoAccountInCurrency",
+			req:  &pb.PreviewRecipientRequest{Username: recipient.Username, Currency: util.CAD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResolveRecipient(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Recipient{}, fmt.Errorf("%w %s", db.ErrRecipientNoAccount, util.CAD))
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.PreviewRecipientResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+				require.Contains(t, status.Convert(err).Message(), util.CAD)
+			},
+		},
+		{
+			name: "InvalidArguments",
+			req:  &pb.PreviewRecipientRequest{Username: recipient.Username, Email: recipient.Email, Currency: "XYZ"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.PreviewRecipientResponse, err error) {
+				require.Nil(t, res)
+				st := status.Convert(err)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+				violations := st.Details()[0].(*errdetails.BadRequest).GetFieldViolations()
+				require.Len(t, violations, 2)
+			},
+		},
+		{
+			name: "MissingRecipient",
+			req:  &pb.PreviewRecipientRequest{Currency: util.EUR},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.PreviewRecipientResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "Unauthenticated",
+			req:  &pb.PreviewRecipientRequest{Username: recipient.Username, Currency: util.EUR},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.PreviewRecipientResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_PreviewRecipient_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.PreviewRecipient(ctx, req.(*pb.PreviewRecipientRequest))
+			})
+			res, _ := out.(*pb.PreviewRecipientResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) QuoteTransfer(context context.Context, req *pb.QuoteTransferRequest) (*pb.QuoteTransferResponse, error) {
+	payload, err := authenticatedUser(context)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateQuoteTransferRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	fromAccount, err := s.store.GetAccount(context, req.GetFromAccountId())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+	if fromAccount.Owner != payload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "from account doesn't belong to the authenticated user")
+	}
+
+	if fromAccount.Currency != req.GetCurrency() {
+		return nil, currencyMismatchError(fromAccount.ID, fromAccount.Currency, req.GetCurrency())
+	}
+
+	toAccount, recipient, err := s.transferRecipient(context, payload.Username, req)
+	if err != nil {
+		return nil, err
+	}
+	if toAccount.ID == fromAccount.ID {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{
+			fieldViolation("to_account_id", db.ErrSameAccount),
+		})
+	}
+	if fromAccount.Frozen || toAccount.Frozen {
+		return nil, status.Errorf(codes.PermissionDenied, "account is frozen")
+	}
+
+	quote, err := s.store.QuoteTransferFee(context, db.QuoteTransferFeeParams{
+		FromAccount: fromAccount,
+		Amount:      req.GetAmount(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to quote transfer: %s", err)
+	}
+
+	rsp := &pb.QuoteTransferResponse{
+		FromAccountId:          fromAccount.ID,
+		Currency:               fromAccount.Currency,
+		Amount:                 quote.Amount,
+		Fee:                    quote.Fee,
+		Total:                  quote.Amount + quote.Fee,
+		FreeTransfersRemaining: quote.FreeTransfersRemaining,
+		Recipient:              recipient,
+	}
+	if recipient == "" {
+		rsp.ToAccountId = toAccount.ID
+	}
+	if quote.Rule != nil {
+		rsp.FeeRuleId = quote.Rule.ID
+	}
+	return rsp, nil
+}
+
+func validateQuoteTransferRequest(req *pb.QuoteTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetFromAccountId() < 1 {
+		violations = append(violations, fieldViolation("from_account_id", fmt.Errorf("must be positive")))
+	}
+	if req.GetToAccountId() != 0 && req.GetToAccountId() == req.GetFromAccountId() {
+		violations = append(violations, fieldViolation("to_account_id", db.ErrSameAccount))
+	}
+	if req.GetAmount() < 1 {
+		violations = append(violations, fieldViolation("amount", fmt.Errorf("must be positive")))
+	}
+	if !util.IsSupportedCurrency(req.GetCurrency()) {
+		violations = append(violations, fieldViolation("currency", fmt.Errorf("unsupported currency %q", req.GetCurrency())))
+	}
+	violations = append(violations, validateTransferRecipient(req)...)
+	return violations
+}
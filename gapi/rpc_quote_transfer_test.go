@@ -0,0 +1,296 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/fees"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestQuoteTransferAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := db.Account{ID: util.RandomInt(1, 1000), Owner: user.Username, Balance: 1000, Currency: util.USD}
+	recipientUser, _ := createRandomUser(t)
+	recipientUser.FullName = "Jane Doe"
+	toAccount := db.Account{ID: account.ID + 1, Owner: recipientUser.Username, Currency: util.USD}
+	payee := db.Payee{ID: util.RandomInt(1, 1000), Owner: user.Username, Nickname: "rent", AccountID: toAccount.ID, Currency: util.USD}
+	rule := db.FeeRule{ID: 3, Currency: util.USD, Kind: fees.KindFlat, FlatAmount: 2, Active: true}
+	req := &pb.QuoteTransferRequest{
+		FromAccountId: account.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        100,
+		Currency:      util.USD,
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.QuoteTransferRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.QuoteTransferResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  req,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					QuoteTransferFee(gomock.Any(), gomock.Eq(db.QuoteTransferFeeParams{FromAccount: account, Amount: 100})).
+					Times(1).
+					Return(db.TransferFeeQuote{Amount: 100, Fee: 2, Rule: &rule}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.QuoteTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(100), res.Amount)
+				require.Equal(t, int64(2), res.Fee)
+				require.Equal(t, int64(102), res.Total)
+				require.Equal(t, rule.ID, res.FeeRuleId)
+				require.Equal(t, util.USD, res.Currency)
+				require.Equal(t, toAccount.ID, res.ToAccountId)
+				require.Empty(t, res.Recipient)
+			},
+		},
+		{
+			name: "PayPayee",
+			req:  &pb.QuoteTransferRequest{FromAccountId: account.ID, PayeeId: payee.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(payee, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					QuoteTransferFee(gomock.Any(), gomock.Eq(db.QuoteTransferFeeParams{FromAccount: account, Amount: 100})).
+					Times(1).
+					Return(db.TransferFeeQuote{Amount: 100, Fee: 2, Rule: &rule}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.QuoteTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(2), res.Fee)
+				require.Equal(t, payee.Nickname, res.Recipient)
+				require.Zero(t, res.ToAccountId)
+			},
+		},
+		{
+			name: "PayByUsername",
+			req:  &pb.QuoteTransferRequest{FromAccountId: account.ID, ToUsername: recipientUser.Username, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ResolveRecipient(gomock.Any(), gomock.Eq(db.ResolveRecipientParams{Username: recipientUser.Username, Currency: util.USD})).
+					Times(1).
+					Return(db.Recipient{User: recipientUser, Account: toAccount}, nil)
+				store.EXPECT().
+					QuoteTransferFee(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferFeeQuote{Amount: 100, Fee: 2, Rule: &rule}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.QuoteTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(102), res.Total)
+				require.Equal(t, "J*** D**", res.Recipient)
+				require.Zero(t, res.ToAccountId)
+			},
+		},
+		{
+			name: "FrozenPayeeAccount",
+			req:  &pb.QuoteTransferRequest{FromAccountId: account.ID, PayeeId: payee.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				frozen := toAccount
+				frozen.Frozen = true
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(payee, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(frozen, nil)
+				store.EXPECT().QuoteTransferFee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.QuoteTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "TwoRecipients",
+			req:  &pb.QuoteTransferRequest{FromAccountId: account.ID, ToAccountId: toAccount.ID, PayeeId: payee.ID, Amount: 100, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.QuoteTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "FreeTransfer",
+			req:  req,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					QuoteTransferFee(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferFeeQuote{Amount: 100, Rule: &rule, FreeTransfersRemaining: 2}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.QuoteTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Zero(t, res.Fee)
+				require.Equal(t, int64(100), res.Total)
+				require.Equal(t, int32(2), res.FreeTransfersRemaining)
+			},
+		},
+		{
+			name: "NotOwner",
+			req:  req,
+			buildStubs: func(store *mockdb.MockStore) {
+				other := account
+				other.Owner = util.RandomOwner()
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(other, nil)
+				store.EXPECT().QuoteTransferFee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.QuoteTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "ToAccountNotFound",
+			req:  req,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().QuoteTransferFee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.QuoteTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			req:  req,
+			buildStubs: func(store *mockdb.MockStore) {
+				euroAccount := toAccount
+				euroAccount.Currency = util.EUR
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(euroAccount, nil)
+				store.EXPECT().QuoteTransferFee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.QuoteTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "FrozenAccount",
+			req:  req,
+			buildStubs: func(store *mockdb.MockStore) {
+				frozen := toAccount
+				frozen.Frozen = true
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(frozen, nil)
+				store.EXPECT().QuoteTransferFee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.QuoteTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidArguments",
+			req: &pb.QuoteTransferRequest{
+				FromAccountId: account.ID,
+				ToAccountId:   account.ID,
+				Amount:        0,
+				Currency:      "XYZ",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.QuoteTransferResponse, err error) {
+				require.Nil(t, res)
+				st := status.Convert(err)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+				violations := st.Details()[0].(*errdetails.BadRequest).GetFieldViolations()
+				require.Len(t, violations, 3)
+			},
+		},
+		{
+			name: "Unauthenticated",
+			req:  req,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.QuoteTransferResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_QuoteTransfer_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.QuoteTransfer(ctx, req.(*pb.QuoteTransferRequest))
+			})
+			res, _ := out.(*pb.QuoteTransferResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hibiken/asynq"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+func (s *Server) RetryFailedTask(context context.Context, req *pb.RetryFailedTaskRequest) (*pb.RetryFailedTaskResponse, error) {
+	if _, err := s.authorizeBanker(context); err != nil {
+		return nil, err
+	}
+
+	if violations := validateRetryFailedTaskRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	err := s.taskInspector.RunTask(req.GetQueue(), req.GetTaskId())
+	if errors.Is(err, asynq.ErrQueueNotFound) || errors.Is(err, asynq.ErrTaskNotFound) {
+		// tasks the outbox relay gave up on never reached the queue, they are published again instead
+		var revived int64
+		revived, err = s.store.ReviveOutboxTask(context, db.ReviveOutboxTaskParams{Queue: req.GetQueue(), TaskID: req.GetTaskId()})
+		if err == nil && revived == 0 {
+			err = asynq.ErrTaskNotFound
+		}
+	}
+	if err != nil {
+		return nil, failedTaskError("retry", err)
+	}
+	s.resolveDeadLetterTask(context, req.GetQueue(), req.GetTaskId(), db.DeadLetterTaskRetried)
+
+	rsp := &pb.RetryFailedTaskResponse{
+		TaskId: req.GetTaskId(),
+	}
+	return rsp, nil
+}
+
+// resolveDeadLetterTask marks the dead letter of a task as handled. The task was already changed in redis, so a
+// failure is only logged.
+func (s *Server) resolveDeadLetterTask(ctx context.Context, queue string, taskID string, resolution string) {
+	err := s.store.ResolveDeadLetterTask(ctx, db.ResolveDeadLetterTaskParams{
+		Queue:      queue,
+		TaskID:     taskID,
+		Resolution: resolution,
+	})
+	if err != nil {
+		logging.Ctx(ctx).Error().Err(err).Str("task_id", taskID).Msg("cannot resolve dead letter task")
+	}
+}
+
+func validateRetryFailedTaskRequest(req *pb.RetryFailedTaskRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validateTaskQueue(req.GetQueue()); err != nil {
+		violations = append(violations, fieldViolation("queue", err))
+	}
+	if req.GetTaskId() == "" {
+		violations = append(violations, fieldViolation("task_id", fmt.Errorf("must not be empty")))
+	}
+	return violations
+}
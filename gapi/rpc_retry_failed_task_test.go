@@ -0,0 +1,216 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/kwalter26/udemy-simplebank/worker"
+	mockwk "github.com/kwalter26/udemy-simplebank/worker/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestRetryFailedTaskAPI(t *testing.T) {
+	banker, _ := createRandomUser(t)
+	banker.Role = util.BankerRole
+	depositor, _ := createRandomUser(t)
+	depositor.Role = util.DepositorRole
+
+	taskID := util.RandomString(16)
+
+	testCases := []struct {
+		name          string
+		req           *pb.RetryFailedTaskRequest
+		buildStubs    func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.RetryFailedTaskResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.RetryFailedTaskRequest{Queue: worker.EmailQueue, TaskId: taskID},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				inspector.EXPECT().
+					RunTask(gomock.Eq(worker.EmailQueue), gomock.Eq(taskID)).
+					Times(1).
+					Return(nil)
+				arg := db.ResolveDeadLetterTaskParams{
+					Queue:      worker.EmailQueue,
+					TaskID:     taskID,
+					Resolution: db.DeadLetterTaskRetried,
+				}
+				store.EXPECT().
+					ResolveDeadLetterTask(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.RetryFailedTaskResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, taskID, res.TaskId)
+			},
+		},
+		{
+			name: "ResolveDeadLetterFails",
+			req:  &pb.RetryFailedTaskRequest{Queue: worker.EmailQueue, TaskId: taskID},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				inspector.EXPECT().
+					RunTask(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					ResolveDeadLetterTask(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.RetryFailedTaskResponse, err error) {
+				// the task runs again, only the bookkeeping is behind
+				require.NoError(t, err)
+				require.Equal(t, taskID, res.TaskId)
+			},
+		},
+		{
+			name: "DeadOutboxTask",
+			req:  &pb.RetryFailedTaskRequest{Queue: worker.EmailQueue, TaskId: taskID},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				inspector.EXPECT().
+					RunTask(gomock.Eq(worker.EmailQueue), gomock.Eq(taskID)).
+					Times(1).
+					Return(asynq.ErrTaskNotFound)
+				store.EXPECT().
+					ReviveOutboxTask(gomock.Any(), gomock.Eq(db.ReviveOutboxTaskParams{Queue: worker.EmailQueue, TaskID: taskID})).
+					Times(1).
+					Return(int64(1), nil)
+				store.EXPECT().
+					ResolveDeadLetterTask(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.RetryFailedTaskResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, taskID, res.TaskId)
+			},
+		},
+		{
+			name: "TaskNotFound",
+			req:  &pb.RetryFailedTaskRequest{Queue: worker.EmailQueue, TaskId: taskID},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				inspector.EXPECT().
+					RunTask(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(asynq.ErrTaskNotFound)
+				store.EXPECT().
+					ReviveOutboxTask(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+				store.EXPECT().
+					ResolveDeadLetterTask(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.RetryFailedTaskResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "NotBanker",
+			req:  &pb.RetryFailedTaskRequest{Queue: worker.EmailQueue, TaskId: taskID},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(depositor.Username)).
+					Times(1).
+					Return(depositor, nil)
+				inspector.EXPECT().
+					RunTask(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, depositor, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.RetryFailedTaskResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidTaskID",
+			req:  &pb.RetryFailedTaskRequest{Queue: worker.EmailQueue},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				inspector.EXPECT().
+					RunTask(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.RetryFailedTaskResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			inspector := mockwk.NewMockTaskInspector(ctrl)
+
+			tc.buildStubs(store, inspector)
+
+			server := newTestServer(t, store, nil)
+			server.taskInspector = inspector
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_RetryFailedTask_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.RetryFailedTask(ctx, req.(*pb.RetryFailedTaskRequest))
+			})
+			res, _ := out.(*pb.RetryFailedTaskResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/limits"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/kwalter26/udemy-simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) SetTransferLimits(context context.Context, req *pb.SetTransferLimitsRequest) (*pb.SetTransferLimitsResponse, error) {
+	payload, err := s.authorizeBanker(context)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateSetTransferLimitsRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	arg := db.SetTransferLimitTxParams{
+		Currency: req.GetCurrency(),
+		Audit:    s.auditContext(context, payload.Username),
+	}
+	switch req.GetScope() {
+	case limits.ScopeUser:
+		if _, err := s.store.GetUser(context, req.GetUsername()); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, status.Errorf(codes.NotFound, "user not found")
+			}
+			return nil, status.Errorf(codes.Internal, "failed to get user: %s", err)
+		}
+		arg.Username = req.GetUsername()
+	case limits.ScopeAccount:
+		account, err := s.store.GetAccount(context, req.GetAccountId())
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, status.Errorf(codes.NotFound, "account not found")
+			}
+			return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+		}
+		arg.AccountID = account.ID
+		arg.Currency = account.Currency
+	}
+	if req.Limits != nil {
+		l := transferLimitsFromPb(req.GetLimits())
+		arg.Limits = &l
+	}
+
+	result, err := s.store.SetTransferLimitTx(context, arg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to set transfer limits: %s", err)
+	}
+
+	rsp := &pb.SetTransferLimitsResponse{}
+	if result.Limit != nil {
+		rsp.Limit = transferLimitToPb(*result.Limit)
+	}
+	if result.Previous != nil {
+		rsp.Previous = transferLimitToPb(*result.Previous)
+	}
+	return rsp, nil
+}
+
+func validateSetTransferLimitsRequest(req *pb.SetTransferLimitsRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	switch req.GetScope() {
+	case limits.ScopeDefault, limits.ScopeUser:
+		if !util.IsSupportedCurrency(req.GetCurrency()) {
+			violations = append(violations, fieldViolation("currency", fmt.Errorf("unsupported currency %q", req.GetCurrency())))
+		}
+		if req.GetScope() == limits.ScopeUser {
+			if err := val.ValidateUsername(req.GetUsername()); err != nil {
+				violations = append(violations, fieldViolation("username", err))
+			}
+		}
+	case limits.ScopeAccount:
+		if req.GetAccountId() < 1 {
+			violations = append(violations, fieldViolation("account_id", fmt.Errorf("must be positive")))
+		}
+	default:
+		violations = append(violations, fieldViolation("scope", fmt.Errorf("must be %s, %s or %s", limits.ScopeDefault, limits.ScopeUser, limits.ScopeAccount)))
+	}
+	if req.Limits != nil {
+		if err := transferLimitsFromPb(req.GetLimits()).Validate(); err != nil {
+			violations = append(violations, fieldViolation("limits", err))
+		}
+	}
+	return violations
+}
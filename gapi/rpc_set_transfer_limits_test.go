@@ -0,0 +1,214 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/limits"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestSetTransferLimitsAPI(t *testing.T) {
+	banker, _ := createRandomUser(t)
+	banker.Role = util.BankerRole
+	depositor, _ := createRandomUser(t)
+	depositor.Role = util.DepositorRole
+	account := db.Account{ID: util.RandomInt(1, 1000), Owner: depositor.Username, Currency: util.EUR}
+	userLimit := db.TransferLimit{
+		ID:         7,
+		Currency:   util.USD,
+		Username:   sql.NullString{String: depositor.Username, Valid: true},
+		DailyMax:   500,
+		MonthlyMax: 2000,
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.SetTransferLimitsRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.SetTransferLimitsResponse, err error)
+	}{
+		{
+			name: "UserLimits",
+			req: &pb.SetTransferLimitsRequest{
+				Scope:    limits.ScopeUser,
+				Currency: util.USD,
+				Username: depositor.Username,
+				Limits:   &pb.TransferLimits{DailyMax: 500, MonthlyMax: 2000},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(banker.Username)).Times(1).Return(banker, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(depositor.Username)).Times(1).Return(depositor, nil)
+				store.EXPECT().
+					SetTransferLimitTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.SetTransferLimitTxParams) (db.SetTransferLimitTxResult, error) {
+						require.Equal(t, util.USD, arg.Currency)
+						require.Equal(t, depositor.Username, arg.Username)
+						require.Zero(t, arg.AccountID)
+						require.Equal(t, &limits.Limits{Daily: 500, Monthly: 2000}, arg.Limits)
+						require.Equal(t, banker.Username, arg.Audit.Actor)
+						return db.SetTransferLimitTxResult{Limit: &userLimit}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.SetTransferLimitsResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, userLimit.ID, res.Limit.Id)
+				require.Equal(t, limits.ScopeUser, res.Limit.Scope)
+				require.Equal(t, depositor.Username, res.Limit.Username)
+				require.Equal(t, int64(500), res.Limit.Limits.DailyMax)
+				require.Nil(t, res.Previous)
+			},
+		},
+		{
+			name: "RemoveAccountLimits",
+			req: &pb.SetTransferLimitsRequest{
+				Scope:     limits.ScopeAccount,
+				AccountId: account.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				previous := db.TransferLimit{
+					ID:        8,
+					Currency:  account.Currency,
+					AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+					DailyMax:  100,
+				}
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(banker.Username)).Times(1).Return(banker, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					SetTransferLimitTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.SetTransferLimitTxParams) (db.SetTransferLimitTxResult, error) {
+						require.Equal(t, account.Currency, arg.Currency)
+						require.Equal(t, account.ID, arg.AccountID)
+						require.Nil(t, arg.Limits)
+						return db.SetTransferLimitTxResult{Previous: &previous}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.SetTransferLimitsResponse, err error) {
+				require.NoError(t, err)
+				require.Nil(t, res.Limit)
+				require.Equal(t, limits.ScopeAccount, res.Previous.Scope)
+				require.Equal(t, account.ID, res.Previous.AccountId)
+			},
+		},
+		{
+			name: "UserNotFound",
+			req: &pb.SetTransferLimitsRequest{
+				Scope:    limits.ScopeUser,
+				Currency: util.USD,
+				Username: depositor.Username,
+				Limits:   &pb.TransferLimits{DailyMax: 500},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(banker.Username)).Times(1).Return(banker, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(depositor.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().SetTransferLimitTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.SetTransferLimitsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidArguments",
+			req: &pb.SetTransferLimitsRequest{
+				Scope:    limits.ScopeDefault,
+				Currency: "XYZ",
+				Limits:   &pb.TransferLimits{DailyMax: -1},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(banker.Username)).Times(1).Return(banker, nil)
+				store.EXPECT().SetTransferLimitTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.SetTransferLimitsResponse, err error) {
+				require.Nil(t, res)
+				st := status.Convert(err)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+				violations := st.Details()[0].(*errdetails.BadRequest).GetFieldViolations()
+				require.Len(t, violations, 2)
+			},
+		},
+		{
+			name: "InvalidScope",
+			req: &pb.SetTransferLimitsRequest{
+				Scope:    "global",
+				Currency: util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(banker.Username)).Times(1).Return(banker, nil)
+				store.EXPECT().SetTransferLimitTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.SetTransferLimitsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "NotBanker",
+			req: &pb.SetTransferLimitsRequest{
+				Scope:    limits.ScopeDefault,
+				Currency: util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(depositor.Username)).Times(1).Return(depositor, nil)
+				store.EXPECT().SetTransferLimitTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, depositor, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.SetTransferLimitsResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_SetTransferLimits_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.SetTransferLimits(ctx, req.(*pb.SetTransferLimitsRequest))
+			})
+			res, _ := out.(*pb.SetTransferLimitsResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package gapi
+
+import (
+	"database/sql"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+const (
+	accountEventsBatchSize = 100
+	// eventPositionsBatchSize is how many committed events get their position per poll
+	eventPositionsBatchSize = 1000
+)
+
+// SubscribeAccountEvents streams the domain events of the caller until the client goes away. Events are sent in
+// offset order; a client that reconnects with the offset of the last event it received misses nothing, since events
+// only get an offset once committed and in the order they got it.
+func (s *Server) SubscribeAccountEvents(req *pb.SubscribeAccountEventsRequest, stream pb.SimpleBank_SubscribeAccountEventsServer) error {
+	ctx := stream.Context()
+	payload, err := authenticatedUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if violations := validateSubscribeAccountEventsRequest(req); violations != nil {
+		return invalidArgumentError(violations)
+	}
+
+	arg := db.ListDomainEventsParams{
+		Owner:      payload.Username,
+		LimitCount: accountEventsBatchSize,
+	}
+
+	if req.AccountId != nil {
+		account, err := s.store.GetAccount(ctx, req.GetAccountId())
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return status.Errorf(codes.NotFound, "account not found")
+			}
+			return status.Errorf(codes.Internal, "failed to get account: %s", err)
+		}
+		if account.Owner != payload.Username {
+			return status.Errorf(codes.PermissionDenied, "account doesn't belong to the authenticated user")
+		}
+		arg.AccountID = sql.NullInt64{Int64: account.ID, Valid: true}
+	}
+
+	if req.FromOffset != nil {
+		arg.AfterOffset = req.GetFromOffset()
+	} else {
+		arg.AfterOffset, err = s.store.GetLatestDomainEventOffset(ctx)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to get latest event offset: %s", err)
+		}
+	}
+
+	ticker := time.NewTicker(s.config.EventStreamPollInterval())
+	defer ticker.Stop()
+
+	for {
+		_, err = s.store.AssignDomainEventPositionsTx(ctx, db.AssignDomainEventPositionsTxParams{Limit: eventPositionsBatchSize})
+		if err != nil {
+			if ctx.Err() != nil {
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			return status.Errorf(codes.Internal, "failed to assign event offsets: %s", err)
+		}
+
+		events, err := s.store.ListDomainEvents(ctx, arg)
+		if err != nil {
+			if ctx.Err() != nil {
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			return status.Errorf(codes.Internal, "failed to list events: %s", err)
+		}
+
+		for _, event := range events {
+			pbEvent, err := domainEventToPb(event)
+			if err != nil {
+				return status.Errorf(codes.Internal, "failed to convert event: %s", err)
+			}
+			if err = stream.Send(pbEvent); err != nil {
+				return err
+			}
+			arg.AfterOffset = event.Position.Int64
+		}
+
+		// a full batch means more events are waiting
+		if len(events) == accountEventsBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func validateSubscribeAccountEventsRequest(req *pb.SubscribeAccountEventsRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.AccountId != nil && req.GetAccountId() < 1 {
+		violations = append(violations, fieldViolation("account_id", fmt.Errorf("must be positive")))
+	}
+	if req.FromOffset != nil && req.GetFromOffset() < 0 {
+		violations = append(violations, fieldViolation("from_offset", fmt.Errorf("must not be negative")))
+	}
+	return violations
+}
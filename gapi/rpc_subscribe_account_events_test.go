@@ -0,0 +1,274 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"testing"
+	"time"
+)
+
+// accountEventsStream is the server side of a SubscribeAccountEvents call. It cancels its context once it sent
+// cancelAfter events.
+type accountEventsStream struct {
+	grpc.ServerStream
+	ctx         context.Context
+	cancel      context.CancelFunc
+	req         *pb.SubscribeAccountEventsRequest
+	events      []*pb.DomainEvent
+	cancelAfter int
+}
+
+func (stream *accountEventsStream) Context() context.Context {
+	return stream.ctx
+}
+
+func (stream *accountEventsStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(proto.Message), stream.req)
+	return nil
+}
+
+func (stream *accountEventsStream) SendMsg(m interface{}) error {
+	stream.events = append(stream.events, m.(*pb.DomainEvent))
+	if len(stream.events) == stream.cancelAfter {
+		stream.cancel()
+	}
+	return nil
+}
+
+func randomDomainEvent(t *testing.T, offset int64, owner string, eventType string, payload interface{}) db.DomainEvent {
+	jsonPayload, err := json.Marshal(payload)
+	require.NoError(t, err)
+	return db.DomainEvent{
+		ID:        util.RandomInt(1, 1000),
+		Position:  sql.NullInt64{Int64: offset, Valid: true},
+		Owner:     owner,
+		EventType: eventType,
+		Payload:   jsonPayload,
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestSubscribeAccountEventsAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	account := db.Account{ID: util.RandomInt(1, 1000), Owner: user.Username, Currency: util.USD}
+	otherAccount := db.Account{ID: account.ID + 1, Owner: util.RandomOwner(), Currency: util.USD}
+
+	opened := randomDomainEvent(t, 6, user.Username, db.DomainEventAccountOpened, db.AccountOpenedEvent{
+		AccountID: account.ID,
+		Owner:     user.Username,
+		Currency:  account.Currency,
+	})
+	changed := randomDomainEvent(t, 9, user.Username, db.DomainEventBalanceChanged, db.BalanceChangedEvent{
+		AccountID: account.ID,
+		EntryID:   3,
+		Currency:  account.Currency,
+		Amount:    -10,
+		Balance:   90,
+	})
+
+	testCases := []struct {
+		name          string
+		req           *pb.SubscribeAccountEventsRequest
+		cancelAfter   int
+		buildStubs    func(store *mockdb.MockStore, cancel context.CancelFunc)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, events []*pb.DomainEvent, err error)
+	}{
+		{
+			name:        "ResumeFromOffset",
+			req:         &pb.SubscribeAccountEventsRequest{FromOffset: proto.Int64(5)},
+			cancelAfter: 2,
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {
+				store.EXPECT().GetLatestDomainEventOffset(gomock.Any()).Times(0)
+				store.EXPECT().
+					AssignDomainEventPositionsTx(gomock.Any(), gomock.Eq(db.AssignDomainEventPositionsTxParams{Limit: eventPositionsBatchSize})).
+					MinTimes(1).
+					Return(db.AssignDomainEventPositionsTxResult{}, nil)
+				arg := db.ListDomainEventsParams{
+					Owner:       user.Username,
+					AfterOffset: 5,
+					LimitCount:  accountEventsBatchSize,
+				}
+				store.EXPECT().
+					ListDomainEvents(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.DomainEvent{opened, changed}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, events []*pb.DomainEvent, err error) {
+				require.Equal(t, codes.Canceled, status.Code(err))
+				require.Len(t, events, 2)
+				require.Equal(t, int64(6), events[0].Offset)
+				require.Equal(t, account.ID, events[0].GetAccountOpened().GetAccountId())
+				require.Equal(t, int64(9), events[1].Offset)
+				require.Equal(t, int64(-10), events[1].GetBalanceChanged().GetAmount())
+				require.Equal(t, int64(90), events[1].GetBalanceChanged().GetBalance())
+			},
+		},
+		{
+			name: "StartAtLatestOffset",
+			req:  &pb.SubscribeAccountEventsRequest{AccountId: proto.Int64(account.ID)},
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					GetLatestDomainEventOffset(gomock.Any()).
+					Times(1).
+					Return(int64(42), nil)
+				store.EXPECT().
+					AssignDomainEventPositionsTx(gomock.Any(), gomock.Eq(db.AssignDomainEventPositionsTxParams{Limit: eventPositionsBatchSize})).
+					MinTimes(1).
+					Return(db.AssignDomainEventPositionsTxResult{}, nil)
+				arg := db.ListDomainEventsParams{
+					Owner:       user.Username,
+					AfterOffset: 42,
+					AccountID:   sql.NullInt64{Int64: account.ID, Valid: true},
+					LimitCount:  accountEventsBatchSize,
+				}
+				store.EXPECT().
+					ListDomainEvents(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.ListDomainEventsParams) ([]db.DomainEvent, error) {
+						cancel()
+						return []db.DomainEvent{}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, events []*pb.DomainEvent, err error) {
+				require.Equal(t, codes.Canceled, status.Code(err))
+				require.Empty(t, events)
+			},
+		},
+		{
+			name: "AccountOfAnotherUser",
+			req:  &pb.SubscribeAccountEventsRequest{AccountId: proto.Int64(otherAccount.ID)},
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(otherAccount.ID)).
+					Times(1).
+					Return(otherAccount, nil)
+				store.EXPECT().ListDomainEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, events []*pb.DomainEvent, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "AccountNotFound",
+			req:  &pb.SubscribeAccountEventsRequest{AccountId: proto.Int64(account.ID)},
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, events []*pb.DomainEvent, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name:       "InvalidOffset",
+			req:        &pb.SubscribeAccountEventsRequest{FromOffset: proto.Int64(-1)},
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, events []*pb.DomainEvent, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name:       "Unauthenticated",
+			req:        &pb.SubscribeAccountEventsRequest{},
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, events []*pb.DomainEvent, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "AssignOffsetsError",
+			req:  &pb.SubscribeAccountEventsRequest{FromOffset: proto.Int64(0)},
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {
+				store.EXPECT().
+					AssignDomainEventPositionsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AssignDomainEventPositionsTxResult{}, sql.ErrConnDone)
+				store.EXPECT().ListDomainEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, events []*pb.DomainEvent, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.SubscribeAccountEventsRequest{FromOffset: proto.Int64(0)},
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {
+				store.EXPECT().
+					AssignDomainEventPositionsTx(gomock.Any(), gomock.Eq(db.AssignDomainEventPositionsTxParams{Limit: eventPositionsBatchSize})).
+					MinTimes(1).
+					Return(db.AssignDomainEventPositionsTxResult{}, nil)
+				store.EXPECT().
+					ListDomainEvents(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, events []*pb.DomainEvent, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			server := newTestServer(t, store, nil)
+
+			ctx, cancel := context.WithCancel(tc.buildContext(t, server.tokenMaker))
+			defer cancel()
+			tc.buildStubs(store, cancel)
+
+			stream := &accountEventsStream{ctx: ctx, cancel: cancel, req: tc.req, cancelAfter: tc.cancelAfter}
+			info := &grpc.StreamServerInfo{FullMethod: pb.SimpleBank_SubscribeAccountEvents_FullMethodName, IsServerStream: true}
+			err := server.StreamAuthInterceptor(server, stream, info, pb.SimpleBank_ServiceDesc.Streams[0].Handler)
+			tc.checkResponse(t, stream.events, err)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package gapi
+
+import (
+	"context"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) UnlockUser(context context.Context, req *pb.UnlockUserRequest) (*pb.UnlockUserResponse, error) {
+	if _, err := s.authorizeBanker(context); err != nil {
+		return nil, err
+	}
+
+	if violations := validateUnlockUserRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	err := s.unlockLogin(context, req.GetUsername())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to unlock user: %s", err)
+	}
+
+	rsp := &pb.UnlockUserResponse{
+		Username: req.GetUsername(),
+	}
+	return rsp, nil
+}
+
+func validateUnlockUserRequest(req *pb.UnlockUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err))
+	}
+	return violations
+}
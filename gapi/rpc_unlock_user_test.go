@@ -0,0 +1,175 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestUnlockUserAPI(t *testing.T) {
+	banker, _ := createRandomUser(t)
+	banker.Role = util.BankerRole
+	depositor, _ := createRandomUser(t)
+	depositor.Role = util.DepositorRole
+	lockedUser, _ := createRandomUser(t)
+
+	testCases := []struct {
+		name          string
+		req           *pb.UnlockUserRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.UnlockUserResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.UnlockUserRequest{
+				Username: lockedUser.Username,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				store.EXPECT().
+					ResetLoginLockout(gomock.Any(), gomock.Eq(db.ResetLoginLockoutParams{Kind: lockoutKindUsername, Key: lockedUser.Username})).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					ListLoginFailureClientIps(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ListLoginFailureClientIpsParams) ([]string, error) {
+						require.Equal(t, "user:"+lockedUser.Username, arg.Target)
+						require.Equal(t, db.AuditActionLoginFailed, arg.Action)
+						require.WithinDuration(t, time.Now().Add(-24*time.Hour), arg.Since, time.Second)
+						return []string{"10.0.0.1:54321", "10.0.0.1", "10.0.0.2"}, nil
+					})
+				store.EXPECT().
+					ResetLoginLockout(gomock.Any(), gomock.Eq(db.ResetLoginLockoutParams{Kind: lockoutKindClientIp, Key: "10.0.0.1"})).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					ResetLoginLockout(gomock.Any(), gomock.Eq(db.ResetLoginLockoutParams{Kind: lockoutKindClientIp, Key: "10.0.0.2"})).
+					Times(1).
+					Return(nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UnlockUserResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, lockedUser.Username, res.Username)
+			},
+		},
+		{
+			name: "NotBanker",
+			req: &pb.UnlockUserRequest{
+				Username: lockedUser.Username,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(depositor.Username)).
+					Times(1).
+					Return(depositor, nil)
+				store.EXPECT().
+					ResetLoginLockout(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, depositor, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UnlockUserResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "Unauthenticated",
+			req: &pb.UnlockUserRequest{
+				Username: lockedUser.Username,
+			},
+			buildStubs: func(store *mockdb.MockStore) {},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.UnlockUserResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidUsername",
+			req: &pb.UnlockUserRequest{
+				Username: "invalid-user#",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UnlockUserResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InternalError",
+			req: &pb.UnlockUserRequest{
+				Username: lockedUser.Username,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(banker.Username)).
+					Times(1).
+					Return(banker, nil)
+				store.EXPECT().
+					ResetLoginLockout(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, banker, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UnlockUserResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.Internal, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_UnlockUser_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.UnlockUser(ctx, req.(*pb.UnlockUserRequest))
+			})
+			res, _ := out.(*pb.UnlockUserResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
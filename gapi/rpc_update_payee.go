@@ -0,0 +1,70 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/lib/pq"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) UpdatePayee(context context.Context, req *pb.UpdatePayeeRequest) (*pb.UpdatePayeeResponse, error) {
+	payload, err := authenticatedUser(context)
+	if err != nil {
+		return nil, err
+	}
+
+	if violations := validateUpdatePayeeRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	if _, err := s.ownedPayee(context, payload.Username, req.GetId()); err != nil {
+		return nil, err
+	}
+
+	payee, err := s.store.UpdatePayee(context, db.UpdatePayeeParams{
+		ID:       req.GetId(),
+		Nickname: req.GetNickname(),
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return nil, status.Errorf(codes.AlreadyExists, "a payee with this nickname already exists")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update payee: %s", err)
+	}
+
+	rsp := &pb.UpdatePayeeResponse{
+		Payee: payeeToPb(payee),
+	}
+	return rsp, nil
+}
+
+// ownedPayee returns the payee when it belongs to username. The returned error is already a gRPC status.
+func (s *Server) ownedPayee(ctx context.Context, username string, id int64) (db.Payee, error) {
+	payee, err := s.store.GetPayee(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return payee, status.Errorf(codes.NotFound, "payee not found")
+		}
+		return payee, status.Errorf(codes.Internal, "failed to get payee: %s", err)
+	}
+	if payee.Owner != username {
+		return payee, status.Errorf(codes.PermissionDenied, "payee doesn't belong to the authenticated user")
+	}
+	return payee, nil
+}
+
+func validateUpdatePayeeRequest(req *pb.UpdatePayeeRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetId() < 1 {
+		violations = append(violations, fieldViolation("id", fmt.Errorf("must be positive")))
+	}
+	if err := validatePayeeNickname(req.GetNickname()); err != nil {
+		violations = append(violations, fieldViolation("nickname", err))
+	}
+	return violations
+}
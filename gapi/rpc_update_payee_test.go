@@ -0,0 +1,136 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/token"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestUpdatePayeeAPI(t *testing.T) {
+	user, _ := createRandomUser(t)
+	payee := db.Payee{ID: util.RandomInt(1, 1000), Owner: user.Username, Nickname: "landlord", AccountID: 10, Currency: util.USD}
+
+	testCases := []struct {
+		name          string
+		req           *pb.UpdatePayeeRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.UpdatePayeeResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.UpdatePayeeRequest{Id: payee.ID, Nickname: "old landlord"},
+			buildStubs: func(store *mockdb.MockStore) {
+				renamed := payee
+				renamed.Nickname = "old landlord"
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(payee, nil)
+				store.EXPECT().
+					UpdatePayee(gomock.Any(), gomock.Eq(db.UpdatePayeeParams{ID: payee.ID, Nickname: "old landlord"})).
+					Times(1).
+					Return(renamed, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdatePayeeResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "old landlord", res.Payee.Nickname)
+				require.Equal(t, payee.AccountID, res.Payee.AccountId)
+			},
+		},
+		{
+			name: "NotOwner",
+			req:  &pb.UpdatePayeeRequest{Id: payee.ID, Nickname: "mine"},
+			buildStubs: func(store *mockdb.MockStore) {
+				other := payee
+				other.Owner = util.RandomOwner()
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(other, nil)
+				store.EXPECT().UpdatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdatePayeeResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "NotFound",
+			req:  &pb.UpdatePayeeRequest{Id: payee.ID, Nickname: "mine"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(db.Payee{}, sql.ErrNoRows)
+				store.EXPECT().UpdatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdatePayeeResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "DuplicateNickname",
+			req:  &pb.UpdatePayeeRequest{Id: payee.ID, Nickname: "friend"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(payee, nil)
+				store.EXPECT().UpdatePayee(gomock.Any(), gomock.Any()).Times(1).Return(db.Payee{}, &pq.Error{Code: "23505"})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdatePayeeResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.AlreadyExists, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidNickname",
+			req:  &pb.UpdatePayeeRequest{Id: payee.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return getAuthCtx(t, tokenMaker, user, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdatePayeeResponse, err error) {
+				require.Nil(t, res)
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			ctx := tc.buildContext(t, server.tokenMaker)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_UpdatePayee_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.UpdatePayee(ctx, req.(*pb.UpdatePayeeRequest))
+			})
+			res, _ := out.(*pb.UpdatePayeeResponse)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
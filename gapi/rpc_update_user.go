@@ -5,7 +5,6 @@ import (
 	"database/sql"
 	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
 	"github.com/kwalter26/udemy-simplebank/pb"
-	"github.com/kwalter26/udemy-simplebank/util"
 	"github.com/kwalter26/udemy-simplebank/val"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
@@ -15,12 +14,12 @@ import (
 
 func (s *Server) UpdateUser(context context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
 
-	authPayload, err := s.authorizeUser(context)
+	authPayload, err := authenticatedUser(context)
 	if err != nil {
-		return nil, unauthenticatedError(err)
+		return nil, err
 	}
 
-	if violations := validateUpdateUserRequest(req); violations != nil {
+	if violations := validateUpdateUserRequest(req, s.passwordPolicy); violations != nil {
 		return nil, invalidArgumentError(violations)
 	}
 
@@ -28,20 +27,23 @@ func (s *Server) UpdateUser(context context.Context, req *pb.UpdateUserRequest)
 		return nil, status.Error(codes.PermissionDenied, "cannot update other user's profile")
 	}
 
-	arg := db.UpdateUserParams{
-		Username: req.GetUsername(),
-		FullName: sql.NullString{
-			String: req.GetFullName(),
-			Valid:  req.FullName != nil,
-		},
-		Email: sql.NullString{
-			String: req.GetEmail(),
-			Valid:  req.Email != nil,
+	arg := db.UpdateUserTxParams{
+		UpdateUserParams: db.UpdateUserParams{
+			Username: req.GetUsername(),
+			FullName: sql.NullString{
+				String: req.GetFullName(),
+				Valid:  req.FullName != nil,
+			},
+			Email: sql.NullString{
+				String: req.GetEmail(),
+				Valid:  req.Email != nil,
+			},
 		},
+		Audit: s.auditContext(context, authPayload.Username),
 	}
 
 	if req.Password != nil {
-		hashedPassword, err := util.HashPassword(req.GetPassword())
+		hashedPassword, err := s.passwordHasher.Hash(req.GetPassword())
 		if err != nil {
 			return nil, status.Errorf(codes.Internal, "failed to hash password: %s", err)
 		}
@@ -55,7 +57,7 @@ func (s *Server) UpdateUser(context context.Context, req *pb.UpdateUserRequest)
 		}
 	}
 
-	user, err := s.store.UpdateUser(context, arg)
+	result, err := s.store.UpdateUserTx(context, arg)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, status.Error(codes.NotFound, "user not found")
@@ -64,17 +66,17 @@ func (s *Server) UpdateUser(context context.Context, req *pb.UpdateUserRequest)
 	}
 
 	response := &pb.UpdateUserResponse{
-		User: userToPb(user),
+		User: userToPb(result.User),
 	}
 	return response, nil
 }
 
-func validateUpdateUserRequest(req *pb.UpdateUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+func validateUpdateUserRequest(req *pb.UpdateUserRequest, passwordPolicy val.PasswordPolicy) (violations []*errdetails.BadRequest_FieldViolation) {
 	if err := val.ValidateUsername(req.GetUsername()); err != nil {
 		violations = append(violations, fieldViolation("username", err))
 	}
 	if req.Password != nil {
-		if err := val.ValidatePassword(req.GetPassword()); err != nil {
+		if err := passwordPolicy.Validate(req.GetPassword()); err != nil {
 			violations = append(violations, fieldViolation("password", err))
 		}
 	}
@@ -11,6 +11,7 @@ import (
 	"github.com/kwalter26/udemy-simplebank/token"
 	"github.com/kwalter26/udemy-simplebank/util"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 	"testing"
 	"time"
@@ -58,9 +59,12 @@ func TestUpdateUserAPI(t *testing.T) {
 				updatedUser.FullName = newName
 				updatedUser.Email = newEmail
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Eq(arg)).
+					UpdateUserTx(gomock.Any(), gomock.Eq(db.UpdateUserTxParams{
+						UpdateUserParams: arg,
+						Audit:            db.AuditContext{Actor: user.Username},
+					})).
 					Times(1).
-					Return(updatedUser, nil)
+					Return(db.UpdateUserTxResult{User: updatedUser}, nil)
 			},
 			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
 				accessToken, _, err := tokenMaker.CreateToken(user.Username, time.Minute)
@@ -190,7 +194,7 @@ func TestUpdateUserAPI(t *testing.T) {
 				Email:    &newEmail,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Return(db.UpdateUserTxResult{}, sql.ErrNoRows)
 			},
 			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
 				return getAuthCtx(t, tokenMaker, user, time.Minute)
@@ -209,7 +213,7 @@ func TestUpdateUserAPI(t *testing.T) {
 				Email:    &newEmail,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Return(db.User{}, sql.ErrConnDone)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Return(db.UpdateUserTxResult{}, sql.ErrConnDone)
 			},
 			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
 				return getAuthCtx(t, tokenMaker, user, time.Minute)
@@ -242,7 +246,7 @@ func TestUpdateUserAPI(t *testing.T) {
 				Password: &newPassword,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Return(user, nil)
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Return(db.UpdateUserTxResult{User: user}, nil)
 			},
 			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
 				return getAuthCtx(t, tokenMaker, user, time.Minute)
@@ -269,7 +273,11 @@ func TestUpdateUserAPI(t *testing.T) {
 			server := newTestServer(t, store, nil)
 
 			ctx := tc.buildContext(t, server.tokenMaker)
-			res, err := server.UpdateUser(ctx, tc.req)
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_UpdateUser_FullMethodName}
+			out, err := server.AuthInterceptor(ctx, tc.req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return server.UpdateUser(ctx, req.(*pb.UpdateUserRequest))
+			})
+			res, _ := out.(*pb.UpdateUserResponse)
 			tc.checkResponse(t, res, err)
 		})
 	}
@@ -2,11 +2,15 @@ package gapi
 
 import (
 	"fmt"
+	"github.com/kwalter26/udemy-simplebank/blob"
 	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
 	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/ratelimit"
 	"github.com/kwalter26/udemy-simplebank/token"
 	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/kwalter26/udemy-simplebank/val"
 	"github.com/kwalter26/udemy-simplebank/worker"
+	"net"
 )
 
 type Server struct {
@@ -15,20 +19,49 @@ type Server struct {
 	store           db.Store
 	tokenMaker      token.Maker
 	taskDistributor worker.TaskDistributor
+	taskInspector   worker.TaskInspector
+	blobs           blob.Store
+	passwordHasher  util.PasswordHasher
+	dummyPassword   *util.DummyPassword
+	passwordPolicy  val.PasswordPolicy
+	rateLimits      ratelimit.Limits
+	trustedProxies  []*net.IPNet
 }
 
 // NewServer Creates a new gRPC server
-func NewServer(config util.Config, store db.Store, taskDistributor worker.TaskDistributor) (*Server, error) {
+func NewServer(config util.Config, store db.Store, taskDistributor worker.TaskDistributor, taskInspector worker.TaskInspector, blobs blob.Store) (*Server, error) {
 	maker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maketer: %w", err)
 	}
 
+	hasher, err := util.NewPasswordHasher(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create password hasher: %w", err)
+	}
+
+	rateLimits, err := ratelimit.ParseLimits(config.RateLimits)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse rate limits: %w", err)
+	}
+
+	trustedProxies, err := parseTrustedProxies(config.TrustedProxyList())
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse trusted proxies: %w", err)
+	}
+
 	server := &Server{
 		store:           store,
 		tokenMaker:      maker,
 		config:          config,
 		taskDistributor: taskDistributor,
+		taskInspector:   taskInspector,
+		blobs:           blobs,
+		passwordHasher:  hasher,
+		dummyPassword:   util.NewDummyPassword(hasher),
+		passwordPolicy:  val.NewPasswordPolicy(config),
+		rateLimits:      rateLimits,
+		trustedProxies:  trustedProxies,
 	}
 
 	return server, nil
@@ -0,0 +1,17 @@
+package gapi
+
+import (
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"net/http"
+)
+
+// HttpTracer starts a span for every gateway request. The gateway calls the server in-process, so this span is the
+// parent of everything the handler does, and it is named after the gRPC method like the spans of gRPC calls.
+func HttpTracer(handler http.Handler) http.Handler {
+	return otelhttp.NewHandler(handler, "gateway", otelhttp.WithSpanNameFormatter(func(_ string, req *http.Request) string {
+		if method, ok := gatewayRouteMethod(req); ok {
+			return method
+		}
+		return req.Method + " " + otherHttpMethod
+	}))
+}
@@ -0,0 +1,33 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// MigrationCheck makes sure the database schema is not dirty and at least at the expected golang-migrate version.
+// A schema ahead of it is fine, like for migration.Prepare: during a rolling deploy the instances of the previous
+// release keep serving once the new release has migrated forward.
+func MigrationCheck(conn *sql.DB, expectedVersion uint) CheckFunc {
+	return func(ctx context.Context) (string, error) {
+		var version uint
+		var dirty bool
+		err := conn.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+		if err != nil {
+			return "", fmt.Errorf("cannot read migration version: %w", err)
+		}
+		return checkMigrationVersion(version, dirty, expectedVersion)
+	}
+}
+
+func checkMigrationVersion(version uint, dirty bool, expectedVersion uint) (string, error) {
+	detail := fmt.Sprintf("version %d", version)
+	if dirty {
+		return detail, fmt.Errorf("migration %d is dirty", version)
+	}
+	if version < expectedVersion {
+		return detail, fmt.Errorf("expected migration version %d or later", expectedVersion)
+	}
+	return detail, nil
+}
@@ -0,0 +1,41 @@
+package health
+
+import (
+	"context"
+	"github.com/rs/zerolog/log"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"time"
+)
+
+const defaultWatchInterval = 10 * time.Second
+
+// Watch runs the checks every interval and publishes the result on the grpc.health.v1 server, for the overall
+// server ("") and every given service, until the context is done.
+func Watch(ctx context.Context, checker *Checker, server *grpchealth.Server, interval time.Duration, services ...string) {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report := checker.Check(ctx)
+		status := healthpb.HealthCheckResponse_SERVING
+		if !report.Ready() {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+			log.Warn().Interface("checks", report.Checks).Msg("service is not ready")
+		}
+
+		server.SetServingStatus("", status)
+		for _, service := range services {
+			server.SetServingStatus(service, status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded"
+
+	defaultTimeout = 2 * time.Second
+)
+
+// CheckFunc checks a single dependency. The detail is reported as is, for example the migration version.
+type CheckFunc func(ctx context.Context) (detail string, err error)
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Checker runs the dependency checks behind the readiness endpoints.
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+}
+
+// CheckResult is the status of a single dependency.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Detail   string `json:"detail,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the status of every dependency. The service is down when a critical dependency is down, and degraded
+// when only non-critical ones are.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// NewChecker creates a checker that gives every check the timeout, or a default one if it is zero.
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a check. The service is not ready while a critical check fails.
+func (c *Checker) Add(name string, critical bool, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Check runs all checks concurrently, each with its own timeout.
+func (c *Checker) Check(ctx context.Context) Report {
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(c.checks))}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, chk := range c.checks {
+		wg.Add(1)
+		go func(chk check) {
+			defer wg.Done()
+			result := c.run(ctx, chk)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Checks[chk.name] = result
+			if result.Status == StatusDown {
+				if chk.critical {
+					report.Status = StatusDown
+				} else if report.Status == StatusUp {
+					report.Status = StatusDegraded
+				}
+			}
+		}(chk)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, chk check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	startTime := time.Now()
+	detail, err := chk.fn(ctx)
+	result := CheckResult{
+		Status:   StatusUp,
+		Critical: chk.critical,
+		Detail:   detail,
+		Duration: time.Since(startTime).String(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Ready reports whether no critical check is failing.
+func (r Report) Ready() bool {
+	return r.Status != StatusDown
+}
+
+// LivenessHandler reports that the process is running. It never looks at dependencies, so an outage of the
+// database doesn't get every instance restarted.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		writeJSON(res, http.StatusOK, Report{Status: StatusUp})
+	})
+}
+
+// PublicReport is the status of every dependency shown to unauthenticated callers. It leaves out the details and
+// errors of the checks, which can name hosts and ports.
+type PublicReport struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// Public returns the status of the report and of each of its checks.
+func (r Report) Public() PublicReport {
+	public := PublicReport{Status: r.Status, Checks: make(map[string]string, len(r.Checks))}
+	for name, result := range r.Checks {
+		public.Checks[name] = result.Status
+	}
+	return public
+}
+
+// ReadinessHandler reports the status of every dependency, with 503 while a critical one is down. The errors of
+// failing checks are logged, not returned.
+func ReadinessHandler(checker *Checker) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		report := checker.Check(req.Context())
+		statusCode := http.StatusOK
+		if !report.Ready() {
+			statusCode = http.StatusServiceUnavailable
+		}
+		for name, result := range report.Checks {
+			if result.Error != "" {
+				log.Warn().Str("check", name).Bool("critical", result.Critical).Str("error", result.Error).Msg("readiness check failed")
+			}
+		}
+		writeJSON(res, statusCode, report.Public())
+	})
+}
+
+func writeJSON(res http.ResponseWriter, statusCode int, body interface{}) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", "no-store")
+	res.WriteHeader(statusCode)
+	_ = json.NewEncoder(res).Encode(body)
+}
@@ -0,0 +1,126 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func up(context.Context) (string, error) {
+	return "", nil
+}
+
+func down(context.Context) (string, error) {
+	return "", errors.New("connection refused")
+}
+
+func TestChecker(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mail     CheckFunc
+		postgres CheckFunc
+		status   string
+	}{
+		{name: "Up", postgres: up, mail: up, status: StatusUp},
+		{name: "NonCriticalDown", postgres: up, mail: down, status: StatusDegraded},
+		{name: "CriticalDown", postgres: down, mail: down, status: StatusDown},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			checker := NewChecker(time.Second)
+			checker.Add("postgres", true, tc.postgres)
+			checker.Add("mail", false, tc.mail)
+
+			report := checker.Check(context.Background())
+			require.Equal(t, tc.status, report.Status)
+			require.Equal(t, tc.status != StatusDown, report.Ready())
+			require.Len(t, report.Checks, 2)
+			require.True(t, report.Checks["postgres"].Critical)
+		})
+	}
+}
+
+func TestCheckerTimeout(t *testing.T) {
+	checker := NewChecker(10 * time.Millisecond)
+	checker.Add("slow", true, func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	report := checker.Check(context.Background())
+	require.Equal(t, StatusDown, report.Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+}
+
+func TestHandlers(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Add("postgres", true, down)
+
+	recorder := httptest.NewRecorder()
+	LivenessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	ReadinessHandler(checker).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	require.NotContains(t, recorder.Body.String(), "connection refused")
+	var report PublicReport
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&report))
+	require.Equal(t, StatusDown, report.Status)
+	require.Equal(t, map[string]string{"postgres": StatusDown}, report.Checks)
+}
+
+func TestWatch(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Add("postgres", true, down)
+	server := grpchealth.NewServer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	Watch(ctx, checker, server, time.Second, "pb.SimpleBank")
+
+	res, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "pb.SimpleBank"})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, res.Status)
+}
+
+func TestCheckMigrationVersion(t *testing.T) {
+	testCases := []struct {
+		name    string
+		version uint
+		dirty   bool
+		ok      bool
+	}{
+		{name: "Expected", version: 20, ok: true},
+		{name: "Ahead", version: 21, ok: true},
+		{name: "Behind", version: 19, ok: false},
+		{name: "Dirty", version: 20, dirty: true, ok: false},
+		{name: "DirtyAhead", version: 21, dirty: true, ok: false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			detail, err := checkMigrationVersion(tc.version, tc.dirty, 20)
+			require.Equal(t, fmt.Sprintf("version %d", tc.version), detail)
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// Package interest computes the interest earned by savings accounts. Interest is accrued every day in millionths
+// of the currency unit (micros), so that a day of interest on a small balance is not lost, and is rounded to
+// whole units once a month when it is posted.
+package interest
+
+import (
+	"math/big"
+	"time"
+)
+
+// Compounding rules of an account product
+const (
+	// CompoundingDaily makes the interest accrued and not posted yet earn interest the following days.
+	CompoundingDaily = "daily"
+	// CompoundingMonthly makes interest earn interest only once it is posted to the balance.
+	CompoundingMonthly = "monthly"
+)
+
+// MicrosPerUnit is the number of micros in a currency unit.
+const MicrosPerUnit = 1_000_000
+
+// basisPoints is the number of basis points in 100%.
+const basisPoints = 10_000
+
+// IsSupportedCompounding reports whether compounding is one of the compounding rules.
+func IsSupportedCompounding(compounding string) bool {
+	return compounding == CompoundingDaily || compounding == CompoundingMonthly
+}
+
+// Day returns the day in UTC that t falls in, at midnight.
+func Day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// IsLastDayOfMonth reports whether day is the last day of its month, when the interest of the month is posted.
+func IsLastDayOfMonth(day time.Time) bool {
+	return Day(day).AddDate(0, 0, 1).Day() == 1
+}
+
+// DaysInYear returns 366 for leap years and 365 otherwise. A day earns 1/366 of the yearly rate in a leap year.
+func DaysInYear(year int) int {
+	if time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay() == 366 {
+		return 366
+	}
+	return 365
+}
+
+// Daily returns the interest in micros that balance, the balance at the end of day, earns for that day at an
+// annual rate in basis points. With daily compounding the pending micros, accrued on earlier days and not posted
+// yet, earn interest too. A balance that is not positive earns nothing. The result is rounded half to even.
+func Daily(balance int64, pendingMicros int64, annualRateBps int32, compounding string, day time.Time) int64 {
+	if balance <= 0 || annualRateBps <= 0 {
+		return 0
+	}
+
+	principal := new(big.Int).Mul(big.NewInt(balance), big.NewInt(MicrosPerUnit))
+	if compounding == CompoundingDaily {
+		principal.Add(principal, big.NewInt(pendingMicros))
+	}
+	numerator := principal.Mul(principal, big.NewInt(int64(annualRateBps)))
+	denominator := big.NewInt(int64(basisPoints * DaysInYear(day.UTC().Year())))
+	return divRoundHalfEven(numerator, denominator)
+}
+
+// Round converts micros to whole currency units, rounding half to even.
+func Round(micros int64) int64 {
+	return divRoundHalfEven(big.NewInt(micros), big.NewInt(MicrosPerUnit))
+}
+
+// divRoundHalfEven divides n by the positive d and rounds the quotient half to even.
+func divRoundHalfEven(n *big.Int, d *big.Int) int64 {
+	quotient, remainder := new(big.Int).QuoRem(n, d, new(big.Int))
+	twice := new(big.Int).Abs(remainder)
+	twice.Lsh(twice, 1)
+
+	cmp := twice.Cmp(d)
+	if cmp > 0 || (cmp == 0 && quotient.Bit(0) == 1) {
+		// round away from zero, in the direction of the remainder
+		quotient.Add(quotient, big.NewInt(int64(remainder.Sign())))
+	}
+	return quotient.Int64()
+}
@@ -0,0 +1,92 @@
+package interest
+
+import (
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDaysInYear(t *testing.T) {
+	require.Equal(t, 365, DaysInYear(2023))
+	require.Equal(t, 366, DaysInYear(2024))
+	require.Equal(t, 365, DaysInYear(2100))
+	require.Equal(t, 366, DaysInYear(2000))
+}
+
+func TestDaily(t *testing.T) {
+	// 10% of 3650 over a common year is exactly 1 a day
+	require.Equal(t, int64(MicrosPerUnit), Daily(3650, 0, 1000, CompoundingMonthly, day(2023, time.March, 1)))
+	// the same balance earns 1/366 of the rate on any day of a leap year, not only on February 29
+	require.Equal(t, int64(997268), Daily(3650, 0, 1000, CompoundingMonthly, day(2024, time.February, 29)))
+	require.Equal(t, int64(997268), Daily(3650, 0, 1000, CompoundingMonthly, day(2024, time.March, 1)))
+
+	// pending interest only earns interest with daily compounding
+	require.Equal(t, int64(MicrosPerUnit), Daily(3650, 5*MicrosPerUnit, 1000, CompoundingMonthly, day(2023, time.March, 1)))
+	require.Equal(t, int64(1001370), Daily(3650, 5*MicrosPerUnit, 1000, CompoundingDaily, day(2023, time.March, 1)))
+
+	require.Zero(t, Daily(0, 0, 1000, CompoundingDaily, day(2023, time.March, 1)))
+	require.Zero(t, Daily(-3650, 0, 1000, CompoundingDaily, day(2023, time.March, 1)))
+	require.Zero(t, Daily(3650, 0, 0, CompoundingDaily, day(2023, time.March, 1)))
+
+	// large balances do not overflow
+	require.Equal(t, int64(25_000_000_000_000_000), Daily(365_000_000_000_000, 0, 250, CompoundingMonthly, day(2023, time.March, 1)))
+}
+
+func TestDivRoundHalfEven(t *testing.T) {
+	for _, tc := range []struct {
+		n, d, want int64
+	}{
+		{5, 2, 2},
+		{7, 2, 4},
+		{-5, 2, -2},
+		{-7, 2, -4},
+		{7, 3, 2},
+		{8, 3, 3},
+		{-8, 3, -3},
+	} {
+		require.Equal(t, tc.want, divRoundHalfEven(big.NewInt(tc.n), big.NewInt(tc.d)), "%d/%d", tc.n, tc.d)
+	}
+}
+
+func TestRound(t *testing.T) {
+	require.Equal(t, int64(0), Round(499_999))
+	require.Equal(t, int64(0), Round(500_000))
+	require.Equal(t, int64(2), Round(1_500_000))
+	require.Equal(t, int64(2), Round(2_500_000))
+	require.Equal(t, int64(3), Round(2_500_001))
+	require.Equal(t, int64(-2), Round(-2_500_000))
+	require.Equal(t, int64(-3), Round(-2_700_000))
+}
+
+// TestAccrueYear accrues every day of a year at 5% on 1000 with monthly posting, the way the worker does, and
+// checks that a leap year pays a whole year of interest too.
+func TestAccrueYear(t *testing.T) {
+	for _, year := range []int{2023, 2024} {
+		balance := int64(1_000_000)
+		var pending, posted int64
+		for d := day(year, time.January, 1); d.Year() == year; d = d.AddDate(0, 0, 1) {
+			pending += Daily(balance, pending, 500, CompoundingMonthly, d)
+			if IsLastDayOfMonth(d) {
+				amount := Round(pending)
+				balance += amount
+				posted += amount
+				pending = 0
+			}
+		}
+		// 5% compounded monthly is 5.116%
+		require.InDelta(t, 51_162, posted, 2, "year %d", year)
+	}
+}
+
+func TestIsLastDayOfMonth(t *testing.T) {
+	require.True(t, IsLastDayOfMonth(day(2024, time.February, 29)))
+	require.False(t, IsLastDayOfMonth(day(2024, time.February, 28)))
+	require.True(t, IsLastDayOfMonth(day(2023, time.February, 28)))
+	require.True(t, IsLastDayOfMonth(time.Date(2023, time.December, 31, 23, 0, 0, 0, time.UTC)))
+	require.Equal(t, day(2023, time.March, 1), Day(time.Date(2023, time.March, 1, 1, 30, 0, 0, time.FixedZone("CET", 3600))))
+}
@@ -0,0 +1,109 @@
+// Package limits checks transfers against the outgoing limits of a user and of an account: a maximum per
+// transfer, and a maximum per calendar day and per calendar month in UTC. A limit of 0 means no limit.
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Scopes of a limit
+const (
+	// ScopeDefault limits the users of a currency that have no limits of their own.
+	ScopeDefault = "default"
+	// ScopeUser limits all the accounts of a user in a currency together, in place of the defaults.
+	ScopeUser = "user"
+	// ScopeAccount limits one account, on top of the limits of its owner.
+	ScopeAccount = "account"
+)
+
+// Windows a limit applies to
+const (
+	WindowTransfer = "transfer"
+	WindowDaily    = "daily"
+	WindowMonthly  = "monthly"
+)
+
+// Limits are the maximum amounts of outgoing transfers. A maximum of 0 means no limit.
+type Limits struct {
+	PerTransfer int64 `json:"per_transfer_max"`
+	Daily       int64 `json:"daily_max"`
+	Monthly     int64 `json:"monthly_max"`
+}
+
+// Usage is the amount already transferred out today and this month.
+type Usage struct {
+	Daily   int64 `json:"daily"`
+	Monthly int64 `json:"monthly"`
+}
+
+// ErrLimitExceeded is returned when a transfer would exceed a limit. Remaining is what can still be transferred in
+// the window, the maximum amount itself for the per transfer limit.
+type ErrLimitExceeded struct {
+	Scope     string
+	Window    string
+	Limit     int64
+	Remaining int64
+}
+
+func (e *ErrLimitExceeded) Error() string {
+	if e.Window == WindowTransfer {
+		return fmt.Sprintf("%s limit of %d per transfer exceeded", e.Scope, e.Limit)
+	}
+	return fmt.Sprintf("%s %s limit of %d exceeded, %d remaining", e.Scope, e.Window, e.Limit, e.Remaining)
+}
+
+// Validate checks that no maximum is negative.
+func (l Limits) Validate() error {
+	if l.PerTransfer < 0 || l.Daily < 0 || l.Monthly < 0 {
+		return errors.New("limits cannot be negative, use 0 for no limit")
+	}
+	return nil
+}
+
+// Remaining returns what can still be transferred under a maximum once used is transferred, and false when there
+// is no maximum.
+func Remaining(max int64, used int64) (int64, bool) {
+	if max == 0 {
+		return 0, false
+	}
+	if used >= max {
+		return 0, true
+	}
+	return max - used, true
+}
+
+// Check returns an *ErrLimitExceeded when transferring amount on top of usage exceeds one of the limits, checking
+// the per transfer limit first, then the daily and the monthly ones.
+func Check(scope string, l Limits, usage Usage, amount int64) error {
+	if l.PerTransfer > 0 && amount > l.PerTransfer {
+		return &ErrLimitExceeded{Scope: scope, Window: WindowTransfer, Limit: l.PerTransfer, Remaining: l.PerTransfer}
+	}
+	windows := []struct {
+		name string
+		max  int64
+		used int64
+	}{
+		{WindowDaily, l.Daily, usage.Daily},
+		{WindowMonthly, l.Monthly, usage.Monthly},
+	}
+	for _, window := range windows {
+		if remaining, limited := Remaining(window.max, window.used); limited && amount > remaining {
+			return &ErrLimitExceeded{Scope: scope, Window: window.name, Limit: window.max, Remaining: remaining}
+		}
+	}
+	return nil
+}
+
+// DayStart returns the start of the day in UTC that t falls in.
+func DayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// MonthStart returns the start of the calendar month in UTC that t falls in.
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,49 @@
+package limits
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	l := Limits{PerTransfer: 100, Daily: 250, Monthly: 1000}
+
+	require.NoError(t, Check(ScopeUser, l, Usage{}, 100))
+	require.NoError(t, Check(ScopeUser, l, Usage{Daily: 150, Monthly: 900}, 100))
+	require.NoError(t, Check(ScopeUser, Limits{}, Usage{Daily: 1 << 40, Monthly: 1 << 40}, 1<<40))
+
+	for _, tc := range []struct {
+		name   string
+		usage  Usage
+		amount int64
+		want   ErrLimitExceeded
+	}{
+		{"PerTransfer", Usage{}, 101, ErrLimitExceeded{Scope: ScopeUser, Window: WindowTransfer, Limit: 100, Remaining: 100}},
+		{"Daily", Usage{Daily: 200, Monthly: 200}, 60, ErrLimitExceeded{Scope: ScopeUser, Window: WindowDaily, Limit: 250, Remaining: 50}},
+		{"DailyUsedUp", Usage{Daily: 300, Monthly: 300}, 1, ErrLimitExceeded{Scope: ScopeUser, Window: WindowDaily, Limit: 250, Remaining: 0}},
+		{"Monthly", Usage{Daily: 0, Monthly: 950}, 60, ErrLimitExceeded{Scope: ScopeUser, Window: WindowMonthly, Limit: 1000, Remaining: 50}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Check(ScopeUser, l, tc.usage, tc.amount)
+			require.Equal(t, &tc.want, err)
+		})
+	}
+}
+
+func TestErrLimitExceeded(t *testing.T) {
+	require.EqualError(t, &ErrLimitExceeded{Scope: ScopeAccount, Window: WindowDaily, Limit: 250, Remaining: 50}, "account daily limit of 250 exceeded, 50 remaining")
+	require.EqualError(t, &ErrLimitExceeded{Scope: ScopeUser, Window: WindowTransfer, Limit: 100, Remaining: 100}, "user limit of 100 per transfer exceeded")
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Limits{}.Validate())
+	require.NoError(t, Limits{PerTransfer: 1, Daily: 2, Monthly: 3}.Validate())
+	require.Error(t, Limits{Daily: -1}.Validate())
+}
+
+func TestWindows(t *testing.T) {
+	now := time.Date(2023, 3, 31, 23, 30, 0, 0, time.FixedZone("EST", -5*3600))
+	require.Equal(t, time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC), DayStart(now))
+	require.Equal(t, time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC), MonthStart(now))
+}
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// RequestIdHeader is the HTTP header and gRPC metadata key carrying the request id.
+const RequestIdHeader = "x-request-id"
+
+// maxRequestIdLength bounds request ids accepted from clients so they can't flood the logs.
+const maxRequestIdLength = 128
+
+type requestIdKey struct{}
+
+// NewRequestId generates a new request id.
+func NewRequestId() string {
+	return uuid.NewString()
+}
+
+// ValidRequestId reports whether a request id sent by a client can be used as is.
+func ValidRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLength {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// WithRequestId returns a context carrying the request id and a logger that adds it to every line.
+func WithRequestId(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIdKey{}, id)
+	logger := Ctx(ctx).With().Str("request_id", id).Logger()
+	return logger.WithContext(ctx)
+}
+
+// RequestId returns the request id stored by WithRequestId, or an empty string.
+func RequestId(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+// Ctx returns the logger stored in the context, or the global logger if there is none.
+func Ctx(ctx context.Context) *zerolog.Logger {
+	if logger := zerolog.Ctx(ctx); logger.GetLevel() != zerolog.Disabled {
+		return logger
+	}
+	return &log.Logger
+}
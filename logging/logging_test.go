@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestValidRequestId(t *testing.T) {
+	require.True(t, ValidRequestId(NewRequestId()))
+	require.True(t, ValidRequestId("client-id_42"))
+	require.False(t, ValidRequestId(""))
+	require.False(t, ValidRequestId("has space"))
+	require.False(t, ValidRequestId("line\nbreak"))
+	require.False(t, ValidRequestId(strings.Repeat("a", maxRequestIdLength+1)))
+}
+
+func TestWithRequestId(t *testing.T) {
+	var buf bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = previous }()
+
+	require.Empty(t, RequestId(context.Background()))
+	require.Equal(t, &log.Logger, Ctx(context.Background()))
+
+	ctx := WithRequestId(context.Background(), "abc")
+	require.Equal(t, "abc", RequestId(ctx))
+
+	Ctx(ctx).Info().Msg("hello")
+	require.Contains(t, buf.String(), `"request_id":"abc"`)
+}
+
+func TestRedactJSON(t *testing.T) {
+	body := []byte(`{"username":"alice","password":"secret","session":{"access_token":"a","refreshToken":"b"},"items":[{"secret_code":"c"}]}`)
+	redacted := string(RedactJSON(body))
+
+	require.Contains(t, redacted, `"username":"alice"`)
+	require.NotContains(t, redacted, `"secret"`)
+	require.NotContains(t, redacted, `"a"`)
+	require.NotContains(t, redacted, `"b"`)
+	require.NotContains(t, redacted, `"c"`)
+	require.Equal(t, 4, strings.Count(redacted, Redacted))
+
+	require.Equal(t, []byte(UnparseableBody), RedactJSON([]byte("not json")))
+	require.Equal(t, []byte(UnparseableBody), RedactJSON([]byte(`{"username":"alice","password":"sec`)))
+	require.Empty(t, RedactJSON(nil))
+}
+
+func TestRedactURL(t *testing.T) {
+	require.Equal(t, "/v1/verify_email?email_id=1&secret_code=%5BREDACTED%5D", RedactURL("/v1/verify_email?email_id=1&secret_code=abc"))
+	require.Equal(t, "/v1/login_user", RedactURL("/v1/login_user"))
+	require.Equal(t, "/v1/accounts?page=1", RedactURL("/v1/accounts?page=1"))
+}
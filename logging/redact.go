@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces the value of sensitive fields in logs.
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are the field names, lower cased and without separators, whose values never reach the logs.
+var sensitiveKeys = map[string]bool{
+	"password":       true,
+	"newpassword":    true,
+	"hashedpassword": true,
+	"token":          true,
+	"accesstoken":    true,
+	"refreshtoken":   true,
+	"secretcode":     true,
+	"authorization":  true,
+	"secret":         true,
+}
+
+// IsSensitive reports whether the value of a field with this name must be redacted.
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	key = strings.NewReplacer("_", "", "-", "").Replace(key)
+	return sensitiveKeys[key]
+}
+
+// UnparseableBody replaces bodies that cannot be redacted, since nothing tells which of their parts are sensitive.
+const UnparseableBody = "[unparseable body redacted]"
+
+// RedactJSON returns body with the values of sensitive fields replaced, at any depth. Bodies that are not valid
+// JSON, truncated ones included, are replaced as a whole by UnparseableBody.
+func RedactJSON(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []byte(UnparseableBody)
+	}
+	redacted, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return []byte(UnparseableBody)
+	}
+	return redacted
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if IsSensitive(key) {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = redactValue(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// RedactURL returns the request URI with the values of sensitive query parameters replaced.
+func RedactURL(requestUri string) string {
+	u, err := url.ParseRequestURI(requestUri)
+	if err != nil || u.RawQuery == "" {
+		return requestUri
+	}
+
+	query := u.Query()
+	changed := false
+	for key := range query {
+		if IsSensitive(key) {
+			query[key] = []string{Redacted}
+			changed = true
+		}
+	}
+	if !changed {
+		return requestUri
+	}
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
@@ -1,11 +1,13 @@
 package mail
 
 import (
+	"context"
 	"fmt"
 	"github.com/jordan-wright/email"
 	"github.com/rs/zerolog/log"
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"net"
 	"net/smtp"
 )
 
@@ -89,3 +91,13 @@ func NewGmailSender(name string, fromEmailAddress string, fromEmailPassword stri
 		fromEmailPassword: fromEmailPassword,
 	}
 }
+
+// PingSmtpServer makes sure the SMTP server used by the gmail sender accepts connections.
+func PingSmtpServer(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", smtpServerAddress)
+	if err != nil {
+		return fmt.Errorf("cannot connect to smtp server: %w", err)
+	}
+	return conn.Close()
+}
@@ -3,30 +3,47 @@ package main
 import (
 	"context"
 	"database/sql"
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"errors"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/hibiken/asynq"
+	"github.com/kwalter26/udemy-simplebank/blob"
 	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
 	"github.com/kwalter26/udemy-simplebank/doc"
 	"github.com/kwalter26/udemy-simplebank/gapi"
+	"github.com/kwalter26/udemy-simplebank/health"
 	"github.com/kwalter26/udemy-simplebank/mail"
+	"github.com/kwalter26/udemy-simplebank/metrics"
+	"github.com/kwalter26/udemy-simplebank/migration"
 	"github.com/kwalter26/udemy-simplebank/pb"
+	"github.com/kwalter26/udemy-simplebank/ratelimit"
+	"github.com/kwalter26/udemy-simplebank/tracing"
 	"github.com/kwalter26/udemy-simplebank/util"
 	"github.com/kwalter26/udemy-simplebank/worker"
 	_ "github.com/lib/pq"
-	_ "github.com/newrelic/go-agent/_integrations/nrpq"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/protobuf/encoding/protojson"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+var interruptSignals = []os.Signal{
+	os.Interrupt,
+	syscall.SIGTERM,
+	syscall.SIGINT,
+}
+
 func main() {
 	config, err := util.LoadConfig(".", false)
 	if err != nil {
@@ -37,84 +54,272 @@ func main() {
 		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), interruptSignals...)
+	defer stop()
+
+	shutdownTracing, err := tracing.Init(ctx, config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot init tracing:")
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ServerDrainTimeout())
+		defer cancel()
+		closeResource("tracer provider", func() error { return shutdownTracing(shutdownCtx) })
+	}()
+
 	conn, err := sql.Open(config.DBDriver, config.DBSource)
 	if err != nil {
 		log.Fatal().Err(err).Msg("cannot connect to db:")
 	}
+	defer closeResource("db connection", conn.Close)
+	if err = metrics.RegisterDB(conn, "simple_bank"); err != nil {
+		log.Fatal().Err(err).Msg("cannot register db metrics")
+	}
 
-	// run db migrations
-	runDBMigrations(config.MigrationUrl, config.DBSource)
+	migrationStatus := prepareDBMigrations(config)
 
 	store := db.NewStore(conn)
 
-	redisOpt := asynq.RedisClientOpt{
-		Addr: config.RedisAddress,
+	redisClient := redis.NewClient(&redis.Options{Addr: config.RedisAddress})
+	defer closeResource("redis client", redisClient.Close)
+
+	blobs := newBlobStore(config)
+
+	// tasks run through redis unless the memory backend runs them inside this process, which needs no redis
+	mailer := mail.NewGmailSender(config.EmailSenderName, config.EmailSenderAddress, config.EmailSenderPassword)
+	var taskDistributor worker.TaskDistributor
+	var taskProcessor worker.TaskProcessor
+	var taskInspector worker.TaskInspector
+	switch config.TaskQueueBackend {
+	case "", "redis":
+		redisOpt := asynq.RedisClientOpt{
+			Addr: config.RedisAddress,
+		}
+		taskDistributor = worker.NewRedisTaskDistributor(redisOpt)
+		inspector := asynq.NewInspector(redisOpt)
+		defer closeResource("task inspector", inspector.Close)
+		if err = metrics.RegisterQueues(inspector); err != nil {
+			log.Fatal().Err(err).Msg("cannot register queue metrics")
+		}
+		taskInspector = worker.NewRedisTaskInspector(inspector)
+		taskProcessor = worker.NewRedisTaskProcessor(redisOpt, store, mailer, blobs, config.WorkerDrainTimeout())
+	case "memory":
+		queue := worker.NewMemoryTaskQueue()
+		taskDistributor = worker.NewMemoryTaskDistributor(queue)
+		taskInspector = queue
+		taskProcessor = worker.NewMemoryTaskProcessor(queue, store, mailer, blobs, config.WorkerDrainTimeout())
+	default:
+		log.Fatal().Msgf("unsupported task queue backend %q", config.TaskQueueBackend)
 	}
+	defer closeResource("task distributor", taskDistributor.Close)
+	rateLimiter := newRateLimiter(config, redisClient)
+	checker := newHealthChecker(config, store, conn, redisClient, migrationStatus)
+
+	waitGroup, ctx := errgroup.WithContext(ctx)
 
-	taskDistributor := worker.NewRedisTaskDistributor(redisOpt)
+	runTaskProcessor(ctx, waitGroup, taskProcessor)
+	runOutboxRelay(ctx, waitGroup, config, store, taskDistributor)
+	runTaskScheduler(ctx, waitGroup, config, taskDistributor)
+	runGatewayServer(ctx, waitGroup, config, store, taskDistributor, taskInspector, blobs, rateLimiter, checker)
+	runGRPCServer(ctx, waitGroup, config, store, taskDistributor, taskInspector, blobs, rateLimiter, checker)
 
-	go runTaskProcessor(config, redisOpt, store)
-	go runGatewayServer(config, store, taskDistributor)
-	runGRPCServer(config, store, taskDistributor)
+	err = waitGroup.Wait()
+	if err != nil {
+		log.Error().Err(err).Msg("error from wait group")
+	}
+	log.Info().Msg("shutdown complete")
 }
 
-func runDBMigrations(migrationURL string, dbSource string) {
-	migration, err := migrate.New(migrationURL, dbSource)
+// closeResource closes a resource on shutdown and logs when it fails.
+func closeResource(name string, close func() error) {
+	if err := close(); err != nil {
+		log.Error().Err(err).Msgf("cannot close %s", name)
+	}
+}
+
+// newRateLimiter creates the rate limiter backend selected by the config. Redis shares the limits between instances.
+func newRateLimiter(config util.Config, redisClient *redis.Client) ratelimit.Limiter {
+	switch config.RateLimitBackend {
+	case "", "memory":
+		return ratelimit.NewMemoryLimiter()
+	case "redis":
+		return ratelimit.NewRedisLimiter(redisClient)
+	default:
+		log.Fatal().Msgf("unsupported rate limit backend %q", config.RateLimitBackend)
+		return nil
+	}
+}
+
+// newBlobStore creates the blob store backend selected by the config, which keeps generated files such as
+// statements.
+func newBlobStore(config util.Config) blob.Store {
+	switch config.BlobStoreBackend {
+	case "", "local":
+		blobs, err := blob.NewLocalStore(config.BlobStoreDirectory())
+		if err != nil {
+			log.Fatal().Err(err).Msg("cannot create blob store")
+		}
+		return blobs
+	default:
+		log.Fatal().Msgf("unsupported blob store backend %q", config.BlobStoreBackend)
+		return nil
+	}
+}
+
+// newHealthChecker creates the checker behind the readiness endpoints. The mail provider is not critical: tasks
+// sending emails are retried once it is back. Redis is only checked when a backend uses it, the schema only when
+// migrations are not off.
+func newHealthChecker(config util.Config, store db.Store, conn *sql.DB, redisClient *redis.Client, migrationStatus migration.Status) *health.Checker {
+	checker := health.NewChecker(config.HealthCheckTimeout)
+	checker.Add("postgres", true, func(ctx context.Context) (string, error) {
+		return "", store.Ping(ctx)
+	})
+	if config.TaskQueueBackend != "memory" || config.RateLimitBackend == "redis" {
+		checker.Add("redis", true, func(ctx context.Context) (string, error) {
+			return "", redisClient.Ping(ctx).Err()
+		})
+	}
+	if config.MigrationMode != migration.ModeOff {
+		checker.Add("migration", true, health.MigrationCheck(conn, migrationStatus.Latest))
+	}
+	checker.Add("mail", false, func(ctx context.Context) (string, error) {
+		return "", mail.PingSmtpServer(ctx)
+	})
+	return checker
+}
+
+// prepareDBMigrations applies or checks the db migrations as MIGRATION_MODE says and returns the status of the
+// schema. A dirty schema, or one that is behind in check-only mode, stops the server.
+func prepareDBMigrations(config util.Config) migration.Status {
+	status, err := migration.Prepare(config.MigrationMode, config.MigrationUrl, config.DBSource)
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot migrate db:")
+		log.Fatal().Err(err).Str("mode", config.MigrationMode).Msg("db schema is not ready")
 	}
-	if err = migration.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatal().Err(err).Msg("failed to apply migration:")
+	if config.MigrationMode == migration.ModeOff {
+		log.Info().Msg("db migrations are off")
+		return status
 	}
-	log.Info().Msg("db migration completed")
+	log.Info().Uint("version", status.Version).Msg("db schema is up to date")
+	return status
 }
 
-func runGRPCServer(config util.Config, store db.Store, taskDistributor worker.TaskDistributor) {
-	server, err := gapi.NewServer(config, store, taskDistributor)
+func runGRPCServer(
+	ctx context.Context,
+	waitGroup *errgroup.Group,
+	config util.Config,
+	store db.Store,
+	taskDistributor worker.TaskDistributor,
+	taskInspector worker.TaskInspector,
+	blobs blob.Store,
+	rateLimiter ratelimit.Limiter,
+	checker *health.Checker,
+) {
+	server, err := gapi.NewServer(config, store, taskDistributor, taskInspector, blobs)
 	if err != nil {
 		log.Fatal().Err(err).Msg("cannot create grpc server:")
 	}
 
-	grpcLogger := grpc.UnaryInterceptor(gapi.GrpcLogger)
-	grpcServer := grpc.NewServer(grpcLogger)
+	unaryInterceptors := grpc.ChainUnaryInterceptor(
+		otelgrpc.UnaryServerInterceptor(),
+		gapi.GrpcRequestId,
+		gapi.GrpcLogger,
+		gapi.GrpcMetrics,
+		server.RateLimiter(rateLimiter),
+		server.AuthInterceptor,
+	)
+	streamInterceptors := grpc.ChainStreamInterceptor(otelgrpc.StreamServerInterceptor(), server.StreamAuthInterceptor)
+	grpcServer := grpc.NewServer(unaryInterceptors, streamInterceptors)
 	pb.RegisterSimpleBankServer(grpcServer, server)
 	reflection.Register(grpcServer)
 
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+
 	listener, err := net.Listen("tcp", config.GrpcServerAddress)
 	if err != nil {
 		log.Fatal().Err(err).Msg("cannot start server:")
 	}
 
-	log.Info().Msgf("starting gRPC server on %s", listener.Addr().String())
+	waitGroup.Go(func() error {
+		log.Info().Msgf("starting gRPC server on %s", listener.Addr().String())
 
-	err = grpcServer.Serve(listener)
-	if err != nil {
-		log.Fatal().Err(err).Msg("cannot start server")
-	}
+		err := grpcServer.Serve(listener)
+		if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			log.Error().Err(err).Msg("gRPC server failed to serve")
+			return err
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		health.Watch(ctx, checker, healthServer, config.HealthCheckInterval, pb.SimpleBank_ServiceDesc.ServiceName)
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		log.Info().Msg("graceful shutdown gRPC server")
+		healthServer.Shutdown()
+
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(config.ServerDrainTimeout()):
+			log.Warn().Msg("gRPC server drain timeout exceeded, closing remaining connections")
+			grpcServer.Stop()
+		}
+
+		log.Info().Msg("gRPC server is stopped")
+		return nil
+	})
 }
 
-func runGatewayServer(config util.Config, store db.Store, taskDistributor worker.TaskDistributor) {
-	server, err := gapi.NewServer(config, store, taskDistributor)
+func runGatewayServer(
+	ctx context.Context,
+	waitGroup *errgroup.Group,
+	config util.Config,
+	store db.Store,
+	taskDistributor worker.TaskDistributor,
+	taskInspector worker.TaskInspector,
+	blobs blob.Store,
+	rateLimiter ratelimit.Limiter,
+	checker *health.Checker,
+) {
+	server, err := gapi.NewServer(config, store, taskDistributor, taskInspector, blobs)
 	if err != nil {
 		log.Fatal().Err(err).Msg("cannot create grpc server")
 	}
 
-	jsonOptions := runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
-		MarshalOptions: protojson.MarshalOptions{
-			UseProtoNames: true,
-		},
-		UnmarshalOptions: protojson.UnmarshalOptions{
-			DiscardUnknown: true,
+	// HttpBody responses, like statement downloads, are written as is; everything else is JSON
+	jsonOptions := runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.HTTPBodyMarshaler{
+		Marshaler: &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{
+				UseProtoNames: true,
+			},
+			UnmarshalOptions: protojson.UnmarshalOptions{
+				DiscardUnknown: true,
+			},
 		},
 	})
 
-	grpcMux := runtime.NewServeMux(jsonOptions)
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	grpcMux := runtime.NewServeMux(jsonOptions, runtime.WithMetadata(gapi.GatewayMetadata))
 	err = pb.RegisterSimpleBankHandlerServer(ctx, grpcMux, server)
 	if err != nil {
 		log.Fatal().Err(err).Msg("cannot register gateway server")
 	}
+	err = grpcMux.HandlePath(http.MethodPost, gapi.BatchTransferCsvPattern, server.BatchTransferCsvHandler(grpcMux))
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot register batch transfer upload")
+	}
+	err = grpcMux.HandlePath(http.MethodGet, gapi.AccountStatementPattern, server.AccountStatementHandler(grpcMux))
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot register account statement download")
+	}
 
 	mux := http.NewServeMux()
 	mux.Handle("/", grpcMux)
@@ -122,31 +327,115 @@ func runGatewayServer(config util.Config, store db.Store, taskDistributor worker
 	assets, _ := doc.Assets()
 	fs := http.FileServer(http.FS(assets))
 	mux.Handle("/swagger/", http.StripPrefix("/swagger/", fs))
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/healthz", health.LivenessHandler())
+	mux.Handle("/readyz", health.ReadinessHandler(checker))
 
-	listener, err := net.Listen("tcp", config.HttpServerAddress)
-	if err != nil {
-		log.Fatal().Err(err).Msg("cannot start server:")
+	// middlewares run from the bottom up: tracing first, authentication right before the gateway
+	var handler http.Handler = mux
+	handler = server.HttpAuthenticator(handler)
+	handler = server.HttpRateLimiter(rateLimiter, handler)
+	handler = gapi.HttpMetrics(handler)
+	handler = gapi.HttpLogger(handler)
+	handler = gapi.HttpRequestId(handler)
+	handler = gapi.HttpTracer(handler)
+
+	httpServer := &http.Server{
+		Handler: handler,
+		Addr:    config.HttpServerAddress,
 	}
 
-	log.Info().Msgf("starting HTTP gateway server on %s", listener.Addr().String())
+	waitGroup.Go(func() error {
+		log.Info().Msgf("starting HTTP gateway server on %s", httpServer.Addr)
 
-	handler := gapi.HttpLogger(mux)
-	err = http.Serve(listener, handler)
-	if err != nil {
-		log.Fatal().Err(err).Msg("cannot start server")
-	}
+		err := httpServer.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("HTTP gateway server failed to serve")
+			return err
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		log.Info().Msg("graceful shutdown HTTP gateway server")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ServerDrainTimeout())
+		defer cancel()
+
+		err := httpServer.Shutdown(shutdownCtx)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to shutdown HTTP gateway server")
+			return err
+		}
+
+		log.Info().Msg("HTTP gateway server is stopped")
+		return nil
+	})
 }
 
 // run task processor
-func runTaskProcessor(config util.Config, redisOpt asynq.RedisClientOpt, store db.Store) {
-	mailer := mail.NewGmailSender(config.EmailSenderName, config.EmailSenderAddress, config.EmailSenderPassword)
-	processor := worker.NewRedisTaskProcessor(redisOpt, store, mailer)
+func runTaskProcessor(
+	ctx context.Context,
+	waitGroup *errgroup.Group,
+	processor worker.TaskProcessor,
+) {
 	log.Info().Msg("starting task processor")
 	err := processor.Start()
 	if err != nil {
 		log.Fatal().Err(err).Msg("cannot start task processor")
 	}
 	log.Info().Msg("task processor started")
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		log.Info().Msg("graceful shutdown task processor")
+
+		processor.Shutdown()
+		log.Info().Msg("task processor is stopped")
+		return nil
+	})
+}
+
+// runOutboxRelay publishes the tasks committed to the outbox until the context is done.
+func runOutboxRelay(
+	ctx context.Context,
+	waitGroup *errgroup.Group,
+	config util.Config,
+	store db.Store,
+	taskDistributor worker.TaskDistributor,
+) {
+	relay := worker.NewOutboxRelay(store, taskDistributor, config.OutboxRelayInterval(), config.OutboxRelayBatchSize(), config.OutboxRelayMaxAttempts(), config.OutboxRetentionPeriod())
+
+	waitGroup.Go(func() error {
+		log.Info().Msg("starting outbox relay")
+		relay.Run(ctx)
+		log.Info().Msg("outbox relay is stopped")
+		return nil
+	})
+}
+
+// runTaskScheduler enqueues the periodic tasks on their schedules until the context is done.
+func runTaskScheduler(
+	ctx context.Context,
+	waitGroup *errgroup.Group,
+	config util.Config,
+	taskDistributor worker.TaskDistributor,
+) {
+	scheduler, err := worker.NewTaskScheduler(taskDistributor, []worker.PeriodicTask{
+		{Type: worker.TaskScheduleMonthlyStatements, Cronspec: config.MonthlyStatementSchedule()},
+		{Type: worker.TaskScheduleInterestAccrual, Cronspec: config.InterestAccrualSchedule()},
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create task scheduler")
+	}
+
+	waitGroup.Go(func() error {
+		log.Info().Msg("starting task scheduler")
+		scheduler.Run(ctx)
+		log.Info().Msg("task scheduler is stopped")
+		return nil
+	})
 }
 
 // runGINServer runs gin server but is not used anymore
@@ -0,0 +1,16 @@
+package metrics
+
+import (
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// RegisterDB exposes the sql.DBStats of the connection pool.
+func RegisterDB(conn *sql.DB, dbName string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(conn, dbName))
+}
+
+// RegisterQueues exposes the task counts of the asynq queues.
+func RegisterQueues(inspector QueueInspector) error {
+	return Registry.Register(NewQueueCollector(inspector))
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+const namespace = "simplebank"
+
+// Registry holds every metric exposed by the service. It is separate from the prometheus default registry so that
+// tests and libraries can't register conflicting collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	GrpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "Number of gRPC requests by method and status code.",
+	}, []string{"method", "code"})
+
+	GrpcRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of gRPC requests by method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	HttpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP gateway requests by method and status code.",
+	}, []string{"method", "code"})
+
+	HttpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP gateway requests by method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	TransfersCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_created_total",
+		Help:      "Number of transfers created by currency.",
+	}, []string{"currency"})
+
+	TransferVolume = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfer_volume_total",
+		Help:      "Sum of transferred amounts by currency.",
+	}, []string{"currency"})
+
+	LoginFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_failures_total",
+		Help:      "Number of failed logins by reason.",
+	}, []string{"reason"})
+)
+
+// Login failure reasons.
+const (
+	LoginFailureInvalidCredentials = "invalid_credentials"
+	LoginFailureLockedOut          = "locked_out"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		GrpcRequests,
+		GrpcRequestDuration,
+		HttpRequests,
+		HttpRequestDuration,
+		TransfersCreated,
+		TransferVolume,
+		LoginFailures,
+	)
+}
+
+// Handler serves the metrics in the prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RecordTransfer counts a transfer that was created.
+func RecordTransfer(currency string, amount int64) {
+	TransfersCreated.WithLabelValues(currency).Inc()
+	TransferVolume.WithLabelValues(currency).Add(float64(amount))
+}
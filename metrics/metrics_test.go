@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeInspector struct {
+	queues map[string]*asynq.QueueInfo
+}
+
+func (i fakeInspector) Queues() ([]string, error) {
+	queues := make([]string, 0, len(i.queues))
+	for queue := range i.queues {
+		queues = append(queues, queue)
+	}
+	return queues, nil
+}
+
+func (i fakeInspector) GetQueueInfo(queue string) (*asynq.QueueInfo, error) {
+	info, ok := i.queues[queue]
+	if !ok {
+		return nil, fmt.Errorf("queue %s not found", queue)
+	}
+	return info, nil
+}
+
+func TestQueueCollector(t *testing.T) {
+	collector := NewQueueCollector(fakeInspector{queues: map[string]*asynq.QueueInfo{
+		"email": {Queue: "email", Pending: 3, Retry: 2, Archived: 1},
+	}})
+
+	expected := `
+# HELP simplebank_queue_tasks Number of tasks in an asynq queue by state.
+# TYPE simplebank_queue_tasks gauge
+simplebank_queue_tasks{queue="email",state="dead"} 1
+simplebank_queue_tasks{queue="email",state="pending"} 3
+simplebank_queue_tasks{queue="email",state="retry"} 2
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+}
+
+func TestRecordTransfer(t *testing.T) {
+	before := testutil.ToFloat64(TransferVolume.WithLabelValues("EUR"))
+	RecordTransfer("EUR", 150)
+	RecordTransfer("EUR", 50)
+
+	require.Equal(t, before+200, testutil.ToFloat64(TransferVolume.WithLabelValues("EUR")))
+}
+
+func TestHandler(t *testing.T) {
+	LoginFailures.WithLabelValues(LoginFailureInvalidCredentials).Inc()
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	body, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `simplebank_login_failures_total{reason="invalid_credentials"}`)
+	require.Contains(t, string(body), "go_goroutines")
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	queueTasksDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "queue", "tasks"),
+		"Number of tasks in an asynq queue by state.",
+		[]string{"queue", "state"}, nil,
+	)
+)
+
+// QueueInspector is the part of asynq.Inspector the queue collector needs.
+type QueueInspector interface {
+	Queues() ([]string, error)
+	GetQueueInfo(queue string) (*asynq.QueueInfo, error)
+}
+
+// QueueCollector exposes the pending, retry and dead task counts of every asynq queue. The counts are read from
+// redis on every scrape.
+type QueueCollector struct {
+	inspector QueueInspector
+}
+
+func NewQueueCollector(inspector QueueInspector) *QueueCollector {
+	return &QueueCollector{inspector: inspector}
+}
+
+func (c *QueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueTasksDesc
+}
+
+func (c *QueueCollector) Collect(ch chan<- prometheus.Metric) {
+	queues, err := c.inspector.Queues()
+	if err != nil {
+		log.Error().Err(err).Msg("cannot list task queues")
+		return
+	}
+
+	for _, queue := range queues {
+		info, err := c.inspector.GetQueueInfo(queue)
+		if err != nil {
+			log.Error().Err(err).Str("queue", queue).Msg("cannot get task queue info")
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(queueTasksDesc, prometheus.GaugeValue, float64(info.Pending), queue, "pending")
+		ch <- prometheus.MustNewConstMetric(queueTasksDesc, prometheus.GaugeValue, float64(info.Retry), queue, "retry")
+		ch <- prometheus.MustNewConstMetric(queueTasksDesc, prometheus.GaugeValue, float64(info.Archived), queue, "dead")
+	}
+}
@@ -0,0 +1,198 @@
+// Package migration applies and checks the golang-migrate migrations of the database schema. The server uses it
+// at startup according to MIGRATION_MODE and bankctl uses it to run migrations by hand.
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"os"
+)
+
+// Migration modes of the server
+const (
+	// ModeAuto applies pending migrations at startup.
+	ModeAuto = "auto"
+	// ModeCheckOnly refuses to start when the schema is behind or dirty, migrations are run with bankctl.
+	ModeCheckOnly = "check-only"
+	// ModeOff neither applies nor checks migrations.
+	ModeOff = "off"
+)
+
+// Status is where the database schema is compared to the migrations at hand.
+type Status struct {
+	Version uint   `json:"version"`
+	Latest  uint   `json:"latest"`
+	Dirty   bool   `json:"dirty"`
+	Pending []uint `json:"pending"`
+}
+
+// Behind reports whether migrations are pending.
+func (s Status) Behind() bool {
+	return len(s.Pending) > 0
+}
+
+// DirtyError is returned when a migration failed halfway. golang-migrate refuses to run any further migrations
+// until the schema is repaired by hand and the version it matches is forced.
+type DirtyError struct {
+	Version uint
+}
+
+func (e DirtyError) Error() string {
+	return fmt.Sprintf("migration %d failed halfway and left the database dirty: repair the schema, then run "+
+		"bankctl migrate force with the version it matches (%d if the migration was completed, the one before if it was rolled back)",
+		e.Version, e.Version)
+}
+
+// BehindError is returned in check-only mode when migrations are pending.
+type BehindError struct {
+	Status Status
+}
+
+func (e BehindError) Error() string {
+	return fmt.Sprintf("database schema is at version %d but the server needs version %d: run bankctl migrate up, "+
+		"or set MIGRATION_MODE=%s", e.Status.Version, e.Status.Latest, ModeAuto)
+}
+
+// Runner applies migrations from sourceURL to the database.
+type Runner struct {
+	migrate  *migrate.Migrate
+	versions []uint
+}
+
+func NewRunner(sourceURL string, databaseURL string) (*Runner, error) {
+	versions, err := sourceVersions(sourceURL)
+	if err != nil {
+		return nil, err
+	}
+	m, err := migrate.New(sourceURL, databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open migrations: %w", err)
+	}
+	return &Runner{migrate: m, versions: versions}, nil
+}
+
+func (runner *Runner) Close() error {
+	sourceErr, databaseErr := runner.migrate.Close()
+	if sourceErr != nil {
+		return sourceErr
+	}
+	return databaseErr
+}
+
+// Status reads the version of the schema and lists the migrations not applied yet.
+func (runner *Runner) Status() (Status, error) {
+	version, dirty, err := runner.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return Status{}, fmt.Errorf("cannot read migration version: %w", err)
+	}
+
+	status := Status{Version: version, Dirty: dirty, Pending: []uint{}}
+	for _, v := range runner.versions {
+		status.Latest = v
+		if v > version {
+			status.Pending = append(status.Pending, v)
+		}
+	}
+	return status, nil
+}
+
+// Up applies all pending migrations.
+func (runner *Runner) Up() (Status, error) {
+	return runner.change(func() error { return runner.migrate.Up() })
+}
+
+// Steps applies n migrations up, or rolls back -n migrations when n is negative.
+func (runner *Runner) Steps(n int) (Status, error) {
+	return runner.change(func() error { return runner.migrate.Steps(n) })
+}
+
+// Goto migrates up or down to version.
+func (runner *Runner) Goto(version uint) (Status, error) {
+	return runner.change(func() error { return runner.migrate.Migrate(version) })
+}
+
+// Force sets the version of the schema without running any migration and clears the dirty flag. It is the way
+// out after repairing a failed migration by hand.
+func (runner *Runner) Force(version uint) (Status, error) {
+	if err := runner.migrate.Force(int(version)); err != nil {
+		return Status{}, fmt.Errorf("cannot force migration version: %w", err)
+	}
+	return runner.Status()
+}
+
+// change runs a migration unless the schema is dirty and returns the status it ends up at.
+func (runner *Runner) change(apply func() error) (Status, error) {
+	status, err := runner.Status()
+	if err != nil {
+		return status, err
+	}
+	if status.Dirty {
+		return status, DirtyError{Version: status.Version}
+	}
+
+	if err = apply(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		status, statusErr := runner.Status()
+		if statusErr == nil && status.Dirty {
+			return status, fmt.Errorf("cannot migrate db: %w: %s", err, DirtyError{Version: status.Version})
+		}
+		return status, fmt.Errorf("cannot migrate db: %w", err)
+	}
+	return runner.Status()
+}
+
+// Prepare brings the schema in line with mode at startup and returns the status the server runs with. In off
+// mode the database is not touched and the status is empty.
+func Prepare(mode string, sourceURL string, databaseURL string) (Status, error) {
+	switch mode {
+	case "", ModeAuto, ModeCheckOnly:
+	case ModeOff:
+		return Status{}, nil
+	default:
+		return Status{}, fmt.Errorf("unsupported migration mode %q, must be %s, %s or %s", mode, ModeAuto, ModeCheckOnly, ModeOff)
+	}
+
+	runner, err := NewRunner(sourceURL, databaseURL)
+	if err != nil {
+		return Status{}, err
+	}
+	defer runner.Close()
+
+	if mode == ModeCheckOnly {
+		status, err := runner.Status()
+		if err != nil {
+			return status, err
+		}
+		if status.Dirty {
+			return status, DirtyError{Version: status.Version}
+		}
+		if status.Behind() {
+			return status, BehindError{Status: status}
+		}
+		return status, nil
+	}
+	return runner.Up()
+}
+
+// sourceVersions lists the versions of the migrations at sourceURL in ascending order.
+func sourceVersions(sourceURL string) ([]uint, error) {
+	driver, err := source.Open(sourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open migration source: %w", err)
+	}
+	defer driver.Close()
+
+	var versions []uint
+	version, err := driver.First()
+	for err == nil {
+		versions = append(versions, version)
+		version, err = driver.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("cannot read migration source: %w", err)
+	}
+	return versions, nil
+}
@@ -0,0 +1,96 @@
+package migration
+
+import (
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const testSourceURL = "file://../db/migration"
+
+// newTestRunner runs the real migrations against a stub database that only records versions.
+func newTestRunner(t *testing.T) (*Runner, *stub.Stub) {
+	versions, err := sourceVersions(testSourceURL)
+	require.NoError(t, err)
+
+	driver, err := (&stub.Stub{}).Open("stub://")
+	require.NoError(t, err)
+	m, err := migrate.NewWithDatabaseInstance(testSourceURL, "stub", driver)
+	require.NoError(t, err)
+
+	runner := &Runner{migrate: m, versions: versions}
+	t.Cleanup(func() { runner.Close() })
+	return runner, driver.(*stub.Stub)
+}
+
+func TestRunner(t *testing.T) {
+	runner, _ := newTestRunner(t)
+	latest := runner.versions[len(runner.versions)-1]
+
+	status, err := runner.Status()
+	require.NoError(t, err)
+	require.Zero(t, status.Version)
+	require.Equal(t, latest, status.Latest)
+	require.Equal(t, runner.versions, status.Pending)
+	require.True(t, status.Behind())
+
+	status, err = runner.Up()
+	require.NoError(t, err)
+	require.Equal(t, latest, status.Version)
+	require.Empty(t, status.Pending)
+	require.False(t, status.Behind())
+
+	// nothing left to apply is not an error
+	_, err = runner.Up()
+	require.NoError(t, err)
+
+	status, err = runner.Steps(-2)
+	require.NoError(t, err)
+	require.Equal(t, latest-2, status.Version)
+	require.Equal(t, []uint{latest - 1, latest}, status.Pending)
+
+	status, err = runner.Goto(1)
+	require.NoError(t, err)
+	require.Equal(t, uint(1), status.Version)
+}
+
+func TestRunnerDirty(t *testing.T) {
+	runner, database := newTestRunner(t)
+	database.CurrentVersion = 3
+	database.IsDirty = true
+
+	status, err := runner.Up()
+	require.Equal(t, DirtyError{Version: 3}, err)
+	require.True(t, status.Dirty)
+	require.Contains(t, err.Error(), "bankctl migrate force")
+
+	status, err = runner.Force(3)
+	require.NoError(t, err)
+	require.False(t, status.Dirty)
+	require.Equal(t, uint(3), status.Version)
+
+	status, err = runner.Up()
+	require.NoError(t, err)
+	require.False(t, status.Behind())
+}
+
+func TestPrepare(t *testing.T) {
+	status, err := Prepare(ModeOff, "file://missing", "postgres://missing")
+	require.NoError(t, err)
+	require.Zero(t, status)
+
+	_, err = Prepare("sometimes", testSourceURL, "stub://")
+	require.EqualError(t, err, `unsupported migration mode "sometimes", must be auto, check-only or off`)
+
+	// every stub database starts empty
+	status, err = Prepare(ModeCheckOnly, testSourceURL, "stub://")
+	require.ErrorAs(t, err, &BehindError{})
+	require.Contains(t, err.Error(), "database schema is at version 0")
+	require.True(t, status.Behind())
+
+	status, err = Prepare(ModeAuto, testSourceURL, "stub://")
+	require.NoError(t, err)
+	require.False(t, status.Behind())
+	require.Equal(t, status.Latest, status.Version)
+}
@@ -0,0 +1,288 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        v3.21.12
+// source: rpc_batch_transfer.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type BatchTransferRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FromAccountId int64  `protobuf:"varint,1,opt,name=from_account_id,json=fromAccountId,proto3" json:"from_account_id,omitempty"`
+	Currency      string `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	// all_or_nothing (default) rejects the batch when a line is invalid, best_effort skips invalid lines
+	Mode  string               `protobuf:"bytes,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	Lines []*BatchTransferLine `protobuf:"bytes,4,rep,name=lines,proto3" json:"lines,omitempty"`
+}
+
+func (x *BatchTransferRequest) Reset() {
+	*x = BatchTransferRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_batch_transfer_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchTransferRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchTransferRequest) ProtoMessage() {}
+
+func (x *BatchTransferRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_batch_transfer_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchTransferRequest.ProtoReflect.Descriptor instead.
+func (*BatchTransferRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_batch_transfer_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *BatchTransferRequest) GetFromAccountId() int64 {
+	if x != nil {
+		return x.FromAccountId
+	}
+	return 0
+}
+
+func (x *BatchTransferRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *BatchTransferRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *BatchTransferRequest) GetLines() []*BatchTransferLine {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+type BatchTransferResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FromAccountId int64                      `protobuf:"varint,1,opt,name=from_account_id,json=fromAccountId,proto3" json:"from_account_id,omitempty"`
+	FromBalance   int64                      `protobuf:"varint,2,opt,name=from_balance,json=fromBalance,proto3" json:"from_balance,omitempty"`
+	Completed     int32                      `protobuf:"varint,3,opt,name=completed,proto3" json:"completed,omitempty"`
+	Failed        int32                      `protobuf:"varint,4,opt,name=failed,proto3" json:"failed,omitempty"`
+	Lines         []*BatchTransferLineResult `protobuf:"bytes,5,rep,name=lines,proto3" json:"lines,omitempty"`
+}
+
+func (x *BatchTransferResponse) Reset() {
+	*x = BatchTransferResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_batch_transfer_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchTransferResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchTransferResponse) ProtoMessage() {}
+
+func (x *BatchTransferResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_batch_transfer_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchTransferResponse.ProtoReflect.Descriptor instead.
+func (*BatchTransferResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_batch_transfer_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BatchTransferResponse) GetFromAccountId() int64 {
+	if x != nil {
+		return x.FromAccountId
+	}
+	return 0
+}
+
+func (x *BatchTransferResponse) GetFromBalance() int64 {
+	if x != nil {
+		return x.FromBalance
+	}
+	return 0
+}
+
+func (x *BatchTransferResponse) GetCompleted() int32 {
+	if x != nil {
+		return x.Completed
+	}
+	return 0
+}
+
+func (x *BatchTransferResponse) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *BatchTransferResponse) GetLines() []*BatchTransferLineResult {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+var File_rpc_batch_transfer_proto protoreflect.FileDescriptor
+
+var file_rpc_batch_transfer_proto_rawDesc = []byte{
+	0x0a, 0x18, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x74, 0x72, 0x61, 0x6e,
+	0x73, 0x66, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x02, 0x70, 0x62, 0x1a, 0x14,
+	0x62, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x22, 0x9b, 0x01, 0x0a, 0x14, 0x42, 0x61, 0x74, 0x63, 0x68, 0x54, 0x72,
+	0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x26, 0x0a,
+	0x0f, 0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x66, 0x72, 0x6f, 0x6d, 0x41, 0x63, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63,
+	0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63,
+	0x79, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6d, 0x6f, 0x64, 0x65, 0x12, 0x2b, 0x0a, 0x05, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18, 0x04,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x62, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x54,
+	0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x4c, 0x69, 0x6e, 0x65, 0x52, 0x05, 0x6c, 0x69, 0x6e,
+	0x65, 0x73, 0x22, 0xcb, 0x01, 0x0a, 0x15, 0x42, 0x61, 0x74, 0x63, 0x68, 0x54, 0x72, 0x61, 0x6e,
+	0x73, 0x66, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x26, 0x0a, 0x0f,
+	0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x66, 0x72, 0x6f, 0x6d, 0x41, 0x63, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x62, 0x61, 0x6c,
+	0x61, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x66, 0x72, 0x6f, 0x6d,
+	0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6c,
+	0x65, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x70,
+	0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x12, 0x31, 0x0a,
+	0x05, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x70,
+	0x62, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x4c,
+	0x69, 0x6e, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x05, 0x6c, 0x69, 0x6e, 0x65, 0x73,
+	0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6b,
+	0x77, 0x61, 0x6c, 0x74, 0x65, 0x72, 0x32, 0x36, 0x2f, 0x75, 0x64, 0x65, 0x6d, 0x79, 0x2d, 0x73,
+	0x69, 0x6d, 0x70, 0x6c, 0x65, 0x62, 0x61, 0x6e, 0x6b, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_rpc_batch_transfer_proto_rawDescOnce sync.Once
+	file_rpc_batch_transfer_proto_rawDescData = file_rpc_batch_transfer_proto_rawDesc
+)
+
+func file_rpc_batch_transfer_proto_rawDescGZIP() []byte {
+	file_rpc_batch_transfer_proto_rawDescOnce.Do(func() {
+		file_rpc_batch_transfer_proto_rawDescData = protoimpl.X.CompressGZIP(file_rpc_batch_transfer_proto_rawDescData)
+	})
+	return file_rpc_batch_transfer_proto_rawDescData
+}
+
+var file_rpc_batch_transfer_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_rpc_batch_transfer_proto_goTypes = []interface{}{
+	(*BatchTransferRequest)(nil),    // 0: pb.BatchTransferRequest
+	(*BatchTransferResponse)(nil),   // 1: pb.BatchTransferResponse
+	(*BatchTransferLine)(nil),       // 2: pb.BatchTransferLine
+	(*BatchTransferLineResult)(nil), // 3: pb.BatchTransferLineResult
+}
+var file_rpc_batch_transfer_proto_depIdxs = []int32{
+	2, // 0: pb.BatchTransferRequest.lines:type_name -> pb.BatchTransferLine
+	3, // 1: pb.BatchTransferResponse.lines:type_name -> pb.BatchTransferLineResult
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_rpc_batch_transfer_proto_init() }
+func file_rpc_batch_transfer_proto_init() {
+	if File_rpc_batch_transfer_proto != nil {
+		return
+	}
+	file_batch_transfer_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_rpc_batch_transfer_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BatchTransferRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_batch_transfer_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BatchTransferResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_rpc_batch_transfer_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_rpc_batch_transfer_proto_goTypes,
+		DependencyIndexes: file_rpc_batch_transfer_proto_depIdxs,
+		MessageInfos:      file_rpc_batch_transfer_proto_msgTypes,
+	}.Build()
+	File_rpc_batch_transfer_proto = out.File
+	file_rpc_batch_transfer_proto_rawDesc = nil
+	file_rpc_batch_transfer_proto_goTypes = nil
+	file_rpc_batch_transfer_proto_depIdxs = nil
+}
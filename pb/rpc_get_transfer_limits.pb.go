@@ -0,0 +1,248 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        v3.21.12
+// source: rpc_get_transfer_limits.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetTransferLimitsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	AccountId int64 `protobuf:"varint,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+}
+
+func (x *GetTransferLimitsRequest) Reset() {
+	*x = GetTransferLimitsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_get_transfer_limits_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetTransferLimitsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTransferLimitsRequest) ProtoMessage() {}
+
+func (x *GetTransferLimitsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_get_transfer_limits_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTransferLimitsRequest.ProtoReflect.Descriptor instead.
+func (*GetTransferLimitsRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_get_transfer_limits_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetTransferLimitsRequest) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+type GetTransferLimitsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	AccountId int64  `protobuf:"varint,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Currency  string `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	// limits of the owner over all their accounts in the currency, the defaults when they have none
+	User *TransferLimitUsage `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
+	// limits of the account on top of those of the owner, unset when it has none
+	Account *TransferLimitUsage `protobuf:"bytes,4,opt,name=account,proto3" json:"account,omitempty"`
+}
+
+func (x *GetTransferLimitsResponse) Reset() {
+	*x = GetTransferLimitsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_get_transfer_limits_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetTransferLimitsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTransferLimitsResponse) ProtoMessage() {}
+
+func (x *GetTransferLimitsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_get_transfer_limits_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTransferLimitsResponse.ProtoReflect.Descriptor instead.
+func (*GetTransferLimitsResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_get_transfer_limits_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetTransferLimitsResponse) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *GetTransferLimitsResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *GetTransferLimitsResponse) GetUser() *TransferLimitUsage {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *GetTransferLimitsResponse) GetAccount() *TransferLimitUsage {
+	if x != nil {
+		return x.Account
+	}
+	return nil
+}
+
+var File_rpc_get_transfer_limits_proto protoreflect.FileDescriptor
+
+var file_rpc_get_transfer_limits_proto_rawDesc = []byte{
+	0x0a, 0x1d, 0x72, 0x70, 0x63, 0x5f, 0x67, 0x65, 0x74, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66,
+	0x65, 0x72, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x02, 0x70, 0x62, 0x1a, 0x14, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x5f, 0x6c, 0x69,
+	0x6d, 0x69, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x39, 0x0a, 0x18, 0x47, 0x65, 0x74,
+	0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x61, 0x63, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x49, 0x64, 0x22, 0xb4, 0x01, 0x0a, 0x19, 0x47, 0x65, 0x74, 0x54, 0x72, 0x61, 0x6e,
+	0x73, 0x66, 0x65, 0x72, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x49,
+	0x64, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x2a, 0x0a,
+	0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x62,
+	0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x55, 0x73,
+	0x61, 0x67, 0x65, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x30, 0x0a, 0x07, 0x61, 0x63, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x62, 0x2e,
+	0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x55, 0x73, 0x61,
+	0x67, 0x65, 0x52, 0x07, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x2a, 0x5a, 0x28, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6b, 0x77, 0x61, 0x6c, 0x74, 0x65,
+	0x72, 0x32, 0x36, 0x2f, 0x75, 0x64, 0x65, 0x6d, 0x79, 0x2d, 0x73, 0x69, 0x6d, 0x70, 0x6c, 0x65,
+	0x62, 0x61, 0x6e, 0x6b, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_rpc_get_transfer_limits_proto_rawDescOnce sync.Once
+	file_rpc_get_transfer_limits_proto_rawDescData = file_rpc_get_transfer_limits_proto_rawDesc
+)
+
+func file_rpc_get_transfer_limits_proto_rawDescGZIP() []byte {
+	file_rpc_get_transfer_limits_proto_rawDescOnce.Do(func() {
+		file_rpc_get_transfer_limits_proto_rawDescData = protoimpl.X.CompressGZIP(file_rpc_get_transfer_limits_proto_rawDescData)
+	})
+	return file_rpc_get_transfer_limits_proto_rawDescData
+}
+
+var file_rpc_get_transfer_limits_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_rpc_get_transfer_limits_proto_goTypes = []interface{}{
+	(*GetTransferLimitsRequest)(nil),  // 0: pb.GetTransferLimitsRequest
+	(*GetTransferLimitsResponse)(nil), // 1: pb.GetTransferLimitsResponse
+	(*TransferLimitUsage)(nil),        // 2: pb.TransferLimitUsage
+}
+var file_rpc_get_transfer_limits_proto_depIdxs = []int32{
+	2, // 0: pb.GetTransferLimitsResponse.user:type_name -> pb.TransferLimitUsage
+	2, // 1: pb.GetTransferLimitsResponse.account:type_name -> pb.TransferLimitUsage
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_rpc_get_transfer_limits_proto_init() }
+func file_rpc_get_transfer_limits_proto_init() {
+	if File_rpc_get_transfer_limits_proto != nil {
+		return
+	}
+	file_transfer_limit_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_rpc_get_transfer_limits_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetTransferLimitsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_get_transfer_limits_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetTransferLimitsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_rpc_get_transfer_limits_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_rpc_get_transfer_limits_proto_goTypes,
+		DependencyIndexes: file_rpc_get_transfer_limits_proto_depIdxs,
+		MessageInfos:      file_rpc_get_transfer_limits_proto_msgTypes,
+	}.Build()
+	File_rpc_get_transfer_limits_proto = out.File
+	file_rpc_get_transfer_limits_proto_rawDesc = nil
+	file_rpc_get_transfer_limits_proto_goTypes = nil
+	file_rpc_get_transfer_limits_proto_depIdxs = nil
+}
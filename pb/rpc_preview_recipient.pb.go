@@ -0,0 +1,241 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        v3.21.12
+// source: rpc_preview_recipient.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PreviewRecipientRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// one of username and email is required
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	// only verified emails can be paid
+	Email    string `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Currency string `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
+}
+
+func (x *PreviewRecipientRequest) Reset() {
+	*x = PreviewRecipientRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_preview_recipient_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PreviewRecipientRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreviewRecipientRequest) ProtoMessage() {}
+
+func (x *PreviewRecipientRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_preview_recipient_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreviewRecipientRequest.ProtoReflect.Descriptor instead.
+func (*PreviewRecipientRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_preview_recipient_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PreviewRecipientRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *PreviewRecipientRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *PreviewRecipientRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+type PreviewRecipientResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// first letter of each word of the full name, for the payer to recognize the recipient
+	MaskedFullName string `protobuf:"bytes,1,opt,name=masked_full_name,json=maskedFullName,proto3" json:"masked_full_name,omitempty"`
+	Currency       string `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+}
+
+func (x *PreviewRecipientResponse) Reset() {
+	*x = PreviewRecipientResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_preview_recipient_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PreviewRecipientResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreviewRecipientResponse) ProtoMessage() {}
+
+func (x *PreviewRecipientResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_preview_recipient_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreviewRecipientResponse.ProtoReflect.Descriptor instead.
+func (*PreviewRecipientResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_preview_recipient_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PreviewRecipientResponse) GetMaskedFullName() string {
+	if x != nil {
+		return x.MaskedFullName
+	}
+	return ""
+}
+
+func (x *PreviewRecipientResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+var File_rpc_preview_recipient_proto protoreflect.FileDescriptor
+
+var file_rpc_preview_recipient_proto_rawDesc = []byte{
+	0x0a, 0x1b, 0x72, 0x70, 0x63, 0x5f, 0x70, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x5f, 0x72, 0x65,
+	0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x02, 0x70,
+	0x62, 0x22, 0x67, 0x0a, 0x17, 0x50, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x63, 0x69,
+	0x70, 0x69, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08,
+	0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x6d, 0x61, 0x69,
+	0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x12, 0x1a,
+	0x0a, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x22, 0x60, 0x0a, 0x18, 0x50, 0x72,
+	0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x28, 0x0a, 0x10, 0x6d, 0x61, 0x73, 0x6b, 0x65, 0x64,
+	0x5f, 0x66, 0x75, 0x6c, 0x6c, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0e, 0x6d, 0x61, 0x73, 0x6b, 0x65, 0x64, 0x46, 0x75, 0x6c, 0x6c, 0x4e, 0x61, 0x6d, 0x65,
+	0x12, 0x1a, 0x0a, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x42, 0x2a, 0x5a, 0x28,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6b, 0x77, 0x61, 0x6c, 0x74,
+	0x65, 0x72, 0x32, 0x36, 0x2f, 0x75, 0x64, 0x65, 0x6d, 0x79, 0x2d, 0x73, 0x69, 0x6d, 0x70, 0x6c,
+	0x65, 0x62, 0x61, 0x6e, 0x6b, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_rpc_preview_recipient_proto_rawDescOnce sync.Once
+	file_rpc_preview_recipient_proto_rawDescData = file_rpc_preview_recipient_proto_rawDesc
+)
+
+func file_rpc_preview_recipient_proto_rawDescGZIP() []byte {
+	file_rpc_preview_recipient_proto_rawDescOnce.Do(func() {
+		file_rpc_preview_recipient_proto_rawDescData = protoimpl.X.CompressGZIP(file_rpc_preview_recipient_proto_rawDescData)
+	})
+	return file_rpc_preview_recipient_proto_rawDescData
+}
+
+var file_rpc_preview_recipient_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_rpc_preview_recipient_proto_goTypes = []interface{}{
+	(*PreviewRecipientRequest)(nil),  // 0: pb.PreviewRecipientRequest
+	(*PreviewRecipientResponse)(nil), // 1: pb.PreviewRecipientResponse
+}
+var file_rpc_preview_recipient_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_rpc_preview_recipient_proto_init() }
+func file_rpc_preview_recipient_proto_init() {
+	if File_rpc_preview_recipient_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_rpc_preview_recipient_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PreviewRecipientRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_preview_recipient_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PreviewRecipientResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_rpc_preview_recipient_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_rpc_preview_recipient_proto_goTypes,
+		DependencyIndexes: file_rpc_preview_recipient_proto_depIdxs,
+		MessageInfos:      file_rpc_preview_recipient_proto_msgTypes,
+	}.Build()
+	File_rpc_preview_recipient_proto = out.File
+	file_rpc_preview_recipient_proto_rawDesc = nil
+	file_rpc_preview_recipient_proto_goTypes = nil
+	file_rpc_preview_recipient_proto_depIdxs = nil
+}
@@ -0,0 +1,274 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        v3.21.12
+// source: rpc_set_transfer_limits.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SetTransferLimitsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// default, user or account
+	Scope string `protobuf:"bytes,1,opt,name=scope,proto3" json:"scope,omitempty"`
+	// required for the default and user scopes
+	Currency string `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	// required for the user scope
+	Username string `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	// required for the account scope
+	AccountId int64 `protobuf:"varint,4,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	// unset removes the limits, so that a user falls back to the defaults of the currency
+	Limits *TransferLimits `protobuf:"bytes,5,opt,name=limits,proto3" json:"limits,omitempty"`
+}
+
+func (x *SetTransferLimitsRequest) Reset() {
+	*x = SetTransferLimitsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_set_transfer_limits_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SetTransferLimitsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetTransferLimitsRequest) ProtoMessage() {}
+
+func (x *SetTransferLimitsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_set_transfer_limits_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetTransferLimitsRequest.ProtoReflect.Descriptor instead.
+func (*SetTransferLimitsRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_set_transfer_limits_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SetTransferLimitsRequest) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+func (x *SetTransferLimitsRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *SetTransferLimitsRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *SetTransferLimitsRequest) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *SetTransferLimitsRequest) GetLimits() *TransferLimits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+type SetTransferLimitsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// unset when the limits were removed
+	Limit    *TransferLimit `protobuf:"bytes,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Previous *TransferLimit `protobuf:"bytes,2,opt,name=previous,proto3" json:"previous,omitempty"`
+}
+
+func (x *SetTransferLimitsResponse) Reset() {
+	*x = SetTransferLimitsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_set_transfer_limits_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SetTransferLimitsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetTransferLimitsResponse) ProtoMessage() {}
+
+func (x *SetTransferLimitsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_set_transfer_limits_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetTransferLimitsResponse.ProtoReflect.Descriptor instead.
+func (*SetTransferLimitsResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_set_transfer_limits_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SetTransferLimitsResponse) GetLimit() *TransferLimit {
+	if x != nil {
+		return x.Limit
+	}
+	return nil
+}
+
+func (x *SetTransferLimitsResponse) GetPrevious() *TransferLimit {
+	if x != nil {
+		return x.Previous
+	}
+	return nil
+}
+
+var File_rpc_set_transfer_limits_proto protoreflect.FileDescriptor
+
+var file_rpc_set_transfer_limits_proto_rawDesc = []byte{
+	0x0a, 0x1d, 0x72, 0x70, 0x63, 0x5f, 0x73, 0x65, 0x74, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66,
+	0x65, 0x72, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x02, 0x70, 0x62, 0x1a, 0x14, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x5f, 0x6c, 0x69,
+	0x6d, 0x69, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xb3, 0x01, 0x0a, 0x18, 0x53, 0x65,
+	0x74, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x70, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x70, 0x65, 0x12, 0x1a, 0x0a, 0x08,
+	0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72,
+	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f,
+	0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x49, 0x64, 0x12, 0x2a, 0x0a, 0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x62, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65,
+	0x72, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x52, 0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x22,
+	0x73, 0x0a, 0x19, 0x53, 0x65, 0x74, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x4c, 0x69,
+	0x6d, 0x69, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x27, 0x0a, 0x05,
+	0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x62,
+	0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x52, 0x05,
+	0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x2d, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x54, 0x72, 0x61,
+	0x6e, 0x73, 0x66, 0x65, 0x72, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x52, 0x08, 0x70, 0x72, 0x65, 0x76,
+	0x69, 0x6f, 0x75, 0x73, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
+	0x6f, 0x6d, 0x2f, 0x6b, 0x77, 0x61, 0x6c, 0x74, 0x65, 0x72, 0x32, 0x36, 0x2f, 0x75, 0x64, 0x65,
+	0x6d, 0x79, 0x2d, 0x73, 0x69, 0x6d, 0x70, 0x6c, 0x65, 0x62, 0x61, 0x6e, 0x6b, 0x2f, 0x70, 0x62,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_rpc_set_transfer_limits_proto_rawDescOnce sync.Once
+	file_rpc_set_transfer_limits_proto_rawDescData = file_rpc_set_transfer_limits_proto_rawDesc
+)
+
+func file_rpc_set_transfer_limits_proto_rawDescGZIP() []byte {
+	file_rpc_set_transfer_limits_proto_rawDescOnce.Do(func() {
+		file_rpc_set_transfer_limits_proto_rawDescData = protoimpl.X.CompressGZIP(file_rpc_set_transfer_limits_proto_rawDescData)
+	})
+	return file_rpc_set_transfer_limits_proto_rawDescData
+}
+
+var file_rpc_set_transfer_limits_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_rpc_set_transfer_limits_proto_goTypes = []interface{}{
+	(*SetTransferLimitsRequest)(nil),  // 0: pb.SetTransferLimitsRequest
+	(*SetTransferLimitsResponse)(nil), // 1: pb.SetTransferLimitsResponse
+	(*TransferLimits)(nil),            // 2: pb.TransferLimits
+	(*TransferLimit)(nil),             // 3: pb.TransferLimit
+}
+var file_rpc_set_transfer_limits_proto_depIdxs = []int32{
+	2, // 0: pb.SetTransferLimitsRequest.limits:type_name -> pb.TransferLimits
+	3, // 1: pb.SetTransferLimitsResponse.limit:type_name -> pb.TransferLimit
+	3, // 2: pb.SetTransferLimitsResponse.previous:type_name -> pb.TransferLimit
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_rpc_set_transfer_limits_proto_init() }
+func file_rpc_set_transfer_limits_proto_init() {
+	if File_rpc_set_transfer_limits_proto != nil {
+		return
+	}
+	file_transfer_limit_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_rpc_set_transfer_limits_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SetTransferLimitsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_set_transfer_limits_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SetTransferLimitsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_rpc_set_transfer_limits_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_rpc_set_transfer_limits_proto_goTypes,
+		DependencyIndexes: file_rpc_set_transfer_limits_proto_depIdxs,
+		MessageInfos:      file_rpc_set_transfer_limits_proto_msgTypes,
+	}.Build()
+	File_rpc_set_transfer_limits_proto = out.File
+	file_rpc_set_transfer_limits_proto_rawDesc = nil
+	file_rpc_set_transfer_limits_proto_goTypes = nil
+	file_rpc_set_transfer_limits_proto_depIdxs = nil
+}
@@ -8,6 +8,7 @@ package pb
 
 import (
 	context "context"
+	httpbody "google.golang.org/genproto/googleapis/api/httpbody"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
@@ -19,10 +20,31 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	SimpleBank_CreateUser_FullMethodName  = "/pb.SimpleBank/CreateUser"
-	SimpleBank_UpdateUser_FullMethodName  = "/pb.SimpleBank/UpdateUser"
-	SimpleBank_Login_FullMethodName       = "/pb.SimpleBank/Login"
-	SimpleBank_VerifyEmail_FullMethodName = "/pb.SimpleBank/VerifyEmail"
+	SimpleBank_CreateUser_FullMethodName               = "/pb.SimpleBank/CreateUser"
+	SimpleBank_UpdateUser_FullMethodName               = "/pb.SimpleBank/UpdateUser"
+	SimpleBank_Login_FullMethodName                    = "/pb.SimpleBank/Login"
+	SimpleBank_VerifyEmail_FullMethodName              = "/pb.SimpleBank/VerifyEmail"
+	SimpleBank_UnlockUser_FullMethodName               = "/pb.SimpleBank/UnlockUser"
+	SimpleBank_ListAuditEvents_FullMethodName          = "/pb.SimpleBank/ListAuditEvents"
+	SimpleBank_SubscribeAccountEvents_FullMethodName   = "/pb.SimpleBank/SubscribeAccountEvents"
+	SimpleBank_CreateWebhook_FullMethodName            = "/pb.SimpleBank/CreateWebhook"
+	SimpleBank_ListWebhookDeliveries_FullMethodName    = "/pb.SimpleBank/ListWebhookDeliveries"
+	SimpleBank_ListFailedTasks_FullMethodName          = "/pb.SimpleBank/ListFailedTasks"
+	SimpleBank_RetryFailedTask_FullMethodName          = "/pb.SimpleBank/RetryFailedTask"
+	SimpleBank_DeleteFailedTask_FullMethodName         = "/pb.SimpleBank/DeleteFailedTask"
+	SimpleBank_CreateTransfer_FullMethodName           = "/pb.SimpleBank/CreateTransfer"
+	SimpleBank_BatchTransfer_FullMethodName            = "/pb.SimpleBank/BatchTransfer"
+	SimpleBank_EmailAccountStatement_FullMethodName    = "/pb.SimpleBank/EmailAccountStatement"
+	SimpleBank_ListAccountStatements_FullMethodName    = "/pb.SimpleBank/ListAccountStatements"
+	SimpleBank_DownloadAccountStatement_FullMethodName = "/pb.SimpleBank/DownloadAccountStatement"
+	SimpleBank_QuoteTransfer_FullMethodName            = "/pb.SimpleBank/QuoteTransfer"
+	SimpleBank_GetTransferLimits_FullMethodName        = "/pb.SimpleBank/GetTransferLimits"
+	SimpleBank_SetTransferLimits_FullMethodName        = "/pb.SimpleBank/SetTransferLimits"
+	SimpleBank_PreviewRecipient_FullMethodName         = "/pb.SimpleBank/PreviewRecipient"
+	SimpleBank_CreatePayee_FullMethodName              = "/pb.SimpleBank/CreatePayee"
+	SimpleBank_ListPayees_FullMethodName               = "/pb.SimpleBank/ListPayees"
+	SimpleBank_UpdatePayee_FullMethodName              = "/pb.SimpleBank/UpdatePayee"
+	SimpleBank_DeletePayee_FullMethodName              = "/pb.SimpleBank/DeletePayee"
 )
 
 // SimpleBankClient is the client API for SimpleBank service.
@@ -33,6 +55,28 @@ type SimpleBankClient interface {
 	UpdateUser(ctx context.Context, in *UpdateUserRequest, opts ...grpc.CallOption) (*UpdateUserResponse, error)
 	Login(ctx context.Context, in *LoginUserRequest, opts ...grpc.CallOption) (*LoginUserResponse, error)
 	VerifyEmail(ctx context.Context, in *VerifyEmailRequest, opts ...grpc.CallOption) (*VerifyEmailResponse, error)
+	UnlockUser(ctx context.Context, in *UnlockUserRequest, opts ...grpc.CallOption) (*UnlockUserResponse, error)
+	ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error)
+	// Streams the domain events of the caller's accounts. Streaming is only available over gRPC.
+	SubscribeAccountEvents(ctx context.Context, in *SubscribeAccountEventsRequest, opts ...grpc.CallOption) (SimpleBank_SubscribeAccountEventsClient, error)
+	CreateWebhook(ctx context.Context, in *CreateWebhookRequest, opts ...grpc.CallOption) (*CreateWebhookResponse, error)
+	ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*ListWebhookDeliveriesResponse, error)
+	ListFailedTasks(ctx context.Context, in *ListFailedTasksRequest, opts ...grpc.CallOption) (*ListFailedTasksResponse, error)
+	RetryFailedTask(ctx context.Context, in *RetryFailedTaskRequest, opts ...grpc.CallOption) (*RetryFailedTaskResponse, error)
+	DeleteFailedTask(ctx context.Context, in *DeleteFailedTaskRequest, opts ...grpc.CallOption) (*DeleteFailedTaskResponse, error)
+	CreateTransfer(ctx context.Context, in *CreateTransferRequest, opts ...grpc.CallOption) (*CreateTransferResponse, error)
+	BatchTransfer(ctx context.Context, in *BatchTransferRequest, opts ...grpc.CallOption) (*BatchTransferResponse, error)
+	EmailAccountStatement(ctx context.Context, in *EmailAccountStatementRequest, opts ...grpc.CallOption) (*EmailAccountStatementResponse, error)
+	ListAccountStatements(ctx context.Context, in *ListAccountStatementsRequest, opts ...grpc.CallOption) (*ListAccountStatementsResponse, error)
+	DownloadAccountStatement(ctx context.Context, in *DownloadAccountStatementRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error)
+	QuoteTransfer(ctx context.Context, in *QuoteTransferRequest, opts ...grpc.CallOption) (*QuoteTransferResponse, error)
+	GetTransferLimits(ctx context.Context, in *GetTransferLimitsRequest, opts ...grpc.CallOption) (*GetTransferLimitsResponse, error)
+	SetTransferLimits(ctx context.Context, in *SetTransferLimitsRequest, opts ...grpc.CallOption) (*SetTransferLimitsResponse, error)
+	PreviewRecipient(ctx context.Context, in *PreviewRecipientRequest, opts ...grpc.CallOption) (*PreviewRecipientResponse, error)
+	CreatePayee(ctx context.Context, in *CreatePayeeRequest, opts ...grpc.CallOption) (*CreatePayeeResponse, error)
+	ListPayees(ctx context.Context, in *ListPayeesRequest, opts ...grpc.CallOption) (*ListPayeesResponse, error)
+	UpdatePayee(ctx context.Context, in *UpdatePayeeRequest, opts ...grpc.CallOption) (*UpdatePayeeResponse, error)
+	DeletePayee(ctx context.Context, in *DeletePayeeRequest, opts ...grpc.CallOption) (*DeletePayeeResponse, error)
 }
 
 type simpleBankClient struct {
@@ -79,6 +123,218 @@ func (c *simpleBankClient) VerifyEmail(ctx context.Context, in *VerifyEmailReque
 	return out, nil
 }
 
+func (c *simpleBankClient) UnlockUser(ctx context.Context, in *UnlockUserRequest, opts ...grpc.CallOption) (*UnlockUserResponse, error) {
+	out := new(UnlockUserResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_UnlockUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error) {
+	out := new(ListAuditEventsResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_ListAuditEvents_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) SubscribeAccountEvents(ctx context.Context, in *SubscribeAccountEventsRequest, opts ...grpc.CallOption) (SimpleBank_SubscribeAccountEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SimpleBank_ServiceDesc.Streams[0], SimpleBank_SubscribeAccountEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &simpleBankSubscribeAccountEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SimpleBank_SubscribeAccountEventsClient interface {
+	Recv() (*DomainEvent, error)
+	grpc.ClientStream
+}
+
+type simpleBankSubscribeAccountEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *simpleBankSubscribeAccountEventsClient) Recv() (*DomainEvent, error) {
+	m := new(DomainEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *simpleBankClient) CreateWebhook(ctx context.Context, in *CreateWebhookRequest, opts ...grpc.CallOption) (*CreateWebhookResponse, error) {
+	out := new(CreateWebhookResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_CreateWebhook_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*ListWebhookDeliveriesResponse, error) {
+	out := new(ListWebhookDeliveriesResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_ListWebhookDeliveries_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) ListFailedTasks(ctx context.Context, in *ListFailedTasksRequest, opts ...grpc.CallOption) (*ListFailedTasksResponse, error) {
+	out := new(ListFailedTasksResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_ListFailedTasks_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) RetryFailedTask(ctx context.Context, in *RetryFailedTaskRequest, opts ...grpc.CallOption) (*RetryFailedTaskResponse, error) {
+	out := new(RetryFailedTaskResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_RetryFailedTask_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) DeleteFailedTask(ctx context.Context, in *DeleteFailedTaskRequest, opts ...grpc.CallOption) (*DeleteFailedTaskResponse, error) {
+	out := new(DeleteFailedTaskResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_DeleteFailedTask_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) CreateTransfer(ctx context.Context, in *CreateTransferRequest, opts ...grpc.CallOption) (*CreateTransferResponse, error) {
+	out := new(CreateTransferResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_CreateTransfer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) BatchTransfer(ctx context.Context, in *BatchTransferRequest, opts ...grpc.CallOption) (*BatchTransferResponse, error) {
+	out := new(BatchTransferResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_BatchTransfer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) EmailAccountStatement(ctx context.Context, in *EmailAccountStatementRequest, opts ...grpc.CallOption) (*EmailAccountStatementResponse, error) {
+	out := new(EmailAccountStatementResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_EmailAccountStatement_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) ListAccountStatements(ctx context.Context, in *ListAccountStatementsRequest, opts ...grpc.CallOption) (*ListAccountStatementsResponse, error) {
+	out := new(ListAccountStatementsResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_ListAccountStatements_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) DownloadAccountStatement(ctx context.Context, in *DownloadAccountStatementRequest, opts ...grpc.CallOption) (*httpbody.HttpBody, error) {
+	out := new(httpbody.HttpBody)
+	err := c.cc.Invoke(ctx, SimpleBank_DownloadAccountStatement_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) QuoteTransfer(ctx context.Context, in *QuoteTransferRequest, opts ...grpc.CallOption) (*QuoteTransferResponse, error) {
+	out := new(QuoteTransferResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_QuoteTransfer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) GetTransferLimits(ctx context.Context, in *GetTransferLimitsRequest, opts ...grpc.CallOption) (*GetTransferLimitsResponse, error) {
+	out := new(GetTransferLimitsResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_GetTransferLimits_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) SetTransferLimits(ctx context.Context, in *SetTransferLimitsRequest, opts ...grpc.CallOption) (*SetTransferLimitsResponse, error) {
+	out := new(SetTransferLimitsResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_SetTransferLimits_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) PreviewRecipient(ctx context.Context, in *PreviewRecipientRequest, opts ...grpc.CallOption) (*PreviewRecipientResponse, error) {
+	out := new(PreviewRecipientResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_PreviewRecipient_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) CreatePayee(ctx context.Context, in *CreatePayeeRequest, opts ...grpc.CallOption) (*CreatePayeeResponse, error) {
+	out := new(CreatePayeeResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_CreatePayee_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) ListPayees(ctx context.Context, in *ListPayeesRequest, opts ...grpc.CallOption) (*ListPayeesResponse, error) {
+	out := new(ListPayeesResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_ListPayees_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) UpdatePayee(ctx context.Context, in *UpdatePayeeRequest, opts ...grpc.CallOption) (*UpdatePayeeResponse, error) {
+	out := new(UpdatePayeeResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_UpdatePayee_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleBankClient) DeletePayee(ctx context.Context, in *DeletePayeeRequest, opts ...grpc.CallOption) (*DeletePayeeResponse, error) {
+	out := new(DeletePayeeResponse)
+	err := c.cc.Invoke(ctx, SimpleBank_DeletePayee_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SimpleBankServer is the server API for SimpleBank service.
 // All implementations must embed UnimplementedSimpleBankServer
 // for forward compatibility
@@ -87,6 +343,28 @@ type SimpleBankServer interface {
 	UpdateUser(context.Context, *UpdateUserRequest) (*UpdateUserResponse, error)
 	Login(context.Context, *LoginUserRequest) (*LoginUserResponse, error)
 	VerifyEmail(context.Context, *VerifyEmailRequest) (*VerifyEmailResponse, error)
+	UnlockUser(context.Context, *UnlockUserRequest) (*UnlockUserResponse, error)
+	ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error)
+	// Streams the domain events of the caller's accounts. Streaming is only available over gRPC.
+	SubscribeAccountEvents(*SubscribeAccountEventsRequest, SimpleBank_SubscribeAccountEventsServer) error
+	CreateWebhook(context.Context, *CreateWebhookRequest) (*CreateWebhookResponse, error)
+	ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error)
+	ListFailedTasks(context.Context, *ListFailedTasksRequest) (*ListFailedTasksResponse, error)
+	RetryFailedTask(context.Context, *RetryFailedTaskRequest) (*RetryFailedTaskResponse, error)
+	DeleteFailedTask(context.Context, *DeleteFailedTaskRequest) (*DeleteFailedTaskResponse, error)
+	CreateTransfer(context.Context, *CreateTransferRequest) (*CreateTransferResponse, error)
+	BatchTransfer(context.Context, *BatchTransferRequest) (*BatchTransferResponse, error)
+	EmailAccountStatement(context.Context, *EmailAccountStatementRequest) (*EmailAccountStatementResponse, error)
+	ListAccountStatements(context.Context, *ListAccountStatementsRequest) (*ListAccountStatementsResponse, error)
+	DownloadAccountStatement(context.Context, *DownloadAccountStatementRequest) (*httpbody.HttpBody, error)
+	QuoteTransfer(context.Context, *QuoteTransferRequest) (*QuoteTransferResponse, error)
+	GetTransferLimits(context.Context, *GetTransferLimitsRequest) (*GetTransferLimitsResponse, error)
+	SetTransferLimits(context.Context, *SetTransferLimitsRequest) (*SetTransferLimitsResponse, error)
+	PreviewRecipient(context.Context, *PreviewRecipientRequest) (*PreviewRecipientResponse, error)
+	CreatePayee(context.Context, *CreatePayeeRequest) (*CreatePayeeResponse, error)
+	ListPayees(context.Context, *ListPayeesRequest) (*ListPayeesResponse, error)
+	UpdatePayee(context.Context, *UpdatePayeeRequest) (*UpdatePayeeResponse, error)
+	DeletePayee(context.Context, *DeletePayeeRequest) (*DeletePayeeResponse, error)
 	mustEmbedUnimplementedSimpleBankServer()
 }
 
@@ -106,6 +384,69 @@ func (UnimplementedSimpleBankServer) Login(context.Context, *LoginUserRequest) (
 func (UnimplementedSimpleBankServer) VerifyEmail(context.Context, *VerifyEmailRequest) (*VerifyEmailResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method VerifyEmail not implemented")
 }
+func (UnimplementedSimpleBankServer) UnlockUser(context.Context, *UnlockUserRequest) (*UnlockUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnlockUser not implemented")
+}
+func (UnimplementedSimpleBankServer) ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditEvents not implemented")
+}
+func (UnimplementedSimpleBankServer) SubscribeAccountEvents(*SubscribeAccountEventsRequest, SimpleBank_SubscribeAccountEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeAccountEvents not implemented")
+}
+func (UnimplementedSimpleBankServer) CreateWebhook(context.Context, *CreateWebhookRequest) (*CreateWebhookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateWebhook not implemented")
+}
+func (UnimplementedSimpleBankServer) ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhookDeliveries not implemented")
+}
+func (UnimplementedSimpleBankServer) ListFailedTasks(context.Context, *ListFailedTasksRequest) (*ListFailedTasksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFailedTasks not implemented")
+}
+func (UnimplementedSimpleBankServer) RetryFailedTask(context.Context, *RetryFailedTaskRequest) (*RetryFailedTaskResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetryFailedTask not implemented")
+}
+func (UnimplementedSimpleBankServer) DeleteFailedTask(context.Context, *DeleteFailedTaskRequest) (*DeleteFailedTaskResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteFailedTask not implemented")
+}
+func (UnimplementedSimpleBankServer) CreateTransfer(context.Context, *CreateTransferRequest) (*CreateTransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateTransfer not implemented")
+}
+func (UnimplementedSimpleBankServer) BatchTransfer(context.Context, *BatchTransferRequest) (*BatchTransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchTransfer not implemented")
+}
+func (UnimplementedSimpleBankServer) EmailAccountStatement(context.Context, *EmailAccountStatementRequest) (*EmailAccountStatementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EmailAccountStatement not implemented")
+}
+func (UnimplementedSimpleBankServer) ListAccountStatements(context.Context, *ListAccountStatementsRequest) (*ListAccountStatementsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAccountStatements not implemented")
+}
+func (UnimplementedSimpleBankServer) DownloadAccountStatement(context.Context, *DownloadAccountStatementRequest) (*httpbody.HttpBody, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DownloadAccountStatement not implemented")
+}
+func (UnimplementedSimpleBankServer) QuoteTransfer(context.Context, *QuoteTransferRequest) (*QuoteTransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QuoteTransfer not implemented")
+}
+func (UnimplementedSimpleBankServer) GetTransferLimits(context.Context, *GetTransferLimitsRequest) (*GetTransferLimitsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransferLimits not implemented")
+}
+func (UnimplementedSimpleBankServer) SetTransferLimits(context.Context, *SetTransferLimitsRequest) (*SetTransferLimitsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetTransferLimits not implemented")
+}
+func (UnimplementedSimpleBankServer) PreviewRecipient(context.Context, *PreviewRecipientRequest) (*PreviewRecipientResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PreviewRecipient not implemented")
+}
+func (UnimplementedSimpleBankServer) CreatePayee(context.Context, *CreatePayeeRequest) (*CreatePayeeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePayee not implemented")
+}
+func (UnimplementedSimpleBankServer) ListPayees(context.Context, *ListPayeesRequest) (*ListPayeesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPayees not implemented")
+}
+func (UnimplementedSimpleBankServer) UpdatePayee(context.Context, *UpdatePayeeRequest) (*UpdatePayeeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePayee not implemented")
+}
+func (UnimplementedSimpleBankServer) DeletePayee(context.Context, *DeletePayeeRequest) (*DeletePayeeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePayee not implemented")
+}
 func (UnimplementedSimpleBankServer) mustEmbedUnimplementedSimpleBankServer() {}
 
 // UnsafeSimpleBankServer may be embedded to opt out of forward compatibility for this service.
@@ -191,6 +532,387 @@ func _SimpleBank_VerifyEmail_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SimpleBank_UnlockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnlockUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).UnlockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_UnlockUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).UnlockUser(ctx, req.(*UnlockUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_ListAuditEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).ListAuditEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_ListAuditEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).ListAuditEvents(ctx, req.(*ListAuditEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_SubscribeAccountEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeAccountEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SimpleBankServer).SubscribeAccountEvents(m, &simpleBankSubscribeAccountEventsServer{stream})
+}
+
+type SimpleBank_SubscribeAccountEventsServer interface {
+	Send(*DomainEvent) error
+	grpc.ServerStream
+}
+
+type simpleBankSubscribeAccountEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *simpleBankSubscribeAccountEventsServer) Send(m *DomainEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _SimpleBank_CreateWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).CreateWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_CreateWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).CreateWebhook(ctx, req.(*CreateWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_ListWebhookDeliveries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhookDeliveriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).ListWebhookDeliveries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_ListWebhookDeliveries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).ListWebhookDeliveries(ctx, req.(*ListWebhookDeliveriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_ListFailedTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFailedTasksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).ListFailedTasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_ListFailedTasks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).ListFailedTasks(ctx, req.(*ListFailedTasksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_RetryFailedTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetryFailedTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).RetryFailedTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_RetryFailedTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).RetryFailedTask(ctx, req.(*RetryFailedTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_DeleteFailedTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteFailedTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).DeleteFailedTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_DeleteFailedTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).DeleteFailedTask(ctx, req.(*DeleteFailedTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_CreateTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateTransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).CreateTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_CreateTransfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).CreateTransfer(ctx, req.(*CreateTransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_BatchTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchTransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).BatchTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_BatchTransfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).BatchTransfer(ctx, req.(*BatchTransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_EmailAccountStatement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmailAccountStatementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).EmailAccountStatement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_EmailAccountStatement_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).EmailAccountStatement(ctx, req.(*EmailAccountStatementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_ListAccountStatements_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAccountStatementsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).ListAccountStatements(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_ListAccountStatements_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).ListAccountStatements(ctx, req.(*ListAccountStatementsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_DownloadAccountStatement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DownloadAccountStatementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).DownloadAccountStatement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_DownloadAccountStatement_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).DownloadAccountStatement(ctx, req.(*DownloadAccountStatementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_QuoteTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QuoteTransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).QuoteTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_QuoteTransfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).QuoteTransfer(ctx, req.(*QuoteTransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_GetTransferLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransferLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).GetTransferLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_GetTransferLimits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).GetTransferLimits(ctx, req.(*GetTransferLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_SetTransferLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetTransferLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).SetTransferLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_SetTransferLimits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).SetTransferLimits(ctx, req.(*SetTransferLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_PreviewRecipient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PreviewRecipientRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).PreviewRecipient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_PreviewRecipient_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).PreviewRecipient(ctx, req.(*PreviewRecipientRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_CreatePayee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePayeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).CreatePayee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_CreatePayee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).CreatePayee(ctx, req.(*CreatePayeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_ListPayees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPayeesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).ListPayees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_ListPayees_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).ListPayees(ctx, req.(*ListPayeesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_UpdatePayee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePayeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).UpdatePayee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_UpdatePayee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).UpdatePayee(ctx, req.(*UpdatePayeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleBank_DeletePayee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePayeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleBankServer).DeletePayee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SimpleBank_DeletePayee_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleBankServer).DeletePayee(ctx, req.(*DeletePayeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // SimpleBank_ServiceDesc is the grpc.ServiceDesc for SimpleBank service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -214,7 +936,93 @@ var SimpleBank_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "VerifyEmail",
 			Handler:    _SimpleBank_VerifyEmail_Handler,
 		},
+		{
+			MethodName: "UnlockUser",
+			Handler:    _SimpleBank_UnlockUser_Handler,
+		},
+		{
+			MethodName: "ListAuditEvents",
+			Handler:    _SimpleBank_ListAuditEvents_Handler,
+		},
+		{
+			MethodName: "CreateWebhook",
+			Handler:    _SimpleBank_CreateWebhook_Handler,
+		},
+		{
+			MethodName: "ListWebhookDeliveries",
+			Handler:    _SimpleBank_ListWebhookDeliveries_Handler,
+		},
+		{
+			MethodName: "ListFailedTasks",
+			Handler:    _SimpleBank_ListFailedTasks_Handler,
+		},
+		{
+			MethodName: "RetryFailedTask",
+			Handler:    _SimpleBank_RetryFailedTask_Handler,
+		},
+		{
+			MethodName: "DeleteFailedTask",
+			Handler:    _SimpleBank_DeleteFailedTask_Handler,
+		},
+		{
+			MethodName: "CreateTransfer",
+			Handler:    _SimpleBank_CreateTransfer_Handler,
+		},
+		{
+			MethodName: "BatchTransfer",
+			Handler:    _SimpleBank_BatchTransfer_Handler,
+		},
+		{
+			MethodName: "EmailAccountStatement",
+			Handler:    _SimpleBank_EmailAccountStatement_Handler,
+		},
+		{
+			MethodName: "ListAccountStatements",
+			Handler:    _SimpleBank_ListAccountStatements_Handler,
+		},
+		{
+			MethodName: "DownloadAccountStatement",
+			Handler:    _SimpleBank_DownloadAccountStatement_Handler,
+		},
+		{
+			MethodName: "QuoteTransfer",
+			Handler:    _SimpleBank_QuoteTransfer_Handler,
+		},
+		{
+			MethodName: "GetTransferLimits",
+			Handler:    _SimpleBank_GetTransferLimits_Handler,
+		},
+		{
+			MethodName: "SetTransferLimits",
+			Handler:    _SimpleBank_SetTransferLimits_Handler,
+		},
+		{
+			MethodName: "PreviewRecipient",
+			Handler:    _SimpleBank_PreviewRecipient_Handler,
+		},
+		{
+			MethodName: "CreatePayee",
+			Handler:    _SimpleBank_CreatePayee_Handler,
+		},
+		{
+			MethodName: "ListPayees",
+			Handler:    _SimpleBank_ListPayees_Handler,
+		},
+		{
+			MethodName: "UpdatePayee",
+			Handler:    _SimpleBank_UpdatePayee_Handler,
+		},
+		{
+			MethodName: "DeletePayee",
+			Handler:    _SimpleBank_DeletePayee_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeAccountEvents",
+			Handler:       _SimpleBank_SubscribeAccountEvents_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "service_simplebank.proto",
 }
@@ -0,0 +1,420 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        v3.21.12
+// source: transfer_limit.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Maximum amounts of outgoing transfers, 0 for no limit
+type TransferLimits struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PerTransferMax int64 `protobuf:"varint,1,opt,name=per_transfer_max,json=perTransferMax,proto3" json:"per_transfer_max,omitempty"`
+	DailyMax       int64 `protobuf:"varint,2,opt,name=daily_max,json=dailyMax,proto3" json:"daily_max,omitempty"`
+	MonthlyMax     int64 `protobuf:"varint,3,opt,name=monthly_max,json=monthlyMax,proto3" json:"monthly_max,omitempty"`
+}
+
+func (x *TransferLimits) Reset() {
+	*x = TransferLimits{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_transfer_limit_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TransferLimits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferLimits) ProtoMessage() {}
+
+func (x *TransferLimits) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_limit_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferLimits.ProtoReflect.Descriptor instead.
+func (*TransferLimits) Descriptor() ([]byte, []int) {
+	return file_transfer_limit_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TransferLimits) GetPerTransferMax() int64 {
+	if x != nil {
+		return x.PerTransferMax
+	}
+	return 0
+}
+
+func (x *TransferLimits) GetDailyMax() int64 {
+	if x != nil {
+		return x.DailyMax
+	}
+	return 0
+}
+
+func (x *TransferLimits) GetMonthlyMax() int64 {
+	if x != nil {
+		return x.MonthlyMax
+	}
+	return 0
+}
+
+type TransferLimitUsage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// default, user or account
+	Scope       string          `protobuf:"bytes,1,opt,name=scope,proto3" json:"scope,omitempty"`
+	Limits      *TransferLimits `protobuf:"bytes,2,opt,name=limits,proto3" json:"limits,omitempty"`
+	DailyUsed   int64           `protobuf:"varint,3,opt,name=daily_used,json=dailyUsed,proto3" json:"daily_used,omitempty"`
+	MonthlyUsed int64           `protobuf:"varint,4,opt,name=monthly_used,json=monthlyUsed,proto3" json:"monthly_used,omitempty"`
+	// unset when there is no daily limit
+	DailyRemaining *int64 `protobuf:"varint,5,opt,name=daily_remaining,json=dailyRemaining,proto3,oneof" json:"daily_remaining,omitempty"`
+	// unset when there is no monthly limit
+	MonthlyRemaining *int64 `protobuf:"varint,6,opt,name=monthly_remaining,json=monthlyRemaining,proto3,oneof" json:"monthly_remaining,omitempty"`
+}
+
+func (x *TransferLimitUsage) Reset() {
+	*x = TransferLimitUsage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_transfer_limit_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TransferLimitUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferLimitUsage) ProtoMessage() {}
+
+func (x *TransferLimitUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_limit_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferLimitUsage.ProtoReflect.Descriptor instead.
+func (*TransferLimitUsage) Descriptor() ([]byte, []int) {
+	return file_transfer_limit_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TransferLimitUsage) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+func (x *TransferLimitUsage) GetLimits() *TransferLimits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *TransferLimitUsage) GetDailyUsed() int64 {
+	if x != nil {
+		return x.DailyUsed
+	}
+	return 0
+}
+
+func (x *TransferLimitUsage) GetMonthlyUsed() int64 {
+	if x != nil {
+		return x.MonthlyUsed
+	}
+	return 0
+}
+
+func (x *TransferLimitUsage) GetDailyRemaining() int64 {
+	if x != nil && x.DailyRemaining != nil {
+		return *x.DailyRemaining
+	}
+	return 0
+}
+
+func (x *TransferLimitUsage) GetMonthlyRemaining() int64 {
+	if x != nil && x.MonthlyRemaining != nil {
+		return *x.MonthlyRemaining
+	}
+	return 0
+}
+
+type TransferLimit struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// default, user or account
+	Scope     string                 `protobuf:"bytes,2,opt,name=scope,proto3" json:"scope,omitempty"`
+	Currency  string                 `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
+	Username  string                 `protobuf:"bytes,4,opt,name=username,proto3" json:"username,omitempty"`
+	AccountId int64                  `protobuf:"varint,5,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Limits    *TransferLimits        `protobuf:"bytes,6,opt,name=limits,proto3" json:"limits,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+}
+
+func (x *TransferLimit) Reset() {
+	*x = TransferLimit{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_transfer_limit_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TransferLimit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferLimit) ProtoMessage() {}
+
+func (x *TransferLimit) ProtoReflect() protoreflect.Message {
+	mi := &file_transfer_limit_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferLimit.ProtoReflect.Descriptor instead.
+func (*TransferLimit) Descriptor() ([]byte, []int) {
+	return file_transfer_limit_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TransferLimit) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *TransferLimit) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+func (x *TransferLimit) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *TransferLimit) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *TransferLimit) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *TransferLimit) GetLimits() *TransferLimits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *TransferLimit) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+var File_transfer_limit_proto protoreflect.FileDescriptor
+
+var file_transfer_limit_proto_rawDesc = []byte{
+	0x0a, 0x14, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x02, 0x70, 0x62, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x78, 0x0a, 0x0e, 0x54,
+	0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x12, 0x28, 0x0a,
+	0x10, 0x70, 0x65, 0x72, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x5f, 0x6d, 0x61,
+	0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x70, 0x65, 0x72, 0x54, 0x72, 0x61, 0x6e,
+	0x73, 0x66, 0x65, 0x72, 0x4d, 0x61, 0x78, 0x12, 0x1b, 0x0a, 0x09, 0x64, 0x61, 0x69, 0x6c, 0x79,
+	0x5f, 0x6d, 0x61, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x64, 0x61, 0x69, 0x6c,
+	0x79, 0x4d, 0x61, 0x78, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x6f, 0x6e, 0x74, 0x68, 0x6c, 0x79, 0x5f,
+	0x6d, 0x61, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x6d, 0x6f, 0x6e, 0x74, 0x68,
+	0x6c, 0x79, 0x4d, 0x61, 0x78, 0x22, 0xa2, 0x02, 0x0a, 0x12, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66,
+	0x65, 0x72, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x73, 0x63, 0x6f, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x63, 0x6f,
+	0x70, 0x65, 0x12, 0x2a, 0x0a, 0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x62, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72,
+	0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x52, 0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x12, 0x1d,
+	0x0a, 0x0a, 0x64, 0x61, 0x69, 0x6c, 0x79, 0x5f, 0x75, 0x73, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x09, 0x64, 0x61, 0x69, 0x6c, 0x79, 0x55, 0x73, 0x65, 0x64, 0x12, 0x21, 0x0a,
+	0x0c, 0x6d, 0x6f, 0x6e, 0x74, 0x68, 0x6c, 0x79, 0x5f, 0x75, 0x73, 0x65, 0x64, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0b, 0x6d, 0x6f, 0x6e, 0x74, 0x68, 0x6c, 0x79, 0x55, 0x73, 0x65, 0x64,
+	0x12, 0x2c, 0x0a, 0x0f, 0x64, 0x61, 0x69, 0x6c, 0x79, 0x5f, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e,
+	0x69, 0x6e, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x48, 0x00, 0x52, 0x0e, 0x64, 0x61, 0x69,
+	0x6c, 0x79, 0x52, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x88, 0x01, 0x01, 0x12, 0x30,
+	0x0a, 0x11, 0x6d, 0x6f, 0x6e, 0x74, 0x68, 0x6c, 0x79, 0x5f, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e,
+	0x69, 0x6e, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x48, 0x01, 0x52, 0x10, 0x6d, 0x6f, 0x6e,
+	0x74, 0x68, 0x6c, 0x79, 0x52, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x88, 0x01, 0x01,
+	0x42, 0x12, 0x0a, 0x10, 0x5f, 0x64, 0x61, 0x69, 0x6c, 0x79, 0x5f, 0x72, 0x65, 0x6d, 0x61, 0x69,
+	0x6e, 0x69, 0x6e, 0x67, 0x42, 0x14, 0x0a, 0x12, 0x5f, 0x6d, 0x6f, 0x6e, 0x74, 0x68, 0x6c, 0x79,
+	0x5f, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x22, 0xf3, 0x01, 0x0a, 0x0d, 0x54,
+	0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05,
+	0x73, 0x63, 0x6f, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x63, 0x6f,
+	0x70, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x1a,
+	0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x63,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
+	0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x2a, 0x0a, 0x06, 0x6c, 0x69, 0x6d,
+	0x69, 0x74, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x62, 0x2e, 0x54,
+	0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x52, 0x06, 0x6c,
+	0x69, 0x6d, 0x69, 0x74, 0x73, 0x12, 0x39, 0x0a, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64,
+	0x5f, 0x61, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74,
+	0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6b,
+	0x77, 0x61, 0x6c, 0x74, 0x65, 0x72, 0x32, 0x36, 0x2f, 0x75, 0x64, 0x65, 0x6d, 0x79, 0x2d, 0x73,
+	0x69, 0x6d, 0x70, 0x6c, 0x65, 0x62, 0x61, 0x6e, 0x6b, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_transfer_limit_proto_rawDescOnce sync.Once
+	file_transfer_limit_proto_rawDescData = file_transfer_limit_proto_rawDesc
+)
+
+func file_transfer_limit_proto_rawDescGZIP() []byte {
+	file_transfer_limit_proto_rawDescOnce.Do(func() {
+		file_transfer_limit_proto_rawDescData = protoimpl.X.CompressGZIP(file_transfer_limit_proto_rawDescData)
+	})
+	return file_transfer_limit_proto_rawDescData
+}
+
+var file_transfer_limit_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_transfer_limit_proto_goTypes = []interface{}{
+	(*TransferLimits)(nil),        // 0: pb.TransferLimits
+	(*TransferLimitUsage)(nil),    // 1: pb.TransferLimitUsage
+	(*TransferLimit)(nil),         // 2: pb.TransferLimit
+	(*timestamppb.Timestamp)(nil), // 3: google.protobuf.Timestamp
+}
+var file_transfer_limit_proto_depIdxs = []int32{
+	0, // 0: pb.TransferLimitUsage.limits:type_name -> pb.TransferLimits
+	0, // 1: pb.TransferLimit.limits:type_name -> pb.TransferLimits
+	3, // 2: pb.TransferLimit.updated_at:type_name -> google.protobuf.Timestamp
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_transfer_limit_proto_init() }
+func file_transfer_limit_proto_init() {
+	if File_transfer_limit_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_transfer_limit_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TransferLimits); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_transfer_limit_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TransferLimitUsage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_transfer_limit_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TransferLimit); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_transfer_limit_proto_msgTypes[1].OneofWrappers = []interface{}{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_transfer_limit_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_transfer_limit_proto_goTypes,
+		DependencyIndexes: file_transfer_limit_proto_depIdxs,
+		MessageInfos:      file_transfer_limit_proto_msgTypes,
+	}.Build()
+	File_transfer_limit_proto = out.File
+	file_transfer_limit_proto_rawDesc = nil
+	file_transfer_limit_proto_goTypes = nil
+	file_transfer_limit_proto_depIdxs = nil
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"math"
+	"strconv"
+	"time"
+)
+
+const retryAfterHeader = "retry-after"
+
+// KeyFunc returns the bucket key of a request, usually the authenticated username or the client IP.
+type KeyFunc func(ctx context.Context) string
+
+// UnaryServerInterceptor rejects requests with ResourceExhausted once the caller ran out of tokens for the method.
+// The retry-after header and a RetryInfo detail tell the caller how long to wait. If the limiter itself fails the
+// request is let through, so that an unavailable backend does not take the API down.
+func UnaryServerInterceptor(limiter Limiter, limits Limits, keyFunc KeyFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		limit, ok := limits.For(info.FullMethod)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		key := fmt.Sprintf("%s:%s", info.FullMethod, keyFunc(ctx))
+		result, err := limiter.Allow(ctx, key, limit)
+		if err != nil {
+			logging.Ctx(ctx).Error().Err(err).Str("method", info.FullMethod).Msg("rate limiter failed")
+			return handler(ctx, req)
+		}
+		if !result.Allowed {
+			_ = grpc.SetHeader(ctx, metadata.Pairs(retryAfterHeader, retryAfterSeconds(result.RetryAfter)))
+			return nil, exhaustedError(result.RetryAfter)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func exhaustedError(retryAfter time.Duration) error {
+	st := status.New(codes.ResourceExhausted, fmt.Sprintf("rate limit exceeded, retry in %ss", retryAfterSeconds(retryAfter)))
+
+	detail, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if err != nil {
+		return st.Err()
+	}
+	return detail.Err()
+}
+
+// retryAfterSeconds rounds up to whole seconds, as expected by the Retry-After header.
+func retryAfterSeconds(retryAfter time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+}
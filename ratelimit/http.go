@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"google.golang.org/grpc/codes"
+	"net/http"
+)
+
+// MethodFunc maps an HTTP request to the method name its limit is configured under.
+type MethodFunc func(req *http.Request) string
+
+// HttpKeyFunc returns the bucket key of an HTTP request, usually the authenticated username or the client IP.
+type HttpKeyFunc func(req *http.Request) string
+
+type errorBody struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// HttpMiddleware rejects requests with 429 Too Many Requests and a Retry-After header once the caller ran out of
+// tokens for the method. The error body has the same shape as the gateway errors.
+func HttpMiddleware(limiter Limiter, limits Limits, methodFunc MethodFunc, keyFunc HttpKeyFunc, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		method := methodFunc(req)
+		limit, ok := limits.For(method)
+		if !ok {
+			handler.ServeHTTP(res, req)
+			return
+		}
+
+		key := fmt.Sprintf("%s:%s", method, keyFunc(req))
+		result, err := limiter.Allow(req.Context(), key, limit)
+		if err != nil {
+			logging.Ctx(req.Context()).Error().Err(err).Str("method", method).Msg("rate limiter failed")
+			handler.ServeHTTP(res, req)
+			return
+		}
+		if !result.Allowed {
+			seconds := retryAfterSeconds(result.RetryAfter)
+			res.Header().Set("Retry-After", seconds)
+			res.Header().Set("Content-Type", "application/json")
+			res.WriteHeader(http.StatusTooManyRequests)
+			_ = json.NewEncoder(res).Encode(errorBody{
+				Code:    int(codes.ResourceExhausted),
+				Message: fmt.Sprintf("rate limit exceeded, retry in %ss", seconds),
+			})
+			return
+		}
+
+		handler.ServeHTTP(res, req)
+	})
+}
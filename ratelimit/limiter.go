@@ -0,0 +1,24 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit is a token bucket: Rate tokens are added every second, up to Burst tokens.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Limiter takes a token from the bucket identified by key.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMethod is the method name used for the limit applied to methods without their own entry.
+const DefaultMethod = "*"
+
+// Limits maps method names to their limit. Methods are looked up by full name ("/pb.SimpleBank/Login") first and by
+// short name ("Login") second, before falling back to the default.
+type Limits map[string]Limit
+
+// ParseLimits parses a comma separated list of method=requests/period[:burst] entries, for example
+// "*=20/s:40,CreateUser=5/m,Login=10/m:5". The period is one of s, m or h. The burst defaults to the number of
+// requests.
+func ParseLimits(spec string) (Limits, error) {
+	limits := Limits{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		method, value, ok := strings.Cut(entry, "=")
+		if !ok || method == "" {
+			return nil, fmt.Errorf("invalid rate limit %q: expected method=requests/period[:burst]", entry)
+		}
+
+		limit, err := parseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit %q: %w", entry, err)
+		}
+		limits[strings.TrimSpace(method)] = limit
+	}
+	return limits, nil
+}
+
+func parseLimit(value string) (Limit, error) {
+	rate, burst, hasBurst := strings.Cut(strings.TrimSpace(value), ":")
+	requests, period, ok := strings.Cut(rate, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("missing period")
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("requests must be a positive integer")
+	}
+
+	var duration time.Duration
+	switch period {
+	case "s":
+		duration = time.Second
+	case "m":
+		duration = time.Minute
+	case "h":
+		duration = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("unsupported period %q", period)
+	}
+
+	limit := Limit{Rate: float64(n) / duration.Seconds(), Burst: n}
+	if hasBurst {
+		limit.Burst, err = strconv.Atoi(burst)
+		if err != nil || limit.Burst <= 0 {
+			return Limit{}, fmt.Errorf("burst must be a positive integer")
+		}
+	}
+	return limit, nil
+}
+
+// For returns the limit of a method, and false if the method is not limited.
+func (l Limits) For(fullMethod string) (Limit, bool) {
+	if limit, ok := l[fullMethod]; ok {
+		return limit, true
+	}
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		if limit, ok := l[fullMethod[i+1:]]; ok {
+			return limit, true
+		}
+	}
+	limit, ok := l[DefaultMethod]
+	return limit, ok
+}
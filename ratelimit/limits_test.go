@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits("*=20/s:40, CreateUser=6/m, /pb.SimpleBank/Login=10/h:2")
+	require.NoError(t, err)
+	require.Len(t, limits, 3)
+
+	limit, ok := limits.For("/pb.SimpleBank/CreateUser")
+	require.True(t, ok)
+	require.Equal(t, Limit{Rate: 0.1, Burst: 6}, limit)
+
+	limit, ok = limits.For("/pb.SimpleBank/Login")
+	require.True(t, ok)
+	require.Equal(t, 2, limit.Burst)
+	require.InDelta(t, 10.0/3600, limit.Rate, 1e-9)
+
+	limit, ok = limits.For("/pb.SimpleBank/UpdateUser")
+	require.True(t, ok)
+	require.Equal(t, Limit{Rate: 20, Burst: 40}, limit)
+}
+
+func TestParseLimitsEmpty(t *testing.T) {
+	limits, err := ParseLimits("")
+	require.NoError(t, err)
+
+	_, ok := limits.For("/pb.SimpleBank/Login")
+	require.False(t, ok)
+}
+
+func TestParseLimitsInvalid(t *testing.T) {
+	for _, spec := range []string{"Login", "Login=10", "Login=ten/s", "Login=10/d", "Login=10/s:0", "=10/s", "Login=-1/s"} {
+		_, err := ParseLimits(spec)
+		require.Error(t, err, spec)
+	}
+}
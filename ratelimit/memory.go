@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// minSweepInserts is the number of new buckets after which full buckets are swept at the earliest.
+const minSweepInserts = 1024
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+	// fullAt is when the bucket has refilled to its burst, after which it is no different from a new bucket.
+	fullAt time.Time
+}
+
+// MemoryLimiter keeps token buckets in process memory. It is meant for a single instance deployment and for tests.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+	// inserts counts the buckets created since the last sweep, and swept is the number of buckets it left.
+	inserts int
+	swept   int
+}
+
+// NewMemoryLimiter creates a new MemoryLimiter
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		l.sweep(now)
+		b = &bucket{tokens: float64(limit.Burst), lastSeen: now}
+		l.buckets[key] = b
+		l.inserts++
+	}
+
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	b.lastSeen = now
+
+	if b.tokens < 1 {
+		retryAfter := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+		b.fullAt = now.Add(refillTime(float64(limit.Burst)-b.tokens, limit))
+		return Result{Allowed: false, Remaining: 0, RetryAfter: retryAfter}, nil
+	}
+
+	b.tokens--
+	b.fullAt = now.Add(refillTime(float64(limit.Burst)-b.tokens, limit))
+	return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+// sweep drops the buckets that have refilled, so that the map does not grow forever. Dropping them loses nothing
+// since a full bucket is what a new one starts with. The map is swept once as many buckets were created as it held
+// after the previous sweep, which keeps the cost of a sweep spread over the inserts that made it necessary.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if l.inserts < minSweepInserts || l.inserts < l.swept {
+		return
+	}
+	for key, b := range l.buckets {
+		if !now.Before(b.fullAt) {
+			delete(l.buckets, key)
+		}
+	}
+	l.inserts = 0
+	l.swept = len(l.buckets)
+}
+
+// refillTime returns how long a bucket takes to get the tokens back.
+func refillTime(tokens float64, limit Limit) time.Duration {
+	return time.Duration(tokens / limit.Rate * float64(time.Second))
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+
+	limit := Limit{Rate: 1, Burst: 3}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		result, err := limiter.Allow(ctx, "key", limit)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, i, result.Remaining)
+	}
+
+	result, err := limiter.Allow(ctx, "key", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, time.Second, result.RetryAfter)
+
+	// other keys have their own bucket
+	result, err = limiter.Allow(ctx, "other", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	now = now.Add(500 * time.Millisecond)
+	result, err = limiter.Allow(ctx, "key", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	now = now.Add(500 * time.Millisecond)
+	result, err = limiter.Allow(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	// the bucket never holds more than the burst
+	now = now.Add(time.Hour)
+	result, err = limiter.Allow(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 2, result.Remaining)
+}
+
+func TestMemoryLimiterSweep(t *testing.T) {
+	now := time.Now()
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+
+	limit := Limit{Rate: 1, Burst: 3}
+	ctx := context.Background()
+
+	// drain one bucket, it needs 3 seconds to refill
+	for i := 0; i < 4; i++ {
+		_, err := limiter.Allow(ctx, "drained", limit)
+		require.NoError(t, err)
+	}
+	for i := 1; i < minSweepInserts; i++ {
+		_, err := limiter.Allow(ctx, fmt.Sprintf("key-%d", i), limit)
+		require.NoError(t, err)
+	}
+	require.Len(t, limiter.buckets, minSweepInserts)
+
+	// the buckets that took a single token are full again after a second
+	now = now.Add(time.Second)
+	_, err := limiter.Allow(ctx, "new", limit)
+	require.NoError(t, err)
+	require.Len(t, limiter.buckets, 2)
+	require.Contains(t, limiter.buckets, "drained")
+
+	result, err := limiter.Allow(ctx, "drained", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 0, result.Remaining)
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, Limit) (Result, error) {
+	return Result{}, errors.New("backend down")
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	limits := Limits{"Login": {Rate: 0.5, Burst: 1}}
+	keyFunc := func(ctx context.Context) string { return "ip:10.0.0.1" }
+	interceptor := UnaryServerInterceptor(NewMemoryLimiter(), limits, keyFunc)
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	login := &grpc.UnaryServerInfo{FullMethod: "/pb.SimpleBank/Login"}
+	updateUser := &grpc.UnaryServerInfo{FullMethod: "/pb.SimpleBank/UpdateUser"}
+
+	res, err := interceptor(context.Background(), nil, login, handler)
+	require.NoError(t, err)
+	require.Equal(t, "ok", res)
+
+	res, err = interceptor(context.Background(), nil, login, handler)
+	require.Nil(t, res)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+
+	// methods without a limit are never rejected
+	for i := 0; i < 5; i++ {
+		_, err = interceptor(context.Background(), nil, updateUser, handler)
+		require.NoError(t, err)
+	}
+
+	// a failing backend lets requests through
+	interceptor = UnaryServerInterceptor(failingLimiter{}, limits, keyFunc)
+	_, err = interceptor(context.Background(), nil, login, handler)
+	require.NoError(t, err)
+}
+
+func TestHttpMiddleware(t *testing.T) {
+	limits := Limits{DefaultMethod: {Rate: 0.5, Burst: 2}}
+	methodFunc := func(req *http.Request) string { return req.URL.Path }
+	keyFunc := func(req *http.Request) string { return req.RemoteAddr }
+	next := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusOK)
+	})
+	handler := HttpMiddleware(NewMemoryLimiter(), limits, methodFunc, keyFunc, next)
+
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/login_user", nil)
+		req.RemoteAddr = remoteAddr
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	require.Equal(t, http.StatusOK, serve("10.0.0.1:1000").Code)
+	require.Equal(t, http.StatusOK, serve("10.0.0.1:1000").Code)
+
+	recorder := serve("10.0.0.1:1000")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "2", recorder.Header().Get("Retry-After"))
+	require.JSONEq(t, `{"code":8,"message":"rate limit exceeded, retry in 2s"}`, recorder.Body.String())
+
+	require.Equal(t, http.StatusOK, serve("10.0.0.2:1000").Code)
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	require.Equal(t, "1", retryAfterSeconds(10*time.Millisecond))
+	require.Equal(t, "2", retryAfterSeconds(2*time.Second))
+	require.Equal(t, "3", retryAfterSeconds(2*time.Second+time.Millisecond))
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// tokenBucketScript refills and takes a token from the bucket stored in a hash at KEYS[1]. The Redis clock is used
+// so that every instance shares the same notion of time.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local data = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil then
+  tokens = burst
+  ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed = 0
+local retry_after = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry_after = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, math.floor(tokens), retry_after}
+`)
+
+// RedisLimiter keeps token buckets in Redis so that the limit is shared by every instance.
+type RedisLimiter struct {
+	client redis.Scripter
+	prefix string
+}
+
+// NewRedisLimiter creates a new RedisLimiter
+func NewRedisLimiter(client redis.Scripter) *RedisLimiter {
+	return &RedisLimiter{client: client, prefix: "ratelimit:"}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	values, err := tokenBucketScript.Run(ctx, l.client, []string{l.prefix + key}, limit.Rate, limit.Burst).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to run rate limit script: %w", err)
+	}
+	if len(values) != 3 {
+		return Result{}, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
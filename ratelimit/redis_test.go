@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRedisLimiter(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	limiter := NewRedisLimiter(client)
+
+	limit := Limit{Rate: 0.001, Burst: 2}
+	ctx := context.Background()
+
+	result, err := limiter.Allow(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 1, result.Remaining)
+
+	result, err = limiter.Allow(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 0, result.Remaining)
+
+	result, err = limiter.Allow(ctx, "key", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Positive(t, result.RetryAfter)
+
+	require.True(t, server.Exists("ratelimit:key"))
+	require.Positive(t, server.TTL("ratelimit:key"))
+
+	result, err = limiter.Allow(ctx, "other", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+}
@@ -0,0 +1,221 @@
+package statement
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats of a statement
+const (
+	FormatCSV  = "csv"
+	FormatOFX  = "ofx"
+	FormatJSON = "json"
+	FormatPDF  = "pdf"
+)
+
+// Formats lists the statement formats.
+var Formats = []string{FormatCSV, FormatOFX, FormatJSON, FormatPDF}
+
+// ofxBankID identifies the bank in the account of an OFX statement.
+const ofxBankID = "SIMPLEBANK"
+
+// IsSupportedFormat reports whether format is one of the statement formats.
+func IsSupportedFormat(format string) bool {
+	switch format {
+	case FormatCSV, FormatOFX, FormatJSON, FormatPDF:
+		return true
+	}
+	return false
+}
+
+// ContentType returns the media type of a statement format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatOFX:
+		return "application/x-ofx"
+	case FormatPDF:
+		return "application/pdf"
+	}
+	return "application/json"
+}
+
+// FileName returns the name a statement is downloaded or attached as, e.g. statement-12-20230101-20230201.csv.
+func FileName(accountID int64, period Period, format string) string {
+	return fmt.Sprintf("statement-%d-%s-%s.%s", accountID, period.From.UTC().Format("20060102"), period.To.UTC().Format("20060102"), format)
+}
+
+// NewWriter returns the writer of a statement format.
+func NewWriter(format string, out io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{out: csv.NewWriter(out)}, nil
+	case FormatOFX:
+		return &ofxWriter{out: bufio.NewWriter(out)}, nil
+	case FormatJSON:
+		return &jsonWriter{out: bufio.NewWriter(out)}, nil
+	case FormatPDF:
+		return newPDFWriter(out), nil
+	}
+	return nil, fmt.Errorf("unsupported statement format %q", format)
+}
+
+// csvWriter writes one row per line, between an opening and a closing balance row.
+type csvWriter struct {
+	out    *csv.Writer
+	header Header
+}
+
+func (w *csvWriter) WriteHeader(header Header) error {
+	w.header = header
+	w.out.Write([]string{"time", "description", "amount", "balance", "currency", "entry_id", "transfer_id", "counterparty_account_id"})
+	return w.writeBalance(header.From, "Opening balance", header.OpeningBalance)
+}
+
+func (w *csvWriter) WriteLine(line Line) error {
+	w.out.Write([]string{
+		line.Time.UTC().Format(time.RFC3339),
+		line.Description,
+		strconv.FormatInt(line.Amount, 10),
+		strconv.FormatInt(line.Balance, 10),
+		w.header.Currency,
+		strconv.FormatInt(line.EntryID, 10),
+		optionalID(line.TransferID),
+		optionalID(line.CounterpartyAccountID),
+	})
+	return w.out.Error()
+}
+
+func (w *csvWriter) Close() error {
+	if err := w.writeBalance(w.header.To, "Closing balance", w.header.ClosingBalance); err != nil {
+		return err
+	}
+	w.out.Flush()
+	return w.out.Error()
+}
+
+func (w *csvWriter) writeBalance(at time.Time, description string, balance int64) error {
+	w.out.Write([]string{at.UTC().Format(time.RFC3339), description, "", strconv.FormatInt(balance, 10), w.header.Currency, "", "", ""})
+	return w.out.Error()
+}
+
+func optionalID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// ofxWriter writes an OFX 2.x bank statement response.
+type ofxWriter struct {
+	out    *bufio.Writer
+	header Header
+}
+
+// ofxTransaction is the STMTTRN aggregate of a line. The entry id is the FITID, which importers use to drop
+// transactions they already have.
+type ofxTransaction struct {
+	XMLName xml.Name `xml:"STMTTRN"`
+	Type    string   `xml:"TRNTYPE"`
+	Posted  string   `xml:"DTPOSTED"`
+	Amount  int64    `xml:"TRNAMT"`
+	FitID   int64    `xml:"FITID"`
+	Name    string   `xml:"NAME"`
+	Memo    string   `xml:"MEMO,omitempty"`
+}
+
+func (w *ofxWriter) WriteHeader(header Header) error {
+	w.header = header
+	fmt.Fprintf(w.out, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><TRNUID>0</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+<STMTRS><CURDEF>%s</CURDEF>
+<BANKACCTFROM><BANKID>%s</BANKID><ACCTID>%d</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>
+<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>
+`, ofxTime(header.GeneratedAt), ofxEscape(header.Currency), ofxBankID, header.AccountID, ofxTime(header.From), ofxTime(header.To))
+	return nil
+}
+
+func (w *ofxWriter) WriteLine(line Line) error {
+	transaction := ofxTransaction{
+		Type:   "CREDIT",
+		Posted: ofxTime(line.Time),
+		Amount: line.Amount,
+		FitID:  line.EntryID,
+		Name:   line.Description,
+	}
+	if line.Amount < 0 {
+		transaction.Type = "DEBIT"
+	}
+	if line.TransferID != 0 {
+		transaction.Memo = fmt.Sprintf("Transfer %d", line.TransferID)
+	}
+	if err := xml.NewEncoder(w.out).Encode(transaction); err != nil {
+		return err
+	}
+	_, err := w.out.WriteString("\n")
+	return err
+}
+
+func (w *ofxWriter) Close() error {
+	fmt.Fprintf(w.out, `</BANKTRANLIST>
+<LEDGERBAL><BALAMT>%d</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`, w.header.ClosingBalance, ofxTime(w.header.To))
+	return w.out.Flush()
+}
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:UTC]"
+}
+
+func ofxEscape(value string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(value))
+	return escaped.String()
+}
+
+// jsonWriter writes the header fields and a lines array as one JSON object, one line at a time.
+type jsonWriter struct {
+	out   *bufio.Writer
+	lines int
+}
+
+func (w *jsonWriter) WriteHeader(header Header) error {
+	data, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	w.out.Write(data[:len(data)-1])
+	_, err = w.out.WriteString(`,"lines":[`)
+	return err
+}
+
+func (w *jsonWriter) WriteLine(line Line) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	if w.lines > 0 {
+		w.out.WriteString(",")
+	}
+	w.lines++
+	_, err = w.out.Write(data)
+	return err
+}
+
+func (w *jsonWriter) Close() error {
+	w.out.WriteString("]}\n")
+	return w.out.Flush()
+}
@@ -0,0 +1,144 @@
+package statement
+
+import (
+	"fmt"
+	"github.com/go-pdf/fpdf"
+	"io"
+	"strconv"
+)
+
+// pdfBrand is the name and color of the bank on PDF statements.
+const pdfBrand = "Simple Bank"
+
+var (
+	pdfBrandColor = [3]int{0, 82, 147}
+	pdfStripe     = [3]int{235, 241, 248}
+)
+
+// pdfColumns are the widths in mm of the date, description, amount and balance columns, which fill an A4 page
+// between its margins.
+var pdfColumns = [4]float64{32, 78, 40, 40}
+
+// pdfWriter renders a branded statement. fpdf builds the whole document in memory and writes it on Close.
+type pdfWriter struct {
+	out        io.Writer
+	pdf        *fpdf.Fpdf
+	translate  func(string) string
+	header     Header
+	lines      int
+	tableStart bool
+}
+
+func newPDFWriter(out io.Writer) *pdfWriter {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AliasNbPages("")
+	w := &pdfWriter{out: out, pdf: pdf, translate: pdf.UnicodeTranslatorFromDescriptor("")}
+	pdf.SetHeaderFuncMode(w.pageHeader, true)
+	pdf.SetFooterFunc(w.pageFooter)
+	return w
+}
+
+func (w *pdfWriter) WriteHeader(header Header) error {
+	w.header = header
+	w.pdf.SetTitle(fmt.Sprintf("%s statement %d", pdfBrand, header.AccountID), true)
+	w.pdf.SetAuthor(pdfBrand, true)
+	w.pdf.SetCreationDate(header.GeneratedAt)
+	w.pdf.AddPage()
+
+	w.pdf.SetFont("Helvetica", "B", 14)
+	w.pdf.SetTextColor(0, 0, 0)
+	w.pdf.CellFormat(0, 8, "Account statement", "", 1, "L", false, 0, "")
+	w.pdf.SetFont("Helvetica", "", 10)
+	w.summaryRow("Account", fmt.Sprintf("%d (%s)", header.AccountID, header.Currency))
+	w.summaryRow("Owner", header.Owner)
+	w.summaryRow("Period", fmt.Sprintf("%s to %s", header.From.UTC().Format("2006-01-02"), header.To.UTC().Add(-1).Format("2006-01-02")))
+	w.summaryRow("Opening balance", w.money(header.OpeningBalance))
+	w.summaryRow("Closing balance", w.money(header.ClosingBalance))
+	w.pdf.Ln(4)
+
+	w.tableStart = true
+	w.tableHeader()
+	w.balanceRow("Opening balance", header.OpeningBalance)
+	return w.pdf.Error()
+}
+
+func (w *pdfWriter) WriteLine(line Line) error {
+	w.lines++
+	w.pdf.SetFont("Helvetica", "", 9)
+	w.pdf.SetTextColor(0, 0, 0)
+	w.pdf.SetFillColor(pdfStripe[0], pdfStripe[1], pdfStripe[2])
+	fill := w.lines%2 == 0
+	w.pdf.CellFormat(pdfColumns[0], 6, line.Time.UTC().Format("2006-01-02 15:04"), "", 0, "L", fill, 0, "")
+	w.pdf.CellFormat(pdfColumns[1], 6, w.translate(line.Description), "", 0, "L", fill, 0, "")
+	w.pdf.CellFormat(pdfColumns[2], 6, strconv.FormatInt(line.Amount, 10), "", 0, "R", fill, 0, "")
+	w.pdf.CellFormat(pdfColumns[3], 6, strconv.FormatInt(line.Balance, 10), "", 1, "R", fill, 0, "")
+	return w.pdf.Error()
+}
+
+func (w *pdfWriter) Close() error {
+	w.balanceRow("Closing balance", w.header.ClosingBalance)
+	if w.lines == 0 {
+		w.pdf.Ln(2)
+		w.pdf.SetFont("Helvetica", "I", 9)
+		w.pdf.CellFormat(0, 6, "No transactions in this period.", "", 1, "L", false, 0, "")
+	}
+	return w.pdf.Output(w.out)
+}
+
+// pageHeader draws the brand band on every page, and repeats the column titles on pages the table continues on.
+func (w *pdfWriter) pageHeader() {
+	w.pdf.SetFillColor(pdfBrandColor[0], pdfBrandColor[1], pdfBrandColor[2])
+	w.pdf.Rect(0, 0, 210, 18, "F")
+	w.pdf.SetY(5)
+	w.pdf.SetFont("Helvetica", "B", 16)
+	w.pdf.SetTextColor(255, 255, 255)
+	w.pdf.CellFormat(0, 8, pdfBrand, "", 0, "L", false, 0, "")
+	w.pdf.SetFont("Helvetica", "", 9)
+	w.pdf.CellFormat(0, 8, fmt.Sprintf("Generated %s", w.header.GeneratedAt.UTC().Format("2006-01-02 15:04 MST")), "", 1, "R", false, 0, "")
+	w.pdf.SetY(24)
+	if w.tableStart {
+		w.tableHeader()
+	}
+}
+
+func (w *pdfWriter) pageFooter() {
+	w.pdf.SetY(-15)
+	w.pdf.SetFont("Helvetica", "I", 8)
+	w.pdf.SetTextColor(128, 128, 128)
+	w.pdf.CellFormat(0, 10, fmt.Sprintf("Account %d - page %d of {nb}", w.header.AccountID, w.pdf.PageNo()), "", 0, "C", false, 0, "")
+}
+
+func (w *pdfWriter) tableHeader() {
+	w.pdf.SetFont("Helvetica", "B", 9)
+	w.pdf.SetFillColor(pdfBrandColor[0], pdfBrandColor[1], pdfBrandColor[2])
+	w.pdf.SetTextColor(255, 255, 255)
+	titles := [4]string{"Date", "Description", "Amount", "Balance"}
+	aligns := [4]string{"L", "L", "R", "R"}
+	for i, title := range titles {
+		ln := 0
+		if i == len(titles)-1 {
+			ln = 1
+		}
+		w.pdf.CellFormat(pdfColumns[i], 7, title, "", ln, aligns[i], true, 0, "")
+	}
+}
+
+func (w *pdfWriter) balanceRow(description string, balance int64) {
+	w.pdf.SetFont("Helvetica", "B", 9)
+	w.pdf.SetTextColor(0, 0, 0)
+	w.pdf.CellFormat(pdfColumns[0]+pdfColumns[1]+pdfColumns[2], 7, description, "T", 0, "L", false, 0, "")
+	w.pdf.CellFormat(pdfColumns[3], 7, strconv.FormatInt(balance, 10), "T", 1, "R", false, 0, "")
+}
+
+func (w *pdfWriter) summaryRow(label string, value string) {
+	w.pdf.SetFont("Helvetica", "B", 10)
+	w.pdf.CellFormat(40, 6, label, "", 0, "L", false, 0, "")
+	w.pdf.SetFont("Helvetica", "", 10)
+	w.pdf.CellFormat(0, 6, w.translate(value), "", 1, "L", false, 0, "")
+}
+
+func (w *pdfWriter) money(amount int64) string {
+	return fmt.Sprintf("%d %s", amount, w.header.Currency)
+}
@@ -0,0 +1,187 @@
+package statement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"time"
+)
+
+// pageSize is how many entries are read at once, so that a long period is never held in memory.
+const pageSize = 500
+
+// dateLayout is the layout of a period given as days.
+const dateLayout = "2006-01-02"
+
+// Period is the time range [From, To) of a statement.
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+// ParsePeriod parses the bounds of a statement, either as dates or as RFC 3339 times. A date bound covers the
+// whole day in UTC, so "2023-01-01" to "2023-01-31" is the month of January. An empty to is now.
+func ParsePeriod(from string, to string, now time.Time) (Period, error) {
+	var period Period
+	if from == "" {
+		return period, errors.New("from is required")
+	}
+	var err error
+	period.From, err = parseBound(from, false)
+	if err != nil {
+		return period, fmt.Errorf("invalid from: %w", err)
+	}
+	period.To = now
+	if to != "" {
+		period.To, err = parseBound(to, true)
+		if err != nil {
+			return period, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	if !period.From.Before(period.To) {
+		return period, errors.New("from must be before to")
+	}
+	return period, nil
+}
+
+// Month returns the calendar month in UTC that t falls in.
+func Month(t time.Time) Period {
+	t = t.UTC()
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return Period{From: from, To: from.AddDate(0, 1, 0)}
+}
+
+// PreviousMonth returns the calendar month in UTC before the one t falls in.
+func PreviousMonth(t time.Time) Period {
+	return Month(Month(t).From.Add(-time.Nanosecond))
+}
+
+func parseBound(value string, end bool) (time.Time, error) {
+	if day, err := time.Parse(dateLayout, value); err == nil {
+		if end {
+			day = day.AddDate(0, 0, 1)
+		}
+		return day, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return parsed, errors.New("must be a date (YYYY-MM-DD) or an RFC 3339 time")
+	}
+	return parsed, nil
+}
+
+// Header describes the statement of an account. The closing balance is the opening balance plus the amounts of
+// all lines.
+type Header struct {
+	AccountID      int64     `json:"account_id"`
+	Owner          string    `json:"owner"`
+	Currency       string    `json:"currency"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	OpeningBalance int64     `json:"opening_balance"`
+	ClosingBalance int64     `json:"closing_balance"`
+	GeneratedAt    time.Time `json:"generated_at"`
+}
+
+// Line is one entry of the account, with the balance right after it.
+type Line struct {
+	EntryID               int64     `json:"entry_id"`
+	Time                  time.Time `json:"time"`
+	Description           string    `json:"description"`
+	Amount                int64     `json:"amount"`
+	Balance               int64     `json:"balance"`
+	TransferID            int64     `json:"transfer_id,omitempty"`
+	CounterpartyAccountID int64     `json:"counterparty_account_id,omitempty"`
+}
+
+// Writer renders a statement: the header first, then the lines in order, then Close.
+type Writer interface {
+	WriteHeader(header Header) error
+	WriteLine(line Line) error
+	Close() error
+}
+
+// Generate writes the statement of account for the period. The balances are read in one query, and the lines
+// stop at the last entry that query saw, so that entries made while the statement is written do not break the
+// running balance.
+func Generate(ctx context.Context, store db.Store, account db.Account, period Period, writer Writer) (Header, error) {
+	balances, err := store.GetAccountStatementBalances(ctx, db.GetAccountStatementBalancesParams{
+		AccountID: account.ID,
+		FromTime:  period.From,
+		ToTime:    period.To,
+	})
+	if err != nil {
+		return Header{}, fmt.Errorf("failed to get statement balances: %w", err)
+	}
+
+	header := Header{
+		AccountID:      account.ID,
+		Owner:          account.Owner,
+		Currency:       account.Currency,
+		From:           period.From,
+		To:             period.To,
+		ClosingBalance: balances.Balance - balances.AfterPeriod,
+		GeneratedAt:    time.Now(),
+	}
+	header.OpeningBalance = header.ClosingBalance - balances.InPeriod
+	if err := writer.WriteHeader(header); err != nil {
+		return header, err
+	}
+
+	balance := header.OpeningBalance
+	var afterID int64
+	for {
+		entries, err := store.ListStatementEntries(ctx, db.ListStatementEntriesParams{
+			AccountID:   account.ID,
+			FromTime:    period.From,
+			ToTime:      period.To,
+			AfterID:     afterID,
+			LastEntryID: balances.LastEntryID,
+			PageSize:    pageSize,
+		})
+		if err != nil {
+			return header, fmt.Errorf("failed to list statement entries: %w", err)
+		}
+		for _, entry := range entries {
+			balance += entry.Amount
+			if err := writer.WriteLine(newLine(account.ID, entry, balance)); err != nil {
+				return header, err
+			}
+			afterID = entry.ID
+		}
+		if len(entries) < pageSize {
+			break
+		}
+	}
+
+	return header, writer.Close()
+}
+
+func newLine(accountID int64, entry db.ListStatementEntriesRow, balance int64) Line {
+	line := Line{
+		EntryID:     entry.ID,
+		Time:        entry.CreatedAt,
+		Description: "Entry",
+		Amount:      entry.Amount,
+		Balance:     balance,
+		TransferID:  entry.TransferID.Int64,
+	}
+	switch {
+	case entry.IsFee && entry.FromAccountID.Int64 == accountID:
+		line.CounterpartyAccountID = entry.ToAccountID.Int64
+		line.Description = fmt.Sprintf("Fee for transfer to account %d", line.CounterpartyAccountID)
+	case entry.IsFee:
+		line.CounterpartyAccountID = entry.FromAccountID.Int64
+		line.Description = fmt.Sprintf("Fee from account %d", line.CounterpartyAccountID)
+	case entry.TransferID.Valid:
+		if entry.FromAccountID.Int64 == accountID {
+			line.CounterpartyAccountID = entry.ToAccountID.Int64
+			line.Description = fmt.Sprintf("Transfer to account %d", line.CounterpartyAccountID)
+		} else {
+			line.CounterpartyAccountID = entry.FromAccountID.Int64
+			line.Description = fmt.Sprintf("Transfer from account %d", line.CounterpartyAccountID)
+		}
+	}
+	return line
+}
@@ -0,0 +1,254 @@
+package statement
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var (
+	testAccount = db.Account{ID: 7, Owner: "alice", Balance: 130, Currency: "USD"}
+	testPeriod  = Period{
+		From: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+)
+
+func TestParsePeriod(t *testing.T) {
+	now := time.Date(2023, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	period, err := ParsePeriod("2023-01-01", "2023-01-31", now)
+	require.NoError(t, err)
+	require.Equal(t, testPeriod, period)
+
+	period, err = ParsePeriod("2023-03-01T10:00:00Z", "", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC), period.From)
+	require.Equal(t, now, period.To)
+
+	_, err = ParsePeriod("", "2023-01-31", now)
+	require.EqualError(t, err, "from is required")
+	_, err = ParsePeriod("01/01/2023", "", now)
+	require.ErrorContains(t, err, "invalid from")
+	_, err = ParsePeriod("2023-01-01", "yesterday", now)
+	require.ErrorContains(t, err, "invalid to")
+	_, err = ParsePeriod("2023-02-01", "2023-01-01", now)
+	require.EqualError(t, err, "from must be before to")
+}
+
+// mockStatement stubs a period with a transfer out, a transfer in and an entry without a transfer, followed by
+// 20 more after the period. The opening balance is 100.
+func mockStatement(store *mockdb.MockStore) {
+	store.EXPECT().
+		GetAccountStatementBalances(gomock.Any(), gomock.Eq(db.GetAccountStatementBalancesParams{
+			AccountID: testAccount.ID,
+			FromTime:  testPeriod.From,
+			ToTime:    testPeriod.To,
+		})).
+		Times(1).
+		Return(db.GetAccountStatementBalancesRow{Balance: 130, AfterPeriod: 20, InPeriod: 10, LastEntryID: 9}, nil)
+	store.EXPECT().
+		ListStatementEntries(gomock.Any(), gomock.Eq(db.ListStatementEntriesParams{
+			AccountID:   testAccount.ID,
+			FromTime:    testPeriod.From,
+			ToTime:      testPeriod.To,
+			AfterID:     0,
+			LastEntryID: 9,
+			PageSize:    pageSize,
+		})).
+		Times(1).
+		Return([]db.ListStatementEntriesRow{
+			{
+				ID:            1,
+				Amount:        -15,
+				CreatedAt:     testPeriod.From.Add(time.Hour),
+				TransferID:    sql.NullInt64{Int64: 11, Valid: true},
+				FromAccountID: sql.NullInt64{Int64: testAccount.ID, Valid: true},
+				ToAccountID:   sql.NullInt64{Int64: 8, Valid: true},
+			},
+			{
+				ID:            2,
+				Amount:        20,
+				CreatedAt:     testPeriod.From.Add(2 * time.Hour),
+				TransferID:    sql.NullInt64{Int64: 12, Valid: true},
+				FromAccountID: sql.NullInt64{Int64: 9, Valid: true},
+				ToAccountID:   sql.NullInt64{Int64: testAccount.ID, Valid: true},
+			},
+			{ID: 3, Amount: 5, CreatedAt: testPeriod.From.Add(3 * time.Hour)},
+		}, nil)
+}
+
+func generate(t *testing.T, format string) (Header, []byte) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	mockStatement(store)
+
+	var out bytes.Buffer
+	writer, err := NewWriter(format, &out)
+	require.NoError(t, err)
+	header, err := Generate(context.Background(), store, testAccount, testPeriod, writer)
+	require.NoError(t, err)
+	return header, out.Bytes()
+}
+
+func TestGenerateJSON(t *testing.T) {
+	header, data := generate(t, FormatJSON)
+	require.Equal(t, int64(100), header.OpeningBalance)
+	require.Equal(t, int64(110), header.ClosingBalance)
+
+	var statement struct {
+		Header
+		Lines []Line `json:"lines"`
+	}
+	require.NoError(t, json.Unmarshal(data, &statement))
+	require.Equal(t, testAccount.ID, statement.AccountID)
+	require.Equal(t, "USD", statement.Currency)
+	require.Equal(t, int64(100), statement.OpeningBalance)
+	require.Equal(t, int64(110), statement.ClosingBalance)
+	require.Equal(t, []Line{
+		{EntryID: 1, Time: testPeriod.From.Add(time.Hour), Description: "Transfer to account 8", Amount: -15, Balance: 85, TransferID: 11, CounterpartyAccountID: 8},
+		{EntryID: 2, Time: testPeriod.From.Add(2 * time.Hour), Description: "Transfer from account 9", Amount: 20, Balance: 105, TransferID: 12, CounterpartyAccountID: 9},
+		{EntryID: 3, Time: testPeriod.From.Add(3 * time.Hour), Description: "Entry", Amount: 5, Balance: 110},
+	}, statement.Lines)
+}
+
+func TestGenerateCSV(t *testing.T) {
+	_, data := generate(t, FormatCSV)
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"time", "description", "amount", "balance", "currency", "entry_id", "transfer_id", "counterparty_account_id"},
+		{"2023-01-01T00:00:00Z", "Opening balance", "", "100", "USD", "", "", ""},
+		{"2023-01-01T01:00:00Z", "Transfer to account 8", "-15", "85", "USD", "1", "11", "8"},
+		{"2023-01-01T02:00:00Z", "Transfer from account 9", "20", "105", "USD", "2", "12", "9"},
+		{"2023-01-01T03:00:00Z", "Entry", "5", "110", "USD", "3", "", ""},
+		{"2023-02-01T00:00:00Z", "Closing balance", "", "110", "USD", "", "", ""},
+	}, records)
+}
+
+func TestGenerateOFX(t *testing.T) {
+	_, data := generate(t, FormatOFX)
+	require.True(t, bytes.HasPrefix(data, []byte(`<?xml version="1.0"`)))
+	require.Contains(t, string(data), `<?OFX OFXHEADER="200" VERSION="220"`)
+
+	var ofx struct {
+		Statement struct {
+			Currency  string `xml:"CURDEF"`
+			AccountID int64  `xml:"BANKACCTFROM>ACCTID"`
+			Start     string `xml:"BANKTRANLIST>DTSTART"`
+			End       string `xml:"BANKTRANLIST>DTEND"`
+			Lines     []struct {
+				Type   string `xml:"TRNTYPE"`
+				Amount int64  `xml:"TRNAMT"`
+				FitID  int64  `xml:"FITID"`
+				Name   string `xml:"NAME"`
+			} `xml:"BANKTRANLIST>STMTTRN"`
+			LedgerBalance int64 `xml:"LEDGERBAL>BALAMT"`
+		} `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS"`
+	}
+	require.NoError(t, xml.Unmarshal(data, &ofx))
+	require.Equal(t, "USD", ofx.Statement.Currency)
+	require.Equal(t, testAccount.ID, ofx.Statement.AccountID)
+	require.Equal(t, "20230101000000.000[0:UTC]", ofx.Statement.Start)
+	require.Equal(t, "20230201000000.000[0:UTC]", ofx.Statement.End)
+	require.Len(t, ofx.Statement.Lines, 3)
+	require.Equal(t, "DEBIT", ofx.Statement.Lines[0].Type)
+	require.Equal(t, int64(-15), ofx.Statement.Lines[0].Amount)
+	require.Equal(t, "CREDIT", ofx.Statement.Lines[1].Type)
+	require.Equal(t, int64(2), ofx.Statement.Lines[1].FitID)
+	require.Equal(t, "Entry", ofx.Statement.Lines[2].Name)
+	require.Equal(t, int64(110), ofx.Statement.LedgerBalance)
+}
+
+func TestGeneratePages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		GetAccountStatementBalances(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.GetAccountStatementBalancesRow{Balance: pageSize + 1, InPeriod: pageSize + 1, LastEntryID: pageSize + 1}, nil)
+	page := make([]db.ListStatementEntriesRow, pageSize)
+	for i := range page {
+		page[i] = db.ListStatementEntriesRow{ID: int64(i + 1), Amount: 1}
+	}
+	gomock.InOrder(
+		store.EXPECT().
+			ListStatementEntries(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(page, nil),
+		store.EXPECT().
+			ListStatementEntries(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(ctx context.Context, arg db.ListStatementEntriesParams) ([]db.ListStatementEntriesRow, error) {
+				require.Equal(t, int64(pageSize), arg.AfterID)
+				return []db.ListStatementEntriesRow{{ID: pageSize + 1, Amount: 1}}, nil
+			}),
+	)
+
+	var out bytes.Buffer
+	writer, err := NewWriter(FormatCSV, &out)
+	require.NoError(t, err)
+	_, err = Generate(context.Background(), store, testAccount, testPeriod, writer)
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, pageSize+4)
+	require.Equal(t, []string{"2023-02-01T00:00:00Z", "Closing balance", "", "501", "USD", "", "", ""}, records[len(records)-1])
+}
+
+func TestNewWriterUnsupportedFormat(t *testing.T) {
+	_, err := NewWriter("xlsx", &bytes.Buffer{})
+	require.EqualError(t, err, `unsupported statement format "xlsx"`)
+	require.False(t, IsSupportedFormat("xlsx"))
+	require.Equal(t, "statement-7-20230101-20230201.ofx", FileName(testAccount.ID, testPeriod, FormatOFX))
+}
+
+func TestGeneratePDF(t *testing.T) {
+	_, data := generate(t, FormatPDF)
+	require.True(t, bytes.HasPrefix(data, []byte("%PDF-")))
+	require.True(t, bytes.HasSuffix(bytes.TrimSpace(data), []byte("%%EOF")))
+	require.Equal(t, "application/pdf", ContentType(FormatPDF))
+}
+
+func TestMonth(t *testing.T) {
+	require.Equal(t, testPeriod, Month(time.Date(2023, 1, 31, 23, 59, 0, 0, time.UTC)))
+	require.Equal(t, testPeriod, PreviousMonth(time.Date(2023, 2, 1, 2, 0, 0, 0, time.UTC)))
+	require.Equal(t, Period{
+		From: time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, PreviousMonth(time.Date(2023, 1, 15, 0, 0, 0, 0, time.FixedZone("CET", 3600))))
+}
+
+func TestNewLineFee(t *testing.T) {
+	transfer := db.ListStatementEntriesRow{
+		ID:            4,
+		Amount:        -2,
+		TransferID:    sql.NullInt64{Int64: 11, Valid: true},
+		IsFee:         true,
+		FromAccountID: sql.NullInt64{Int64: testAccount.ID, Valid: true},
+		ToAccountID:   sql.NullInt64{Int64: 8, Valid: true},
+	}
+	line := newLine(testAccount.ID, transfer, 98)
+	require.Equal(t, "Fee for transfer to account 8", line.Description)
+	require.Equal(t, int64(8), line.CounterpartyAccountID)
+
+	// the fee revenue account is neither side of the transfer
+	transfer.Amount = 2
+	line = newLine(99, transfer, 2)
+	require.Equal(t, "Fee from account 7", line.Description)
+	require.Equal(t, testAccount.ID, line.CounterpartyAccountID)
+}
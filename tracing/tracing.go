@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOtlp   = "otlp"
+
+	defaultServiceName  = "simplebank"
+	instrumentationName = "github.com/kwalter26/udemy-simplebank"
+)
+
+// Tracer returns the tracer used for the spans the service creates itself.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Init installs the global tracer provider and propagator for the exporter selected by the config. The returned
+// function flushes pending spans and must be called on shutdown.
+func Init(ctx context.Context, config util.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, err := newExporter(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	serviceName := config.TracingServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	provider := NewProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.DeploymentEnvironment(string(config.Environment)),
+	)))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewProvider creates a tracer provider that samples every trace unless the parent says otherwise.
+func NewProvider(opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	opts = append([]sdktrace.TracerProviderOption{sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample()))}, opts...)
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+func newExporter(ctx context.Context, config util.Config) (sdktrace.SpanExporter, error) {
+	switch config.TracingExporter {
+	case "", ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOtlp:
+		opts := []otlptracegrpc.Option{}
+		if config.OtlpEndpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(config.OtlpEndpoint))
+		}
+		if config.OtlpInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter %q", config.TracingExporter)
+	}
+}
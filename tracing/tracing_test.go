@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestInjectExtract(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewProvider(sdktrace.WithSyncer(exporter))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	carrier := Inject(ctx)
+	require.Contains(t, carrier, "traceparent")
+	parent.End()
+
+	ctx = Extract(context.Background(), carrier)
+	_, child := provider.Tracer("test").Start(ctx, "child")
+	child.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	require.Equal(t, spans[0].SpanContext.TraceID(), spans[1].SpanContext.TraceID())
+	require.Equal(t, spans[0].SpanContext.SpanID(), spans[1].Parent.SpanID())
+}
+
+func TestInjectWithoutSpan(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	require.Nil(t, Inject(context.Background()))
+	require.Equal(t, context.Background(), Extract(context.Background(), nil))
+}
+
+func TestInit(t *testing.T) {
+	for _, exporter := range []string{"", ExporterNone, ExporterStdout} {
+		shutdown, err := Init(context.Background(), util.Config{TracingExporter: exporter})
+		require.NoError(t, err)
+		require.NoError(t, shutdown(context.Background()))
+	}
+
+	_, err := Init(context.Background(), util.Config{TracingExporter: "jaeger"})
+	require.Error(t, err)
+}
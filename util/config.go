@@ -9,25 +9,60 @@ import (
 )
 
 type Config struct {
-	Environment                       Environment   `mapstructure:"ENVIRONMENT"`
-	DBDriver                          string        `mapstructure:"DB_DRIVER"`
-	DBSource                          string        `mapstructure:"DB_SOURCE"`
-	HttpServerAddress                 string        `mapstructure:"HTTP_SERVER_ADDRESS"`
-	GrpcServerAddress                 string        `mapstructure:"GRPC_SERVER_ADDRESS"`
-	RedisAddress                      string        `mapstructure:"REDIS_ADDRESS"`
-	TokenSymmetricKey                 string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration               time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
-	MigrationUrl                      string        `mapstructure:"MIGRATION_URL"`
-	NewRelicAppName                   string        `mapstructure:"NEWRELIC_APP_NAME"`
-	NewRelicLicenseKey                string        `mapstructure:"NEWRELIC_LICENSE_KEY"`
-	NewRelicLogForwardingEnabled      bool          `mapstructure:"NEWRELIC_LOG_FORWARDING_ENABLED"`
-	NewRelicDistributedTracingEnabled bool          `mapstructure:"NEWRELIC_DIST_TRACING_ENABLED"`
-	NewRelicAppEnabled                bool          `mapstructure:"NEWRELIC_APP_ENABLED"`
-	RefreshTokenDuration              time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
-	EmailSenderName                   string        `mapstructure:"EMAIL_SENDER_NAME"`
-	EmailSenderAddress                string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
-	EmailSenderPassword               string        `mapstructure:"EMAIL_SENDER_PASSWORD"`
-	SendGridApiKey                    string        `mapstructure:"SENDGRID_API_KEY"`
+	Environment           Environment   `mapstructure:"ENVIRONMENT"`
+	DBDriver              string        `mapstructure:"DB_DRIVER"`
+	DBSource              string        `mapstructure:"DB_SOURCE"`
+	HttpServerAddress     string        `mapstructure:"HTTP_SERVER_ADDRESS"`
+	GrpcServerAddress     string        `mapstructure:"GRPC_SERVER_ADDRESS"`
+	RedisAddress          string        `mapstructure:"REDIS_ADDRESS"`
+	TokenSymmetricKey     string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration   time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	MigrationUrl          string        `mapstructure:"MIGRATION_URL"`
+	MigrationMode         string        `mapstructure:"MIGRATION_MODE"`
+	RefreshTokenDuration  time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	EmailSenderName       string        `mapstructure:"EMAIL_SENDER_NAME"`
+	EmailSenderAddress    string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
+	EmailSenderPassword   string        `mapstructure:"EMAIL_SENDER_PASSWORD"`
+	SendGridApiKey        string        `mapstructure:"SENDGRID_API_KEY"`
+	LoginMaxAttempts      int32         `mapstructure:"LOGIN_MAX_ATTEMPTS"`
+	LoginMaxAttemptsPerIp int32         `mapstructure:"LOGIN_MAX_ATTEMPTS_PER_IP"`
+	LoginLockoutBase      time.Duration `mapstructure:"LOGIN_LOCKOUT_BASE"`
+	LoginLockoutMax       time.Duration `mapstructure:"LOGIN_LOCKOUT_MAX"`
+	LoginFailureWindow    time.Duration `mapstructure:"LOGIN_FAILURE_WINDOW"`
+	PasswordMinLength     int           `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordRequireUpper  bool          `mapstructure:"PASSWORD_REQUIRE_UPPER"`
+	PasswordRequireLower  bool          `mapstructure:"PASSWORD_REQUIRE_LOWER"`
+	PasswordRequireDigit  bool          `mapstructure:"PASSWORD_REQUIRE_DIGIT"`
+	PasswordRequireSymbol bool          `mapstructure:"PASSWORD_REQUIRE_SYMBOL"`
+	PasswordHashAlgorithm string        `mapstructure:"PASSWORD_HASH_ALGORITHM"`
+	BcryptCost            int           `mapstructure:"BCRYPT_COST"`
+	Argon2Memory          uint32        `mapstructure:"ARGON2_MEMORY"`
+	Argon2Iterations      uint32        `mapstructure:"ARGON2_ITERATIONS"`
+	Argon2Parallelism     uint8         `mapstructure:"ARGON2_PARALLELISM"`
+	RateLimitBackend      string        `mapstructure:"RATE_LIMIT_BACKEND"`
+	TaskQueueBackend      string        `mapstructure:"TASK_QUEUE_BACKEND"`
+	RateLimits            string        `mapstructure:"RATE_LIMITS"`
+	TrustedProxies        string        `mapstructure:"TRUSTED_PROXIES"`
+	ServerShutdownTimeout time.Duration `mapstructure:"SERVER_SHUTDOWN_TIMEOUT"`
+	WorkerShutdownTimeout time.Duration `mapstructure:"WORKER_SHUTDOWN_TIMEOUT"`
+	HealthCheckTimeout    time.Duration `mapstructure:"HEALTH_CHECK_TIMEOUT"`
+	HealthCheckInterval   time.Duration `mapstructure:"HEALTH_CHECK_INTERVAL"`
+	TracingExporter       string        `mapstructure:"TRACING_EXPORTER"`
+	TracingServiceName    string        `mapstructure:"TRACING_SERVICE_NAME"`
+	OtlpEndpoint          string        `mapstructure:"OTLP_ENDPOINT"`
+	OtlpInsecure          bool          `mapstructure:"OTLP_INSECURE"`
+	OutboxPollInterval    time.Duration `mapstructure:"OUTBOX_POLL_INTERVAL"`
+	OutboxBatchSize       int32         `mapstructure:"OUTBOX_BATCH_SIZE"`
+	OutboxRetention       time.Duration `mapstructure:"OUTBOX_RETENTION"`
+	OutboxMaxAttempts     int32         `mapstructure:"OUTBOX_MAX_ATTEMPTS"`
+	EventPollInterval     time.Duration `mapstructure:"EVENT_POLL_INTERVAL"`
+	BatchTransferMaxLines int           `mapstructure:"BATCH_TRANSFER_MAX_LINES"`
+	BlobStoreBackend      string        `mapstructure:"BLOB_STORE_BACKEND"`
+	BlobStorePath         string        `mapstructure:"BLOB_STORE_PATH"`
+	StatementSchedule     string        `mapstructure:"STATEMENT_SCHEDULE"`
+	InterestSchedule      string        `mapstructure:"INTEREST_SCHEDULE"`
+	PayeeCoolingOff       time.Duration `mapstructure:"PAYEE_COOLING_OFF"`
+	PayeeCoolingOffAmount int64         `mapstructure:"PAYEE_COOLING_OFF_AMOUNT"`
 }
 
 type Environment string
@@ -0,0 +1,15 @@
+package util
+
+import (
+	"time"
+)
+
+const defaultEventPollInterval = time.Second
+
+// EventStreamPollInterval returns how often event subscriptions look for new domain events.
+func (c Config) EventStreamPollInterval() time.Duration {
+	if c.EventPollInterval <= 0 {
+		return defaultEventPollInterval
+	}
+	return c.EventPollInterval
+}
@@ -0,0 +1,12 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestEventStreamPollInterval(t *testing.T) {
+	require.Equal(t, defaultEventPollInterval, Config{}.EventStreamPollInterval())
+	require.Equal(t, 5*time.Second, Config{EventPollInterval: 5 * time.Second}.EventStreamPollInterval())
+}
@@ -0,0 +1,12 @@
+package util
+
+// defaultInterestSchedule accrues the interest of a day at 00:30 UTC the day after, once its last entries are in.
+const defaultInterestSchedule = "30 0 * * *"
+
+// InterestAccrualSchedule returns the cron spec, in UTC, of the task accruing the interest of the day before.
+func (c Config) InterestAccrualSchedule() string {
+	if c.InterestSchedule == "" {
+		return defaultInterestSchedule
+	}
+	return c.InterestSchedule
+}
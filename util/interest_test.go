@@ -0,0 +1,11 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestInterestAccrualSchedule(t *testing.T) {
+	require.Equal(t, defaultInterestSchedule, Config{}.InterestAccrualSchedule())
+	require.Equal(t, "0 1 * * *", Config{InterestSchedule: "0 1 * * *"}.InterestAccrualSchedule())
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"time"
+)
+
+const (
+	defaultLoginMaxAttempts      = 5
+	defaultLoginMaxAttemptsPerIp = 20
+	defaultLoginLockoutBase      = 30 * time.Second
+	defaultLoginLockoutMax       = time.Hour
+	defaultLoginFailureWindow    = 24 * time.Hour
+)
+
+// LockoutPolicy describes how many failed logins are tolerated before a key is locked, and for how long.
+type LockoutPolicy struct {
+	MaxAttempts int32
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Window is how long a failure is remembered: a failure after a longer quiet period starts the count again.
+	Window time.Duration
+}
+
+// Backoff returns how long a key stays locked after failedCount consecutive failures. The delay doubles with every
+// failure past MaxAttempts and is capped at MaxDelay. Zero means the key is not locked.
+func (p LockoutPolicy) Backoff(failedCount int32) time.Duration {
+	if failedCount < p.MaxAttempts {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.MaxAttempts; i < failedCount; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// UsernameLockoutPolicy returns the lockout policy applied to failed logins for a single username.
+func (c Config) UsernameLockoutPolicy() LockoutPolicy {
+	return c.lockoutPolicy(c.LoginMaxAttempts, defaultLoginMaxAttempts)
+}
+
+// ClientIpLockoutPolicy returns the lockout policy applied to failed logins coming from a single client IP.
+func (c Config) ClientIpLockoutPolicy() LockoutPolicy {
+	return c.lockoutPolicy(c.LoginMaxAttemptsPerIp, defaultLoginMaxAttemptsPerIp)
+}
+
+func (c Config) lockoutPolicy(maxAttempts int32, defaultMaxAttempts int32) LockoutPolicy {
+	policy := LockoutPolicy{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   c.LoginLockoutBase,
+		MaxDelay:    c.LoginLockoutMax,
+		Window:      c.LoginFailureWindow,
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaultMaxAttempts
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = defaultLoginLockoutBase
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = defaultLoginLockoutMax
+	}
+	if policy.Window <= 0 {
+		policy.Window = defaultLoginFailureWindow
+	}
+	return policy
+}
+
+// ResetBefore returns the time before which the last failure of a key is too old to count towards a lockout.
+func (p LockoutPolicy) ResetBefore(now time.Time) time.Time {
+	return now.Add(-p.Window)
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLockoutPolicyBackoff(t *testing.T) {
+	policy := LockoutPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Second,
+		MaxDelay:    10 * time.Second,
+	}
+
+	require.Zero(t, policy.Backoff(0))
+	require.Zero(t, policy.Backoff(2))
+	require.Equal(t, time.Second, policy.Backoff(3))
+	require.Equal(t, 2*time.Second, policy.Backoff(4))
+	require.Equal(t, 4*time.Second, policy.Backoff(5))
+	require.Equal(t, 8*time.Second, policy.Backoff(6))
+	require.Equal(t, 10*time.Second, policy.Backoff(7))
+	require.Equal(t, 10*time.Second, policy.Backoff(1000))
+}
+
+func TestLockoutPolicyDefaults(t *testing.T) {
+	config := Config{}
+
+	usernamePolicy := config.UsernameLockoutPolicy()
+	require.Equal(t, int32(defaultLoginMaxAttempts), usernamePolicy.MaxAttempts)
+	require.Equal(t, defaultLoginLockoutBase, usernamePolicy.BaseDelay)
+	require.Equal(t, defaultLoginLockoutMax, usernamePolicy.MaxDelay)
+	require.Equal(t, defaultLoginFailureWindow, usernamePolicy.Window)
+
+	clientIpPolicy := config.ClientIpLockoutPolicy()
+	require.Equal(t, int32(defaultLoginMaxAttemptsPerIp), clientIpPolicy.MaxAttempts)
+
+	config = Config{
+		LoginMaxAttempts:      2,
+		LoginMaxAttemptsPerIp: 4,
+		LoginLockoutBase:      time.Minute,
+		LoginLockoutMax:       time.Minute * 5,
+		LoginFailureWindow:    time.Hour,
+	}
+	require.Equal(t, LockoutPolicy{MaxAttempts: 2, BaseDelay: time.Minute, MaxDelay: 5 * time.Minute, Window: time.Hour}, config.UsernameLockoutPolicy())
+	require.Equal(t, LockoutPolicy{MaxAttempts: 4, BaseDelay: time.Minute, MaxDelay: 5 * time.Minute, Window: time.Hour}, config.ClientIpLockoutPolicy())
+
+	now := time.Now()
+	require.Equal(t, now.Add(-time.Hour), config.UsernameLockoutPolicy().ResetBefore(now))
+}
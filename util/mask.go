@@ -0,0 +1,17 @@
+package util
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MaskName keeps the first letter of each word of a full name and masks the others, so that a payer can recognize
+// the recipient without the name being disclosed to anyone who knows their username or email.
+func MaskName(fullName string) string {
+	words := strings.Fields(fullName)
+	for i, word := range words {
+		first, size := utf8.DecodeRuneInString(word)
+		words[i] = string(first) + strings.Repeat("*", utf8.RuneCountInString(word[size:]))
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,13 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMaskName(t *testing.T) {
+	require.Equal(t, "J*** S****", MaskName("John Smith"))
+	require.Equal(t, "A* B", MaskName("  Al   B "))
+	require.Equal(t, "É****", MaskName("Émile"))
+	require.Empty(t, MaskName(""))
+}
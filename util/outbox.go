@@ -0,0 +1,45 @@
+package util
+
+import (
+	"time"
+)
+
+const (
+	defaultOutboxPollInterval = time.Second
+	defaultOutboxBatchSize    = 100
+	defaultOutboxRetention    = 7 * 24 * time.Hour
+	defaultOutboxMaxAttempts  = 20
+)
+
+// OutboxRelayInterval returns how often the relay polls the outbox for committed tasks.
+func (c Config) OutboxRelayInterval() time.Duration {
+	if c.OutboxPollInterval <= 0 {
+		return defaultOutboxPollInterval
+	}
+	return c.OutboxPollInterval
+}
+
+// OutboxRelayBatchSize returns how many tasks the relay publishes per transaction.
+func (c Config) OutboxRelayBatchSize() int32 {
+	if c.OutboxBatchSize <= 0 {
+		return defaultOutboxBatchSize
+	}
+	return c.OutboxBatchSize
+}
+
+// OutboxRetentionPeriod returns how long published tasks stay in the outbox before they are deleted.
+func (c Config) OutboxRetentionPeriod() time.Duration {
+	if c.OutboxRetention <= 0 {
+		return defaultOutboxRetention
+	}
+	return c.OutboxRetention
+}
+
+// OutboxRelayMaxAttempts returns how many times the relay tries to publish a task before it gives up and moves the
+// task to the dead letters.
+func (c Config) OutboxRelayMaxAttempts() int32 {
+	if c.OutboxMaxAttempts <= 0 {
+		return defaultOutboxMaxAttempts
+	}
+	return c.OutboxMaxAttempts
+}
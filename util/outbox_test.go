@@ -0,0 +1,26 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestOutboxRelaySettings(t *testing.T) {
+	config := Config{}
+	require.Equal(t, defaultOutboxPollInterval, config.OutboxRelayInterval())
+	require.Equal(t, int32(defaultOutboxBatchSize), config.OutboxRelayBatchSize())
+	require.Equal(t, defaultOutboxRetention, config.OutboxRetentionPeriod())
+	require.Equal(t, int32(defaultOutboxMaxAttempts), config.OutboxRelayMaxAttempts())
+
+	config = Config{
+		OutboxPollInterval: 5 * time.Second,
+		OutboxBatchSize:    10,
+		OutboxRetention:    time.Hour,
+		OutboxMaxAttempts:  3,
+	}
+	require.Equal(t, 5*time.Second, config.OutboxRelayInterval())
+	require.Equal(t, int32(10), config.OutboxRelayBatchSize())
+	require.Equal(t, time.Hour, config.OutboxRetentionPeriod())
+	require.Equal(t, int32(3), config.OutboxRelayMaxAttempts())
+}
@@ -1,20 +1,212 @@
 package util
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"sync"
 )
 
-// HashPassword hashes a password with bcrypt
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// Password hash algorithms
+const (
+	Bcrypt   = "bcrypt"
+	Argon2id = "argon2id"
+)
+
+const (
+	defaultArgon2Memory      = 64 * 1024
+	defaultArgon2Iterations  = 3
+	defaultArgon2Parallelism = 2
+	argon2SaltLength         = 16
+	argon2KeyLength          = 32
+)
+
+var (
+	ErrMismatchedHashAndPassword = bcrypt.ErrMismatchedHashAndPassword
+	ErrInvalidHash               = errors.New("invalid password hash format")
+)
+
+// PasswordHasher hashes passwords and tells whether an existing hash was made with weaker parameters.
+type PasswordHasher interface {
+	// Hash hashes the password with the hasher's current parameters.
+	Hash(password string) (string, error)
+	// NeedsRehash reports whether hashedPassword was produced by another algorithm or with weaker parameters.
+	NeedsRehash(hashedPassword string) bool
+}
+
+// NewPasswordHasher returns the hasher selected by the config. Unset parameters fall back to sane defaults.
+func NewPasswordHasher(config Config) (PasswordHasher, error) {
+	switch config.PasswordHashAlgorithm {
+	case "", Bcrypt:
+		cost := config.BcryptCost
+		if cost == 0 {
+			cost = bcrypt.DefaultCost
+		}
+		if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("invalid bcrypt cost %d: must be between %d and %d", cost, bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		return &BcryptHasher{Cost: cost}, nil
+	case Argon2id:
+		hasher := &Argon2idHasher{
+			Memory:      config.Argon2Memory,
+			Iterations:  config.Argon2Iterations,
+			Parallelism: config.Argon2Parallelism,
+		}
+		if hasher.Memory == 0 {
+			hasher.Memory = defaultArgon2Memory
+		}
+		if hasher.Iterations == 0 {
+			hasher.Iterations = defaultArgon2Iterations
+		}
+		if hasher.Parallelism == 0 {
+			hasher.Parallelism = defaultArgon2Parallelism
+		}
+		return hasher, nil
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", config.PasswordHashAlgorithm)
+	}
+}
+
+// BcryptHasher hashes passwords with bcrypt at a fixed cost.
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
 	if err != nil {
 		return "", err
 	}
 	return string(hashedPassword), nil
+}
+
+func (h *BcryptHasher) NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	if err != nil {
+		return true
+	}
+	return cost < h.Cost
+}
+
+// Argon2idHasher hashes passwords with argon2id and encodes them in the PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	params := argon2Params{memory: h.Memory, iterations: h.Iterations, parallelism: h.Parallelism}
+	key := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, argon2KeyLength)
+	return params.encode(salt, key), nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(hashedPassword string) bool {
+	params, _, _, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return true
+	}
+	return params.memory < h.Memory || params.iterations < h.Iterations || params.parallelism < h.Parallelism
+}
+
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+func (p argon2Params) encode(salt []byte, key []byte) string {
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		Argon2id,
+		argon2.Version,
+		p.memory,
+		p.iterations,
+		p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+func decodeArgon2id(hashedPassword string) (params argon2Params, salt []byte, key []byte, err error) {
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != Argon2id {
+		err = ErrInvalidHash
+		return
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		err = ErrInvalidHash
+		return
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		err = ErrInvalidHash
+		return
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		err = ErrInvalidHash
+		return
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		err = ErrInvalidHash
+		return
+	}
+	return
+}
 
+// HashPassword hashes a password with bcrypt
+func HashPassword(password string) (string, error) {
+	return (&BcryptHasher{Cost: bcrypt.DefaultCost}).Hash(password)
 }
 
-// CheckPassword checks if the provided password is correct or not
+// CheckPassword checks if the provided password is correct or not. Both bcrypt and argon2id hashes are accepted,
+// so that users keep working while their hashes are upgraded.
 func CheckPassword(password string, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	if !strings.HasPrefix(hashedPassword, "$"+Argon2id+"$") {
+		return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	}
+
+	params, salt, key, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return ErrMismatchedHashAndPassword
+	}
+	return nil
+}
+
+// DummyPassword checks passwords against a throwaway hash. It is used when a user does not exist so that the
+// response time does not reveal whether a username is registered. The hash is made by the hasher of the current
+// policy, so that checking it costs as much as checking the password of a registered user.
+type DummyPassword struct {
+	hasher         PasswordHasher
+	once           sync.Once
+	hashedPassword string
+}
+
+// NewDummyPassword creates a DummyPassword hashed by hasher. The hash is made on the first check.
+func NewDummyPassword(hasher PasswordHasher) *DummyPassword {
+	return &DummyPassword{hasher: hasher}
+}
+
+// Check compares the password against the throwaway hash and discards the result.
+func (d *DummyPassword) Check(password string) {
+	d.once.Do(func() {
+		d.hashedPassword, _ = d.hasher.Hash(RandomString(32))
+	})
+	_ = CheckPassword(password, d.hashedPassword)
 }
@@ -3,6 +3,7 @@ package util
 import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
+	"strings"
 	"testing"
 )
 
@@ -30,3 +31,70 @@ func TestHashPassword(t *testing.T) {
 	require.EqualError(t, err, bcrypt.ErrPasswordTooLong.Error())
 
 }
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher, err := NewPasswordHasher(Config{PasswordHashAlgorithm: Argon2id})
+	require.NoError(t, err)
+
+	password := RandomString(12)
+	hashedPassword, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hashedPassword, "$argon2id$v=19$m=65536,t=3,p=2$"))
+
+	require.NoError(t, CheckPassword(password, hashedPassword))
+	require.ErrorIs(t, CheckPassword(RandomString(12), hashedPassword), ErrMismatchedHashAndPassword)
+	require.ErrorIs(t, CheckPassword(password, "$argon2id$v=19$broken"), ErrInvalidHash)
+
+	hashedPassword2, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.NotEqual(t, hashedPassword, hashedPassword2)
+	require.False(t, hasher.NeedsRehash(hashedPassword))
+}
+
+func TestNeedsRehash(t *testing.T) {
+	password := RandomString(8)
+
+	weakBcrypt, err := (&BcryptHasher{Cost: bcrypt.MinCost}).Hash(password)
+	require.NoError(t, err)
+	weakArgon2id, err := (&Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1}).Hash(password)
+	require.NoError(t, err)
+
+	bcryptHasher, err := NewPasswordHasher(Config{})
+	require.NoError(t, err)
+	require.True(t, bcryptHasher.NeedsRehash(weakBcrypt))
+	require.True(t, bcryptHasher.NeedsRehash(weakArgon2id))
+
+	argon2idHasher, err := NewPasswordHasher(Config{PasswordHashAlgorithm: Argon2id})
+	require.NoError(t, err)
+	require.True(t, argon2idHasher.NeedsRehash(weakBcrypt))
+	require.True(t, argon2idHasher.NeedsRehash(weakArgon2id))
+
+	require.NoError(t, CheckPassword(password, weakBcrypt))
+	require.NoError(t, CheckPassword(password, weakArgon2id))
+}
+
+func TestNewPasswordHasherInvalidConfig(t *testing.T) {
+	_, err := NewPasswordHasher(Config{PasswordHashAlgorithm: "md5"})
+	require.Error(t, err)
+
+	_, err = NewPasswordHasher(Config{BcryptCost: 100})
+	require.Error(t, err)
+}
+
+func TestDummyPassword(t *testing.T) {
+	hashers := []PasswordHasher{
+		&BcryptHasher{Cost: bcrypt.MinCost + 1},
+		&Argon2idHasher{Memory: 2048, Iterations: 2, Parallelism: 1},
+	}
+
+	for _, hasher := range hashers {
+		dummyPassword := NewDummyPassword(hasher)
+		dummyPassword.Check(RandomString(8))
+		require.NotEmpty(t, dummyPassword.hashedPassword)
+		require.False(t, hasher.NeedsRehash(dummyPassword.hashedPassword))
+
+		hashedPassword := dummyPassword.hashedPassword
+		dummyPassword.Check(RandomString(8))
+		require.Equal(t, hashedPassword, dummyPassword.hashedPassword)
+	}
+}
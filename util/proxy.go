@@ -0,0 +1,15 @@
+package util
+
+import "strings"
+
+// TrustedProxyList returns the IPs and CIDRs of the proxies whose X-Forwarded-For header is believed, from the comma
+// separated TRUSTED_PROXIES. Without any, the client IP is always the address of the connection.
+func (c Config) TrustedProxyList() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(c.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
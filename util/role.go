@@ -0,0 +1,7 @@
+package util
+
+// Role constants
+const (
+	DepositorRole = "depositor"
+	BankerRole    = "banker"
+)
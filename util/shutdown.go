@@ -0,0 +1,27 @@
+package util
+
+import (
+	"time"
+)
+
+const (
+	defaultServerShutdownTimeout = 10 * time.Second
+	defaultWorkerShutdownTimeout = 8 * time.Second
+)
+
+// ServerDrainTimeout returns how long the gRPC and HTTP servers wait for in-flight requests before they are closed.
+func (c Config) ServerDrainTimeout() time.Duration {
+	if c.ServerShutdownTimeout <= 0 {
+		return defaultServerShutdownTimeout
+	}
+	return c.ServerShutdownTimeout
+}
+
+// WorkerDrainTimeout returns how long the task processor waits for running tasks before they are pushed back to
+// the queue.
+func (c Config) WorkerDrainTimeout() time.Duration {
+	if c.WorkerShutdownTimeout <= 0 {
+		return defaultWorkerShutdownTimeout
+	}
+	return c.WorkerShutdownTimeout
+}
@@ -0,0 +1,20 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestDrainTimeouts(t *testing.T) {
+	config := Config{}
+	require.Equal(t, defaultServerShutdownTimeout, config.ServerDrainTimeout())
+	require.Equal(t, defaultWorkerShutdownTimeout, config.WorkerDrainTimeout())
+
+	config = Config{
+		ServerShutdownTimeout: 3 * time.Second,
+		WorkerShutdownTimeout: 5 * time.Second,
+	}
+	require.Equal(t, 3*time.Second, config.ServerDrainTimeout())
+	require.Equal(t, 5*time.Second, config.WorkerDrainTimeout())
+}
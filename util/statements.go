@@ -0,0 +1,23 @@
+package util
+
+const (
+	// defaultStatementSchedule runs the monthly statements at 02:00 UTC on the first day of each month.
+	defaultStatementSchedule = "0 2 1 * *"
+	defaultBlobStorePath     = "blobs"
+)
+
+// MonthlyStatementSchedule returns the cron spec, in UTC, of the task generating the statements of the past month.
+func (c Config) MonthlyStatementSchedule() string {
+	if c.StatementSchedule == "" {
+		return defaultStatementSchedule
+	}
+	return c.StatementSchedule
+}
+
+// BlobStoreDirectory returns the directory the local blob store keeps its files in.
+func (c Config) BlobStoreDirectory() string {
+	if c.BlobStorePath == "" {
+		return defaultBlobStorePath
+	}
+	return c.BlobStorePath
+}
@@ -0,0 +1,19 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestStatementSettings(t *testing.T) {
+	config := Config{}
+	require.Equal(t, defaultStatementSchedule, config.MonthlyStatementSchedule())
+	require.Equal(t, defaultBlobStorePath, config.BlobStoreDirectory())
+
+	config = Config{
+		StatementSchedule: "0 6 2 * *",
+		BlobStorePath:     "/var/lib/simplebank",
+	}
+	require.Equal(t, "0 6 2 * *", config.MonthlyStatementSchedule())
+	require.Equal(t, "/var/lib/simplebank", config.BlobStoreDirectory())
+}
@@ -0,0 +1,96 @@
+package val
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"github.com/kwalter26/udemy-simplebank/util"
+	"strings"
+	"unicode"
+)
+
+const (
+	defaultPasswordMinLength = 6
+	passwordMaxLength        = 100
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords is the set of passwords that are always rejected, lowercased.
+var commonPasswords = loadCommonPasswords(commonPasswordsFile)
+
+// PasswordPolicy describes the rules new passwords have to follow.
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// NewPasswordPolicy builds the password policy from the config. Without any configuration only the length is
+// checked, which matches ValidatePassword.
+func NewPasswordPolicy(config util.Config) PasswordPolicy {
+	policy := PasswordPolicy{
+		MinLength:     config.PasswordMinLength,
+		RequireUpper:  config.PasswordRequireUpper,
+		RequireLower:  config.PasswordRequireLower,
+		RequireDigit:  config.PasswordRequireDigit,
+		RequireSymbol: config.PasswordRequireSymbol,
+	}
+	if policy.MinLength <= 0 {
+		policy.MinLength = defaultPasswordMinLength
+	}
+	return policy
+}
+
+// Validate checks the password against the policy and the list of common passwords. Returns an error describing
+// the first rule that is not met.
+func (p PasswordPolicy) Validate(password string) error {
+	if err := ValidateString(password, p.MinLength, passwordMaxLength); err != nil {
+		return err
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUpper && !hasUpper {
+		return fmt.Errorf("password must contain an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		return fmt.Errorf("password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		return fmt.Errorf("password must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		return fmt.Errorf("password must contain a symbol")
+	}
+	if _, ok := commonPasswords[strings.ToLower(password)]; ok {
+		return fmt.Errorf("password is too common")
+	}
+	return nil
+}
+
+func loadCommonPasswords(file string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(file))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			passwords[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	return passwords
+}
@@ -0,0 +1,43 @@
+package val
+
+import (
+	"github.com/kwalter26/udemy-simplebank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	defaultPolicy := NewPasswordPolicy(util.Config{})
+	require.NoError(t, defaultPolicy.Validate("xkcdhorse"))
+	require.Error(t, defaultPolicy.Validate("short"))
+	require.EqualError(t, defaultPolicy.Validate("Password123"), "password is too common")
+
+	strictPolicy := NewPasswordPolicy(util.Config{
+		PasswordMinLength:     10,
+		PasswordRequireUpper:  true,
+		PasswordRequireLower:  true,
+		PasswordRequireDigit:  true,
+		PasswordRequireSymbol: true,
+	})
+
+	testCases := []struct {
+		password string
+		err      string
+	}{
+		{password: "Tr0ub4dor&3x", err: ""},
+		{password: "Tr0ub4&3", err: "invalid string length: must be between 10 and 100 characters"},
+		{password: "tr0ub4dor&3x", err: "password must contain an uppercase letter"},
+		{password: "TR0UB4DOR&3X", err: "password must contain a lowercase letter"},
+		{password: "Troubador&xx", err: "password must contain a digit"},
+		{password: "Tr0ub4dor33x", err: "password must contain a symbol"},
+	}
+
+	for _, tc := range testCases {
+		err := strictPolicy.Validate(tc.password)
+		if tc.err == "" {
+			require.NoError(t, err, tc.password)
+		} else {
+			require.EqualError(t, err, tc.err, tc.password)
+		}
+	}
+}
@@ -2,8 +2,12 @@ package val
 
 import (
 	"fmt"
+	"github.com/kwalter26/udemy-simplebank/webhook"
+	"net"
 	"net/mail"
+	"net/url"
 	"regexp"
+	"strings"
 )
 
 var (
@@ -80,3 +84,21 @@ func ValidateSecretCode(value string) error {
 	}
 	return nil
 }
+
+// ValidateWebhookUrl function to Validate a webhook url and make sure it is an absolute http or https url whose host
+// is not an address of the bank's own network. Names are only checked once resolved, when a delivery is sent.
+// Returns an error if the url is invalid.
+func ValidateWebhookUrl(value string) error {
+	if err := ValidateString(value, 10, 2048); err != nil {
+		return err
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("must be an absolute http or https url")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if ip := net.ParseIP(host); (ip != nil && webhook.IsBlockedIP(ip)) || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("must not point to a loopback, private or link-local address")
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Headers sent with every delivery. Receivers verify the signature over "<timestamp>.<body>" with their secret.
+const (
+	EventHeader     = "X-Simplebank-Event"
+	DeliveryHeader  = "X-Simplebank-Delivery"
+	TimestampHeader = "X-Simplebank-Timestamp"
+	SignatureHeader = "X-Simplebank-Signature"
+
+	signaturePrefix = "sha256="
+	secretBytes     = 32
+	defaultTimeout  = 10 * time.Second
+)
+
+// NewSecret returns a random signing secret for a new webhook.
+func NewSecret() (string, error) {
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("cannot generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// Sign returns the signature header value of a body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header value in constant time.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Delivery is a single signed POST of an event to an endpoint.
+type Delivery struct {
+	ID        int64
+	URL       string
+	Secret    string
+	EventType string
+	Body      []byte
+}
+
+// ErrBlockedAddress is returned for endpoints that resolve to an address of the bank's own network.
+var ErrBlockedAddress = errors.New("webhook endpoint address is not public")
+
+// IsBlockedIP reports whether ip is a loopback, private, link-local or unspecified address, which webhooks must not
+// reach: anyone can register a webhook, and its requests come from inside the bank's network.
+func IsBlockedIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsUnspecified()
+}
+
+// Sender posts deliveries to webhook endpoints.
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a sender whose requests time out after timeout, 10s when zero. Endpoints are checked at dial
+// time, after the name was resolved, so that a DNS answer cannot point a webhook at a blocked address. allowPrivate
+// lifts the check, for tests and local development only.
+func NewSender(timeout time.Duration, allowPrivate bool) *Sender {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = checkDialedAddress
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would make the dialed address the proxy's
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Sender{client: &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// a redirect is answered like any other non 2xx status, it would otherwise lead anywhere
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// checkDialedAddress refuses connections to blocked addresses. address is the resolved ip and port.
+func checkDialedAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("cannot parse dialed address %q: %w", address, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || IsBlockedIP(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	return nil
+}
+
+// Send posts the delivery and returns the response status code. Any status other than 2xx is an error; the code
+// is 0 when no response was received.
+func (sender *Sender) Send(ctx context.Context, delivery Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return 0, fmt.Errorf("cannot create request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "simplebank-webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, delivery.Body))
+
+	res, err := sender.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("cannot post webhook: %w", err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("webhook endpoint responded with %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// RetryDelay backs off exponentially from 10s, doubling after every failed attempt up to an hour.
+func RetryDelay(retried int) time.Duration {
+	delay := 10 * time.Second
+	for i := 0; i < retried && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
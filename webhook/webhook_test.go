@@ -0,0 +1,153 @@
+package webhook
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+	other, err := NewSecret()
+	require.NoError(t, err)
+	require.NotEqual(t, secret, other)
+
+	body := []byte(`{"type":"balance.changed"}`)
+	signature := Sign(secret, 1700000000, body)
+	require.True(t, Verify(secret, 1700000000, body, signature))
+	require.False(t, Verify(other, 1700000000, body, signature))
+	require.False(t, Verify(secret, 1700000001, body, signature))
+	require.False(t, Verify(secret, 1700000000, []byte(`{}`), signature))
+}
+
+func TestSend(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+	body := []byte(`{"type":"transfer.completed"}`)
+
+	var received *http.Request
+	var receivedBody []byte
+	endpoint := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		received = req
+		receivedBody, _ = io.ReadAll(req.Body)
+		res.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+
+	sender := NewSender(time.Second, true)
+	code, err := sender.Send(context.Background(), Delivery{ID: 7, URL: endpoint.URL, Secret: secret, EventType: "transfer.completed", Body: body})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, code)
+
+	require.Equal(t, http.MethodPost, received.Method)
+	require.Equal(t, body, receivedBody)
+	require.Equal(t, "transfer.completed", received.Header.Get(EventHeader))
+	require.Equal(t, "7", received.Header.Get(DeliveryHeader))
+	timestamp, err := strconv.ParseInt(received.Header.Get(TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	require.True(t, Verify(secret, timestamp, receivedBody, received.Header.Get(SignatureHeader)))
+}
+
+func TestSendFailure(t *testing.T) {
+	endpoint := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusBadGateway)
+	}))
+
+	sender := NewSender(time.Second, true)
+	code, err := sender.Send(context.Background(), Delivery{URL: endpoint.URL, Body: []byte(`{}`)})
+	require.Error(t, err)
+	require.Equal(t, http.StatusBadGateway, code)
+
+	endpoint.Close()
+	code, err = sender.Send(context.Background(), Delivery{URL: endpoint.URL, Body: []byte(`{}`)})
+	require.Error(t, err)
+	require.Zero(t, code)
+}
+
+func TestCheckDialedAddress(t *testing.T) {
+	testCases := []struct {
+		name    string
+		address string
+		blocked bool
+	}{
+		{name: "LoopbackIPv4", address: "127.0.0.1:80", blocked: true},
+		{name: "LoopbackRange", address: "127.1.2.3:80", blocked: true},
+		{name: "LoopbackIPv6", address: "[::1]:80", blocked: true},
+		{name: "Private10", address: "10.1.2.3:443", blocked: true},
+		{name: "Private172", address: "172.16.0.1:443", blocked: true},
+		{name: "Private192", address: "192.168.1.1:443", blocked: true},
+		{name: "PrivateIPv6", address: "[fd00::1]:443", blocked: true},
+		{name: "LinkLocalIPv4", address: "169.254.169.254:80", blocked: true},
+		{name: "LinkLocalIPv6", address: "[fe80::1]:80", blocked: true},
+		{name: "LinkLocalMulticast", address: "224.0.0.1:80", blocked: true},
+		{name: "UnspecifiedIPv4", address: "0.0.0.0:80", blocked: true},
+		{name: "UnspecifiedIPv6", address: "[::]:80", blocked: true},
+		{name: "MappedLoopback", address: "[::ffff:127.0.0.1]:80", blocked: true},
+		{name: "MappedPrivate", address: "[::ffff:10.0.0.1]:80", blocked: true},
+		{name: "PublicIPv4", address: "93.184.216.34:443"},
+		{name: "PublicIPv6", address: "[2606:2800:220:1::]:443"},
+		{name: "Private172Edge", address: "172.32.0.1:443"},
+	}
+
+	for _, tc := range testCases {
+		err := checkDialedAddress("tcp", tc.address, nil)
+		if tc.blocked {
+			require.ErrorIs(t, err, ErrBlockedAddress, tc.name)
+		} else {
+			require.NoError(t, err, tc.name)
+		}
+	}
+}
+
+func TestSendBlocksPrivateAddresses(t *testing.T) {
+	called := false
+	endpoint := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		called = true
+		res.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+
+	sender := NewSender(time.Second, false)
+	code, err := sender.Send(context.Background(), Delivery{URL: endpoint.URL, Body: []byte(`{}`)})
+	require.ErrorIs(t, err, ErrBlockedAddress)
+	require.Zero(t, code)
+	require.False(t, called)
+
+	// names are checked once resolved
+	localhostUrl := strings.Replace(endpoint.URL, "127.0.0.1", "localhost", 1)
+	_, err = sender.Send(context.Background(), Delivery{URL: localhostUrl, Body: []byte(`{}`)})
+	require.ErrorIs(t, err, ErrBlockedAddress)
+	require.False(t, called)
+}
+
+func TestSendDoesNotFollowRedirects(t *testing.T) {
+	redirected := false
+	target := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		redirected = true
+	}))
+	defer target.Close()
+	endpoint := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		http.Redirect(res, req, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer endpoint.Close()
+
+	sender := NewSender(time.Second, true)
+	code, err := sender.Send(context.Background(), Delivery{URL: endpoint.URL, Body: []byte(`{}`)})
+	require.Error(t, err)
+	require.Equal(t, http.StatusTemporaryRedirect, code)
+	require.False(t, redirected)
+}
+
+func TestRetryDelay(t *testing.T) {
+	require.Equal(t, 10*time.Second, RetryDelay(0))
+	require.Equal(t, 20*time.Second, RetryDelay(1))
+	require.Equal(t, 80*time.Second, RetryDelay(3))
+	require.Equal(t, time.Hour, RetryDelay(20))
+}
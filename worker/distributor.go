@@ -3,6 +3,7 @@ package worker
 import (
 	"context"
 	"github.com/hibiken/asynq"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
 )
 
 type TaskDistributor interface {
@@ -11,6 +12,8 @@ type TaskDistributor interface {
 		payload *PayloadSendVerifyEmail,
 		opts ...asynq.Option,
 	) error
+	DistributeOutboxTask(ctx context.Context, task db.Outbox) error
+	Close() error
 }
 
 type RedisTaskDistributor struct {
@@ -21,3 +24,8 @@ func NewRedisTaskDistributor(redisOpt asynq.RedisClientOpt) TaskDistributor {
 	client := asynq.NewClient(redisOpt)
 	return &RedisTaskDistributor{client: client}
 }
+
+// Close closes the connection to redis.
+func (distributor *RedisTaskDistributor) Close() error {
+	return distributor.client.Close()
+}
@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"fmt"
+	"github.com/hibiken/asynq"
+)
+
+// TaskInspector reads and changes the tasks that failed in redis. Archived tasks ran out of retries, retry tasks
+// are waiting for their next attempt.
+type TaskInspector interface {
+	ListFailedTasks(queue string, state asynq.TaskState, pageSize int, page int) ([]*asynq.TaskInfo, error)
+	RunTask(queue string, taskID string) error
+	DeleteTask(queue string, taskID string) error
+}
+
+type RedisTaskInspector struct {
+	inspector *asynq.Inspector
+}
+
+func NewRedisTaskInspector(inspector *asynq.Inspector) TaskInspector {
+	return &RedisTaskInspector{inspector: inspector}
+}
+
+func (inspector *RedisTaskInspector) ListFailedTasks(queue string, state asynq.TaskState, pageSize int, page int) ([]*asynq.TaskInfo, error) {
+	opts := []asynq.ListOption{asynq.PageSize(pageSize), asynq.Page(page)}
+	switch state {
+	case asynq.TaskStateArchived:
+		return inspector.inspector.ListArchivedTasks(queue, opts...)
+	case asynq.TaskStateRetry:
+		return inspector.inspector.ListRetryTasks(queue, opts...)
+	default:
+		return nil, fmt.Errorf("tasks in state %s did not fail", state)
+	}
+}
+
+// RunTask moves a failed task back to pending, so it is processed right away.
+func (inspector *RedisTaskInspector) RunTask(queue string, taskID string) error {
+	return inspector.inspector.RunTask(queue, taskID)
+}
+
+func (inspector *RedisTaskInspector) DeleteTask(queue string, taskID string) error {
+	return inspector.inspector.DeleteTask(queue, taskID)
+}
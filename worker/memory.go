@@ -0,0 +1,401 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/kwalter26/udemy-simplebank/blob"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/mail"
+	"github.com/kwalter26/udemy-simplebank/webhook"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultMaxRetry is the max retry asynq uses when a task is enqueued without one.
+const defaultMaxRetry = 25
+
+// MemoryTaskQueue keeps tasks in the memory of the process, for local development and tests without redis. Tasks
+// are lost when the process exits. It is shared by a MemoryTaskDistributor and a MemoryTaskProcessor, and also
+// serves as their TaskInspector.
+type MemoryTaskQueue struct {
+	mu    sync.Mutex
+	tasks map[string]*asynq.TaskInfo
+	// done keeps the ids of processed tasks for outboxTaskRetention, so that duplicates are dropped like in redis
+	done map[string]time.Time
+	// wake is closed and replaced whenever a task becomes due, so that every waiting worker looks at the queue again
+	wake chan struct{}
+}
+
+func NewMemoryTaskQueue() *MemoryTaskQueue {
+	return &MemoryTaskQueue{
+		tasks: map[string]*asynq.TaskInfo{},
+		done:  map[string]time.Time{},
+		wake:  make(chan struct{}),
+	}
+}
+
+// enqueue adds a task with the same options and defaults as asynq.Client.Enqueue.
+func (queue *MemoryTaskQueue) enqueue(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	now := time.Now()
+	info := &asynq.TaskInfo{
+		ID:            uuid.NewString(),
+		Queue:         DefaultQueue,
+		Type:          task.Type(),
+		Payload:       task.Payload(),
+		State:         asynq.TaskStatePending,
+		MaxRetry:      defaultMaxRetry,
+		NextProcessAt: now,
+	}
+	for _, opt := range opts {
+		switch opt.Type() {
+		case asynq.QueueOpt:
+			info.Queue = opt.Value().(string)
+		case asynq.MaxRetryOpt:
+			info.MaxRetry = opt.Value().(int)
+		case asynq.TaskIDOpt:
+			info.ID = opt.Value().(string)
+		case asynq.ProcessAtOpt:
+			info.NextProcessAt = opt.Value().(time.Time)
+		case asynq.ProcessInOpt:
+			info.NextProcessAt = now.Add(opt.Value().(time.Duration))
+		}
+	}
+	if info.NextProcessAt.After(now) {
+		info.State = asynq.TaskStateScheduled
+	}
+
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	for id, doneAt := range queue.done {
+		if now.Sub(doneAt) > outboxTaskRetention {
+			delete(queue.done, id)
+		}
+	}
+	if _, exists := queue.tasks[info.ID]; exists {
+		return nil, asynq.ErrTaskIDConflict
+	}
+	if _, exists := queue.done[info.ID]; exists {
+		return nil, asynq.ErrTaskIDConflict
+	}
+	queue.tasks[info.ID] = info
+	queue.notify()
+
+	copied := *info
+	return &copied, nil
+}
+
+// notify wakes up every waiting worker. The caller holds the lock.
+func (queue *MemoryTaskQueue) notify() {
+	close(queue.wake)
+	queue.wake = make(chan struct{})
+}
+
+// wakeup returns the channel closed by the next notify. A worker takes it before looking for a task, so that a task
+// enqueued in between is not missed.
+func (queue *MemoryTaskQueue) wakeup() <-chan struct{} {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	return queue.wake
+}
+
+// next marks a due task of one of the queues active and returns a copy of it. Queues are picked at random by
+// their weight, the way asynq does. Without a due task it returns how long until the next one is, or zero when
+// there is none.
+func (queue *MemoryTaskQueue) next(priorities map[string]int) (*asynq.TaskInfo, time.Duration) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	now := time.Now()
+	due := map[string]*asynq.TaskInfo{}
+	var wait time.Duration
+	for _, info := range queue.tasks {
+		if info.State == asynq.TaskStateActive || info.State == asynq.TaskStateArchived {
+			continue
+		}
+		if _, ok := priorities[info.Queue]; !ok {
+			continue
+		}
+		if info.NextProcessAt.After(now) {
+			if until := info.NextProcessAt.Sub(now); wait == 0 || until < wait {
+				wait = until
+			}
+			continue
+		}
+		if oldest, ok := due[info.Queue]; !ok || info.NextProcessAt.Before(oldest.NextProcessAt) {
+			due[info.Queue] = info
+		}
+	}
+	if len(due) == 0 {
+		return nil, wait
+	}
+
+	queues := make([]string, 0, len(due))
+	total := 0
+	for name := range due {
+		queues = append(queues, name)
+		total += priorities[name]
+	}
+	sort.Strings(queues)
+	pick := rand.Intn(total)
+	for _, name := range queues {
+		pick -= priorities[name]
+		if pick < 0 {
+			info := due[name]
+			info.State = asynq.TaskStateActive
+			copied := *info
+			return &copied, 0
+		}
+	}
+	return nil, wait
+}
+
+// complete removes a processed task.
+func (queue *MemoryTaskQueue) complete(id string) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	delete(queue.tasks, id)
+	queue.done[id] = time.Now()
+}
+
+// fail schedules the next attempt of a task after delay, or archives it.
+func (queue *MemoryTaskQueue) fail(id string, err error, delay time.Duration, archive bool) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	info, ok := queue.tasks[id]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	info.LastErr = err.Error()
+	info.LastFailedAt = now
+	if archive {
+		info.State = asynq.TaskStateArchived
+		info.NextProcessAt = time.Time{}
+		return
+	}
+	info.State = asynq.TaskStateRetry
+	info.Retried++
+	info.NextProcessAt = now.Add(delay)
+}
+
+func (queue *MemoryTaskQueue) ListFailedTasks(name string, state asynq.TaskState, pageSize int, page int) ([]*asynq.TaskInfo, error) {
+	if state != asynq.TaskStateArchived && state != asynq.TaskStateRetry {
+		return nil, fmt.Errorf("tasks in state %s did not fail", state)
+	}
+
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	var tasks []*asynq.TaskInfo
+	for _, info := range queue.tasks {
+		if info.Queue == name && info.State == state {
+			copied := *info
+			tasks = append(tasks, &copied)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].LastFailedAt.After(tasks[j].LastFailedAt)
+	})
+
+	start := (page - 1) * pageSize
+	if start >= len(tasks) {
+		return []*asynq.TaskInfo{}, nil
+	}
+	end := start + pageSize
+	if end > len(tasks) {
+		end = len(tasks)
+	}
+	return tasks[start:end], nil
+}
+
+func (queue *MemoryTaskQueue) RunTask(name string, taskID string) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	info, ok := queue.tasks[taskID]
+	if !ok || info.Queue != name {
+		return asynq.ErrTaskNotFound
+	}
+	if info.State == asynq.TaskStateActive || info.State == asynq.TaskStatePending {
+		return fmt.Errorf("task is already %s", info.State)
+	}
+	info.State = asynq.TaskStatePending
+	info.NextProcessAt = time.Now()
+	queue.notify()
+	return nil
+}
+
+func (queue *MemoryTaskQueue) DeleteTask(name string, taskID string) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	info, ok := queue.tasks[taskID]
+	if !ok || info.Queue != name {
+		return asynq.ErrTaskNotFound
+	}
+	if info.State == asynq.TaskStateActive {
+		return fmt.Errorf("cannot delete an active task")
+	}
+	delete(queue.tasks, taskID)
+	return nil
+}
+
+// MemoryTaskDistributor enqueues tasks to a MemoryTaskQueue.
+type MemoryTaskDistributor struct {
+	queue *MemoryTaskQueue
+}
+
+func NewMemoryTaskDistributor(queue *MemoryTaskQueue) TaskDistributor {
+	return &MemoryTaskDistributor{queue: queue}
+}
+
+func (distributor *MemoryTaskDistributor) DistributeTaskSendVerifyEmail(ctx context.Context, payload *PayloadSendVerifyEmail, opts ...asynq.Option) error {
+	tracedPayload := *payload
+	tracedPayload.TaskMetadata = newTaskMetadata(ctx)
+	jsonPayload, err := json.Marshal(tracedPayload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	task := asynq.NewTask(TaskSendVerifyEmail, jsonPayload)
+	_, err = distributor.queue.enqueue(task, taskOptions(TaskSendVerifyEmail, opts...)...)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+	return nil
+}
+
+func (distributor *MemoryTaskDistributor) DistributeOutboxTask(ctx context.Context, outboxTask db.Outbox) error {
+	task := asynq.NewTask(outboxTask.TaskType, outboxTask.Payload)
+	_, err := distributor.queue.enqueue(task,
+		asynq.TaskID(outboxTask.TaskID),
+		asynq.Queue(outboxTask.Queue),
+		asynq.MaxRetry(int(outboxTask.MaxRetry)),
+		asynq.ProcessAt(outboxTask.ProcessAt),
+	)
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+	return nil
+}
+
+func (distributor *MemoryTaskDistributor) Close() error {
+	return nil
+}
+
+// MemoryTaskProcessor runs the tasks of a MemoryTaskQueue with the handlers of RedisTaskProcessor, honouring
+// queue weights, delays and the retry policies of the task types.
+type MemoryTaskProcessor struct {
+	queue           *MemoryTaskQueue
+	handlers        *RedisTaskProcessor
+	concurrency     int
+	shutdownTimeout time.Duration
+	retryDelay      asynq.RetryDelayFunc
+
+	stop    chan struct{}
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+}
+
+func NewMemoryTaskProcessor(queue *MemoryTaskQueue, store db.Store, mailer mail.EmailSender, blobs blob.Store, shutdownTimeout time.Duration) TaskProcessor {
+	return &MemoryTaskProcessor{
+		queue:           queue,
+		handlers:        &RedisTaskProcessor{store: store, mailer: mailer, webhooks: webhook.NewSender(0, false), blobs: blobs},
+		concurrency:     10,
+		shutdownTimeout: shutdownTimeout,
+		retryDelay:      retryDelay,
+	}
+}
+
+// Start serves every registered task type until Shutdown.
+func (processor *MemoryTaskProcessor) Start() error {
+	mux := processor.handlers.newServeMux()
+	ctx, cancel := context.WithCancel(context.Background())
+	processor.stop = make(chan struct{})
+	processor.cancel = cancel
+
+	for i := 0; i < processor.concurrency; i++ {
+		processor.workers.Add(1)
+		go func() {
+			defer processor.workers.Done()
+			processor.work(ctx, mux)
+		}()
+	}
+	return nil
+}
+
+func (processor *MemoryTaskProcessor) work(ctx context.Context, handler asynq.Handler) {
+	for {
+		select {
+		case <-processor.stop:
+			return
+		default:
+		}
+
+		wake := processor.queue.wakeup()
+		info, wait := processor.queue.next(queuePriorities)
+		if info == nil {
+			var timer <-chan time.Time
+			if wait > 0 {
+				timer = time.After(wait)
+			}
+			select {
+			case <-processor.stop:
+				return
+			case <-wake:
+			case <-timer:
+			}
+			continue
+		}
+		processor.process(ctx, handler, info)
+	}
+}
+
+func (processor *MemoryTaskProcessor) process(ctx context.Context, handler asynq.Handler, info *asynq.TaskInfo) {
+	task := asynq.NewTask(info.Type, info.Payload)
+	attempt := taskAttempt{TaskID: info.ID, Queue: info.Queue, Retried: info.Retried, MaxRetry: info.MaxRetry}
+	ctx = context.WithValue(ctx, taskAttemptKey{}, attempt)
+
+	err := handler.ProcessTask(ctx, task)
+	if err == nil {
+		processor.queue.complete(info.ID)
+		return
+	}
+
+	processor.handlers.handleError(ctx, task, err)
+	archive := attempt.Retried >= attempt.MaxRetry || errors.Is(err, asynq.SkipRetry)
+	processor.queue.fail(info.ID, err, processor.retryDelay(attempt.Retried, err, task), archive)
+}
+
+// Shutdown stops taking new tasks and waits up to the shutdown timeout for running ones to finish before
+// cancelling them.
+func (processor *MemoryTaskProcessor) Shutdown() {
+	if processor.stop == nil {
+		return
+	}
+	close(processor.stop)
+
+	finished := make(chan struct{})
+	go func() {
+		processor.workers.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(processor.shutdownTimeout):
+		processor.cancel()
+		<-finished
+	}
+	processor.cancel()
+}
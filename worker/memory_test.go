@@ -0,0 +1,239 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMailer fails the first failures emails and records the recipients of every email.
+type fakeMailer struct {
+	mu       sync.Mutex
+	failures int
+	sent     []string
+	calls    chan struct{}
+}
+
+func newFakeMailer(failures int) *fakeMailer {
+	return &fakeMailer{failures: failures, calls: make(chan struct{}, 100)}
+}
+
+func (mailer *fakeMailer) SendEmail(subject string, content string, to []string, cc []string, bcc []string, attachFiles []string) error {
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+	defer func() { mailer.calls <- struct{}{} }()
+
+	if mailer.failures > 0 {
+		mailer.failures--
+		return errors.New("smtp down")
+	}
+	mailer.sent = append(mailer.sent, to...)
+	return nil
+}
+
+func (mailer *fakeMailer) waitForCalls(t *testing.T, n int) {
+	for i := 0; i < n; i++ {
+		select {
+		case <-mailer.calls:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d of %d emails", i, n)
+		}
+	}
+}
+
+func TestMemoryTaskQueueEnqueue(t *testing.T) {
+	queue := NewMemoryTaskQueue()
+
+	info, err := queue.enqueue(asynq.NewTask(TaskSendVerifyEmail, nil), asynq.Queue(EmailQueue), asynq.MaxRetry(3), asynq.ProcessIn(time.Hour))
+	require.NoError(t, err)
+	require.NotEmpty(t, info.ID)
+	require.Equal(t, EmailQueue, info.Queue)
+	require.Equal(t, 3, info.MaxRetry)
+	require.Equal(t, asynq.TaskStateScheduled, info.State)
+
+	info, err = queue.enqueue(asynq.NewTask(TaskSendVerifyEmail, nil), asynq.TaskID("task-1"))
+	require.NoError(t, err)
+	require.Equal(t, DefaultQueue, info.Queue)
+	require.Equal(t, defaultMaxRetry, info.MaxRetry)
+	require.Equal(t, asynq.TaskStatePending, info.State)
+
+	_, err = queue.enqueue(asynq.NewTask(TaskSendVerifyEmail, nil), asynq.TaskID("task-1"))
+	require.ErrorIs(t, err, asynq.ErrTaskIDConflict)
+
+	// processed tasks keep their id reserved
+	queue.complete("task-1")
+	_, err = queue.enqueue(asynq.NewTask(TaskSendVerifyEmail, nil), asynq.TaskID("task-1"))
+	require.ErrorIs(t, err, asynq.ErrTaskIDConflict)
+}
+
+func TestMemoryTaskQueueNext(t *testing.T) {
+	queue := NewMemoryTaskQueue()
+	priorities := map[string]int{EmailQueue: 1}
+
+	info, wait := queue.next(priorities)
+	require.Nil(t, info)
+	require.Zero(t, wait)
+
+	_, err := queue.enqueue(asynq.NewTask(TaskSendVerifyEmail, nil), asynq.Queue(EmailQueue), asynq.ProcessIn(time.Minute))
+	require.NoError(t, err)
+	_, err = queue.enqueue(asynq.NewTask(TaskSendVerifyEmail, nil), asynq.Queue("unknown"))
+	require.NoError(t, err)
+
+	info, wait = queue.next(priorities)
+	require.Nil(t, info)
+	require.InDelta(t, time.Minute, wait, float64(time.Second))
+
+	due, err := queue.enqueue(asynq.NewTask(TaskSendVerifyEmail, nil), asynq.Queue(EmailQueue))
+	require.NoError(t, err)
+	info, _ = queue.next(priorities)
+	require.Equal(t, due.ID, info.ID)
+	require.Equal(t, asynq.TaskStateActive, info.State)
+
+	// an active task is not handed out twice
+	info, _ = queue.next(priorities)
+	require.Nil(t, info)
+
+	queue.fail(due.ID, errors.New("boom"), time.Hour, false)
+	tasks, err := queue.ListFailedTasks(EmailQueue, asynq.TaskStateRetry, 10, 1)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	require.Equal(t, 1, tasks[0].Retried)
+	require.Equal(t, "boom", tasks[0].LastErr)
+
+	require.NoError(t, queue.RunTask(EmailQueue, due.ID))
+	info, _ = queue.next(priorities)
+	require.Equal(t, due.ID, info.ID)
+
+	require.ErrorIs(t, queue.RunTask(EmailQueue, "missing"), asynq.ErrTaskNotFound)
+	require.ErrorIs(t, queue.DeleteTask(DefaultQueue, due.ID), asynq.ErrTaskNotFound)
+}
+
+func TestMemoryTaskProcessorRetries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	user := db.User{Username: "alice", FullName: "Alice", Email: "alice@example.com"}
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(2).Return(user, nil)
+	store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(2).Return(db.VerifyEmail{ID: 1}, nil)
+
+	mailer := newFakeMailer(1)
+	queue := NewMemoryTaskQueue()
+	processor := NewMemoryTaskProcessor(queue, store, mailer, nil, time.Second).(*MemoryTaskProcessor)
+	processor.retryDelay = func(n int, err error, task *asynq.Task) time.Duration { return 10 * time.Millisecond }
+	require.NoError(t, processor.Start())
+	defer processor.Shutdown()
+
+	distributor := NewMemoryTaskDistributor(queue)
+	err := distributor.DistributeTaskSendVerifyEmail(context.Background(), &PayloadSendVerifyEmail{Username: user.Username}, asynq.ProcessIn(10*time.Millisecond))
+	require.NoError(t, err)
+
+	mailer.waitForCalls(t, 2)
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+	require.Equal(t, []string{user.Email}, mailer.sent)
+}
+
+func TestMemoryTaskProcessorDeadLetter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	deadLetters := make(chan db.CreateDeadLetterTaskParams, 1)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(2).Return(db.User{}, errors.New("db down"))
+	store.EXPECT().
+		CreateDeadLetterTask(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.CreateDeadLetterTaskParams) (db.DeadLetterTask, error) {
+			deadLetters <- arg
+			return db.DeadLetterTask{}, nil
+		})
+
+	queue := NewMemoryTaskQueue()
+	processor := NewMemoryTaskProcessor(queue, store, newFakeMailer(0), nil, time.Second).(*MemoryTaskProcessor)
+	processor.retryDelay = func(n int, err error, task *asynq.Task) time.Duration { return time.Millisecond }
+	require.NoError(t, processor.Start())
+	defer processor.Shutdown()
+
+	distributor := NewMemoryTaskDistributor(queue)
+	err := distributor.DistributeOutboxTask(context.Background(), db.Outbox{
+		TaskID:    "task-1",
+		TaskType:  TaskSendVerifyEmail,
+		Payload:   []byte(`{"username":"alice"}`),
+		Queue:     EmailQueue,
+		MaxRetry:  1,
+		ProcessAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	select {
+	case arg := <-deadLetters:
+		require.Equal(t, "task-1", arg.TaskID)
+		require.Equal(t, EmailQueue, arg.Queue)
+		require.Equal(t, int32(1), arg.Retried)
+	case <-time.After(5 * time.Second):
+		t.Fatal("task was not moved to dead letter")
+	}
+
+	require.Eventually(t, func() bool {
+		tasks, err := queue.ListFailedTasks(EmailQueue, asynq.TaskStateArchived, 10, 1)
+		return err == nil && len(tasks) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// TestMemoryTaskProcessorConcurrency makes several tasks due at once while every worker is idle: each of them is
+// picked up at once instead of one after the other.
+func TestMemoryTaskProcessorConcurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	const n = 5
+	started := make(chan struct{}, n)
+	release := make(chan struct{})
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Any()).
+		Times(n).
+		DoAndReturn(func(ctx context.Context, username string) (db.User, error) {
+			started <- struct{}{}
+			<-release
+			return db.User{}, errors.New("db down")
+		})
+
+	queue := NewMemoryTaskQueue()
+	processor := NewMemoryTaskProcessor(queue, store, newFakeMailer(0), nil, time.Second).(*MemoryTaskProcessor)
+	processor.retryDelay = func(n int, err error, task *asynq.Task) time.Duration { return time.Hour }
+	require.NoError(t, processor.Start())
+	defer processor.Shutdown()
+	defer close(release)
+
+	distributor := NewMemoryTaskDistributor(queue)
+	for i := 0; i < n; i++ {
+		err := distributor.DistributeTaskSendVerifyEmail(context.Background(), &PayloadSendVerifyEmail{Username: "alice"}, asynq.ProcessIn(time.Hour))
+		require.NoError(t, err)
+	}
+	// let every worker wait for the scheduled tasks, then make them all due at once
+	time.Sleep(50 * time.Millisecond)
+	queue.mu.Lock()
+	for _, info := range queue.tasks {
+		info.State = asynq.TaskStatePending
+		info.NextProcessAt = time.Now()
+	}
+	queue.notify()
+	queue.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-started:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%d of %d tasks ran in parallel", i, n)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hibiken/asynq"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"github.com/kwalter26/udemy-simplebank/tracing"
+)
+
+// TaskMetadata is embedded in every task payload and carries what the processor needs besides the task itself.
+type TaskMetadata struct {
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+	RequestId    string            `json:"request_id,omitempty"`
+}
+
+func newTaskMetadata(ctx context.Context) TaskMetadata {
+	return TaskMetadata{
+		TraceContext: tracing.Inject(ctx),
+		RequestId:    logging.RequestId(ctx),
+	}
+}
+
+// taskMetadata reads the metadata of a task without knowing the type of its payload.
+func taskMetadata(task *asynq.Task) TaskMetadata {
+	var metadata TaskMetadata
+	_ = json.Unmarshal(task.Payload(), &metadata)
+	return metadata
+}
+
+// loggingMiddleware stores a logger correlated with the request that enqueued the task in the context. Tasks
+// enqueued outside a request get a request id of their own.
+func loggingMiddleware(handler asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		id := taskMetadata(task).RequestId
+		if !logging.ValidRequestId(id) {
+			id = logging.NewRequestId()
+		}
+
+		ctx = logging.WithRequestId(ctx, id)
+		logger := logging.Ctx(ctx).With().Str("type", task.Type()).Logger()
+		return handler.ProcessTask(logger.WithContext(ctx), task)
+	})
+}
@@ -0,0 +1,30 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hibiken/asynq"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	ctx := logging.WithRequestId(context.Background(), "request-1")
+	payload := PayloadSendVerifyEmail{TaskMetadata: newTaskMetadata(ctx), Username: "alice"}
+	jsonPayload, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	var requestId string
+	handler := loggingMiddleware(asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		requestId = logging.RequestId(ctx)
+		return nil
+	}))
+
+	require.NoError(t, handler.ProcessTask(context.Background(), asynq.NewTask(TaskSendVerifyEmail, jsonPayload)))
+	require.Equal(t, "request-1", requestId)
+
+	require.NoError(t, handler.ProcessTask(context.Background(), asynq.NewTask(TaskSendVerifyEmail, []byte(`{}`))))
+	require.NotEqual(t, "request-1", requestId)
+	require.True(t, logging.ValidRequestId(requestId))
+}
@@ -10,6 +10,7 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	asynq "github.com/hibiken/asynq"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
 	worker "github.com/kwalter26/udemy-simplebank/worker"
 )
 
@@ -36,6 +37,34 @@ func (m *MockTaskDistributor) EXPECT() *MockTaskDistributorMockRecorder {
 	return m.recorder
 }
 
+// Close mocks base method.
+func (m *MockTaskDistributor) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockTaskDistributorMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockTaskDistributor)(nil).Close))
+}
+
+// DistributeOutboxTask mocks base method.
+func (m *MockTaskDistributor) DistributeOutboxTask(arg0 context.Context, arg1 db.Outbox) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DistributeOutboxTask", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeOutboxTask indicates an expected call of DistributeOutboxTask.
+func (mr *MockTaskDistributorMockRecorder) DistributeOutboxTask(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeOutboxTask", reflect.TypeOf((*MockTaskDistributor)(nil).DistributeOutboxTask), arg0, arg1)
+}
+
 // DistributeTaskSendVerifyEmail mocks base method.
 func (m *MockTaskDistributor) DistributeTaskSendVerifyEmail(arg0 context.Context, arg1 *worker.PayloadSendVerifyEmail, arg2 ...asynq.Option) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"github.com/kwalter26/udemy-simplebank/tracing"
+	"time"
+)
+
+// outboxTaskRetention keeps the id of a processed task reserved in redis, so that a row published twice is not
+// processed twice.
+const outboxTaskRetention = 24 * time.Hour
+
+// OutboxOptions are the enqueue options stored with a task in the outbox. Zero values fall back to the queue and
+// retry policy the task type was registered with.
+type OutboxOptions struct {
+	Queue     string
+	MaxRetry  int
+	ProcessIn time.Duration
+}
+
+// NewSendVerifyEmailOutboxTask builds the outbox row of a send verify email task.
+func NewSendVerifyEmailOutboxTask(ctx context.Context, payload *PayloadSendVerifyEmail, opts OutboxOptions) (db.CreateOutboxTaskParams, error) {
+	tracedPayload := *payload
+	tracedPayload.TaskMetadata = newTaskMetadata(ctx)
+	return newOutboxTask(TaskSendVerifyEmail, tracedPayload, opts)
+}
+
+// NewSendAccountStatementOutboxTask builds the outbox row of a send account statement task.
+func NewSendAccountStatementOutboxTask(ctx context.Context, payload *PayloadSendAccountStatement, opts OutboxOptions) (db.CreateOutboxTaskParams, error) {
+	tracedPayload := *payload
+	tracedPayload.TaskMetadata = newTaskMetadata(ctx)
+	return newOutboxTask(TaskSendAccountStatement, tracedPayload, opts)
+}
+
+func newOutboxTask(taskType string, payload interface{}, opts OutboxOptions) (db.CreateOutboxTaskParams, error) {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return db.CreateOutboxTaskParams{}, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	definition := taskRegistry[taskType]
+	queue := opts.Queue
+	if queue == "" {
+		queue = definition.Queue
+	}
+	if queue == "" {
+		queue = DefaultQueue
+	}
+	maxRetry := opts.MaxRetry
+	if maxRetry == 0 {
+		maxRetry = definition.Retry.MaxRetry
+	}
+
+	return db.CreateOutboxTaskParams{
+		TaskID:    uuid.NewString(),
+		TaskType:  taskType,
+		Payload:   jsonPayload,
+		Queue:     queue,
+		MaxRetry:  int32(maxRetry),
+		ProcessAt: time.Now().Add(opts.ProcessIn),
+	}, nil
+}
+
+// DistributeOutboxTask enqueues a task read from the outbox under its task id. A task that was already enqueued
+// is not an error: the relay publishes at least once and redis drops the duplicate.
+func (distributor *RedisTaskDistributor) DistributeOutboxTask(ctx context.Context, outboxTask db.Outbox) error {
+	task := asynq.NewTask(outboxTask.TaskType, outboxTask.Payload)
+	metadata := taskMetadata(task)
+
+	ctx = tracing.Extract(ctx, metadata.TraceContext)
+	if logging.ValidRequestId(metadata.RequestId) {
+		ctx = logging.WithRequestId(ctx, metadata.RequestId)
+	}
+	ctx, span := startEnqueueSpan(ctx, outboxTask.TaskType)
+	defer span.End()
+
+	info, err := distributor.client.EnqueueContext(ctx, task,
+		asynq.TaskID(outboxTask.TaskID),
+		asynq.Queue(outboxTask.Queue),
+		asynq.MaxRetry(int(outboxTask.MaxRetry)),
+		asynq.ProcessAt(outboxTask.ProcessAt),
+		asynq.Retention(outboxTaskRetention),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		logging.Ctx(ctx).Info().Str("task_id", outboxTask.TaskID).Msg("outbox task already enqueued")
+		return nil
+	}
+	if err != nil {
+		recordSpanError(span, err)
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+	logging.Ctx(ctx).
+		Info().
+		Str("type", task.Type()).
+		Str("task_id", info.ID).
+		Bytes("payload", logging.RedactJSON(task.Payload())).
+		Str("queue", info.Queue).
+		Int("max_retry", info.MaxRetry).
+		Msg("enqueued outbox task")
+	return nil
+}
@@ -0,0 +1,85 @@
+package worker
+
+import (
+	"context"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// OutboxRelay publishes the tasks committed to the outbox to the task queue. Every task is published at least
+// once; the distributor drops duplicates by task id.
+type OutboxRelay struct {
+	store       db.Store
+	distributor TaskDistributor
+	interval    time.Duration
+	batchSize   int32
+	maxAttempts int32
+	retention   time.Duration
+}
+
+// NewOutboxRelay creates a relay polling the outbox every interval for up to batchSize tasks. A task that failed to
+// publish maxAttempts times is moved to the dead letters. Published tasks are deleted once they are older than
+// retention.
+func NewOutboxRelay(store db.Store, distributor TaskDistributor, interval time.Duration, batchSize int32, maxAttempts int32, retention time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		store:       store,
+		distributor: distributor,
+		interval:    interval,
+		batchSize:   batchSize,
+		maxAttempts: maxAttempts,
+		retention:   retention,
+	}
+}
+
+// Run relays tasks until the context is done.
+func (relay *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relay.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// keep going while full batches come back, so that a backlog drains faster than one batch per interval
+		for {
+			result, err := relay.RelayOnce(ctx)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to relay outbox tasks")
+				break
+			}
+			if result.Dead > 0 {
+				log.Error().Int("dead", result.Dead).Msg("outbox tasks moved to dead letter")
+			}
+			if result.Published+result.Failed < int(relay.batchSize) || result.Failed > 0 || ctx.Err() != nil {
+				break
+			}
+		}
+
+		deleted, err := relay.store.DeletePublishedOutboxTasks(ctx, time.Now().Add(-relay.retention))
+		if err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Msg("failed to delete published outbox tasks")
+		} else if deleted > 0 {
+			log.Info().Int64("deleted", deleted).Msg("deleted published outbox tasks")
+		}
+	}
+}
+
+// RelayOnce publishes one batch of pending tasks.
+func (relay *OutboxRelay) RelayOnce(ctx context.Context) (db.PublishOutboxTxResult, error) {
+	return relay.store.PublishOutboxTx(ctx, db.PublishOutboxTxParams{
+		Limit:       relay.batchSize,
+		MaxAttempts: relay.maxAttempts,
+		Publish: func(task db.Outbox) error {
+			err := relay.distributor.DistributeOutboxTask(ctx, task)
+			if err != nil {
+				logging.Ctx(ctx).Warn().Err(err).Str("task_id", task.TaskID).Int32("attempts", task.Attempts+1).Msg("failed to publish outbox task")
+			}
+			return err
+		},
+	})
+}
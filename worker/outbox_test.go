@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// fakeDistributor records the outbox tasks it is asked to publish and fails the ones listed in failures.
+type fakeDistributor struct {
+	published []string
+	failures  map[string]error
+}
+
+func (distributor *fakeDistributor) DistributeTaskSendVerifyEmail(ctx context.Context, payload *PayloadSendVerifyEmail, opts ...asynq.Option) error {
+	return errors.New("not expected")
+}
+
+func (distributor *fakeDistributor) DistributeOutboxTask(ctx context.Context, task db.Outbox) error {
+	if err := distributor.failures[task.TaskID]; err != nil {
+		return err
+	}
+	distributor.published = append(distributor.published, task.TaskID)
+	return nil
+}
+
+func (distributor *fakeDistributor) Close() error {
+	return nil
+}
+
+func TestNewSendVerifyEmailOutboxTask(t *testing.T) {
+	ctx := logging.WithRequestId(context.Background(), "request-1")
+	task, err := NewSendVerifyEmailOutboxTask(ctx, &PayloadSendVerifyEmail{Username: "alice"}, OutboxOptions{
+		Queue:     DefaultQueue,
+		MaxRetry:  3,
+		ProcessIn: time.Minute,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, task.TaskID)
+	require.Equal(t, TaskSendVerifyEmail, task.TaskType)
+	require.Equal(t, DefaultQueue, task.Queue)
+	require.Equal(t, int32(3), task.MaxRetry)
+	require.WithinDuration(t, time.Now().Add(time.Minute), task.ProcessAt, time.Second)
+
+	var payload PayloadSendVerifyEmail
+	require.NoError(t, json.Unmarshal(task.Payload, &payload))
+	require.Equal(t, "alice", payload.Username)
+	require.Equal(t, "request-1", payload.RequestId)
+
+	other, err := NewSendVerifyEmailOutboxTask(ctx, &PayloadSendVerifyEmail{Username: "alice"}, OutboxOptions{})
+	require.NoError(t, err)
+	require.NotEqual(t, task.TaskID, other.TaskID)
+	// the registered retry policy fills in what the caller left out
+	require.Equal(t, EmailQueue, other.Queue)
+	require.Equal(t, int32(10), other.MaxRetry)
+}
+
+func TestOutboxRelayOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	tasks := []db.Outbox{{ID: 1, TaskID: "task-1"}, {ID: 2, TaskID: "task-2"}, {ID: 3, TaskID: "task-3"}}
+	distributor := &fakeDistributor{failures: map[string]error{"task-2": errors.New("redis down")}}
+
+	store.EXPECT().
+		PublishOutboxTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.PublishOutboxTxParams) (db.PublishOutboxTxResult, error) {
+			require.Equal(t, int32(10), arg.Limit)
+			require.Equal(t, int32(5), arg.MaxAttempts)
+			var result db.PublishOutboxTxResult
+			for _, task := range tasks {
+				if err := arg.Publish(task); err != nil {
+					result.Failed++
+					continue
+				}
+				result.Published++
+			}
+			return result, nil
+		})
+
+	relay := NewOutboxRelay(store, distributor, time.Second, 10, 5, time.Hour)
+	result, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, db.PublishOutboxTxResult{Published: 2, Failed: 1}, result)
+	require.Equal(t, []string{"task-1", "task-3"}, distributor.published)
+}
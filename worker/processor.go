@@ -2,55 +2,156 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"github.com/hibiken/asynq"
+	"github.com/kwalter26/udemy-simplebank/blob"
 	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/logging"
 	"github.com/kwalter26/udemy-simplebank/mail"
+	"github.com/kwalter26/udemy-simplebank/webhook"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
+	"sort"
+	"time"
 )
 
 const (
 	EmailQueue   = "email"
+	WebhookQueue = db.WebhookDeliveryQueue
 	DefaultQueue = "default"
 )
 
+// queuePriorities are the queues the processor pulls from and their weights.
+var queuePriorities = map[string]int{
+	EmailQueue:   10,
+	WebhookQueue: 5,
+	DefaultQueue: 5,
+}
+
+// deadLetterTimeout bounds the write of a dead letter, which may run after the task context expired.
+const deadLetterTimeout = 5 * time.Second
+
+// Queues returns the names of the queues the processor pulls from.
+func Queues() []string {
+	queues := make([]string, 0, len(queuePriorities))
+	for queue := range queuePriorities {
+		queues = append(queues, queue)
+	}
+	sort.Strings(queues)
+	return queues
+}
+
 type TaskProcessor interface {
 	Start() error
-	ProcessTaskSendVerifyEmail(ctx context.Context, task *asynq.Task) error
+	Shutdown()
 }
 
 type RedisTaskProcessor struct {
-	server *asynq.Server
-	store  db.Store
-	mailer mail.EmailSender
+	server   *asynq.Server
+	store    db.Store
+	mailer   mail.EmailSender
+	webhooks *webhook.Sender
+	blobs    blob.Store
 }
 
-func NewRedisTaskProcessor(redisOpt asynq.RedisClientOpt, store db.Store, mailer mail.EmailSender) TaskProcessor {
+func NewRedisTaskProcessor(redisOpt asynq.RedisClientOpt, store db.Store, mailer mail.EmailSender, blobs blob.Store, shutdownTimeout time.Duration) TaskProcessor {
 	logger := NewLogger()
 	redis.SetLogger(logger)
-	server := asynq.NewServer(redisOpt, asynq.Config{
-
-		Concurrency: 10,
-		Queues: map[string]int{
-			EmailQueue:   10,
-			DefaultQueue: 5,
-		},
-		ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
-			// log error messages to stdout
-			log.Error().
-				Err(err).
-				Str("type", task.Type()).
-				Bytes("payload", task.Payload()).
-				Msg("process task error")
-		}),
-		Logger: logger,
+	processor := &RedisTaskProcessor{store: store, mailer: mailer, webhooks: webhook.NewSender(0, false), blobs: blobs}
+	processor.server = asynq.NewServer(redisOpt, asynq.Config{
+
+		Concurrency:     10,
+		Queues:          queuePriorities,
+		RetryDelayFunc:  retryDelay,
+		ErrorHandler:    asynq.ErrorHandlerFunc(processor.handleError),
+		Logger:          logger,
+		ShutdownTimeout: shutdownTimeout,
 	})
-	return &RedisTaskProcessor{server: server, store: store, mailer: mailer}
+	return processor
 }
+
+// Start serves every registered task type.
 func (processor *RedisTaskProcessor) Start() error {
+	return processor.server.Start(processor.newServeMux())
+}
+
+func (processor *RedisTaskProcessor) newServeMux() *asynq.ServeMux {
 	mux := asynq.NewServeMux()
+	mux.Use(tracingMiddleware, loggingMiddleware)
+
+	for _, definition := range RegisteredTasks() {
+		mux.HandleFunc(definition.Type, processor.handlerFunc(definition.Handler))
+	}
+	return mux
+}
+
+func (processor *RedisTaskProcessor) handlerFunc(handler TaskHandler) asynq.HandlerFunc {
+	return func(ctx context.Context, task *asynq.Task) error {
+		return handler(processor, ctx, task)
+	}
+}
 
-	mux.HandleFunc(TaskSendVerifyEmail, processor.ProcessTaskSendVerifyEmail)
+// taskAttempt is what the processor knows about the attempt running a task.
+type taskAttempt struct {
+	TaskID   string
+	Queue    string
+	Retried  int
+	MaxRetry int
+}
+
+type taskAttemptKey struct{}
+
+// currentAttempt returns the attempt running in ctx, read from asynq or stored by the in-memory processor.
+func currentAttempt(ctx context.Context) taskAttempt {
+	if attempt, ok := ctx.Value(taskAttemptKey{}).(taskAttempt); ok {
+		return attempt
+	}
+	var attempt taskAttempt
+	attempt.TaskID, _ = asynq.GetTaskID(ctx)
+	attempt.Queue, _ = asynq.GetQueueName(ctx)
+	attempt.Retried, _ = asynq.GetRetryCount(ctx)
+	attempt.MaxRetry, _ = asynq.GetMaxRetry(ctx)
+	return attempt
+}
+
+// handleError logs a failed attempt and records the task as a dead letter when it will not be retried anymore.
+func (processor *RedisTaskProcessor) handleError(ctx context.Context, task *asynq.Task, err error) {
+	log.Error().
+		Err(err).
+		Str("type", task.Type()).
+		Bytes("payload", logging.RedactJSON(task.Payload())).
+		Msg("process task error")
+
+	processor.recordDeadLetter(task, currentAttempt(ctx), err)
+}
+
+// recordDeadLetter writes a task that will not be retried anymore to the dead letter table.
+func (processor *RedisTaskProcessor) recordDeadLetter(task *asynq.Task, attempt taskAttempt, err error) {
+	if attempt.Retried < attempt.MaxRetry && !errors.Is(err, asynq.SkipRetry) {
+		return
+	}
+
+	// the task context may be expired already, which is why the task failed
+	ctx, cancel := context.WithTimeout(context.Background(), deadLetterTimeout)
+	defer cancel()
+
+	_, dbErr := processor.store.CreateDeadLetterTask(ctx, db.CreateDeadLetterTaskParams{
+		TaskID:    attempt.TaskID,
+		TaskType:  task.Type(),
+		Queue:     attempt.Queue,
+		Payload:   task.Payload(),
+		LastError: err.Error(),
+		Retried:   int32(attempt.Retried),
+		MaxRetry:  int32(attempt.MaxRetry),
+	})
+	if dbErr != nil {
+		log.Error().Err(dbErr).Str("task_id", attempt.TaskID).Str("type", task.Type()).Msg("cannot record dead letter task")
+		return
+	}
+	log.Warn().Str("task_id", attempt.TaskID).Str("type", task.Type()).Str("queue", attempt.Queue).Msg("task moved to dead letter")
+}
 
-	return processor.server.Start(mux)
+// Shutdown stops pulling new tasks and waits up to the shutdown timeout for running ones to finish.
+func (processor *RedisTaskProcessor) Shutdown() {
+	processor.server.Shutdown()
 }
@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"sort"
+	"time"
+)
+
+// TaskHandler processes a task with the dependencies of the processor. Methods of RedisTaskProcessor are used
+// through method expressions, e.g. (*RedisTaskProcessor).ProcessTaskSendVerifyEmail.
+type TaskHandler func(processor *RedisTaskProcessor, ctx context.Context, task *asynq.Task) error
+
+// RetryPolicy is how often and how far apart the attempts of a task type are.
+type RetryPolicy struct {
+	// MaxRetry is used when a task is enqueued without one. Zero keeps the asynq default.
+	MaxRetry int
+	// Delay returns how long to wait after retried failed attempts. Nil keeps the asynq default.
+	Delay func(retried int) time.Duration
+}
+
+// TaskDefinition is a task type the processor handles.
+type TaskDefinition struct {
+	Type    string
+	Queue   string
+	Handler TaskHandler
+	Retry   RetryPolicy
+}
+
+var taskRegistry = map[string]TaskDefinition{}
+
+// RegisterTask adds a task type to the processor. Task files register themselves from init, so a new task type
+// does not touch the processor. Registering a type twice is a programming error and panics.
+func RegisterTask(definition TaskDefinition) {
+	if definition.Type == "" || definition.Handler == nil {
+		panic("worker: task definition needs a type and a handler")
+	}
+	if _, exists := taskRegistry[definition.Type]; exists {
+		panic(fmt.Sprintf("worker: task type %q registered twice", definition.Type))
+	}
+	taskRegistry[definition.Type] = definition
+}
+
+// RegisteredTasks returns the registered task types sorted by type.
+func RegisteredTasks() []TaskDefinition {
+	definitions := make([]TaskDefinition, 0, len(taskRegistry))
+	for _, definition := range taskRegistry {
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Type < definitions[j].Type
+	})
+	return definitions
+}
+
+// ExponentialBackoff returns a retry delay that starts at base and doubles with every attempt up to max.
+func ExponentialBackoff(base, max time.Duration) func(retried int) time.Duration {
+	return func(retried int) time.Duration {
+		delay := base
+		for i := 0; i < retried && delay < max; i++ {
+			delay *= 2
+		}
+		if delay > max {
+			return max
+		}
+		return delay
+	}
+}
+
+// taskOptions prepends the queue and max retry of the task type to opts, so that options given by the caller win.
+func taskOptions(taskType string, opts ...asynq.Option) []asynq.Option {
+	definition, ok := taskRegistry[taskType]
+	if !ok {
+		return opts
+	}
+	var defaults []asynq.Option
+	if definition.Queue != "" {
+		defaults = append(defaults, asynq.Queue(definition.Queue))
+	}
+	if definition.Retry.MaxRetry > 0 {
+		defaults = append(defaults, asynq.MaxRetry(definition.Retry.MaxRetry))
+	}
+	return append(defaults, opts...)
+}
+
+// retryDelay applies the retry policy of the task type and keeps the asynq default for types without one.
+func retryDelay(retried int, err error, task *asynq.Task) time.Duration {
+	if definition, ok := taskRegistry[task.Type()]; ok && definition.Retry.Delay != nil {
+		return definition.Retry.Delay(retried)
+	}
+	return asynq.DefaultRetryDelayFunc(retried, err, task)
+}
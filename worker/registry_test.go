@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/webhook"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRegisteredTasks(t *testing.T) {
+	definitions := RegisteredTasks()
+
+	types := make([]string, 0, len(definitions))
+	for _, definition := range definitions {
+		require.NotNil(t, definition.Handler)
+		require.Contains(t, Queues(), definition.Queue)
+		types = append(types, definition.Type)
+	}
+	require.Equal(t, []string{TaskAccrueInterest, TaskDeliverWebhook, TaskGenerateMonthlyStatement, TaskScheduleInterestAccrual, TaskScheduleMonthlyStatements, TaskSendAccountStatement, TaskSendVerifyEmail}, types)
+
+	require.Panics(t, func() {
+		RegisterTask(TaskDefinition{Type: TaskSendVerifyEmail, Handler: (*RedisTaskProcessor).ProcessTaskSendVerifyEmail})
+	})
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(time.Second, 10*time.Second)
+	require.Equal(t, time.Second, backoff(0))
+	require.Equal(t, 4*time.Second, backoff(2))
+	require.Equal(t, 10*time.Second, backoff(4))
+	require.Equal(t, 10*time.Second, backoff(100))
+}
+
+func TestRetryDelay(t *testing.T) {
+	require.Equal(t, webhook.RetryDelay(2), retryDelay(2, nil, asynq.NewTask(TaskDeliverWebhook, nil)))
+	require.Equal(t, 40*time.Second, retryDelay(2, nil, asynq.NewTask(TaskSendVerifyEmail, nil)))
+	require.Positive(t, retryDelay(2, nil, asynq.NewTask("task:unknown", nil)))
+}
+
+func TestTaskOptions(t *testing.T) {
+	opts := taskOptions(TaskSendVerifyEmail, asynq.MaxRetry(3))
+	require.Len(t, opts, 3)
+	require.Equal(t, asynq.QueueOpt, opts[0].Type())
+	require.Equal(t, EmailQueue, opts[0].Value())
+	// the option given by the caller comes last and wins
+	require.Equal(t, 3, opts[2].Value())
+
+	require.Empty(t, taskOptions("task:unknown"))
+}
+
+func TestRecordDeadLetter(t *testing.T) {
+	task := asynq.NewTask(TaskSendVerifyEmail, []byte(`{"username":"alice"}`))
+
+	testCases := []struct {
+		name       string
+		attempt    taskAttempt
+		err        error
+		buildStubs func(store *mockdb.MockStore)
+	}{
+		{
+			name:    "RetriesExhausted",
+			attempt: taskAttempt{TaskID: "task-1", Queue: EmailQueue, Retried: 10, MaxRetry: 10},
+			err:     errors.New("smtp down"),
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateDeadLetterTaskParams{
+					TaskID:    "task-1",
+					TaskType:  TaskSendVerifyEmail,
+					Queue:     EmailQueue,
+					Payload:   task.Payload(),
+					LastError: "smtp down",
+					Retried:   10,
+					MaxRetry:  10,
+				}
+				store.EXPECT().
+					CreateDeadLetterTask(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.DeadLetterTask{ID: 1}, nil)
+			},
+		},
+		{
+			name:    "SkipRetry",
+			attempt: taskAttempt{TaskID: "task-1", Queue: EmailQueue, Retried: 0, MaxRetry: 10},
+			err:     fmt.Errorf("bad payload: %w", asynq.SkipRetry),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateDeadLetterTask(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.DeadLetterTask{ID: 1}, nil)
+			},
+		},
+		{
+			name:    "WillBeRetried",
+			attempt: taskAttempt{TaskID: "task-1", Queue: EmailQueue, Retried: 2, MaxRetry: 10},
+			err:     errors.New("smtp down"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateDeadLetterTask(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			processor := &RedisTaskProcessor{store: store}
+			processor.recordDeadLetter(task, tc.attempt, tc.err)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// PeriodicTask is a task type enqueued on a cron schedule, e.g. "0 2 1 * *" for 02:00 UTC on the first of every
+// month. Its payload is a PayloadPeriodicTask.
+type PeriodicTask struct {
+	Type     string
+	Cronspec string
+}
+
+// PayloadPeriodicTask tells a periodic task which tick of its schedule it runs for.
+type PayloadPeriodicTask struct {
+	TaskMetadata
+	ScheduledAt time.Time `json:"scheduled_at"`
+}
+
+// TaskScheduler enqueues periodic tasks through a distributor. Every server instance runs one: the task id is
+// made of the task type and the tick, so the queue keeps a single task per tick however many instances enqueue it.
+type TaskScheduler struct {
+	cron        *cron.Cron
+	distributor TaskDistributor
+}
+
+// NewTaskScheduler creates a scheduler for tasks, whose cron specs are read in UTC.
+func NewTaskScheduler(distributor TaskDistributor, tasks []PeriodicTask) (*TaskScheduler, error) {
+	scheduler := &TaskScheduler{
+		cron:        cron.New(cron.WithLocation(time.UTC)),
+		distributor: distributor,
+	}
+	for _, task := range tasks {
+		taskType := task.Type
+		_, err := scheduler.cron.AddFunc(task.Cronspec, func() {
+			at := time.Now().UTC().Truncate(time.Minute)
+			if err := scheduler.enqueue(context.Background(), taskType, at); err != nil {
+				log.Error().Err(err).Str("type", taskType).Time("scheduled_at", at).Msg("failed to enqueue periodic task")
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q of %s: %w", task.Cronspec, task.Type, err)
+		}
+	}
+	return scheduler, nil
+}
+
+// Run enqueues tasks on schedule until the context is done.
+func (scheduler *TaskScheduler) Run(ctx context.Context) {
+	scheduler.cron.Start()
+	<-ctx.Done()
+	<-scheduler.cron.Stop().Done()
+}
+
+func (scheduler *TaskScheduler) enqueue(ctx context.Context, taskType string, at time.Time) error {
+	task, err := newOutboxTask(taskType, PayloadPeriodicTask{TaskMetadata: newTaskMetadata(ctx), ScheduledAt: at}, OutboxOptions{})
+	if err != nil {
+		return err
+	}
+	return scheduler.distributor.DistributeOutboxTask(ctx, db.Outbox{
+		TaskID:    fmt.Sprintf("%s:%d", taskType, at.Unix()),
+		TaskType:  task.TaskType,
+		Payload:   task.Payload,
+		Queue:     task.Queue,
+		MaxRetry:  task.MaxRetry,
+		ProcessAt: task.ProcessAt,
+	})
+}
@@ -0,0 +1,31 @@
+package worker
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTaskSchedulerTaskID(t *testing.T) {
+	distributor := &fakeDistributor{}
+	scheduler, err := NewTaskScheduler(distributor, []PeriodicTask{{Type: TaskScheduleMonthlyStatements, Cronspec: "0 2 1 * *"}})
+	require.NoError(t, err)
+
+	tick := time.Date(2023, 2, 1, 2, 0, 0, 0, time.UTC)
+	require.NoError(t, scheduler.enqueue(context.Background(), TaskScheduleMonthlyStatements, tick))
+	require.NoError(t, scheduler.enqueue(context.Background(), TaskScheduleMonthlyStatements, tick))
+	require.NoError(t, scheduler.enqueue(context.Background(), TaskScheduleMonthlyStatements, tick.AddDate(0, 1, 0)))
+
+	// instances enqueueing the same tick use the same task id, which the queue keeps once
+	require.Equal(t, []string{
+		"task:schedule_monthly_statements:1675216800",
+		"task:schedule_monthly_statements:1675216800",
+		"task:schedule_monthly_statements:1677636000",
+	}, distributor.published)
+}
+
+func TestTaskSchedulerInvalidSchedule(t *testing.T) {
+	_, err := NewTaskScheduler(&fakeDistributor{}, []PeriodicTask{{Type: TaskScheduleMonthlyStatements, Cronspec: "monthly"}})
+	require.ErrorContains(t, err, `invalid schedule "monthly" of task:schedule_monthly_statements`)
+}
@@ -0,0 +1,210 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hibiken/asynq"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/interest"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"time"
+)
+
+const (
+	// TaskScheduleInterestAccrual is the periodic task that enqueues the interest accrual of the day before it runs.
+	TaskScheduleInterestAccrual = "task:schedule_interest_accrual"
+	TaskAccrueInterest          = "task:accrue_interest"
+)
+
+// interestAccountsPage is how many accounts the schedule task reads at once.
+const interestAccountsPage = 500
+
+// interestAudit is who posts interest in the audit log.
+var interestAudit = db.AuditContext{Actor: "worker:interest", UserAgent: "worker"}
+
+func init() {
+	RegisterTask(TaskDefinition{
+		Type:    TaskScheduleInterestAccrual,
+		Queue:   DefaultQueue,
+		Handler: (*RedisTaskProcessor).ProcessTaskScheduleInterestAccrual,
+		Retry:   RetryPolicy{MaxRetry: 5, Delay: ExponentialBackoff(time.Minute, 30*time.Minute)},
+	})
+	RegisterTask(TaskDefinition{
+		Type:    TaskAccrueInterest,
+		Queue:   DefaultQueue,
+		Handler: (*RedisTaskProcessor).ProcessTaskAccrueInterest,
+		Retry:   RetryPolicy{MaxRetry: 10, Delay: ExponentialBackoff(30*time.Second, 30*time.Minute)},
+	})
+}
+
+type PayloadAccrueInterest struct {
+	TaskMetadata
+	AccountID int64     `json:"account_id"`
+	Day       time.Time `json:"day"`
+}
+
+// NewAccrueInterestOutboxTask builds the outbox row of an accrue interest task.
+func NewAccrueInterestOutboxTask(ctx context.Context, payload *PayloadAccrueInterest, opts OutboxOptions) (db.CreateOutboxTaskParams, error) {
+	tracedPayload := *payload
+	tracedPayload.TaskMetadata = newTaskMetadata(ctx)
+	return newOutboxTask(TaskAccrueInterest, tracedPayload, opts)
+}
+
+// ProcessTaskScheduleInterestAccrual enqueues the accrual of the day before the tick for every account of a product
+// earning interest that was opened by the end of that day.
+func (processor *RedisTaskProcessor) ProcessTaskScheduleInterestAccrual(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadPeriodicTask
+	err := json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+	day := interest.Day(payload.ScheduledAt).AddDate(0, 0, -1)
+
+	var afterID int64
+	scheduled := 0
+	for {
+		ids, err := processor.store.ListInterestBearingAccountIDs(ctx, db.ListInterestBearingAccountIDsParams{
+			CreatedBefore: day.AddDate(0, 0, 1),
+			AfterID:       afterID,
+			PageSize:      interestAccountsPage,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list accounts: %w", err)
+		}
+		for _, id := range ids {
+			outboxTask, err := NewAccrueInterestOutboxTask(ctx, &PayloadAccrueInterest{AccountID: id, Day: day}, OutboxOptions{})
+			if err != nil {
+				return err
+			}
+			if _, err := processor.store.CreateOutboxTask(ctx, outboxTask); err != nil {
+				return fmt.Errorf("failed to enqueue interest accrual of account %d: %w", id, err)
+			}
+			afterID = id
+			scheduled++
+		}
+		if len(ids) < interestAccountsPage {
+			break
+		}
+	}
+
+	logging.Ctx(ctx).
+		Info().
+		Str("day", day.Format("2006-01-02")).
+		Int("accounts", scheduled).
+		Msg("scheduled interest accrual")
+	return nil
+}
+
+// ProcessTaskAccrueInterest records the interest an account earned on a day, at most once, from its balance at the
+// end of that day. On the last day of a month it then posts the interest accrued and not posted yet.
+func (processor *RedisTaskProcessor) ProcessTaskAccrueInterest(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadAccrueInterest
+	err := json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+	day := interest.Day(payload.Day)
+
+	account, err := processor.store.GetAccount(ctx, payload.AccountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("account %d not found: %w", payload.AccountID, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+	product, err := processor.store.GetAccountProduct(ctx, account.Product)
+	if err != nil {
+		return fmt.Errorf("failed to get account product: %w", err)
+	}
+
+	if product.AnnualRateBps > 0 && !product.Internal {
+		if err := processor.accrueInterest(ctx, account, product, day); err != nil {
+			return err
+		}
+	}
+
+	if interest.IsLastDayOfMonth(day) {
+		return processor.postInterest(ctx, account, day.AddDate(0, 0, 1))
+	}
+	return nil
+}
+
+func (processor *RedisTaskProcessor) accrueInterest(ctx context.Context, account db.Account, product db.AccountProduct, day time.Time) error {
+	balances, err := processor.store.GetAccountStatementBalances(ctx, db.GetAccountStatementBalancesParams{
+		AccountID: account.ID,
+		FromTime:  day,
+		ToTime:    day.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get balance: %w", err)
+	}
+	balance := balances.Balance - balances.AfterPeriod
+
+	var pending int64
+	if product.Compounding == interest.CompoundingDaily {
+		pending, err = processor.store.GetPendingInterestMicros(ctx, db.GetPendingInterestMicrosParams{
+			AccountID:  account.ID,
+			BeforeDate: day,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get pending interest: %w", err)
+		}
+	}
+
+	accrual, err := processor.store.CreateInterestAccrual(ctx, db.CreateInterestAccrualParams{
+		AccountID:     account.ID,
+		AccrualDate:   day,
+		Balance:       balance,
+		AnnualRateBps: product.AnnualRateBps,
+		Compounding:   product.Compounding,
+		AmountMicros:  interest.Daily(balance, pending, product.AnnualRateBps, product.Compounding, day),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// accrued by an earlier attempt
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record interest accrual: %w", err)
+	}
+
+	logging.Ctx(ctx).
+		Info().
+		Int64("account_id", account.ID).
+		Str("day", day.Format("2006-01-02")).
+		Int64("balance", accrual.Balance).
+		Int64("amount_micros", accrual.AmountMicros).
+		Msg("accrued interest")
+	return nil
+}
+
+// postInterest posts the pending interest of the days before a day. A frozen account cannot receive it: the
+// interest stays pending and is posted with the first month end after the account is unfrozen.
+func (processor *RedisTaskProcessor) postInterest(ctx context.Context, account db.Account, before time.Time) error {
+	result, err := processor.store.PostInterestTx(ctx, db.PostInterestTxParams{
+		AccountID: account.ID,
+		Before:    before,
+		Audit:     interestAudit,
+	})
+	if errors.Is(err, db.ErrAccountFrozen) {
+		logging.Ctx(ctx).Warn().Int64("account_id", account.ID).Msg("interest not posted to frozen account")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to post interest: %w", err)
+	}
+	if result.Transfer == nil {
+		return nil
+	}
+
+	logging.Ctx(ctx).
+		Info().
+		Int64("account_id", account.ID).
+		Int64("transfer_id", result.Transfer.Transfer.ID).
+		Int64("amount", result.Transfer.Transfer.Amount).
+		Int("days", len(result.Accruals)).
+		Msg("posted interest")
+	return nil
+}
@@ -0,0 +1,210 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/interest"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestProcessTaskScheduleInterestAccrual(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	leapDay := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+	store.EXPECT().
+		ListInterestBearingAccountIDs(gomock.Any(), gomock.Eq(db.ListInterestBearingAccountIDsParams{
+			CreatedBefore: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			AfterID:       0,
+			PageSize:      interestAccountsPage,
+		})).
+		Times(1).
+		Return([]int64{3, 8}, nil)
+	var accounts []int64
+	store.EXPECT().
+		CreateOutboxTask(gomock.Any(), gomock.Any()).
+		Times(2).
+		DoAndReturn(func(ctx context.Context, arg db.CreateOutboxTaskParams) (db.Outbox, error) {
+			require.Equal(t, TaskAccrueInterest, arg.TaskType)
+			var payload PayloadAccrueInterest
+			require.NoError(t, json.Unmarshal(arg.Payload, &payload))
+			require.Equal(t, leapDay, payload.Day)
+			accounts = append(accounts, payload.AccountID)
+			return db.Outbox{}, nil
+		})
+
+	payload, err := json.Marshal(PayloadPeriodicTask{ScheduledAt: time.Date(2024, 3, 1, 0, 30, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	processor := &RedisTaskProcessor{store: store}
+	err = processor.ProcessTaskScheduleInterestAccrual(context.Background(), asynq.NewTask(TaskScheduleInterestAccrual, payload))
+	require.NoError(t, err)
+	require.Equal(t, []int64{3, 8}, accounts)
+}
+
+func TestProcessTaskAccrueInterest(t *testing.T) {
+	account := db.Account{ID: 7, Owner: "alice", Balance: 4000, Currency: "USD", Product: db.ProductSavings}
+	daily := db.AccountProduct{Code: db.ProductSavings, AnnualRateBps: 250, Compounding: interest.CompoundingDaily}
+	monthly := db.AccountProduct{Code: db.ProductSavings, AnnualRateBps: 1000, Compounding: interest.CompoundingMonthly}
+	checking := db.AccountProduct{Code: db.ProductChecking, Compounding: interest.CompoundingMonthly}
+	midMonth := time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC)
+	leapDay := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		day        time.Time
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "DailyCompounding",
+			day:  midMonth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountProduct(gomock.Any(), gomock.Eq(account.Product)).Times(1).Return(daily, nil)
+				store.EXPECT().
+					GetAccountStatementBalances(gomock.Any(), gomock.Eq(db.GetAccountStatementBalancesParams{
+						AccountID: account.ID,
+						FromTime:  midMonth,
+						ToTime:    midMonth.AddDate(0, 0, 1),
+					})).
+					Times(1).
+					Return(db.GetAccountStatementBalancesRow{Balance: 4000, AfterPeriod: 3000}, nil)
+				store.EXPECT().
+					GetPendingInterestMicros(gomock.Any(), gomock.Eq(db.GetPendingInterestMicrosParams{AccountID: account.ID, BeforeDate: midMonth})).
+					Times(1).
+					Return(int64(5*interest.MicrosPerUnit), nil)
+				// (1000 + 5) * 2.5% / 365
+				store.EXPECT().
+					CreateInterestAccrual(gomock.Any(), gomock.Eq(db.CreateInterestAccrualParams{
+						AccountID:     account.ID,
+						AccrualDate:   midMonth,
+						Balance:       1000,
+						AnnualRateBps: 250,
+						Compounding:   interest.CompoundingDaily,
+						AmountMicros:  68836,
+					})).
+					Times(1).
+					Return(db.InterestAccrual{AccountID: account.ID, Balance: 1000, AmountMicros: 68836}, nil)
+				store.EXPECT().PostInterestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "LeapDayPostsMonth",
+			day:  leapDay,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountProduct(gomock.Any(), gomock.Eq(account.Product)).Times(1).Return(monthly, nil)
+				store.EXPECT().
+					GetAccountStatementBalances(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.GetAccountStatementBalancesRow{Balance: 3660}, nil)
+				store.EXPECT().GetPendingInterestMicros(gomock.Any(), gomock.Any()).Times(0)
+				// 10% of 3660 over the 366 days of 2024
+				store.EXPECT().
+					CreateInterestAccrual(gomock.Any(), gomock.Eq(db.CreateInterestAccrualParams{
+						AccountID:     account.ID,
+						AccrualDate:   leapDay,
+						Balance:       3660,
+						AnnualRateBps: 1000,
+						Compounding:   interest.CompoundingMonthly,
+						AmountMicros:  interest.MicrosPerUnit,
+					})).
+					Times(1).
+					Return(db.InterestAccrual{}, nil)
+				store.EXPECT().
+					PostInterestTx(gomock.Any(), gomock.Eq(db.PostInterestTxParams{
+						AccountID: account.ID,
+						Before:    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+						Audit:     interestAudit,
+					})).
+					Times(1).
+					Return(db.PostInterestTxResult{
+						Accruals: make([]db.InterestAccrual, 29),
+						Transfer: &db.TransferTxResult{Transfer: db.Transfer{ID: 11, Amount: 29}},
+					}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "AlreadyAccrued",
+			day:  midMonth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountProduct(gomock.Any(), gomock.Eq(account.Product)).Times(1).Return(monthly, nil)
+				store.EXPECT().GetAccountStatementBalances(gomock.Any(), gomock.Any()).Times(1).Return(db.GetAccountStatementBalancesRow{Balance: 3650}, nil)
+				store.EXPECT().CreateInterestAccrual(gomock.Any(), gomock.Any()).Times(1).Return(db.InterestAccrual{}, sql.ErrNoRows)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "FrozenAtMonthEnd",
+			day:  leapDay,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountProduct(gomock.Any(), gomock.Eq(account.Product)).Times(1).Return(monthly, nil)
+				store.EXPECT().GetAccountStatementBalances(gomock.Any(), gomock.Any()).Times(1).Return(db.GetAccountStatementBalancesRow{Balance: 3660}, nil)
+				store.EXPECT().CreateInterestAccrual(gomock.Any(), gomock.Any()).Times(1).Return(db.InterestAccrual{}, nil)
+				store.EXPECT().PostInterestTx(gomock.Any(), gomock.Any()).Times(1).Return(db.PostInterestTxResult{}, db.ErrAccountFrozen)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "NoRateStillPostsPending",
+			day:  time.Date(2023, 4, 30, 0, 0, 0, 0, time.UTC),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountProduct(gomock.Any(), gomock.Eq(account.Product)).Times(1).Return(checking, nil)
+				store.EXPECT().GetAccountStatementBalances(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateInterestAccrual(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().PostInterestTx(gomock.Any(), gomock.Any()).Times(1).Return(db.PostInterestTxResult{}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			day:  midMonth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, asynq.SkipRetry)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			payload, err := json.Marshal(PayloadAccrueInterest{AccountID: account.ID, Day: tc.day})
+			require.NoError(t, err)
+			processor := &RedisTaskProcessor{store: store}
+			err = processor.ProcessTaskAccrueInterest(context.Background(), asynq.NewTask(TaskAccrueInterest, payload))
+			tc.checkError(t, err)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/hibiken/asynq"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"github.com/kwalter26/udemy-simplebank/webhook"
+)
+
+const (
+	TaskDeliverWebhook = db.WebhookDeliveryTaskType
+)
+
+func init() {
+	RegisterTask(TaskDefinition{
+		Type:    TaskDeliverWebhook,
+		Queue:   WebhookQueue,
+		Handler: (*RedisTaskProcessor).ProcessTaskDeliverWebhook,
+		Retry:   RetryPolicy{MaxRetry: db.WebhookDeliveryMaxRetry, Delay: webhook.RetryDelay},
+	})
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskDeliverWebhook(ctx context.Context, task *asynq.Task) error {
+	var payload db.WebhookDeliveryTaskPayload
+	err := json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	attempt := currentAttempt(ctx)
+	return processor.deliverWebhook(ctx, payload.DeliveryID, attempt.Retried >= attempt.MaxRetry)
+}
+
+// deliverWebhook posts a delivery and records the attempt. A failed last attempt leaves the delivery dead.
+func (processor *RedisTaskProcessor) deliverWebhook(ctx context.Context, deliveryID int64, lastAttempt bool) error {
+	delivery, err := processor.store.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("webhook delivery %d not found: %w", deliveryID, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	if delivery.Status == db.WebhookDeliverySucceeded {
+		// the task was delivered twice, the endpoint already has the event
+		return nil
+	}
+
+	hook, err := processor.store.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	code, sendErr := processor.webhooks.Send(ctx, webhook.Delivery{
+		ID:        delivery.ID,
+		URL:       hook.Url,
+		Secret:    hook.Secret,
+		EventType: delivery.EventType,
+		Body:      delivery.Payload,
+	})
+
+	arg := db.UpdateWebhookDeliveryAttemptParams{
+		ID:           delivery.ID,
+		Status:       db.WebhookDeliverySucceeded,
+		ResponseCode: int32(code),
+	}
+	if sendErr != nil {
+		arg.Status = db.WebhookDeliveryFailed
+		if lastAttempt {
+			arg.Status = db.WebhookDeliveryDead
+		}
+		arg.LastError = sendErr.Error()
+	}
+	if _, err = processor.store.UpdateWebhookDeliveryAttempt(ctx, arg); err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	if sendErr != nil {
+		return fmt.Errorf("failed to deliver webhook: %w", sendErr)
+	}
+
+	logging.Ctx(ctx).
+		Info().
+		Int64("delivery_id", delivery.ID).
+		Int64("webhook_id", hook.ID).
+		Int("status_code", code).
+		Msg("delivered webhook")
+	return nil
+}
@@ -0,0 +1,161 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/webhook"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDeliverWebhook(t *testing.T) {
+	secret, err := webhook.NewSecret()
+	require.NoError(t, err)
+	body := json.RawMessage(`{"event_id":3,"type":"balance.changed"}`)
+
+	testCases := []struct {
+		name        string
+		status      int
+		lastAttempt bool
+		delivery    db.WebhookDelivery
+		buildStubs  func(store *mockdb.MockStore, url string)
+		checkResult func(t *testing.T, received int, err error)
+	}{
+		{
+			name:     "OK",
+			status:   http.StatusOK,
+			delivery: db.WebhookDelivery{ID: 1, WebhookID: 2, EventType: db.DomainEventBalanceChanged, Payload: body},
+			buildStubs: func(store *mockdb.MockStore, url string) {
+				store.EXPECT().
+					GetWebhook(gomock.Any(), gomock.Eq(int64(2))).
+					Times(1).
+					Return(db.Webhook{ID: 2, Url: url, Secret: secret}, nil)
+				store.EXPECT().
+					UpdateWebhookDeliveryAttempt(gomock.Any(), gomock.Eq(db.UpdateWebhookDeliveryAttemptParams{
+						ID:           1,
+						Status:       db.WebhookDeliverySucceeded,
+						ResponseCode: http.StatusOK,
+					})).
+					Times(1)
+			},
+			checkResult: func(t *testing.T, received int, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 1, received)
+			},
+		},
+		{
+			name:     "EndpointFails",
+			status:   http.StatusInternalServerError,
+			delivery: db.WebhookDelivery{ID: 1, WebhookID: 2, EventType: db.DomainEventBalanceChanged, Payload: body},
+			buildStubs: func(store *mockdb.MockStore, url string) {
+				store.EXPECT().
+					GetWebhook(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Webhook{ID: 2, Url: url, Secret: secret}, nil)
+				store.EXPECT().
+					UpdateWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateWebhookDeliveryAttemptParams) (db.WebhookDelivery, error) {
+						require.Equal(t, db.WebhookDeliveryFailed, arg.Status)
+						require.Equal(t, int32(http.StatusInternalServerError), arg.ResponseCode)
+						require.NotEmpty(t, arg.LastError)
+						return db.WebhookDelivery{}, nil
+					})
+			},
+			checkResult: func(t *testing.T, received int, err error) {
+				require.Error(t, err)
+				require.NotErrorIs(t, err, asynq.SkipRetry)
+			},
+		},
+		{
+			name:        "LastAttemptIsDead",
+			status:      http.StatusInternalServerError,
+			lastAttempt: true,
+			delivery:    db.WebhookDelivery{ID: 1, WebhookID: 2, EventType: db.DomainEventBalanceChanged, Payload: body},
+			buildStubs: func(store *mockdb.MockStore, url string) {
+				store.EXPECT().
+					GetWebhook(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Webhook{ID: 2, Url: url, Secret: secret}, nil)
+				store.EXPECT().
+					UpdateWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.UpdateWebhookDeliveryAttemptParams) (db.WebhookDelivery, error) {
+						require.Equal(t, db.WebhookDeliveryDead, arg.Status)
+						return db.WebhookDelivery{}, nil
+					})
+			},
+			checkResult: func(t *testing.T, received int, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name:     "AlreadyDelivered",
+			status:   http.StatusOK,
+			delivery: db.WebhookDelivery{ID: 1, WebhookID: 2, Status: db.WebhookDeliverySucceeded},
+			buildStubs: func(store *mockdb.MockStore, url string) {
+				store.EXPECT().GetWebhook(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, received int, err error) {
+				require.NoError(t, err)
+				require.Zero(t, received)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			received := 0
+			endpoint := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				received++
+				receivedBody, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.JSONEq(t, string(body), string(receivedBody))
+
+				timestamp, err := strconv.ParseInt(req.Header.Get(webhook.TimestampHeader), 10, 64)
+				require.NoError(t, err)
+				require.True(t, webhook.Verify(secret, timestamp, receivedBody, req.Header.Get(webhook.SignatureHeader)))
+				res.WriteHeader(tc.status)
+			}))
+			defer endpoint.Close()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+
+			store.EXPECT().
+				GetWebhookDelivery(gomock.Any(), gomock.Eq(tc.delivery.ID)).
+				Times(1).
+				Return(tc.delivery, nil)
+			tc.buildStubs(store, endpoint.URL)
+
+			processor := &RedisTaskProcessor{store: store, webhooks: webhook.NewSender(time.Second, true)}
+			err := processor.deliverWebhook(context.Background(), tc.delivery.ID, tc.lastAttempt)
+			tc.checkResult(t, received, err)
+		})
+	}
+}
+
+func TestDeliverWebhookNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).Return(db.WebhookDelivery{}, sql.ErrNoRows)
+
+	processor := &RedisTaskProcessor{store: store, webhooks: webhook.NewSender(time.Second, true)}
+	err := processor.deliverWebhook(context.Background(), 1, false)
+	require.ErrorIs(t, err, asynq.SkipRetry)
+}
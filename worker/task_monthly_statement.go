@@ -0,0 +1,201 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hibiken/asynq"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"github.com/kwalter26/udemy-simplebank/statement"
+	"time"
+)
+
+const (
+	// TaskScheduleMonthlyStatements is the periodic task that enqueues the statements of the month before it runs.
+	TaskScheduleMonthlyStatements = "task:schedule_monthly_statements"
+	TaskGenerateMonthlyStatement  = "task:generate_monthly_statement"
+)
+
+// monthlyStatementAccountsPage is how many accounts the schedule task reads at once.
+const monthlyStatementAccountsPage = 500
+
+func init() {
+	RegisterTask(TaskDefinition{
+		Type:    TaskScheduleMonthlyStatements,
+		Queue:   DefaultQueue,
+		Handler: (*RedisTaskProcessor).ProcessTaskScheduleMonthlyStatements,
+		Retry:   RetryPolicy{MaxRetry: 5, Delay: ExponentialBackoff(time.Minute, 30*time.Minute)},
+	})
+	RegisterTask(TaskDefinition{
+		Type:    TaskGenerateMonthlyStatement,
+		Queue:   DefaultQueue,
+		Handler: (*RedisTaskProcessor).ProcessTaskGenerateMonthlyStatement,
+		Retry:   RetryPolicy{MaxRetry: 10, Delay: ExponentialBackoff(30*time.Second, 30*time.Minute)},
+	})
+}
+
+type PayloadGenerateMonthlyStatement struct {
+	TaskMetadata
+	AccountID   int64     `json:"account_id"`
+	PeriodStart time.Time `json:"period_start"`
+}
+
+// NewGenerateMonthlyStatementOutboxTask builds the outbox row of a generate monthly statement task.
+func NewGenerateMonthlyStatementOutboxTask(ctx context.Context, payload *PayloadGenerateMonthlyStatement, opts OutboxOptions) (db.CreateOutboxTaskParams, error) {
+	tracedPayload := *payload
+	tracedPayload.TaskMetadata = newTaskMetadata(ctx)
+	return newOutboxTask(TaskGenerateMonthlyStatement, tracedPayload, opts)
+}
+
+// MonthlyStatementBlobKey returns where the PDF statement of an account for a month is stored.
+func MonthlyStatementBlobKey(accountID int64, period statement.Period) string {
+	return fmt.Sprintf("statements/%d/%s.pdf", accountID, period.From.Format("2006-01"))
+}
+
+// ProcessTaskScheduleMonthlyStatements enqueues one statement task for every account opened before the end of the
+// previous month. Running it again for the same month is harmless: statements already sent are skipped.
+func (processor *RedisTaskProcessor) ProcessTaskScheduleMonthlyStatements(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadPeriodicTask
+	err := json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+	period := statement.PreviousMonth(payload.ScheduledAt)
+
+	var afterID int64
+	scheduled := 0
+	for {
+		ids, err := processor.store.ListAccountIDsCreatedBefore(ctx, db.ListAccountIDsCreatedBeforeParams{
+			CreatedBefore: period.To,
+			AfterID:       afterID,
+			PageSize:      monthlyStatementAccountsPage,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list accounts: %w", err)
+		}
+		for _, id := range ids {
+			outboxTask, err := NewGenerateMonthlyStatementOutboxTask(ctx, &PayloadGenerateMonthlyStatement{
+				AccountID:   id,
+				PeriodStart: period.From,
+			}, OutboxOptions{})
+			if err != nil {
+				return err
+			}
+			if _, err := processor.store.CreateOutboxTask(ctx, outboxTask); err != nil {
+				return fmt.Errorf("failed to enqueue statement of account %d: %w", id, err)
+			}
+			afterID = id
+			scheduled++
+		}
+		if len(ids) < monthlyStatementAccountsPage {
+			break
+		}
+	}
+
+	logging.Ctx(ctx).
+		Info().
+		Str("month", period.From.Format("2006-01")).
+		Int("accounts", scheduled).
+		Msg("scheduled monthly statements")
+	return nil
+}
+
+// ProcessTaskGenerateMonthlyStatement renders the PDF statement of an account for a month, stores it and emails
+// the owner. Each step is skipped when an earlier attempt already did it.
+func (processor *RedisTaskProcessor) ProcessTaskGenerateMonthlyStatement(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadGenerateMonthlyStatement
+	err := json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+	period := statement.Month(payload.PeriodStart)
+
+	account, err := processor.store.GetAccount(ctx, payload.AccountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("account %d not found: %w", payload.AccountID, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+
+	record, err := processor.store.GetAccountStatementByPeriod(ctx, db.GetAccountStatementByPeriodParams{
+		AccountID:   account.ID,
+		PeriodStart: period.From,
+	})
+	switch {
+	case err == nil && record.NotifiedAt.Valid:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		record, err = processor.storeMonthlyStatement(ctx, account, period)
+		if err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get account statement: %w", err)
+	}
+
+	user, err := processor.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	subject := fmt.Sprintf("Your Simple Bank statement for %s", period.From.Format("January 2006"))
+	content := fmt.Sprintf(`
+	Hello %s, <br/>
+The statement of your %s account %d for %s is ready. <br/>
+Closing balance: %d <br/>
+You can download it from /v1/accounts/%d/statements/%d. <br/>
+`, user.FullName, account.Currency, account.ID, period.From.Format("January 2006"), record.ClosingBalance, account.ID, record.ID)
+
+	err = processor.mailer.SendEmail(subject, content, []string{user.Email}, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := processor.store.MarkAccountStatementNotified(ctx, record.ID); err != nil {
+		return fmt.Errorf("failed to mark statement notified: %w", err)
+	}
+
+	logging.Ctx(ctx).
+		Info().
+		Bytes("payload", logging.RedactJSON(task.Payload())).
+		Int64("statement_id", record.ID).
+		Msg("processed task")
+	return nil
+}
+
+// storeMonthlyStatement renders the PDF into the blob store and records it.
+func (processor *RedisTaskProcessor) storeMonthlyStatement(ctx context.Context, account db.Account, period statement.Period) (db.AccountStatement, error) {
+	var pdf bytes.Buffer
+	writer, err := statement.NewWriter(statement.FormatPDF, &pdf)
+	if err != nil {
+		return db.AccountStatement{}, err
+	}
+	header, err := statement.Generate(ctx, processor.store, account, period, writer)
+	if err != nil {
+		return db.AccountStatement{}, fmt.Errorf("failed to generate statement: %w", err)
+	}
+
+	key := MonthlyStatementBlobKey(account.ID, period)
+	size, err := processor.blobs.Put(ctx, key, &pdf)
+	if err != nil {
+		return db.AccountStatement{}, fmt.Errorf("failed to store statement: %w", err)
+	}
+
+	record, err := processor.store.CreateAccountStatement(ctx, db.CreateAccountStatementParams{
+		AccountID:      account.ID,
+		PeriodStart:    period.From,
+		PeriodEnd:      period.To,
+		BlobKey:        key,
+		SizeBytes:      size,
+		OpeningBalance: header.OpeningBalance,
+		ClosingBalance: header.ClosingBalance,
+	})
+	if err != nil {
+		return record, fmt.Errorf("failed to record statement: %w", err)
+	}
+	return record, nil
+}
@@ -0,0 +1,158 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	"github.com/kwalter26/udemy-simplebank/blob"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestProcessTaskScheduleMonthlyStatements(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	page := make([]int64, monthlyStatementAccountsPage)
+	for i := range page {
+		page[i] = int64(i + 1)
+	}
+	gomock.InOrder(
+		store.EXPECT().
+			ListAccountIDsCreatedBefore(gomock.Any(), gomock.Eq(db.ListAccountIDsCreatedBeforeParams{
+				CreatedBefore: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+				AfterID:       0,
+				PageSize:      monthlyStatementAccountsPage,
+			})).
+			Times(1).
+			Return(page, nil),
+		store.EXPECT().
+			ListAccountIDsCreatedBefore(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(ctx context.Context, arg db.ListAccountIDsCreatedBeforeParams) ([]int64, error) {
+				require.Equal(t, int64(monthlyStatementAccountsPage), arg.AfterID)
+				return []int64{monthlyStatementAccountsPage + 1}, nil
+			}),
+	)
+	store.EXPECT().
+		CreateOutboxTask(gomock.Any(), gomock.Any()).
+		Times(monthlyStatementAccountsPage + 1).
+		DoAndReturn(func(ctx context.Context, arg db.CreateOutboxTaskParams) (db.Outbox, error) {
+			require.Equal(t, TaskGenerateMonthlyStatement, arg.TaskType)
+			var payload PayloadGenerateMonthlyStatement
+			require.NoError(t, json.Unmarshal(arg.Payload, &payload))
+			require.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), payload.PeriodStart)
+			return db.Outbox{}, nil
+		})
+
+	payload, err := json.Marshal(PayloadPeriodicTask{ScheduledAt: time.Date(2023, 2, 1, 2, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	processor := &RedisTaskProcessor{store: store}
+	err = processor.ProcessTaskScheduleMonthlyStatements(context.Background(), asynq.NewTask(TaskScheduleMonthlyStatements, payload))
+	require.NoError(t, err)
+}
+
+func TestProcessTaskGenerateMonthlyStatement(t *testing.T) {
+	account := db.Account{ID: 7, Owner: "alice", Balance: 100, Currency: "USD"}
+	user := db.User{Username: "alice", FullName: "Alice", Email: "alice@example.com"}
+	periodStart := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	byPeriod := db.GetAccountStatementByPeriodParams{AccountID: account.ID, PeriodStart: periodStart}
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		sent       int
+		stored     bool
+	}{
+		{
+			name: "New",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountStatementByPeriod(gomock.Any(), gomock.Eq(byPeriod)).Times(1).Return(db.AccountStatement{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetAccountStatementBalances(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.GetAccountStatementBalancesRow{Balance: 100, InPeriod: 40, LastEntryID: 1}, nil)
+				store.EXPECT().
+					ListStatementEntries(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.ListStatementEntriesRow{{ID: 1, Amount: 40, CreatedAt: periodStart.Add(time.Hour)}}, nil)
+				store.EXPECT().
+					CreateAccountStatement(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateAccountStatementParams) (db.AccountStatement, error) {
+						require.Equal(t, "statements/7/2023-01.pdf", arg.BlobKey)
+						require.Equal(t, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), arg.PeriodEnd)
+						require.Equal(t, int64(60), arg.OpeningBalance)
+						require.Equal(t, int64(100), arg.ClosingBalance)
+						require.Positive(t, arg.SizeBytes)
+						return db.AccountStatement{ID: 3, AccountID: arg.AccountID, ClosingBalance: arg.ClosingBalance}, nil
+					})
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(account.Owner)).Times(1).Return(user, nil)
+				store.EXPECT().MarkAccountStatementNotified(gomock.Any(), gomock.Eq(int64(3))).Times(1)
+			},
+			sent:   1,
+			stored: true,
+		},
+		{
+			name: "StoredNotNotified",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountStatementByPeriod(gomock.Any(), gomock.Eq(byPeriod)).Times(1).Return(db.AccountStatement{ID: 3}, nil)
+				store.EXPECT().CreateAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(account.Owner)).Times(1).Return(user, nil)
+				store.EXPECT().MarkAccountStatementNotified(gomock.Any(), gomock.Eq(int64(3))).Times(1)
+			},
+			sent: 1,
+		},
+		{
+			name: "AlreadyNotified",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountStatementByPeriod(gomock.Any(), gomock.Eq(byPeriod)).
+					Times(1).
+					Return(db.AccountStatement{ID: 3, NotifiedAt: sql.NullTime{Time: time.Now(), Valid: true}}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			tc.buildStubs(store)
+
+			blobs, err := blob.NewLocalStore(t.TempDir())
+			require.NoError(t, err)
+			mailer := newFakeMailer(0)
+			processor := &RedisTaskProcessor{store: store, mailer: mailer, blobs: blobs}
+
+			payload, err := json.Marshal(PayloadGenerateMonthlyStatement{AccountID: account.ID, PeriodStart: periodStart.Add(5 * time.Hour)})
+			require.NoError(t, err)
+			err = processor.ProcessTaskGenerateMonthlyStatement(context.Background(), asynq.NewTask(TaskGenerateMonthlyStatement, payload))
+			require.NoError(t, err)
+			require.Len(t, mailer.sent, tc.sent)
+
+			content, err := blobs.Get(context.Background(), "statements/7/2023-01.pdf")
+			if !tc.stored {
+				require.ErrorIs(t, err, blob.ErrNotFound)
+				return
+			}
+			require.NoError(t, err)
+			defer content.Close()
+			data, err := io.ReadAll(content)
+			require.NoError(t, err)
+			require.Equal(t, "%PDF-", string(data[:5]))
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hibiken/asynq"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/logging"
+	"github.com/kwalter26/udemy-simplebank/statement"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	TaskSendAccountStatement = "task:send_account_statement"
+)
+
+func init() {
+	RegisterTask(TaskDefinition{
+		Type:    TaskSendAccountStatement,
+		Queue:   EmailQueue,
+		Handler: (*RedisTaskProcessor).ProcessTaskSendAccountStatement,
+		Retry:   RetryPolicy{MaxRetry: 5, Delay: ExponentialBackoff(30*time.Second, 10*time.Minute)},
+	})
+}
+
+type PayloadSendAccountStatement struct {
+	TaskMetadata
+	AccountID int64     `json:"account_id"`
+	Format    string    `json:"format"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+}
+
+// ProcessTaskSendAccountStatement writes the statement to a temporary file and emails it to the account owner
+// as an attachment.
+func (processor *RedisTaskProcessor) ProcessTaskSendAccountStatement(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadSendAccountStatement
+	err := json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+	if !statement.IsSupportedFormat(payload.Format) {
+		return fmt.Errorf("unsupported statement format %q: %w", payload.Format, asynq.SkipRetry)
+	}
+
+	account, err := processor.store.GetAccount(ctx, payload.AccountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("account %d not found: %w", payload.AccountID, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+	user, err := processor.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "statement")
+	if err != nil {
+		return fmt.Errorf("failed to create statement directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	period := statement.Period{From: payload.From, To: payload.To}
+	path := filepath.Join(dir, statement.FileName(account.ID, period, payload.Format))
+	header, err := processor.writeStatementFile(ctx, path, account, period, payload.Format)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("Your Simple Bank statement for account %d", account.ID)
+	content := fmt.Sprintf(`
+	Hello %s, <br/>
+Please find attached the statement of your %s account %d from %s to %s. <br/>
+Opening balance: %d <br/>
+Closing balance: %d <br/>
+`, user.FullName, account.Currency, account.ID, period.From.UTC().Format(time.RFC3339), period.To.UTC().Format(time.RFC3339),
+		header.OpeningBalance, header.ClosingBalance)
+
+	err = processor.mailer.SendEmail(subject, content, []string{user.Email}, nil, nil, []string{path})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	logging.Ctx(ctx).
+		Info().
+		Bytes("payload", logging.RedactJSON(task.Payload())).
+		Str("username", user.Username).
+		Msg("processed task")
+	return nil
+}
+
+// writeStatementFile generates the statement into a new file at path.
+func (processor *RedisTaskProcessor) writeStatementFile(ctx context.Context, path string, account db.Account, period statement.Period, format string) (statement.Header, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return statement.Header{}, fmt.Errorf("failed to create statement file: %w", err)
+	}
+	defer file.Close()
+
+	writer, err := statement.NewWriter(format, file)
+	if err != nil {
+		return statement.Header{}, err
+	}
+	header, err := statement.Generate(ctx, processor.store, account, period, writer)
+	if err != nil {
+		return header, fmt.Errorf("failed to generate statement: %w", err)
+	}
+	return header, file.Close()
+}
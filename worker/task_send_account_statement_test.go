@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	mockdb "github.com/kwalter26/udemy-simplebank/db/mock"
+	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/statement"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// attachmentMailer records the emails it sends with the content of their attachments.
+type attachmentMailer struct {
+	to          []string
+	attachments map[string]string
+}
+
+func (mailer *attachmentMailer) SendEmail(subject string, content string, to []string, cc []string, bcc []string, attachFiles []string) error {
+	mailer.to = to
+	mailer.attachments = map[string]string{}
+	for _, file := range attachFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		mailer.attachments[filepath.Base(file)] = string(data)
+	}
+	return nil
+}
+
+func TestProcessTaskSendAccountStatement(t *testing.T) {
+	account := db.Account{ID: 7, Owner: "alice", Balance: 100, Currency: "USD"}
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(account.Owner)).Times(1).Return(db.User{Username: "alice", Email: "alice@example.com"}, nil)
+	store.EXPECT().
+		GetAccountStatementBalances(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.GetAccountStatementBalancesRow{Balance: 100, InPeriod: 25, LastEntryID: 1}, nil)
+	store.EXPECT().
+		ListStatementEntries(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ListStatementEntriesRow{{ID: 1, Amount: 25, CreatedAt: from.Add(time.Hour)}}, nil)
+
+	payload, err := json.Marshal(PayloadSendAccountStatement{AccountID: account.ID, Format: statement.FormatCSV, From: from, To: to})
+	require.NoError(t, err)
+
+	mailer := &attachmentMailer{}
+	processor := &RedisTaskProcessor{store: store, mailer: mailer}
+	err = processor.ProcessTaskSendAccountStatement(context.Background(), asynq.NewTask(TaskSendAccountStatement, payload))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"alice@example.com"}, mailer.to)
+	require.Contains(t, mailer.attachments, "statement-7-20230101-20230201.csv")
+	require.Contains(t, mailer.attachments["statement-7-20230101-20230201.csv"], "2023-01-01T01:00:00Z,Entry,25,100,USD,1,,\n")
+}
+
+func TestProcessTaskSendAccountStatementSkipsRetry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+	processor := &RedisTaskProcessor{store: store, mailer: &attachmentMailer{}}
+
+	payload, err := json.Marshal(PayloadSendAccountStatement{AccountID: 7, Format: statement.FormatOFX})
+	require.NoError(t, err)
+	err = processor.ProcessTaskSendAccountStatement(context.Background(), asynq.NewTask(TaskSendAccountStatement, payload))
+	require.ErrorIs(t, err, asynq.SkipRetry)
+
+	payload, err = json.Marshal(PayloadSendAccountStatement{AccountID: 7, Format: "xlsx"})
+	require.NoError(t, err)
+	err = processor.ProcessTaskSendAccountStatement(context.Background(), asynq.NewTask(TaskSendAccountStatement, payload))
+	require.ErrorIs(t, err, asynq.SkipRetry)
+}
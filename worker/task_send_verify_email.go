@@ -6,34 +6,51 @@ import (
 	"fmt"
 	"github.com/hibiken/asynq"
 	db "github.com/kwalter26/udemy-simplebank/db/sqlc"
+	"github.com/kwalter26/udemy-simplebank/logging"
 	"github.com/kwalter26/udemy-simplebank/util"
-	"github.com/rs/zerolog/log"
+	"time"
 )
 
 const (
 	TaskSendVerifyEmail = "task:send_verify_email"
 )
 
+func init() {
+	RegisterTask(TaskDefinition{
+		Type:    TaskSendVerifyEmail,
+		Queue:   EmailQueue,
+		Handler: (*RedisTaskProcessor).ProcessTaskSendVerifyEmail,
+		Retry:   RetryPolicy{MaxRetry: 10, Delay: ExponentialBackoff(10*time.Second, 10*time.Minute)},
+	})
+}
+
 type PayloadSendVerifyEmail struct {
+	TaskMetadata
 	Username string `json:"username"`
 }
 
 func (r RedisTaskDistributor) DistributeTaskSendVerifyEmail(ctx context.Context, payload *PayloadSendVerifyEmail, opts ...asynq.Option) error {
-	jsonPayload, err := json.Marshal(payload)
+	ctx, span := startEnqueueSpan(ctx, TaskSendVerifyEmail)
+	defer span.End()
+
+	tracedPayload := *payload
+	tracedPayload.TaskMetadata = newTaskMetadata(ctx)
+	jsonPayload, err := json.Marshal(tracedPayload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	task := asynq.NewTask(TaskSendVerifyEmail, jsonPayload, opts...)
+	task := asynq.NewTask(TaskSendVerifyEmail, jsonPayload, taskOptions(TaskSendVerifyEmail, opts...)...)
 
 	info, err := r.client.EnqueueContext(ctx, task)
 	if err != nil {
+		recordSpanError(span, err)
 		return fmt.Errorf("failed to enqueue task: %w", err)
 	}
-	log.
+	logging.Ctx(ctx).
 		Info().
 		Str("type", task.Type()).
-		Bytes("payload", task.Payload()).
+		Bytes("payload", logging.RedactJSON(task.Payload())).
 		Str("queue", info.Queue).
 		Int("max_retry", info.MaxRetry).
 		Msg("enqueued task")
@@ -77,10 +94,9 @@ Please <a href="%s">click here</a> to verify your email address. <br/>
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
-	log.
+	logging.Ctx(ctx).
 		Info().
-		Str("type", task.Type()).
-		Bytes("payload", task.Payload()).
+		Bytes("payload", logging.RedactJSON(task.Payload())).
 		Str("username", user.Username).
 		Msg("processed task")
 	return nil
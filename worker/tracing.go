@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"github.com/hibiken/asynq"
+	"github.com/kwalter26/udemy-simplebank/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func startEnqueueSpan(ctx context.Context, taskType string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "enqueue "+taskType,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("task.type", taskType)),
+	)
+}
+
+// tracingMiddleware continues the trace stored in the task payload by the distributor.
+func tracingMiddleware(handler asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		ctx = tracing.Extract(ctx, taskMetadata(task).TraceContext)
+
+		ctx, span := tracing.Tracer().Start(ctx, "process "+task.Type(),
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(attribute.String("task.type", task.Type())),
+		)
+		defer span.End()
+
+		err := handler.ProcessTask(ctx, task)
+		recordSpanError(span, err)
+		return err
+	})
+}
+
+func recordSpanError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
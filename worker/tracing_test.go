@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	}()
+
+	ctx, span := startEnqueueSpan(context.Background(), TaskSendVerifyEmail)
+	payload := PayloadSendVerifyEmail{TaskMetadata: newTaskMetadata(ctx), Username: "alice"}
+	span.End()
+
+	jsonPayload, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	handler := tracingMiddleware(asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		var received PayloadSendVerifyEmail
+		require.NoError(t, json.Unmarshal(task.Payload(), &received))
+		require.Equal(t, "alice", received.Username)
+		return nil
+	}))
+	require.NoError(t, handler.ProcessTask(context.Background(), asynq.NewTask(TaskSendVerifyEmail, jsonPayload)))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	require.Equal(t, "enqueue "+TaskSendVerifyEmail, spans[0].Name)
+	require.Equal(t, "process "+TaskSendVerifyEmail, spans[1].Name)
+	require.Equal(t, spans[0].SpanContext.TraceID(), spans[1].SpanContext.TraceID())
+	require.Equal(t, spans[0].SpanContext.SpanID(), spans[1].Parent.SpanID())
+}